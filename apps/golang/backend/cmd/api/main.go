@@ -6,6 +6,8 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/user/micro-dp/db"
@@ -17,6 +19,7 @@ import (
 	"github.com/user/micro-dp/internal/featureflag"
 	"github.com/user/micro-dp/internal/notification"
 	"github.com/user/micro-dp/internal/observability"
+	"github.com/user/micro-dp/internal/secret"
 	"github.com/user/micro-dp/queue"
 	"github.com/user/micro-dp/storage"
 	"github.com/user/micro-dp/usecase"
@@ -84,7 +87,10 @@ func main() {
 	moduleTypeSchemaRepo := db.NewModuleTypeSchemaRepo(sqlDB)
 	connectionRepo := db.NewConnectionRepo(sqlDB)
 	datasetRepo := db.NewDatasetRepo(sqlDB)
+	datasetVersionRepo := db.NewDatasetVersionRepo(sqlDB)
 	uploadRepo := db.NewUploadRepo(sqlDB)
+	uploadSessionRepo := db.NewUploadSessionRepo(sqlDB)
+	uploadFetchRepo := db.NewUploadFetchRepo(sqlDB)
 	adminAuditLogRepo := db.NewAdminAuditLogRepo(sqlDB)
 	billingSubscriptionRepo := db.NewBillingSubscriptionRepo(sqlDB)
 	stripeWebhookEventRepo := db.NewStripeWebhookEventRepo(sqlDB)
@@ -104,10 +110,27 @@ func main() {
 	dashboardRepo := db.NewDashboardRepo(sqlDB)
 	dashboardWidgetRepo := db.NewDashboardWidgetRepo(sqlDB)
 	chartRepo := db.NewChartRepo(sqlDB)
+	lineageRepo := db.NewLineageRepo(sqlDB)
 	templateRunRepo := db.NewTemplateRunRepo(sqlDB)
 
-	// Connector registry
+	// Connector registry (embedded + optional external definitions, reloaded on SIGHUP or file change)
+	connectorCfg := connector.LoadConfig()
+	connector.LogStartup(connectorCfg)
 	connectorRegistry := connector.Global()
+	connectorReload := make(chan os.Signal, 1)
+	signal.Notify(connectorReload, syscall.SIGHUP)
+	go connectorRegistry.Watch(context.Background(), connectorCfg.PollInterval, connectorReload)
+
+	// Secret encryption (master keys for credential tokens and x-secret connection fields)
+	secretCfg, err := secret.LoadConfig()
+	if err != nil {
+		log.Fatalf("secret config: %v", err)
+	}
+	secretKeyring, err := secret.NewKeyring(secretCfg)
+	if err != nil {
+		log.Fatalf("secret keyring: %v", err)
+	}
+	secret.LogStartup(secretKeyring)
 
 	// Bootstrap superadmins
 	bootstrapCfg := usecase.ParseBootstrapConfig(os.Getenv("BOOTSTRAP_SUPERADMINS"), os.Getenv("SUPERADMIN_EMAILS"))
@@ -137,25 +160,49 @@ func main() {
 		},
 	)
 	jobRunService := usecase.NewJobRunService(jobRunRepo, jobRepo, jobVersionRepo, jobModuleRepo, jobModuleEdgeRepo, moduleTypeRepo)
-	jobService := usecase.NewJobService(jobRepo, jobVersionRepo, jobModuleRepo, jobModuleEdgeRepo, moduleTypeSchemaRepo, txManager)
+	connectionService := usecase.NewConnectionService(connectionRepo, connectorRegistry, secretKeyring, secret.NewRefResolver(secretCfg.RefDir))
+	postgresCDCService := usecase.NewPostgresCDCService(jobRunRepo, jobVersionRepo, jobModuleRepo, connectionRepo, connectionService)
+	jobService := usecase.NewJobService(jobRepo, jobVersionRepo, jobModuleRepo, jobModuleEdgeRepo, moduleTypeSchemaRepo, txManager, postgresCDCService)
 	moduleTypeService := usecase.NewModuleTypeService(moduleTypeRepo, moduleTypeSchemaRepo)
-	connectionService := usecase.NewConnectionService(connectionRepo, connectorRegistry)
 	googleCredProvider := credential.NewGoogleProvider(credential.GoogleConfig{
 		ClientID:        os.Getenv("GOOGLE_OAUTH_CLIENT_ID"),
 		ClientSecret:    os.Getenv("GOOGLE_OAUTH_CLIENT_SECRET"),
 		RedirectURL:     os.Getenv("GOOGLE_OAUTH_CREDENTIAL_REDIRECT_URI"),
 		PostRedirectURI: os.Getenv("GOOGLE_OAUTH_CREDENTIAL_POST_REDIRECT_URI"),
 	})
+	credProviders := []credential.OAuthProvider{googleCredProvider}
+	genericCredCfgs, err := credential.LoadGenericConfigs()
+	if err != nil {
+		log.Fatalf("credential providers: %v", err)
+	}
+	for _, cfg := range genericCredCfgs {
+		credProviders = append(credProviders, credential.NewGenericProvider(cfg))
+		log.Printf("credential provider registered name=%s", cfg.Name)
+	}
 	credentialService := usecase.NewCredentialService(
 		credentialRepo,
-		[]credential.OAuthProvider{googleCredProvider},
+		credProviders,
 		jwtSecret,
+		secretKeyring,
 	)
 
 	// Register real connection testers and schema fetchers
+	healthCfg := usecase.LoadConnectionHealthConfig()
 	connectorRegistry.RegisterTester("source-google-sheets", testers.NewGoogleSheetsTester())
+	connectorRegistry.RegisterTester("source-postgres", testers.NewPostgresTester(healthCfg.SlotLagLimit))
 	connectorRegistry.RegisterFetcher("source-google-sheets", fetchers.NewGoogleSheetsFetcher())
-	datasetService := usecase.NewDatasetService(datasetRepo, minioClient)
+	datasetMaskingService := usecase.NewDatasetMaskingService(db.NewDatasetMaskingPolicyRepo(sqlDB), datasetRepo, tenantRepo, usecase.LoadPIIConfig())
+	dataCatalogService := usecase.NewDataCatalogService(
+		db.NewDatasetStewardRepo(sqlDB), db.NewDatasetCertificationRepo(sqlDB), db.NewGlossaryTermRepo(sqlDB),
+		db.NewDatasetUsageRepo(sqlDB), datasetRepo, tenantRepo, usecase.LoadCatalogConfig(),
+	)
+	datasetService := usecase.NewDatasetService(datasetRepo, db.NewDatasetSchemaChangeRepo(sqlDB), datasetVersionRepo, minioClient, datasetMaskingService, dataCatalogService)
+	retentionPolicyRepo := db.NewRetentionPolicyRepo(sqlDB)
+	datasetVersionService := usecase.NewDatasetVersionService(datasetRepo, datasetVersionRepo, retentionPolicyRepo, minioClient, usecase.LoadDatasetVersionConfig())
+	dataQualityService := usecase.NewDataQualityService(
+		db.NewDataQualityRuleRepo(sqlDB), db.NewDataQualityResultRepo(sqlDB),
+		datasetRepo, datasetVersionRepo, datasetVersionService, tenantRepo, datasetMaskingService, minioClient,
+	)
 	eventService := usecase.NewEventService(eventQueue)
 	eventMetrics := observability.NewEventMetrics()
 	planService := usecase.NewPlanService(planRepo, tenantPlanRepo, usageRepo)
@@ -181,7 +228,10 @@ func main() {
 	}
 	uploadQueue := queue.NewUploadQueue(valkeyClient)
 	transformQueue := queue.NewTransformQueue(valkeyClient)
-	uploadService := usecase.NewUploadService(uploadRepo, minioPresignClient, uploadQueue)
+	uploadService := usecase.NewUploadService(
+		uploadRepo, uploadSessionRepo, uploadFetchRepo, datasetRepo,
+		minioPresignClient, minioPresignClient, uploadQueue, minioClient, planService, secretKeyring,
+	)
 	jobRunModuleService := usecase.NewJobRunModuleService(jobRunModuleRepo)
 	jobRunArtifactService := usecase.NewJobRunArtifactService(jobRunArtifactRepo)
 	adminTenantService := usecase.NewAdminTenantService(tenantRepo, adminAuditLogRepo)
@@ -192,14 +242,27 @@ func main() {
 	}
 	memberService := usecase.NewMemberService(tenantRepo, userRepo, invitationRepo, emailSender, appBaseURL)
 	transformService := usecase.NewTransformService(
-		datasetRepo, minioClient, jobService, moduleTypeRepo,
-		jobRunRepo, jobVersionRepo, jobModuleRepo, transformQueue,
+		datasetRepo, datasetVersionRepo, minioClient, jobService, moduleTypeRepo,
+		jobRunRepo, jobVersionRepo, jobModuleRepo, transformQueue, datasetMaskingService, dataCatalogService,
 	)
 	writeKeyService := usecase.NewWriteKeyService(writeKeyRepo, tenantRepo)
 	aggregationBackfillService := usecase.NewAggregationBackfillService(aggregationQueue, tenantRepo)
 	importJobService := usecase.NewImportJobService(jobService, jobRunService, moduleTypeRepo, jobVersionRepo, jobModuleRepo, connectionRepo)
 	dashboardService := usecase.NewDashboardService(dashboardRepo, dashboardWidgetRepo, chartRepo)
-	chartService := usecase.NewChartService(chartRepo, datasetRepo, minioClient)
+	chartService := usecase.NewChartService(chartRepo, datasetRepo, lineageRepo, minioClient, datasetMaskingService, dataCatalogService)
+	datasetDeletionService := usecase.NewDatasetDeletionService(
+		datasetRepo, datasetVersionRepo, chartRepo, jobRepo, lineageRepo,
+		usecase.NewMeteringService(usageRepo), minioClient, usecase.LoadDatasetDeletionConfig(),
+	)
+	datasetExportService := usecase.NewDatasetExportService(
+		datasetRepo, datasetVersionRepo, db.NewDatasetExportRepo(sqlDB), queue.NewDatasetExportQueue(valkeyClient),
+		minioClient, minioPresignClient, datasetMaskingService, usecase.LoadDatasetExportConfig(),
+	)
+	retentionService := usecase.NewRetentionService(
+		retentionPolicyRepo, db.NewRetentionPurgeRepo(sqlDB), datasetRepo, datasetVersionRepo, tenantRepo,
+		planService, usecase.NewMeteringService(usageRepo), minioClient, usecase.LoadRetentionConfig(),
+	)
+	lineageService := usecase.NewLineageService(lineageRepo, datasetRepo, jobRepo, connectionRepo, chartRepo)
 	templateRunService := usecase.NewTemplateRunService(templateRunRepo)
 
 	// Handlers
@@ -208,10 +271,22 @@ func main() {
 	jobRunH := handler.NewJobRunHandler(jobRunService)
 	jobH := handler.NewJobHandler(jobService)
 	moduleTypeH := handler.NewModuleTypeHandler(moduleTypeService)
-	connectionH := handler.NewConnectionHandler(connectionService, credentialService, connectorRegistry)
+	connectionHealthService := usecase.NewConnectionHealthService(
+		db.NewConnectionHealthRepo(sqlDB), connectionRepo, tenantRepo,
+		connectionService, credentialService, connectorRegistry, emailSender, healthCfg,
+	)
+	connectionH := handler.NewConnectionHandler(connectionService, credentialService, connectionHealthService, connectorRegistry)
 	credentialH := handler.NewCredentialHandler(credentialService)
 	connectorH := handler.NewConnectorHandler(connectorRegistry)
-	datasetH := handler.NewDatasetHandler(datasetService)
+	datasetH := handler.NewDatasetHandler(datasetService, dataCatalogService)
+	dataCatalogH := handler.NewDataCatalogHandler(dataCatalogService)
+	datasetVersionH := handler.NewDatasetVersionHandler(datasetVersionService)
+	datasetDeletionH := handler.NewDatasetDeletionHandler(datasetDeletionService)
+	datasetExportH := handler.NewDatasetExportHandler(datasetExportService)
+	datasetMaskingH := handler.NewDatasetMaskingHandler(datasetMaskingService)
+	retentionH := handler.NewRetentionHandler(retentionService)
+	dataQualityH := handler.NewDataQualityHandler(dataQualityService)
+	lineageH := handler.NewLineageHandler(lineageService)
 	trackerTenantID := os.Getenv("TRACKER_TENANT_ID")
 	eventH := handler.NewEventHandler(eventService, planService, eventMetrics, trackerTenantID)
 	uploadH := handler.NewUploadHandler(uploadService, planService)
@@ -230,6 +305,7 @@ func main() {
 	chartH := handler.NewChartHandler(chartService)
 	templateRunH := handler.NewTemplateRunHandler(templateRunService)
 	adminAggregationH := handler.NewAdminAggregationHandler(aggregationBackfillService)
+	adminConnectorH := handler.NewAdminConnectorHandler(connectorRegistry)
 
 	// Middleware
 	authMW := handler.AuthMiddleware(jwtSecret)
@@ -305,6 +381,7 @@ func main() {
 
 	// Credentials
 	mux.Handle("GET /api/v1/credentials", protected(credentialH.List))
+	mux.Handle("GET /api/v1/credentials/providers", protected(credentialH.ListProviders))
 	mux.Handle("DELETE /api/v1/credentials/{id}", protected(credentialH.Delete))
 	mux.Handle("GET /api/v1/credentials/{provider}/start", protected(credentialH.OAuthStart))
 	mux.HandleFunc("GET /api/v1/credentials/{provider}/callback", credentialH.OAuthCallback)
@@ -315,18 +392,73 @@ func main() {
 	mux.Handle("GET /api/v1/connections/{id}", protected(connectionH.Get))
 	mux.Handle("PUT /api/v1/connections/{id}", protected(connectionH.Update))
 	mux.Handle("DELETE /api/v1/connections/{id}", protected(connectionH.Delete))
+	mux.Handle("GET /api/v1/connections/{id}/health", protected(connectionH.ListHealth))
 	mux.Handle("POST /api/v1/connections/test", protected(connectionH.Test))
 	mux.Handle("GET /api/v1/connections/{connection_id}/schemas", protected(connectionH.ListSchemas))
 
 	// Datasets
 	mux.Handle("GET /api/v1/datasets", protected(datasetH.List))
 	mux.Handle("GET /api/v1/datasets/{id}", protected(datasetH.Get))
+	mux.Handle("DELETE /api/v1/datasets/{id}", protected(datasetDeletionH.Delete))
+	mux.Handle("POST /api/v1/datasets/{id}/restore", protected(datasetDeletionH.Restore))
 	mux.Handle("GET /api/v1/datasets/{id}/rows", protected(datasetH.GetRows))
 	mux.Handle("PATCH /api/v1/datasets/{id}/columns", protected(datasetH.UpdateColumns))
+	mux.Handle("GET /api/v1/datasets/{id}/schema-changes", protected(datasetH.ListSchemaChanges))
+	mux.Handle("GET /api/v1/datasets/{id}/lineage", protected(lineageH.GetDatasetLineage))
+	mux.Handle("GET /api/v1/datasets/{id}/masking-policies", protected(datasetMaskingH.List))
+	mux.Handle("PUT /api/v1/datasets/{id}/masking-policies/{column}", protected(datasetMaskingH.Set))
+	mux.Handle("DELETE /api/v1/datasets/{id}/masking-policies/{column}", protected(datasetMaskingH.Delete))
+	mux.Handle("GET /api/v1/datasets/{id}/export", protected(datasetExportH.Export))
+	mux.Handle("POST /api/v1/datasets/{id}/exports", protected(datasetExportH.Create))
+	mux.Handle("GET /api/v1/datasets/{id}/exports/{export_id}", protected(datasetExportH.Get))
+	mux.Handle("GET /api/v1/datasets/{id}/versions", protected(datasetVersionH.List))
+	mux.Handle("GET /api/v1/datasets/{id}/versions/{version}", protected(datasetVersionH.Get))
+	mux.Handle("PATCH /api/v1/datasets/{id}/versions/{version}", protected(datasetVersionH.Update))
+	mux.Handle("POST /api/v1/datasets/{id}/versions/{version}/rollback", protected(datasetVersionH.Rollback))
+	mux.Handle("GET /api/v1/datasets/{id}/quality-rules", protected(dataQualityH.ListRules))
+	mux.Handle("POST /api/v1/datasets/{id}/quality-rules", protected(dataQualityH.CreateRule))
+	mux.Handle("PUT /api/v1/datasets/{id}/quality-rules/{rule_id}", protected(dataQualityH.UpdateRule))
+	mux.Handle("DELETE /api/v1/datasets/{id}/quality-rules/{rule_id}", protected(dataQualityH.DeleteRule))
+	mux.Handle("POST /api/v1/datasets/{id}/quality-checks", protected(dataQualityH.Check))
+	mux.Handle("GET /api/v1/datasets/{id}/quality-results", protected(dataQualityH.ListResults))
+	mux.Handle("GET /api/v1/datasets/{id}/health", protected(dataQualityH.Health))
+	mux.Handle("PUT /api/v1/datasets/{id}/stewards/{user_id}", protected(dataCatalogH.SetSteward))
+	mux.Handle("DELETE /api/v1/datasets/{id}/stewards/{user_id}", protected(dataCatalogH.DeleteSteward))
+	mux.Handle("PUT /api/v1/datasets/{id}/certification", protected(dataCatalogH.Certify))
+	mux.Handle("DELETE /api/v1/datasets/{id}/certification", protected(dataCatalogH.DeleteCertification))
+	mux.Handle("PUT /api/v1/datasets/{id}/columns/{column}/glossary-terms/{term_id}", protected(dataCatalogH.LinkTerm))
+	mux.Handle("DELETE /api/v1/datasets/{id}/columns/{column}/glossary-terms/{term_id}", protected(dataCatalogH.UnlinkTerm))
+
+	mux.Handle("GET /api/v1/datasets/{id}/retention-policy", protected(retentionH.GetDatasetPolicy))
+	mux.Handle("PUT /api/v1/datasets/{id}/retention-policy", protected(retentionH.SetDatasetPolicy))
+	mux.Handle("DELETE /api/v1/datasets/{id}/retention-policy", protected(retentionH.DeleteDatasetPolicy))
+
+	// Glossary
+	mux.Handle("GET /api/v1/glossary-terms", protected(dataCatalogH.ListTerms))
+	mux.Handle("POST /api/v1/glossary-terms", protected(dataCatalogH.CreateTerm))
+	mux.Handle("GET /api/v1/glossary-terms/{id}", protected(dataCatalogH.GetTerm))
+	mux.Handle("PUT /api/v1/glossary-terms/{id}", protected(dataCatalogH.UpdateTerm))
+	mux.Handle("DELETE /api/v1/glossary-terms/{id}", protected(dataCatalogH.DeleteTerm))
+
+	// Retention
+	mux.Handle("GET /api/v1/retention-policy", protected(retentionH.GetTenantPolicy))
+	mux.Handle("PUT /api/v1/retention-policy", protected(retentionH.SetTenantPolicy))
+	mux.Handle("DELETE /api/v1/retention-policy", protected(retentionH.DeleteTenantPolicy))
+	mux.Handle("GET /api/v1/retention-purges", protected(retentionH.ListPurges))
 
 	// Uploads
+	mux.Handle("GET /api/v1/uploads", protected(uploadH.List))
 	mux.Handle("POST /api/v1/uploads/presign", protected(uploadH.Presign))
+	mux.Handle("POST /api/v1/uploads/multipart", protected(uploadH.CreateMultipart))
+	mux.Handle("POST /api/v1/uploads/url", protected(uploadH.CreateFromURL))
+	mux.Handle("GET /api/v1/uploads/{id}", protected(uploadH.Get))
 	mux.Handle("POST /api/v1/uploads/{id}/complete", protected(uploadH.Complete))
+	mux.Handle("GET /api/v1/uploads/{id}/files/{file_id}/multipart", protected(uploadH.GetMultipart))
+	mux.Handle("DELETE /api/v1/uploads/{id}/files/{file_id}/multipart", protected(uploadH.AbortMultipart))
+	mux.Handle("POST /api/v1/uploads/{id}/files/{file_id}/multipart/parts", protected(uploadH.PresignParts))
+	mux.Handle("POST /api/v1/uploads/{id}/files/{file_id}/multipart/complete", protected(uploadH.CompleteMultipart))
+	mux.Handle("POST /api/v1/uploads/{id}/files/{file_id}/reimport", protected(uploadH.Reimport))
+	mux.Handle("POST /api/v1/uploads/{id}/files/{file_id}/preview", protected(uploadH.Preview))
 
 	// Transform
 	mux.Handle("POST /api/v1/transform/validate", protected(transformH.Validate))
@@ -399,6 +531,10 @@ func main() {
 	// Admin aggregation
 	mux.Handle("POST /api/v1/admin/aggregations/backfill", adminProtected(adminAggregationH.TriggerBackfill))
 
+	// Admin connector registry
+	mux.Handle("GET /api/v1/admin/connectors/status", adminProtected(adminConnectorH.Status))
+	mux.Handle("POST /api/v1/admin/connectors/reload", adminProtected(adminConnectorH.Reload))
+
 	// Admin plans
 	mux.Handle("POST /api/v1/admin/plans", adminProtected(adminPlanH.Create))
 	mux.Handle("GET /api/v1/admin/plans", adminProtected(adminPlanH.List))
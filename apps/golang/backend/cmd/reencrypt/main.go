@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/internal/connector"
+	"github.com/user/micro-dp/internal/secret"
+	"github.com/user/micro-dp/usecase"
+)
+
+// reencrypt seals secrets that are still stored in plain text and re-wraps
+// every data key with the active (first) master key in SECRETS_MASTER_KEYS.
+// Run it after adding a new master key in front of the old one; once it
+// completes, the old key can be removed from the configuration.
+func main() {
+	flag.Parse()
+
+	secretCfg, err := secret.LoadConfig()
+	if err != nil {
+		log.Fatalf("secret config: %v", err)
+	}
+	keyring, err := secret.NewKeyring(secretCfg)
+	if err != nil {
+		log.Fatalf("secret keyring: %v", err)
+	}
+	if !keyring.Enabled() {
+		log.Fatal("SECRETS_MASTER_KEYS (or SECRETS_MASTER_KEYS_FILE) is required")
+	}
+
+	sqlDB, err := db.Open()
+	if err != nil {
+		log.Fatalf("db open: %v", err)
+	}
+	defer sqlDB.Close()
+
+	if err := db.Migrate(sqlDB); err != nil {
+		log.Fatalf("db migrate: %v", err)
+	}
+
+	registry := connector.Global()
+	credentialService := usecase.NewCredentialService(db.NewCredentialRepo(sqlDB), nil, "", keyring)
+	connectionService := usecase.NewConnectionService(db.NewConnectionRepo(sqlDB), registry, keyring, secret.NewRefResolver(secretCfg.RefDir))
+
+	ctx := context.Background()
+	credentials, err := credentialService.Reencrypt(ctx)
+	if err != nil {
+		log.Fatalf("reencrypt credentials: %v", err)
+	}
+	connections, err := connectionService.Reencrypt(ctx)
+	if err != nil {
+		log.Fatalf("reencrypt connections: %v", err)
+	}
+	fmt.Printf("reencrypted with key %s: credentials=%d connections=%d\n", keyring.ActiveKeyID(), credentials, connections)
+}
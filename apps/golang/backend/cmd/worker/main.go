@@ -13,9 +13,13 @@ import (
 	"github.com/user/micro-dp/handler"
 	"github.com/user/micro-dp/internal/connector"
 	"github.com/user/micro-dp/internal/connector/executors"
+	"github.com/user/micro-dp/internal/connector/testers"
 	"github.com/user/micro-dp/internal/credential"
 	"github.com/user/micro-dp/internal/featureflag"
+	"github.com/user/micro-dp/internal/notification"
 	"github.com/user/micro-dp/internal/observability"
+	"github.com/user/micro-dp/internal/secret"
+	"github.com/user/micro-dp/internal/urlfetch"
 	"github.com/user/micro-dp/queue"
 	"github.com/user/micro-dp/storage"
 	"github.com/user/micro-dp/usecase"
@@ -71,17 +75,40 @@ func main() {
 
 	// Event consumer
 	eventMetrics := observability.NewEventMetrics()
-	parquetWriter := worker.NewParquetWriter(minioClient)
+	datasetRepo := db.NewDatasetRepo(sqlDB)
+	parquetWriter := worker.NewParquetWriter(minioClient, datasetRepo)
 	consumer := worker.NewEventConsumer(eventQueue, parquetWriter, eventMetrics, meteringService, aggregationQueue)
 
 	go consumer.Run(ctx)
 
-	// Upload consumer (CSV→Parquet)
-	datasetRepo := db.NewDatasetRepo(sqlDB)
+	// Secrets (connection and credential secrets, URL fetch credentials)
+	secretCfg, err := secret.LoadConfig()
+	if err != nil {
+		log.Fatalf("secret config: %v", err)
+	}
+	secretKeyring, err := secret.NewKeyring(secretCfg)
+	if err != nil {
+		log.Fatalf("secret keyring: %v", err)
+	}
+	secret.LogStartup(secretKeyring)
+
+	// Upload consumer (URL fetch, CSV/TSV/JSON/Parquet/Excel/gzip/zip→Parquet)
+	datasetVersionRepo := db.NewDatasetVersionRepo(sqlDB)
+	lineageRepo := db.NewLineageRepo(sqlDB)
+	versionCfg := usecase.LoadDatasetVersionConfig()
+	retentionPolicyRepo := db.NewRetentionPolicyRepo(sqlDB)
+	datasetVersionService := usecase.NewDatasetVersionService(datasetRepo, datasetVersionRepo, retentionPolicyRepo, minioClient, versionCfg)
+	datasetMaskingService := usecase.NewDatasetMaskingService(db.NewDatasetMaskingPolicyRepo(sqlDB), datasetRepo, db.NewTenantRepo(sqlDB), usecase.LoadPIIConfig())
+	dataQualityService := usecase.NewDataQualityService(
+		db.NewDataQualityRuleRepo(sqlDB), db.NewDataQualityResultRepo(sqlDB),
+		datasetRepo, datasetVersionRepo, datasetVersionService, db.NewTenantRepo(sqlDB), datasetMaskingService, minioClient,
+	)
+	uploadRepo := db.NewUploadRepo(sqlDB)
 	uploadQueue := queue.NewUploadQueue(valkeyClient)
 	uploadMetrics := observability.NewUploadMetrics()
-	csvImportWriter := worker.NewCSVImportWriter(minioClient, datasetRepo)
-	uploadConsumer := worker.NewUploadConsumer(uploadQueue, csvImportWriter, uploadMetrics, meteringService)
+	uploadFetcher := worker.NewUploadFetcher(uploadRepo, db.NewUploadFetchRepo(sqlDB), minioClient, secretKeyring, urlfetch.New(urlfetch.LoadConfig()))
+	uploadImportWriter := worker.NewUploadImportWriter(minioClient, datasetRepo, datasetVersionRepo, dataQualityService)
+	uploadConsumer := worker.NewUploadConsumer(uploadQueue, uploadRepo, uploadFetcher, uploadImportWriter, uploadMetrics, meteringService)
 
 	go uploadConsumer.Run(ctx)
 
@@ -89,13 +116,55 @@ func main() {
 	jobRunRepo := db.NewJobRunRepo(sqlDB)
 	transformQueue := queue.NewTransformQueue(valkeyClient)
 	transformMetrics := observability.NewTransformMetrics()
-	transformWriter := worker.NewTransformWriter(minioClient, datasetRepo)
+	transformWriter := worker.NewTransformWriter(minioClient, datasetRepo, datasetVersionRepo, lineageRepo, dataQualityService, datasetMaskingService)
 	transformConsumer := worker.NewTransformConsumer(
 		transformQueue, transformWriter, transformMetrics, meteringService, jobRunRepo,
 	)
 
 	go transformConsumer.Run(ctx)
 
+	// Dataset version collector (retention of old dataset versions)
+	datasetVersionCollector := worker.NewDatasetVersionCollector(datasetVersionService, versionCfg.GCInterval)
+
+	go datasetVersionCollector.Run(ctx)
+
+	// Dataset purger (storage of soft-deleted datasets past their restore
+	// window, catalog usage events past the usage window)
+	deletionCfg := usecase.LoadDatasetDeletionConfig()
+	datasetDeletionService := usecase.NewDatasetDeletionService(
+		datasetRepo, datasetVersionRepo, db.NewChartRepo(sqlDB), db.NewJobRepo(sqlDB), lineageRepo,
+		meteringService, minioClient, deletionCfg,
+	)
+	dataCatalogService := usecase.NewDataCatalogService(
+		db.NewDatasetStewardRepo(sqlDB), db.NewDatasetCertificationRepo(sqlDB), db.NewGlossaryTermRepo(sqlDB),
+		db.NewDatasetUsageRepo(sqlDB), datasetRepo, db.NewTenantRepo(sqlDB), usecase.LoadCatalogConfig(),
+	)
+	datasetPurger := worker.NewDatasetPurger(datasetDeletionService, dataCatalogService, deletionCfg.PurgeInterval)
+
+	go datasetPurger.Run(ctx)
+
+	// Retention sweeper (raw partitions and dataset versions past retention
+	// policies and plan maximums)
+	retentionCfg := usecase.LoadRetentionConfig()
+	retentionService := usecase.NewRetentionService(
+		retentionPolicyRepo, db.NewRetentionPurgeRepo(sqlDB), datasetRepo, datasetVersionRepo, db.NewTenantRepo(sqlDB),
+		usecase.NewPlanService(db.NewPlanRepo(sqlDB), db.NewTenantPlanRepo(sqlDB), usageRepo),
+		meteringService, minioClient, retentionCfg,
+	)
+	retentionSweeper := worker.NewRetentionSweeper(retentionService, retentionCfg.SweepInterval)
+
+	go retentionSweeper.Run(ctx)
+
+	// Dataset export consumer (async exports to object storage)
+	datasetExportQueue := queue.NewDatasetExportQueue(valkeyClient)
+	datasetExportService := usecase.NewDatasetExportService(
+		datasetRepo, datasetVersionRepo, db.NewDatasetExportRepo(sqlDB), datasetExportQueue,
+		minioClient, nil, nil, usecase.LoadDatasetExportConfig(),
+	)
+	datasetExportConsumer := worker.NewDatasetExportConsumer(datasetExportQueue, datasetExportService)
+
+	go datasetExportConsumer.Run(ctx)
+
 	// Credential + Connection (for import jobs)
 	credentialRepo := db.NewCredentialRepo(sqlDB)
 	connectionRepo := db.NewConnectionRepo(sqlDB)
@@ -103,17 +172,43 @@ func main() {
 		ClientID:     os.Getenv("GOOGLE_OAUTH_CLIENT_ID"),
 		ClientSecret: os.Getenv("GOOGLE_OAUTH_CLIENT_SECRET"),
 	})
+	credProviders := []credential.OAuthProvider{googleCredProvider}
+	genericCredCfgs, err := credential.LoadGenericConfigs()
+	if err != nil {
+		log.Fatalf("credential providers: %v", err)
+	}
+	for _, cfg := range genericCredCfgs {
+		credProviders = append(credProviders, credential.NewGenericProvider(cfg))
+	}
 	credentialService := usecase.NewCredentialService(
 		credentialRepo,
-		[]credential.OAuthProvider{googleCredProvider},
+		credProviders,
 		os.Getenv("JWT_SECRET"),
+		secretKeyring,
 	)
 
 	// Connector registry with import executors
 	connectorRegistry := connector.Global()
-	sheetsImportWriter := worker.NewSheetsImportWriter(minioClient, datasetRepo)
+	sheetsImportWriter := worker.NewSheetsImportWriter(minioClient, datasetRepo, db.NewDatasetSchemaChangeRepo(sqlDB), datasetVersionRepo, dataQualityService)
 	connectorRegistry.RegisterExecutor("source-google-sheets",
 		executors.NewGoogleSheetsExecutor(sheetsImportWriter))
+	connectorRegistry.RegisterTester("source-google-sheets", testers.NewGoogleSheetsTester())
+	postgresCDCWriter := worker.NewPostgresCDCWriter(minioClient, datasetRepo, db.NewDatasetSchemaChangeRepo(sqlDB), datasetVersionRepo, dataQualityService)
+	connectorRegistry.RegisterExecutor("source-postgres", executors.NewPostgresExecutor(postgresCDCWriter))
+	healthCfg := usecase.LoadConnectionHealthConfig()
+	connectorRegistry.RegisterTester("source-postgres", testers.NewPostgresTester(healthCfg.SlotLagLimit))
+
+	// Connection health monitor (periodic connectivity tests + failure notifications)
+	notifCfg := notification.LoadConfig()
+	emailSender := notification.NewEmailSender(notifCfg)
+	connectionService := usecase.NewConnectionService(connectionRepo, connectorRegistry, secretKeyring, secret.NewRefResolver(secretCfg.RefDir))
+	connectionHealthService := usecase.NewConnectionHealthService(
+		db.NewConnectionHealthRepo(sqlDB), connectionRepo, db.NewTenantRepo(sqlDB),
+		connectionService, credentialService, connectorRegistry, emailSender, healthCfg,
+	)
+	connectionHealthMonitor := worker.NewConnectionHealthMonitor(connectionHealthService, healthCfg.Interval)
+
+	go connectionHealthMonitor.Run(ctx)
 
 	// Job Run poller + consumer (generic job execution)
 	jobRunQueue := queue.NewJobRunQueue(valkeyClient)
@@ -121,15 +216,15 @@ func main() {
 	jobRunPoller := worker.NewJobRunPoller(jobRunRepo, jobRunQueue, jobRunMetrics, 5*time.Second)
 	jobRunConsumer := worker.NewJobRunConsumer(
 		jobRunQueue, jobRunRepo, transformWriter,
-		connectorRegistry, credentialService, connectionRepo,
-		jobRunMetrics, meteringService,
+		connectorRegistry, credentialService, connectionRepo, connectionService,
+		datasetRepo, lineageRepo, jobRunMetrics, meteringService,
 	)
 
 	go jobRunPoller.Run(ctx)
 	go jobRunConsumer.Run(ctx)
 
 	// Aggregation consumer (raw → events/visits)
-	aggregationWriter := worker.NewAggregationWriter(minioClient)
+	aggregationWriter := worker.NewAggregationWriter(minioClient, datasetRepo)
 	aggregationMetrics := observability.NewAggregationMetrics()
 	aggregationConsumer := worker.NewAggregationConsumer(aggregationQueue, aggregationWriter, aggregationMetrics)
 
@@ -62,6 +62,28 @@ func (r *ChartRepo) ListByTenant(ctx context.Context, tenantID string) ([]domain
 	return charts, rows.Err()
 }
 
+func (r *ChartRepo) ListByDataset(ctx context.Context, tenantID, datasetID string) ([]domain.Chart, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, tenant_id, name, chart_type, dataset_id, measure, dimension, config_json, created_at, updated_at
+		 FROM charts WHERE tenant_id = ? AND dataset_id = ? ORDER BY name`,
+		tenantID, datasetID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var charts []domain.Chart
+	for rows.Next() {
+		var c domain.Chart
+		if err := rows.Scan(&c.ID, &c.TenantID, &c.Name, &c.ChartType, &c.DatasetID, &c.Measure, &c.Dimension, &c.ConfigJSON, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		charts = append(charts, c)
+	}
+	return charts, rows.Err()
+}
+
 func (r *ChartRepo) Update(ctx context.Context, c *domain.Chart) error {
 	_, err := r.db.ExecContext(ctx,
 		`UPDATE charts SET name = ?, chart_type = ?, dataset_id = ?, measure = ?, dimension = ?, config_json = ?, updated_at = datetime('now')
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/user/micro-dp/domain"
+)
+
+type ConnectionHealthRepo struct {
+	db DBTX
+}
+
+func NewConnectionHealthRepo(db DBTX) *ConnectionHealthRepo {
+	return &ConnectionHealthRepo{db: db}
+}
+
+func scanConnectionHealthCheck(s interface{ Scan(...any) error }) (*domain.ConnectionHealthCheck, error) {
+	var c domain.ConnectionHealthCheck
+	if err := s.Scan(&c.ID, &c.TenantID, &c.ConnectionID, &c.Status, &c.Code, &c.Message, &c.LatencyMs, &c.CheckedAt); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *ConnectionHealthRepo) Create(ctx context.Context, c *domain.ConnectionHealthCheck) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO connection_health_checks (id, tenant_id, connection_id, status, code, message, latency_ms, checked_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
+		c.ID, c.TenantID, c.ConnectionID, c.Status, c.Code, c.Message, c.LatencyMs,
+	)
+	return err
+}
+
+func (r *ConnectionHealthRepo) FindLatest(ctx context.Context, tenantID, connectionID string) (*domain.ConnectionHealthCheck, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT id, tenant_id, connection_id, status, code, message, latency_ms, checked_at
+		 FROM connection_health_checks WHERE tenant_id = ? AND connection_id = ?
+		 ORDER BY checked_at DESC, rowid DESC LIMIT 1`, tenantID, connectionID,
+	)
+	c, err := scanConnectionHealthCheck(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *ConnectionHealthRepo) ListByConnection(ctx context.Context, tenantID, connectionID string, limit int) ([]domain.ConnectionHealthCheck, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, tenant_id, connection_id, status, code, message, latency_ms, checked_at
+		 FROM connection_health_checks WHERE tenant_id = ? AND connection_id = ?
+		 ORDER BY checked_at DESC, rowid DESC LIMIT ?`, tenantID, connectionID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []domain.ConnectionHealthCheck
+	for rows.Next() {
+		c, err := scanConnectionHealthCheck(rows)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, *c)
+	}
+	return checks, rows.Err()
+}
+
+func (r *ConnectionHealthRepo) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM connection_health_checks WHERE checked_at < ?`,
+		before.UTC().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -18,9 +18,9 @@ func NewCredentialRepo(db DBTX) *CredentialRepo {
 
 func (r *CredentialRepo) Create(ctx context.Context, c *domain.Credential) error {
 	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO credentials (id, user_id, tenant_id, provider, provider_label, access_token, refresh_token, token_expiry, scopes, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
-		c.ID, c.UserID, c.TenantID, c.Provider, c.ProviderLabel, c.AccessToken, c.RefreshToken, c.TokenExpiry, c.Scopes,
+		`INSERT INTO credentials (id, user_id, tenant_id, provider, provider_label, access_token, refresh_token, token_expiry, scopes, status, data_key, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
+		c.ID, c.UserID, c.TenantID, c.Provider, c.ProviderLabel, c.AccessToken, c.RefreshToken, c.TokenExpiry, c.Scopes, c.Status, c.DataKey,
 	)
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
@@ -33,11 +33,11 @@ func (r *CredentialRepo) Create(ctx context.Context, c *domain.Credential) error
 
 func (r *CredentialRepo) FindByID(ctx context.Context, tenantID, id string) (*domain.Credential, error) {
 	row := r.db.QueryRowContext(ctx,
-		`SELECT id, user_id, tenant_id, provider, provider_label, access_token, refresh_token, token_expiry, scopes, created_at, updated_at
+		`SELECT id, user_id, tenant_id, provider, provider_label, access_token, refresh_token, token_expiry, scopes, status, data_key, created_at, updated_at
 		 FROM credentials WHERE tenant_id = ? AND id = ?`, tenantID, id,
 	)
 	var c domain.Credential
-	if err := row.Scan(&c.ID, &c.UserID, &c.TenantID, &c.Provider, &c.ProviderLabel, &c.AccessToken, &c.RefreshToken, &c.TokenExpiry, &c.Scopes, &c.CreatedAt, &c.UpdatedAt); err != nil {
+	if err := row.Scan(&c.ID, &c.UserID, &c.TenantID, &c.Provider, &c.ProviderLabel, &c.AccessToken, &c.RefreshToken, &c.TokenExpiry, &c.Scopes, &c.Status, &c.DataKey, &c.CreatedAt, &c.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, domain.ErrCredentialNotFound
 		}
@@ -48,11 +48,11 @@ func (r *CredentialRepo) FindByID(ctx context.Context, tenantID, id string) (*do
 
 func (r *CredentialRepo) FindByUserAndProvider(ctx context.Context, userID, tenantID, provider string) (*domain.Credential, error) {
 	row := r.db.QueryRowContext(ctx,
-		`SELECT id, user_id, tenant_id, provider, provider_label, access_token, refresh_token, token_expiry, scopes, created_at, updated_at
+		`SELECT id, user_id, tenant_id, provider, provider_label, access_token, refresh_token, token_expiry, scopes, status, data_key, created_at, updated_at
 		 FROM credentials WHERE user_id = ? AND tenant_id = ? AND provider = ?`, userID, tenantID, provider,
 	)
 	var c domain.Credential
-	if err := row.Scan(&c.ID, &c.UserID, &c.TenantID, &c.Provider, &c.ProviderLabel, &c.AccessToken, &c.RefreshToken, &c.TokenExpiry, &c.Scopes, &c.CreatedAt, &c.UpdatedAt); err != nil {
+	if err := row.Scan(&c.ID, &c.UserID, &c.TenantID, &c.Provider, &c.ProviderLabel, &c.AccessToken, &c.RefreshToken, &c.TokenExpiry, &c.Scopes, &c.Status, &c.DataKey, &c.CreatedAt, &c.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, domain.ErrCredentialNotFound
 		}
@@ -63,7 +63,7 @@ func (r *CredentialRepo) FindByUserAndProvider(ctx context.Context, userID, tena
 
 func (r *CredentialRepo) ListByTenant(ctx context.Context, tenantID string) ([]domain.Credential, error) {
 	rows, err := r.db.QueryContext(ctx,
-		`SELECT id, user_id, tenant_id, provider, provider_label, access_token, refresh_token, token_expiry, scopes, created_at, updated_at
+		`SELECT id, user_id, tenant_id, provider, provider_label, access_token, refresh_token, token_expiry, scopes, status, data_key, created_at, updated_at
 		 FROM credentials WHERE tenant_id = ?
 		 ORDER BY created_at DESC`, tenantID,
 	)
@@ -75,7 +75,28 @@ func (r *CredentialRepo) ListByTenant(ctx context.Context, tenantID string) ([]d
 	var credentials []domain.Credential
 	for rows.Next() {
 		var c domain.Credential
-		if err := rows.Scan(&c.ID, &c.UserID, &c.TenantID, &c.Provider, &c.ProviderLabel, &c.AccessToken, &c.RefreshToken, &c.TokenExpiry, &c.Scopes, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.UserID, &c.TenantID, &c.Provider, &c.ProviderLabel, &c.AccessToken, &c.RefreshToken, &c.TokenExpiry, &c.Scopes, &c.Status, &c.DataKey, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, c)
+	}
+	return credentials, rows.Err()
+}
+
+func (r *CredentialRepo) ListAll(ctx context.Context) ([]domain.Credential, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, tenant_id, provider, provider_label, access_token, refresh_token, token_expiry, scopes, status, data_key, created_at, updated_at
+		 FROM credentials ORDER BY created_at`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []domain.Credential
+	for rows.Next() {
+		var c domain.Credential
+		if err := rows.Scan(&c.ID, &c.UserID, &c.TenantID, &c.Provider, &c.ProviderLabel, &c.AccessToken, &c.RefreshToken, &c.TokenExpiry, &c.Scopes, &c.Status, &c.DataKey, &c.CreatedAt, &c.UpdatedAt); err != nil {
 			return nil, err
 		}
 		credentials = append(credentials, c)
@@ -85,9 +106,9 @@ func (r *CredentialRepo) ListByTenant(ctx context.Context, tenantID string) ([]d
 
 func (r *CredentialRepo) Update(ctx context.Context, c *domain.Credential) error {
 	_, err := r.db.ExecContext(ctx,
-		`UPDATE credentials SET access_token = ?, refresh_token = ?, token_expiry = ?, provider_label = ?, scopes = ?, updated_at = datetime('now')
+		`UPDATE credentials SET access_token = ?, refresh_token = ?, token_expiry = ?, provider_label = ?, scopes = ?, status = ?, data_key = ?, updated_at = datetime('now')
 		 WHERE tenant_id = ? AND id = ?`,
-		c.AccessToken, c.RefreshToken, c.TokenExpiry, c.ProviderLabel, c.Scopes, c.TenantID, c.ID,
+		c.AccessToken, c.RefreshToken, c.TokenExpiry, c.ProviderLabel, c.Scopes, c.Status, c.DataKey, c.TenantID, c.ID,
 	)
 	return err
 }
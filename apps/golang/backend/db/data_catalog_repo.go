@@ -0,0 +1,415 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+)
+
+// inList returns the placeholders and arguments of an IN list of ids.
+func inList(ids []string) (string, []any) {
+	marks := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		marks[i] = "?"
+		args[i] = id
+	}
+	return strings.Join(marks, ", "), args
+}
+
+type DatasetStewardRepo struct {
+	db DBTX
+}
+
+func NewDatasetStewardRepo(db DBTX) *DatasetStewardRepo {
+	return &DatasetStewardRepo{db: db}
+}
+
+func (r *DatasetStewardRepo) Upsert(ctx context.Context, s *domain.DatasetSteward) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO dataset_stewards (dataset_id, user_id, tenant_id, role)
+		 VALUES (?, ?, ?, ?)
+		 ON CONFLICT(dataset_id, user_id) DO UPDATE SET role = excluded.role`,
+		s.DatasetID, s.UserID, s.TenantID, s.Role,
+	)
+	return err
+}
+
+func (r *DatasetStewardRepo) ListByDatasets(ctx context.Context, tenantID string, datasetIDs []string) ([]domain.DatasetSteward, error) {
+	if len(datasetIDs) == 0 {
+		return nil, nil
+	}
+	marks, args := inList(datasetIDs)
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT s.dataset_id, s.tenant_id, s.user_id, u.email, u.display_name, s.role, s.created_at
+		 FROM dataset_stewards s JOIN users u ON u.id = s.user_id
+		 WHERE s.tenant_id = ? AND s.dataset_id IN (`+marks+`)
+		 ORDER BY s.dataset_id, s.role, u.email`,
+		append([]any{tenantID}, args...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stewards []domain.DatasetSteward
+	for rows.Next() {
+		var s domain.DatasetSteward
+		if err := rows.Scan(&s.DatasetID, &s.TenantID, &s.UserID, &s.Email, &s.DisplayName, &s.Role, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		stewards = append(stewards, s)
+	}
+	return stewards, rows.Err()
+}
+
+func (r *DatasetStewardRepo) Delete(ctx context.Context, tenantID, datasetID, userID string) error {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM dataset_stewards WHERE tenant_id = ? AND dataset_id = ? AND user_id = ?`,
+		tenantID, datasetID, userID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrDatasetStewardNotFound
+	}
+	return nil
+}
+
+type DatasetCertificationRepo struct {
+	db DBTX
+}
+
+func NewDatasetCertificationRepo(db DBTX) *DatasetCertificationRepo {
+	return &DatasetCertificationRepo{db: db}
+}
+
+func (r *DatasetCertificationRepo) Upsert(ctx context.Context, c *domain.DatasetCertification) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO dataset_certifications (dataset_id, tenant_id, status, note, certified_by, certified_at)
+		 VALUES (?, ?, ?, ?, ?, datetime('now'))
+		 ON CONFLICT(dataset_id) DO UPDATE SET
+		   status = excluded.status,
+		   note = excluded.note,
+		   certified_by = excluded.certified_by,
+		   certified_at = excluded.certified_at`,
+		c.DatasetID, c.TenantID, c.Status, c.Note, c.CertifiedBy,
+	)
+	return err
+}
+
+func (r *DatasetCertificationRepo) ListByDatasets(ctx context.Context, tenantID string, datasetIDs []string) ([]domain.DatasetCertification, error) {
+	if len(datasetIDs) == 0 {
+		return nil, nil
+	}
+	marks, args := inList(datasetIDs)
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT dataset_id, tenant_id, status, note, certified_by, certified_at
+		 FROM dataset_certifications
+		 WHERE tenant_id = ? AND dataset_id IN (`+marks+`)`,
+		append([]any{tenantID}, args...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var certs []domain.DatasetCertification
+	for rows.Next() {
+		var c domain.DatasetCertification
+		if err := rows.Scan(&c.DatasetID, &c.TenantID, &c.Status, &c.Note, &c.CertifiedBy, &c.CertifiedAt); err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+	return certs, rows.Err()
+}
+
+func (r *DatasetCertificationRepo) Delete(ctx context.Context, tenantID, datasetID string) error {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM dataset_certifications WHERE tenant_id = ? AND dataset_id = ?`,
+		tenantID, datasetID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrDatasetCertificationNotFound
+	}
+	return nil
+}
+
+type GlossaryTermRepo struct {
+	db DBTX
+}
+
+func NewGlossaryTermRepo(db DBTX) *GlossaryTermRepo {
+	return &GlossaryTermRepo{db: db}
+}
+
+const glossaryTermColumns = `id, tenant_id, name, definition, created_at, updated_at`
+
+func scanGlossaryTerm(s interface{ Scan(...any) error }) (*domain.GlossaryTerm, error) {
+	var t domain.GlossaryTerm
+	if err := s.Scan(&t.ID, &t.TenantID, &t.Name, &t.Definition, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *GlossaryTermRepo) Create(ctx context.Context, t *domain.GlossaryTerm) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO glossary_terms (id, tenant_id, name, definition, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, datetime('now'), datetime('now'))`,
+		t.ID, t.TenantID, t.Name, t.Definition,
+	)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return domain.ErrGlossaryTermExists
+	}
+	return err
+}
+
+func (r *GlossaryTermRepo) FindByID(ctx context.Context, tenantID, id string) (*domain.GlossaryTerm, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+glossaryTermColumns+` FROM glossary_terms WHERE tenant_id = ? AND id = ?`,
+		tenantID, id,
+	)
+	t, err := scanGlossaryTerm(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrGlossaryTermNotFound
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *GlossaryTermRepo) ListByTenant(ctx context.Context, tenantID string) ([]domain.GlossaryTerm, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+glossaryTermColumns+` FROM glossary_terms WHERE tenant_id = ? ORDER BY name`,
+		tenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var terms []domain.GlossaryTerm
+	for rows.Next() {
+		t, err := scanGlossaryTerm(rows)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, *t)
+	}
+	return terms, rows.Err()
+}
+
+func (r *GlossaryTermRepo) Update(ctx context.Context, t *domain.GlossaryTerm) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE glossary_terms SET name = ?, definition = ?, updated_at = datetime('now')
+		 WHERE tenant_id = ? AND id = ?`,
+		t.Name, t.Definition, t.TenantID, t.ID,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return domain.ErrGlossaryTermExists
+		}
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrGlossaryTermNotFound
+	}
+	return nil
+}
+
+func (r *GlossaryTermRepo) Delete(ctx context.Context, tenantID, id string) error {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM glossary_terms WHERE tenant_id = ? AND id = ?`, tenantID, id,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrGlossaryTermNotFound
+	}
+	return nil
+}
+
+func (r *GlossaryTermRepo) LinkColumn(ctx context.Context, tenantID string, c *domain.GlossaryTermColumn) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO glossary_term_columns (term_id, dataset_id, tenant_id, column_name)
+		 VALUES (?, ?, ?, ?)
+		 ON CONFLICT(term_id, dataset_id, column_name) DO NOTHING`,
+		c.TermID, c.DatasetID, tenantID, c.ColumnName,
+	)
+	return err
+}
+
+func (r *GlossaryTermRepo) UnlinkColumn(ctx context.Context, tenantID, termID, datasetID, columnName string) error {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM glossary_term_columns
+		 WHERE tenant_id = ? AND term_id = ? AND dataset_id = ? AND column_name = ?`,
+		tenantID, termID, datasetID, columnName,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrGlossaryTermLinkNotFound
+	}
+	return nil
+}
+
+func (r *GlossaryTermRepo) ListColumnsByTerms(ctx context.Context, tenantID string, termIDs []string) ([]domain.GlossaryTermColumn, error) {
+	return r.listColumns(ctx, tenantID, "c.term_id", termIDs)
+}
+
+func (r *GlossaryTermRepo) ListColumnsByDatasets(ctx context.Context, tenantID string, datasetIDs []string) ([]domain.GlossaryTermColumn, error) {
+	return r.listColumns(ctx, tenantID, "c.dataset_id", datasetIDs)
+}
+
+// listColumns lists the term links whose column key is one of ids.
+func (r *GlossaryTermRepo) listColumns(ctx context.Context, tenantID, key string, ids []string) ([]domain.GlossaryTermColumn, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	marks, args := inList(ids)
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT c.term_id, t.name, c.dataset_id, c.column_name, c.created_at
+		 FROM glossary_term_columns c JOIN glossary_terms t ON t.id = c.term_id
+		 WHERE c.tenant_id = ? AND `+key+` IN (`+marks+`)
+		 ORDER BY c.dataset_id, c.column_name, t.name`,
+		append([]any{tenantID}, args...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []domain.GlossaryTermColumn
+	for rows.Next() {
+		var c domain.GlossaryTermColumn
+		if err := rows.Scan(&c.TermID, &c.TermName, &c.DatasetID, &c.ColumnName, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+type DatasetUsageRepo struct {
+	db DBTX
+}
+
+func NewDatasetUsageRepo(db DBTX) *DatasetUsageRepo {
+	return &DatasetUsageRepo{db: db}
+}
+
+func (r *DatasetUsageRepo) Record(ctx context.Context, e *domain.DatasetUsageEvent) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO dataset_usage_events (id, tenant_id, dataset_id, kind, subject_id)
+		 VALUES (?, ?, ?, ?, ?)`,
+		e.ID, e.TenantID, e.DatasetID, e.Kind, e.SubjectID,
+	)
+	return err
+}
+
+func (r *DatasetUsageRepo) CountSince(ctx context.Context, tenantID string, datasetIDs []string, since time.Time) (map[string]domain.DatasetUsage, error) {
+	usage := make(map[string]domain.DatasetUsage, len(datasetIDs))
+	if len(datasetIDs) == 0 {
+		return usage, nil
+	}
+	marks, ids := inList(datasetIDs)
+	at := since.UTC().Format("2006-01-02 15:04:05")
+
+	// Charts are counted once however often their data was read
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT dataset_id,
+		   COUNT(DISTINCT CASE WHEN kind = 'chart' THEN subject_id END),
+		   COUNT(CASE WHEN kind = 'preview' THEN 1 END)
+		 FROM dataset_usage_events
+		 WHERE tenant_id = ? AND dataset_id IN (`+marks+`) AND created_at >= ?
+		 GROUP BY dataset_id`,
+		append(append([]any{tenantID}, ids...), at)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var u domain.DatasetUsage
+		if err := rows.Scan(&id, &u.Charts, &u.Previews); err != nil {
+			return nil, err
+		}
+		usage[id] = u
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Each transform run refreshes the edges from its input datasets
+	rows, err = r.db.QueryContext(ctx,
+		`SELECT source_id, COUNT(DISTINCT target_id)
+		 FROM lineage_edges
+		 WHERE tenant_id = ? AND source_type = 'dataset' AND source_id IN (`+marks+`)
+		   AND target_type = 'job' AND updated_at >= ?
+		 GROUP BY source_id`,
+		append(append([]any{tenantID}, ids...), at)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var n int64
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		u := usage[id]
+		u.Transforms = n
+		usage[id] = u
+	}
+	return usage, rows.Err()
+}
+
+func (r *DatasetUsageRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM dataset_usage_events WHERE created_at < ?`,
+		before.UTC().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/user/micro-dp/domain"
+)
+
+func datasetIDs(list []domain.Dataset) []string {
+	ids := make([]string, len(list))
+	for i, d := range list {
+		ids[i] = d.ID
+	}
+	return ids
+}
+
+func TestDatasetSearch(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	createTestTenant(t, db, "t1")
+	createTestTenant(t, db, "t2")
+	repo := NewDatasetRepo(db)
+
+	schema := `[{"column_name":"customer_email","column_type":"VARCHAR","description":"Contact address","tags":["pii"]},
+		{"column_name":"amount","column_type":"DOUBLE"}]`
+	orders := createTestDataset(t, db, "t1", "d1", "orders")
+	orders.SchemaJSON = &schema
+	if err := repo.Update(ctx, orders); err != nil {
+		t.Fatal(err)
+	}
+	customers := createTestDataset(t, db, "t1", "d2", "customers")
+	createTestDataset(t, db, "t1", "d3", "email_list")
+	createTestDataset(t, db, "t2", "d4", "orders")
+
+	search := func(q string) []string {
+		t.Helper()
+		list, err := repo.ListByTenant(ctx, "t1", domain.DatasetListFilter{Query: q})
+		if err != nil {
+			t.Fatalf("search %q: %v", q, err)
+		}
+		return datasetIDs(list)
+	}
+
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		{"ord", []string{"d1"}},         // name prefix, other tenants excluded
+		{"contact", []string{"d1"}},     // column description
+		{"PII", []string{"d1"}},         // column tag
+		{"amount", []string{"d1"}},      // column name
+		{"email", []string{"d3", "d1"}}, // a name match ranks above a column match
+		{"customer email", []string{"d1"}},
+		{`"or*`, []string{"d1"}}, // FTS5 syntax is not interpreted
+		{"invoices", []string{}},
+	} {
+		if got := search(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("search %q = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// The index follows schema changes, and soft-deleted datasets are hidden
+	tagged := `[{"column_name":"phone","column_type":"VARCHAR","tags":["pii"]}]`
+	customers.SchemaJSON = &tagged
+	if err := repo.Update(ctx, customers); err != nil {
+		t.Fatal(err)
+	}
+	got := search("pii")
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, []string{"d1", "d2"}) {
+		t.Errorf("search pii after tagging customers = %v, want d1 and d2", got)
+	}
+	if err := repo.SoftDelete(ctx, "t1", "d1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("pii"); !reflect.DeepEqual(got, []string{"d2"}) {
+		t.Errorf("search pii after deleting orders = %v, want [d2]", got)
+	}
+}
+
+func TestDatasetUsageCountSince(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	createTestTenant(t, db, "t1")
+	createTestTenant(t, db, "t2")
+	createTestDataset(t, db, "t1", "d1", "orders")
+	createTestDataset(t, db, "t1", "d2", "customers")
+	createTestDataset(t, db, "t2", "d3", "orders")
+	repo := NewDatasetUsageRepo(db)
+
+	for _, e := range []domain.DatasetUsageEvent{
+		{ID: "e1", TenantID: "t1", DatasetID: "d1", Kind: "chart", SubjectID: "ch1"},
+		{ID: "e2", TenantID: "t1", DatasetID: "d1", Kind: "chart", SubjectID: "ch1"},
+		{ID: "e3", TenantID: "t1", DatasetID: "d1", Kind: "chart", SubjectID: "ch2"},
+		{ID: "e4", TenantID: "t1", DatasetID: "d1", Kind: "preview"},
+		{ID: "e5", TenantID: "t1", DatasetID: "d1", Kind: "preview"},
+		{ID: "e6", TenantID: "t1", DatasetID: "d1", Kind: "chart", SubjectID: "ch3"},
+		{ID: "e7", TenantID: "t2", DatasetID: "d3", Kind: "preview"},
+	} {
+		if err := repo.Record(ctx, &e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lineage := NewLineageRepo(db)
+	for _, job := range []string{"j1", "j2", "j3"} {
+		e := &domain.LineageEdge{TenantID: "t1",
+			Source: domain.LineageNode{Type: domain.LineageNodeDataset, ID: "d1"},
+			Target: domain.LineageNode{Type: domain.LineageNodeJob, ID: job}}
+		if err := lineage.Upsert(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The chart ch3 and the job j3 last read orders before the window
+	execSQL(t, db, `UPDATE dataset_usage_events SET created_at = datetime('now', '-40 days') WHERE id = 'e6'`)
+	execSQL(t, db, `UPDATE lineage_edges SET updated_at = datetime('now', '-40 days') WHERE target_id = 'j3'`)
+
+	usage, err := repo.CountSince(ctx, "t1", []string{"d1", "d2", "d3"}, time.Now().Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]domain.DatasetUsage{"d1": {Charts: 2, Transforms: 2, Previews: 2}}
+	if !reflect.DeepEqual(usage, want) {
+		t.Errorf("usage = %+v, want %+v", usage, want)
+	}
+
+	n, err := repo.DeleteBefore(ctx, time.Now().Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("DeleteBefore removed %d events, want the one before the window", n)
+	}
+}
+
+func execSQL(t *testing.T, db *sql.DB, query string) {
+	t.Helper()
+	if _, err := db.Exec(query); err != nil {
+		t.Fatal(err)
+	}
+}
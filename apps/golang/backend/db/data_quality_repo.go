@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/user/micro-dp/domain"
+)
+
+type DataQualityRuleRepo struct {
+	db DBTX
+}
+
+func NewDataQualityRuleRepo(db DBTX) *DataQualityRuleRepo {
+	return &DataQualityRuleRepo{db: db}
+}
+
+const dataQualityRuleColumns = `id, tenant_id, dataset_id, name, type, config_json, severity, enabled, created_at, updated_at`
+
+func scanDataQualityRule(s interface{ Scan(...any) error }) (*domain.DataQualityRule, error) {
+	var r domain.DataQualityRule
+	if err := s.Scan(&r.ID, &r.TenantID, &r.DatasetID, &r.Name, &r.Type, &r.ConfigJSON, &r.Severity, &r.Enabled,
+		&r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (r *DataQualityRuleRepo) Create(ctx context.Context, rule *domain.DataQualityRule) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO data_quality_rules (id, tenant_id, dataset_id, name, type, config_json, severity, enabled, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
+		rule.ID, rule.TenantID, rule.DatasetID, rule.Name, rule.Type, rule.ConfigJSON, rule.Severity, rule.Enabled,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return domain.ErrDataQualityRuleExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *DataQualityRuleRepo) FindByID(ctx context.Context, tenantID, id string) (*domain.DataQualityRule, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+dataQualityRuleColumns+` FROM data_quality_rules WHERE tenant_id = ? AND id = ?`, tenantID, id,
+	)
+	rule, err := scanDataQualityRule(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrDataQualityRuleNotFound
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *DataQualityRuleRepo) ListByDataset(ctx context.Context, tenantID, datasetID string) ([]domain.DataQualityRule, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+dataQualityRuleColumns+` FROM data_quality_rules
+		 WHERE tenant_id = ? AND dataset_id = ? ORDER BY created_at, name`, tenantID, datasetID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []domain.DataQualityRule
+	for rows.Next() {
+		rule, err := scanDataQualityRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *DataQualityRuleRepo) Update(ctx context.Context, rule *domain.DataQualityRule) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE data_quality_rules SET name = ?, type = ?, config_json = ?, severity = ?, enabled = ?, updated_at = datetime('now')
+		 WHERE tenant_id = ? AND id = ?`,
+		rule.Name, rule.Type, rule.ConfigJSON, rule.Severity, rule.Enabled, rule.TenantID, rule.ID,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return domain.ErrDataQualityRuleExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *DataQualityRuleRepo) Delete(ctx context.Context, tenantID, id string) error {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM data_quality_rules WHERE tenant_id = ? AND id = ?`, tenantID, id,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrDataQualityRuleNotFound
+	}
+	return nil
+}
+
+type DataQualityResultRepo struct {
+	db DBTX
+}
+
+func NewDataQualityResultRepo(db DBTX) *DataQualityResultRepo {
+	return &DataQualityResultRepo{db: db}
+}
+
+const dataQualityResultColumns = `id, tenant_id, dataset_id, rule_id, job_run_id, dataset_version, status, severity,
+	failing_rows, message, created_at`
+
+func scanDataQualityResult(s interface{ Scan(...any) error }) (*domain.DataQualityResult, error) {
+	var r domain.DataQualityResult
+	if err := s.Scan(&r.ID, &r.TenantID, &r.DatasetID, &r.RuleID, &r.JobRunID, &r.DatasetVersion, &r.Status, &r.Severity,
+		&r.FailingRows, &r.Message, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (r *DataQualityResultRepo) Create(ctx context.Context, res *domain.DataQualityResult) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO data_quality_results (id, tenant_id, dataset_id, rule_id, job_run_id, dataset_version, status, severity,
+		   failing_rows, message, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
+		res.ID, res.TenantID, res.DatasetID, res.RuleID, res.JobRunID, res.DatasetVersion, res.Status, res.Severity,
+		res.FailingRows, res.Message,
+	)
+	if err != nil {
+		return err
+	}
+	return r.db.QueryRowContext(ctx,
+		`SELECT created_at FROM data_quality_results WHERE id = ?`, res.ID,
+	).Scan(&res.CreatedAt)
+}
+
+func (r *DataQualityResultRepo) ListByDataset(ctx context.Context, tenantID, datasetID, jobRunID string, limit int) ([]domain.DataQualityResult, error) {
+	query := `SELECT ` + dataQualityResultColumns + `
+		 FROM data_quality_results WHERE tenant_id = ? AND dataset_id = ?`
+	args := []any{tenantID, datasetID}
+	if jobRunID != "" {
+		query += ` AND job_run_id = ?`
+		args = append(args, jobRunID)
+	}
+	query += ` ORDER BY created_at DESC, rowid DESC LIMIT ?`
+	args = append(args, limit)
+	return r.list(ctx, query, args...)
+}
+
+func (r *DataQualityResultRepo) LatestByDataset(ctx context.Context, tenantID, datasetID string) ([]domain.DataQualityResult, error) {
+	return r.list(ctx,
+		`SELECT `+dataQualityResultColumns+` FROM (
+		   SELECT *, ROW_NUMBER() OVER (PARTITION BY rule_id ORDER BY created_at DESC, rowid DESC) AS rn
+		   FROM data_quality_results WHERE tenant_id = ? AND dataset_id = ?
+		 ) WHERE rn = 1`,
+		tenantID, datasetID,
+	)
+}
+
+func (r *DataQualityResultRepo) list(ctx context.Context, query string, args ...any) ([]domain.DataQualityResult, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []domain.DataQualityResult
+	for rows.Next() {
+		res, err := scanDataQualityResult(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *res)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/user/micro-dp/domain"
+)
+
+type DatasetExportRepo struct {
+	db DBTX
+}
+
+func NewDatasetExportRepo(db DBTX) *DatasetExportRepo {
+	return &DatasetExportRepo{db: db}
+}
+
+func (r *DatasetExportRepo) Create(ctx context.Context, e *domain.DatasetExport) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO dataset_exports (id, tenant_id, dataset_id, format, version, query_json, status, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
+		e.ID, e.TenantID, e.DatasetID, e.Format, e.Version, e.QueryJSON, e.Status,
+	)
+	return err
+}
+
+func (r *DatasetExportRepo) FindByID(ctx context.Context, tenantID, id string) (*domain.DatasetExport, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT id, tenant_id, dataset_id, format, version, query_json, status, object_key, size_bytes, error_message, created_at, updated_at, completed_at
+		 FROM dataset_exports WHERE tenant_id = ? AND id = ?`, tenantID, id,
+	)
+	var e domain.DatasetExport
+	if err := row.Scan(&e.ID, &e.TenantID, &e.DatasetID, &e.Format, &e.Version, &e.QueryJSON, &e.Status,
+		&e.ObjectKey, &e.SizeBytes, &e.ErrorMessage, &e.CreatedAt, &e.UpdatedAt, &e.CompletedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrDatasetExportNotFound
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *DatasetExportRepo) Update(ctx context.Context, e *domain.DatasetExport) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE dataset_exports SET status = ?, object_key = ?, size_bytes = ?, error_message = ?, completed_at = ?, updated_at = datetime('now')
+		 WHERE tenant_id = ? AND id = ?`,
+		e.Status, e.ObjectKey, e.SizeBytes, e.ErrorMessage, e.CompletedAt, e.TenantID, e.ID,
+	)
+	return err
+}
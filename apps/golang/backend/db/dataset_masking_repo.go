@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+
+	"github.com/user/micro-dp/domain"
+)
+
+type DatasetMaskingPolicyRepo struct {
+	db DBTX
+}
+
+func NewDatasetMaskingPolicyRepo(db DBTX) *DatasetMaskingPolicyRepo {
+	return &DatasetMaskingPolicyRepo{db: db}
+}
+
+const datasetMaskingPolicyColumns = `id, tenant_id, dataset_id, column_name, policy, created_at, updated_at`
+
+func scanDatasetMaskingPolicy(s interface{ Scan(...any) error }) (*domain.DatasetMaskingPolicy, error) {
+	var p domain.DatasetMaskingPolicy
+	if err := s.Scan(&p.ID, &p.TenantID, &p.DatasetID, &p.ColumnName, &p.Policy, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *DatasetMaskingPolicyRepo) Upsert(ctx context.Context, p *domain.DatasetMaskingPolicy) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO dataset_masking_policies (id, tenant_id, dataset_id, column_name, policy, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, datetime('now'), datetime('now'))
+		 ON CONFLICT(dataset_id, column_name) DO UPDATE SET
+		   policy = excluded.policy,
+		   updated_at = datetime('now')`,
+		p.ID, p.TenantID, p.DatasetID, p.ColumnName, p.Policy,
+	)
+	return err
+}
+
+func (r *DatasetMaskingPolicyRepo) ListByDataset(ctx context.Context, tenantID, datasetID string) ([]domain.DatasetMaskingPolicy, error) {
+	return r.list(ctx,
+		`SELECT `+datasetMaskingPolicyColumns+` FROM dataset_masking_policies
+		 WHERE tenant_id = ? AND dataset_id = ? ORDER BY column_name`,
+		tenantID, datasetID,
+	)
+}
+
+func (r *DatasetMaskingPolicyRepo) ListByTenant(ctx context.Context, tenantID string) ([]domain.DatasetMaskingPolicy, error) {
+	return r.list(ctx,
+		`SELECT `+datasetMaskingPolicyColumns+` FROM dataset_masking_policies
+		 WHERE tenant_id = ? ORDER BY dataset_id, column_name`,
+		tenantID,
+	)
+}
+
+func (r *DatasetMaskingPolicyRepo) list(ctx context.Context, query string, args ...any) ([]domain.DatasetMaskingPolicy, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []domain.DatasetMaskingPolicy
+	for rows.Next() {
+		p, err := scanDatasetMaskingPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *p)
+	}
+	return policies, rows.Err()
+}
+
+func (r *DatasetMaskingPolicyRepo) Delete(ctx context.Context, tenantID, datasetID, columnName string) error {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM dataset_masking_policies WHERE tenant_id = ? AND dataset_id = ? AND column_name = ?`,
+		tenantID, datasetID, columnName,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrMaskingPolicyNotFound
+	}
+	return nil
+}
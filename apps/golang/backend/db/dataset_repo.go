@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/user/micro-dp/domain"
 )
@@ -16,12 +19,14 @@ func NewDatasetRepo(db DBTX) *DatasetRepo {
 	return &DatasetRepo{db: db}
 }
 
+const datasetColumns = `id, tenant_id, name, source_type, schema_json, row_count, storage_path, last_updated_at, created_at, updated_at, deleted_at`
+
 func scanDataset(s interface{ Scan(...any) error }) (*domain.Dataset, error) {
 	var d domain.Dataset
 	if err := s.Scan(
 		&d.ID, &d.TenantID, &d.Name, &d.SourceType,
 		&d.SchemaJSON, &d.RowCount, &d.StoragePath,
-		&d.LastUpdatedAt, &d.CreatedAt, &d.UpdatedAt,
+		&d.LastUpdatedAt, &d.CreatedAt, &d.UpdatedAt, &d.DeletedAt,
 	); err != nil {
 		return nil, err
 	}
@@ -30,8 +35,38 @@ func scanDataset(s interface{ Scan(...any) error }) (*domain.Dataset, error) {
 
 func (r *DatasetRepo) FindByID(ctx context.Context, tenantID, id string) (*domain.Dataset, error) {
 	row := r.db.QueryRowContext(ctx,
-		`SELECT id, tenant_id, name, source_type, schema_json, row_count, storage_path, last_updated_at, created_at, updated_at
-		 FROM datasets WHERE tenant_id = ? AND id = ?`, tenantID, id,
+		`SELECT `+datasetColumns+`
+		 FROM datasets WHERE tenant_id = ? AND id = ? AND deleted_at IS NULL`, tenantID, id,
+	)
+	d, err := scanDataset(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrDatasetNotFound
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *DatasetRepo) FindByName(ctx context.Context, tenantID, name string) (*domain.Dataset, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+datasetColumns+`
+		 FROM datasets WHERE tenant_id = ? AND name = ? AND deleted_at IS NULL`, tenantID, name,
+	)
+	d, err := scanDataset(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrDatasetNotFound
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *DatasetRepo) FindDeletedByID(ctx context.Context, tenantID, id string) (*domain.Dataset, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+datasetColumns+`
+		 FROM datasets WHERE tenant_id = ? AND id = ? AND deleted_at IS NOT NULL`, tenantID, id,
 	)
 	d, err := scanDataset(row)
 	if err != nil {
@@ -44,20 +79,53 @@ func (r *DatasetRepo) FindByID(ctx context.Context, tenantID, id string) (*domai
 }
 
 func (r *DatasetRepo) ListByTenant(ctx context.Context, tenantID string, filter domain.DatasetListFilter) ([]domain.Dataset, error) {
-	query := `SELECT id, tenant_id, name, source_type, schema_json, row_count, storage_path, last_updated_at, created_at, updated_at
-		 FROM datasets WHERE tenant_id = ?`
-	args := []any{tenantID}
+	query := `SELECT ` + datasetColumns + ` FROM datasets`
+	var args []any
+
+	// Full-text matches are ranked by relevance, names weighing most
+	match := searchMatch(filter.Query)
+	if match != "" {
+		query += ` LEFT JOIN (
+			SELECT dataset_id, bm25(dataset_search, 0, 0, 10.0, 5.0, 1.0, 5.0) AS rank
+			FROM dataset_search WHERE dataset_search MATCH ? AND tenant_id = ?
+		) search ON search.dataset_id = datasets.id`
+		args = append(args, match, tenantID)
+	}
+	query += ` WHERE tenant_id = ?`
+	args = append(args, tenantID)
+
+	if filter.Deleted {
+		query += ` AND deleted_at IS NOT NULL`
+	} else {
+		query += ` AND deleted_at IS NULL`
+	}
 
 	if filter.Query != "" {
-		query += ` AND name LIKE ?`
+		query += ` AND (name LIKE ?`
 		args = append(args, fmt.Sprintf("%%%s%%", filter.Query))
+		if match != "" {
+			query += ` OR search.dataset_id IS NOT NULL`
+		}
+		query += `)`
 	}
 	if filter.SourceType != "" {
 		query += ` AND source_type = ?`
 		args = append(args, filter.SourceType)
 	}
+	if filter.Certification != "" {
+		query += ` AND id IN (SELECT dataset_id FROM dataset_certifications WHERE status = ?)`
+		args = append(args, filter.Certification)
+	}
+	if filter.StewardUserID != "" {
+		query += ` AND id IN (SELECT dataset_id FROM dataset_stewards WHERE user_id = ?)`
+		args = append(args, filter.StewardUserID)
+	}
 
-	query += ` ORDER BY name`
+	if match != "" {
+		query += ` ORDER BY COALESCE(search.rank, 0), name`
+	} else {
+		query += ` ORDER BY name`
+	}
 
 	limit := filter.Limit
 	if limit <= 0 {
@@ -117,8 +185,112 @@ func (r *DatasetRepo) Upsert(ctx context.Context, d *domain.Dataset) error {
 		   row_count = excluded.row_count,
 		   storage_path = excluded.storage_path,
 		   last_updated_at = excluded.last_updated_at,
-		   updated_at = datetime('now')`,
+		   updated_at = datetime('now'),
+		   deleted_at = NULL`,
 		d.ID, d.TenantID, d.Name, d.SourceType, d.SchemaJSON, d.RowCount, d.StoragePath, d.LastUpdatedAt,
 	)
 	return err
 }
+
+func (r *DatasetRepo) ListDeletedBefore(ctx context.Context, before time.Time) ([]domain.Dataset, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+datasetColumns+`
+		 FROM datasets WHERE deleted_at IS NOT NULL AND deleted_at < ?
+		 ORDER BY deleted_at`, before.UTC().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var datasets []domain.Dataset
+	for rows.Next() {
+		d, err := scanDataset(rows)
+		if err != nil {
+			return nil, err
+		}
+		datasets = append(datasets, *d)
+	}
+	return datasets, rows.Err()
+}
+
+func (r *DatasetRepo) ListAllByTenant(ctx context.Context, tenantID string) ([]domain.Dataset, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+datasetColumns+`
+		 FROM datasets WHERE tenant_id = ?
+		 ORDER BY name`, tenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var datasets []domain.Dataset
+	for rows.Next() {
+		d, err := scanDataset(rows)
+		if err != nil {
+			return nil, err
+		}
+		datasets = append(datasets, *d)
+	}
+	return datasets, rows.Err()
+}
+
+func (r *DatasetRepo) SoftDelete(ctx context.Context, tenantID, id string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE datasets SET deleted_at = datetime('now'), updated_at = datetime('now')
+		 WHERE tenant_id = ? AND id = ? AND deleted_at IS NULL`,
+		tenantID, id,
+	)
+	if err != nil {
+		return err
+	}
+	return datasetAffected(res)
+}
+
+func (r *DatasetRepo) Restore(ctx context.Context, tenantID, id string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE datasets SET deleted_at = NULL, updated_at = datetime('now')
+		 WHERE tenant_id = ? AND id = ? AND deleted_at IS NOT NULL`,
+		tenantID, id,
+	)
+	if err != nil {
+		return err
+	}
+	return datasetAffected(res)
+}
+
+func (r *DatasetRepo) DeleteSoftDeleted(ctx context.Context, tenantID, id string) error {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM datasets WHERE tenant_id = ? AND id = ? AND deleted_at IS NOT NULL`,
+		tenantID, id,
+	)
+	if err != nil {
+		return err
+	}
+	return datasetAffected(res)
+}
+
+func datasetAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrDatasetNotFound
+	}
+	return nil
+}
+
+// searchMatch turns a search query into an FTS5 query matching datasets
+// whose indexed text has every word of it as a prefix of a token. It is
+// empty when the query has no words.
+func searchMatch(q string) string {
+	words := strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = `"` + w + `"*`
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/user/micro-dp/domain"
+)
+
+func TestDatasetDeleteSoftDeleted(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	createTestTenant(t, db, "t1")
+	repo := NewDatasetRepo(db)
+	ds := createTestDataset(t, db, "t1", "d1", "orders")
+
+	if err := repo.DeleteSoftDeleted(ctx, "t1", "d1"); !errors.Is(err, domain.ErrDatasetNotFound) {
+		t.Fatalf("DeleteSoftDeleted of a live dataset = %v, want %v", err, domain.ErrDatasetNotFound)
+	}
+	if err := repo.SoftDelete(ctx, "t1", "d1"); err != nil {
+		t.Fatal(err)
+	}
+	// A write to the name restores the dataset before the purge deletes it
+	if err := repo.Upsert(ctx, ds); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DeleteSoftDeleted(ctx, "t1", "d1"); !errors.Is(err, domain.ErrDatasetNotFound) {
+		t.Fatalf("DeleteSoftDeleted of a restored dataset = %v, want %v", err, domain.ErrDatasetNotFound)
+	}
+	if _, err := repo.FindByID(ctx, "t1", "d1"); err != nil {
+		t.Fatalf("restored dataset = %v, want it kept", err)
+	}
+
+	if err := repo.SoftDelete(ctx, "t1", "d1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DeleteSoftDeleted(ctx, "t2", "d1"); !errors.Is(err, domain.ErrDatasetNotFound) {
+		t.Errorf("DeleteSoftDeleted by another tenant = %v, want %v", err, domain.ErrDatasetNotFound)
+	}
+	if err := repo.DeleteSoftDeleted(ctx, "t1", "d1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.FindDeletedByID(ctx, "t1", "d1"); !errors.Is(err, domain.ErrDatasetNotFound) {
+		t.Errorf("FindDeletedByID after the purge = %v, want %v", err, domain.ErrDatasetNotFound)
+	}
+}
+
+func TestJobListByInputDataset(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	createTestTenant(t, db, "t1")
+	if err := NewModuleTypeRepo(db).Create(ctx, &domain.ModuleType{ID: "mt1", TenantID: "t1", Name: "sql", Category: "transform"}); err != nil {
+		t.Fatal(err)
+	}
+	jobs := NewJobRepo(db)
+	for _, j := range []struct {
+		id, config string
+		active     bool
+	}{
+		{"j1", `{"dataset_ids":["d1"]}`, true},
+		{"j2", `{"dataset_ids":["d2","d1"]}`, false},
+		{"j3", `{"dataset_ids":["d2"]}`, true},
+	} {
+		if err := jobs.Create(ctx, &domain.Job{ID: j.id, TenantID: "t1", Name: j.id, Slug: j.id, IsActive: j.active}); err != nil {
+			t.Fatal(err)
+		}
+		v := &domain.JobVersion{ID: j.id + "-v1", TenantID: "t1", JobID: j.id, Version: 1, Status: "draft"}
+		if err := NewJobVersionRepo(db).Create(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+		m := &domain.JobModule{ID: j.id + "-m1", TenantID: "t1", JobVersionID: v.ID, ModuleTypeID: "mt1", Name: "transform", ConfigJSON: j.config}
+		if err := NewJobModuleRepo(db).Create(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := jobs.ListByInputDataset(ctx, "t1", "d1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != "j1" || list[1].ID != "j2" || list[1].IsActive {
+		t.Errorf("jobs reading d1 = %+v, want j1 and the inactive j2", list)
+	}
+}
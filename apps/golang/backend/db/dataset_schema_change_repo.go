@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+
+	"github.com/user/micro-dp/domain"
+)
+
+type DatasetSchemaChangeRepo struct {
+	db DBTX
+}
+
+func NewDatasetSchemaChangeRepo(db DBTX) *DatasetSchemaChangeRepo {
+	return &DatasetSchemaChangeRepo{db: db}
+}
+
+func scanDatasetSchemaChange(s interface{ Scan(...any) error }) (*domain.DatasetSchemaChange, error) {
+	var c domain.DatasetSchemaChange
+	if err := s.Scan(&c.ID, &c.TenantID, &c.DatasetID, &c.JobRunID, &c.Policy, &c.Action, &c.ChangesJSON, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *DatasetSchemaChangeRepo) Create(ctx context.Context, c *domain.DatasetSchemaChange) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO dataset_schema_changes (id, tenant_id, dataset_id, job_run_id, policy, action, changes_json, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
+		c.ID, c.TenantID, c.DatasetID, c.JobRunID, c.Policy, c.Action, c.ChangesJSON,
+	)
+	return err
+}
+
+func (r *DatasetSchemaChangeRepo) ListByDataset(ctx context.Context, tenantID, datasetID string, limit int) ([]domain.DatasetSchemaChange, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, tenant_id, dataset_id, job_run_id, policy, action, changes_json, created_at
+		 FROM dataset_schema_changes WHERE tenant_id = ? AND dataset_id = ?
+		 ORDER BY created_at DESC, rowid DESC LIMIT ?`, tenantID, datasetID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []domain.DatasetSchemaChange
+	for rows.Next() {
+		c, err := scanDatasetSchemaChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *c)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/user/micro-dp/domain"
+)
+
+type DatasetVersionRepo struct {
+	db DBTX
+}
+
+func NewDatasetVersionRepo(db DBTX) *DatasetVersionRepo {
+	return &DatasetVersionRepo{db: db}
+}
+
+const datasetVersionColumns = `id, tenant_id, dataset_id, version, storage_path, object_keys_json, schema_json, row_count,
+	job_run_id, restored_from, pinned, created_at`
+
+func scanDatasetVersion(s interface{ Scan(...any) error }) (*domain.DatasetVersion, error) {
+	var v domain.DatasetVersion
+	if err := s.Scan(&v.ID, &v.TenantID, &v.DatasetID, &v.Version, &v.StoragePath, &v.ObjectKeysJSON, &v.SchemaJSON, &v.RowCount,
+		&v.JobRunID, &v.RestoredFrom, &v.Pinned, &v.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *DatasetVersionRepo) Create(ctx context.Context, v *domain.DatasetVersion) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO dataset_versions (id, tenant_id, dataset_id, version, storage_path, object_keys_json, schema_json, row_count,
+		   job_run_id, restored_from, pinned, created_at)
+		 SELECT ?, ?, ?, COALESCE(MAX(version), 0) + 1, ?, ?, ?, ?, ?, ?, ?, datetime('now')
+		 FROM dataset_versions WHERE dataset_id = ?`,
+		v.ID, v.TenantID, v.DatasetID, v.StoragePath, v.ObjectKeysJSON, v.SchemaJSON, v.RowCount,
+		v.JobRunID, v.RestoredFrom, v.Pinned, v.DatasetID,
+	)
+	if err != nil {
+		return err
+	}
+	return r.db.QueryRowContext(ctx,
+		`SELECT version, created_at FROM dataset_versions WHERE id = ?`, v.ID,
+	).Scan(&v.Version, &v.CreatedAt)
+}
+
+func (r *DatasetVersionRepo) FindByVersion(ctx context.Context, tenantID, datasetID string, version int) (*domain.DatasetVersion, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+datasetVersionColumns+`
+		 FROM dataset_versions WHERE tenant_id = ? AND dataset_id = ? AND version = ?`, tenantID, datasetID, version,
+	)
+	v, err := scanDatasetVersion(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrDatasetVersionNotFound
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *DatasetVersionRepo) FindAsOf(ctx context.Context, tenantID, datasetID string, at time.Time) (*domain.DatasetVersion, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+datasetVersionColumns+`
+		 FROM dataset_versions WHERE tenant_id = ? AND dataset_id = ? AND created_at <= ?
+		 ORDER BY version DESC LIMIT 1`,
+		tenantID, datasetID, at.UTC().Format("2006-01-02 15:04:05"),
+	)
+	v, err := scanDatasetVersion(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrDatasetVersionNotFound
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *DatasetVersionRepo) ListByDataset(ctx context.Context, tenantID, datasetID string, limit int) ([]domain.DatasetVersion, error) {
+	query := `SELECT ` + datasetVersionColumns + `
+		 FROM dataset_versions WHERE tenant_id = ? AND dataset_id = ?
+		 ORDER BY version DESC`
+	args := []any{tenantID, datasetID}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []domain.DatasetVersion
+	for rows.Next() {
+		v, err := scanDatasetVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *v)
+	}
+	return versions, rows.Err()
+}
+
+func (r *DatasetVersionRepo) SetPinned(ctx context.Context, tenantID, datasetID string, version int, pinned bool) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE dataset_versions SET pinned = ? WHERE tenant_id = ? AND dataset_id = ? AND version = ?`,
+		pinned, tenantID, datasetID, version,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrDatasetVersionNotFound
+	}
+	return nil
+}
+
+func (r *DatasetVersionRepo) ListDatasetsOverCount(ctx context.Context, keep int) ([]domain.DatasetKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT tenant_id, dataset_id FROM dataset_versions
+		 GROUP BY tenant_id, dataset_id HAVING COUNT(*) > ?`, keep,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []domain.DatasetKey
+	for rows.Next() {
+		var k domain.DatasetKey
+		if err := rows.Scan(&k.TenantID, &k.DatasetID); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r *DatasetVersionRepo) Delete(ctx context.Context, tenantID, datasetID string, version int) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM dataset_versions WHERE tenant_id = ? AND dataset_id = ? AND version = ?`,
+		tenantID, datasetID, version,
+	)
+	return err
+}
@@ -25,10 +25,14 @@ func (r *JobRepo) Create(ctx context.Context, job *domain.Job) error {
 	if kind == "" {
 		kind = domain.JobKindPipeline
 	}
+	policy := job.SchemaDriftPolicy
+	if policy == "" {
+		policy = "accept"
+	}
 	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO jobs (id, tenant_id, name, slug, description, kind, is_active, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
-		job.ID, job.TenantID, job.Name, job.Slug, job.Description, kind, isActive,
+		`INSERT INTO jobs (id, tenant_id, name, slug, description, kind, is_active, schema_drift_policy, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
+		job.ID, job.TenantID, job.Name, job.Slug, job.Description, kind, isActive, policy,
 	)
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
@@ -41,7 +45,7 @@ func (r *JobRepo) Create(ctx context.Context, job *domain.Job) error {
 
 func (r *JobRepo) FindByID(ctx context.Context, tenantID, id string) (*domain.Job, error) {
 	row := r.db.QueryRowContext(ctx,
-		`SELECT id, tenant_id, name, slug, description, kind, is_active, created_at, updated_at
+		`SELECT id, tenant_id, name, slug, description, kind, is_active, schema_drift_policy, created_at, updated_at
 		 FROM jobs WHERE tenant_id = ? AND id = ?`, tenantID, id,
 	)
 	return scanJob(row)
@@ -49,7 +53,7 @@ func (r *JobRepo) FindByID(ctx context.Context, tenantID, id string) (*domain.Jo
 
 func (r *JobRepo) ListByTenant(ctx context.Context, tenantID string) ([]domain.Job, error) {
 	rows, err := r.db.QueryContext(ctx,
-		`SELECT id, tenant_id, name, slug, description, kind, is_active, created_at, updated_at
+		`SELECT id, tenant_id, name, slug, description, kind, is_active, schema_drift_policy, created_at, updated_at
 		 FROM jobs WHERE tenant_id = ?
 		 ORDER BY created_at DESC`, tenantID,
 	)
@@ -62,7 +66,36 @@ func (r *JobRepo) ListByTenant(ctx context.Context, tenantID string) ([]domain.J
 	for rows.Next() {
 		var j domain.Job
 		var isActive int
-		if err := rows.Scan(&j.ID, &j.TenantID, &j.Name, &j.Slug, &j.Description, &j.Kind, &isActive, &j.CreatedAt, &j.UpdatedAt); err != nil {
+		if err := rows.Scan(&j.ID, &j.TenantID, &j.Name, &j.Slug, &j.Description, &j.Kind, &isActive, &j.SchemaDriftPolicy, &j.CreatedAt, &j.UpdatedAt); err != nil {
+			return nil, err
+		}
+		j.IsActive = isActive != 0
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *JobRepo) ListByInputDataset(ctx context.Context, tenantID, datasetID string) ([]domain.Job, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, tenant_id, name, slug, description, kind, is_active, schema_drift_policy, created_at, updated_at
+		 FROM jobs j WHERE tenant_id = ? AND EXISTS (
+		   SELECT 1 FROM job_versions v
+		   JOIN job_modules m ON m.job_version_id = v.id
+		   JOIN json_each(m.config_json, '$.dataset_ids') d
+		   WHERE v.job_id = j.id AND d.value = ?
+		 )
+		 ORDER BY name`, tenantID, datasetID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []domain.Job
+	for rows.Next() {
+		var j domain.Job
+		var isActive int
+		if err := rows.Scan(&j.ID, &j.TenantID, &j.Name, &j.Slug, &j.Description, &j.Kind, &isActive, &j.SchemaDriftPolicy, &j.CreatedAt, &j.UpdatedAt); err != nil {
 			return nil, err
 		}
 		j.IsActive = isActive != 0
@@ -77,9 +110,9 @@ func (r *JobRepo) Update(ctx context.Context, job *domain.Job) error {
 		isActive = 1
 	}
 	_, err := r.db.ExecContext(ctx,
-		`UPDATE jobs SET name = ?, slug = ?, description = ?, kind = ?, is_active = ?, updated_at = datetime('now')
+		`UPDATE jobs SET name = ?, slug = ?, description = ?, kind = ?, is_active = ?, schema_drift_policy = ?, updated_at = datetime('now')
 		 WHERE tenant_id = ? AND id = ?`,
-		job.Name, job.Slug, job.Description, job.Kind, isActive, job.TenantID, job.ID,
+		job.Name, job.Slug, job.Description, job.Kind, isActive, job.SchemaDriftPolicy, job.TenantID, job.ID,
 	)
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
@@ -93,7 +126,7 @@ func (r *JobRepo) Update(ctx context.Context, job *domain.Job) error {
 func scanJob(row *sql.Row) (*domain.Job, error) {
 	var j domain.Job
 	var isActive int
-	if err := row.Scan(&j.ID, &j.TenantID, &j.Name, &j.Slug, &j.Description, &j.Kind, &isActive, &j.CreatedAt, &j.UpdatedAt); err != nil {
+	if err := row.Scan(&j.ID, &j.TenantID, &j.Name, &j.Slug, &j.Description, &j.Kind, &isActive, &j.SchemaDriftPolicy, &j.CreatedAt, &j.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, domain.ErrJobNotFound
 		}
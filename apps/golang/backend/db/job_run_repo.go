@@ -28,7 +28,7 @@ func (r *JobRunRepo) FindByID(ctx context.Context, tenantID, id string) (*domain
 	row := r.db.QueryRowContext(ctx,
 		`SELECT id, tenant_id, job_id, job_version_id, status,
 		        run_snapshot_json, checkpoint_json, progress_json, attempt,
-		        next_run_at, last_error, started_at, finished_at,
+		        next_run_at, last_error, schema_drift_json, started_at, finished_at,
 		        created_at, updated_at
 		 FROM job_runs WHERE tenant_id = ? AND id = ?`, tenantID, id,
 	)
@@ -39,7 +39,7 @@ func (r *JobRunRepo) ListByTenant(ctx context.Context, tenantID string) ([]domai
 	rows, err := r.db.QueryContext(ctx,
 		`SELECT id, tenant_id, job_id, job_version_id, status,
 		        run_snapshot_json, checkpoint_json, progress_json, attempt,
-		        next_run_at, last_error, started_at, finished_at,
+		        next_run_at, last_error, schema_drift_json, started_at, finished_at,
 		        created_at, updated_at
 		 FROM job_runs WHERE tenant_id = ?
 		 ORDER BY created_at DESC`, tenantID,
@@ -55,7 +55,7 @@ func (r *JobRunRepo) ListByTenant(ctx context.Context, tenantID string) ([]domai
 		if err := rows.Scan(
 			&jr.ID, &jr.TenantID, &jr.JobID, &jr.JobVersionID, &jr.Status,
 			&jr.RunSnapshotJSON, &jr.CheckpointJSON, &jr.ProgressJSON, &jr.Attempt,
-			&jr.NextRunAt, &jr.LastError, &jr.StartedAt, &jr.FinishedAt,
+			&jr.NextRunAt, &jr.LastError, &jr.SchemaDriftJSON, &jr.StartedAt, &jr.FinishedAt,
 			&jr.CreatedAt, &jr.UpdatedAt,
 		); err != nil {
 			return nil, err
@@ -69,7 +69,7 @@ func (r *JobRunRepo) ListReady(ctx context.Context) ([]domain.JobRun, error) {
 	rows, err := r.db.QueryContext(ctx,
 		`SELECT id, tenant_id, job_id, job_version_id, status,
 		        run_snapshot_json, checkpoint_json, progress_json, attempt,
-		        next_run_at, last_error, started_at, finished_at,
+		        next_run_at, last_error, schema_drift_json, started_at, finished_at,
 		        created_at, updated_at
 		 FROM job_runs
 		 WHERE status = 'queued' AND (next_run_at IS NULL OR next_run_at <= datetime('now'))
@@ -86,7 +86,7 @@ func (r *JobRunRepo) ListReady(ctx context.Context) ([]domain.JobRun, error) {
 		if err := rows.Scan(
 			&jr.ID, &jr.TenantID, &jr.JobID, &jr.JobVersionID, &jr.Status,
 			&jr.RunSnapshotJSON, &jr.CheckpointJSON, &jr.ProgressJSON, &jr.Attempt,
-			&jr.NextRunAt, &jr.LastError, &jr.StartedAt, &jr.FinishedAt,
+			&jr.NextRunAt, &jr.LastError, &jr.SchemaDriftJSON, &jr.StartedAt, &jr.FinishedAt,
 			&jr.CreatedAt, &jr.UpdatedAt,
 		); err != nil {
 			return nil, err
@@ -120,12 +120,44 @@ func (r *JobRunRepo) UpdateFailed(ctx context.Context, id, lastError string) err
 	return err
 }
 
+func (r *JobRunRepo) UpdateSchemaDrift(ctx context.Context, id, driftJSON string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE job_runs SET schema_drift_json = ?, updated_at = datetime('now') WHERE id = ?`,
+		driftJSON, id,
+	)
+	return err
+}
+
+func (r *JobRunRepo) UpdateCheckpoint(ctx context.Context, id, checkpointJSON string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE job_runs SET checkpoint_json = ?, updated_at = datetime('now') WHERE id = ?`,
+		checkpointJSON, id,
+	)
+	return err
+}
+
+func (r *JobRunRepo) FindLatestCheckpoint(ctx context.Context, tenantID, jobID string) (*string, error) {
+	var checkpoint string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT checkpoint_json FROM job_runs
+		 WHERE tenant_id = ? AND job_id = ? AND status = 'success' AND checkpoint_json IS NOT NULL
+		 ORDER BY created_at DESC, rowid DESC LIMIT 1`, tenantID, jobID,
+	).Scan(&checkpoint)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
 func scanJobRun(row *sql.Row) (*domain.JobRun, error) {
 	var jr domain.JobRun
 	if err := row.Scan(
 		&jr.ID, &jr.TenantID, &jr.JobID, &jr.JobVersionID, &jr.Status,
 		&jr.RunSnapshotJSON, &jr.CheckpointJSON, &jr.ProgressJSON, &jr.Attempt,
-		&jr.NextRunAt, &jr.LastError, &jr.StartedAt, &jr.FinishedAt,
+		&jr.NextRunAt, &jr.LastError, &jr.SchemaDriftJSON, &jr.StartedAt, &jr.FinishedAt,
 		&jr.CreatedAt, &jr.UpdatedAt,
 	); err != nil {
 		if err == sql.ErrNoRows {
@@ -17,16 +17,16 @@ func NewJobVersionRepo(db DBTX) *JobVersionRepo {
 
 func (r *JobVersionRepo) Create(ctx context.Context, v *domain.JobVersion) error {
 	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO job_versions (id, tenant_id, job_id, version, status, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
-		v.ID, v.TenantID, v.JobID, v.Version, v.Status,
+		`INSERT INTO job_versions (id, tenant_id, job_id, version, status, created_by, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
+		v.ID, v.TenantID, v.JobID, v.Version, v.Status, v.CreatedBy,
 	)
 	return err
 }
 
 func (r *JobVersionRepo) FindByID(ctx context.Context, tenantID, id string) (*domain.JobVersion, error) {
 	row := r.db.QueryRowContext(ctx,
-		`SELECT id, tenant_id, job_id, version, status, published_at, created_at, updated_at
+		`SELECT id, tenant_id, job_id, version, status, created_by, published_at, created_at, updated_at
 		 FROM job_versions WHERE tenant_id = ? AND id = ?`, tenantID, id,
 	)
 	return scanJobVersion(row)
@@ -34,7 +34,7 @@ func (r *JobVersionRepo) FindByID(ctx context.Context, tenantID, id string) (*do
 
 func (r *JobVersionRepo) ListByJobID(ctx context.Context, tenantID, jobID string) ([]domain.JobVersion, error) {
 	rows, err := r.db.QueryContext(ctx,
-		`SELECT id, tenant_id, job_id, version, status, published_at, created_at, updated_at
+		`SELECT id, tenant_id, job_id, version, status, created_by, published_at, created_at, updated_at
 		 FROM job_versions WHERE tenant_id = ? AND job_id = ?
 		 ORDER BY version DESC`, tenantID, jobID,
 	)
@@ -46,7 +46,7 @@ func (r *JobVersionRepo) ListByJobID(ctx context.Context, tenantID, jobID string
 	var versions []domain.JobVersion
 	for rows.Next() {
 		var v domain.JobVersion
-		if err := rows.Scan(&v.ID, &v.TenantID, &v.JobID, &v.Version, &v.Status, &v.PublishedAt, &v.CreatedAt, &v.UpdatedAt); err != nil {
+		if err := rows.Scan(&v.ID, &v.TenantID, &v.JobID, &v.Version, &v.Status, &v.CreatedBy, &v.PublishedAt, &v.CreatedAt, &v.UpdatedAt); err != nil {
 			return nil, err
 		}
 		versions = append(versions, v)
@@ -78,7 +78,7 @@ func (r *JobVersionRepo) NextVersion(ctx context.Context, jobID string) (int, er
 
 func scanJobVersion(row *sql.Row) (*domain.JobVersion, error) {
 	var v domain.JobVersion
-	if err := row.Scan(&v.ID, &v.TenantID, &v.JobID, &v.Version, &v.Status, &v.PublishedAt, &v.CreatedAt, &v.UpdatedAt); err != nil {
+	if err := row.Scan(&v.ID, &v.TenantID, &v.JobID, &v.Version, &v.Status, &v.CreatedBy, &v.PublishedAt, &v.CreatedAt, &v.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, domain.ErrJobVersionNotFound
 		}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+)
+
+type LineageRepo struct {
+	db DBTX
+}
+
+func NewLineageRepo(db DBTX) *LineageRepo {
+	return &LineageRepo{db: db}
+}
+
+const lineageEdgeColumns = `id, tenant_id, source_type, source_id, target_type, target_id, job_run_id, created_at, updated_at`
+
+func scanLineageEdge(s interface{ Scan(...any) error }) (*domain.LineageEdge, error) {
+	var e domain.LineageEdge
+	if err := s.Scan(&e.ID, &e.TenantID, &e.Source.Type, &e.Source.ID, &e.Target.Type, &e.Target.ID,
+		&e.JobRunID, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *LineageRepo) Upsert(ctx context.Context, e *domain.LineageEdge) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO lineage_edges (id, tenant_id, source_type, source_id, target_type, target_id, job_run_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(tenant_id, source_type, source_id, target_type, target_id) DO UPDATE SET
+		   job_run_id = excluded.job_run_id,
+		   updated_at = datetime('now')`,
+		e.ID, e.TenantID, e.Source.Type, e.Source.ID, e.Target.Type, e.Target.ID, e.JobRunID,
+	)
+	return err
+}
+
+func (r *LineageRepo) ReplaceSources(ctx context.Context, tenantID string, target domain.LineageNode, edges []domain.LineageEdge) error {
+	query := `DELETE FROM lineage_edges WHERE tenant_id = ? AND target_type = ? AND target_id = ?`
+	args := []any{tenantID, target.Type, target.ID}
+	if len(edges) > 0 {
+		keep := make([]string, len(edges))
+		for i, e := range edges {
+			keep[i] = "(?, ?)"
+			args = append(args, e.Source.Type, e.Source.ID)
+		}
+		query += ` AND (source_type, source_id) NOT IN (VALUES ` + strings.Join(keep, ", ") + `)`
+	}
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	for i := range edges {
+		if err := r.Upsert(ctx, &edges[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *LineageRepo) ListBySources(ctx context.Context, tenantID string, sources []domain.LineageNode) ([]domain.LineageEdge, error) {
+	return r.listByNodes(ctx, tenantID, "source", sources)
+}
+
+func (r *LineageRepo) ListByTargets(ctx context.Context, tenantID string, targets []domain.LineageNode) ([]domain.LineageEdge, error) {
+	return r.listByNodes(ctx, tenantID, "target", targets)
+}
+
+// listByNodes lists the edges whose side ("source" or "target") is one of
+// nodes.
+func (r *LineageRepo) listByNodes(ctx context.Context, tenantID, side string, nodes []domain.LineageNode) ([]domain.LineageEdge, error) {
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	match := make([]string, len(nodes))
+	args := []any{tenantID}
+	for i, n := range nodes {
+		match[i] = "(?, ?)"
+		args = append(args, n.Type, n.ID)
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+lineageEdgeColumns+` FROM lineage_edges
+		 WHERE tenant_id = ? AND (`+side+`_type, `+side+`_id) IN (VALUES `+strings.Join(match, ", ")+`)
+		 ORDER BY created_at, id`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edges []domain.LineageEdge
+	for rows.Next() {
+		e, err := scanLineageEdge(rows)
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, *e)
+	}
+	return edges, rows.Err()
+}
+
+func (r *LineageRepo) DeleteNode(ctx context.Context, tenantID string, node domain.LineageNode) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM lineage_edges
+		 WHERE tenant_id = ? AND ((source_type = ? AND source_id = ?) OR (target_type = ? AND target_id = ?))`,
+		tenantID, node.Type, node.ID, node.Type, node.ID,
+	)
+	return err
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/user/micro-dp/domain"
+)
+
+func TestLineageRepo(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	createTestTenant(t, db, "t1")
+	createTestTenant(t, db, "t2")
+	repo := NewLineageRepo(db)
+
+	ds := func(id string) domain.LineageNode { return domain.LineageNode{Type: domain.LineageNodeDataset, ID: id} }
+	job := domain.LineageNode{Type: domain.LineageNodeJob, ID: "j1"}
+	edge := func(tenantID string, source, target domain.LineageNode) domain.LineageEdge {
+		return domain.LineageEdge{TenantID: tenantID, Source: source, Target: target}
+	}
+	for _, e := range []domain.LineageEdge{
+		edge("t1", ds("a"), job), edge("t1", ds("b"), job), edge("t1", job, ds("out")),
+		edge("t2", ds("a"), job),
+	} {
+		if err := repo.Upsert(ctx, &e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Upserting an existing edge keeps a single edge
+	runID := "run-2"
+	again := edge("t1", ds("a"), job)
+	again.JobRunID = &runID
+	if err := repo.Upsert(ctx, &again); err != nil {
+		t.Fatal(err)
+	}
+
+	edges, err := repo.ListByTargets(ctx, "t1", []domain.LineageNode{job, ds("out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edges) != 3 {
+		t.Fatalf("edges into j1 and out = %+v, want 3", edges)
+	}
+	for _, e := range edges {
+		if e.Source == ds("a") && (e.JobRunID == nil || *e.JobRunID != runID) {
+			t.Errorf("upserted edge = %+v, want a -> j1 of run-2", e)
+		}
+	}
+
+	// A job that now reads b and c only
+	if err := repo.ReplaceSources(ctx, "t1", job, []domain.LineageEdge{edge("t1", ds("b"), job), edge("t1", ds("c"), job)}); err != nil {
+		t.Fatal(err)
+	}
+	edges, err = repo.ListByTargets(ctx, "t1", []domain.LineageNode{job})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := map[string]bool{}
+	for _, e := range edges {
+		sources[e.Source.ID] = true
+	}
+	if len(edges) != 2 || !sources["b"] || !sources["c"] {
+		t.Errorf("sources of j1 = %v, want b and c", sources)
+	}
+	if edges, _ := repo.ListBySources(ctx, "t2", []domain.LineageNode{ds("a")}); len(edges) != 1 {
+		t.Errorf("other tenant's edges = %d, want 1 kept", len(edges))
+	}
+
+	if err := repo.DeleteNode(ctx, "t1", job); err != nil {
+		t.Fatal(err)
+	}
+	for _, list := range []func(context.Context, string, []domain.LineageNode) ([]domain.LineageEdge, error){repo.ListBySources, repo.ListByTargets} {
+		if edges, err := list(ctx, "t1", []domain.LineageNode{job}); err != nil || len(edges) != 0 {
+			t.Errorf("edges of deleted node = %d, %v, want none", len(edges), err)
+		}
+	}
+}
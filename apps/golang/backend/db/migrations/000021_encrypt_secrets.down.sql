@@ -0,0 +1,2 @@
+ALTER TABLE connections DROP COLUMN data_key;
+ALTER TABLE credentials DROP COLUMN data_key;
@@ -0,0 +1,2 @@
+ALTER TABLE credentials ADD COLUMN data_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE connections ADD COLUMN data_key TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE credentials ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
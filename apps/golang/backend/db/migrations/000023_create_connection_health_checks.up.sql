@@ -0,0 +1,12 @@
+CREATE TABLE connection_health_checks (
+    id            TEXT PRIMARY KEY,
+    tenant_id     TEXT NOT NULL REFERENCES tenants(id),
+    connection_id TEXT NOT NULL REFERENCES connections(id) ON DELETE CASCADE,
+    status        TEXT NOT NULL,
+    code          TEXT NOT NULL DEFAULT '',
+    message       TEXT NOT NULL DEFAULT '',
+    latency_ms    INTEGER NOT NULL DEFAULT 0,
+    checked_at    DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+CREATE INDEX idx_connection_health_checks_connection ON connection_health_checks(tenant_id, connection_id, checked_at);
+CREATE INDEX idx_connection_health_checks_checked_at ON connection_health_checks(checked_at);
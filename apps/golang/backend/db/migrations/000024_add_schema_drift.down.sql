@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS dataset_schema_changes;
+ALTER TABLE job_runs DROP COLUMN schema_drift_json;
+ALTER TABLE jobs DROP COLUMN schema_drift_policy;
@@ -0,0 +1,14 @@
+ALTER TABLE jobs ADD COLUMN schema_drift_policy TEXT NOT NULL DEFAULT 'accept';
+ALTER TABLE job_runs ADD COLUMN schema_drift_json TEXT;
+
+CREATE TABLE dataset_schema_changes (
+    id           TEXT PRIMARY KEY,
+    tenant_id    TEXT NOT NULL REFERENCES tenants(id),
+    dataset_id   TEXT NOT NULL REFERENCES datasets(id) ON DELETE CASCADE,
+    job_run_id   TEXT,
+    policy       TEXT NOT NULL,
+    action       TEXT NOT NULL,
+    changes_json TEXT NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+CREATE INDEX idx_dataset_schema_changes_dataset ON dataset_schema_changes(tenant_id, dataset_id, created_at);
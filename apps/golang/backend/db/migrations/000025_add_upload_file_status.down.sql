@@ -0,0 +1,20 @@
+-- dataset_id carries a foreign key, which SQLite cannot drop; recreate the table.
+DROP INDEX IF EXISTS idx_uploads_tenant_created;
+
+CREATE TABLE upload_files_old (
+    id           TEXT PRIMARY KEY,
+    tenant_id    TEXT NOT NULL REFERENCES tenants(id),
+    upload_id    TEXT NOT NULL REFERENCES uploads(id),
+    file_name    TEXT NOT NULL,
+    object_key   TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes   INTEGER NOT NULL DEFAULT 0,
+    created_at   DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+INSERT INTO upload_files_old
+SELECT id, tenant_id, upload_id, file_name, object_key, content_type, size_bytes, created_at FROM upload_files;
+DROP TABLE upload_files;
+ALTER TABLE upload_files_old RENAME TO upload_files;
+
+CREATE INDEX idx_upload_files_upload_id ON upload_files(upload_id);
@@ -0,0 +1,10 @@
+ALTER TABLE upload_files ADD COLUMN status TEXT NOT NULL DEFAULT 'pending'
+    CHECK(status IN ('pending', 'queued', 'processing', 'converted', 'failed', 'skipped'));
+ALTER TABLE upload_files ADD COLUMN error_message TEXT;
+ALTER TABLE upload_files ADD COLUMN row_count INTEGER;
+ALTER TABLE upload_files ADD COLUMN dataset_id TEXT REFERENCES datasets(id) ON DELETE SET NULL;
+ALTER TABLE upload_files ADD COLUMN datasets_json TEXT;
+ALTER TABLE upload_files ADD COLUMN started_at DATETIME;
+ALTER TABLE upload_files ADD COLUMN finished_at DATETIME;
+
+CREATE INDEX idx_uploads_tenant_created ON uploads(tenant_id, created_at);
@@ -0,0 +1 @@
+ALTER TABLE upload_files DROP COLUMN parse_options_json;
@@ -0,0 +1 @@
+ALTER TABLE upload_files ADD COLUMN parse_options_json TEXT;
@@ -0,0 +1 @@
+ALTER TABLE upload_files DROP COLUMN target_json;
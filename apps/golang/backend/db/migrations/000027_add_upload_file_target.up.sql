@@ -0,0 +1 @@
+ALTER TABLE upload_files ADD COLUMN target_json TEXT;
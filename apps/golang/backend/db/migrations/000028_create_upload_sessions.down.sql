@@ -0,0 +1,4 @@
+ALTER TABLE plans DROP COLUMN max_file_size_bytes;
+
+DROP INDEX IF EXISTS idx_upload_sessions_upload_id;
+DROP TABLE IF EXISTS upload_sessions;
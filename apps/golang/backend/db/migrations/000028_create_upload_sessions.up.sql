@@ -0,0 +1,21 @@
+-- Multipart upload sessions: one per file uploaded in parts, so clients can
+-- resume an interrupted upload.
+CREATE TABLE upload_sessions (
+    file_id         TEXT PRIMARY KEY REFERENCES upload_files(id),
+    tenant_id       TEXT NOT NULL REFERENCES tenants(id),
+    upload_id       TEXT NOT NULL REFERENCES uploads(id),
+    s3_upload_id    TEXT NOT NULL,
+    part_size_bytes INTEGER NOT NULL,
+    part_count      INTEGER NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'active' CHECK(status IN ('active', 'completed', 'aborted')),
+    created_at      DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at      DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+CREATE INDEX idx_upload_sessions_upload_id ON upload_sessions(upload_id);
+
+-- Per-file upload size limit; -1 = unlimited
+ALTER TABLE plans ADD COLUMN max_file_size_bytes BIGINT NOT NULL DEFAULT -1;
+
+UPDATE plans SET max_file_size_bytes = 104857600 WHERE id = 'plan-free-default';
+UPDATE plans SET max_file_size_bytes = 5368709120 WHERE id = 'plan-starter-default';
+UPDATE plans SET max_file_size_bytes = 53687091200 WHERE id = 'plan-pro-default';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_upload_fetches_upload_id;
+DROP TABLE IF EXISTS upload_fetches;
+
+ALTER TABLE upload_files DROP COLUMN source_url;
@@ -0,0 +1,17 @@
+-- URL imports: the file is fetched by the worker instead of uploaded.
+ALTER TABLE upload_files ADD COLUMN source_url TEXT;
+
+-- One fetch request per URL-imported file. auth_secret holds the sealed
+-- credentials and is cleared once the fetch has run.
+CREATE TABLE upload_fetches (
+    file_id        TEXT PRIMARY KEY REFERENCES upload_files(id),
+    tenant_id      TEXT NOT NULL REFERENCES tenants(id),
+    upload_id      TEXT NOT NULL REFERENCES uploads(id),
+    auth_type      TEXT NOT NULL DEFAULT 'none' CHECK(auth_type IN ('none', 'basic', 'bearer')),
+    auth_secret    TEXT NOT NULL DEFAULT '',
+    data_key       TEXT NOT NULL DEFAULT '',
+    checksum       TEXT,
+    max_size_bytes INTEGER NOT NULL DEFAULT -1,
+    created_at     DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+CREATE INDEX idx_upload_fetches_upload_id ON upload_fetches(upload_id);
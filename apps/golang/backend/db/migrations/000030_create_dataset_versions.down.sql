@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_dataset_versions_dataset;
+DROP TABLE IF EXISTS dataset_versions;
@@ -0,0 +1,23 @@
+-- Immutable version history of dataset data. object_keys_json lists the
+-- Parquet parts of a version whose storage_path is a prefix.
+CREATE TABLE dataset_versions (
+    id               TEXT PRIMARY KEY,
+    tenant_id        TEXT NOT NULL REFERENCES tenants(id),
+    dataset_id       TEXT NOT NULL REFERENCES datasets(id) ON DELETE CASCADE,
+    version          INTEGER NOT NULL,
+    storage_path     TEXT NOT NULL,
+    object_keys_json TEXT,
+    schema_json      TEXT,
+    row_count        INTEGER,
+    job_run_id       TEXT,
+    restored_from    INTEGER,
+    pinned           INTEGER NOT NULL DEFAULT 0,
+    created_at       DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(dataset_id, version)
+);
+CREATE INDEX idx_dataset_versions_dataset ON dataset_versions(tenant_id, dataset_id, created_at);
+
+-- The current data of existing datasets becomes their first version
+INSERT INTO dataset_versions (id, tenant_id, dataset_id, version, storage_path, schema_json, row_count, created_at)
+SELECT lower(hex(randomblob(16))), tenant_id, id, 1, storage_path, schema_json, row_count, COALESCE(last_updated_at, updated_at)
+FROM datasets WHERE storage_path <> '';
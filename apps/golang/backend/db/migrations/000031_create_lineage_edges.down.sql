@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_lineage_edges_target;
+DROP TABLE IF EXISTS lineage_edges;
@@ -0,0 +1,21 @@
+-- Lineage between connections, datasets, jobs and charts. Edges are
+-- recorded when runs complete and charts are saved; job_run_id is the
+-- latest run that produced the edge.
+CREATE TABLE lineage_edges (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL REFERENCES tenants(id),
+    source_type TEXT NOT NULL CHECK(source_type IN ('connection', 'dataset', 'job')),
+    source_id   TEXT NOT NULL,
+    target_type TEXT NOT NULL CHECK(target_type IN ('dataset', 'job', 'chart')),
+    target_id   TEXT NOT NULL,
+    job_run_id  TEXT,
+    created_at  DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at  DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(tenant_id, source_type, source_id, target_type, target_id)
+);
+CREATE INDEX idx_lineage_edges_target ON lineage_edges(tenant_id, target_type, target_id);
+
+-- Charts already reference their dataset
+INSERT INTO lineage_edges (id, tenant_id, source_type, source_id, target_type, target_id, created_at, updated_at)
+SELECT lower(hex(randomblob(16))), tenant_id, 'dataset', dataset_id, 'chart', id, created_at, updated_at
+FROM charts;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_datasets_deleted_at;
+ALTER TABLE datasets DROP COLUMN deleted_at;
@@ -0,0 +1,4 @@
+-- Soft-deleted datasets keep their row and storage until the restore
+-- window passes and the worker purges them.
+ALTER TABLE datasets ADD COLUMN deleted_at DATETIME;
+CREATE INDEX idx_datasets_deleted_at ON datasets(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_dataset_exports_dataset;
+DROP TABLE IF EXISTS dataset_exports;
@@ -0,0 +1,18 @@
+-- Asynchronous dataset exports. The worker writes the file to object_key
+-- and the API hands out presigned download links for it.
+CREATE TABLE dataset_exports (
+    id            TEXT PRIMARY KEY,
+    tenant_id     TEXT NOT NULL REFERENCES tenants(id),
+    dataset_id    TEXT NOT NULL REFERENCES datasets(id) ON DELETE CASCADE,
+    format        TEXT NOT NULL CHECK(format IN ('csv', 'jsonl', 'parquet')),
+    version       INTEGER,
+    query_json    TEXT NOT NULL DEFAULT '{}',
+    status        TEXT NOT NULL DEFAULT 'queued' CHECK(status IN ('queued', 'running', 'completed', 'failed')),
+    object_key    TEXT,
+    size_bytes    INTEGER,
+    error_message TEXT,
+    created_at    DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at    DATETIME NOT NULL DEFAULT (datetime('now')),
+    completed_at  DATETIME
+);
+CREATE INDEX idx_dataset_exports_dataset ON dataset_exports(tenant_id, dataset_id, created_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_data_quality_results_job_run;
+DROP INDEX IF EXISTS idx_data_quality_results_rule;
+DROP INDEX IF EXISTS idx_data_quality_results_dataset;
+DROP TABLE IF EXISTS data_quality_results;
+DROP TABLE IF EXISTS data_quality_rules;
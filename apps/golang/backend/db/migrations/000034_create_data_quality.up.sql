@@ -0,0 +1,34 @@
+-- Data quality rules attached to a dataset. config_json holds the
+-- parameters of the rule type (column, values, bounds, pattern, ...).
+CREATE TABLE data_quality_rules (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL REFERENCES tenants(id),
+    dataset_id  TEXT NOT NULL REFERENCES datasets(id) ON DELETE CASCADE,
+    name        TEXT NOT NULL,
+    type        TEXT NOT NULL CHECK(type IN ('not_null', 'unique', 'accepted_values', 'range', 'regex', 'row_count', 'freshness', 'referential', 'sql')),
+    config_json TEXT NOT NULL DEFAULT '{}',
+    severity    TEXT NOT NULL DEFAULT 'warn' CHECK(severity IN ('warn', 'fail')),
+    enabled     INTEGER NOT NULL DEFAULT 1,
+    created_at  DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at  DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(dataset_id, name)
+);
+
+-- Outcome of evaluating a rule against a version of its dataset, after the
+-- job run that wrote it or on demand (job_run_id NULL).
+CREATE TABLE data_quality_results (
+    id              TEXT PRIMARY KEY,
+    tenant_id       TEXT NOT NULL REFERENCES tenants(id),
+    dataset_id      TEXT NOT NULL REFERENCES datasets(id) ON DELETE CASCADE,
+    rule_id         TEXT NOT NULL REFERENCES data_quality_rules(id) ON DELETE CASCADE,
+    job_run_id      TEXT,
+    dataset_version INTEGER,
+    status          TEXT NOT NULL CHECK(status IN ('passed', 'failed', 'error')),
+    severity        TEXT NOT NULL CHECK(severity IN ('warn', 'fail')),
+    failing_rows    INTEGER,
+    message         TEXT,
+    created_at      DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+CREATE INDEX idx_data_quality_results_dataset ON data_quality_results(tenant_id, dataset_id, created_at);
+CREATE INDEX idx_data_quality_results_rule ON data_quality_results(rule_id, created_at);
+CREATE INDEX idx_data_quality_results_job_run ON data_quality_results(tenant_id, job_run_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_dataset_masking_policies_tenant;
+DROP TABLE IF EXISTS dataset_masking_policies;
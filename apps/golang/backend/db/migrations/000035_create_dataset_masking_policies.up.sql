@@ -0,0 +1,15 @@
+-- Masking applied to a dataset column for members whose role may not read
+-- personal data raw. Keyed by column name so that it outlives rewrites of
+-- the dataset's schema.
+CREATE TABLE dataset_masking_policies (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL REFERENCES tenants(id),
+    dataset_id  TEXT NOT NULL REFERENCES datasets(id) ON DELETE CASCADE,
+    column_name TEXT NOT NULL,
+    policy      TEXT NOT NULL CHECK(policy IN ('hash', 'redact', 'partial', 'null')),
+    created_at  DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at  DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(dataset_id, column_name)
+);
+
+CREATE INDEX idx_dataset_masking_policies_tenant ON dataset_masking_policies(tenant_id, dataset_id);
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_dataset_usage_events_created;
+DROP INDEX IF EXISTS idx_dataset_usage_events_dataset;
+DROP TABLE IF EXISTS dataset_usage_events;
+DROP INDEX IF EXISTS idx_glossary_term_columns_dataset;
+DROP TABLE IF EXISTS glossary_term_columns;
+DROP TABLE IF EXISTS glossary_terms;
+DROP INDEX IF EXISTS idx_dataset_certifications_tenant;
+DROP TABLE IF EXISTS dataset_certifications;
+DROP INDEX IF EXISTS idx_dataset_stewards_user;
+DROP TABLE IF EXISTS dataset_stewards;
+DROP TRIGGER IF EXISTS datasets_search_delete;
+DROP TRIGGER IF EXISTS datasets_search_update;
+DROP TRIGGER IF EXISTS datasets_search_insert;
+DROP VIEW IF EXISTS dataset_search_documents;
+DROP TABLE IF EXISTS dataset_search;
@@ -0,0 +1,103 @@
+-- Full-text index of the catalog: dataset names and the names,
+-- descriptions and tags of their columns. Kept in sync with datasets by
+-- the triggers below; soft-deleted datasets stay indexed and are filtered
+-- out by the listing.
+CREATE VIRTUAL TABLE dataset_search USING fts5(
+    dataset_id UNINDEXED,
+    tenant_id UNINDEXED,
+    name,
+    columns,
+    descriptions,
+    tags
+);
+
+CREATE VIEW dataset_search_documents AS
+SELECT
+    d.id AS dataset_id,
+    d.tenant_id,
+    d.name,
+    (SELECT group_concat(json_extract(c.value, '$.column_name'), ' ')
+     FROM json_each(CASE WHEN json_valid(d.schema_json) THEN d.schema_json END) c) AS columns,
+    (SELECT group_concat(json_extract(c.value, '$.description'), ' ')
+     FROM json_each(CASE WHEN json_valid(d.schema_json) THEN d.schema_json END) c) AS descriptions,
+    (SELECT group_concat(t.value, ' ')
+     FROM json_each(CASE WHEN json_valid(d.schema_json) THEN d.schema_json END) c,
+          json_each(c.value, '$.tags') t) AS tags
+FROM datasets d;
+
+INSERT INTO dataset_search (dataset_id, tenant_id, name, columns, descriptions, tags)
+SELECT dataset_id, tenant_id, name, columns, descriptions, tags FROM dataset_search_documents;
+
+CREATE TRIGGER datasets_search_insert AFTER INSERT ON datasets BEGIN
+    INSERT INTO dataset_search (dataset_id, tenant_id, name, columns, descriptions, tags)
+    SELECT dataset_id, tenant_id, name, columns, descriptions, tags
+    FROM dataset_search_documents WHERE dataset_id = new.id;
+END;
+
+CREATE TRIGGER datasets_search_update AFTER UPDATE OF name, schema_json ON datasets BEGIN
+    DELETE FROM dataset_search WHERE dataset_id = old.id;
+    INSERT INTO dataset_search (dataset_id, tenant_id, name, columns, descriptions, tags)
+    SELECT dataset_id, tenant_id, name, columns, descriptions, tags
+    FROM dataset_search_documents WHERE dataset_id = new.id;
+END;
+
+CREATE TRIGGER datasets_search_delete AFTER DELETE ON datasets BEGIN
+    DELETE FROM dataset_search WHERE dataset_id = old.id;
+END;
+
+-- Users accountable for a dataset: its owners and the stewards who curate
+-- its metadata.
+CREATE TABLE dataset_stewards (
+    dataset_id TEXT NOT NULL REFERENCES datasets(id) ON DELETE CASCADE,
+    user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tenant_id  TEXT NOT NULL REFERENCES tenants(id),
+    role       TEXT NOT NULL CHECK(role IN ('owner', 'steward')),
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (dataset_id, user_id)
+);
+CREATE INDEX idx_dataset_stewards_user ON dataset_stewards(tenant_id, user_id);
+
+-- Certification badge of a dataset: certified as trusted or deprecated.
+CREATE TABLE dataset_certifications (
+    dataset_id   TEXT PRIMARY KEY REFERENCES datasets(id) ON DELETE CASCADE,
+    tenant_id    TEXT NOT NULL REFERENCES tenants(id),
+    status       TEXT NOT NULL CHECK(status IN ('certified', 'deprecated')),
+    note         TEXT NOT NULL DEFAULT '',
+    certified_by TEXT NOT NULL,
+    certified_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+CREATE INDEX idx_dataset_certifications_tenant ON dataset_certifications(tenant_id, status);
+
+-- Business glossary of a tenant and the dataset columns each term describes.
+CREATE TABLE glossary_terms (
+    id         TEXT PRIMARY KEY,
+    tenant_id  TEXT NOT NULL REFERENCES tenants(id),
+    name       TEXT NOT NULL,
+    definition TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(tenant_id, name)
+);
+
+CREATE TABLE glossary_term_columns (
+    term_id     TEXT NOT NULL REFERENCES glossary_terms(id) ON DELETE CASCADE,
+    dataset_id  TEXT NOT NULL REFERENCES datasets(id) ON DELETE CASCADE,
+    tenant_id   TEXT NOT NULL REFERENCES tenants(id),
+    column_name TEXT NOT NULL,
+    created_at  DATETIME NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (term_id, dataset_id, column_name)
+);
+CREATE INDEX idx_glossary_term_columns_dataset ON glossary_term_columns(tenant_id, dataset_id);
+
+-- Reads of a dataset by chart data and preview queries, counted by the
+-- catalog over a trailing window. subject_id is the chart read for.
+CREATE TABLE dataset_usage_events (
+    id         TEXT PRIMARY KEY,
+    tenant_id  TEXT NOT NULL REFERENCES tenants(id),
+    dataset_id TEXT NOT NULL REFERENCES datasets(id) ON DELETE CASCADE,
+    kind       TEXT NOT NULL CHECK(kind IN ('chart', 'preview')),
+    subject_id TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+CREATE INDEX idx_dataset_usage_events_dataset ON dataset_usage_events(tenant_id, dataset_id, created_at);
+CREATE INDEX idx_dataset_usage_events_created ON dataset_usage_events(created_at);
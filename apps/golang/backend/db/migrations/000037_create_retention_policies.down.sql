@@ -0,0 +1,7 @@
+ALTER TABLE plans DROP COLUMN max_retention_days;
+
+DROP INDEX IF EXISTS idx_retention_purges_tenant;
+DROP TABLE IF EXISTS retention_purges;
+DROP INDEX IF EXISTS idx_retention_policies_dataset;
+DROP INDEX IF EXISTS idx_retention_policies_tenant;
+DROP TABLE IF EXISTS retention_policies;
@@ -0,0 +1,38 @@
+-- Retention of a tenant's data. A row without dataset_id is the tenant's
+-- policy: it sweeps the raw dt= partitions and is the default for the
+-- tenant's datasets. A dataset's own policy overrides it field by field.
+CREATE TABLE retention_policies (
+    id            TEXT PRIMARY KEY,
+    tenant_id     TEXT NOT NULL REFERENCES tenants(id),
+    dataset_id    TEXT REFERENCES datasets(id) ON DELETE CASCADE,
+    keep_days     INTEGER CHECK(keep_days > 0),
+    keep_versions INTEGER CHECK(keep_versions > 0),
+    archive       INTEGER NOT NULL DEFAULT 0,
+    created_at    DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at    DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE UNIQUE INDEX idx_retention_policies_tenant ON retention_policies(tenant_id) WHERE dataset_id IS NULL;
+CREATE UNIQUE INDEX idx_retention_policies_dataset ON retention_policies(tenant_id, dataset_id) WHERE dataset_id IS NOT NULL;
+
+-- Audit log of what the retention sweeper deleted or archived. Not tied to
+-- the dataset row so that it outlives the dataset.
+CREATE TABLE retention_purges (
+    id           TEXT PRIMARY KEY,
+    tenant_id    TEXT NOT NULL REFERENCES tenants(id),
+    dataset_id   TEXT,
+    kind         TEXT NOT NULL CHECK(kind IN ('partition', 'version')),
+    target       TEXT NOT NULL,
+    action       TEXT NOT NULL CHECK(action IN ('deleted', 'archived')),
+    object_count INTEGER NOT NULL DEFAULT 0,
+    bytes        BIGINT NOT NULL DEFAULT 0,
+    created_at   DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX idx_retention_purges_tenant ON retention_purges(tenant_id, created_at);
+
+-- Longest retention a plan allows, in days; -1 = unlimited
+ALTER TABLE plans ADD COLUMN max_retention_days INTEGER NOT NULL DEFAULT -1;
+
+UPDATE plans SET max_retention_days = 30 WHERE id = 'plan-free-default';
+UPDATE plans SET max_retention_days = 365 WHERE id = 'plan-starter-default';
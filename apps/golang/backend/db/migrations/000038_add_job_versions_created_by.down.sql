@@ -0,0 +1 @@
+ALTER TABLE job_versions DROP COLUMN created_by;
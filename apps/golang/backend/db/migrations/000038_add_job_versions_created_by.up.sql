@@ -0,0 +1,3 @@
+-- The user who created a version, whose masking applies to the datasets its
+-- transform reads. NULL for versions created before it was recorded.
+ALTER TABLE job_versions ADD COLUMN created_by TEXT REFERENCES users(id);
@@ -21,7 +21,7 @@ func scanPlan(s interface{ Scan(...any) error }) (*domain.Plan, error) {
 	var p domain.Plan
 	if err := s.Scan(
 		&p.ID, &p.Name, &p.DisplayName,
-		&p.MaxEventsPerDay, &p.MaxStorageBytes, &p.MaxRowsPerDay, &p.MaxUploadsPerDay,
+		&p.MaxEventsPerDay, &p.MaxStorageBytes, &p.MaxRowsPerDay, &p.MaxUploadsPerDay, &p.MaxFileSizeBytes, &p.MaxRetentionDays,
 		&p.IsDefault, &p.CreatedAt, &p.UpdatedAt,
 	); err != nil {
 		return nil, err
@@ -29,7 +29,7 @@ func scanPlan(s interface{ Scan(...any) error }) (*domain.Plan, error) {
 	return &p, nil
 }
 
-const planColumns = `id, name, display_name, max_events_per_day, max_storage_bytes, max_rows_per_day, max_uploads_per_day, is_default, created_at, updated_at`
+const planColumns = `id, name, display_name, max_events_per_day, max_storage_bytes, max_rows_per_day, max_uploads_per_day, max_file_size_bytes, max_retention_days, is_default, created_at, updated_at`
 
 func (r *PlanRepo) FindByID(ctx context.Context, id string) (*domain.Plan, error) {
 	row := r.db.QueryRowContext(ctx,
@@ -95,18 +95,18 @@ func (r *PlanRepo) ListAll(ctx context.Context) ([]domain.Plan, error) {
 
 func (r *PlanRepo) Create(ctx context.Context, p *domain.Plan) error {
 	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO plans (id, name, display_name, max_events_per_day, max_storage_bytes, max_rows_per_day, max_uploads_per_day, is_default, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
-		p.ID, p.Name, p.DisplayName, p.MaxEventsPerDay, p.MaxStorageBytes, p.MaxRowsPerDay, p.MaxUploadsPerDay, p.IsDefault,
+		`INSERT INTO plans (id, name, display_name, max_events_per_day, max_storage_bytes, max_rows_per_day, max_uploads_per_day, max_file_size_bytes, max_retention_days, is_default, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
+		p.ID, p.Name, p.DisplayName, p.MaxEventsPerDay, p.MaxStorageBytes, p.MaxRowsPerDay, p.MaxUploadsPerDay, p.MaxFileSizeBytes, p.MaxRetentionDays, p.IsDefault,
 	)
 	return err
 }
 
 func (r *PlanRepo) Update(ctx context.Context, p *domain.Plan) error {
 	_, err := r.db.ExecContext(ctx,
-		`UPDATE plans SET name = ?, display_name = ?, max_events_per_day = ?, max_storage_bytes = ?, max_rows_per_day = ?, max_uploads_per_day = ?, is_default = ?, updated_at = datetime('now')
+		`UPDATE plans SET name = ?, display_name = ?, max_events_per_day = ?, max_storage_bytes = ?, max_rows_per_day = ?, max_uploads_per_day = ?, max_file_size_bytes = ?, max_retention_days = ?, is_default = ?, updated_at = datetime('now')
 		 WHERE id = ?`,
-		p.Name, p.DisplayName, p.MaxEventsPerDay, p.MaxStorageBytes, p.MaxRowsPerDay, p.MaxUploadsPerDay, p.IsDefault, p.ID,
+		p.Name, p.DisplayName, p.MaxEventsPerDay, p.MaxStorageBytes, p.MaxRowsPerDay, p.MaxUploadsPerDay, p.MaxFileSizeBytes, p.MaxRetentionDays, p.IsDefault, p.ID,
 	)
 	return err
 }
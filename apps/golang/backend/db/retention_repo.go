@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/user/micro-dp/domain"
+)
+
+type RetentionPolicyRepo struct {
+	db DBTX
+}
+
+func NewRetentionPolicyRepo(db DBTX) *RetentionPolicyRepo {
+	return &RetentionPolicyRepo{db: db}
+}
+
+const retentionPolicyColumns = `id, tenant_id, dataset_id, keep_days, keep_versions, archive, created_at, updated_at`
+
+func scanRetentionPolicy(s interface{ Scan(...any) error }) (*domain.RetentionPolicy, error) {
+	var p domain.RetentionPolicy
+	if err := s.Scan(&p.ID, &p.TenantID, &p.DatasetID, &p.KeepDays, &p.KeepVersions, &p.Archive, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *RetentionPolicyRepo) Find(ctx context.Context, tenantID, datasetID string) (*domain.RetentionPolicy, error) {
+	query := `SELECT ` + retentionPolicyColumns + ` FROM retention_policies WHERE tenant_id = ? AND dataset_id IS NULL`
+	args := []any{tenantID}
+	if datasetID != "" {
+		query = `SELECT ` + retentionPolicyColumns + ` FROM retention_policies WHERE tenant_id = ? AND dataset_id = ?`
+		args = append(args, datasetID)
+	}
+	p, err := scanRetentionPolicy(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrRetentionPolicyNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *RetentionPolicyRepo) ListByTenant(ctx context.Context, tenantID string) ([]domain.RetentionPolicy, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+retentionPolicyColumns+` FROM retention_policies
+		 WHERE tenant_id = ? ORDER BY dataset_id IS NOT NULL, dataset_id`,
+		tenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []domain.RetentionPolicy
+	for rows.Next() {
+		p, err := scanRetentionPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *p)
+	}
+	return policies, rows.Err()
+}
+
+func (r *RetentionPolicyRepo) Upsert(ctx context.Context, p *domain.RetentionPolicy) error {
+	conflict := `ON CONFLICT(tenant_id) WHERE dataset_id IS NULL`
+	if p.DatasetID != nil {
+		conflict = `ON CONFLICT(tenant_id, dataset_id) WHERE dataset_id IS NOT NULL`
+	}
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO retention_policies (id, tenant_id, dataset_id, keep_days, keep_versions, archive, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
+		 `+conflict+` DO UPDATE SET
+		   keep_days = excluded.keep_days,
+		   keep_versions = excluded.keep_versions,
+		   archive = excluded.archive,
+		   updated_at = datetime('now')`,
+		p.ID, p.TenantID, p.DatasetID, p.KeepDays, p.KeepVersions, p.Archive,
+	)
+	return err
+}
+
+func (r *RetentionPolicyRepo) Delete(ctx context.Context, tenantID, datasetID string) error {
+	query := `DELETE FROM retention_policies WHERE tenant_id = ? AND dataset_id IS NULL`
+	args := []any{tenantID}
+	if datasetID != "" {
+		query = `DELETE FROM retention_policies WHERE tenant_id = ? AND dataset_id = ?`
+		args = append(args, datasetID)
+	}
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrRetentionPolicyNotFound
+	}
+	return nil
+}
+
+type RetentionPurgeRepo struct {
+	db DBTX
+}
+
+func NewRetentionPurgeRepo(db DBTX) *RetentionPurgeRepo {
+	return &RetentionPurgeRepo{db: db}
+}
+
+func (r *RetentionPurgeRepo) Create(ctx context.Context, p *domain.RetentionPurge) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO retention_purges (id, tenant_id, dataset_id, kind, target, action, object_count, bytes, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
+		p.ID, p.TenantID, p.DatasetID, p.Kind, p.Target, p.Action, p.ObjectCount, p.Bytes,
+	)
+	return err
+}
+
+func (r *RetentionPurgeRepo) ListByTenant(ctx context.Context, tenantID, datasetID string, limit int) ([]domain.RetentionPurge, error) {
+	query := `SELECT id, tenant_id, dataset_id, kind, target, action, object_count, bytes, created_at
+		 FROM retention_purges WHERE tenant_id = ?`
+	args := []any{tenantID}
+	if datasetID != "" {
+		query += ` AND dataset_id = ?`
+		args = append(args, datasetID)
+	}
+	query += ` ORDER BY created_at DESC, rowid DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var purges []domain.RetentionPurge
+	for rows.Next() {
+		var p domain.RetentionPurge
+		if err := rows.Scan(&p.ID, &p.TenantID, &p.DatasetID, &p.Kind, &p.Target, &p.Action, &p.ObjectCount, &p.Bytes, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		purges = append(purges, p)
+	}
+	return purges, rows.Err()
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/micro-dp/domain"
+)
+
+// openTestDB returns a migrated database in a temporary file.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "test.db"))
+	db, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// createTestTenant inserts an active tenant.
+func createTestTenant(t *testing.T, db *sql.DB, id string) {
+	t.Helper()
+	if err := NewTenantRepo(db).Create(context.Background(), &domain.Tenant{ID: id, Name: id, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createTestDataset inserts a dataset imported into datasets/<tenant>/<name>/.
+func createTestDataset(t *testing.T, db *sql.DB, tenantID, id, name string) *domain.Dataset {
+	t.Helper()
+	d := &domain.Dataset{ID: id, TenantID: tenantID, Name: name, SourceType: "import", StoragePath: "datasets/" + tenantID + "/" + name + "/"}
+	if err := NewDatasetRepo(db).Create(context.Background(), d); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
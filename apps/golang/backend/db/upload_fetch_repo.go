@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/user/micro-dp/domain"
+)
+
+type UploadFetchRepo struct {
+	db DBTX
+}
+
+func NewUploadFetchRepo(db DBTX) *UploadFetchRepo {
+	return &UploadFetchRepo{db: db}
+}
+
+func (r *UploadFetchRepo) Create(ctx context.Context, f *domain.UploadFetch) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO upload_fetches (file_id, tenant_id, upload_id, auth_type, auth_secret, data_key, checksum, max_size_bytes, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
+		f.FileID, f.TenantID, f.UploadID, f.AuthType, f.AuthSecret, f.DataKey, f.Checksum, f.MaxSizeBytes,
+	)
+	return err
+}
+
+func (r *UploadFetchRepo) FindByFileID(ctx context.Context, tenantID, fileID string) (*domain.UploadFetch, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT file_id, tenant_id, upload_id, auth_type, auth_secret, data_key, checksum, max_size_bytes, created_at
+		 FROM upload_fetches WHERE tenant_id = ? AND file_id = ?`, tenantID, fileID,
+	)
+	var f domain.UploadFetch
+	if err := row.Scan(&f.FileID, &f.TenantID, &f.UploadID, &f.AuthType, &f.AuthSecret, &f.DataKey,
+		&f.Checksum, &f.MaxSizeBytes, &f.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrUploadFetchNotFound
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *UploadFetchRepo) ClearAuth(ctx context.Context, tenantID, fileID string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE upload_fetches SET auth_secret = '', data_key = ''
+		 WHERE tenant_id = ? AND file_id = ?`,
+		tenantID, fileID,
+	)
+	return err
+}
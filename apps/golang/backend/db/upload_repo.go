@@ -23,9 +23,13 @@ func scanUpload(s interface{ Scan(...any) error }) (*domain.Upload, error) {
 	return &u, nil
 }
 
+const uploadFileColumns = `id, tenant_id, upload_id, file_name, object_key, content_type, size_bytes, created_at,
+	parse_options_json, target_json, source_url, status, error_message, row_count, dataset_id, datasets_json, started_at, finished_at`
+
 func scanUploadFile(s interface{ Scan(...any) error }) (*domain.UploadFile, error) {
 	var f domain.UploadFile
-	if err := s.Scan(&f.ID, &f.TenantID, &f.UploadID, &f.FileName, &f.ObjectKey, &f.ContentType, &f.SizeBytes, &f.CreatedAt); err != nil {
+	if err := s.Scan(&f.ID, &f.TenantID, &f.UploadID, &f.FileName, &f.ObjectKey, &f.ContentType, &f.SizeBytes, &f.CreatedAt,
+		&f.ParseOptionsJSON, &f.TargetJSON, &f.SourceURL, &f.Status, &f.ErrorMessage, &f.RowCount, &f.DatasetID, &f.DatasetsJSON, &f.StartedAt, &f.FinishedAt); err != nil {
 		return nil, err
 	}
 	return &f, nil
@@ -42,9 +46,9 @@ func (r *UploadRepo) CreateUpload(ctx context.Context, u *domain.Upload) error {
 
 func (r *UploadRepo) CreateUploadFile(ctx context.Context, f *domain.UploadFile) error {
 	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO upload_files (id, tenant_id, upload_id, file_name, object_key, content_type, size_bytes, created_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
-		f.ID, f.TenantID, f.UploadID, f.FileName, f.ObjectKey, f.ContentType, f.SizeBytes,
+		`INSERT INTO upload_files (id, tenant_id, upload_id, file_name, object_key, content_type, size_bytes, parse_options_json, target_json, source_url, status, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
+		f.ID, f.TenantID, f.UploadID, f.FileName, f.ObjectKey, f.ContentType, f.SizeBytes, f.ParseOptionsJSON, f.TargetJSON, f.SourceURL, f.Status,
 	)
 	return err
 }
@@ -66,8 +70,9 @@ func (r *UploadRepo) FindByID(ctx context.Context, tenantID, id string) (*domain
 
 func (r *UploadRepo) FindFilesByUploadID(ctx context.Context, tenantID, uploadID string) ([]domain.UploadFile, error) {
 	rows, err := r.db.QueryContext(ctx,
-		`SELECT id, tenant_id, upload_id, file_name, object_key, content_type, size_bytes, created_at
-		 FROM upload_files WHERE tenant_id = ? AND upload_id = ?`, tenantID, uploadID,
+		`SELECT `+uploadFileColumns+`
+		 FROM upload_files WHERE tenant_id = ? AND upload_id = ?
+		 ORDER BY created_at, rowid`, tenantID, uploadID,
 	)
 	if err != nil {
 		return nil, err
@@ -85,6 +90,21 @@ func (r *UploadRepo) FindFilesByUploadID(ctx context.Context, tenantID, uploadID
 	return files, rows.Err()
 }
 
+func (r *UploadRepo) FindFileByID(ctx context.Context, tenantID, uploadID, fileID string) (*domain.UploadFile, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+uploadFileColumns+`
+		 FROM upload_files WHERE tenant_id = ? AND upload_id = ? AND id = ?`, tenantID, uploadID, fileID,
+	)
+	f, err := scanUploadFile(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrUploadFileNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
 func (r *UploadRepo) UpdateStatus(ctx context.Context, tenantID, id, status string) error {
 	_, err := r.db.ExecContext(ctx,
 		`UPDATE uploads SET status = ?, updated_at = datetime('now')
@@ -93,3 +113,96 @@ func (r *UploadRepo) UpdateStatus(ctx context.Context, tenantID, id, status stri
 	)
 	return err
 }
+
+func (r *UploadRepo) ListByTenant(ctx context.Context, tenantID string, filter domain.UploadListFilter) ([]domain.Upload, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, tenant_id, status, created_at, updated_at
+		 FROM uploads WHERE tenant_id = ?
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT ? OFFSET ?`, tenantID, limit, filter.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uploads []domain.Upload
+	for rows.Next() {
+		u, err := scanUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, *u)
+	}
+	return uploads, rows.Err()
+}
+
+func (r *UploadRepo) UpdateFilesStatus(ctx context.Context, tenantID, uploadID, status string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE upload_files SET status = ?, error_message = NULL, row_count = NULL, dataset_id = NULL,
+		   datasets_json = NULL, started_at = NULL, finished_at = NULL
+		 WHERE tenant_id = ? AND upload_id = ?`,
+		status, tenantID, uploadID,
+	)
+	return err
+}
+
+func (r *UploadRepo) UpdateFileParseOptions(ctx context.Context, tenantID, fileID string, optionsJSON *string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE upload_files SET parse_options_json = ?
+		 WHERE tenant_id = ? AND id = ?`,
+		optionsJSON, tenantID, fileID,
+	)
+	return err
+}
+
+func (r *UploadRepo) UpdateFileTarget(ctx context.Context, tenantID, fileID string, targetJSON *string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE upload_files SET target_json = ?
+		 WHERE tenant_id = ? AND id = ?`,
+		targetJSON, tenantID, fileID,
+	)
+	return err
+}
+
+func (r *UploadRepo) UpdateFileStatus(ctx context.Context, tenantID, fileID, status string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE upload_files SET status = ?, error_message = NULL, row_count = NULL, dataset_id = NULL,
+		   datasets_json = NULL, started_at = NULL, finished_at = NULL
+		 WHERE tenant_id = ? AND id = ?`,
+		status, tenantID, fileID,
+	)
+	return err
+}
+
+func (r *UploadRepo) UpdateFileStarted(ctx context.Context, tenantID, fileID string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE upload_files SET status = ?, started_at = datetime('now'), finished_at = NULL
+		 WHERE tenant_id = ? AND id = ?`,
+		domain.UploadFileStatusProcessing, tenantID, fileID,
+	)
+	return err
+}
+
+func (r *UploadRepo) UpdateFileResult(ctx context.Context, f *domain.UploadFile) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE upload_files SET status = ?, error_message = ?, row_count = ?, dataset_id = ?,
+		   datasets_json = ?, finished_at = datetime('now')
+		 WHERE tenant_id = ? AND id = ?`,
+		f.Status, f.ErrorMessage, f.RowCount, f.DatasetID, f.DatasetsJSON, f.TenantID, f.ID,
+	)
+	return err
+}
+
+func (r *UploadRepo) UpdateFileFetched(ctx context.Context, f *domain.UploadFile) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE upload_files SET file_name = ?, object_key = ?, content_type = ?, size_bytes = ?
+		 WHERE tenant_id = ? AND id = ?`,
+		f.FileName, f.ObjectKey, f.ContentType, f.SizeBytes, f.TenantID, f.ID,
+	)
+	return err
+}
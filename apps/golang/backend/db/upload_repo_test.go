@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/user/micro-dp/domain"
+)
+
+func TestUploadFileStatusTransitions(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	createTestTenant(t, db, "t1")
+	createTestDataset(t, db, "t1", "d2", "f2")
+	repo := NewUploadRepo(db)
+
+	if err := repo.CreateUpload(ctx, &domain.Upload{ID: "u1", TenantID: "t1", Status: domain.UploadStatusPresigned}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"f1", "f2"} {
+		f := &domain.UploadFile{ID: id, TenantID: "t1", UploadID: "u1", FileName: id + ".csv", ObjectKey: "uploads/t1/" + id,
+			ContentType: "text/csv", SizeBytes: 10, Status: domain.UploadFileStatusPending}
+		if err := repo.CreateUploadFile(ctx, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := func(id string) *domain.UploadFile {
+		t.Helper()
+		f, err := repo.FindFileByID(ctx, "t1", "u1", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	if got := file("f1").Status; got != domain.UploadFileStatusPending {
+		t.Fatalf("new file status = %s, want pending", got)
+	}
+
+	// Completing the upload queues every file
+	if err := repo.UpdateFilesStatus(ctx, "t1", "u1", domain.UploadFileStatusQueued); err != nil {
+		t.Fatal(err)
+	}
+	files, err := repo.FindFilesByUploadID(ctx, "t1", "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if f.Status != domain.UploadFileStatusQueued {
+			t.Errorf("file %s status = %s, want queued", f.ID, f.Status)
+		}
+	}
+
+	// f1 fails, f2 converts
+	if err := repo.UpdateFileStarted(ctx, "t1", "f1"); err != nil {
+		t.Fatal(err)
+	}
+	f1 := file("f1")
+	if f1.Status != domain.UploadFileStatusProcessing || f1.StartedAt == nil || f1.FinishedAt != nil {
+		t.Errorf("started file = %s started=%v finished=%v, want processing with only started_at", f1.Status, f1.StartedAt, f1.FinishedAt)
+	}
+	errMsg := "parse csv: unterminated quote"
+	if err := repo.UpdateFileResult(ctx, &domain.UploadFile{ID: "f1", TenantID: "t1", Status: domain.UploadFileStatusFailed, ErrorMessage: &errMsg}); err != nil {
+		t.Fatal(err)
+	}
+	f1 = file("f1")
+	if f1.Status != domain.UploadFileStatusFailed || f1.ErrorMessage == nil || *f1.ErrorMessage != errMsg || f1.FinishedAt == nil {
+		t.Errorf("failed file = %s %v finished=%v, want failed with the error", f1.Status, f1.ErrorMessage, f1.FinishedAt)
+	}
+
+	rows, datasetID, datasetsJSON := int64(3), "d2", `[{"dataset_id":"d2","dataset_name":"f2","row_count":3}]`
+	if err := repo.UpdateFileStarted(ctx, "t1", "f2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateFileResult(ctx, &domain.UploadFile{ID: "f2", TenantID: "t1", Status: domain.UploadFileStatusConverted,
+		RowCount: &rows, DatasetID: &datasetID, DatasetsJSON: &datasetsJSON}); err != nil {
+		t.Fatal(err)
+	}
+	f2 := file("f2")
+	if f2.Status != domain.UploadFileStatusConverted || f2.ErrorMessage != nil || f2.RowCount == nil || *f2.RowCount != 3 ||
+		f2.DatasetID == nil || *f2.DatasetID != "d2" || f2.DatasetsJSON == nil || *f2.DatasetsJSON != datasetsJSON {
+		t.Errorf("converted file = %+v, want converted with its dataset", f2)
+	}
+
+	// Re-importing f1 clears its previous result but leaves f2 alone
+	if err := repo.UpdateFileStatus(ctx, "t1", "f1", domain.UploadFileStatusQueued); err != nil {
+		t.Fatal(err)
+	}
+	f1 = file("f1")
+	if f1.Status != domain.UploadFileStatusQueued || f1.ErrorMessage != nil || f1.StartedAt != nil || f1.FinishedAt != nil {
+		t.Errorf("requeued file = %s %v started=%v finished=%v, want queued with results cleared", f1.Status, f1.ErrorMessage, f1.StartedAt, f1.FinishedAt)
+	}
+	if f2 := file("f2"); f2.Status != domain.UploadFileStatusConverted || f2.RowCount == nil {
+		t.Errorf("other file = %s, want converted kept", f2.Status)
+	}
+
+	// Re-queueing the whole upload clears every result
+	if err := repo.UpdateFilesStatus(ctx, "t1", "u1", domain.UploadFileStatusQueued); err != nil {
+		t.Fatal(err)
+	}
+	if f2 := file("f2"); f2.RowCount != nil || f2.DatasetID != nil || f2.DatasetsJSON != nil {
+		t.Errorf("requeued file kept results: %+v", f2)
+	}
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/user/micro-dp/domain"
+)
+
+type UploadSessionRepo struct {
+	db DBTX
+}
+
+func NewUploadSessionRepo(db DBTX) *UploadSessionRepo {
+	return &UploadSessionRepo{db: db}
+}
+
+const uploadSessionColumns = `file_id, tenant_id, upload_id, s3_upload_id, part_size_bytes, part_count, status, created_at, updated_at`
+
+func scanUploadSession(s interface{ Scan(...any) error }) (*domain.UploadSession, error) {
+	var us domain.UploadSession
+	if err := s.Scan(&us.FileID, &us.TenantID, &us.UploadID, &us.S3UploadID, &us.PartSizeBytes, &us.PartCount,
+		&us.Status, &us.CreatedAt, &us.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &us, nil
+}
+
+func (r *UploadSessionRepo) Create(ctx context.Context, s *domain.UploadSession) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO upload_sessions (file_id, tenant_id, upload_id, s3_upload_id, part_size_bytes, part_count, status, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
+		s.FileID, s.TenantID, s.UploadID, s.S3UploadID, s.PartSizeBytes, s.PartCount, s.Status,
+	)
+	return err
+}
+
+func (r *UploadSessionRepo) FindByFileID(ctx context.Context, tenantID, fileID string) (*domain.UploadSession, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+uploadSessionColumns+`
+		 FROM upload_sessions WHERE tenant_id = ? AND file_id = ?`, tenantID, fileID,
+	)
+	s, err := scanUploadSession(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrUploadSessionNotFound
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *UploadSessionRepo) ListByUploadID(ctx context.Context, tenantID, uploadID string) ([]domain.UploadSession, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+uploadSessionColumns+`
+		 FROM upload_sessions WHERE tenant_id = ? AND upload_id = ?
+		 ORDER BY created_at, rowid`, tenantID, uploadID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []domain.UploadSession
+	for rows.Next() {
+		s, err := scanUploadSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *UploadSessionRepo) UpdateStatus(ctx context.Context, tenantID, fileID, status string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE upload_sessions SET status = ?, updated_at = datetime('now')
+		 WHERE tenant_id = ? AND file_id = ?`,
+		status, tenantID, fileID,
+	)
+	return err
+}
@@ -27,6 +27,7 @@ type ChartRepository interface {
 	Create(ctx context.Context, c *Chart) error
 	FindByID(ctx context.Context, tenantID, id string) (*Chart, error)
 	ListByTenant(ctx context.Context, tenantID string) ([]Chart, error)
+	ListByDataset(ctx context.Context, tenantID, datasetID string) ([]Chart, error)
 	Update(ctx context.Context, c *Chart) error
 	Delete(ctx context.Context, tenantID, id string) error
 }
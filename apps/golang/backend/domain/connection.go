@@ -11,6 +11,9 @@ var (
 	ErrConnectionNameDuplicate = errors.New("connection name already exists")
 	ErrConnectorTypeUnknown    = errors.New("unknown connector type")
 	ErrConnectionConfigInvalid = errors.New("connection config validation failed")
+	// ErrConnectionSecretRequired is returned when a config keeps the stored
+	// secrets of a connection but points it somewhere else.
+	ErrConnectionSecretRequired = errors.New("secret fields must be re-entered when the connection settings change")
 )
 
 type Connection struct {
@@ -21,6 +24,7 @@ type Connection struct {
 	ConfigJSON   string    `json:"config_json"`
 	SecretRef    *string   `json:"secret_ref,omitempty"`
 	CredentialID *string   `json:"credential_id,omitempty"`
+	DataKey      string    `json:"-"` // wrapped per-row data key sealing x-secret config fields
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -29,6 +33,7 @@ type ConnectionRepository interface {
 	Create(ctx context.Context, c *Connection) error
 	FindByID(ctx context.Context, tenantID, id string) (*Connection, error)
 	ListByTenant(ctx context.Context, tenantID string) ([]Connection, error)
+	ListAll(ctx context.Context) ([]Connection, error)
 	Update(ctx context.Context, c *Connection) error
 	Delete(ctx context.Context, tenantID, id string) error
 }
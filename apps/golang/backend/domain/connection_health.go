@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ConnectionHealthOK     = "ok"
+	ConnectionHealthFailed = "failed"
+)
+
+// ConnectionHealthCheck is the result of one periodic connectivity test.
+type ConnectionHealthCheck struct {
+	ID           string    `json:"id"`
+	TenantID     string    `json:"tenant_id"`
+	ConnectionID string    `json:"connection_id"`
+	Status       string    `json:"status"`
+	Code         string    `json:"code"`
+	Message      string    `json:"message"`
+	LatencyMs    int64     `json:"latency_ms"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+type ConnectionHealthRepository interface {
+	Create(ctx context.Context, c *ConnectionHealthCheck) error
+	// FindLatest returns the most recent check, or nil if the connection
+	// has never been checked.
+	FindLatest(ctx context.Context, tenantID, connectionID string) (*ConnectionHealthCheck, error)
+	ListByConnection(ctx context.Context, tenantID, connectionID string, limit int) ([]ConnectionHealthCheck, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+}
@@ -7,8 +7,15 @@ import (
 )
 
 var (
-	ErrCredentialNotFound      = errors.New("credential not found")
-	ErrCredentialAlreadyExists = errors.New("credential already exists")
+	ErrCredentialNotFound       = errors.New("credential not found")
+	ErrCredentialAlreadyExists  = errors.New("credential already exists")
+	ErrCredentialNeedsReconsent = errors.New("credential needs re-consent")
+	ErrCredentialExpired        = errors.New("credential expired")
+)
+
+const (
+	CredentialStatusActive         = "active"
+	CredentialStatusNeedsReconsent = "needs_reconsent"
 )
 
 type Credential struct {
@@ -21,6 +28,8 @@ type Credential struct {
 	RefreshToken  string     `json:"refresh_token"`
 	TokenExpiry   *time.Time `json:"token_expiry,omitempty"`
 	Scopes        string     `json:"scopes"`
+	Status        string     `json:"status"`
+	DataKey       string     `json:"-"` // wrapped per-row data key sealing the tokens
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 }
@@ -30,6 +39,7 @@ type CredentialRepository interface {
 	FindByID(ctx context.Context, tenantID, id string) (*Credential, error)
 	FindByUserAndProvider(ctx context.Context, userID, tenantID, provider string) (*Credential, error)
 	ListByTenant(ctx context.Context, tenantID string) ([]Credential, error)
+	ListAll(ctx context.Context) ([]Credential, error)
 	Update(ctx context.Context, c *Credential) error
 	Delete(ctx context.Context, tenantID, id string) error
 }
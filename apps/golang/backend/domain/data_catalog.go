@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrDatasetStewardNotFound       = errors.New("dataset steward not found")
+	ErrDatasetCertificationNotFound = errors.New("dataset certification not found")
+	ErrGlossaryTermNotFound         = errors.New("glossary term not found")
+	ErrGlossaryTermExists           = errors.New("glossary term already exists")
+	ErrGlossaryTermLinkNotFound     = errors.New("glossary term is not linked to the column")
+	// ErrNotTenantMember is returned when making a user who is not a member
+	// of the tenant a dataset steward.
+	ErrNotTenantMember = errors.New("user is not a member of the tenant")
+	// ErrInvalidCatalogEntry is returned for unknown steward roles and
+	// certification statuses and for unnamed glossary terms.
+	ErrInvalidCatalogEntry = errors.New("invalid catalog entry")
+)
+
+const (
+	DatasetStewardRoleOwner   = "owner"
+	DatasetStewardRoleSteward = "steward"
+
+	DatasetCertified  = "certified"
+	DatasetDeprecated = "deprecated"
+
+	DatasetUsageChart   = "chart"
+	DatasetUsagePreview = "preview"
+)
+
+// DatasetSteward is a user accountable for a dataset, as its owner or as a
+// steward curating its metadata. Email and DisplayName are read from the
+// user.
+type DatasetSteward struct {
+	DatasetID   string    `json:"dataset_id"`
+	TenantID    string    `json:"tenant_id"`
+	UserID      string    `json:"user_id"`
+	Email       string    `json:"email"`
+	DisplayName string    `json:"display_name"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DatasetCertification is the badge of a dataset: certified as trusted, or
+// deprecated.
+type DatasetCertification struct {
+	DatasetID   string    `json:"dataset_id"`
+	TenantID    string    `json:"tenant_id"`
+	Status      string    `json:"status"`
+	Note        string    `json:"note"`
+	CertifiedBy string    `json:"certified_by"`
+	CertifiedAt time.Time `json:"certified_at"`
+}
+
+// GlossaryTerm is a business term of a tenant's glossary. Columns are the
+// dataset columns linked to it.
+type GlossaryTerm struct {
+	ID         string               `json:"id"`
+	TenantID   string               `json:"tenant_id"`
+	Name       string               `json:"name"`
+	Definition string               `json:"definition"`
+	Columns    []GlossaryTermColumn `json:"columns,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+// GlossaryTermColumn links a glossary term to a dataset column. TermName is
+// read from the term.
+type GlossaryTermColumn struct {
+	TermID     string    `json:"term_id"`
+	TermName   string    `json:"term_name"`
+	DatasetID  string    `json:"dataset_id"`
+	ColumnName string    `json:"column_name"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DatasetUsageEvent is a read of a dataset by chart data (SubjectID is the
+// chart) or a preview query.
+type DatasetUsageEvent struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"tenant_id"`
+	DatasetID string    `json:"dataset_id"`
+	Kind      string    `json:"kind"`
+	SubjectID string    `json:"subject_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DatasetUsage counts the charts, transform jobs and preview queries that
+// read a dataset since a point in time.
+type DatasetUsage struct {
+	Charts     int64 `json:"charts"`
+	Transforms int64 `json:"transforms"`
+	Previews   int64 `json:"previews"`
+}
+
+type DatasetStewardRepository interface {
+	// Upsert makes a user a steward of a dataset or changes their role.
+	Upsert(ctx context.Context, s *DatasetSteward) error
+	ListByDatasets(ctx context.Context, tenantID string, datasetIDs []string) ([]DatasetSteward, error)
+	Delete(ctx context.Context, tenantID, datasetID, userID string) error
+}
+
+type DatasetCertificationRepository interface {
+	// Upsert certifies a dataset or replaces its certification.
+	Upsert(ctx context.Context, c *DatasetCertification) error
+	ListByDatasets(ctx context.Context, tenantID string, datasetIDs []string) ([]DatasetCertification, error)
+	Delete(ctx context.Context, tenantID, datasetID string) error
+}
+
+type GlossaryTermRepository interface {
+	Create(ctx context.Context, t *GlossaryTerm) error
+	FindByID(ctx context.Context, tenantID, id string) (*GlossaryTerm, error)
+	ListByTenant(ctx context.Context, tenantID string) ([]GlossaryTerm, error)
+	Update(ctx context.Context, t *GlossaryTerm) error
+	Delete(ctx context.Context, tenantID, id string) error
+	LinkColumn(ctx context.Context, tenantID string, c *GlossaryTermColumn) error
+	UnlinkColumn(ctx context.Context, tenantID, termID, datasetID, columnName string) error
+	ListColumnsByTerms(ctx context.Context, tenantID string, termIDs []string) ([]GlossaryTermColumn, error)
+	ListColumnsByDatasets(ctx context.Context, tenantID string, datasetIDs []string) ([]GlossaryTermColumn, error)
+}
+
+type DatasetUsageRepository interface {
+	Record(ctx context.Context, e *DatasetUsageEvent) error
+	// CountSince counts the usage of datasets since a time, by dataset ID.
+	// Transform jobs are counted from the lineage edges their runs refresh.
+	CountSince(ctx context.Context, tenantID string, datasetIDs []string, since time.Time) (map[string]DatasetUsage, error)
+	// DeleteBefore removes the usage events of every tenant older than a time.
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrDataQualityRuleNotFound = errors.New("data quality rule not found")
+	ErrDataQualityRuleExists   = errors.New("data quality rule already exists")
+	// ErrDataQualityFailed is returned when a rule of severity fail does not
+	// pass after a run wrote its dataset.
+	ErrDataQualityFailed = errors.New("data quality checks failed")
+)
+
+// DataQualityRule is a check run against a dataset after each run that
+// writes it. Type, Severity and ConfigJSON are interpreted by package
+// dataquality.
+type DataQualityRule struct {
+	ID         string    `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	DatasetID  string    `json:"dataset_id"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	ConfigJSON string    `json:"config_json"`
+	Severity   string    `json:"severity"` // "warn", or "fail" to fail the run
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// DataQualityResult is the outcome of evaluating a rule against a version
+// of its dataset: "passed", "failed", or "error" when the rule could not be
+// evaluated. JobRunID is nil for checks run on demand.
+type DataQualityResult struct {
+	ID             string    `json:"id"`
+	TenantID       string    `json:"tenant_id"`
+	DatasetID      string    `json:"dataset_id"`
+	RuleID         string    `json:"rule_id"`
+	JobRunID       *string   `json:"job_run_id,omitempty"`
+	DatasetVersion *int      `json:"dataset_version,omitempty"`
+	Status         string    `json:"status"`
+	Severity       string    `json:"severity"`
+	FailingRows    *int64    `json:"failing_rows,omitempty"`
+	Message        *string   `json:"message,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type DataQualityRuleRepository interface {
+	Create(ctx context.Context, r *DataQualityRule) error
+	FindByID(ctx context.Context, tenantID, id string) (*DataQualityRule, error)
+	ListByDataset(ctx context.Context, tenantID, datasetID string) ([]DataQualityRule, error)
+	Update(ctx context.Context, r *DataQualityRule) error
+	Delete(ctx context.Context, tenantID, id string) error
+}
+
+type DataQualityResultRepository interface {
+	Create(ctx context.Context, r *DataQualityResult) error
+	// ListByDataset returns results newest first, those of one job run
+	// when jobRunID is set.
+	ListByDataset(ctx context.Context, tenantID, datasetID, jobRunID string, limit int) ([]DataQualityResult, error)
+	// LatestByDataset returns the newest result of each rule of a dataset.
+	LatestByDataset(ctx context.Context, tenantID, datasetID string) ([]DataQualityResult, error)
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 )
 
 var (
 	ErrDatasetNotFound  = errors.New("dataset not found")
 	ErrColumnNotFound   = errors.New("column not found")
+	ErrDatasetInUse     = errors.New("dataset in use")
+	// ErrDatasetRestoreExpired is returned when restoring a dataset whose
+	// restore window has passed.
+	ErrDatasetRestoreExpired = errors.New("dataset restore window has passed")
 )
 
 const (
@@ -30,6 +35,7 @@ type Dataset struct {
 	LastUpdatedAt *time.Time `json:"last_updated_at,omitempty"`
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
 }
 
 type DatasetColumnMeta struct {
@@ -41,6 +47,10 @@ type DatasetColumnMeta struct {
 	Tags         []string         `json:"tags,omitempty"`
 	SampleValues []interface{}    `json:"sample_values,omitempty"`
 	Statistics   *ColumnStatistics `json:"statistics,omitempty"`
+	// PIIKind is the kind of personal data detected in the column's values
+	// when it was written, and SuggestedTags the tags suggested for it.
+	PIIKind       string   `json:"pii_kind,omitempty"`
+	SuggestedTags []string `json:"suggested_tags,omitempty"`
 }
 
 type ColumnStatistics struct {
@@ -73,17 +83,61 @@ func (d *Dataset) SetColumns(cols []DatasetColumnMeta) error {
 	return nil
 }
 
+// DatasetListFilter selects datasets. Query is searched in dataset names
+// and in the names, descriptions and tags of their columns.
 type DatasetListFilter struct {
-	Query      string
-	SourceType string
-	Limit      int
-	Offset     int
+	Query         string
+	SourceType    string
+	Certification string // certification status
+	StewardUserID string // datasets the user owns or stewards
+	Deleted       bool   // list soft-deleted datasets instead of live ones
+	Limit         int
+	Offset        int
 }
 
+// DatasetReference names a chart or job that reads a dataset.
+type DatasetReference struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// DatasetInUseError is returned when deleting a dataset that charts or
+// transform jobs still read.
+type DatasetInUseError struct {
+	Charts []DatasetReference
+	Jobs   []DatasetReference
+}
+
+func (e *DatasetInUseError) Error() string {
+	return fmt.Sprintf("dataset is read by %d chart(s) and %d transform job(s)", len(e.Charts), len(e.Jobs))
+}
+
+func (e *DatasetInUseError) Unwrap() error {
+	return ErrDatasetInUse
+}
+
+// DatasetRepository lookups and listings skip soft-deleted datasets unless
+// noted. Upsert restores a soft-deleted dataset of the same name.
 type DatasetRepository interface {
 	FindByID(ctx context.Context, tenantID, id string) (*Dataset, error)
+	FindByName(ctx context.Context, tenantID, name string) (*Dataset, error)
+	// FindDeletedByID finds a soft-deleted dataset.
+	FindDeletedByID(ctx context.Context, tenantID, id string) (*Dataset, error)
 	ListByTenant(ctx context.Context, tenantID string, filter DatasetListFilter) ([]Dataset, error)
+	// ListDeletedBefore lists soft-deleted datasets of every tenant deleted
+	// before the given time.
+	ListDeletedBefore(ctx context.Context, before time.Time) ([]Dataset, error)
+	// ListAllByTenant lists every dataset of a tenant, soft-deleted ones
+	// included.
+	ListAllByTenant(ctx context.Context, tenantID string) ([]Dataset, error)
 	Create(ctx context.Context, d *Dataset) error
 	Update(ctx context.Context, d *Dataset) error
 	Upsert(ctx context.Context, d *Dataset) error
+	SoftDelete(ctx context.Context, tenantID, id string) error
+	Restore(ctx context.Context, tenantID, id string) error
+	// DeleteSoftDeleted removes a soft-deleted dataset row together with
+	// its versions and schema change log. It returns ErrDatasetNotFound
+	// when the dataset is not soft-deleted, as when a write restored it
+	// after it was listed for purging.
+	DeleteSoftDeleted(ctx context.Context, tenantID, id string) error
 }
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrDatasetExportNotFound         = errors.New("dataset export not found")
+	ErrDatasetExportAlreadyProcessed = errors.New("dataset export already processed")
+)
+
+// Dataset export formats.
+const (
+	DatasetExportCSV     = "csv"
+	DatasetExportJSONL   = "jsonl"
+	DatasetExportParquet = "parquet"
+)
+
+// Dataset export statuses.
+const (
+	DatasetExportQueued    = "queued"
+	DatasetExportRunning   = "running"
+	DatasetExportCompleted = "completed"
+	DatasetExportFailed    = "failed"
+)
+
+// DatasetExport is an export the worker writes to object storage. Version
+// is the dataset version read; nil reads the data current when it runs.
+type DatasetExport struct {
+	ID           string
+	TenantID     string
+	DatasetID    string
+	Format       string
+	Version      *int
+	QueryJSON    string // projection and filters (datasetquery.Query)
+	Status       string
+	ObjectKey    *string
+	SizeBytes    *int64
+	ErrorMessage *string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	CompletedAt  *time.Time
+}
+
+type DatasetExportRepository interface {
+	Create(ctx context.Context, e *DatasetExport) error
+	FindByID(ctx context.Context, tenantID, id string) (*DatasetExport, error)
+	// Update saves the status, result and error of an export.
+	Update(ctx context.Context, e *DatasetExport) error
+}
+
+type DatasetExportMessage struct {
+	ExportID string `json:"export_id"`
+	TenantID string `json:"tenant_id"`
+}
+
+type DatasetExportQueue interface {
+	Enqueue(ctx context.Context, msg *DatasetExportMessage) error
+	Dequeue(ctx context.Context) (*DatasetExportMessage, error)
+	MarkProcessed(ctx context.Context, exportID string) error
+	EnqueueDLQ(ctx context.Context, msg *DatasetExportMessage, reason string) error
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrMaskingPolicyNotFound = errors.New("masking policy not found")
+
+// DatasetMaskingPolicy masks a column of a dataset for members whose tenant
+// role may not read personal data raw. Policy is interpreted by package pii.
+type DatasetMaskingPolicy struct {
+	ID         string    `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	DatasetID  string    `json:"dataset_id"`
+	ColumnName string    `json:"column_name"`
+	Policy     string    `json:"policy"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type DatasetMaskingPolicyRepository interface {
+	// Upsert creates the policy of p's column or replaces it.
+	Upsert(ctx context.Context, p *DatasetMaskingPolicy) error
+	ListByDataset(ctx context.Context, tenantID, datasetID string) ([]DatasetMaskingPolicy, error)
+	ListByTenant(ctx context.Context, tenantID string) ([]DatasetMaskingPolicy, error)
+	Delete(ctx context.Context, tenantID, datasetID, columnName string) error
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrInvalidSchemaDriftPolicy = errors.New("invalid schema drift policy")
+
+// DatasetSchemaChange records the schema changes an import made (or tried
+// to make) to a dataset. Action is "applied" or "rejected"; ChangesJSON
+// holds the list of changes.
+type DatasetSchemaChange struct {
+	ID          string    `json:"id"`
+	TenantID    string    `json:"tenant_id"`
+	DatasetID   string    `json:"dataset_id"`
+	JobRunID    *string   `json:"job_run_id,omitempty"`
+	Policy      string    `json:"policy"`
+	Action      string    `json:"action"`
+	ChangesJSON string    `json:"changes_json"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type DatasetSchemaChangeRepository interface {
+	Create(ctx context.Context, c *DatasetSchemaChange) error
+	ListByDataset(ctx context.Context, tenantID, datasetID string, limit int) ([]DatasetSchemaChange, error)
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrDatasetVersionNotFound    = errors.New("dataset version not found")
+	ErrDatasetVersionUnavailable = errors.New("dataset version cannot be restored")
+)
+
+// DatasetVersion is an immutable snapshot of a dataset's data, recorded
+// whenever a run writes the dataset. A dataset stored under a prefix of
+// Parquet parts lists the parts of the version in ObjectKeysJSON, since
+// later appends add parts to the same prefix.
+type DatasetVersion struct {
+	ID             string    `json:"id"`
+	TenantID       string    `json:"tenant_id"`
+	DatasetID      string    `json:"dataset_id"`
+	Version        int       `json:"version"`
+	StoragePath    string    `json:"storage_path"`
+	ObjectKeysJSON *string   `json:"object_keys_json,omitempty"` // []string
+	SchemaJSON     *string   `json:"schema_json,omitempty"`
+	RowCount       *int64    `json:"row_count,omitempty"`
+	JobRunID       *string   `json:"job_run_id,omitempty"`    // run that wrote the version
+	RestoredFrom   *int      `json:"restored_from,omitempty"` // set on rollbacks
+	Pinned         bool      `json:"pinned"`                  // never garbage-collected
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ObjectKeys returns the listed parts of the version, or nil when the
+// version is read from StoragePath alone.
+func (v *DatasetVersion) ObjectKeys() ([]string, error) {
+	if v.ObjectKeysJSON != nil {
+		var keys []string
+		if err := json.Unmarshal([]byte(*v.ObjectKeysJSON), &keys); err != nil {
+			return nil, err
+		}
+		return keys, nil
+	}
+	return nil, nil
+}
+
+// DatasetKey identifies a dataset of a tenant.
+type DatasetKey struct {
+	TenantID  string
+	DatasetID string
+}
+
+type DatasetVersionRepository interface {
+	// Create stores v as the dataset's next version and sets v.Version.
+	Create(ctx context.Context, v *DatasetVersion) error
+	FindByVersion(ctx context.Context, tenantID, datasetID string, version int) (*DatasetVersion, error)
+	// FindAsOf returns the latest version created at or before at.
+	FindAsOf(ctx context.Context, tenantID, datasetID string, at time.Time) (*DatasetVersion, error)
+	ListByDataset(ctx context.Context, tenantID, datasetID string, limit int) ([]DatasetVersion, error)
+	SetPinned(ctx context.Context, tenantID, datasetID string, version int, pinned bool) error
+	// ListDatasetsOverCount returns datasets with more than keep versions.
+	ListDatasetsOverCount(ctx context.Context, keep int) ([]DatasetKey, error)
+	Delete(ctx context.Context, tenantID, datasetID string, version int) error
+}
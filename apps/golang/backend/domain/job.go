@@ -16,23 +16,30 @@ const (
 var (
 	ErrJobNotFound      = errors.New("job not found")
 	ErrJobSlugDuplicate = errors.New("job slug already exists")
+	// ErrReplicationSlotRelease is returned when a Postgres CDC job cannot be
+	// deactivated because its replication slot could not be dropped.
+	ErrReplicationSlotRelease = errors.New("cannot release replication slot")
 )
 
 type Job struct {
-	ID          string    `json:"id"`
-	TenantID    string    `json:"tenant_id"`
-	Name        string    `json:"name"`
-	Slug        string    `json:"slug"`
-	Description string    `json:"description"`
-	Kind        string    `json:"kind"`
-	IsActive    bool      `json:"is_active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                string    `json:"id"`
+	TenantID          string    `json:"tenant_id"`
+	Name              string    `json:"name"`
+	Slug              string    `json:"slug"`
+	Description       string    `json:"description"`
+	Kind              string    `json:"kind"`
+	IsActive          bool      `json:"is_active"`
+	SchemaDriftPolicy string    `json:"schema_drift_policy"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 type JobRepository interface {
 	Create(ctx context.Context, job *Job) error
 	FindByID(ctx context.Context, tenantID, id string) (*Job, error)
 	ListByTenant(ctx context.Context, tenantID string) ([]Job, error)
+	// ListByInputDataset lists the jobs, active or not, with a module in
+	// any version whose dataset_ids include datasetID.
+	ListByInputDataset(ctx context.Context, tenantID, datasetID string) ([]Job, error)
 	Update(ctx context.Context, job *Job) error
 }
@@ -22,11 +22,13 @@ var (
 
 // RunSnapshot captures all information needed to execute a job run.
 type RunSnapshot struct {
-	JobKind   string              `json:"job_kind"`
-	JobID     string              `json:"job_id"`
-	VersionID string              `json:"version_id"`
-	Modules   []RunSnapshotModule `json:"modules"`
-	Edges     []RunSnapshotEdge   `json:"edges"`
+	JobKind           string              `json:"job_kind"`
+	JobID             string              `json:"job_id"`
+	VersionID         string              `json:"version_id"`
+	SchemaDriftPolicy string              `json:"schema_drift_policy,omitempty"`
+	Modules           []RunSnapshotModule `json:"modules"`
+	Edges             []RunSnapshotEdge   `json:"edges"`
+	CreatedBy         string              `json:"created_by,omitempty"` // user who created the version, whose masking a transform reads with
 }
 
 type RunSnapshotModule struct {
@@ -69,6 +71,7 @@ type JobRun struct {
 	Attempt         int        `json:"attempt"`
 	NextRunAt       *time.Time `json:"next_run_at,omitempty"`
 	LastError       *string    `json:"last_error,omitempty"`
+	SchemaDriftJSON *string    `json:"schema_drift_json,omitempty"`
 	StartedAt       *time.Time `json:"started_at,omitempty"`
 	FinishedAt      *time.Time `json:"finished_at,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
@@ -83,4 +86,9 @@ type JobRunRepository interface {
 	UpdateStatus(ctx context.Context, tenantID, id, status string) error
 	UpdateStarted(ctx context.Context, id string) error
 	UpdateFailed(ctx context.Context, id, lastError string) error
+	UpdateSchemaDrift(ctx context.Context, id, driftJSON string) error
+	UpdateCheckpoint(ctx context.Context, id, checkpointJSON string) error
+	// FindLatestCheckpoint returns the checkpoint_json of the job's most
+	// recent successful run that recorded one, or nil.
+	FindLatestCheckpoint(ctx context.Context, tenantID, jobID string) (*string, error)
 }
@@ -22,6 +22,7 @@ type JobVersion struct {
 	JobID       string     `json:"job_id"`
 	Version     int        `json:"version"`
 	Status      string     `json:"status"`
+	CreatedBy   *string    `json:"created_by,omitempty"` // user whose masking its transform reads with
 	PublishedAt *time.Time `json:"published_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrInvalidLineageQuery = errors.New("invalid lineage query")
+
+// Lineage node types.
+const (
+	LineageNodeConnection = "connection"
+	LineageNodeDataset    = "dataset"
+	LineageNodeJob        = "job"
+	LineageNodeChart      = "chart"
+)
+
+// Lineage query directions.
+const (
+	LineageUpstream   = "upstream"
+	LineageDownstream = "downstream"
+	LineageBoth       = "both"
+)
+
+type LineageNode struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// LineageEdge records that data flows from Source to Target: a connection
+// into a dataset it imports, a dataset into a transform job that reads it
+// and that job into its output, or a dataset into a chart.
+type LineageEdge struct {
+	ID        string      `json:"id"`
+	TenantID  string      `json:"tenant_id"`
+	Source    LineageNode `json:"source"`
+	Target    LineageNode `json:"target"`
+	JobRunID  *string     `json:"job_run_id,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type LineageRepository interface {
+	// Upsert records e, refreshing the job run and update time of an
+	// existing edge between the same nodes.
+	Upsert(ctx context.Context, e *LineageEdge) error
+	// ReplaceSources makes edges the only inbound edges of target.
+	ReplaceSources(ctx context.Context, tenantID string, target LineageNode, edges []LineageEdge) error
+	ListBySources(ctx context.Context, tenantID string, sources []LineageNode) ([]LineageEdge, error)
+	ListByTargets(ctx context.Context, tenantID string, targets []LineageNode) ([]LineageEdge, error)
+	// DeleteNode removes every edge from or to node.
+	DeleteNode(ctx context.Context, tenantID string, node LineageNode) error
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "context"
+
+// ObjectStore is the part of the object storage bucket that version
+// collection, dataset purges and retention sweeps manage objects through.
+type ObjectStore interface {
+	ListObjectKeys(ctx context.Context, prefix string) ([]string, error)
+	// ListPrefixes returns the "directories" directly under prefix, each
+	// ending in "/".
+	ListPrefixes(ctx context.Context, prefix string) ([]string, error)
+	// ObjectSize returns storage.ErrObjectNotFound for a missing object.
+	ObjectSize(ctx context.Context, objectKey string) (int64, error)
+	CopyObject(ctx context.Context, srcKey, dstKey string) error
+	// RemoveObject does not fail for a missing object.
+	RemoveObject(ctx context.Context, objectKey string) error
+	// CountParquetRows counts the rows of the Parquet objects under prefix
+	// from their footers, read with the tenant's credentials.
+	CountParquetRows(ctx context.Context, tenantID, prefix string) (int64, error)
+}
@@ -19,6 +19,10 @@ type Plan struct {
 	MaxStorageBytes  int64
 	MaxRowsPerDay    int
 	MaxUploadsPerDay int
+	// MaxFileSizeBytes caps the size of one uploaded file; -1 = unlimited.
+	MaxFileSizeBytes int64
+	// MaxRetentionDays caps how long retention policies keep data; -1 = unlimited.
+	MaxRetentionDays int
 	IsDefault        bool
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRetentionPolicyNotFound = errors.New("retention policy not found")
+	// ErrInvalidRetentionPolicy is returned for a policy keeping neither a
+	// number of days nor of versions, or a non-positive one.
+	ErrInvalidRetentionPolicy = errors.New("invalid retention policy")
+	// ErrRetentionExceedsPlan is returned for a policy keeping data longer
+	// than the tenant's plan allows.
+	ErrRetentionExceedsPlan = errors.New("retention exceeds the plan's maximum")
+)
+
+const (
+	RetentionPurgePartition = "partition"
+	RetentionPurgeVersion   = "version"
+
+	RetentionPurgeDeleted  = "deleted"
+	RetentionPurgeArchived = "archived"
+)
+
+// RetentionPolicy limits how long a tenant's data is kept. DatasetID is nil
+// for the tenant's policy, which applies to its raw dt= partitions and to
+// the datasets without a policy of their own. KeepDays and KeepVersions
+// are nil when not limited; Archive moves purged objects under the archive
+// prefix instead of deleting them.
+type RetentionPolicy struct {
+	ID           string    `json:"id"`
+	TenantID     string    `json:"tenant_id"`
+	DatasetID    *string   `json:"dataset_id,omitempty"`
+	KeepDays     *int      `json:"keep_days,omitempty"`
+	KeepVersions *int      `json:"keep_versions,omitempty"`
+	Archive      bool      `json:"archive"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// RetentionPurge records a partition or dataset version the retention
+// sweeper deleted or archived. Target is the partition prefix or the
+// version number.
+type RetentionPurge struct {
+	ID          string    `json:"id"`
+	TenantID    string    `json:"tenant_id"`
+	DatasetID   *string   `json:"dataset_id,omitempty"`
+	Kind        string    `json:"kind"`
+	Target      string    `json:"target"`
+	Action      string    `json:"action"`
+	ObjectCount int       `json:"object_count"`
+	Bytes       int64     `json:"bytes"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RetentionPolicyRepository addresses the tenant's policy with an empty
+// datasetID.
+type RetentionPolicyRepository interface {
+	Find(ctx context.Context, tenantID, datasetID string) (*RetentionPolicy, error)
+	ListByTenant(ctx context.Context, tenantID string) ([]RetentionPolicy, error)
+	// Upsert creates the policy or replaces its limits.
+	Upsert(ctx context.Context, p *RetentionPolicy) error
+	Delete(ctx context.Context, tenantID, datasetID string) error
+}
+
+type RetentionPurgeRepository interface {
+	Create(ctx context.Context, p *RetentionPurge) error
+	// ListByTenant lists purges newest first, of one dataset when datasetID
+	// is set.
+	ListByTenant(ctx context.Context, tenantID, datasetID string, limit int) ([]RetentionPurge, error)
+}
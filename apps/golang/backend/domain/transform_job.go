@@ -5,15 +5,44 @@ import (
 	"errors"
 )
 
-var ErrTransformAlreadyProcessed = errors.New("transform already processed")
+var (
+	ErrTransformAlreadyProcessed = errors.New("transform already processed")
+	ErrInvalidTransformOutput    = errors.New("invalid transform output")
+)
+
+const (
+	TransformOutputModeOverwrite         = "overwrite"          // replace the dataset's rows
+	TransformOutputModeAppend            = "append"             // add the rows as a new Parquet part
+	TransformOutputModeMerge             = "merge"              // replace rows with matching key columns, add the rest
+	TransformOutputModeReplacePartitions = "replace_partitions" // replace the rows of the partition values the run produced
+)
+
+// TransformOutput names the dataset every run of a transform writes, so that
+// runs keep one dataset with a version per run, and how a run's rows are
+// written into it.
+type TransformOutput struct {
+	DatasetName     string   `json:"dataset_name"`
+	Mode            string   `json:"mode"`
+	KeyColumns      []string `json:"key_columns,omitempty"`      // merge only
+	PartitionColumn string   `json:"partition_column,omitempty"` // replace_partitions only
+}
 
 type TransformJobMessage struct {
 	JobRunID   string   `json:"job_run_id"`
 	TenantID   string   `json:"tenant_id"`
 	SQL        string   `json:"sql"`
 	DatasetIDs []string `json:"dataset_ids"`
-	JobID      string   `json:"job_id"`
-	VersionID  string   `json:"version_id"`
+	// DatasetVersions pins input datasets to a version by dataset ID;
+	// other inputs are read at their current version.
+	DatasetVersions map[string]int `json:"dataset_versions,omitempty"`
+	JobID           string         `json:"job_id"`
+	VersionID       string         `json:"version_id"`
+	// UserID is the user the inputs are masked for, as dataset rows are
+	// for them; inputs are masked as for a caller without a role when empty.
+	UserID string `json:"user_id,omitempty"`
+	// Output is nil for transforms writing a new transform_<run> dataset
+	// on every run.
+	Output *TransformOutput `json:"output,omitempty"`
 }
 
 type TransformJobQueue interface {
@@ -9,6 +9,13 @@ import (
 var (
 	ErrUploadNotFound       = errors.New("upload not found")
 	ErrUploadAlreadyComplete = errors.New("upload already complete")
+	ErrUploadNotComplete     = errors.New("upload not complete")
+	ErrUploadFileNotFound    = errors.New("upload file not found")
+	ErrUploadFileBusy        = errors.New("upload file is being processed")
+	ErrUploadFileMissing     = errors.New("upload file has not been uploaded")
+	ErrInvalidParseOptions   = errors.New("invalid parse options")
+	ErrUploadConversion      = errors.New("upload file could not be converted")
+	ErrInvalidUploadTarget   = errors.New("invalid upload target")
 )
 
 const (
@@ -16,6 +23,32 @@ const (
 	UploadStatusUploaded  = "uploaded"
 )
 
+// Upload file processing states. Files are pending until the upload is
+// completed, then queued for the worker.
+const (
+	UploadFileStatusPending    = "pending"
+	UploadFileStatusQueued     = "queued"
+	UploadFileStatusProcessing = "processing"
+	UploadFileStatusConverted  = "converted"
+	UploadFileStatusFailed     = "failed"
+	UploadFileStatusSkipped    = "skipped"
+)
+
+// Write modes of an upload into an existing dataset.
+const (
+	UploadWriteModeReplace = "replace" // overwrite the dataset's rows
+	UploadWriteModeAppend  = "append"  // add the rows as a new Parquet part
+	UploadWriteModeUpsert  = "upsert"  // replace rows with matching key columns, add the rest
+)
+
+// UploadTarget directs an uploaded file into an existing dataset instead
+// of the dataset named after the file.
+type UploadTarget struct {
+	DatasetID  string   `json:"dataset_id"`
+	Mode       string   `json:"mode"`
+	KeyColumns []string `json:"key_columns,omitempty"` // upsert only
+}
+
 type Upload struct {
 	ID        string
 	TenantID  string
@@ -33,6 +66,34 @@ type UploadFile struct {
 	ContentType string
 	SizeBytes   int64
 	CreatedAt   time.Time
+
+	// ParseOptionsJSON holds the options delimited text files are read
+	// with; nil sniffs them.
+	ParseOptionsJSON *string
+	TargetJSON       *string // UploadTarget
+	// SourceURL is set on files the worker fetches from a URL.
+	SourceURL *string
+
+	Status       string
+	ErrorMessage *string
+	RowCount     *int64
+	DatasetID    *string // first dataset written from the file
+	DatasetsJSON *string // []UploadFileDataset
+	StartedAt    *time.Time
+	FinishedAt   *time.Time
+}
+
+// UploadFileDataset is one dataset written from an uploaded file; zip
+// archives and Excel workbooks produce several.
+type UploadFileDataset struct {
+	DatasetID   string `json:"dataset_id"`
+	DatasetName string `json:"dataset_name"`
+	RowCount    int64  `json:"row_count"`
+}
+
+type UploadListFilter struct {
+	Limit  int
+	Offset int
 }
 
 type UploadRepository interface {
@@ -40,7 +101,22 @@ type UploadRepository interface {
 	CreateUploadFile(ctx context.Context, f *UploadFile) error
 	FindByID(ctx context.Context, tenantID, id string) (*Upload, error)
 	FindFilesByUploadID(ctx context.Context, tenantID, uploadID string) ([]UploadFile, error)
+	ListByTenant(ctx context.Context, tenantID string, filter UploadListFilter) ([]Upload, error)
 	UpdateStatus(ctx context.Context, tenantID, id, status string) error
+	// UpdateFilesStatus sets the status of every file of an upload and
+	// clears previous processing results.
+	UpdateFilesStatus(ctx context.Context, tenantID, uploadID, status string) error
+	FindFileByID(ctx context.Context, tenantID, uploadID, fileID string) (*UploadFile, error)
+	UpdateFileParseOptions(ctx context.Context, tenantID, fileID string, optionsJSON *string) error
+	UpdateFileTarget(ctx context.Context, tenantID, fileID string, targetJSON *string) error
+	// UpdateFileStatus sets the status of one file and clears previous
+	// processing results.
+	UpdateFileStatus(ctx context.Context, tenantID, fileID, status string) error
+	UpdateFileStarted(ctx context.Context, tenantID, fileID string) error
+	UpdateFileResult(ctx context.Context, f *UploadFile) error
+	// UpdateFileFetched records the name, content type and size of a file
+	// fetched from its source URL.
+	UpdateFileFetched(ctx context.Context, f *UploadFile) error
 }
 
 type PresignedURLGenerator interface {
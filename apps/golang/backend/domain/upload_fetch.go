@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidUploadURL    = errors.New("invalid upload url")
+	ErrUploadFetchNotFound = errors.New("upload fetch not found")
+)
+
+const (
+	UploadFetchAuthNone   = "none"
+	UploadFetchAuthBasic  = "basic"
+	UploadFetchAuthBearer = "bearer"
+)
+
+// UploadFetch is the request to download an upload file from its source
+// URL. AuthSecret holds the sealed credentials, encrypted with DataKey,
+// until the worker has run the fetch.
+type UploadFetch struct {
+	FileID       string
+	TenantID     string
+	UploadID     string
+	AuthType     string
+	AuthSecret   string
+	DataKey      string
+	Checksum     *string // "<algorithm>:<hex digest>"
+	MaxSizeBytes int64   // -1 = unlimited
+	CreatedAt    time.Time
+}
+
+type UploadFetchRepository interface {
+	Create(ctx context.Context, f *UploadFetch) error
+	FindByFileID(ctx context.Context, tenantID, fileID string) (*UploadFetch, error)
+	// ClearAuth drops the stored credentials once the fetch has run.
+	ClearAuth(ctx context.Context, tenantID, fileID string) error
+}
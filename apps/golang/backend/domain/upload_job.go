@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 )
 
@@ -13,9 +14,19 @@ type UploadJobFile struct {
 	ObjectKey   string `json:"object_key"`
 	ContentType string `json:"content_type"`
 	SizeBytes   int64  `json:"size_bytes"`
+	// ParseOptions holds the file's parse options (fileconv.Options), if any.
+	ParseOptions json.RawMessage `json:"parse_options,omitempty"`
+	// Target is set when the file is written into an existing dataset.
+	Target *UploadTarget `json:"target,omitempty"`
+	// Fetch is set when the worker first downloads the file from its
+	// source URL (see UploadFetch).
+	Fetch bool `json:"fetch,omitempty"`
 }
 
 type UploadJobMessage struct {
+	// JobID identifies one conversion request and is the idempotency key;
+	// re-imports of an upload get a new one. Empty means UploadID.
+	JobID    string          `json:"job_id,omitempty"`
 	UploadID string          `json:"upload_id"`
 	TenantID string          `json:"tenant_id"`
 	Files    []UploadJobFile `json:"files"`
@@ -24,6 +35,6 @@ type UploadJobMessage struct {
 type UploadJobQueue interface {
 	Enqueue(ctx context.Context, msg *UploadJobMessage) error
 	Dequeue(ctx context.Context) (*UploadJobMessage, error)
-	MarkProcessed(ctx context.Context, uploadID string) error
+	MarkProcessed(ctx context.Context, jobID string) error
 	EnqueueDLQ(ctx context.Context, msg *UploadJobMessage, reason string) error
 }
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrUploadFileTooLarge     = errors.New("upload file too large")
+	ErrUploadSessionNotFound  = errors.New("upload session not found")
+	ErrUploadSessionNotActive = errors.New("upload session is not active")
+	ErrInvalidUploadPart      = errors.New("invalid upload part")
+	ErrUploadPartsIncomplete  = errors.New("upload parts incomplete")
+)
+
+const (
+	UploadSessionStatusActive    = "active"
+	UploadSessionStatusCompleted = "completed"
+	UploadSessionStatusAborted   = "aborted"
+)
+
+// UploadSession tracks the S3 multipart upload of one upload file, so a
+// client can list the parts already stored and resume after a failure.
+type UploadSession struct {
+	FileID        string
+	TenantID      string
+	UploadID      string
+	S3UploadID    string
+	PartSizeBytes int64
+	PartCount     int
+	Status        string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// UploadedPart is one stored part of a multipart upload.
+type UploadedPart struct {
+	PartNumber int
+	ETag       string
+	SizeBytes  int64
+}
+
+type UploadSessionRepository interface {
+	Create(ctx context.Context, s *UploadSession) error
+	FindByFileID(ctx context.Context, tenantID, fileID string) (*UploadSession, error)
+	ListByUploadID(ctx context.Context, tenantID, uploadID string) ([]UploadSession, error)
+	UpdateStatus(ctx context.Context, tenantID, fileID, status string) error
+}
+
+// MultipartUploader drives S3 multipart uploads whose parts are sent by
+// the client to presigned URLs.
+type MultipartUploader interface {
+	InitiateMultipartUpload(ctx context.Context, objectKey, contentType string) (string, error)
+	PresignUploadPartURL(ctx context.Context, objectKey, uploadID string, partNumber int, expiry time.Duration) (string, time.Time, error)
+	ListUploadedParts(ctx context.Context, objectKey, uploadID string) ([]UploadedPart, error)
+	CompleteMultipartUpload(ctx context.Context, objectKey, uploadID string, parts []UploadedPart) error
+	AbortMultipartUpload(ctx context.Context, objectKey, uploadID string) error
+}
@@ -12,6 +12,7 @@ const (
 	UsageTypeEventsIngest  = "events_ingest"
 	UsageTypeUploadComplete = "upload_complete"
 	UsageTypeStorageWrite  = "storage_write"
+	UsageTypeStorageRelease = "storage_release"
 )
 
 type UsageDaily struct {
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/user/micro-dp/internal/connector"
+)
+
+type AdminConnectorHandler struct {
+	registry *connector.Registry
+}
+
+func NewAdminConnectorHandler(registry *connector.Registry) *AdminConnectorHandler {
+	return &AdminConnectorHandler{registry: registry}
+}
+
+func (h *AdminConnectorHandler) Status(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, toOpenAPIConnectorRegistryStatus(h.registry.Status()))
+}
+
+func (h *AdminConnectorHandler) Reload(w http.ResponseWriter, r *http.Request) {
+	h.registry.Reload()
+	writeJSON(w, http.StatusOK, toOpenAPIConnectorRegistryStatus(h.registry.Status()))
+}
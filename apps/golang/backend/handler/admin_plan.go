@@ -48,8 +48,17 @@ func (h *AdminPlanHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if req.MaxStorageBytes != nil {
 		maxStorage = *req.MaxStorageBytes
 	}
+	var maxFileSize int64 = -1
+	if req.MaxFileSizeBytes != nil {
+		maxFileSize = *req.MaxFileSizeBytes
+	}
+
+	maxRetention := -1
+	if req.MaxRetentionDays != nil {
+		maxRetention = *req.MaxRetentionDays
+	}
 
-	plan, err := h.plans.CreatePlan(r.Context(), req.Name, req.DisplayName, maxEvents, maxRows, maxUploads, maxStorage)
+	plan, err := h.plans.CreatePlan(r.Context(), req.Name, req.DisplayName, maxEvents, maxRows, maxUploads, maxStorage, maxFileSize, maxRetention)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
@@ -88,7 +97,7 @@ func (h *AdminPlanHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	plan, err := h.plans.UpdatePlan(r.Context(), id, req.DisplayName, req.MaxEventsPerDay, req.MaxRowsPerDay, req.MaxUploadsPerDay, req.MaxStorageBytes)
+	plan, err := h.plans.UpdatePlan(r.Context(), id, req.DisplayName, req.MaxEventsPerDay, req.MaxRowsPerDay, req.MaxUploadsPerDay, req.MaxStorageBytes, req.MaxFileSizeBytes, req.MaxRetentionDays)
 	if err != nil {
 		if errors.Is(err, domain.ErrPlanNotFound) {
 			writeError(w, http.StatusNotFound, "plan not found")
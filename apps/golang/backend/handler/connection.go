@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/user/micro-dp/domain"
 	"github.com/user/micro-dp/internal/connector"
@@ -14,11 +15,20 @@ import (
 type ConnectionHandler struct {
 	connections *usecase.ConnectionService
 	credentials *usecase.CredentialService
+	health      *usecase.ConnectionHealthService
 	registry    *connector.Registry
 }
 
-func NewConnectionHandler(connections *usecase.ConnectionService, credentials *usecase.CredentialService, registry *connector.Registry) *ConnectionHandler {
-	return &ConnectionHandler{connections: connections, credentials: credentials, registry: registry}
+func NewConnectionHandler(connections *usecase.ConnectionService, credentials *usecase.CredentialService, health *usecase.ConnectionHealthService, registry *connector.Registry) *ConnectionHandler {
+	return &ConnectionHandler{connections: connections, credentials: credentials, health: health, registry: registry}
+}
+
+// toResponse converts a stored connection for API output with its x-secret
+// fields redacted.
+func (h *ConnectionHandler) toResponse(c *domain.Connection) openapi.Connection {
+	redacted := *c
+	redacted.ConfigJSON = h.connections.RedactConfig(c)
+	return toOpenAPIConnection(&redacted)
 }
 
 func (h *ConnectionHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -55,7 +65,7 @@ func (h *ConnectionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, toOpenAPIConnection(c))
+	writeJSON(w, http.StatusCreated, h.toResponse(c))
 }
 
 func (h *ConnectionHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -70,7 +80,7 @@ func (h *ConnectionHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	items := make([]openapi.Connection, len(connections))
 	for i := range connections {
-		items[i] = toOpenAPIConnection(&connections[i])
+		items[i] = h.toResponse(&connections[i])
 	}
 
 	writeJSON(w, http.StatusOK, struct {
@@ -95,7 +105,55 @@ func (h *ConnectionHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, toOpenAPIConnection(c))
+	out := h.toResponse(c)
+	latest, err := h.health.Latest(r.Context(), c.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if latest != nil {
+		health := toOpenAPIConnectionHealthCheck(latest)
+		out.Health = &health
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *ConnectionHandler) ListHealth(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing id")
+		return
+	}
+
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			writeError(w, http.StatusBadRequest, "invalid limit (1-500)")
+			return
+		}
+		limit = n
+	}
+
+	checks, err := h.health.History(r.Context(), id, limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrConnectionNotFound) {
+			writeError(w, http.StatusNotFound, "connection not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	items := make([]openapi.ConnectionHealthCheck, len(checks))
+	for i := range checks {
+		items[i] = toOpenAPIConnectionHealthCheck(&checks[i])
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Items []openapi.ConnectionHealthCheck `json:"items"`
+	}{Items: items})
 }
 
 func (h *ConnectionHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -130,6 +188,10 @@ func (h *ConnectionHandler) Update(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusBadRequest, "unknown connector type")
 			return
 		}
+		if errors.Is(err, domain.ErrConnectionSecretRequired) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		if errors.Is(err, domain.ErrConnectionConfigInvalid) {
 			writeError(w, http.StatusUnprocessableEntity, err.Error())
 			return
@@ -142,7 +204,7 @@ func (h *ConnectionHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, toOpenAPIConnection(c))
+	writeJSON(w, http.StatusOK, h.toResponse(c))
 }
 
 func (h *ConnectionHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -180,9 +242,32 @@ func (h *ConnectionHandler) Test(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Fill in secrets that an edit form left redacted from the stored connection
+	configJSON := req.ConfigJson
+	if req.ConnectionId != nil && *req.ConnectionId != "" {
+		resolved, err := h.connections.ResolveTestConfig(r.Context(), *req.ConnectionId, req.Type, configJSON)
+		if err != nil {
+			if errors.Is(err, domain.ErrConnectionNotFound) {
+				writeError(w, http.StatusNotFound, "connection not found")
+				return
+			}
+			if errors.Is(err, domain.ErrConnectionSecretRequired) {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if errors.Is(err, domain.ErrConnectionConfigInvalid) {
+				writeError(w, http.StatusBadRequest, "invalid config_json")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		configJSON = resolved
+	}
+
 	// Validate config against JSON Schema
 	validationResult := openapi.ValidationResult{Status: openapi.ValidationResultStatusOk}
-	if err := h.registry.ValidateConfig(req.Type, req.ConfigJson); err != nil {
+	if err := h.registry.ValidateConfig(req.Type, configJSON); err != nil {
 		msg := err.Error()
 		validationResult = openapi.ValidationResult{
 			Status:  openapi.ValidationResultStatusFailed,
@@ -214,6 +299,10 @@ func (h *ConnectionHandler) Test(w http.ResponseWriter, r *http.Request) {
 			if err != nil {
 				code := "unauthorized"
 				msg := "failed to retrieve access token"
+				if errors.Is(err, domain.ErrCredentialNeedsReconsent) {
+					code = "needs_reconsent"
+					msg = "credential needs re-consent; reconnect the account"
+				}
 				writeJSON(w, http.StatusOK, openapi.TestConnectionResponse{
 					Validation: validationResult,
 					Connectivity: openapi.ConnectivityResult{
@@ -227,7 +316,7 @@ func (h *ConnectionHandler) Test(w http.ResponseWriter, r *http.Request) {
 			accessToken = token
 		}
 
-		result := tester.Test(r.Context(), req.ConfigJson, accessToken)
+		result := tester.Test(r.Context(), configJSON, accessToken)
 		connStatus := openapi.ConnectivityResultStatusOk
 		if !result.OK {
 			connStatus = openapi.ConnectivityResultStatusFailed
@@ -277,8 +366,13 @@ func (h *ConnectionHandler) ListSchemas(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	configJSON, err := h.connections.ResolveConfig(r.Context(), conn)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
 	// Merge spreadsheet_id query param into configJSON if provided
-	configJSON := conn.ConfigJSON
 	if qsID := r.URL.Query().Get("spreadsheet_id"); qsID != "" {
 		var cfgMap map[string]interface{}
 		if err := json.Unmarshal([]byte(configJSON), &cfgMap); err != nil {
@@ -299,6 +393,10 @@ func (h *ConnectionHandler) ListSchemas(w http.ResponseWriter, r *http.Request)
 		}
 		token, err := h.credentials.GetValidAccessToken(r.Context(), tenantID, *conn.CredentialID)
 		if err != nil {
+			if errors.Is(err, domain.ErrCredentialNeedsReconsent) {
+				writeError(w, http.StatusBadRequest, "credential_needs_reconsent")
+				return
+			}
 			writeError(w, http.StatusBadRequest, "credential_expired")
 			return
 		}
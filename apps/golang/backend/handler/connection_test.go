@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/connector"
+	"github.com/user/micro-dp/internal/openapi"
+	"github.com/user/micro-dp/internal/secret"
+	"github.com/user/micro-dp/usecase"
+)
+
+// connectionServer serves the connection routes of tenant t1 over a
+// migrated database, with secrets sealed under a test master key.
+func connectionServer(t *testing.T) http.Handler {
+	t.Helper()
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "test.db"))
+	sqlDB, err := db.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.Migrate(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.NewTenantRepo(sqlDB).Create(context.Background(), &domain.Tenant{ID: "t1", Name: "t1", IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := secret.NewKeyring(secret.Config{MasterKeys: "k1:" + base64.StdEncoding.EncodeToString(key)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := connector.New("")
+	connections := usecase.NewConnectionService(db.NewConnectionRepo(sqlDB), registry, keyring, nil)
+	h := NewConnectionHandler(connections, nil, nil, registry)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /connections", h.Create)
+	mux.HandleFunc("GET /connections", h.List)
+	mux.HandleFunc("PUT /connections/{id}", h.Update)
+	mux.HandleFunc("POST /connections/test", h.Test)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(domain.ContextWithTenantID(r.Context(), "t1")))
+	})
+}
+
+func serveJSON(t *testing.T, h http.Handler, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, &buf))
+	return rec
+}
+
+func TestConnectionResponsesRedactSecrets(t *testing.T) {
+	h := connectionServer(t)
+	config := `{"host":"db.internal","port":5432,"database":"app","username":"app","password":"hunter2"}`
+
+	rec := serveJSON(t, h, http.MethodPost, "/connections", openapi.CreateConnectionRequest{Name: "pg", Type: "source-postgres", ConfigJson: &config})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create = %d %s", rec.Code, rec.Body)
+	}
+	var created openapi.Connection
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	wantRedacted := func(name, body string) {
+		t.Helper()
+		if strings.Contains(body, "hunter2") || strings.Contains(body, "enc:v1:") {
+			t.Errorf("%s response exposes the password: %s", name, body)
+		}
+		if !strings.Contains(body, `\"password\":\"`+connector.RedactedValue+`\"`) {
+			t.Errorf("%s response = %s, want the password redacted", name, body)
+		}
+	}
+	wantRedacted("create", rec.Body.String())
+	wantRedacted("list", serveJSON(t, h, http.MethodGet, "/connections", nil).Body.String())
+
+	// The redacted config sent back keeps the password
+	redacted := *created.ConfigJson
+	rec = serveJSON(t, h, http.MethodPut, "/connections/"+created.Id, openapi.UpdateConnectionRequest{Name: "pg", Type: "source-postgres", ConfigJson: &redacted})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update = %d %s", rec.Code, rec.Body)
+	}
+	wantRedacted("update", rec.Body.String())
+
+	// but not once the host is changed
+	moved := strings.Replace(redacted, "db.internal", "attacker.example", 1)
+	rec = serveJSON(t, h, http.MethodPut, "/connections/"+created.Id, openapi.UpdateConnectionRequest{Name: "pg", Type: "source-postgres", ConfigJson: &moved})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("update with a new host = %d %s, want 400", rec.Code, rec.Body)
+	}
+	rec = serveJSON(t, h, http.MethodPost, "/connections/test", openapi.TestConnectionRequest{Type: "source-postgres", ConfigJson: moved, ConnectionId: &created.Id})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("test with a new host = %d %s, want 400", rec.Code, rec.Body)
+	}
+}
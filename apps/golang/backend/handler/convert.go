@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"slices"
 
 	openapi_types "github.com/oapi-codegen/runtime/types"
 
@@ -28,6 +29,12 @@ func toOpenAPIJobRun(jr *domain.JobRun) openapi.JobRun {
 	if jr.FinishedAt != nil {
 		out.FinishedAt = jr.FinishedAt
 	}
+	if jr.SchemaDriftJSON != nil && *jr.SchemaDriftJSON != "" {
+		var drift []openapi.SchemaDriftReport
+		if err := json.Unmarshal([]byte(*jr.SchemaDriftJSON), &drift); err == nil {
+			out.SchemaDrift = &drift
+		}
+	}
 	return out
 }
 
@@ -48,6 +55,8 @@ func toOpenAPIJob(j *domain.Job) openapi.Job {
 		Slug:     j.Slug,
 		Kind:     kind,
 		IsActive: j.IsActive,
+
+		SchemaDriftPolicy: openapi.SchemaDriftPolicy(j.SchemaDriftPolicy),
 	}
 	if j.Description != "" {
 		out.Description = &j.Description
@@ -135,6 +144,7 @@ func toOpenAPIDataset(d *domain.Dataset) openapi.Dataset {
 	out.LastUpdatedAt = d.LastUpdatedAt
 	out.CreatedAt = &d.CreatedAt
 	out.UpdatedAt = &d.UpdatedAt
+	out.DeletedAt = d.DeletedAt
 
 	if cols, err := d.ParseColumns(); err == nil && len(cols) > 0 {
 		apiCols := make([]openapi.DatasetColumn, len(cols))
@@ -167,6 +177,20 @@ func toOpenAPIDatasetColumn(c *domain.DatasetColumnMeta) openapi.DatasetColumn {
 	if len(c.SampleValues) > 0 {
 		col.SampleValues = &c.SampleValues
 	}
+	if c.PIIKind != "" {
+		kind := openapi.PIIKind(c.PIIKind)
+		col.PiiKind = &kind
+	}
+	// Only suggest the tags the column does not have yet
+	var suggested []string
+	for _, tag := range c.SuggestedTags {
+		if !slices.Contains(c.Tags, tag) {
+			suggested = append(suggested, tag)
+		}
+	}
+	if len(suggested) > 0 {
+		col.SuggestedTags = &suggested
+	}
 	if c.Statistics != nil {
 		col.Statistics = &openapi.ColumnStatistics{
 			Min:           c.Statistics.Min,
@@ -196,15 +220,55 @@ func toOpenAPIUpload(u *domain.Upload, files []domain.UploadFile) openapi.Upload
 }
 
 func toOpenAPIUploadFile(f *domain.UploadFile) openapi.UploadFile {
-	return openapi.UploadFile{
-		Id:          f.ID,
-		UploadId:    f.UploadID,
-		FileName:    f.FileName,
-		ObjectKey:   f.ObjectKey,
-		ContentType: f.ContentType,
-		SizeBytes:   f.SizeBytes,
-		CreatedAt:   &f.CreatedAt,
+	out := openapi.UploadFile{
+		Id:           f.ID,
+		UploadId:     f.UploadID,
+		FileName:     f.FileName,
+		ObjectKey:    f.ObjectKey,
+		ContentType:  f.ContentType,
+		SizeBytes:    f.SizeBytes,
+		Status:       openapi.UploadFileStatus(f.Status),
+		ErrorMessage: f.ErrorMessage,
+		SourceUrl:    f.SourceURL,
+		RowCount:     f.RowCount,
+		DatasetId:    f.DatasetID,
+		StartedAt:    f.StartedAt,
+		FinishedAt:   f.FinishedAt,
+		CreatedAt:    &f.CreatedAt,
+	}
+	if f.DatasetsJSON != nil {
+		var datasets []domain.UploadFileDataset
+		if err := json.Unmarshal([]byte(*f.DatasetsJSON), &datasets); err == nil {
+			items := make([]openapi.UploadFileDataset, len(datasets))
+			for i, d := range datasets {
+				items[i] = openapi.UploadFileDataset{
+					DatasetId:   d.DatasetID,
+					DatasetName: d.DatasetName,
+					RowCount:    d.RowCount,
+				}
+			}
+			out.Datasets = &items
+		}
+	}
+	if f.ParseOptionsJSON != nil {
+		var opts openapi.UploadParseOptions
+		if err := json.Unmarshal([]byte(*f.ParseOptionsJSON), &opts); err == nil {
+			out.ParseOptions = &opts
+		}
 	}
+	if f.TargetJSON != nil {
+		var target domain.UploadTarget
+		if err := json.Unmarshal([]byte(*f.TargetJSON), &target); err == nil {
+			out.Target = &openapi.UploadTarget{
+				DatasetId: target.DatasetID,
+				Mode:      openapi.UploadWriteMode(target.Mode),
+			}
+			if len(target.KeyColumns) > 0 {
+				out.Target.KeyColumns = &target.KeyColumns
+			}
+		}
+	}
+	return out
 }
 
 func toOpenAPIJobRunModule(m *domain.JobRunModule) openapi.JobRunModule {
@@ -259,6 +323,8 @@ func toOpenAPIPlan(p *domain.Plan) openapi.Plan {
 		MaxStorageBytes:  p.MaxStorageBytes,
 		MaxRowsPerDay:    p.MaxRowsPerDay,
 		MaxUploadsPerDay: p.MaxUploadsPerDay,
+		MaxFileSizeBytes: p.MaxFileSizeBytes,
+		MaxRetentionDays: p.MaxRetentionDays,
 		IsDefault:        p.IsDefault,
 	}
 }
@@ -337,12 +403,50 @@ func toOpenAPIConnectorDefinitionDetail(d *connector.Definition) openapi.Connect
 	return out
 }
 
+func toOpenAPIConnectorRegistryStatus(st connector.Status) openapi.ConnectorRegistryStatus {
+	defs := make([]openapi.ConnectorRegistryDefinition, len(st.Definitions))
+	for i, d := range st.Definitions {
+		defs[i] = openapi.ConnectorRegistryDefinition{
+			Id:     d.ID,
+			Kind:   openapi.ConnectorKind(d.Kind),
+			Source: openapi.ConnectorDefinitionSource(d.Source),
+			Path:   d.Path,
+		}
+	}
+	errs := make([]openapi.ConnectorLoadError, len(st.Errors))
+	for i, e := range st.Errors {
+		errs[i] = openapi.ConnectorLoadError{
+			Source:  openapi.ConnectorDefinitionSource(e.Source),
+			Path:    e.Path,
+			Message: e.Message,
+		}
+		if e.DefinitionID != "" {
+			id := e.DefinitionID
+			errs[i].DefinitionId = &id
+		}
+		if e.KeptPrevious {
+			kept := true
+			errs[i].KeptPrevious = &kept
+		}
+	}
+	out := openapi.ConnectorRegistryStatus{
+		Definitions: defs,
+		Errors:      errs,
+		LoadedAt:    st.LoadedAt,
+	}
+	if st.DefinitionsDir != "" {
+		out.DefinitionsDir = &st.DefinitionsDir
+	}
+	return out
+}
+
 func toOpenAPICredential(c *domain.Credential) openapi.Credential {
 	out := openapi.Credential{
 		Id:       c.ID,
 		UserId:   c.UserID,
 		TenantId: c.TenantID,
 		Provider: c.Provider,
+		Status:   openapi.CredentialStatus(c.Status),
 	}
 	if c.ProviderLabel != "" {
 		out.ProviderLabel = &c.ProviderLabel
@@ -372,6 +476,37 @@ func toOpenAPIConnection(c *domain.Connection) openapi.Connection {
 	return out
 }
 
+func toOpenAPIConnectionHealthCheck(c *domain.ConnectionHealthCheck) openapi.ConnectionHealthCheck {
+	out := openapi.ConnectionHealthCheck{
+		Id:           c.ID,
+		ConnectionId: c.ConnectionID,
+		Status:       openapi.ConnectionHealthStatus(c.Status),
+		LatencyMs:    c.LatencyMs,
+		CheckedAt:    c.CheckedAt,
+	}
+	if c.Code != "" {
+		out.Code = &c.Code
+	}
+	if c.Message != "" {
+		out.Message = &c.Message
+	}
+	return out
+}
+
+func toOpenAPIDatasetSchemaChange(c *domain.DatasetSchemaChange) openapi.DatasetSchemaChange {
+	out := openapi.DatasetSchemaChange{
+		Id:        c.ID,
+		DatasetId: c.DatasetID,
+		JobRunId:  c.JobRunID,
+		Policy:    openapi.SchemaDriftPolicy(c.Policy),
+		Action:    openapi.SchemaDriftAction(c.Action),
+		Changes:   []openapi.SchemaChange{},
+		CreatedAt: c.CreatedAt,
+	}
+	_ = json.Unmarshal([]byte(c.ChangesJSON), &out.Changes)
+	return out
+}
+
 func toOpenAPITenantMember(m *domain.TenantMember) openapi.TenantMember {
 	return openapi.TenantMember{
 		UserId:      m.UserID,
@@ -44,6 +44,22 @@ func (h *CredentialHandler) List(w http.ResponseWriter, r *http.Request) {
 	}{Items: items})
 }
 
+func (h *CredentialHandler) ListProviders(w http.ResponseWriter, r *http.Request) {
+	providers := h.credentials.Providers()
+	items := make([]openapi.CredentialProvider, len(providers))
+	for i, p := range providers {
+		items[i] = openapi.CredentialProvider{
+			Name:        p.ProviderName(),
+			DisplayName: p.DisplayName(),
+			Enabled:     p.OAuthEnabled(),
+		}
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Items []openapi.CredentialProvider `json:"items"`
+	}{Items: items})
+}
+
 func (h *CredentialHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
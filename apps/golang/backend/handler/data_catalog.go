@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/openapi"
+	"github.com/user/micro-dp/usecase"
+)
+
+type DataCatalogHandler struct {
+	catalog *usecase.DataCatalogService
+}
+
+func NewDataCatalogHandler(catalog *usecase.DataCatalogService) *DataCatalogHandler {
+	return &DataCatalogHandler{catalog: catalog}
+}
+
+func (h *DataCatalogHandler) SetSteward(w http.ResponseWriter, r *http.Request) {
+	var req openapi.SetDatasetStewardRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	st, err := h.catalog.SetSteward(r.Context(), r.PathValue("id"), r.PathValue("user_id"), string(req.Role))
+	if err != nil {
+		writeDataCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOpenAPIDatasetSteward(st))
+}
+
+func (h *DataCatalogHandler) DeleteSteward(w http.ResponseWriter, r *http.Request) {
+	if err := h.catalog.RemoveSteward(r.Context(), r.PathValue("id"), r.PathValue("user_id")); err != nil {
+		writeDataCatalogError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *DataCatalogHandler) Certify(w http.ResponseWriter, r *http.Request) {
+	var req openapi.CertifyDatasetRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	c, err := h.catalog.Certify(r.Context(), r.PathValue("id"), string(req.Status), ptrStr(req.Note))
+	if err != nil {
+		writeDataCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOpenAPIDatasetCertification(c))
+}
+
+func (h *DataCatalogHandler) DeleteCertification(w http.ResponseWriter, r *http.Request) {
+	if err := h.catalog.Uncertify(r.Context(), r.PathValue("id")); err != nil {
+		writeDataCatalogError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *DataCatalogHandler) ListTerms(w http.ResponseWriter, r *http.Request) {
+	terms, err := h.catalog.ListTerms(r.Context())
+	if err != nil {
+		writeDataCatalogError(w, err)
+		return
+	}
+
+	items := make([]openapi.GlossaryTerm, len(terms))
+	for i := range terms {
+		items[i] = toOpenAPIGlossaryTerm(&terms[i])
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Items []openapi.GlossaryTerm `json:"items"`
+	}{Items: items})
+}
+
+func (h *DataCatalogHandler) GetTerm(w http.ResponseWriter, r *http.Request) {
+	t, err := h.catalog.GetTerm(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeDataCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOpenAPIGlossaryTerm(t))
+}
+
+func (h *DataCatalogHandler) CreateTerm(w http.ResponseWriter, r *http.Request) {
+	var req openapi.GlossaryTermRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	t, err := h.catalog.CreateTerm(r.Context(), req.Name, ptrStr(req.Definition))
+	if err != nil {
+		writeDataCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toOpenAPIGlossaryTerm(t))
+}
+
+func (h *DataCatalogHandler) UpdateTerm(w http.ResponseWriter, r *http.Request) {
+	var req openapi.GlossaryTermRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	t, err := h.catalog.UpdateTerm(r.Context(), r.PathValue("id"), req.Name, ptrStr(req.Definition))
+	if err != nil {
+		writeDataCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOpenAPIGlossaryTerm(t))
+}
+
+func (h *DataCatalogHandler) DeleteTerm(w http.ResponseWriter, r *http.Request) {
+	if err := h.catalog.DeleteTerm(r.Context(), r.PathValue("id")); err != nil {
+		writeDataCatalogError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *DataCatalogHandler) LinkTerm(w http.ResponseWriter, r *http.Request) {
+	t, err := h.catalog.LinkTerm(r.Context(), r.PathValue("id"), r.PathValue("column"), r.PathValue("term_id"))
+	if err != nil {
+		writeDataCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOpenAPIGlossaryTerm(t))
+}
+
+func (h *DataCatalogHandler) UnlinkTerm(w http.ResponseWriter, r *http.Request) {
+	if err := h.catalog.UnlinkTerm(r.Context(), r.PathValue("id"), r.PathValue("column"), r.PathValue("term_id")); err != nil {
+		writeDataCatalogError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeDataCatalogError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCatalogEntry), errors.Is(err, domain.ErrColumnNotFound),
+		errors.Is(err, domain.ErrNotTenantMember):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrInsufficientRole):
+		writeError(w, http.StatusForbidden, "insufficient role")
+	case errors.Is(err, domain.ErrDatasetNotFound):
+		writeError(w, http.StatusNotFound, "dataset not found")
+	case errors.Is(err, domain.ErrDatasetStewardNotFound), errors.Is(err, domain.ErrDatasetCertificationNotFound),
+		errors.Is(err, domain.ErrGlossaryTermNotFound), errors.Is(err, domain.ErrGlossaryTermLinkNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrGlossaryTermExists):
+		writeError(w, http.StatusConflict, "a glossary term with this name already exists")
+	default:
+		log.Printf("data catalog error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// withCatalog adds a dataset's catalog entry to its API representation.
+func withCatalog(out *openapi.Dataset, c *usecase.DatasetCatalog) {
+	if c == nil {
+		return
+	}
+	if c.Certification != nil {
+		cert := toOpenAPIDatasetCertification(c.Certification)
+		out.Certification = &cert
+	}
+	stewards := make([]openapi.DatasetSteward, len(c.Stewards))
+	for i := range c.Stewards {
+		stewards[i] = toOpenAPIDatasetSteward(&c.Stewards[i])
+	}
+	out.Stewards = &stewards
+	out.Usage = &openapi.DatasetUsage{
+		Charts:     c.Usage.Charts,
+		Transforms: c.Usage.Transforms,
+		Previews:   c.Usage.Previews,
+	}
+
+	if out.Columns == nil {
+		return
+	}
+	cols := *out.Columns
+	for _, t := range c.Terms {
+		for i := range cols {
+			if cols[i].Name != t.ColumnName {
+				continue
+			}
+			if cols[i].GlossaryTerms == nil {
+				cols[i].GlossaryTerms = &[]openapi.GlossaryTermRef{}
+			}
+			*cols[i].GlossaryTerms = append(*cols[i].GlossaryTerms, openapi.GlossaryTermRef{Id: t.TermID, Name: t.TermName})
+		}
+	}
+}
+
+func toOpenAPIDatasetSteward(st *domain.DatasetSteward) openapi.DatasetSteward {
+	return openapi.DatasetSteward{
+		UserId:      st.UserID,
+		Email:       st.Email,
+		DisplayName: st.DisplayName,
+		Role:        openapi.DatasetStewardRole(st.Role),
+		CreatedAt:   st.CreatedAt,
+	}
+}
+
+func toOpenAPIDatasetCertification(c *domain.DatasetCertification) openapi.DatasetCertification {
+	return openapi.DatasetCertification{
+		Status:      openapi.DatasetCertificationStatus(c.Status),
+		Note:        c.Note,
+		CertifiedBy: c.CertifiedBy,
+		CertifiedAt: c.CertifiedAt,
+	}
+}
+
+func toOpenAPIGlossaryTerm(t *domain.GlossaryTerm) openapi.GlossaryTerm {
+	out := openapi.GlossaryTerm{
+		Id:         t.ID,
+		Name:       t.Name,
+		Definition: t.Definition,
+		Columns:    make([]openapi.GlossaryTermColumn, len(t.Columns)),
+		CreatedAt:  t.CreatedAt,
+		UpdatedAt:  t.UpdatedAt,
+	}
+	for i, c := range t.Columns {
+		out.Columns[i] = openapi.GlossaryTermColumn{DatasetId: c.DatasetID, Column: c.ColumnName}
+	}
+	return out
+}
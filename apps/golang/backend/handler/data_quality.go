@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/dataquality"
+	"github.com/user/micro-dp/internal/openapi"
+	"github.com/user/micro-dp/usecase"
+)
+
+type DataQualityHandler struct {
+	quality *usecase.DataQualityService
+}
+
+func NewDataQualityHandler(quality *usecase.DataQualityService) *DataQualityHandler {
+	return &DataQualityHandler{quality: quality}
+}
+
+func (h *DataQualityHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.quality.ListRules(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeDataQualityError(w, err)
+		return
+	}
+
+	items := make([]openapi.DataQualityRule, len(rules))
+	for i := range rules {
+		items[i] = toOpenAPIDataQualityRule(&rules[i])
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Items []openapi.DataQualityRule `json:"items"`
+	}{Items: items})
+}
+
+func (h *DataQualityHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var req openapi.CreateDataQualityRuleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	rule, err := h.quality.CreateRule(r.Context(), r.PathValue("id"),
+		toDataQualityRuleInput(req.Name, req.Type, req.Config, req.Severity, req.Enabled))
+	if err != nil {
+		writeDataQualityError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toOpenAPIDataQualityRule(rule))
+}
+
+func (h *DataQualityHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	var req openapi.UpdateDataQualityRuleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	rule, err := h.quality.UpdateRule(r.Context(), r.PathValue("id"), r.PathValue("rule_id"),
+		toDataQualityRuleInput(req.Name, req.Type, req.Config, req.Severity, req.Enabled))
+	if err != nil {
+		writeDataQualityError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOpenAPIDataQualityRule(rule))
+}
+
+func (h *DataQualityHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	if err := h.quality.DeleteRule(r.Context(), r.PathValue("id"), r.PathValue("rule_id")); err != nil {
+		writeDataQualityError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *DataQualityHandler) Check(w http.ResponseWriter, r *http.Request) {
+	results, err := h.quality.Check(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeDataQualityError(w, err)
+		return
+	}
+	writeDataQualityResults(w, results)
+}
+
+func (h *DataQualityHandler) ListResults(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			writeError(w, http.StatusBadRequest, "invalid limit (1-500)")
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.quality.ListResults(r.Context(), r.PathValue("id"), r.URL.Query().Get("job_run_id"), limit)
+	if err != nil {
+		writeDataQualityError(w, err)
+		return
+	}
+	writeDataQualityResults(w, results)
+}
+
+func (h *DataQualityHandler) Health(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	health, err := h.quality.Health(r.Context(), id)
+	if err != nil {
+		writeDataQualityError(w, err)
+		return
+	}
+
+	resp := openapi.DatasetHealth{
+		DatasetId: id,
+		Status:    openapi.DatasetHealthStatus(health.Status),
+		CheckedAt: health.CheckedAt,
+		Rules:     make([]openapi.DataQualityRuleHealth, len(health.Rules)),
+	}
+	for i, rh := range health.Rules {
+		resp.Rules[i].Rule = toOpenAPIDataQualityRule(&rh.Rule)
+		if rh.Latest != nil {
+			res := toOpenAPIDataQualityResult(rh.Latest)
+			resp.Rules[i].LatestResult = &res
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeDataQualityResults(w http.ResponseWriter, results []domain.DataQualityResult) {
+	items := make([]openapi.DataQualityResult, len(results))
+	for i := range results {
+		items[i] = toOpenAPIDataQualityResult(&results[i])
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Items []openapi.DataQualityResult `json:"items"`
+	}{Items: items})
+}
+
+func writeDataQualityError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, dataquality.ErrInvalid):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrInsufficientRole):
+		writeError(w, http.StatusForbidden, "insufficient role")
+	case errors.Is(err, domain.ErrDatasetNotFound):
+		writeError(w, http.StatusNotFound, "dataset not found")
+	case errors.Is(err, domain.ErrDataQualityRuleNotFound):
+		writeError(w, http.StatusNotFound, "data quality rule not found")
+	case errors.Is(err, domain.ErrDataQualityRuleExists):
+		writeError(w, http.StatusConflict, "a rule with this name already exists")
+	default:
+		log.Printf("data quality error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+func toDataQualityRuleInput(name string, typ openapi.DataQualityRuleType, c openapi.DataQualityRuleConfig, severity *openapi.DataQualitySeverity, enabled *bool) usecase.DataQualityRuleInput {
+	in := usecase.DataQualityRuleInput{
+		Name:    name,
+		Type:    string(typ),
+		Enabled: enabled == nil || *enabled,
+		Config: dataquality.Config{
+			Column:       ptrStr(c.Column),
+			Min:          c.Min,
+			Max:          c.Max,
+			Pattern:      ptrStr(c.Pattern),
+			MinRows:      c.MinRows,
+			MaxRows:      c.MaxRows,
+			MaxAge:       ptrStr(c.MaxAge),
+			RefDatasetID: ptrStr(c.RefDatasetId),
+			RefColumn:    ptrStr(c.RefColumn),
+			SQL:          ptrStr(c.Sql),
+		},
+	}
+	if c.Values != nil {
+		in.Config.Values = *c.Values
+	}
+	if severity != nil {
+		in.Severity = string(*severity)
+	}
+	return in
+}
+
+func toOpenAPIDataQualityRule(rule *domain.DataQualityRule) openapi.DataQualityRule {
+	out := openapi.DataQualityRule{
+		Id:        rule.ID,
+		DatasetId: rule.DatasetID,
+		Name:      rule.Name,
+		Type:      openapi.DataQualityRuleType(rule.Type),
+		Severity:  openapi.DataQualitySeverity(rule.Severity),
+		Enabled:   rule.Enabled,
+		CreatedAt: rule.CreatedAt,
+		UpdatedAt: rule.UpdatedAt,
+	}
+	// The stored config has the same JSON field names as the API's
+	_ = json.Unmarshal([]byte(rule.ConfigJSON), &out.Config)
+	return out
+}
+
+func toOpenAPIDataQualityResult(res *domain.DataQualityResult) openapi.DataQualityResult {
+	return openapi.DataQualityResult{
+		Id:             res.ID,
+		DatasetId:      res.DatasetID,
+		RuleId:         res.RuleID,
+		JobRunId:       res.JobRunID,
+		DatasetVersion: res.DatasetVersion,
+		Status:         openapi.DataQualityResultStatus(res.Status),
+		Severity:       openapi.DataQualitySeverity(res.Severity),
+		FailingRows:    res.FailingRows,
+		Message:        res.Message,
+		CreatedAt:      res.CreatedAt,
+	}
+}
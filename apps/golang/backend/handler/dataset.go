@@ -6,18 +6,21 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/datasetquery"
 	"github.com/user/micro-dp/internal/openapi"
 	"github.com/user/micro-dp/usecase"
 )
 
 type DatasetHandler struct {
 	datasets *usecase.DatasetService
+	catalog  *usecase.DataCatalogService
 }
 
-func NewDatasetHandler(datasets *usecase.DatasetService) *DatasetHandler {
-	return &DatasetHandler{datasets: datasets}
+func NewDatasetHandler(datasets *usecase.DatasetService, catalog *usecase.DataCatalogService) *DatasetHandler {
+	return &DatasetHandler{datasets: datasets, catalog: catalog}
 }
 
 func (h *DatasetHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -26,6 +29,16 @@ func (h *DatasetHandler) List(w http.ResponseWriter, r *http.Request) {
 	var filter domain.DatasetListFilter
 	filter.Query = q.Get("q")
 	filter.SourceType = q.Get("source_type")
+	filter.Certification = q.Get("certification")
+	filter.StewardUserID = q.Get("steward_id")
+	if v := q.Get("deleted"); v != "" {
+		deleted, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid deleted")
+			return
+		}
+		filter.Deleted = deleted
+	}
 
 	if v := q.Get("limit"); v != "" {
 		n, err := strconv.Atoi(v)
@@ -53,9 +66,15 @@ func (h *DatasetHandler) List(w http.ResponseWriter, r *http.Request) {
 		datasets = []domain.Dataset{}
 	}
 
-	items := make([]openapi.Dataset, len(datasets))
+	ptrs := make([]*domain.Dataset, len(datasets))
 	for i := range datasets {
-		items[i] = toOpenAPIDataset(&datasets[i])
+		ptrs[i] = &datasets[i]
+	}
+	items, err := h.describe(r, ptrs...)
+	if err != nil {
+		log.Printf("describe datasets error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
 	}
 
 	writeJSON(w, http.StatusOK, struct {
@@ -80,7 +99,13 @@ func (h *DatasetHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, toOpenAPIDataset(d))
+	items, err := h.describe(r, d)
+	if err != nil {
+		log.Printf("describe datasets error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, items[0])
 }
 
 func (h *DatasetHandler) GetRows(w http.ResponseWriter, r *http.Request) {
@@ -110,29 +135,113 @@ func (h *DatasetHandler) GetRows(w http.ResponseWriter, r *http.Request) {
 		offset = n
 	}
 
-	result, err := h.datasets.GetRows(r.Context(), id, limit, offset)
+	q, err := parseDatasetQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sel, err := parseDatasetVersionSelector(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.datasets.GetRows(r.Context(), id, sel, q, limit, offset, r.URL.Query().Get("page_token"))
 	if err != nil {
+		if errors.Is(err, datasetquery.ErrInvalid) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		if errors.Is(err, domain.ErrDatasetNotFound) {
 			writeError(w, http.StatusNotFound, "dataset not found")
 			return
 		}
+		if errors.Is(err, domain.ErrDatasetVersionNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
 		log.Printf("dataset get rows error: %v", err)
 		writeError(w, http.StatusInternalServerError, "failed to read dataset rows")
 		return
 	}
 
-	columns := make([]openapi.DatasetColumn, len(result.Columns))
-	for i, c := range result.Columns {
+	columns := make([]openapi.DatasetColumn, len(page.Columns))
+	for i, c := range page.Columns {
 		columns[i] = openapi.DatasetColumn{Name: c.Name, Type: c.Type}
 	}
-
-	writeJSON(w, http.StatusOK, openapi.DatasetRowsResponse{
+	resp := openapi.DatasetRowsResponse{
 		Columns:   columns,
-		Rows:      result.Rows,
-		TotalRows: result.TotalRows,
+		Rows:      page.Rows,
+		TotalRows: page.TotalRows,
 		Limit:     limit,
 		Offset:    offset,
-	})
+	}
+	if page.NextToken != "" {
+		resp.NextPageToken = &page.NextToken
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parseDatasetQuery reads the columns, filter and sort query parameters
+// that select and order a dataset's rows.
+func parseDatasetQuery(r *http.Request) (datasetquery.Query, error) {
+	var q datasetquery.Query
+	params := r.URL.Query()
+	if v := params.Get("columns"); v != "" {
+		q.Columns = strings.Split(v, ",")
+	}
+	for _, v := range params["filter"] {
+		f, err := datasetquery.ParseFilter(v)
+		if err != nil {
+			return q, err
+		}
+		q.Filters = append(q.Filters, f)
+	}
+	if v := params.Get("sort"); v != "" {
+		keys, err := datasetquery.ParseSort(v)
+		if err != nil {
+			return q, err
+		}
+		q.Sort = keys
+	}
+	return q, nil
+}
+
+func (h *DatasetHandler) ListSchemaChanges(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing id")
+		return
+	}
+
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			writeError(w, http.StatusBadRequest, "invalid limit (1-500)")
+			return
+		}
+		limit = n
+	}
+
+	changes, err := h.datasets.ListSchemaChanges(r.Context(), id, limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrDatasetNotFound) {
+			writeError(w, http.StatusNotFound, "dataset not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	items := make([]openapi.DatasetSchemaChange, len(changes))
+	for i := range changes {
+		items[i] = toOpenAPIDatasetSchemaChange(&changes[i])
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Items []openapi.DatasetSchemaChange `json:"items"`
+	}{Items: items})
 }
 
 func (h *DatasetHandler) UpdateColumns(w http.ResponseWriter, r *http.Request) {
@@ -177,7 +286,28 @@ func (h *DatasetHandler) UpdateColumns(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, toOpenAPIDataset(d))
+	items, err := h.describe(r, d)
+	if err != nil {
+		log.Printf("describe datasets error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, items[0])
+}
+
+// describe converts datasets to their API representation with their catalog
+// entries: certification, stewards, usage and column glossary terms.
+func (h *DatasetHandler) describe(r *http.Request, datasets ...*domain.Dataset) ([]openapi.Dataset, error) {
+	entries, err := h.catalog.Describe(r.Context(), datasets...)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]openapi.Dataset, len(datasets))
+	for i, d := range datasets {
+		out[i] = toOpenAPIDataset(d)
+		withCatalog(&out[i], entries[d.ID])
+	}
+	return out, nil
 }
 
 func ptrStr(p *string) string {
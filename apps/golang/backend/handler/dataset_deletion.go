@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/openapi"
+	"github.com/user/micro-dp/usecase"
+)
+
+type DatasetDeletionHandler struct {
+	deletions *usecase.DatasetDeletionService
+}
+
+func NewDatasetDeletionHandler(deletions *usecase.DatasetDeletionService) *DatasetDeletionHandler {
+	return &DatasetDeletionHandler{deletions: deletions}
+}
+
+func (h *DatasetDeletionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing id")
+		return
+	}
+
+	cascade := false
+	if v := r.URL.Query().Get("cascade"); v != "" {
+		var err error
+		if cascade, err = strconv.ParseBool(v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid cascade")
+			return
+		}
+	}
+
+	if err := h.deletions.Delete(r.Context(), id, cascade); err != nil {
+		var inUse *domain.DatasetInUseError
+		switch {
+		case errors.As(err, &inUse):
+			writeJSON(w, http.StatusConflict, openapi.DatasetInUseResponse{
+				Error:  inUse.Error(),
+				Charts: toOpenAPIDatasetReferences(inUse.Charts),
+				Jobs:   toOpenAPIDatasetReferences(inUse.Jobs),
+			})
+		case errors.Is(err, domain.ErrDatasetNotFound):
+			writeError(w, http.StatusNotFound, "dataset not found")
+		default:
+			log.Printf("delete dataset error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *DatasetDeletionHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing id")
+		return
+	}
+
+	ds, err := h.deletions.Restore(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDatasetNotFound):
+			writeError(w, http.StatusNotFound, "deleted dataset not found")
+		case errors.Is(err, domain.ErrDatasetRestoreExpired):
+			writeError(w, http.StatusGone, err.Error())
+		default:
+			log.Printf("restore dataset error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, toOpenAPIDataset(ds))
+}
+
+func toOpenAPIDatasetReferences(refs []domain.DatasetReference) []openapi.DatasetReference {
+	out := make([]openapi.DatasetReference, len(refs))
+	for i, ref := range refs {
+		out[i] = openapi.DatasetReference{Id: ref.ID, Name: ref.Name}
+	}
+	return out
+}
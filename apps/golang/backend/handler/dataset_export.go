@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/datasetquery"
+	"github.com/user/micro-dp/internal/openapi"
+	"github.com/user/micro-dp/usecase"
+)
+
+// exportContentTypes are the Content-Type of each export format.
+var exportContentTypes = map[string]string{
+	domain.DatasetExportCSV:     "text/csv; charset=utf-8",
+	domain.DatasetExportJSONL:   "application/x-ndjson",
+	domain.DatasetExportParquet: "application/vnd.apache.parquet",
+}
+
+type DatasetExportHandler struct {
+	exports *usecase.DatasetExportService
+}
+
+func NewDatasetExportHandler(exports *usecase.DatasetExportService) *DatasetExportHandler {
+	return &DatasetExportHandler{exports: exports}
+}
+
+func (h *DatasetExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing id")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = domain.DatasetExportCSV
+	}
+	q, err := parseDatasetQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sel, err := parseDatasetVersionSelector(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stream, err := h.exports.OpenStream(r.Context(), id, format, sel, q)
+	if err != nil {
+		writeDatasetExportError(w, err)
+		return
+	}
+	defer stream.Close()
+
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": stream.Dataset.Name + "." + format,
+	}))
+	if _, err := stream.WriteTo(w); err != nil {
+		log.Printf("export dataset id=%s: %v", id, err)
+	}
+}
+
+func (h *DatasetExportHandler) Create(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing id")
+		return
+	}
+
+	var req openapi.CreateDatasetExportRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	var sel usecase.DatasetVersionSelector
+	if req.Version != nil {
+		if *req.Version < 1 {
+			writeError(w, http.StatusBadRequest, "invalid version")
+			return
+		}
+		sel.Version = *req.Version
+	}
+	if req.AsOf != nil {
+		if sel.Version > 0 {
+			writeError(w, http.StatusBadRequest, "version and as_of are mutually exclusive")
+			return
+		}
+		sel.AsOf = req.AsOf
+	}
+	var q datasetquery.Query
+	if req.Columns != nil {
+		q.Columns = *req.Columns
+	}
+	if req.Filters != nil {
+		for _, f := range *req.Filters {
+			filter := datasetquery.Filter{Column: f.Column, Op: string(f.Op)}
+			if f.Value != nil {
+				filter.Value = *f.Value
+			}
+			q.Filters = append(q.Filters, filter)
+		}
+	}
+	if req.Sort != nil {
+		for _, k := range *req.Sort {
+			q.Sort = append(q.Sort, datasetquery.SortKey{Column: k.Column, Desc: k.Desc != nil && *k.Desc})
+		}
+	}
+
+	e, err := h.exports.Create(r.Context(), id, string(req.Format), sel, q)
+	if err != nil {
+		writeDatasetExportError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, toOpenAPIDatasetExport(&usecase.DatasetExportResult{DatasetExport: e}))
+}
+
+func (h *DatasetExportHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	exportID := r.PathValue("export_id")
+	if id == "" || exportID == "" {
+		writeError(w, http.StatusBadRequest, "missing id")
+		return
+	}
+
+	e, err := h.exports.Get(r.Context(), id, exportID)
+	if err != nil {
+		writeDatasetExportError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOpenAPIDatasetExport(e))
+}
+
+func writeDatasetExportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, datasetquery.ErrInvalid):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrDatasetNotFound):
+		writeError(w, http.StatusNotFound, "dataset not found")
+	case errors.Is(err, domain.ErrDatasetVersionNotFound):
+		writeError(w, http.StatusNotFound, "dataset version not found")
+	case errors.Is(err, domain.ErrDatasetExportNotFound):
+		writeError(w, http.StatusNotFound, "dataset export not found")
+	default:
+		log.Printf("dataset export error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+func toOpenAPIDatasetExport(e *usecase.DatasetExportResult) openapi.DatasetExport {
+	out := openapi.DatasetExport{
+		Id:                e.ID,
+		DatasetId:         e.DatasetID,
+		Format:            openapi.DatasetExportFormat(e.Format),
+		Version:           e.Version,
+		Status:            openapi.DatasetExportStatus(e.Status),
+		SizeBytes:         e.SizeBytes,
+		ErrorMessage:      e.ErrorMessage,
+		DownloadExpiresAt: e.ExpiresAt,
+		CreatedAt:         e.CreatedAt,
+		UpdatedAt:         e.UpdatedAt,
+		CompletedAt:       e.CompletedAt,
+	}
+	if e.DownloadURL != "" {
+		out.DownloadUrl = &e.DownloadURL
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/openapi"
+	"github.com/user/micro-dp/internal/pii"
+	"github.com/user/micro-dp/usecase"
+)
+
+type DatasetMaskingHandler struct {
+	masking *usecase.DatasetMaskingService
+}
+
+func NewDatasetMaskingHandler(masking *usecase.DatasetMaskingService) *DatasetMaskingHandler {
+	return &DatasetMaskingHandler{masking: masking}
+}
+
+func (h *DatasetMaskingHandler) List(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.masking.List(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeDatasetMaskingError(w, err)
+		return
+	}
+
+	items := make([]openapi.DatasetMaskingPolicy, len(policies))
+	for i := range policies {
+		items[i] = toOpenAPIDatasetMaskingPolicy(&policies[i])
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Items []openapi.DatasetMaskingPolicy `json:"items"`
+	}{Items: items})
+}
+
+func (h *DatasetMaskingHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var req openapi.SetMaskingPolicyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	p, err := h.masking.Set(r.Context(), r.PathValue("id"), r.PathValue("column"), string(req.Policy))
+	if err != nil {
+		writeDatasetMaskingError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOpenAPIDatasetMaskingPolicy(p))
+}
+
+func (h *DatasetMaskingHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.masking.Delete(r.Context(), r.PathValue("id"), r.PathValue("column")); err != nil {
+		writeDatasetMaskingError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeDatasetMaskingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pii.ErrInvalid), errors.Is(err, domain.ErrColumnNotFound):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrInsufficientRole):
+		writeError(w, http.StatusForbidden, "insufficient role")
+	case errors.Is(err, domain.ErrDatasetNotFound):
+		writeError(w, http.StatusNotFound, "dataset not found")
+	case errors.Is(err, domain.ErrMaskingPolicyNotFound):
+		writeError(w, http.StatusNotFound, "masking policy not found")
+	default:
+		log.Printf("dataset masking error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+func toOpenAPIDatasetMaskingPolicy(p *domain.DatasetMaskingPolicy) openapi.DatasetMaskingPolicy {
+	return openapi.DatasetMaskingPolicy{
+		Id:        p.ID,
+		DatasetId: p.DatasetID,
+		Column:    p.ColumnName,
+		Policy:    openapi.MaskingPolicy(p.Policy),
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/openapi"
+	"github.com/user/micro-dp/usecase"
+)
+
+type DatasetVersionHandler struct {
+	versions *usecase.DatasetVersionService
+}
+
+func NewDatasetVersionHandler(versions *usecase.DatasetVersionService) *DatasetVersionHandler {
+	return &DatasetVersionHandler{versions: versions}
+}
+
+func (h *DatasetVersionHandler) List(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing id")
+		return
+	}
+
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			writeError(w, http.StatusBadRequest, "invalid limit (1-500)")
+			return
+		}
+		limit = n
+	}
+
+	versions, err := h.versions.List(r.Context(), id, limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrDatasetNotFound) {
+			writeError(w, http.StatusNotFound, "dataset not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	items := make([]openapi.DatasetVersion, len(versions))
+	for i := range versions {
+		items[i] = toOpenAPIDatasetVersion(&versions[i])
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Items []openapi.DatasetVersion `json:"items"`
+	}{Items: items})
+}
+
+func (h *DatasetVersionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, version, ok := datasetVersionPath(w, r)
+	if !ok {
+		return
+	}
+
+	v, err := h.versions.Get(r.Context(), id, version)
+	if err != nil {
+		writeDatasetVersionError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toOpenAPIDatasetVersion(v))
+}
+
+func (h *DatasetVersionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, version, ok := datasetVersionPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req openapi.UpdateDatasetVersionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	v, err := h.versions.SetPinned(r.Context(), id, version, req.Pinned)
+	if err != nil {
+		writeDatasetVersionError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toOpenAPIDatasetVersion(v))
+}
+
+func (h *DatasetVersionHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+	id, version, ok := datasetVersionPath(w, r)
+	if !ok {
+		return
+	}
+
+	v, err := h.versions.Rollback(r.Context(), id, version)
+	if err != nil {
+		writeDatasetVersionError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toOpenAPIDatasetVersion(v))
+}
+
+func datasetVersionPath(w http.ResponseWriter, r *http.Request) (string, int, bool) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing id")
+		return "", 0, false
+	}
+	version, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || version < 1 {
+		writeError(w, http.StatusBadRequest, "invalid version")
+		return "", 0, false
+	}
+	return id, version, true
+}
+
+func writeDatasetVersionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrDatasetNotFound):
+		writeError(w, http.StatusNotFound, "dataset not found")
+	case errors.Is(err, domain.ErrDatasetVersionNotFound):
+		writeError(w, http.StatusNotFound, "dataset version not found")
+	case errors.Is(err, domain.ErrDatasetVersionUnavailable):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		log.Printf("dataset version error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// parseDatasetVersionSelector reads the version and as_of query parameters
+// that select a prior version of a dataset.
+func parseDatasetVersionSelector(r *http.Request) (usecase.DatasetVersionSelector, error) {
+	var sel usecase.DatasetVersionSelector
+	q := r.URL.Query()
+	if v := q.Get("version"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return sel, fmt.Errorf("invalid version")
+		}
+		sel.Version = n
+	}
+	if v := q.Get("as_of"); v != "" {
+		if sel.Version > 0 {
+			return sel, fmt.Errorf("version and as_of are mutually exclusive")
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return sel, fmt.Errorf("invalid as_of (RFC 3339 timestamp)")
+		}
+		sel.AsOf = &t
+	}
+	return sel, nil
+}
+
+func toOpenAPIDatasetVersion(v *domain.DatasetVersion) openapi.DatasetVersion {
+	out := openapi.DatasetVersion{
+		Id:           v.ID,
+		DatasetId:    v.DatasetID,
+		Version:      v.Version,
+		StoragePath:  v.StoragePath,
+		RowCount:     v.RowCount,
+		JobRunId:     v.JobRunID,
+		RestoredFrom: v.RestoredFrom,
+		Pinned:       v.Pinned,
+		CreatedAt:    v.CreatedAt,
+	}
+	ds := domain.Dataset{SchemaJSON: v.SchemaJSON}
+	if cols, err := ds.ParseColumns(); err == nil && len(cols) > 0 {
+		apiCols := make([]openapi.DatasetColumn, len(cols))
+		for i, c := range cols {
+			apiCols[i] = openapi.DatasetColumn{Name: c.Name, Type: c.Type}
+		}
+		out.Columns = &apiCols
+	}
+	return out
+}
@@ -38,8 +38,17 @@ func (h *JobHandler) Create(w http.ResponseWriter, r *http.Request) {
 		kind = string(*req.Kind)
 	}
 
-	job, err := h.jobs.CreateJob(r.Context(), req.Name, req.Slug, desc, kind)
+	policy := ""
+	if req.SchemaDriftPolicy != nil {
+		policy = string(*req.SchemaDriftPolicy)
+	}
+
+	job, err := h.jobs.CreateJob(r.Context(), req.Name, req.Slug, desc, kind, policy)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSchemaDriftPolicy) {
+			writeError(w, http.StatusBadRequest, "invalid schema_drift_policy")
+			return
+		}
 		if errors.Is(err, domain.ErrJobSlugDuplicate) {
 			writeError(w, http.StatusConflict, "job slug already exists")
 			return
@@ -113,8 +122,17 @@ func (h *JobHandler) Update(w http.ResponseWriter, r *http.Request) {
 		desc = *req.Description
 	}
 
-	job, err := h.jobs.UpdateJob(r.Context(), id, req.Name, req.Slug, desc, req.IsActive)
+	policy := ""
+	if req.SchemaDriftPolicy != nil {
+		policy = string(*req.SchemaDriftPolicy)
+	}
+
+	job, err := h.jobs.UpdateJob(r.Context(), id, req.Name, req.Slug, desc, req.IsActive, policy)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSchemaDriftPolicy) {
+			writeError(w, http.StatusBadRequest, "invalid schema_drift_policy")
+			return
+		}
 		if errors.Is(err, domain.ErrJobNotFound) {
 			writeError(w, http.StatusNotFound, "job not found")
 			return
@@ -123,6 +141,10 @@ func (h *JobHandler) Update(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusConflict, "job slug already exists")
 			return
 		}
+		if errors.Is(err, domain.ErrReplicationSlotRelease) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/openapi"
+	"github.com/user/micro-dp/usecase"
+)
+
+type LineageHandler struct {
+	lineage *usecase.LineageService
+}
+
+func NewLineageHandler(lineage *usecase.LineageService) *LineageHandler {
+	return &LineageHandler{lineage: lineage}
+}
+
+func (h *LineageHandler) GetDatasetLineage(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing id")
+		return
+	}
+
+	q := r.URL.Query()
+	depth := 0
+	if v := q.Get("depth"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "invalid depth")
+			return
+		}
+		depth = n
+	}
+
+	g, err := h.lineage.DatasetGraph(r.Context(), id, q.Get("direction"), depth)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDatasetNotFound):
+			writeError(w, http.StatusNotFound, "dataset not found")
+		case errors.Is(err, domain.ErrInvalidLineageQuery):
+			writeError(w, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("dataset lineage error: %v", err)
+			writeError(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toOpenAPIDatasetLineage(g))
+}
+
+func toOpenAPILineageNode(n domain.LineageNode) openapi.LineageNode {
+	return openapi.LineageNode{Type: openapi.LineageNodeType(n.Type), Id: n.ID}
+}
+
+func toOpenAPIDatasetLineage(g *usecase.LineageGraph) openapi.DatasetLineage {
+	out := openapi.DatasetLineage{
+		Root:  toOpenAPILineageNode(g.Root),
+		Nodes: make([]openapi.LineageGraphNode, len(g.Nodes)),
+		Edges: make([]openapi.LineageEdge, len(g.Edges)),
+	}
+	for i, n := range g.Nodes {
+		node := openapi.LineageGraphNode{
+			Type:  openapi.LineageNodeType(n.Type),
+			Id:    n.ID,
+			Depth: n.Depth,
+		}
+		if n.Name != "" {
+			name := n.Name
+			node.Name = &name
+		}
+		if n.Direction != "" {
+			direction := openapi.LineageDirection(n.Direction)
+			node.Direction = &direction
+		}
+		out.Nodes[i] = node
+	}
+	for i, e := range g.Edges {
+		out.Edges[i] = openapi.LineageEdge{
+			Id:        e.ID,
+			Source:    toOpenAPILineageNode(e.Source),
+			Target:    toOpenAPILineageNode(e.Target),
+			JobRunId:  e.JobRunID,
+			CreatedAt: e.CreatedAt,
+			UpdatedAt: e.UpdatedAt,
+		}
+	}
+	return out
+}
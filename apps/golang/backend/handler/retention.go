@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/openapi"
+	"github.com/user/micro-dp/usecase"
+)
+
+type RetentionHandler struct {
+	retention *usecase.RetentionService
+}
+
+func NewRetentionHandler(retention *usecase.RetentionService) *RetentionHandler {
+	return &RetentionHandler{retention: retention}
+}
+
+func (h *RetentionHandler) GetTenantPolicy(w http.ResponseWriter, r *http.Request) {
+	h.getPolicy(w, r, "")
+}
+
+func (h *RetentionHandler) SetTenantPolicy(w http.ResponseWriter, r *http.Request) {
+	h.setPolicy(w, r, "")
+}
+
+func (h *RetentionHandler) DeleteTenantPolicy(w http.ResponseWriter, r *http.Request) {
+	h.deletePolicy(w, r, "")
+}
+
+func (h *RetentionHandler) GetDatasetPolicy(w http.ResponseWriter, r *http.Request) {
+	h.getPolicy(w, r, r.PathValue("id"))
+}
+
+func (h *RetentionHandler) SetDatasetPolicy(w http.ResponseWriter, r *http.Request) {
+	h.setPolicy(w, r, r.PathValue("id"))
+}
+
+func (h *RetentionHandler) DeleteDatasetPolicy(w http.ResponseWriter, r *http.Request) {
+	h.deletePolicy(w, r, r.PathValue("id"))
+}
+
+func (h *RetentionHandler) ListPurges(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			writeError(w, http.StatusBadRequest, "invalid limit (1-500)")
+			return
+		}
+		limit = n
+	}
+
+	purges, err := h.retention.ListPurges(r.Context(), r.URL.Query().Get("dataset_id"), limit)
+	if err != nil {
+		writeRetentionError(w, err)
+		return
+	}
+
+	items := make([]openapi.RetentionPurge, len(purges))
+	for i := range purges {
+		items[i] = toOpenAPIRetentionPurge(&purges[i])
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Items []openapi.RetentionPurge `json:"items"`
+	}{Items: items})
+}
+
+func (h *RetentionHandler) getPolicy(w http.ResponseWriter, r *http.Request, datasetID string) {
+	p, err := h.retention.GetPolicy(r.Context(), datasetID)
+	if err != nil {
+		writeRetentionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOpenAPIRetentionPolicy(p))
+}
+
+func (h *RetentionHandler) setPolicy(w http.ResponseWriter, r *http.Request, datasetID string) {
+	var req openapi.RetentionPolicyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	archive := req.Archive != nil && *req.Archive
+	p, err := h.retention.SetPolicy(r.Context(), datasetID, req.KeepDays, req.KeepVersions, archive)
+	if err != nil {
+		writeRetentionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOpenAPIRetentionPolicy(p))
+}
+
+func (h *RetentionHandler) deletePolicy(w http.ResponseWriter, r *http.Request, datasetID string) {
+	if err := h.retention.DeletePolicy(r.Context(), datasetID); err != nil {
+		writeRetentionError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeRetentionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidRetentionPolicy), errors.Is(err, domain.ErrRetentionExceedsPlan):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrInsufficientRole):
+		writeError(w, http.StatusForbidden, "insufficient role")
+	case errors.Is(err, domain.ErrDatasetNotFound):
+		writeError(w, http.StatusNotFound, "dataset not found")
+	case errors.Is(err, domain.ErrRetentionPolicyNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		log.Printf("retention error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+func toOpenAPIRetentionPolicy(p *domain.RetentionPolicy) openapi.RetentionPolicy {
+	return openapi.RetentionPolicy{
+		Id:           p.ID,
+		DatasetId:    p.DatasetID,
+		KeepDays:     p.KeepDays,
+		KeepVersions: p.KeepVersions,
+		Archive:      p.Archive,
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+	}
+}
+
+func toOpenAPIRetentionPurge(p *domain.RetentionPurge) openapi.RetentionPurge {
+	return openapi.RetentionPurge{
+		Id:          p.ID,
+		DatasetId:   p.DatasetID,
+		Kind:        openapi.RetentionPurgeKind(p.Kind),
+		Target:      p.Target,
+		Action:      openapi.RetentionPurgeAction(p.Action),
+		ObjectCount: p.ObjectCount,
+		Bytes:       p.Bytes,
+		CreatedAt:   p.CreatedAt,
+	}
+}
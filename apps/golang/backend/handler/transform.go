@@ -2,7 +2,9 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/user/micro-dp/domain"
 	"github.com/user/micro-dp/internal/openapi"
 	"github.com/user/micro-dp/usecase"
 )
@@ -30,7 +32,7 @@ func (h *TransformHandler) Validate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.transform.ValidateSQL(r.Context(), req.Sql, req.DatasetIds)
+	result, err := h.transform.ValidateSQL(r.Context(), req.Sql, req.DatasetIds, fromOpenAPIDatasetVersions(req.DatasetVersions, req.AsOf))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
@@ -73,7 +75,7 @@ func (h *TransformHandler) Preview(w http.ResponseWriter, r *http.Request) {
 		limit = *req.Limit
 	}
 
-	result, err := h.transform.PreviewSQL(r.Context(), req.Sql, req.DatasetIds, limit)
+	result, err := h.transform.PreviewSQL(r.Context(), req.Sql, req.DatasetIds, fromOpenAPIDatasetVersions(req.DatasetVersions, req.AsOf), limit)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -129,13 +131,15 @@ func (h *TransformHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
 	}
 
 	input := usecase.CreateTransformJobInput{
-		Name:        req.Name,
-		Slug:        req.Slug,
-		Description: desc,
-		SQL:         req.Sql,
-		DatasetIDs:  req.DatasetIds,
-		Execution:   execution,
-		ScheduledAt: req.ScheduledAt,
+		Name:            req.Name,
+		Slug:            req.Slug,
+		Description:     desc,
+		SQL:             req.Sql,
+		DatasetIDs:      req.DatasetIds,
+		DatasetVersions: fromOpenAPIDatasetVersions(req.DatasetVersions, req.AsOf),
+		Execution:       execution,
+		ScheduledAt:     req.ScheduledAt,
+		Output:          fromOpenAPITransformOutput(req.Output),
 	}
 
 	result, err := h.transform.CreateTransformJob(r.Context(), input)
@@ -155,3 +159,25 @@ func (h *TransformHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusCreated, resp)
 }
+
+func fromOpenAPIDatasetVersions(versions *map[string]int, asOf *time.Time) usecase.TransformDatasetVersions {
+	out := usecase.TransformDatasetVersions{AsOf: asOf}
+	if versions != nil {
+		out.Versions = *versions
+	}
+	return out
+}
+
+func fromOpenAPITransformOutput(o *openapi.TransformOutput) *domain.TransformOutput {
+	if o == nil {
+		return nil
+	}
+	out := &domain.TransformOutput{DatasetName: o.DatasetName, Mode: string(o.Mode)}
+	if o.KeyColumns != nil {
+		out.KeyColumns = *o.KeyColumns
+	}
+	if o.PartitionColumn != nil {
+		out.PartitionColumn = *o.PartitionColumn
+	}
+	return out
+}
@@ -2,9 +2,12 @@ package handler
 
 import (
 	"errors"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/fileconv"
 	"github.com/user/micro-dp/internal/openapi"
 	"github.com/user/micro-dp/usecase"
 )
@@ -43,11 +46,18 @@ func (h *UploadHandler) Presign(w http.ResponseWriter, r *http.Request) {
 			Filename:    f.Filename,
 			ContentType: f.ContentType,
 			SizeBytes:   f.SizeBytes,
+
+			ParseOptions: fromOpenAPIParseOptions(f.ParseOptions),
+			Target:       fromOpenAPIUploadTarget(f.Target),
 		}
 	}
 
 	result, err := h.uploads.CreatePresign(r.Context(), files)
 	if err != nil {
+		if errors.Is(err, domain.ErrUploadFileTooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -76,8 +86,26 @@ func (h *UploadHandler) Complete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	upload, files, err := h.uploads.Complete(r.Context(), id)
+	// The body is optional
+	var req openapi.CompleteUploadRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	input := usecase.CompleteUploadInput{ParseOptions: fromOpenAPIParseOptions(req.ParseOptions)}
+	if req.Files != nil {
+		input.Files = make(map[string]*fileconv.Options, len(*req.Files))
+		for fileID, opts := range *req.Files {
+			input.Files[fileID] = fromOpenAPIParseOptions(&opts)
+		}
+	}
+
+	upload, files, err := h.uploads.Complete(r.Context(), id, input)
 	if err != nil {
+		if errors.Is(err, domain.ErrUploadFileNotFound) || errors.Is(err, domain.ErrInvalidParseOptions) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		if errors.Is(err, domain.ErrUploadNotFound) {
 			writeError(w, http.StatusNotFound, "upload not found")
 			return
@@ -86,9 +114,201 @@ func (h *UploadHandler) Complete(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusConflict, "upload already complete")
 			return
 		}
+		if errors.Is(err, domain.ErrUploadFileMissing) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
 	writeJSON(w, http.StatusOK, toOpenAPIUpload(upload, files))
 }
+
+func (h *UploadHandler) Reimport(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	fileID := r.PathValue("file_id")
+	if id == "" || fileID == "" {
+		writeError(w, http.StatusBadRequest, "missing upload or file id")
+		return
+	}
+
+	// The body is optional
+	var req openapi.ReimportUploadFileRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	upload, files, err := h.uploads.Reimport(r.Context(), id, fileID, fromOpenAPIParseOptions(req.ParseOptions), fromOpenAPIUploadTarget(req.Target))
+	if err != nil {
+		writeUploadFileError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, toOpenAPIUpload(upload, files))
+}
+
+func (h *UploadHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	fileID := r.PathValue("file_id")
+	if id == "" || fileID == "" {
+		writeError(w, http.StatusBadRequest, "missing upload or file id")
+		return
+	}
+
+	// The body is optional
+	var req openapi.UploadFilePreviewRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	limit := 20
+	if req.Limit != nil {
+		limit = *req.Limit
+	}
+
+	tables, err := h.uploads.Preview(r.Context(), id, fileID, fromOpenAPIParseOptions(req.ParseOptions), limit)
+	if err != nil {
+		writeUploadFileError(w, err)
+		return
+	}
+
+	items := make([]openapi.UploadFilePreviewTable, len(tables))
+	for i, t := range tables {
+		cols := make([]openapi.DatasetColumn, len(t.Columns))
+		for j, c := range t.Columns {
+			cols[j] = openapi.DatasetColumn{Name: c.Name, Type: c.Type}
+		}
+		rows := make([]map[string]interface{}, len(t.Rows))
+		for j, row := range t.Rows {
+			rows[j] = row
+		}
+		items[i] = openapi.UploadFilePreviewTable{DatasetName: t.DatasetName, Columns: cols, Rows: rows}
+	}
+
+	writeJSON(w, http.StatusOK, openapi.UploadFilePreviewResponse{Tables: items})
+}
+
+// writeUploadFileError maps the errors of the single-file upload endpoints.
+func writeUploadFileError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidParseOptions), errors.Is(err, domain.ErrInvalidUploadTarget),
+		errors.Is(err, domain.ErrInvalidUploadPart):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrUploadNotFound):
+		writeError(w, http.StatusNotFound, "upload not found")
+	case errors.Is(err, domain.ErrUploadFileNotFound):
+		writeError(w, http.StatusNotFound, "upload file not found")
+	case errors.Is(err, domain.ErrUploadSessionNotFound):
+		writeError(w, http.StatusNotFound, "file is not a multipart upload")
+	case errors.Is(err, domain.ErrUploadNotComplete), errors.Is(err, domain.ErrUploadFileBusy),
+		errors.Is(err, domain.ErrUploadFileMissing), errors.Is(err, domain.ErrUploadSessionNotActive),
+		errors.Is(err, domain.ErrUploadPartsIncomplete):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrUploadFileTooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, domain.ErrUploadConversion):
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func fromOpenAPIParseOptions(o *openapi.UploadParseOptions) *fileconv.Options {
+	if o == nil {
+		return nil
+	}
+	opts := &fileconv.Options{Header: o.Header}
+	if o.Delimiter != nil {
+		opts.Delimiter = *o.Delimiter
+	}
+	if o.Quote != nil {
+		opts.Quote = *o.Quote
+	}
+	if o.SkipRows != nil {
+		opts.SkipRows = *o.SkipRows
+	}
+	if o.Encoding != nil {
+		opts.Encoding = *o.Encoding
+	}
+	if o.NullStrings != nil {
+		opts.NullStrings = *o.NullStrings
+	}
+	if o.DateFormat != nil {
+		opts.DateFormat = *o.DateFormat
+	}
+	if o.ColumnTypes != nil {
+		opts.ColumnTypes = *o.ColumnTypes
+	}
+	return opts
+}
+
+func (h *UploadHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing upload id")
+		return
+	}
+
+	upload, files, err := h.uploads.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrUploadNotFound) {
+			writeError(w, http.StatusNotFound, "upload not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toOpenAPIUpload(upload, files))
+}
+
+func (h *UploadHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := domain.UploadListFilter{Limit: 50}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			writeError(w, http.StatusBadRequest, "invalid limit (1-500)")
+			return
+		}
+		filter.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid offset")
+			return
+		}
+		filter.Offset = n
+	}
+
+	uploads, err := h.uploads.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	items := make([]openapi.Upload, len(uploads))
+	for i := range uploads {
+		items[i] = toOpenAPIUpload(&uploads[i].Upload, uploads[i].Files)
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Items []openapi.Upload `json:"items"`
+	}{Items: items})
+}
+
+func fromOpenAPIUploadTarget(t *openapi.UploadTarget) *domain.UploadTarget {
+	if t == nil {
+		return nil
+	}
+	target := &domain.UploadTarget{DatasetID: t.DatasetId, Mode: string(t.Mode)}
+	if t.KeyColumns != nil {
+		target.KeyColumns = *t.KeyColumns
+	}
+	return target
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/openapi"
+	"github.com/user/micro-dp/usecase"
+)
+
+func (h *UploadHandler) CreateMultipart(w http.ResponseWriter, r *http.Request) {
+	if err := h.plans.CheckUploadQuota(r.Context()); err != nil {
+		if errors.Is(err, domain.ErrQuotaExceeded) {
+			writeError(w, http.StatusPaymentRequired, "upload quota exceeded")
+			return
+		}
+	}
+
+	var req openapi.CreateMultipartUploadRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	input := usecase.CreateMultipartUploadInput{
+		File: usecase.UploadFileInput{
+			Filename:    req.File.Filename,
+			ContentType: req.File.ContentType,
+			SizeBytes:   req.File.SizeBytes,
+
+			ParseOptions: fromOpenAPIParseOptions(req.File.ParseOptions),
+			Target:       fromOpenAPIUploadTarget(req.File.Target),
+		},
+	}
+	if req.PartSizeBytes != nil {
+		input.PartSizeBytes = *req.PartSizeBytes
+	}
+
+	mu, err := h.uploads.CreateMultipart(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, domain.ErrUploadFileTooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, toOpenAPIMultipartUpload(mu))
+}
+
+func (h *UploadHandler) GetMultipart(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	fileID := r.PathValue("file_id")
+	if id == "" || fileID == "" {
+		writeError(w, http.StatusBadRequest, "missing upload or file id")
+		return
+	}
+
+	mu, err := h.uploads.GetMultipart(r.Context(), id, fileID)
+	if err != nil {
+		writeUploadFileError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toOpenAPIMultipartUpload(mu))
+}
+
+func (h *UploadHandler) PresignParts(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	fileID := r.PathValue("file_id")
+	if id == "" || fileID == "" {
+		writeError(w, http.StatusBadRequest, "missing upload or file id")
+		return
+	}
+
+	var req openapi.PresignUploadPartsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	urls, err := h.uploads.PresignParts(r.Context(), id, fileID, req.PartNumbers)
+	if err != nil {
+		writeUploadFileError(w, err)
+		return
+	}
+
+	parts := make([]openapi.PresignedUploadPart, len(urls))
+	for i, u := range urls {
+		parts[i] = openapi.PresignedUploadPart{PartNumber: u.PartNumber, Url: u.URL, ExpiresAt: u.ExpiresAt}
+	}
+	writeJSON(w, http.StatusOK, openapi.PresignUploadPartsResponse{Parts: parts})
+}
+
+func (h *UploadHandler) CompleteMultipart(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	fileID := r.PathValue("file_id")
+	if id == "" || fileID == "" {
+		writeError(w, http.StatusBadRequest, "missing upload or file id")
+		return
+	}
+
+	mu, err := h.uploads.CompleteMultipart(r.Context(), id, fileID)
+	if err != nil {
+		writeUploadFileError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toOpenAPIMultipartUpload(mu))
+}
+
+func (h *UploadHandler) AbortMultipart(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	fileID := r.PathValue("file_id")
+	if id == "" || fileID == "" {
+		writeError(w, http.StatusBadRequest, "missing upload or file id")
+		return
+	}
+
+	if err := h.uploads.AbortMultipart(r.Context(), id, fileID); err != nil {
+		writeUploadFileError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toOpenAPIMultipartUpload(mu *usecase.MultipartUpload) openapi.MultipartUpload {
+	parts := make([]openapi.UploadedPart, len(mu.Parts))
+	for i, p := range mu.Parts {
+		parts[i] = openapi.UploadedPart{PartNumber: p.PartNumber, Etag: p.ETag, SizeBytes: p.SizeBytes}
+	}
+	return openapi.MultipartUpload{
+		UploadId:      mu.File.UploadID,
+		FileId:        mu.File.ID,
+		Filename:      mu.File.FileName,
+		ObjectKey:     mu.File.ObjectKey,
+		SizeBytes:     mu.File.SizeBytes,
+		PartSizeBytes: mu.Session.PartSizeBytes,
+		PartCount:     mu.Session.PartCount,
+		Status:        openapi.MultipartUploadStatus(mu.Session.Status),
+		Parts:         parts,
+		CreatedAt:     &mu.Session.CreatedAt,
+	}
+}
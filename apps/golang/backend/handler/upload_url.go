@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/openapi"
+	"github.com/user/micro-dp/internal/urlfetch"
+	"github.com/user/micro-dp/usecase"
+)
+
+func (h *UploadHandler) CreateFromURL(w http.ResponseWriter, r *http.Request) {
+	if err := h.plans.CheckUploadQuota(r.Context()); err != nil {
+		if errors.Is(err, domain.ErrQuotaExceeded) {
+			writeError(w, http.StatusPaymentRequired, "upload quota exceeded")
+			return
+		}
+	}
+
+	var req openapi.CreateUrlUploadRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	input := usecase.CreateURLUploadInput{
+		URL:          req.Url,
+		ParseOptions: fromOpenAPIParseOptions(req.ParseOptions),
+		Target:       fromOpenAPIUploadTarget(req.Target),
+	}
+	if req.Filename != nil {
+		input.Filename = *req.Filename
+	}
+	if req.Checksum != nil {
+		input.Checksum = *req.Checksum
+	}
+	if req.Auth != nil {
+		input.Auth = &urlfetch.Auth{Type: string(req.Auth.Type)}
+		if req.Auth.Username != nil {
+			input.Auth.Username = *req.Auth.Username
+		}
+		if req.Auth.Password != nil {
+			input.Auth.Password = *req.Auth.Password
+		}
+		if req.Auth.Token != nil {
+			input.Auth.Token = *req.Auth.Token
+		}
+	}
+
+	upload, files, err := h.uploads.CreateFromURL(r.Context(), input)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, toOpenAPIUpload(upload, files))
+}
@@ -1,6 +1,10 @@
 package connector
 
-import "context"
+import (
+	"context"
+
+	"github.com/user/micro-dp/internal/schemadrift"
+)
 
 // ImportParams holds the generic parameters for an import executor.
 type ImportParams struct {
@@ -10,12 +14,22 @@ type ImportParams struct {
 	VersionID   string
 	Config      map[string]any // module config_json parsed as generic map
 	AccessToken string         // empty for connectors that don't require credentials
+	// ConnectionConfig is the connection's config_json with secrets resolved.
+	ConnectionConfig map[string]any
+	// Checkpoint is the checkpoint_json of the job's last successful run,
+	// empty for the first run or when the connector keeps no checkpoint.
+	Checkpoint string
+	// SchemaDriftPolicy is the job's schemadrift policy for the output dataset.
+	SchemaDriftPolicy string
 }
 
 // ImportResult holds the result of an import execution.
 type ImportResult struct {
-	RowCount  int64
-	OutputKey string
+	RowCount    int64
+	OutputKey   string
+	Datasets    []string              // names of the datasets written
+	SchemaDrift []*schemadrift.Report // one per output dataset whose schema changed
+	Checkpoint  string                // stored as the run's checkpoint_json when non-empty
 }
 
 // ImportExecutor performs data import for a specific connector type.
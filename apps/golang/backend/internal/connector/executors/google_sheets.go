@@ -19,31 +19,116 @@ func NewGoogleSheetsExecutor(writer *worker.SheetsImportWriter) *GoogleSheetsExe
 }
 
 func (e *GoogleSheetsExecutor) ExecuteImport(ctx context.Context, params *connector.ImportParams) (*connector.ImportResult, error) {
+	msg, err := sheetsImportMessage(params)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := e.writer.Execute(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	datasets := make([]string, len(result.Datasets))
+	for i, ds := range result.Datasets {
+		datasets[i] = ds.DatasetName
+	}
+	return &connector.ImportResult{
+		RowCount:    result.RowCount,
+		OutputKey:   result.OutputKey,
+		Datasets:    datasets,
+		SchemaDrift: result.SchemaDrift,
+	}, nil
+}
+
+// sheetsImportMessage builds the writer message from the module config:
+//
+//	{
+//	  "spreadsheet_id": "...",
+//	  "sheets": [
+//	    {"sheet_name": "Orders", "range": "A3:H", "header_row": 1, "dataset_name": "orders"},
+//	    {"sheet_name": "Customers", "dataset_name": "customers"}
+//	  ],
+//	  "value_render_option": "UNFORMATTED_VALUE",
+//	  "date_time_render_option": "FORMATTED_STRING"
+//	}
+//
+// Without "sheets", the top-level sheet_name, range, header_row and
+// dataset_name describe a single sheet (the first sheet when sheet_name is
+// empty).
+func sheetsImportMessage(params *connector.ImportParams) (*worker.SheetsImportMessage, error) {
 	spreadsheetID, _ := params.Config["spreadsheet_id"].(string)
 	if spreadsheetID == "" {
 		return nil, fmt.Errorf("import config missing spreadsheet_id")
 	}
-	sheetName, _ := params.Config["sheet_name"].(string)
-	cellRange, _ := params.Config["range"].(string)
 
-	msg := &worker.SheetsImportMessage{
-		JobRunID:      params.JobRunID,
-		TenantID:      params.TenantID,
-		SpreadsheetID: spreadsheetID,
-		SheetName:     sheetName,
-		Range:         cellRange,
-		AccessToken:   params.AccessToken,
-		JobID:         params.JobID,
-		VersionID:     params.VersionID,
+	var specs []worker.SheetSpec
+	if raw, ok := params.Config["sheets"]; ok {
+		list, ok := raw.([]any)
+		if !ok {
+			return nil, fmt.Errorf("import config sheets must be an array")
+		}
+		for i, item := range list {
+			m, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("import config sheets[%d] must be an object", i)
+			}
+			spec, err := sheetSpec(m)
+			if err != nil {
+				return nil, fmt.Errorf("import config sheets[%d]: %w", i, err)
+			}
+			if spec.SheetName == "" {
+				return nil, fmt.Errorf("import config sheets[%d] missing sheet_name", i)
+			}
+			specs = append(specs, spec)
+		}
+	} else {
+		spec, err := sheetSpec(params.Config)
+		if err != nil {
+			return nil, fmt.Errorf("import config: %w", err)
+		}
+		specs = []worker.SheetSpec{spec}
 	}
 
-	result, err := e.writer.Execute(ctx, msg)
-	if err != nil {
-		return nil, err
+	valueRender, _ := params.Config["value_render_option"].(string)
+	switch valueRender {
+	case "", "UNFORMATTED_VALUE", "FORMATTED_VALUE":
+	default:
+		return nil, fmt.Errorf("import config value_render_option must be UNFORMATTED_VALUE or FORMATTED_VALUE")
+	}
+	dateTimeRender, _ := params.Config["date_time_render_option"].(string)
+	switch dateTimeRender {
+	case "", "FORMATTED_STRING", "SERIAL_NUMBER":
+	default:
+		return nil, fmt.Errorf("import config date_time_render_option must be FORMATTED_STRING or SERIAL_NUMBER")
 	}
 
-	return &connector.ImportResult{
-		RowCount:  result.RowCount,
-		OutputKey: result.OutputKey,
+	return &worker.SheetsImportMessage{
+		JobRunID:             params.JobRunID,
+		TenantID:             params.TenantID,
+		SpreadsheetID:        spreadsheetID,
+		Sheets:               specs,
+		ValueRenderOption:    valueRender,
+		DateTimeRenderOption: dateTimeRender,
+		AccessToken:          params.AccessToken,
+		JobID:                params.JobID,
+		VersionID:            params.VersionID,
+
+		SchemaDriftPolicy: params.SchemaDriftPolicy,
 	}, nil
 }
+
+func sheetSpec(m map[string]any) (worker.SheetSpec, error) {
+	spec := worker.SheetSpec{}
+	spec.SheetName, _ = m["sheet_name"].(string)
+	spec.Range, _ = m["range"].(string)
+	spec.DatasetName, _ = m["dataset_name"].(string)
+	if v, ok := m["header_row"]; ok {
+		n, ok := v.(float64)
+		if !ok || n < 1 || n != float64(int(n)) {
+			return spec, fmt.Errorf("header_row must be a positive integer")
+		}
+		spec.HeaderRow = int(n)
+	}
+	return spec, nil
+}
@@ -3,6 +3,7 @@ package executors
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/user/micro-dp/internal/connector"
@@ -146,22 +147,9 @@ type testableExecutor struct {
 }
 
 func (e *testableExecutor) ExecuteImport(ctx context.Context, params *connector.ImportParams) (*connector.ImportResult, error) {
-	spreadsheetID, _ := params.Config["spreadsheet_id"].(string)
-	if spreadsheetID == "" {
-		return nil, fmt.Errorf("import config missing spreadsheet_id")
-	}
-	sheetName, _ := params.Config["sheet_name"].(string)
-	cellRange, _ := params.Config["range"].(string)
-
-	msg := &worker.SheetsImportMessage{
-		JobRunID:      params.JobRunID,
-		TenantID:      params.TenantID,
-		SpreadsheetID: spreadsheetID,
-		SheetName:     sheetName,
-		Range:         cellRange,
-		AccessToken:   params.AccessToken,
-		JobID:         params.JobID,
-		VersionID:     params.VersionID,
+	msg, err := sheetsImportMessage(params)
+	if err != nil {
+		return nil, err
 	}
 
 	result, err := e.mock.Execute(ctx, msg)
@@ -170,11 +158,79 @@ func (e *testableExecutor) ExecuteImport(ctx context.Context, params *connector.
 	}
 
 	return &connector.ImportResult{
-		RowCount:  result.RowCount,
-		OutputKey: result.OutputKey,
+		RowCount:    result.RowCount,
+		OutputKey:   result.OutputKey,
+		SchemaDrift: result.SchemaDrift,
 	}, nil
 }
 
+func TestSheetsImportMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     map[string]any
+		wantSheets []worker.SheetSpec
+		errContain string
+	}{
+		{
+			name:       "legacy single sheet",
+			config:     map[string]any{"spreadsheet_id": "abc", "sheet_name": "Sheet1", "range": "A1:C9"},
+			wantSheets: []worker.SheetSpec{{SheetName: "Sheet1", Range: "A1:C9"}},
+		},
+		{
+			name:       "first sheet with stable name",
+			config:     map[string]any{"spreadsheet_id": "abc", "dataset_name": "orders", "header_row": float64(3)},
+			wantSheets: []worker.SheetSpec{{HeaderRow: 3, DatasetName: "orders"}},
+		},
+		{
+			name: "multiple sheets",
+			config: map[string]any{
+				"spreadsheet_id": "abc",
+				"sheets": []any{
+					map[string]any{"sheet_name": "Orders", "dataset_name": "orders", "header_row": float64(2)},
+					map[string]any{"sheet_name": "Customers", "range": "B:F"},
+				},
+			},
+			wantSheets: []worker.SheetSpec{
+				{SheetName: "Orders", HeaderRow: 2, DatasetName: "orders"},
+				{SheetName: "Customers", Range: "B:F"},
+			},
+		},
+		{
+			name:       "sheets entry without sheet_name",
+			config:     map[string]any{"spreadsheet_id": "abc", "sheets": []any{map[string]any{"dataset_name": "x"}}},
+			errContain: "sheet_name",
+		},
+		{
+			name:       "invalid header_row",
+			config:     map[string]any{"spreadsheet_id": "abc", "header_row": float64(0)},
+			errContain: "header_row",
+		},
+		{
+			name:       "invalid value_render_option",
+			config:     map[string]any{"spreadsheet_id": "abc", "value_render_option": "FORMULA"},
+			errContain: "value_render_option",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := sheetsImportMessage(&connector.ImportParams{Config: tt.config})
+			if tt.errContain != "" {
+				if err == nil || !containsStr(err.Error(), tt.errContain) {
+					t.Fatalf("error = %v, want containing %q", err, tt.errContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(msg.Sheets, tt.wantSheets) {
+				t.Errorf("Sheets = %+v, want %+v", msg.Sheets, tt.wantSheets)
+			}
+		})
+	}
+}
+
 func containsStr(s, substr string) bool {
 	for i := 0; i <= len(s)-len(substr); i++ {
 		if s[i:i+len(substr)] == substr {
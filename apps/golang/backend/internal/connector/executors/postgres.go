@@ -0,0 +1,164 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/user/micro-dp/internal/connector"
+	"github.com/user/micro-dp/internal/pgcdc"
+	"github.com/user/micro-dp/worker"
+)
+
+// PostgresExecutor adapts PostgresCDCWriter to the ImportExecutor interface.
+type PostgresExecutor struct {
+	writer *worker.PostgresCDCWriter
+}
+
+// NewPostgresExecutor creates a new PostgresExecutor wrapping the given PostgresCDCWriter.
+func NewPostgresExecutor(writer *worker.PostgresCDCWriter) *PostgresExecutor {
+	return &PostgresExecutor{writer: writer}
+}
+
+func (e *PostgresExecutor) ExecuteImport(ctx context.Context, params *connector.ImportParams) (*connector.ImportResult, error) {
+	msg, err := postgresCDCMessage(params)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := e.writer.Execute(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint, err := json.Marshal(result.Checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	datasets := make([]string, len(result.Datasets))
+	for i, ds := range result.Datasets {
+		datasets[i] = ds.DatasetName
+	}
+	return &connector.ImportResult{
+		RowCount:    result.RowCount,
+		OutputKey:   result.OutputKey,
+		Datasets:    datasets,
+		SchemaDrift: result.SchemaDrift,
+		Checkpoint:  string(checkpoint),
+	}, nil
+}
+
+// postgresCDCMessage builds the writer message from the connection config
+// and the module config:
+//
+//	{
+//	  "mode": "cdc",
+//	  "tables": ["public.orders", {"table": "public.customers", "dataset_name": "customers"}],
+//	  "slot_name": "micro_dp_orders",
+//	  "publication": "micro_dp_orders",
+//	  "idle_timeout_seconds": 10,
+//	  "max_changes": 100000
+//	}
+//
+// slot_name and publication default to "micro_dp_<job id>". A checkpoint
+// for another slot is ignored, so renaming the slot starts over from a
+// snapshot.
+func postgresCDCMessage(params *connector.ImportParams) (*worker.PostgresCDCMessage, error) {
+	if mode, _ := params.Config["mode"].(string); mode != "cdc" {
+		return nil, fmt.Errorf(`import config mode must be "cdc"`)
+	}
+
+	source, err := pgcdc.ConfigFromConnection(params.ConnectionConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultName := pgcdc.DefaultName(params.JobID)
+	slot, _ := params.Config["slot_name"].(string)
+	if slot == "" {
+		slot = defaultName
+	}
+	publication, _ := params.Config["publication"].(string)
+	if publication == "" {
+		publication = defaultName
+	}
+	if !pgcdc.ValidName(slot) {
+		return nil, fmt.Errorf("import config slot_name must be 1-63 lower-case letters, digits or underscores")
+	}
+	if !pgcdc.ValidName(publication) {
+		return nil, fmt.Errorf("import config publication must be 1-63 lower-case letters, digits or underscores")
+	}
+
+	list, _ := params.Config["tables"].([]any)
+	if len(list) == 0 {
+		return nil, fmt.Errorf("import config tables must be a non-empty array")
+	}
+	tables := make([]worker.CDCTableSpec, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for i, item := range list {
+		var spec worker.CDCTableSpec
+		switch v := item.(type) {
+		case string:
+			spec.Table = v
+		case map[string]any:
+			spec.Table, _ = v["table"].(string)
+			spec.DatasetName, _ = v["dataset_name"].(string)
+		default:
+			return nil, fmt.Errorf("import config tables[%d] must be a string or an object", i)
+		}
+		t, err := pgcdc.ParseTable(spec.Table)
+		if err != nil {
+			return nil, fmt.Errorf("import config tables[%d]: %w", i, err)
+		}
+		if seen[t.String()] {
+			return nil, fmt.Errorf("import config tables[%d]: %s is listed twice", i, t)
+		}
+		seen[t.String()] = true
+		tables = append(tables, spec)
+	}
+
+	var idle time.Duration
+	if v, ok := params.Config["idle_timeout_seconds"]; ok {
+		n, ok := v.(float64)
+		if !ok || n <= 0 {
+			return nil, fmt.Errorf("import config idle_timeout_seconds must be a positive number")
+		}
+		idle = time.Duration(n * float64(time.Second))
+	}
+	var maxChanges int64
+	if v, ok := params.Config["max_changes"]; ok {
+		n, ok := v.(float64)
+		if !ok || n < 0 || n != float64(int64(n)) {
+			return nil, fmt.Errorf("import config max_changes must be a non-negative integer")
+		}
+		maxChanges = int64(n)
+	}
+
+	var checkpoint *worker.PostgresCDCCheckpoint
+	if params.Checkpoint != "" {
+		var cp worker.PostgresCDCCheckpoint
+		if err := json.Unmarshal([]byte(params.Checkpoint), &cp); err != nil {
+			return nil, fmt.Errorf("parse checkpoint: %w", err)
+		}
+		if cp.SlotName == slot && cp.LSN != "" {
+			checkpoint = &cp
+		}
+	}
+
+	return &worker.PostgresCDCMessage{
+		JobRunID:    params.JobRunID,
+		TenantID:    params.TenantID,
+		JobID:       params.JobID,
+		VersionID:   params.VersionID,
+		Source:      *source,
+		SlotName:    slot,
+		Publication: publication,
+		Tables:      tables,
+		Checkpoint:  checkpoint,
+		IdleTimeout: idle,
+		MaxChanges:  maxChanges,
+
+		SchemaDriftPolicy: params.SchemaDriftPolicy,
+	}, nil
+}
@@ -0,0 +1,121 @@
+package executors
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/user/micro-dp/internal/connector"
+	"github.com/user/micro-dp/worker"
+)
+
+func TestPostgresCDCMessage(t *testing.T) {
+	conn := map[string]any{
+		"host": "db", "port": float64(5433), "database": "shop",
+		"username": "cdc", "password": "secret", "ssl_mode": "require",
+	}
+
+	tests := []struct {
+		name           string
+		config         map[string]any
+		checkpoint     string
+		wantTables     []worker.CDCTableSpec
+		wantSlot       string
+		wantCheckpoint bool
+		errContain     string
+	}{
+		{
+			name: "defaults",
+			config: map[string]any{
+				"mode":   "cdc",
+				"tables": []any{"orders", map[string]any{"table": "sales.customers", "dataset_name": "customers"}},
+			},
+			wantTables: []worker.CDCTableSpec{
+				{Table: "orders"},
+				{Table: "sales.customers", DatasetName: "customers"},
+			},
+			wantSlot: "micro_dp_0b1f_aa",
+		},
+		{
+			name:           "checkpoint of the same slot",
+			config:         map[string]any{"mode": "cdc", "tables": []any{"orders"}, "slot_name": "orders_cdc"},
+			checkpoint:     `{"slot_name":"orders_cdc","publication":"orders_cdc","lsn":"0/16B3748"}`,
+			wantTables:     []worker.CDCTableSpec{{Table: "orders"}},
+			wantSlot:       "orders_cdc",
+			wantCheckpoint: true,
+		},
+		{
+			name:       "checkpoint of another slot is ignored",
+			config:     map[string]any{"mode": "cdc", "tables": []any{"orders"}, "slot_name": "orders_cdc"},
+			checkpoint: `{"slot_name":"old_slot","lsn":"0/16B3748"}`,
+			wantTables: []worker.CDCTableSpec{{Table: "orders"}},
+			wantSlot:   "orders_cdc",
+		},
+		{
+			name:       "missing mode",
+			config:     map[string]any{"tables": []any{"orders"}},
+			errContain: "mode",
+		},
+		{
+			name:       "no tables",
+			config:     map[string]any{"mode": "cdc", "tables": []any{}},
+			errContain: "tables",
+		},
+		{
+			name:       "duplicate table",
+			config:     map[string]any{"mode": "cdc", "tables": []any{"orders", "public.orders"}},
+			errContain: "listed twice",
+		},
+		{
+			name:       "invalid slot name",
+			config:     map[string]any{"mode": "cdc", "tables": []any{"orders"}, "slot_name": "Orders-CDC"},
+			errContain: "slot_name",
+		},
+		{
+			name:       "invalid max_changes",
+			config:     map[string]any{"mode": "cdc", "tables": []any{"orders"}, "max_changes": float64(1.5)},
+			errContain: "max_changes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := postgresCDCMessage(&connector.ImportParams{
+				JobID:            "0B1F-AA",
+				Config:           tt.config,
+				ConnectionConfig: conn,
+				Checkpoint:       tt.checkpoint,
+			})
+			if tt.errContain != "" {
+				if err == nil || !containsStr(err.Error(), tt.errContain) {
+					t.Fatalf("error = %v, want containing %q", err, tt.errContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(msg.Tables, tt.wantTables) {
+				t.Errorf("tables = %+v, want %+v", msg.Tables, tt.wantTables)
+			}
+			if msg.SlotName != tt.wantSlot {
+				t.Errorf("slot = %q, want %q", msg.SlotName, tt.wantSlot)
+			}
+			if (msg.Checkpoint != nil) != tt.wantCheckpoint {
+				t.Errorf("checkpoint = %+v, want present=%v", msg.Checkpoint, tt.wantCheckpoint)
+			}
+			if msg.Source.Host != "db" || msg.Source.Port != 5433 || msg.Source.Password != "secret" || msg.Source.SSLMode != "require" {
+				t.Errorf("unexpected source %+v", msg.Source)
+			}
+		})
+	}
+
+	msg, err := postgresCDCMessage(&connector.ImportParams{
+		Config:           map[string]any{"mode": "cdc", "tables": []any{"orders"}, "idle_timeout_seconds": float64(2.5)},
+		ConnectionConfig: conn,
+		JobID:            "job",
+	})
+	if err != nil || msg.IdleTimeout != 2500*time.Millisecond {
+		t.Fatalf("idle timeout = %v, %v", msg, err)
+	}
+}
@@ -0,0 +1,47 @@
+package connector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validCapabilities lists the capability values a definition may declare.
+var validCapabilities = map[string]bool{"testable": true, "fetchable": true, "importable": true}
+
+// Lint checks structural invariants for a definition and returns one message
+// per violation. Both embedded and external definitions must pass before
+// they are added to the registry.
+func Lint(def *Definition) []string {
+	var problems []string
+
+	// Required fields
+	if def.ID == "" {
+		problems = append(problems, "id is empty")
+	}
+	if def.Name == "" {
+		problems = append(problems, "name is empty")
+	}
+	if def.Kind == "" {
+		problems = append(problems, "kind is empty")
+	} else if def.Kind != "source" && def.Kind != "destination" {
+		problems = append(problems, fmt.Sprintf("kind must be source or destination, got %q", def.Kind))
+	}
+
+	// ID format: {kind}-{rest}
+	if def.ID != "" && def.Kind != "" && !strings.HasPrefix(def.ID, def.Kind+"-") {
+		problems = append(problems, fmt.Sprintf("id %q should start with %q", def.ID, def.Kind+"-"))
+	}
+
+	// Capabilities must be known values
+	for _, cap := range def.Capabilities {
+		if !validCapabilities[cap] {
+			problems = append(problems, fmt.Sprintf("unknown capability %q", cap))
+		}
+	}
+
+	if len(def.Spec) == 0 {
+		problems = append(problems, "spec is empty")
+	}
+
+	return problems
+}
@@ -4,9 +4,14 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"log"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
 )
@@ -14,7 +19,14 @@ import (
 //go:embed definitions/sources/*.json definitions/destinations/*.json
 var definitionsFS embed.FS
 
-// Definition represents a connector definition loaded from embedded JSON.
+// Definition sources.
+const (
+	SourceEmbedded = "embedded"
+	SourceExternal = "external"
+)
+
+// Definition represents a connector definition loaded from embedded JSON
+// or from the external definitions directory.
 type Definition struct {
 	ID                 string          `json:"id"`
 	Name               string          `json:"name"`
@@ -24,6 +36,9 @@ type Definition struct {
 	CredentialProvider string          `json:"x-credential-provider"`
 	Capabilities       []string        `json:"capabilities"`
 	Spec               json.RawMessage `json:"spec"`
+
+	Source string `json:"-"` // SourceEmbedded or SourceExternal
+	Path   string `json:"-"` // file the definition was loaded from
 }
 
 // HasCapability returns true if the definition includes the given capability.
@@ -36,10 +51,47 @@ func (d *Definition) HasCapability(cap string) bool {
 	return false
 }
 
+// LoadError describes a definition file that could not be loaded.
+// Invalid files are skipped; the rest of the registry stays usable.
+type LoadError struct {
+	Source       string `json:"source"`
+	Path         string `json:"path"`
+	DefinitionID string `json:"definition_id,omitempty"`
+	Message      string `json:"message"`
+	// KeptPrevious is set when the definition last loaded from the file is
+	// still served in its place.
+	KeptPrevious bool `json:"kept_previous,omitempty"`
+}
+
+func (e LoadError) Error() string {
+	if e.DefinitionID != "" {
+		return fmt.Sprintf("%s (%s): %s", e.Path, e.DefinitionID, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Status is a snapshot of the registry's last load.
+type Status struct {
+	DefinitionsDir string
+	LoadedAt       time.Time
+	Definitions    []*Definition
+	Errors         []LoadError
+}
+
 // Registry holds all connector definitions and their compiled JSON Schemas.
+// Definitions can be reloaded at runtime; registered testers, fetchers and
+// executors are keyed by connector ID and survive reloads.
 type Registry struct {
-	defs      map[string]*Definition
-	schemas   map[string]*jsonschema.Schema
+	mu          sync.RWMutex
+	externalDir string
+	defs        map[string]*Definition
+	schemas     map[string]*jsonschema.Schema
+	loadErrors  []LoadError
+	loadedAt    time.Time
+	// lastGood holds the contents of each external file as last loaded
+	// without error, keyed by path.
+	lastGood map[string][]byte
+
 	testers   map[string]ConnectionTester
 	fetchers  map[string]SchemaFetcher
 	executors map[string]ImportExecutor
@@ -51,32 +103,70 @@ var (
 )
 
 // Global returns the singleton Registry, loading definitions on first call.
-// Panics (via log.Fatalf) if any definition is invalid.
+// Invalid definitions are skipped and reported via Status instead of
+// aborting startup.
 func Global() *Registry {
 	globalOnce.Do(func() {
-		r, err := load()
-		if err != nil {
-			log.Fatalf("connector registry: %v", err)
-		}
-		globalRegistry = r
+		globalRegistry = New(LoadConfig().DefinitionsDir)
 	})
 	return globalRegistry
 }
 
-func load() (*Registry, error) {
+// New creates a Registry from the embedded definitions merged with the
+// definitions found in externalDir (if non-empty).
+func New(externalDir string) *Registry {
 	r := &Registry{
-		defs:      make(map[string]*Definition),
-		schemas:   make(map[string]*jsonschema.Schema),
-		testers:   make(map[string]ConnectionTester),
-		fetchers:  make(map[string]SchemaFetcher),
-		executors: make(map[string]ImportExecutor),
+		externalDir: externalDir,
+		defs:        make(map[string]*Definition),
+		schemas:     make(map[string]*jsonschema.Schema),
+		testers:     make(map[string]ConnectionTester),
+		fetchers:    make(map[string]SchemaFetcher),
+		executors:   make(map[string]ImportExecutor),
+	}
+	r.Reload()
+	return r
+}
+
+// load builds a registry from the embedded definitions only and fails on
+// the first invalid definition. Used by tests to gate embedded connectors.
+func load() (*Registry, error) {
+	r := New("")
+	if len(r.loadErrors) > 0 {
+		return nil, r.loadErrors[0]
+	}
+	return r, nil
+}
+
+// Reload re-reads the embedded and external definitions and atomically
+// replaces the current set. External definitions override embedded ones
+// with the same ID. An external file that fails to load, such as one being
+// written while the watcher polls, keeps its last good definition until it
+// loads again or is removed. Returns the per-file errors encountered.
+func (r *Registry) Reload() []LoadError {
+	defs := make(map[string]*Definition)
+	schemas := make(map[string]*jsonschema.Schema)
+	var errs []LoadError
+
+	r.mu.RLock()
+	lastGood := r.lastGood
+	r.mu.RUnlock()
+	good := make(map[string][]byte)
+	// keepPrevious serves the last good contents of a file that failed
+	// to load.
+	keepPrevious := func(lerr *LoadError) {
+		prev, ok := lastGood[lerr.Path]
+		if ok && addDefinition(defs, schemas, SourceExternal, lerr.Path, prev) == nil {
+			lerr.KeptPrevious = true
+			good[lerr.Path] = prev
+		}
+		errs = append(errs, *lerr)
 	}
 
-	dirs := []string{"definitions/sources", "definitions/destinations"}
-	for _, dir := range dirs {
+	for _, dir := range []string{"definitions/sources", "definitions/destinations"} {
 		entries, err := definitionsFS.ReadDir(dir)
 		if err != nil {
-			return nil, fmt.Errorf("read dir %s: %w", dir, err)
+			errs = append(errs, LoadError{Source: SourceEmbedded, Path: dir, Message: err.Error()})
+			continue
 		}
 		for _, e := range entries {
 			if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
@@ -85,33 +175,108 @@ func load() (*Registry, error) {
 			path := dir + "/" + e.Name()
 			data, err := definitionsFS.ReadFile(path)
 			if err != nil {
-				return nil, fmt.Errorf("read %s: %w", path, err)
+				errs = append(errs, LoadError{Source: SourceEmbedded, Path: path, Message: err.Error()})
+				continue
+			}
+			if lerr := addDefinition(defs, schemas, SourceEmbedded, path, data); lerr != nil {
+				errs = append(errs, *lerr)
 			}
+		}
+	}
 
-			var def Definition
-			if err := json.Unmarshal(data, &def); err != nil {
-				return nil, fmt.Errorf("parse %s: %w", path, err)
+	if r.externalDir != "" {
+		paths, err := externalDefinitionFiles(r.externalDir)
+		listed := make(map[string]bool, len(paths))
+		for _, path := range paths {
+			listed[path] = true
+			data, err := os.ReadFile(path)
+			if err != nil {
+				keepPrevious(&LoadError{Source: SourceExternal, Path: path, Message: err.Error()})
+				continue
 			}
-			if def.ID == "" || def.Kind == "" {
-				return nil, fmt.Errorf("definition %s: id and kind are required", path)
+			if lerr := addDefinition(defs, schemas, SourceExternal, path, data); lerr != nil {
+				keepPrevious(lerr)
+				continue
 			}
-			if _, exists := r.defs[def.ID]; exists {
-				return nil, fmt.Errorf("duplicate definition id: %s", def.ID)
+			good[path] = data
+		}
+		if err != nil {
+			errs = append(errs, LoadError{Source: SourceExternal, Path: r.externalDir, Message: err.Error()})
+			// The files the directory listing missed were not removed
+			for _, path := range sortedKeys(lastGood) {
+				if !listed[path] {
+					keepPrevious(&LoadError{Source: SourceExternal, Path: path, Message: "not listed: " + err.Error()})
+				}
 			}
+		}
+	}
 
-			// Compile JSON Schema for the spec.
-			schema, err := compileSchema(def.ID, def.Spec)
-			if err != nil {
-				return nil, fmt.Errorf("compile schema for %s: %w", def.ID, err)
-			}
+	r.mu.Lock()
+	r.defs = defs
+	r.schemas = schemas
+	r.loadErrors = errs
+	r.lastGood = good
+	r.loadedAt = time.Now().UTC()
+	r.mu.Unlock()
 
-			r.defs[def.ID] = &def
-			r.schemas[def.ID] = schema
-		}
+	log.Printf("connector registry: loaded %d definitions (%d errors)", len(defs), len(errs))
+	for _, e := range errs {
+		log.Printf("connector registry: skipped %s", e.Error())
 	}
+	return errs
+}
 
-	log.Printf("connector registry: loaded %d definitions", len(r.defs))
-	return r, nil
+// addDefinition parses, lints and compiles a single definition file and adds
+// it to defs/schemas. Embedded IDs must be unique; an external definition
+// replaces an embedded one with the same ID but not another external one.
+func addDefinition(defs map[string]*Definition, schemas map[string]*jsonschema.Schema, source, path string, data []byte) *LoadError {
+	var def Definition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return &LoadError{Source: source, Path: path, Message: fmt.Sprintf("parse: %v", err)}
+	}
+	def.Source = source
+	def.Path = path
+
+	if problems := Lint(&def); len(problems) > 0 {
+		return &LoadError{Source: source, Path: path, DefinitionID: def.ID, Message: strings.Join(problems, "; ")}
+	}
+	if existing, exists := defs[def.ID]; exists && (source == SourceEmbedded || existing.Source == SourceExternal) {
+		return &LoadError{Source: source, Path: path, DefinitionID: def.ID, Message: fmt.Sprintf("duplicate definition id (already loaded from %s)", existing.Path)}
+	}
+
+	schema, err := compileSchema(def.ID, def.Spec)
+	if err != nil {
+		return &LoadError{Source: source, Path: path, DefinitionID: def.ID, Message: fmt.Sprintf("compile schema: %v", err)}
+	}
+
+	defs[def.ID] = &def
+	schemas[def.ID] = schema
+	return nil
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// externalDefinitionFiles returns all *.json files below dir in lexical order.
+func externalDefinitionFiles(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".json") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	sort.Strings(paths)
+	return paths, err
 }
 
 func compileSchema(id string, raw json.RawMessage) (*jsonschema.Schema, error) {
@@ -128,19 +293,44 @@ func compileSchema(id string, raw json.RawMessage) (*jsonschema.Schema, error) {
 	return c.Compile(url)
 }
 
+// Status returns a snapshot of the loaded definitions and load errors.
+func (r *Registry) Status() Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	defs := make([]*Definition, 0, len(r.defs))
+	for _, d := range r.defs {
+		defs = append(defs, d)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].ID < defs[j].ID })
+
+	return Status{
+		DefinitionsDir: r.externalDir,
+		LoadedAt:       r.loadedAt,
+		Definitions:    defs,
+		Errors:         append([]LoadError(nil), r.loadErrors...),
+	}
+}
+
 // Get returns a definition by ID or nil if not found.
 func (r *Registry) Get(id string) *Definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	return r.defs[id]
 }
 
 // Exists returns true if a definition with the given ID exists.
 func (r *Registry) Exists(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	_, ok := r.defs[id]
 	return ok
 }
 
 // List returns definitions filtered by kind. Pass "" to return all.
 func (r *Registry) List(kind string) []*Definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	out := make([]*Definition, 0, len(r.defs))
 	for _, d := range r.defs {
 		if kind == "" || d.Kind == kind {
@@ -152,38 +342,52 @@ func (r *Registry) List(kind string) []*Definition {
 
 // RegisterTester registers a ConnectionTester for a given connector ID.
 func (r *Registry) RegisterTester(connectorID string, t ConnectionTester) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.testers[connectorID] = t
 }
 
 // GetTester returns the ConnectionTester for a connector ID, or nil if not registered.
 func (r *Registry) GetTester(connectorID string) ConnectionTester {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	return r.testers[connectorID]
 }
 
 // RegisterFetcher registers a SchemaFetcher for a given connector ID.
 func (r *Registry) RegisterFetcher(connectorID string, f SchemaFetcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.fetchers[connectorID] = f
 }
 
 // GetFetcher returns the SchemaFetcher for a connector ID, or nil if not registered.
 func (r *Registry) GetFetcher(connectorID string) SchemaFetcher {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	return r.fetchers[connectorID]
 }
 
 // RegisterExecutor registers an ImportExecutor for a given connector ID.
 func (r *Registry) RegisterExecutor(connectorID string, e ImportExecutor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.executors[connectorID] = e
 }
 
 // GetExecutor returns the ImportExecutor for a connector ID, or nil if not registered.
 func (r *Registry) GetExecutor(connectorID string) ImportExecutor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	return r.executors[connectorID]
 }
 
 // ValidateConfig validates a JSON config string against the connector's spec.
 // Returns nil if valid. Returns an error describing validation failures.
 func (r *Registry) ValidateConfig(connectorID, configJSON string) error {
+	r.mu.RLock()
 	schema, ok := r.schemas[connectorID]
+	r.mu.RUnlock()
 	if !ok {
 		return fmt.Errorf("unknown connector: %s", connectorID)
 	}
@@ -2,7 +2,8 @@ package connector
 
 import (
 	"fmt"
-	"strings"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -19,7 +20,8 @@ func TestDefinitionsLoad(t *testing.T) {
 }
 
 // TestDefinitionsLint checks structural invariants for every definition.
-// New connectors automatically inherit these checks.
+// New connectors automatically inherit these checks; external definitions
+// are validated with the same Lint rules at load time.
 func TestDefinitionsLint(t *testing.T) {
 	r, err := load()
 	if err != nil {
@@ -28,36 +30,8 @@ func TestDefinitionsLint(t *testing.T) {
 
 	for id, def := range r.defs {
 		t.Run(id, func(t *testing.T) {
-			// Required fields
-			if def.ID == "" {
-				t.Error("id is empty")
-			}
-			if def.Name == "" {
-				t.Error("name is empty")
-			}
-			if def.Kind == "" {
-				t.Error("kind is empty")
-			}
-			if def.Kind != "source" && def.Kind != "destination" {
-				t.Errorf("kind must be source or destination, got %q", def.Kind)
-			}
-
-			// ID format: {kind}-{rest}
-			if !strings.HasPrefix(def.ID, def.Kind+"-") {
-				t.Errorf("id %q should start with %q", def.ID, def.Kind+"-")
-			}
-
-			// Capabilities must be known values
-			validCaps := map[string]bool{"testable": true, "fetchable": true, "importable": true}
-			for _, cap := range def.Capabilities {
-				if !validCaps[cap] {
-					t.Errorf("unknown capability %q", cap)
-				}
-			}
-
-			// Spec must be valid JSON (already parsed, but check non-nil)
-			if len(def.Spec) == 0 {
-				t.Error("spec is empty")
+			for _, problem := range Lint(def) {
+				t.Error(problem)
 			}
 
 			// Schema must have compiled successfully
@@ -139,3 +113,112 @@ func TestValidateConfig_Template(t *testing.T) {
 		})
 	}
 }
+
+// TestExternalDefinitions verifies that external definitions are merged over
+// the embedded set and that invalid files are reported instead of failing the load.
+func TestExternalDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	write("custom.json", `{"id":"source-custom","name":"Custom","kind":"source","capabilities":["testable"],"spec":{"type":"object"}}`)
+	write("postgres.json", `{"id":"source-postgres","name":"Postgres Override","kind":"source","spec":{"type":"object"}}`)
+	write("bad-kind.json", `{"id":"sink-bad","name":"Bad","kind":"sink","spec":{"type":"object"}}`)
+	write("broken.json", `{not json`)
+
+	r := New(dir)
+
+	if def := r.Get("source-custom"); def == nil || def.Source != SourceExternal {
+		t.Errorf("expected external source-custom, got %+v", def)
+	}
+	if def := r.Get("source-postgres"); def == nil || def.Name != "Postgres Override" {
+		t.Errorf("expected external override of source-postgres, got %+v", def)
+	}
+	if r.Exists("sink-bad") {
+		t.Error("invalid definition should not be registered")
+	}
+	if !r.Exists("source-google-sheets") {
+		t.Error("embedded definitions should still be loaded")
+	}
+
+	errs := r.Status().Errors
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 load errors, got %d: %+v", len(errs), errs)
+	}
+	for _, e := range errs {
+		if e.Source != SourceExternal {
+			t.Errorf("unexpected error source %q", e.Source)
+		}
+	}
+
+	// Fixing the broken file and reloading clears its error.
+	write("broken.json", `{"id":"destination-fixed","name":"Fixed","kind":"destination","spec":{"type":"object"}}`)
+	if errs := r.Reload(); len(errs) != 1 {
+		t.Errorf("expected 1 load error after reload, got %d: %+v", len(errs), errs)
+	}
+	if !r.Exists("destination-fixed") {
+		t.Error("expected destination-fixed after reload")
+	}
+}
+
+// TestReloadKeepsLastGoodDefinition verifies that an external file that
+// stops loading, as when it is half-written during a poll, keeps serving
+// the definition it last loaded, and that the error is reported.
+func TestReloadKeepsLastGoodDefinition(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	write("custom.json", `{"id":"source-custom","name":"Custom","kind":"source","spec":{"type":"object"}}`)
+	write("postgres.json", `{"id":"source-postgres","name":"Postgres Override","kind":"source","spec":{"type":"object"}}`)
+	r := New(dir)
+
+	// Both files break: the external-only connector and the override stay
+	write("custom.json", `{"id":"source-custom","name":"Cus`)
+	write("postgres.json", `{"id":"source-postgres","name":"Postgres Override","kind":"sink","spec":{"type":"object"}}`)
+	errs := r.Reload()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 load errors, got %d: %+v", len(errs), errs)
+	}
+	for _, e := range r.Status().Errors {
+		if !e.KeptPrevious {
+			t.Errorf("error %+v does not report the kept definition", e)
+		}
+	}
+	if def := r.Get("source-custom"); def == nil || def.Name != "Custom" {
+		t.Errorf("expected the last good source-custom, got %+v", def)
+	}
+	if def := r.Get("source-postgres"); def == nil || def.Name != "Postgres Override" {
+		t.Errorf("expected the last good source-postgres override, got %+v", def)
+	}
+
+	// A file that never loaded has nothing to keep
+	write("new.json", `{not json`)
+	for _, e := range r.Reload() {
+		if e.KeptPrevious != (filepath.Base(e.Path) != "new.json") {
+			t.Errorf("error %+v, want kept_previous only for files that loaded before", e)
+		}
+	}
+
+	// Fixing a file serves its new contents; removing one drops it
+	write("custom.json", `{"id":"source-custom","name":"Custom v2","kind":"source","spec":{"type":"object"}}`)
+	if err := os.Remove(filepath.Join(dir, "postgres.json")); err != nil {
+		t.Fatal(err)
+	}
+	if errs := r.Reload(); len(errs) != 1 || errs[0].KeptPrevious {
+		t.Errorf("expected only the error of new.json, got %+v", errs)
+	}
+	if def := r.Get("source-custom"); def == nil || def.Name != "Custom v2" {
+		t.Errorf("expected the fixed source-custom, got %+v", def)
+	}
+	if def := r.Get("source-postgres"); def == nil || def.Source != SourceEmbedded {
+		t.Errorf("expected the embedded source-postgres once the override is removed, got %+v", def)
+	}
+}
@@ -0,0 +1,58 @@
+package connector
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// RedactedValue replaces x-secret field values in API responses. Sending it
+// back on update keeps the stored value.
+const RedactedValue = "**********"
+
+// SecretFields returns the top-level spec properties marked "x-secret": true.
+func (d *Definition) SecretFields() []string {
+	var spec struct {
+		Properties map[string]struct {
+			Secret bool `json:"x-secret"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(d.Spec, &spec); err != nil {
+		return nil
+	}
+	var fields []string
+	for name, p := range spec.Properties {
+		if p.Secret {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// RedactConfig replaces the values of the definition's secret fields in
+// configJSON with RedactedValue. Invalid JSON is returned unchanged.
+func (d *Definition) RedactConfig(configJSON string) string {
+	fields := d.SecretFields()
+	if len(fields) == 0 {
+		return configJSON
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+		return configJSON
+	}
+	changed := false
+	for _, f := range fields {
+		if v, ok := cfg[f]; ok && v != nil && v != "" {
+			cfg[f] = RedactedValue
+			changed = true
+		}
+	}
+	if !changed {
+		return configJSON
+	}
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		return configJSON
+	}
+	return string(out)
+}
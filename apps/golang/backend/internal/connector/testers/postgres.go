@@ -0,0 +1,81 @@
+package testers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/user/micro-dp/internal/connector"
+	"github.com/user/micro-dp/internal/pgcdc"
+)
+
+// PostgresTester tests connectivity by connecting to the source database.
+// It also fails when a replication slot that no run is using retains more
+// WAL than the limit, as an abandoned slot fills the source's disk.
+type PostgresTester struct {
+	slotLagLimit int64
+}
+
+// NewPostgresTester creates a PostgresTester. A slotLagLimit of 0 only
+// reports slot lag without failing on it.
+func NewPostgresTester(slotLagLimit int64) *PostgresTester {
+	return &PostgresTester{slotLagLimit: slotLagLimit}
+}
+
+func (t *PostgresTester) Test(ctx context.Context, configJSON string, accessToken string) *connector.TestResult {
+	var config map[string]any
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return &connector.TestResult{OK: false, Code: "invalid_config", Message: err.Error()}
+	}
+	cfg, err := pgcdc.ConfigFromConnection(config)
+	if err != nil {
+		return &connector.TestResult{OK: false, Code: "invalid_config", Message: err.Error()}
+	}
+	slots, err := pgcdc.Slots(ctx, *cfg)
+	if err != nil {
+		return &connector.TestResult{OK: false, Code: "connection_failed", Message: err.Error()}
+	}
+	return slotResult(slots, t.slotLagLimit)
+}
+
+// slotResult reports the WAL retained by each slot, failing with code
+// slot_lag when an inactive slot retains more than limit bytes.
+func slotResult(slots []pgcdc.SlotInfo, limit int64) *connector.TestResult {
+	if len(slots) == 0 {
+		return &connector.TestResult{OK: true, Code: "ok", Message: "connected successfully"}
+	}
+	var lagging, all []string
+	for _, s := range slots {
+		state := "active"
+		if !s.Active {
+			state = "inactive"
+		}
+		desc := fmt.Sprintf("%s retains %s (%s)", s.Name, formatBytes(s.RetainedBytes), state)
+		all = append(all, desc)
+		if !s.Active && limit > 0 && s.RetainedBytes > limit {
+			lagging = append(lagging, desc)
+		}
+	}
+	if len(lagging) > 0 {
+		return &connector.TestResult{
+			OK:      false,
+			Code:    "slot_lag",
+			Message: "replication slots keep WAL on the source: " + strings.Join(lagging, "; ") + ". Run or deactivate their jobs",
+		}
+	}
+	return &connector.TestResult{OK: true, Code: "ok", Message: "connected successfully; replication slots: " + strings.Join(all, "; ")}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,65 @@
+package testers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/user/micro-dp/internal/pgcdc"
+)
+
+func TestSlotResult(t *testing.T) {
+	const limit = 1 << 30
+	tests := []struct {
+		name     string
+		slots    []pgcdc.SlotInfo
+		wantOK   bool
+		wantCode string
+		wantMsg  string
+	}{
+		{
+			name:     "no slots",
+			wantOK:   true,
+			wantCode: "ok",
+			wantMsg:  "connected successfully",
+		},
+		{
+			name:     "under the limit",
+			slots:    []pgcdc.SlotInfo{{Name: "micro_dp_a", RetainedBytes: 5 << 20}},
+			wantOK:   true,
+			wantCode: "ok",
+			wantMsg:  "connected successfully; replication slots: micro_dp_a retains 5.0 MiB (inactive)",
+		},
+		{
+			name:     "active slot over the limit",
+			slots:    []pgcdc.SlotInfo{{Name: "micro_dp_a", Active: true, RetainedBytes: 2 << 30}},
+			wantOK:   true,
+			wantCode: "ok",
+			wantMsg:  "connected successfully; replication slots: micro_dp_a retains 2.0 GiB (active)",
+		},
+		{
+			name: "inactive slot over the limit",
+			slots: []pgcdc.SlotInfo{
+				{Name: "micro_dp_a", Active: true, RetainedBytes: 100},
+				{Name: "micro_dp_b", RetainedBytes: 3 << 30},
+			},
+			wantOK:   false,
+			wantCode: "slot_lag",
+			wantMsg:  "replication slots keep WAL on the source: micro_dp_b retains 3.0 GiB (inactive). Run or deactivate their jobs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slotResult(tt.slots, limit)
+			if got.OK != tt.wantOK || got.Code != tt.wantCode || got.Message != tt.wantMsg {
+				t.Errorf("slotResult = %+v, want OK=%v Code=%s Message=%q", got, tt.wantOK, tt.wantCode, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestPostgresTesterInvalidConfig(t *testing.T) {
+	result := NewPostgresTester(0).Test(context.Background(), `{"host": "db"}`, "")
+	if result.OK || result.Code != "invalid_config" {
+		t.Errorf("Test = %+v, want invalid_config", result)
+	}
+}
@@ -0,0 +1,91 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"time"
+)
+
+// Config holds connector registry settings.
+type Config struct {
+	DefinitionsDir string        // optional directory of external definitions
+	PollInterval   time.Duration // how often to check DefinitionsDir for changes (0 disables)
+}
+
+// LoadConfig reads connector registry configuration from environment variables.
+func LoadConfig() Config {
+	interval := 10 * time.Second
+	if v := os.Getenv("CONNECTOR_DEFINITIONS_POLL_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			interval = d
+		}
+	}
+	return Config{
+		DefinitionsDir: os.Getenv("CONNECTOR_DEFINITIONS_DIR"),
+		PollInterval:   interval,
+	}
+}
+
+// LogStartup logs the connector registry configuration at startup.
+func LogStartup(cfg Config) {
+	if cfg.DefinitionsDir == "" {
+		log.Printf("connector registry initialized external_dir=none")
+		return
+	}
+	log.Printf("connector registry initialized external_dir=%s poll_interval=%s", cfg.DefinitionsDir, cfg.PollInterval)
+}
+
+// Watch reloads the registry whenever reload receives a value (e.g. SIGHUP)
+// or, if interval > 0, when the external definitions directory changes.
+// Blocks until ctx is cancelled.
+func (r *Registry) Watch(ctx context.Context, interval time.Duration, reload <-chan os.Signal) {
+	var tick <-chan time.Time
+	if r.externalDir != "" && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	last := fingerprint(r.externalDir)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-reload:
+			log.Printf("connector registry: reloading on %v", sig)
+			r.Reload()
+			last = fingerprint(r.externalDir)
+		case <-tick:
+			if fp := fingerprint(r.externalDir); fp != last {
+				log.Printf("connector registry: definitions changed in %s, reloading", r.externalDir)
+				r.Reload()
+				last = fp
+			}
+		}
+	}
+}
+
+// fingerprint hashes the name, size and modification time of every
+// definition file under dir so that edits, additions and removals are
+// detected without reading file contents.
+func fingerprint(dir string) uint64 {
+	h := fnv.New64a()
+	if dir == "" {
+		return h.Sum64()
+	}
+	paths, err := externalDefinitionFiles(dir)
+	if err != nil {
+		fmt.Fprintf(h, "err:%v", err)
+	}
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(h, "%s|%d|%d\n", p, info.Size(), info.ModTime().UnixNano())
+	}
+	return h.Sum64()
+}
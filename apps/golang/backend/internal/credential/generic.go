@@ -0,0 +1,308 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// GenericConfig configures an OAuth 2.0 / OIDC provider without code changes
+// (Microsoft, GitHub, Salesforce, internal IdPs, ...).
+type GenericConfig struct {
+	Name            string            `json:"name"`                   // URL-safe provider name, e.g. "microsoft"
+	DisplayName     string            `json:"display_name,omitempty"` // defaults to Name
+	ClientID        string            `json:"client_id"`
+	ClientSecret    string            `json:"client_secret,omitempty"`
+	ClientSecretEnv string            `json:"client_secret_env,omitempty"` // env var holding the secret (preferred over client_secret)
+	Issuer          string            `json:"issuer,omitempty"`            // OIDC issuer; endpoints are discovered when not set explicitly
+	AuthURL         string            `json:"auth_url,omitempty"`
+	TokenURL        string            `json:"token_url,omitempty"`
+	UserInfoURL     string            `json:"userinfo_url,omitempty"`
+	LabelPath       string            `json:"label_path,omitempty"` // dot path into the userinfo response, default "email"
+	Scopes          []string          `json:"scopes,omitempty"`
+	PKCE            *bool             `json:"pkce,omitempty"`        // default true
+	AuthParams      map[string]string `json:"auth_params,omitempty"` // extra authorize URL params, e.g. {"prompt": "consent"}
+	RedirectURL     string            `json:"redirect_url,omitempty"`
+	PostRedirectURI string            `json:"post_redirect_url,omitempty"`
+	// ReconsentErrors lists token endpoint error codes that mean the grant is
+	// gone and the user has to authorize again. Defaults to ["invalid_grant"].
+	ReconsentErrors []string `json:"reconsent_errors,omitempty"`
+}
+
+// LoadGenericConfigs reads provider definitions from the JSON array in the
+// file named by CREDENTIAL_PROVIDERS_FILE. Providers without an explicit
+// redirect_url get CREDENTIAL_OAUTH_REDIRECT_BASE_URL +
+// /api/credentials/oauth/{name}/callback (the web app's callback proxy).
+func LoadGenericConfigs() ([]GenericConfig, error) {
+	path := os.Getenv("CREDENTIAL_PROVIDERS_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CREDENTIAL_PROVIDERS_FILE: %w", err)
+	}
+	var cfgs []GenericConfig
+	if err := json.Unmarshal(data, &cfgs); err != nil {
+		return nil, fmt.Errorf("parse CREDENTIAL_PROVIDERS_FILE: %w", err)
+	}
+
+	baseURL := strings.TrimRight(os.Getenv("CREDENTIAL_OAUTH_REDIRECT_BASE_URL"), "/")
+	seen := map[string]bool{"google": true}
+	for i := range cfgs {
+		c := &cfgs[i]
+		if !providerNamePattern.MatchString(c.Name) {
+			return nil, fmt.Errorf("credential provider %d: invalid name %q", i, c.Name)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("credential provider %q: duplicate name", c.Name)
+		}
+		seen[c.Name] = true
+		if c.Issuer == "" && (c.AuthURL == "" || c.TokenURL == "") {
+			return nil, fmt.Errorf("credential provider %q: issuer or auth_url and token_url are required", c.Name)
+		}
+		if c.ClientSecretEnv != "" {
+			c.ClientSecret = os.Getenv(c.ClientSecretEnv)
+		}
+		if c.RedirectURL == "" && baseURL != "" {
+			c.RedirectURL = baseURL + "/api/credentials/oauth/" + c.Name + "/callback"
+		}
+	}
+	return cfgs, nil
+}
+
+// GenericProvider implements OAuthProvider from a GenericConfig.
+type GenericProvider struct {
+	cfg    GenericConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	endpoint *genericEndpoints // resolved lazily when Issuer is used
+}
+
+type genericEndpoints struct {
+	AuthURL     string `json:"authorization_endpoint"`
+	TokenURL    string `json:"token_endpoint"`
+	UserInfoURL string `json:"userinfo_endpoint"`
+}
+
+// NewGenericProvider creates a provider from cfg.
+func NewGenericProvider(cfg GenericConfig) *GenericProvider {
+	if cfg.DisplayName == "" {
+		cfg.DisplayName = cfg.Name
+	}
+	if cfg.LabelPath == "" {
+		cfg.LabelPath = "email"
+	}
+	if len(cfg.ReconsentErrors) == 0 {
+		cfg.ReconsentErrors = []string{"invalid_grant"}
+	}
+	return &GenericProvider{cfg: cfg, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (p *GenericProvider) ProviderName() string {
+	return p.cfg.Name
+}
+
+// DisplayName returns the human-readable provider name.
+func (p *GenericProvider) DisplayName() string {
+	return p.cfg.DisplayName
+}
+
+func (p *GenericProvider) Scopes() []string {
+	return p.cfg.Scopes
+}
+
+func (p *GenericProvider) OAuthEnabled() bool {
+	return p.cfg.ClientID != "" && p.cfg.RedirectURL != ""
+}
+
+func (p *GenericProvider) PostRedirectURL() string {
+	if p.cfg.PostRedirectURI != "" {
+		return p.cfg.PostRedirectURI
+	}
+	return "http://localhost:3000/integrations"
+}
+
+func (p *GenericProvider) pkce() bool {
+	return p.cfg.PKCE == nil || *p.cfg.PKCE
+}
+
+// endpoints returns the configured endpoints, filling gaps from OIDC
+// discovery. Discovery results are cached after the first success.
+func (p *GenericProvider) endpoints(ctx context.Context) (genericEndpoints, error) {
+	ep := genericEndpoints{AuthURL: p.cfg.AuthURL, TokenURL: p.cfg.TokenURL, UserInfoURL: p.cfg.UserInfoURL}
+	if p.cfg.Issuer == "" || (ep.AuthURL != "" && ep.TokenURL != "" && ep.UserInfoURL != "") {
+		return ep, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoint == nil {
+		discovered, err := p.discover(ctx)
+		if err != nil {
+			return ep, err
+		}
+		p.endpoint = discovered
+	}
+	if ep.AuthURL == "" {
+		ep.AuthURL = p.endpoint.AuthURL
+	}
+	if ep.TokenURL == "" {
+		ep.TokenURL = p.endpoint.TokenURL
+	}
+	if ep.UserInfoURL == "" {
+		ep.UserInfoURL = p.endpoint.UserInfoURL
+	}
+	return ep, nil
+}
+
+func (p *GenericProvider) discover(ctx context.Context) (*genericEndpoints, error) {
+	url := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery: status %d", resp.StatusCode)
+	}
+	var ep genericEndpoints
+	if err := json.NewDecoder(resp.Body).Decode(&ep); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if ep.AuthURL == "" || ep.TokenURL == "" {
+		return nil, fmt.Errorf("oidc discovery: missing authorization or token endpoint")
+	}
+	return &ep, nil
+}
+
+func (p *GenericProvider) oauth2Config(ctx context.Context) (oauth2.Config, error) {
+	ep, err := p.endpoints(ctx)
+	if err != nil {
+		return oauth2.Config{}, err
+	}
+	return oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Endpoint:     oauth2.Endpoint{AuthURL: ep.AuthURL, TokenURL: ep.TokenURL},
+		Scopes:       p.cfg.Scopes,
+	}, nil
+}
+
+func (p *GenericProvider) AuthCodeURL(state, codeChallenge string) string {
+	cfg, err := p.oauth2Config(context.Background())
+	if err != nil {
+		return ""
+	}
+	var opts []oauth2.AuthCodeOption
+	if p.pkce() {
+		opts = append(opts,
+			oauth2.SetAuthURLParam("code_challenge", codeChallenge),
+			oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+		)
+	}
+	for k, v := range p.cfg.AuthParams {
+		opts = append(opts, oauth2.SetAuthURLParam(k, v))
+	}
+	return cfg.AuthCodeURL(state, opts...)
+}
+
+func (p *GenericProvider) Exchange(ctx context.Context, code, codeVerifier string) (*oauth2.Token, error) {
+	cfg, err := p.oauth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var opts []oauth2.AuthCodeOption
+	if p.pkce() {
+		opts = append(opts, oauth2.SetAuthURLParam("code_verifier", codeVerifier))
+	}
+	token, err := cfg.Exchange(p.context(ctx), code, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("oauth exchange: %w", err)
+	}
+	return token, nil
+}
+
+func (p *GenericProvider) FetchLabel(ctx context.Context, token *oauth2.Token) (string, error) {
+	ep, err := p.endpoints(ctx)
+	if err != nil {
+		return "", err
+	}
+	if ep.UserInfoURL == "" {
+		return "", nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ep.UserInfoURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	token.SetAuthHeader(req)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch userinfo: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch userinfo: status %d", resp.StatusCode)
+	}
+
+	var userInfo map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
+		return "", fmt.Errorf("decode userinfo: %w", err)
+	}
+	return lookupPath(userInfo, p.cfg.LabelPath), nil
+}
+
+func (p *GenericProvider) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	cfg, err := p.oauth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token := &oauth2.Token{RefreshToken: refreshToken}
+	newToken, err := cfg.TokenSource(p.context(ctx), token).Token()
+	if err != nil {
+		return nil, classifyRefreshError(err, p.cfg.ReconsentErrors)
+	}
+	return newToken, nil
+}
+
+func (p *GenericProvider) context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, p.client)
+}
+
+// lookupPath resolves a dot-separated path such as "user.email" in a decoded
+// JSON object and returns it as a string.
+func lookupPath(obj map[string]any, path string) string {
+	var cur any = obj
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return ""
+		}
+		cur = m[part]
+	}
+	switch v := cur.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,128 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func newIdP(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"userinfo_endpoint":      srv.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Form.Get("refresh_token") == "revoked" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		if r.Form.Get("refresh_token") == "bad-client" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		if r.Form.Get("refresh_token") == "flaky" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "new-access", "token_type": "Bearer", "expires_in": 3600})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"user": map[string]any{"login": "octocat"}})
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGenericProviderDiscoveryAndPKCE(t *testing.T) {
+	idp := newIdP(t)
+	p := NewGenericProvider(GenericConfig{
+		Name:        "corp",
+		ClientID:    "client",
+		Issuer:      idp.URL,
+		RedirectURL: "http://localhost/cb",
+		Scopes:      []string{"openid", "email"},
+		AuthParams:  map[string]string{"prompt": "consent"},
+	})
+
+	u, err := url.Parse(p.AuthCodeURL("state", "challenge"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/authorize" {
+		t.Errorf("auth path = %q", u.Path)
+	}
+	q := u.Query()
+	for k, want := range map[string]string{"code_challenge": "challenge", "code_challenge_method": "S256", "prompt": "consent", "state": "state"} {
+		if q.Get(k) != want {
+			t.Errorf("%s = %q, want %q", k, q.Get(k), want)
+		}
+	}
+
+	noPKCE := false
+	p2 := NewGenericProvider(GenericConfig{Name: "gh", ClientID: "c", AuthURL: idp.URL + "/authorize", TokenURL: idp.URL + "/token", RedirectURL: "x", PKCE: &noPKCE})
+	if q := mustQuery(t, p2.AuthCodeURL("s", "challenge")); q.Get("code_challenge") != "" {
+		t.Error("code_challenge sent with pkce disabled")
+	}
+}
+
+func mustQuery(t *testing.T, raw string) url.Values {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
+
+func TestGenericProviderLabelPath(t *testing.T) {
+	idp := newIdP(t)
+	p := NewGenericProvider(GenericConfig{Name: "gh", ClientID: "c", Issuer: idp.URL, LabelPath: "user.login"})
+	label, err := p.FetchLabel(context.Background(), &oauth2.Token{AccessToken: "access", TokenType: "Bearer"})
+	if err != nil {
+		t.Fatalf("FetchLabel: %v", err)
+	}
+	if label != "octocat" {
+		t.Errorf("label = %q, want octocat", label)
+	}
+}
+
+func TestGenericProviderRefreshReconsent(t *testing.T) {
+	idp := newIdP(t)
+	p := NewGenericProvider(GenericConfig{Name: "corp", ClientID: "c", Issuer: idp.URL})
+	ctx := context.Background()
+
+	tok, err := p.RefreshToken(ctx, "valid")
+	if err != nil || tok.AccessToken != "new-access" {
+		t.Fatalf("RefreshToken(valid) = %v, %v", tok, err)
+	}
+	if _, err := p.RefreshToken(ctx, "revoked"); !errors.Is(err, ErrReconsentRequired) {
+		t.Errorf("RefreshToken(revoked) err = %v, want ErrReconsentRequired", err)
+	}
+	if _, err := p.RefreshToken(ctx, "bad-client"); !errors.Is(err, ErrRefreshRejected) || errors.Is(err, ErrReconsentRequired) {
+		t.Errorf("RefreshToken(bad-client) err = %v, want ErrRefreshRejected", err)
+	}
+	if _, err := p.RefreshToken(ctx, "flaky"); err == nil || errors.Is(err, ErrReconsentRequired) || errors.Is(err, ErrRefreshRejected) {
+		t.Errorf("RefreshToken(flaky) err = %v, want transient error", err)
+	}
+}
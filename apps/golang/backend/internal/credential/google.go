@@ -40,6 +40,10 @@ func (p *GoogleProvider) ProviderName() string {
 	return "google"
 }
 
+func (p *GoogleProvider) DisplayName() string {
+	return "Google"
+}
+
 func (p *GoogleProvider) Scopes() []string {
 	return p.cfg.Scopes
 }
@@ -111,7 +115,8 @@ func (p *GoogleProvider) RefreshToken(ctx context.Context, refreshToken string)
 	}
 	newToken, err := cfg.TokenSource(ctx, token).Token()
 	if err != nil {
-		return nil, fmt.Errorf("token refresh: %w", err)
+		// Google reports revoked or expired refresh tokens as invalid_grant.
+		return nil, classifyRefreshError(err, []string{"invalid_grant"})
 	}
 	return newToken, nil
 }
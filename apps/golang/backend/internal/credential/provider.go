@@ -2,14 +2,30 @@ package credential
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 
 	"golang.org/x/oauth2"
 )
 
+// ErrReconsentRequired is returned by RefreshToken when the provider rejected
+// the refresh token for good (revoked, expired or password changed) and the
+// user has to go through the authorization flow again.
+var ErrReconsentRequired = errors.New("credential: re-consent required")
+
+// ErrRefreshRejected is returned by RefreshToken when the token endpoint
+// rejected the refresh with a client error that is not one of the re-consent
+// codes, e.g. an expired grant reported under a different code or invalid
+// client credentials. Network failures and 5xx responses are not wrapped.
+var ErrRefreshRejected = errors.New("credential: token refresh rejected")
+
 // OAuthProvider abstracts an OAuth 2.0 credential provider (e.g., Google).
 type OAuthProvider interface {
 	// ProviderName returns the canonical name (e.g., "google").
 	ProviderName() string
+	// DisplayName returns a human-readable name (e.g., "Google").
+	DisplayName() string
 	// Scopes returns the OAuth scopes.
 	Scopes() []string
 	// AuthCodeURL builds the authorization URL with PKCE.
@@ -25,3 +41,20 @@ type OAuthProvider interface {
 	// PostRedirectURL returns the URL to redirect after OAuth completion.
 	PostRedirectURL() string
 }
+
+// classifyRefreshError wraps err with ErrReconsentRequired when the token
+// endpoint responded with one of the given OAuth error codes, and with
+// ErrRefreshRejected when it answered with any other 4xx.
+func classifyRefreshError(err error, reconsentCodes []string) error {
+	var re *oauth2.RetrieveError
+	if !errors.As(err, &re) {
+		return fmt.Errorf("token refresh: %w", err)
+	}
+	if slices.Contains(reconsentCodes, re.ErrorCode) {
+		return fmt.Errorf("token refresh: %w: %s", ErrReconsentRequired, re.ErrorCode)
+	}
+	if re.Response != nil && re.Response.StatusCode >= 400 && re.Response.StatusCode < 500 {
+		return fmt.Errorf("token refresh: %w: %v", ErrRefreshRejected, err)
+	}
+	return fmt.Errorf("token refresh: %w", err)
+}
@@ -0,0 +1,246 @@
+// Package dataquality validates data quality rules and evaluates them
+// against a dataset with DuckDB. A rule counts the rows that violate it;
+// any such row fails the rule.
+package dataquality
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/user/micro-dp/internal/datasetquery"
+)
+
+// ErrInvalid is wrapped by errors in a rule's type or configuration.
+var ErrInvalid = errors.New("invalid data quality rule")
+
+// Rule types.
+const (
+	TypeNotNull        = "not_null"        // Column has no NULLs
+	TypeUnique         = "unique"          // Column has no duplicate values
+	TypeAcceptedValues = "accepted_values" // Column is one of Values
+	TypeRange          = "range"           // Column is within Min and Max
+	TypeRegex          = "regex"           // the text of Column matches Pattern in full
+	TypeRowCount       = "row_count"       // the row count is within MinRows and MaxRows
+	TypeFreshness      = "freshness"       // the dataset was updated within MaxAge
+	TypeReferential    = "referential"     // Column values exist in RefColumn of RefDatasetID
+	TypeSQL            = "sql"             // SQL, a SELECT over the views, returns no rows
+)
+
+// Severities.
+const (
+	SeverityWarn = "warn" // a failure is recorded
+	SeverityFail = "fail" // a failure also fails the run that wrote the dataset
+)
+
+// Result statuses.
+const (
+	StatusPassed = "passed"
+	StatusFailed = "failed"
+	StatusError  = "error" // the rule could not be evaluated
+)
+
+// Views a rule is evaluated against: the dataset, and the referenced
+// dataset of a referential rule. SQL assertions query DatasetView, and
+// RefView when they set RefDatasetID.
+const (
+	DatasetView = "dataset"
+	RefView     = "ref"
+)
+
+// Config holds the parameters of a rule; which are used depends on its
+// type. Min and Max are in the text form of the column's type.
+type Config struct {
+	Column       string   `json:"column,omitempty"`
+	Values       []string `json:"values,omitempty"`
+	Min          *string  `json:"min,omitempty"`
+	Max          *string  `json:"max,omitempty"`
+	Pattern      string   `json:"pattern,omitempty"`
+	MinRows      *int64   `json:"min_rows,omitempty"`
+	MaxRows      *int64   `json:"max_rows,omitempty"`
+	MaxAge       string   `json:"max_age,omitempty"`        // Go duration, e.g. "26h"
+	RefDatasetID string   `json:"ref_dataset_id,omitempty"` // also exposes RefView to SQL
+	RefColumn    string   `json:"ref_column,omitempty"`
+	SQL          string   `json:"sql,omitempty"`
+}
+
+// ValidSeverity reports whether s is a known severity.
+func ValidSeverity(s string) bool {
+	return s == SeverityWarn || s == SeverityFail
+}
+
+// Validate checks that cfg holds what a rule of type typ needs.
+func Validate(typ string, cfg Config) error {
+	switch typ {
+	case TypeNotNull, TypeUnique, TypeAcceptedValues, TypeRange, TypeRegex, TypeReferential:
+		if cfg.Column == "" {
+			return fmt.Errorf("%w: %s requires column", ErrInvalid, typ)
+		}
+	}
+
+	switch typ {
+	case TypeNotNull, TypeUnique:
+	case TypeAcceptedValues:
+		if len(cfg.Values) == 0 {
+			return fmt.Errorf("%w: accepted_values requires values", ErrInvalid)
+		}
+	case TypeRange:
+		if cfg.Min == nil && cfg.Max == nil {
+			return fmt.Errorf("%w: range requires min or max", ErrInvalid)
+		}
+	case TypeRegex:
+		if cfg.Pattern == "" {
+			return fmt.Errorf("%w: regex requires pattern", ErrInvalid)
+		}
+		if _, err := regexp.Compile(cfg.Pattern); err != nil {
+			return fmt.Errorf("%w: pattern: %v", ErrInvalid, err)
+		}
+	case TypeRowCount:
+		if cfg.MinRows == nil && cfg.MaxRows == nil {
+			return fmt.Errorf("%w: row_count requires min_rows or max_rows", ErrInvalid)
+		}
+		if (cfg.MinRows != nil && *cfg.MinRows < 0) || (cfg.MaxRows != nil && *cfg.MaxRows < 0) {
+			return fmt.Errorf("%w: row_count bounds must not be negative", ErrInvalid)
+		}
+		if cfg.MinRows != nil && cfg.MaxRows != nil && *cfg.MinRows > *cfg.MaxRows {
+			return fmt.Errorf("%w: min_rows is greater than max_rows", ErrInvalid)
+		}
+	case TypeFreshness:
+		d, err := time.ParseDuration(cfg.MaxAge)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%w: freshness requires a positive max_age duration", ErrInvalid)
+		}
+	case TypeReferential:
+		if cfg.RefDatasetID == "" || cfg.RefColumn == "" {
+			return fmt.Errorf("%w: referential requires ref_dataset_id and ref_column", ErrInvalid)
+		}
+	case TypeSQL:
+		if strings.TrimSpace(cfg.SQL) == "" {
+			return fmt.Errorf("%w: sql requires sql", ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalid, typ)
+	}
+	return nil
+}
+
+// Result is the outcome of evaluating a rule. FailingRows is set by rules
+// that count violating rows.
+type Result struct {
+	Status      string
+	FailingRows *int64
+	Message     string
+}
+
+func passed(n int64) Result {
+	return Result{Status: StatusPassed, FailingRows: &n}
+}
+
+func failed(n int64, format string, args ...any) Result {
+	return Result{Status: StatusFailed, FailingRows: &n, Message: fmt.Sprintf(format, args...)}
+}
+
+func errored(err error) Result {
+	return Result{Status: StatusError, Message: err.Error()}
+}
+
+// Evaluate evaluates a rule of type typ against DatasetView in db, which
+// a referential rule joins with RefView. Freshness rules are evaluated by
+// Fresh instead.
+func Evaluate(ctx context.Context, db *sql.DB, typ string, cfg Config) Result {
+	col := datasetquery.QuoteIdentifier(cfg.Column)
+	var query string
+	switch typ {
+	case TypeNotNull:
+		query = fmt.Sprintf("SELECT count(*) FROM %s WHERE %s IS NULL", DatasetView, col)
+	case TypeUnique:
+		query = fmt.Sprintf("SELECT coalesce(sum(n), 0) FROM (SELECT count(*) AS n FROM %s WHERE %s IS NOT NULL GROUP BY %s HAVING count(*) > 1)",
+			DatasetView, col, col)
+	case TypeAcceptedValues:
+		values := make([]string, len(cfg.Values))
+		for i, v := range cfg.Values {
+			values[i] = datasetquery.QuoteLiteral(v)
+		}
+		query = fmt.Sprintf("SELECT count(*) FROM %s WHERE %s IS NOT NULL AND CAST(%s AS VARCHAR) NOT IN (%s)",
+			DatasetView, col, col, strings.Join(values, ", "))
+	case TypeRange:
+		var outside []string
+		if cfg.Min != nil {
+			outside = append(outside, fmt.Sprintf("%s < %s", col, datasetquery.QuoteLiteral(*cfg.Min)))
+		}
+		if cfg.Max != nil {
+			outside = append(outside, fmt.Sprintf("%s > %s", col, datasetquery.QuoteLiteral(*cfg.Max)))
+		}
+		query = fmt.Sprintf("SELECT count(*) FROM %s WHERE %s", DatasetView, strings.Join(outside, " OR "))
+	case TypeRegex:
+		query = fmt.Sprintf("SELECT count(*) FROM %s WHERE %s IS NOT NULL AND NOT regexp_full_match(CAST(%s AS VARCHAR), %s)",
+			DatasetView, col, col, datasetquery.QuoteLiteral(cfg.Pattern))
+	case TypeRowCount:
+		return rowCount(ctx, db, cfg)
+	case TypeReferential:
+		query = fmt.Sprintf("SELECT count(*) FROM %s AS d WHERE d.%s IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %s AS r WHERE r.%s = d.%s)",
+			DatasetView, col, RefView, datasetquery.QuoteIdentifier(cfg.RefColumn), col)
+	case TypeSQL:
+		query = fmt.Sprintf("SELECT count(*) FROM (%s) AS _assertion", cfg.SQL)
+	default:
+		return errored(fmt.Errorf("%s rules are not evaluated against the data", typ))
+	}
+
+	var n int64
+	if err := db.QueryRowContext(ctx, query).Scan(&n); err != nil {
+		return errored(err)
+	}
+	if n == 0 {
+		return passed(0)
+	}
+	switch typ {
+	case TypeNotNull:
+		return failed(n, "%d rows have a NULL %q", n, cfg.Column)
+	case TypeUnique:
+		return failed(n, "%d rows share their %q with another row", n, cfg.Column)
+	case TypeAcceptedValues:
+		return failed(n, "%d rows have a %q that is not accepted", n, cfg.Column)
+	case TypeRange:
+		return failed(n, "%d rows have a %q out of range", n, cfg.Column)
+	case TypeRegex:
+		return failed(n, "%d rows have a %q not matching the pattern", n, cfg.Column)
+	case TypeReferential:
+		return failed(n, "%d rows have a %q missing from the referenced %q", n, cfg.Column, cfg.RefColumn)
+	default:
+		return failed(n, "the assertion returned %d rows", n)
+	}
+}
+
+func rowCount(ctx context.Context, db *sql.DB, cfg Config) Result {
+	var n int64
+	if err := db.QueryRowContext(ctx, "SELECT count(*) FROM "+DatasetView).Scan(&n); err != nil {
+		return errored(err)
+	}
+	switch {
+	case cfg.MinRows != nil && n < *cfg.MinRows:
+		return Result{Status: StatusFailed, Message: fmt.Sprintf("%d rows, fewer than %d", n, *cfg.MinRows)}
+	case cfg.MaxRows != nil && n > *cfg.MaxRows:
+		return Result{Status: StatusFailed, Message: fmt.Sprintf("%d rows, more than %d", n, *cfg.MaxRows)}
+	}
+	return Result{Status: StatusPassed}
+}
+
+// Fresh evaluates a freshness rule against the time the dataset was last
+// updated, nil when it never was.
+func Fresh(cfg Config, lastUpdated *time.Time, now time.Time) Result {
+	maxAge, err := time.ParseDuration(cfg.MaxAge)
+	if err != nil {
+		return errored(err)
+	}
+	if lastUpdated == nil {
+		return Result{Status: StatusFailed, Message: "the dataset has never been updated"}
+	}
+	if age := now.Sub(*lastUpdated); age > maxAge {
+		return Result{Status: StatusFailed, Message: fmt.Sprintf("last updated %s ago, more than %s", age.Truncate(time.Second), maxAge)}
+	}
+	return Result{Status: StatusPassed}
+}
@@ -0,0 +1,150 @@
+package dataquality
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	_ "github.com/marcboeker/go-duckdb"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// openDataset registers small dataset and ref views in an in-memory DuckDB.
+func openDataset(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, stmt := range []string{
+		`CREATE TABLE t AS SELECT * FROM (VALUES
+			(1::BIGINT, 'a@example.com', 'active', 10.5::DOUBLE, DATE '2024-01-01', 'JP'),
+			(2::BIGINT, NULL, 'active', 99.0::DOUBLE, DATE '2024-02-01', 'US'),
+			(2::BIGINT, 'not-an-email', 'deleted', -1.0::DOUBLE, DATE '2025-03-01', 'XX')
+		) AS t(id, email, status, amount, day, country)`,
+		`CREATE VIEW dataset AS SELECT * FROM t`,
+		`CREATE VIEW ref AS SELECT * FROM (VALUES ('JP'), ('US')) AS r(code)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		typ   string
+		cfg   Config
+		valid bool
+	}{
+		{TypeNotNull, Config{Column: "id"}, true},
+		{TypeNotNull, Config{}, false},
+		{TypeUnique, Config{Column: "id"}, true},
+		{TypeAcceptedValues, Config{Column: "status", Values: []string{"active"}}, true},
+		{TypeAcceptedValues, Config{Column: "status"}, false},
+		{TypeRange, Config{Column: "amount", Min: ptr("0")}, true},
+		{TypeRange, Config{Column: "amount"}, false},
+		{TypeRegex, Config{Column: "email", Pattern: `.+@.+`}, true},
+		{TypeRegex, Config{Column: "email", Pattern: `(`}, false},
+		{TypeRowCount, Config{MinRows: ptr(int64(1))}, true},
+		{TypeRowCount, Config{MinRows: ptr(int64(5)), MaxRows: ptr(int64(1))}, false},
+		{TypeRowCount, Config{}, false},
+		{TypeFreshness, Config{MaxAge: "24h"}, true},
+		{TypeFreshness, Config{MaxAge: "soon"}, false},
+		{TypeFreshness, Config{MaxAge: "-1h"}, false},
+		{TypeReferential, Config{Column: "country", RefDatasetID: "ds", RefColumn: "code"}, true},
+		{TypeReferential, Config{Column: "country", RefColumn: "code"}, false},
+		{TypeSQL, Config{SQL: "SELECT * FROM dataset WHERE amount < 0"}, true},
+		{TypeSQL, Config{SQL: "  "}, false},
+		{"median", Config{Column: "id"}, false},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.typ, tt.cfg)
+		if tt.valid && err != nil {
+			t.Errorf("Validate(%s, %+v) = %v", tt.typ, tt.cfg, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalid) {
+			t.Errorf("Validate(%s, %+v) = %v, want ErrInvalid", tt.typ, tt.cfg, err)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	db := openDataset(t)
+	tests := []struct {
+		name    string
+		typ     string
+		cfg     Config
+		status  string
+		failing int64
+	}{
+		{"not null passes", TypeNotNull, Config{Column: "id"}, StatusPassed, 0},
+		{"not null fails", TypeNotNull, Config{Column: "email"}, StatusFailed, 1},
+		{"unique fails", TypeUnique, Config{Column: "id"}, StatusFailed, 2},
+		{"unique ignores nulls", TypeUnique, Config{Column: "email"}, StatusPassed, 0},
+		{"accepted values", TypeAcceptedValues, Config{Column: "status", Values: []string{"active"}}, StatusFailed, 1},
+		{"range numeric", TypeRange, Config{Column: "amount", Min: ptr("0"), Max: ptr("50")}, StatusFailed, 2},
+		{"range date", TypeRange, Config{Column: "day", Max: ptr("2024-12-31")}, StatusFailed, 1},
+		{"range passes", TypeRange, Config{Column: "id", Min: ptr("1")}, StatusPassed, 0},
+		{"regex", TypeRegex, Config{Column: "email", Pattern: `[^@]+@[^@]+`}, StatusFailed, 1},
+		{"referential", TypeReferential, Config{Column: "country", RefDatasetID: "ds", RefColumn: "code"}, StatusFailed, 1},
+		{"sql", TypeSQL, Config{SQL: "SELECT * FROM dataset WHERE amount < 0"}, StatusFailed, 1},
+		{"sql passes", TypeSQL, Config{SQL: "SELECT * FROM dataset WHERE amount > 1000"}, StatusPassed, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate(context.Background(), db, tt.typ, tt.cfg)
+			if got.Status != tt.status {
+				t.Fatalf("status = %s (%s), want %s", got.Status, got.Message, tt.status)
+			}
+			if got.FailingRows == nil || *got.FailingRows != tt.failing {
+				t.Errorf("failing rows = %v, want %d", got.FailingRows, tt.failing)
+			}
+			if (got.Message != "") != (tt.status != StatusPassed) {
+				t.Errorf("message = %q", got.Message)
+			}
+		})
+	}
+}
+
+func TestEvaluateRowCountAndErrors(t *testing.T) {
+	db := openDataset(t)
+	ctx := context.Background()
+
+	if got := Evaluate(ctx, db, TypeRowCount, Config{MinRows: ptr(int64(3)), MaxRows: ptr(int64(3))}); got.Status != StatusPassed {
+		t.Errorf("row count 3..3 = %+v", got)
+	}
+	if got := Evaluate(ctx, db, TypeRowCount, Config{MinRows: ptr(int64(4))}); got.Status != StatusFailed || got.FailingRows != nil {
+		t.Errorf("row count >= 4 = %+v", got)
+	}
+	if got := Evaluate(ctx, db, TypeNotNull, Config{Column: "missing"}); got.Status != StatusError || got.Message == "" {
+		t.Errorf("missing column = %+v", got)
+	}
+	if got := Evaluate(ctx, db, TypeSQL, Config{SQL: "SELECT 1; DROP TABLE t"}); got.Status != StatusError {
+		t.Errorf("multiple statements = %+v", got)
+	}
+	if got := Evaluate(ctx, db, TypeFreshness, Config{MaxAge: "1h"}); got.Status != StatusError {
+		t.Errorf("freshness = %+v", got)
+	}
+}
+
+func TestFresh(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	cfg := Config{MaxAge: "24h"}
+
+	if got := Fresh(cfg, ptr(now.Add(-time.Hour)), now); got.Status != StatusPassed {
+		t.Errorf("1h old = %+v", got)
+	}
+	if got := Fresh(cfg, ptr(now.Add(-25*time.Hour)), now); got.Status != StatusFailed || got.Message == "" {
+		t.Errorf("25h old = %+v", got)
+	}
+	if got := Fresh(cfg, nil, now); got.Status != StatusFailed {
+		t.Errorf("never updated = %+v", got)
+	}
+}
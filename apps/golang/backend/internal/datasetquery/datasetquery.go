@@ -0,0 +1,356 @@
+// Package datasetquery builds DuckDB queries over a dataset from a column
+// projection, structured filters and sort keys, and reads them a page at a
+// time. Column names and filter values come from API callers, so they are
+// checked against the dataset's columns and quoted rather than spliced into
+// SQL.
+package datasetquery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/user/micro-dp/internal/pii"
+)
+
+// ErrInvalid is wrapped by errors in a caller's projection or filters.
+var ErrInvalid = errors.New("invalid dataset query")
+
+// Filter operators.
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpContains = "contains" // case-insensitive substring of the text form
+	OpIn       = "in"       // comma-separated values
+	OpIsNull   = "is_null"
+	OpNotNull  = "not_null"
+)
+
+var comparisons = map[string]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpLt:  "<",
+	OpLte: "<=",
+	OpGt:  ">",
+	OpGte: ">=",
+}
+
+// scalarType matches the column types filter values are cast to. Values
+// compared with other columns (lists, structs, maps) use the text form.
+var scalarType = regexp.MustCompile(`^(BOOLEAN|TINYINT|SMALLINT|INTEGER|BIGINT|HUGEINT|UTINYINT|USMALLINT|UINTEGER|UBIGINT|FLOAT|DOUBLE|DECIMAL\(\d+,\d+\)|VARCHAR|DATE|TIME|TIMESTAMP|TIMESTAMP WITH TIME ZONE|TIMESTAMP_S|TIMESTAMP_MS|TIMESTAMP_NS|UUID|INTERVAL)$`)
+
+// Column is a dataset column as DuckDB describes it.
+type Column struct {
+	Name string
+	Type string
+}
+
+type Filter struct {
+	Column string `json:"column"`
+	Op     string `json:"op"`
+	Value  string `json:"value,omitempty"` // unused by is_null and not_null
+}
+
+// ParseFilter parses "column:op:value", or "column:op" for is_null and
+// not_null. The value is everything after the second colon.
+func ParseFilter(s string) (Filter, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) < 2 || parts[0] == "" {
+		return Filter{}, fmt.Errorf("%w: filter %q must be column:op:value", ErrInvalid, s)
+	}
+	f := Filter{Column: parts[0], Op: parts[1]}
+	if len(parts) == 3 {
+		f.Value = parts[2]
+	}
+	return f, nil
+}
+
+type SortKey struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc,omitempty"`
+}
+
+// ParseSort parses comma-separated sort keys, each a column name optionally
+// prefixed with - for descending order.
+func ParseSort(s string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(s, ",") {
+		k := SortKey{Column: part}
+		if strings.HasPrefix(part, "-") {
+			k = SortKey{Column: part[1:], Desc: true}
+		}
+		if k.Column == "" {
+			return nil, fmt.Errorf("%w: empty sort key in %q", ErrInvalid, s)
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// Query selects rows of a dataset.
+type Query struct {
+	Columns []string  `json:"columns,omitempty"` // projection; empty selects every column
+	Filters []Filter  `json:"filters,omitempty"` // combined with AND
+	Sort    []SortKey `json:"sort,omitempty"`    // nulls sort last in either direction
+	// Masks maps columns to the pii masking policy applied before anything
+	// else, so that filters and sorting see masked values only.
+	Masks map[string]string `json:"masks,omitempty"`
+}
+
+// readParquet returns the FROM clause reading source with extra
+// read_parquet options. Parts of a prefix written before and after a schema
+// change are read together by name. Masked columns are replaced by their
+// masked form; every column in masks must exist.
+func readParquet(source string, masks map[string]string, options ...string) string {
+	from := fmt.Sprintf("read_parquet(%s)", strings.Join(append([]string{source, "union_by_name = true"}, options...), ", "))
+	if len(masks) == 0 {
+		return from
+	}
+	names := make([]string, 0, len(masks))
+	for name := range masks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	replace := make([]string, len(names))
+	for i, name := range names {
+		col := QuoteIdentifier(name)
+		expr, _ := pii.MaskExpr(masks[name], col)
+		replace[i] = fmt.Sprintf("%s AS %s", expr, col)
+	}
+	return fmt.Sprintf("(SELECT * REPLACE (%s) FROM %s)", strings.Join(replace, ", "), from)
+}
+
+// From describes source and returns a FROM clause reading it with the
+// columns in masks masked. Masks of columns source lacks are ignored.
+func From(ctx context.Context, db *sql.DB, source string, masks map[string]string) (string, error) {
+	schema, err := Describe(ctx, db, source)
+	if err != nil {
+		return "", err
+	}
+	present, _, err := presentMasks(schema, masks)
+	if err != nil {
+		return "", err
+	}
+	return readParquet(source, present), nil
+}
+
+// presentMasks returns the masks of the columns in schema, and schema with
+// those columns typed as the text their masks produce.
+func presentMasks(schema []Column, masks map[string]string) (map[string]string, []Column, error) {
+	if len(masks) == 0 {
+		return nil, schema, nil
+	}
+	present := make(map[string]string, len(masks))
+	masked := make([]Column, len(schema))
+	for i, c := range schema {
+		masked[i] = c
+		policy, ok := masks[c.Name]
+		if !ok {
+			continue
+		}
+		if !pii.ValidPolicy(policy) {
+			return nil, nil, fmt.Errorf("%w: unknown masking policy %q", ErrInvalid, policy)
+		}
+		present[c.Name] = policy
+		masked[i].Type = "VARCHAR"
+	}
+	return present, masked, nil
+}
+
+// Describe returns the columns of a read_parquet source.
+func Describe(ctx context.Context, db *sql.DB, source string) ([]Column, error) {
+	rows, err := db.QueryContext(ctx, "DESCRIBE SELECT * FROM "+readParquet(source, nil))
+	if err != nil {
+		return nil, fmt.Errorf("describe: %w", err)
+	}
+	defer rows.Close()
+
+	var cols []Column
+	for rows.Next() {
+		var c Column
+		var null, key, def, extra sql.NullString
+		if err := rows.Scan(&c.Name, &c.Type, &null, &key, &def, &extra); err != nil {
+			return nil, fmt.Errorf("scan describe: %w", err)
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+// Build describes source and returns a SELECT over it applying q, together
+// with the columns the SELECT returns. Filter values that do not convert to
+// their column's type are rejected here rather than when the rows are read.
+func Build(ctx context.Context, db *sql.DB, source string, q Query) (string, []Column, error) {
+	p, err := prepare(ctx, db, source, q)
+	if err != nil {
+		return "", nil, err
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(p.projection(), ", "), readParquet(source, p.masks))
+	if len(p.conds) > 0 {
+		query += " WHERE " + strings.Join(p.conds, " AND ")
+	}
+	if len(p.sort) > 0 {
+		order := make([]string, len(p.sort))
+		for i, k := range p.sort {
+			order[i] = orderTerm(QuoteIdentifier(k.Name), k.Desc)
+		}
+		query += " ORDER BY " + strings.Join(order, ", ")
+	}
+	return query, p.selected, nil
+}
+
+// plan is a Query checked against the columns of a source.
+type plan struct {
+	schema   []Column
+	masks    map[string]string // of columns the source has
+	selected []Column
+	conds    []string
+	sort     []sortColumn
+}
+
+type sortColumn struct {
+	Column
+	Desc bool
+}
+
+func (p *plan) projection() []string {
+	out := make([]string, len(p.selected))
+	for i, c := range p.selected {
+		out[i] = QuoteIdentifier(c.Name)
+	}
+	return out
+}
+
+func prepare(ctx context.Context, db *sql.DB, source string, q Query) (*plan, error) {
+	schema, err := Describe(ctx, db, source)
+	if err != nil {
+		return nil, err
+	}
+	masks, schema, err := presentMasks(schema, q.Masks)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]Column, len(schema))
+	for _, c := range schema {
+		byName[c.Name] = c
+	}
+
+	p := &plan{schema: schema, masks: masks, selected: schema}
+	if len(q.Columns) > 0 {
+		p.selected = make([]Column, 0, len(q.Columns))
+		seen := make(map[string]bool, len(q.Columns))
+		for _, name := range q.Columns {
+			c, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown column %q", ErrInvalid, name)
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("%w: column %q selected twice", ErrInvalid, name)
+			}
+			seen[name] = true
+			p.selected = append(p.selected, c)
+		}
+	}
+
+	for _, f := range q.Filters {
+		c, ok := byName[f.Column]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown filter column %q", ErrInvalid, f.Column)
+		}
+		cond, literals, err := condition(c, f)
+		if err != nil {
+			return nil, err
+		}
+		for _, lit := range literals {
+			if _, err := db.ExecContext(ctx, "SELECT "+lit); err != nil {
+				return nil, fmt.Errorf("%w: filter on %q: %q is not a valid %s", ErrInvalid, c.Name, f.Value, c.Type)
+			}
+		}
+		p.conds = append(p.conds, cond)
+	}
+
+	seen := make(map[string]bool, len(q.Sort))
+	for _, k := range q.Sort {
+		c, ok := byName[k.Column]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown sort column %q", ErrInvalid, k.Column)
+		}
+		if !scalarType.MatchString(c.Type) {
+			return nil, fmt.Errorf("%w: cannot sort by %q of type %s", ErrInvalid, c.Name, c.Type)
+		}
+		if seen[k.Column] {
+			return nil, fmt.Errorf("%w: sort column %q given twice", ErrInvalid, k.Column)
+		}
+		seen[k.Column] = true
+		p.sort = append(p.sort, sortColumn{Column: c, Desc: k.Desc})
+	}
+	return p, nil
+}
+
+func orderTerm(expr string, desc bool) string {
+	if desc {
+		return expr + " DESC NULLS LAST"
+	}
+	return expr + " ASC NULLS LAST"
+}
+
+// condition returns the WHERE condition of f on c and the cast literals it
+// compares against.
+func condition(c Column, f Filter) (string, []string, error) {
+	col := QuoteIdentifier(c.Name)
+	if strings.ContainsRune(f.Value, 0) {
+		return "", nil, fmt.Errorf("%w: filter on %q: value contains a NUL byte", ErrInvalid, c.Name)
+	}
+	castType := "VARCHAR"
+	if scalarType.MatchString(c.Type) {
+		castType = c.Type
+	} else {
+		col = fmt.Sprintf("CAST(%s AS VARCHAR)", col)
+	}
+	literal := func(v string) string {
+		return fmt.Sprintf("CAST(%s AS %s)", QuoteLiteral(v), castType)
+	}
+
+	switch f.Op {
+	case OpIsNull:
+		return col + " IS NULL", nil, nil
+	case OpNotNull:
+		return col + " IS NOT NULL", nil, nil
+	case OpContains:
+		if castType != "VARCHAR" {
+			col = fmt.Sprintf("CAST(%s AS VARCHAR)", col)
+		}
+		return fmt.Sprintf("contains(lower(%s), lower(%s))", col, QuoteLiteral(f.Value)), nil, nil
+	case OpIn:
+		values := strings.Split(f.Value, ",")
+		literals := make([]string, len(values))
+		for i, v := range values {
+			literals[i] = literal(v)
+		}
+		return fmt.Sprintf("%s IN (%s)", col, strings.Join(literals, ", ")), literals, nil
+	}
+	op, ok := comparisons[f.Op]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: unknown filter operator %q", ErrInvalid, f.Op)
+	}
+	lit := literal(f.Value)
+	return fmt.Sprintf("%s %s %s", col, op, lit), []string{lit}, nil
+}
+
+// QuoteIdentifier quotes a column name for DuckDB.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteLiteral quotes s as a DuckDB string literal.
+func QuoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
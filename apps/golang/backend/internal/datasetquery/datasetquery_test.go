@@ -0,0 +1,409 @@
+package datasetquery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "github.com/marcboeker/go-duckdb"
+)
+
+// writeParquet writes a small dataset and returns its read_parquet source.
+func writeParquet(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	path := filepath.Join(t.TempDir(), "data.parquet")
+	_, err = db.Exec(`COPY (
+		SELECT * FROM (VALUES
+			(1::BIGINT, 'Tokyo', 10.5::DOUBLE, DATE '2024-01-01', ['a']),
+			(2::BIGINT, 'Osaka', NULL, DATE '2024-02-01', ['b', 'c']),
+			(3::BIGINT, 'O''Hare', 7.0::DOUBLE, DATE '2024-03-01', [])
+		) AS t(id, "city name", amount, day, tags)
+	) TO '` + path + `' (FORMAT parquet)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, QuoteLiteral(path)
+}
+
+func queryIDs(t *testing.T, db *sql.DB, query string) []int64 {
+	t.Helper()
+	rows, err := db.Query("SELECT id FROM (" + query + ") ORDER BY id")
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	defer rows.Close()
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Filter
+		wantErr bool
+	}{
+		{in: "city:eq:Tokyo", want: Filter{Column: "city", Op: "eq", Value: "Tokyo"}},
+		{in: "at:gte:2024-01-01 00:00:00", want: Filter{Column: "at", Op: "gte", Value: "2024-01-01 00:00:00"}},
+		{in: "amount:is_null", want: Filter{Column: "amount", Op: "is_null"}},
+		{in: "city:eq:", want: Filter{Column: "city", Op: "eq"}},
+		{in: "city", wantErr: true},
+		{in: ":eq:x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFilter(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("ParseFilter(%q) error = %v, want ErrInvalid", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseFilter(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestBuildFilters(t *testing.T) {
+	db, source := writeParquet(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		filters []Filter
+		want    []int64
+	}{
+		{"none", nil, []int64{1, 2, 3}},
+		{"eq text", []Filter{{Column: "city name", Op: OpEq, Value: "Osaka"}}, []int64{2}},
+		{"quote in value", []Filter{{Column: "city name", Op: OpEq, Value: "O'Hare"}}, []int64{3}},
+		{"injection attempt", []Filter{{Column: "city name", Op: OpEq, Value: "x' OR 1=1 --"}}, []int64{}},
+		{"gt number", []Filter{{Column: "amount", Op: OpGt, Value: "8"}}, []int64{1}},
+		{"lte date", []Filter{{Column: "day", Op: OpLte, Value: "2024-02-01"}}, []int64{1, 2}},
+		{"in", []Filter{{Column: "id", Op: OpIn, Value: "1,3"}}, []int64{1, 3}},
+		{"contains", []Filter{{Column: "city name", Op: OpContains, Value: "o"}}, []int64{1, 2, 3}},
+		{"contains list", []Filter{{Column: "tags", Op: OpContains, Value: "c"}}, []int64{2}},
+		{"is_null", []Filter{{Column: "amount", Op: OpIsNull}}, []int64{2}},
+		{"and", []Filter{{Column: "amount", Op: OpNotNull}, {Column: "id", Op: OpNe, Value: "1"}}, []int64{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _, err := Build(ctx, db, source, Query{Filters: tt.filters})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := queryIDs(t, db, query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildProjection(t *testing.T) {
+	db, source := writeParquet(t)
+
+	_, cols, err := Build(context.Background(), db, source, Query{Columns: []string{"id", "city name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Column{{Name: "id", Type: "BIGINT"}, {Name: "city name", Type: "VARCHAR"}}
+	if !reflect.DeepEqual(cols, want) {
+		t.Errorf("columns = %+v, want %+v", cols, want)
+	}
+}
+
+func TestBuildRejects(t *testing.T) {
+	db, source := writeParquet(t)
+
+	tests := []struct {
+		name string
+		q    Query
+	}{
+		{"unknown column", Query{Columns: []string{"nope"}}},
+		{"column twice", Query{Columns: []string{"id", "id"}}},
+		{"unknown filter column", Query{Filters: []Filter{{Column: "nope", Op: OpEq, Value: "1"}}}},
+		{"unknown operator", Query{Filters: []Filter{{Column: "id", Op: "like", Value: "1"}}}},
+		{"value of wrong type", Query{Filters: []Filter{{Column: "id", Op: OpEq, Value: "abc"}}}},
+		{"bad date in list", Query{Filters: []Filter{{Column: "day", Op: OpIn, Value: "2024-01-01,soon"}}}},
+		{"sort by list", Query{Sort: []SortKey{{Column: "tags"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Build(context.Background(), db, source, tt.q); !errors.Is(err, ErrInvalid) {
+				t.Errorf("error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	got, err := ParseSort("day,-amount")
+	want := []SortKey{{Column: "day"}, {Column: "amount", Desc: true}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSort = %+v, %v, want %+v", got, err, want)
+	}
+	for _, in := range []string{"", "-", "day,"} {
+		if _, err := ParseSort(in); !errors.Is(err, ErrInvalid) {
+			t.Errorf("ParseSort(%q) error = %v, want ErrInvalid", in, err)
+		}
+	}
+}
+
+// writeParts writes rows over two Parquet files, with ties and NULLs in
+// the sort columns, and returns a read_parquet source listing both.
+func writeParts(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	dir := t.TempDir()
+	parts := []string{
+		`SELECT * FROM (VALUES (1::BIGINT, 'b', 2.0::DOUBLE), (2, 'a', NULL), (3, 'b', 1.0), (4, NULL, 2.0)) AS t(id, grp, score)`,
+		`SELECT * FROM (VALUES (5::BIGINT, 'a', 2.0::DOUBLE), (6, 'b', NULL), (7, 'a', 3.0)) AS t(id, grp, score)`,
+	}
+	sources := make([]string, len(parts))
+	for i, part := range parts {
+		path := filepath.Join(dir, fmt.Sprintf("part-%d.parquet", i))
+		if _, err := db.Exec("COPY (" + part + ") TO " + QuoteLiteral(path) + " (FORMAT parquet)"); err != nil {
+			t.Fatal(err)
+		}
+		sources[i] = QuoteLiteral(path)
+	}
+	return db, "[" + strings.Join(sources, ", ") + "]"
+}
+
+// readAll pages through q and returns the ids read in order.
+func readAll(t *testing.T, db *sql.DB, source string, q Query, limit int) []int64 {
+	t.Helper()
+	ids := []int64{}
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("too many pages")
+		}
+		page, err := ReadPage(context.Background(), db, source, q, limit, 0, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Rows) > limit {
+			t.Fatalf("page of %d rows, limit %d", len(page.Rows), limit)
+		}
+		for _, row := range page.Rows {
+			ids = append(ids, row["id"].(int64))
+		}
+		if page.NextToken == "" {
+			return ids
+		}
+		token = page.NextToken
+	}
+}
+
+func TestReadPage(t *testing.T) {
+	db, source := writeParts(t)
+
+	tests := []struct {
+		name string
+		q    Query
+		want []int64
+	}{
+		{"storage order", Query{}, []int64{1, 2, 3, 4, 5, 6, 7}},
+		{"ascending with ties", Query{Sort: []SortKey{{Column: "grp"}}}, []int64{2, 5, 7, 1, 3, 6, 4}},
+		{"descending with nulls", Query{Sort: []SortKey{{Column: "score", Desc: true}}}, []int64{7, 1, 4, 5, 3, 2, 6}},
+		{"two keys", Query{Sort: []SortKey{{Column: "grp", Desc: true}, {Column: "score"}}}, []int64{3, 1, 6, 5, 7, 2, 4}},
+		{"filtered", Query{Filters: []Filter{{Column: "grp", Op: OpEq, Value: "a"}}, Sort: []SortKey{{Column: "score"}}}, []int64{5, 7, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, limit := range []int{1, 2, 3, 10} {
+				if got := readAll(t, db, source, tt.q, limit); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("limit %d: ids = %v, want %v", limit, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadPageTotalAndProjection(t *testing.T) {
+	db, source := writeParts(t)
+
+	q := Query{Columns: []string{"grp"}, Filters: []Filter{{Column: "score", Op: OpNotNull}}, Sort: []SortKey{{Column: "id"}}}
+	page, err := ReadPage(context.Background(), db, source, q, 2, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalRows != 5 {
+		t.Errorf("total rows = %d, want 5", page.TotalRows)
+	}
+	want := []map[string]any{{"grp": "b"}, {"grp": "b"}}
+	if !reflect.DeepEqual(page.Rows, want) {
+		t.Errorf("rows = %v, want %v", page.Rows, want)
+	}
+	if page.NextToken == "" {
+		t.Error("missing next page token")
+	}
+}
+
+func TestReadPageRejectsToken(t *testing.T) {
+	db, source := writeParts(t)
+	ctx := context.Background()
+
+	q := Query{Sort: []SortKey{{Column: "score"}}}
+	page, err := ReadPage(ctx, db, source, q, 2, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := Query{Sort: []SortKey{{Column: "score", Desc: true}}}
+	if _, err := ReadPage(ctx, db, source, other, 2, 0, page.NextToken); !errors.Is(err, ErrInvalid) {
+		t.Errorf("token of another sort: error = %v, want ErrInvalid", err)
+	}
+	if _, err := ReadPage(ctx, db, source, q, 2, 0, "not a token"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("malformed token: error = %v, want ErrInvalid", err)
+	}
+	if _, err := ReadPage(ctx, db, source, Query{Sort: []SortKey{{Column: "nope"}}}, 2, 0, ""); !errors.Is(err, ErrInvalid) {
+		t.Errorf("unknown sort column: error = %v, want ErrInvalid", err)
+	}
+	if _, err := ReadPage(ctx, db, source, q, 2, 1, page.NextToken); !errors.Is(err, ErrInvalid) {
+		t.Errorf("token with offset: error = %v, want ErrInvalid", err)
+	}
+}
+
+func TestReadPageOffset(t *testing.T) {
+	db, source := writeParts(t)
+	ctx := context.Background()
+	q := Query{Sort: []SortKey{{Column: "grp"}}}
+
+	// An offset page continues with a token like any other page
+	page, err := ReadPage(ctx, db, source, q, 2, 3, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []int64{}
+	for _, row := range page.Rows {
+		ids = append(ids, row["id"].(int64))
+	}
+	next, err := ReadPage(ctx, db, source, q, 10, 0, page.NextToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range next.Rows {
+		ids = append(ids, row["id"].(int64))
+	}
+	if want := []int64{1, 3, 6, 4}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+	if page.TotalRows != 7 {
+		t.Errorf("total rows = %d, want 7", page.TotalRows)
+	}
+
+	past, err := ReadPage(ctx, db, source, q, 2, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(past.Rows) != 0 || past.NextToken != "" {
+		t.Errorf("offset past the end = %v rows, token %q, want none", past.Rows, past.NextToken)
+	}
+}
+
+func TestReadPagePagingColumns(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	write := func(query string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "data.parquet")
+		if _, err := db.Exec("COPY (" + query + ") TO " + QuoteLiteral(path) + " (FORMAT parquet)"); err != nil {
+			t.Fatal(err)
+		}
+		return QuoteLiteral(path)
+	}
+	ctx := context.Background()
+
+	// Dataset columns named like the object column are read as they are
+	source := write(`SELECT * FROM (VALUES (1::BIGINT, 'x', 'y'), (2, 'x', 'y'), (3, 'x', 'y')) AS t(id, "__file", "___file")`)
+	page, err := ReadPage(ctx, db, source, Query{}, 2, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]any{{"id": int64(1), "__file": "x", "___file": "y"}, {"id": int64(2), "__file": "x", "___file": "y"}}
+	if !reflect.DeepEqual(page.Rows, want) {
+		t.Errorf("rows = %v, want %v", page.Rows, want)
+	}
+	next, err := ReadPage(ctx, db, source, Query{}, 2, 0, page.NextToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next.Rows) != 1 || next.Rows[0]["id"] != int64(3) {
+		t.Errorf("next page = %v, want row 3", next.Rows)
+	}
+
+	// A file_row_number column cannot be read next to the row numbers
+	source = write(`SELECT 1::BIGINT AS id, 5::BIGINT AS file_row_number`)
+	if _, err := ReadPage(ctx, db, source, Query{}, 2, 0, ""); !errors.Is(err, ErrInvalid) {
+		t.Errorf("file_row_number column: error = %v, want ErrInvalid", err)
+	}
+}
+
+func TestMasks(t *testing.T) {
+	db, source := writeParquet(t)
+	ctx := context.Background()
+	masks := map[string]string{"city name": "partial", "amount": "null", "dropped": "redact"}
+
+	q := Query{Columns: []string{"id", "city name", "amount"}, Sort: []SortKey{{Column: "id"}}, Masks: masks}
+	page, err := ReadPage(ctx, db, source, q, 10, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]any{
+		{"id": int64(1), "city name": "*****", "amount": nil},
+		{"id": int64(2), "city name": "*****", "amount": nil},
+		{"id": int64(3), "city name": "******", "amount": nil},
+	}
+	if !reflect.DeepEqual(page.Rows, want) {
+		t.Errorf("rows = %v, want %v", page.Rows, want)
+	}
+	if page.Columns[1].Type != "VARCHAR" || page.Columns[2].Type != "VARCHAR" {
+		t.Errorf("masked columns = %v, want VARCHAR", page.Columns)
+	}
+
+	// Filters compare with masked values, so raw values cannot be probed
+	query, _, err := Build(ctx, db, source, Query{Filters: []Filter{{Column: "city name", Op: OpEq, Value: "Tokyo"}}, Masks: masks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := queryIDs(t, db, query); len(got) != 0 {
+		t.Errorf("filter on raw value matched %v", got)
+	}
+
+	from, err := From(ctx, db, source, masks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := queryIDs(t, db, `SELECT id FROM `+from+` WHERE amount IS NULL`); !reflect.DeepEqual(got, []int64{1, 2, 3}) {
+		t.Errorf("From: ids with NULL amount = %v", got)
+	}
+
+	if _, _, err := Build(ctx, db, source, Query{Masks: map[string]string{"id": "shuffle"}}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("unknown policy: err = %v", err)
+	}
+}
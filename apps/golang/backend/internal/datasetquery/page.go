@@ -0,0 +1,243 @@
+package datasetquery
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// rowNumberColumn is the column read_parquet adds with the position of each
+// row in its object. Together with the object's name it orders rows that tie
+// on every sort key, so that a page token picks up exactly where a page
+// ended. DuckDB does not let it be renamed.
+const rowNumberColumn = "file_row_number"
+
+// pagingColumns returns the name to give the column read_parquet adds with
+// the object each row was read from: __file, or a longer run of underscores
+// when schema has a column of that name. A schema with a column named
+// file_row_number is rejected, since DuckDB cannot number its rows.
+func pagingColumns(schema []Column) (string, error) {
+	names := make(map[string]bool, len(schema))
+	for _, c := range schema {
+		names[c.Name] = true
+	}
+	if names[rowNumberColumn] {
+		return "", fmt.Errorf("%w: column %q is reserved for paging; rename it to read the rows", ErrInvalid, rowNumberColumn)
+	}
+	file := "__file"
+	for names[file] {
+		file = "_" + file
+	}
+	return file, nil
+}
+
+// Page is a page of the rows a Query selects.
+type Page struct {
+	Columns   []Column
+	Rows      []map[string]any
+	TotalRows int64  // rows matching the filters
+	NextToken string // empty on the last page
+}
+
+// pageToken is the position after the last row of a page: the text form of
+// its sort key values, where nil is NULL, and its object and row number.
+type pageToken struct {
+	Sort   string    `json:"s"`
+	Values []*string `json:"v"`
+	File   string    `json:"f"`
+	Row    int64     `json:"r"`
+}
+
+func decodePageToken(s string) (*pageToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed page token", ErrInvalid)
+	}
+	var t pageToken
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("%w: malformed page token", ErrInvalid)
+	}
+	return &t, nil
+}
+
+func (t *pageToken) encode() string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// sortSpec is the sort of q in ParseSort form, recorded in page tokens so a
+// token is only used with the sort it was issued for.
+func sortSpec(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k.Column
+		if k.Desc {
+			parts[i] = "-" + k.Column
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// ReadPage reads up to limit rows of source that q selects, starting after
+// the position token encodes (the NextToken of the previous page, or empty
+// for the first page). Rows are ordered by q.Sort and then by where they are
+// stored. Without a token, offset rows are skipped first; a token and an
+// offset cannot be combined.
+func ReadPage(ctx context.Context, db *sql.DB, source string, q Query, limit, offset int, token string) (*Page, error) {
+	if token != "" && offset > 0 {
+		return nil, fmt.Errorf("%w: offset and page_token are mutually exclusive", ErrInvalid)
+	}
+	p, err := prepare(ctx, db, source, q)
+	if err != nil {
+		return nil, err
+	}
+	fileColumn, err := pagingColumns(p.schema)
+	if err != nil {
+		return nil, err
+	}
+	spec := sortSpec(q.Sort)
+
+	countQ := "SELECT count(*) FROM " + readParquet(source, p.masks)
+	if len(p.conds) > 0 {
+		countQ += " WHERE " + strings.Join(p.conds, " AND ")
+	}
+	var total int64
+	if err := db.QueryRowContext(ctx, countQ).Scan(&total); err != nil {
+		return nil, fmt.Errorf("count rows: %w", err)
+	}
+
+	selects := p.projection()
+	order := make([]string, 0, len(p.sort)+2)
+	for _, c := range p.sort {
+		col := QuoteIdentifier(c.Name)
+		selects = append(selects, fmt.Sprintf("CAST(%s AS VARCHAR)", col))
+		order = append(order, orderTerm(col, c.Desc))
+	}
+	file, rowNumber := QuoteIdentifier(fileColumn), QuoteIdentifier(rowNumberColumn)
+	selects = append(selects, file, rowNumber)
+	order = append(order, file, rowNumber)
+
+	conds := append([]string{}, p.conds...)
+	if token != "" {
+		t, err := decodePageToken(token)
+		if err != nil {
+			return nil, err
+		}
+		if t.Sort != spec || len(t.Values) != len(p.sort) {
+			return nil, fmt.Errorf("%w: page token was issued for a different sort", ErrInvalid)
+		}
+		cond, err := after(ctx, db, p.sort, fileColumn, t)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(selects, ", "),
+		readParquet(source, p.masks, "filename = "+QuoteLiteral(fileColumn), "file_row_number = true"))
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", strings.Join(order, ", "), limit+1)
+	if offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", offset)
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("read rows: %w", err)
+	}
+	defer rows.Close()
+
+	page := &Page{Columns: p.selected, Rows: []map[string]any{}, TotalRows: total}
+	values := make([]any, len(selects))
+	ptrs := make([]any, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	var last *pageToken
+	for rows.Next() {
+		if len(page.Rows) == limit {
+			page.NextToken = last.encode()
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		row := make(map[string]any, len(p.selected))
+		for i, c := range p.selected {
+			v := values[i]
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			row[c.Name] = v
+		}
+		page.Rows = append(page.Rows, row)
+
+		n := len(p.selected)
+		last = &pageToken{Sort: spec, Values: make([]*string, len(p.sort))}
+		for i := range p.sort {
+			if v, ok := values[n+i].(string); ok {
+				last.Values[i] = &v
+			}
+		}
+		last.File, _ = values[n+len(p.sort)].(string)
+		last.Row, _ = values[n+len(p.sort)+1].(int64)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// after returns the condition selecting the rows ordered after the position
+// t: those equal to it on the first i keys and after it on key i, for some
+// i. NULLs sort last, so every value is before NULL and nothing after it.
+// fileColumn is the column holding the object each row was read from.
+func after(ctx context.Context, db *sql.DB, sort []sortColumn, fileColumn string, t *pageToken) (string, error) {
+	type key struct {
+		expr    string
+		literal string // empty for NULL
+		desc    bool
+	}
+	keys := make([]key, 0, len(sort)+2)
+	for i, c := range sort {
+		k := key{expr: QuoteIdentifier(c.Name), desc: c.Desc}
+		if v := t.Values[i]; v != nil {
+			k.literal = fmt.Sprintf("CAST(%s AS %s)", QuoteLiteral(*v), c.Type)
+			if _, err := db.ExecContext(ctx, "SELECT "+k.literal); err != nil {
+				return "", fmt.Errorf("%w: malformed page token", ErrInvalid)
+			}
+		}
+		keys = append(keys, k)
+	}
+	keys = append(keys,
+		key{expr: QuoteIdentifier(fileColumn), literal: QuoteLiteral(t.File)},
+		key{expr: QuoteIdentifier(rowNumberColumn), literal: fmt.Sprintf("%d", t.Row)},
+	)
+
+	var alts []string
+	for i, k := range keys {
+		if k.literal == "" {
+			continue
+		}
+		terms := make([]string, 0, i+1)
+		for _, prev := range keys[:i] {
+			if prev.literal == "" {
+				terms = append(terms, prev.expr+" IS NULL")
+			} else {
+				terms = append(terms, fmt.Sprintf("%s = %s", prev.expr, prev.literal))
+			}
+		}
+		op := ">"
+		if k.desc {
+			op = "<"
+		}
+		terms = append(terms, fmt.Sprintf("(%s %s %s OR %s IS NULL)", k.expr, op, k.literal, k.expr))
+		alts = append(alts, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(alts, " OR ") + ")", nil
+}
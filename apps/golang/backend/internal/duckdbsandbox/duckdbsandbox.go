@@ -0,0 +1,218 @@
+// Package duckdbsandbox restricts DuckDB sessions that run user SQL. A
+// session registers the datasets it may read with CreateView, then CheckSelect
+// accepts only a single SELECT over those views, ScopeS3 limits its S3
+// credentials to the tenant's prefixes, and Lock turns off extension
+// loading and file access and freezes the configuration.
+package duckdbsandbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/user/micro-dp/internal/datasetquery"
+)
+
+// ErrRejected is wrapped by errors for user SQL the sandbox does not run.
+var ErrRejected = errors.New("sql rejected")
+
+// deniedTableRefs are the FROM clause items that read something other than
+// a table or a subquery: table functions such as read_parquet or read_csv,
+// and DESCRIBE/SUMMARIZE/SHOW.
+var deniedTableRefs = map[string]bool{
+	"TABLE_FUNCTION": true,
+	"SHOW_REF":       true,
+	"COLUMN_DATA":    true,
+	"DELIM_GET":      true,
+}
+
+// deniedFunctions expose the session's settings or the environment.
+var deniedFunctions = map[string]bool{
+	"current_setting": true,
+	"getenv":          true,
+}
+
+// CreateView registers a dataset the session may read as the view name
+// over from. The name is quoted, so that a dataset name is never read as
+// SQL.
+func CreateView(ctx context.Context, db *sql.DB, name, from string) error {
+	viewSQL := fmt.Sprintf("CREATE VIEW %s AS SELECT * FROM %s", datasetquery.QuoteIdentifier(name), from)
+	if _, err := db.ExecContext(ctx, viewSQL); err != nil {
+		return fmt.Errorf("create view %s: %w", name, err)
+	}
+	return nil
+}
+
+// CheckSelect parses query with the session's parser and accepts it only
+// when it is a single SELECT that reads nothing but views and its own CTEs.
+// Views are matched case-insensitively, as DuckDB resolves them.
+func CheckSelect(ctx context.Context, db *sql.DB, query string, views []string) error {
+	var serialized string
+	if err := db.QueryRowContext(ctx, "SELECT json_serialize_sql(?::VARCHAR)::VARCHAR", query).Scan(&serialized); err != nil {
+		return fmt.Errorf("parse sql: %w", err)
+	}
+	var parsed struct {
+		Error        bool              `json:"error"`
+		ErrorType    string            `json:"error_type"`
+		ErrorMessage string            `json:"error_message"`
+		Statements   []json.RawMessage `json:"statements"`
+	}
+	if err := json.Unmarshal([]byte(serialized), &parsed); err != nil {
+		return fmt.Errorf("parse sql: %w", err)
+	}
+	switch {
+	case parsed.Error && parsed.ErrorType == "parser":
+		return fmt.Errorf("%w: %s", ErrRejected, parsed.ErrorMessage)
+	case parsed.Error, len(parsed.Statements) != 1:
+		return fmt.Errorf("%w: only a single SELECT statement is allowed", ErrRejected)
+	}
+
+	var tree any
+	if err := json.Unmarshal(parsed.Statements[0], &tree); err != nil {
+		return fmt.Errorf("parse sql: %w", err)
+	}
+	allowed := make(map[string]bool, len(views))
+	for _, v := range views {
+		allowed[strings.ToLower(v)] = true
+	}
+	collectCTEs(tree, allowed)
+	return checkNode(tree, allowed)
+}
+
+// collectCTEs adds the names of the CTEs the query defines to names.
+func collectCTEs(node any, names map[string]bool) {
+	switch n := node.(type) {
+	case map[string]any:
+		if m, ok := n["cte_map"].(map[string]any); ok {
+			entries, _ := m["map"].([]any)
+			for _, e := range entries {
+				if entry, ok := e.(map[string]any); ok {
+					if key, ok := entry["key"].(string); ok {
+						names[strings.ToLower(key)] = true
+					}
+				}
+			}
+		}
+		for _, child := range n {
+			collectCTEs(child, names)
+		}
+	case []any:
+		for _, child := range n {
+			collectCTEs(child, names)
+		}
+	}
+}
+
+// checkNode walks a serialized statement. Table references carry a type
+// but, unlike expressions, no class.
+func checkNode(node any, allowed map[string]bool) error {
+	switch n := node.(type) {
+	case map[string]any:
+		typ, _ := n["type"].(string)
+		class, isExpr := n["class"].(string)
+		switch {
+		case !isExpr && typ == "BASE_TABLE":
+			name, _ := n["table_name"].(string)
+			schema, _ := n["schema_name"].(string)
+			catalog, _ := n["catalog_name"].(string)
+			if schema != "" || catalog != "" || !allowed[strings.ToLower(name)] {
+				return fmt.Errorf("%w: %q is not a dataset of this query", ErrRejected, qualified(catalog, schema, name))
+			}
+		case !isExpr && deniedTableRefs[typ]:
+			return fmt.Errorf("%w: only datasets can be read (found %s)", ErrRejected, strings.ToLower(typ))
+		case class == "FUNCTION":
+			name, _ := n["function_name"].(string)
+			if deniedFunctions[strings.ToLower(name)] {
+				return fmt.Errorf("%w: function %s is not allowed", ErrRejected, name)
+			}
+		}
+		for _, child := range n {
+			if err := checkNode(child, allowed); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, child := range n {
+			if err := checkNode(child, allowed); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func qualified(parts ...string) string {
+	var nonEmpty []string
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, ".")
+}
+
+// Lock disables extension installing and loading and the remote file
+// systems other than S3, then locks the configuration so that the session
+// cannot turn them back on. Local files are disabled too unless
+// allowLocalFiles, which a session writing its output through a local file
+// needs; its user SQL is kept from local files by CheckSelect alone.
+func Lock(ctx context.Context, db *sql.DB, allowLocalFiles bool) error {
+	disabled := "HTTPFileSystem,HuggingFaceFileSystem"
+	if !allowLocalFiles {
+		disabled = "LocalFileSystem," + disabled
+	}
+	stmts := []string{
+		"SET autoinstall_known_extensions = false",
+		"SET autoload_known_extensions = false",
+		"SET allow_community_extensions = false",
+		fmt.Sprintf("SET disabled_filesystems = '%s'", disabled),
+		"SET lock_configuration = true",
+	}
+	for _, stmt := range stmts {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("duckdb lock (%s): %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// S3Credentials are the S3 endpoint and keys a session reads objects with.
+type S3Credentials struct {
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+}
+
+// ScopeS3 creates an S3 secret for each prefix of bucket, so that the
+// session authenticates to no object outside them. It needs httpfs loaded.
+func ScopeS3(ctx context.Context, db *sql.DB, creds S3Credentials, bucket string, prefixes []string) error {
+	for i, stmt := range s3SecretStatements(creds, bucket, prefixes) {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("duckdb s3 secret for %s: %w", prefixes[i], err)
+		}
+	}
+	return nil
+}
+
+// s3SecretStatements returns a CREATE SECRET statement per prefix. Scopes
+// end with a slash, so that a prefix never matches a sibling sharing its
+// name as a prefix, such as tenant t1 and tenant t10.
+func s3SecretStatements(creds S3Credentials, bucket string, prefixes []string) []string {
+	stmts := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		scope := fmt.Sprintf("s3://%s/%s/", bucket, strings.Trim(p, "/"))
+		stmts[i] = fmt.Sprintf(
+			"CREATE SECRET s3_scope_%d (TYPE S3, KEY_ID %s, SECRET %s, REGION %s, ENDPOINT %s, USE_SSL %t, URL_STYLE 'path', SCOPE %s)",
+			i, quote(creds.AccessKeyID), quote(creds.SecretAccessKey), quote(creds.Region), quote(creds.Endpoint), creds.UseSSL, quote(scope))
+	}
+	return stmts
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,275 @@
+package duckdbsandbox
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/marcboeker/go-duckdb"
+
+	"github.com/user/micro-dp/internal/datasetquery"
+)
+
+// session opens a DuckDB session with the views orders and Customers, as a
+// transform registers its input datasets.
+func session(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, stmt := range []string{
+		"CREATE TABLE _orders AS SELECT * FROM (VALUES (1, 10, 5.0), (2, 20, 7.5)) AS t(id, customer_id, amount)",
+		"CREATE TABLE _customers AS SELECT * FROM (VALUES (10, 'Tokyo'), (20, 'Osaka')) AS t(id, city)",
+		`CREATE VIEW "orders" AS SELECT * FROM _orders`,
+		`CREATE VIEW "Customers" AS SELECT * FROM _customers`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+var views = []string{"orders", "Customers"}
+
+func TestCheckSelectAccepts(t *testing.T) {
+	db := session(t)
+	queries := []string{
+		"SELECT * FROM orders",
+		"SELECT 1",
+		"SELECT * FROM orders;",
+		"SELECT o.id, c.city FROM orders o JOIN customers c ON o.customer_id = c.id",
+		`SELECT * FROM "Customers" WHERE city IN (SELECT city FROM customers)`,
+		"WITH big AS (SELECT * FROM orders WHERE amount > 6) SELECT count(*) FROM big",
+		"WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 3) SELECT * FROM n",
+		"SELECT id FROM orders UNION ALL SELECT id FROM customers",
+		"SELECT * FROM (SELECT customer_id, sum(amount) AS total FROM orders GROUP BY 1) t ORDER BY total",
+		"SELECT * FROM (VALUES (1), (2)) AS v(x)",
+		"SELECT id, 'read_csv(''/etc/passwd'')' AS note FROM orders -- s3://bucket/events/t2/",
+	}
+	for _, q := range queries {
+		if err := CheckSelect(context.Background(), db, q, views); err != nil {
+			t.Errorf("CheckSelect(%q) = %v, want nil", q, err)
+		}
+	}
+}
+
+func TestCheckSelectRejects(t *testing.T) {
+	db := session(t)
+	queries := []string{
+		// Other tenants' objects and local files
+		"SELECT * FROM read_parquet('s3://bucket/events/t2/dt=2024-01-01/a.parquet')",
+		"SELECT * FROM 's3://bucket/transforms/t2/dt=2024-01-01/run.parquet'",
+		"SELECT o.* FROM orders o JOIN read_parquet('s3://bucket/imports/t2/**/*.parquet') x ON o.id = x.id",
+		"SELECT * FROM orders WHERE id IN (SELECT id FROM read_parquet('s3://bucket/datasets/t2/x/1/*.parquet'))",
+		"WITH x AS (SELECT * FROM read_csv('/etc/passwd')) SELECT * FROM x",
+		"SELECT * FROM read_csv('/etc/passwd')",
+		"SELECT * FROM read_text('/etc/passwd')",
+		"SELECT * FROM '/etc/passwd'",
+		"SELECT * FROM glob('/*')",
+		"SELECT * FROM query('SELECT 1')",
+		// Settings and the environment
+		"SELECT current_setting('s3_secret_access_key')",
+		"SELECT getenv('HOME')",
+		"SELECT * FROM duckdb_secrets()",
+		"SELECT * FROM duckdb_settings()",
+		// Tables other than the views
+		"SELECT * FROM _orders",
+		"SELECT * FROM main.orders",
+		"SELECT * FROM memory.main.orders",
+		"SELECT * FROM information_schema.tables",
+		"DESCRIBE orders",
+		"SUMMARIZE orders",
+		// Statements other than a single SELECT
+		"",
+		"SELECT 1; SELECT 2",
+		"SELECT * FROM orders; DROP VIEW orders",
+		"COPY (SELECT * FROM orders) TO '/tmp/out.csv'",
+		"ATTACH '/tmp/other.db' AS other",
+		"INSTALL httpfs",
+		"LOAD httpfs",
+		"SET enable_external_access = true",
+		"CREATE TABLE t AS SELECT * FROM orders",
+		"DELETE FROM orders",
+		"PIVOT orders ON customer_id USING sum(amount)",
+		"SELECT * FROM orders) AS _q, read_csv('/etc/passwd') AS x --",
+		"SELEC 1",
+	}
+	for _, q := range queries {
+		if err := CheckSelect(context.Background(), db, q, views); !errors.Is(err, ErrRejected) {
+			t.Errorf("CheckSelect(%q) = %v, want ErrRejected", q, err)
+		}
+	}
+}
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret.csv")
+	if err := os.WriteFile(path, []byte("a,b\n1,2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	db := session(t)
+	if err := Lock(ctx, db, false); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.QueryRow("SELECT count(*) FROM orders o JOIN customers c ON o.customer_id = c.id").Scan(&n); err != nil || n != 2 {
+		t.Fatalf("query over views = %d, %v, want 2", n, err)
+	}
+	// Statements CheckSelect rejects still fail if they reach the session
+	for _, stmt := range []string{
+		"SELECT * FROM read_csv('" + path + "')",
+		"COPY orders TO '" + filepath.Join(t.TempDir(), "out.csv") + "'",
+		"LOAD httpfs",
+		"INSTALL httpfs",
+		"SET disabled_filesystems = ''",
+		"SET lock_configuration = false",
+		"SET autoload_known_extensions = true",
+	} {
+		if _, err := db.Exec(stmt); err == nil {
+			t.Errorf("%s after Lock succeeded, want an error", stmt)
+		}
+	}
+
+	db = session(t)
+	if err := Lock(ctx, db, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("COPY orders TO '" + filepath.Join(t.TempDir(), "out.parquet") + "' (FORMAT parquet)"); err != nil {
+		t.Errorf("COPY to a local file with allowLocalFiles: %v", err)
+	}
+	for _, stmt := range []string{"LOAD httpfs", "SET disabled_filesystems = ''"} {
+		if _, err := db.Exec(stmt); err == nil {
+			t.Errorf("%s after Lock succeeded, want an error", stmt)
+		}
+	}
+}
+
+func TestS3SecretStatements(t *testing.T) {
+	creds := S3Credentials{Endpoint: "minio:9000", Region: "us-east-1", AccessKeyID: "key", SecretAccessKey: "se'cret"}
+	stmts := s3SecretStatements(creds, "bucket", []string{"events/t1", "transforms/t1/"})
+	want := []string{
+		"CREATE SECRET s3_scope_0 (TYPE S3, KEY_ID 'key', SECRET 'se''cret', REGION 'us-east-1', ENDPOINT 'minio:9000', USE_SSL false, URL_STYLE 'path', SCOPE 's3://bucket/events/t1/')",
+		"CREATE SECRET s3_scope_1 (TYPE S3, KEY_ID 'key', SECRET 'se''cret', REGION 'us-east-1', ENDPOINT 'minio:9000', USE_SSL false, URL_STYLE 'path', SCOPE 's3://bucket/transforms/t1/')",
+	}
+	if len(stmts) != len(want) {
+		t.Fatalf("got %d statements, want %d", len(stmts), len(want))
+	}
+	for i := range want {
+		if stmts[i] != want[i] {
+			t.Errorf("statement %d = %s, want %s", i, stmts[i], want[i])
+		}
+	}
+}
+
+// TestS3SecretScopes reads objects through a session scoped to tenant t1
+// from an S3 endpoint that serves only signed requests. Objects outside the
+// scopes, of tenant t10 sharing the t1 prefix or of tenant t2, are read
+// without credentials and refused.
+func TestS3SecretScopes(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec("LOAD httpfs"); err != nil {
+		if _, err := db.Exec("INSTALL httpfs"); err != nil {
+			t.Skipf("httpfs is not available: %v", err)
+		}
+		if _, err := db.Exec("LOAD httpfs"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "a.parquet")
+	if _, err := db.Exec("COPY (SELECT 1 AS id) TO '" + path + "' (FORMAT parquet)"); err != nil {
+		t.Fatal(err)
+	}
+	object, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	signed := map[string]bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok := strings.Contains(r.Header.Get("Authorization"), "Credential=key/")
+		mu.Lock()
+		signed[r.URL.Path] = signed[r.URL.Path] || ok
+		mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+			return
+		}
+		http.ServeContent(w, r, "a.parquet", time.Now(), bytes.NewReader(object))
+	}))
+	t.Cleanup(srv.Close)
+
+	creds := S3Credentials{Endpoint: strings.TrimPrefix(srv.URL, "http://"), Region: "us-east-1", AccessKeyID: "key", SecretAccessKey: "secret"}
+	if err := ScopeS3(ctx, db, creds, "bucket", []string{"events/t1", "imports/t1"}); err != nil {
+		t.Fatal(err)
+	}
+	for key, readable := range map[string]bool{
+		"events/t1/dt=2024-01-01/a.parquet":  true,
+		"imports/t1/dt=2024-01-01/a.parquet": true,
+		"events/t10/dt=2024-01-01/a.parquet": false,
+		"events/t2/dt=2024-01-01/a.parquet":  false,
+		"transforms/t1/run.parquet":          false,
+	} {
+		var n int
+		err := db.QueryRowContext(ctx, "SELECT count(*) FROM read_parquet('s3://bucket/"+key+"')").Scan(&n)
+		if readable && (err != nil || n != 1) {
+			t.Errorf("read %s = %d, %v, want its row", key, n, err)
+		}
+		if !readable && err == nil {
+			t.Errorf("read %s succeeded, want it refused", key)
+		}
+		mu.Lock()
+		got := signed["/bucket/"+key]
+		mu.Unlock()
+		if got != readable {
+			t.Errorf("%s signed = %v, want %v", key, got, readable)
+		}
+	}
+}
+
+// TestCreateView registers datasets whose names hold quotes, as the names
+// of uploaded datasets may, and reads them by their quoted names.
+func TestCreateView(t *testing.T) {
+	ctx := context.Background()
+	db := session(t)
+	names := []string{`o"rders`, `x" AS SELECT 1; DROP TABLE _orders; --`}
+	for _, name := range names {
+		if err := CreateView(ctx, db, name, "_orders"); err != nil {
+			t.Fatalf("CreateView(%q): %v", name, err)
+		}
+	}
+	for _, name := range names {
+		query := "SELECT count(*) FROM " + datasetquery.QuoteIdentifier(name)
+		if err := CheckSelect(ctx, db, query, append([]string{name}, views...)); err != nil {
+			t.Errorf("CheckSelect(%q) = %v, want nil", query, err)
+		}
+		var n int
+		if err := db.QueryRow(query).Scan(&n); err != nil || n != 2 {
+			t.Errorf("%s = %d, %v, want the 2 orders", query, n, err)
+		}
+	}
+	// The view is named after the dataset, not read as two names
+	if err := CheckSelect(ctx, db, `SELECT * FROM "o""rders"`, []string{"o"}); !errors.Is(err, ErrRejected) {
+		t.Errorf("CheckSelect with another view name = %v, want ErrRejected", err)
+	}
+}
@@ -0,0 +1,314 @@
+// Package fileconv stages uploaded files (delimited text, JSON, Parquet,
+// Excel workbooks, gzip files and zip archives) as DuckDB tables, one per
+// dataset they produce.
+package fileconv
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+
+	"github.com/user/micro-dp/internal/gsheets"
+	"github.com/user/micro-dp/internal/xlsx"
+)
+
+const (
+	// maxExtractedBytes caps the uncompressed size of a gzip file, zip
+	// archive or Excel workbook.
+	maxExtractedBytes = 1 << 30 // 1 GiB
+	// maxArchiveMembers caps the number of files read from a zip archive.
+	maxArchiveMembers = 100
+)
+
+var (
+	// ErrUnsupportedFormat is returned for uploads whose extension has no
+	// converter.
+	ErrUnsupportedFormat = errors.New("unsupported file format")
+	// ErrNoData is returned for workbooks and archives with nothing to
+	// convert.
+	ErrNoData = errors.New("no data to import")
+)
+
+// Table is a DuckDB table or view staged from an uploaded file, waiting to
+// be written as a dataset.
+type Table struct {
+	DatasetName string
+	Name        string
+	// ParquetPath is set for Parquet uploads, which are stored as-is.
+	ParquetPath string
+}
+
+// Converter stages the tables of one uploaded file into DuckDB,
+// dispatching on the file extension:
+//
+//   - .csv, .tsv, .txt: delimited text, read with the converter's Options
+//     (by default the delimiter of .csv and .txt is sniffed and .tsv is
+//     tab-separated)
+//   - .json, .ndjson, .jsonl: a JSON array of objects or newline-delimited JSON
+//   - .parquet: read for its schema and stored unchanged
+//   - .xlsx: one table per non-empty visible sheet
+//   - .gz: a gzip-compressed file of any of the above, e.g. orders.csv.gz
+//   - .zip: one table per supported member
+type Converter struct {
+	duckDB    *sql.DB
+	tmpDir    string
+	opts      *Options
+	tables    []Table
+	extracted int64 // bytes decompressed so far
+	files     int   // temp files created so far
+}
+
+// NewConverter creates a Converter that stages tables in duckDB and keeps
+// extracted files in tmpDir. opts may be nil.
+func NewConverter(duckDB *sql.DB, tmpDir string, opts *Options) *Converter {
+	if opts == nil {
+		opts = &Options{}
+	}
+	return &Converter{duckDB: duckDB, tmpDir: tmpDir, opts: opts}
+}
+
+// Tables returns the tables staged so far, in file order.
+func (c *Converter) Tables() []Table {
+	return c.tables
+}
+
+// Convert stages the file at path. fileName is the uploaded (or archive
+// member) name that selects the format, datasetName the dataset to write.
+func (c *Converter) Convert(ctx context.Context, path, fileName, datasetName string) error {
+	switch ext := strings.ToLower(filepath.Ext(fileName)); ext {
+	case ".csv", ".txt", ".tsv":
+		return c.loadDelimited(ctx, path, ext, datasetName)
+	case ".json", ".ndjson", ".jsonl":
+		return c.loadQuery(ctx, datasetName, fmt.Sprintf("read_json_auto('%s')", path))
+	case ".parquet":
+		return c.loadParquet(ctx, path, datasetName)
+	case ".xlsx":
+		return c.loadWorkbook(ctx, path, datasetName)
+	case ".gz":
+		return c.loadGzip(ctx, path, fileName, datasetName)
+	case ".zip":
+		return c.loadArchive(ctx, path, datasetName)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, ext)
+	}
+}
+
+// loadQuery materializes a DuckDB table function into a new table.
+func (c *Converter) loadQuery(ctx context.Context, datasetName, source string) error {
+	table := c.nextTable()
+	if _, err := c.duckDB.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s AS SELECT * FROM %s", table, source)); err != nil {
+		return fmt.Errorf("read %s: %w", datasetName, err)
+	}
+	c.tables = append(c.tables, Table{DatasetName: datasetName, Name: table})
+	return nil
+}
+
+func (c *Converter) loadDelimited(ctx context.Context, path, ext, datasetName string) error {
+	if enc := c.opts.encoding(); enc != nil {
+		out := c.tempPath(ext)
+		if err := c.transcode(path, out, enc); err != nil {
+			return fmt.Errorf("decode %s: %w", c.opts.Encoding, err)
+		}
+		path = out
+	}
+	defaultDelim := ""
+	if ext == ".tsv" {
+		defaultDelim = "\t"
+	}
+	return c.loadQuery(ctx, datasetName, fmt.Sprintf("read_csv_auto(%s%s)", sqlString(path), c.opts.csvParams(defaultDelim)))
+}
+
+func (c *Converter) loadParquet(ctx context.Context, path, datasetName string) error {
+	table := c.nextTable()
+	_, err := c.duckDB.ExecContext(ctx, fmt.Sprintf("CREATE VIEW %s AS SELECT * FROM read_parquet('%s')", table, path))
+	if err == nil {
+		// Views are bound lazily; make sure the file really is Parquet.
+		_, err = c.duckDB.ExecContext(ctx, fmt.Sprintf("DESCRIBE %s", table))
+	}
+	if err != nil {
+		return fmt.Errorf("read parquet %s: %w", datasetName, err)
+	}
+	c.tables = append(c.tables, Table{DatasetName: datasetName, Name: table, ParquetPath: path})
+	return nil
+}
+
+func (c *Converter) loadWorkbook(ctx context.Context, path, datasetName string) error {
+	sheets, err := xlsx.ReadFile(path, xlsx.Limits{UnzipSizeLimit: maxExtractedBytes - c.extracted})
+	if err != nil {
+		return err
+	}
+	loaded := 0
+	for _, sheet := range sheets {
+		if len(sheet.Values) == 0 {
+			continue
+		}
+		table, err := gsheets.Build(sheet.Values, gsheets.Options{Merges: sheet.Merges})
+		if err != nil {
+			return fmt.Errorf("sheet %q: %w", sheet.Name, err)
+		}
+		name := c.nextTable()
+		if err := LoadTypedTable(ctx, c.duckDB, name, filepath.Join(c.tmpDir, name+".csv"), table); err != nil {
+			return fmt.Errorf("sheet %q: %w", sheet.Name, err)
+		}
+		c.tables = append(c.tables, Table{
+			DatasetName: fmt.Sprintf("%s - %s", datasetName, sheet.Name),
+			Name:        name,
+		})
+		loaded++
+	}
+	if loaded == 0 {
+		return fmt.Errorf("%w: workbook has no sheets with data", ErrNoData)
+	}
+	return nil
+}
+
+// loadGzip decompresses name.ext.gz and converts it as name.ext.
+func (c *Converter) loadGzip(ctx context.Context, path, fileName, datasetName string) error {
+	inner := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	innerExt := strings.ToLower(filepath.Ext(inner))
+	if innerExt == ".gz" || innerExt == ".zip" {
+		return fmt.Errorf("%w: nested archive %q", ErrUnsupportedFormat, fileName)
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	zr, err := gzip.NewReader(src)
+	if err != nil {
+		return fmt.Errorf("open gzip: %w", err)
+	}
+	defer zr.Close()
+
+	out := c.tempPath(innerExt)
+	if err := c.extract(out, zr); err != nil {
+		return fmt.Errorf("decompress gzip: %w", err)
+	}
+	return c.Convert(ctx, out, inner, datasetName)
+}
+
+// loadArchive converts every supported member of a zip archive into its
+// own dataset named "<archive> - <member path without extension>".
+// Directories, hidden files, nested archives and unsupported members are
+// skipped.
+func (c *Converter) loadArchive(ctx context.Context, archivePath, datasetName string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("open zip: %w", err)
+	}
+	defer zr.Close()
+
+	members := 0
+	for _, f := range zr.File {
+		name := f.Name
+		if f.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".") {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(name))
+		if ext == ".gz" {
+			ext = strings.ToLower(path.Ext(strings.TrimSuffix(name, path.Ext(name))))
+		}
+		if ext == ".zip" || !isConvertible(ext) {
+			log.Printf("upload import: skipping archive member %q", name)
+			continue
+		}
+		members++
+		if members > maxArchiveMembers {
+			return fmt.Errorf("zip archive has more than %d files", maxArchiveMembers)
+		}
+
+		out := c.tempPath(strings.ToLower(path.Ext(name)))
+		if err := c.extractMember(f, out); err != nil {
+			return fmt.Errorf("extract %q: %w", name, err)
+		}
+		memberName := fmt.Sprintf("%s - %s", datasetName, DatasetBaseName(name))
+		if err := c.Convert(ctx, out, name, memberName); err != nil {
+			return fmt.Errorf("%q: %w", name, err)
+		}
+	}
+	if members == 0 {
+		return fmt.Errorf("%w: zip archive has no supported files", ErrNoData)
+	}
+	return nil
+}
+
+func (c *Converter) extractMember(f *zip.File, out string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return c.extract(out, rc)
+}
+
+// extract copies r to a new file, failing once the total extracted size
+// exceeds maxExtractedBytes.
+func (c *Converter) extract(out string, r io.Reader) error {
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	remaining := maxExtractedBytes - c.extracted
+	n, err := io.Copy(f, io.LimitReader(r, remaining+1))
+	c.extracted += n
+	if err != nil {
+		return err
+	}
+	if n > remaining {
+		return fmt.Errorf("uncompressed size exceeds %d bytes", int64(maxExtractedBytes))
+	}
+	return f.Close()
+}
+
+// transcode rewrites the file at path as UTF-8.
+func (c *Converter) transcode(path, out string, enc encoding.Encoding) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return c.extract(out, transform.NewReader(src, enc.NewDecoder()))
+}
+
+func (c *Converter) nextTable() string {
+	return fmt.Sprintf("upload_%d", len(c.tables))
+}
+
+func (c *Converter) tempPath(ext string) string {
+	c.files++
+	return filepath.Join(c.tmpDir, fmt.Sprintf("extracted_%d%s", c.files, ext))
+}
+
+// isConvertible reports whether uncompressed files with the given extension
+// have a converter.
+func isConvertible(ext string) bool {
+	switch ext {
+	case ".csv", ".txt", ".tsv", ".json", ".ndjson", ".jsonl", ".parquet", ".xlsx":
+		return true
+	}
+	return false
+}
+
+// DatasetBaseName strips the extension, and a .gz suffix before it, from an
+// uploaded file name: "orders.csv.gz" becomes "orders".
+func DatasetBaseName(name string) string {
+	if strings.EqualFold(path.Ext(name), ".gz") {
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	return strings.TrimSuffix(name, path.Ext(name))
+}
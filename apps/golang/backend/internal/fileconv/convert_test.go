@@ -0,0 +1,171 @@
+package fileconv
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	_ "github.com/marcboeker/go-duckdb"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func openDuckDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func queryStrings(t *testing.T, db *sql.DB, query string) []string {
+	t.Helper()
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestConvertWithOptions(t *testing.T) {
+	dir := t.TempDir()
+	text := "exported by POS\ncode;name;amount\n007;東京;1,5\n010;大阪;NA\n"
+	sjis, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeFile(t, dir, "input.csv", sjis)
+
+	db := openDuckDB(t)
+	conv := NewConverter(db, dir, &Options{
+		Delimiter:   ";",
+		SkipRows:    1,
+		Encoding:    "shift_jis",
+		NullStrings: []string{"NA"},
+		ColumnTypes: map[string]string{"code": "VARCHAR"},
+	})
+	if err := conv.Convert(context.Background(), path, "stores.csv", "stores"); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	tables := conv.Tables()
+	if len(tables) != 1 || tables[0].DatasetName != "stores" {
+		t.Fatalf("tables = %+v", tables)
+	}
+
+	got := queryStrings(t, db, "SELECT code || '|' || name || '|' || COALESCE(amount, 'null') FROM "+tables[0].Name+" ORDER BY code")
+	want := []string{"007|東京|1,5", "010|大阪|null"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+}
+
+func TestConvertFormats(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("id,name\n1,a\n2,b\n"))
+	zw.Close()
+
+	var archive bytes.Buffer
+	ar := zip.NewWriter(&archive)
+	for name, body := range map[string]string{
+		"orders/2024.csv": "id\n1\n2\n",
+		"notes.md":        "# skipped",
+		"events.ndjson":   `{"id": 1}` + "\n" + `{"id": 2}` + "\n",
+	} {
+		w, err := ar.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	ar.Close()
+
+	tests := []struct {
+		name     string
+		data     []byte
+		datasets []string
+	}{
+		{"orders.csv", []byte("id,name\n1,a\n2,b\n"), []string{"orders"}},
+		{"orders.tsv", []byte("id\tname\n1\ta\n2\tb\n"), []string{"orders"}},
+		{"orders.txt", []byte("id|name\n1|a\n2|b\n"), []string{"orders"}},
+		{"orders.json", []byte(`[{"id": 1, "name": "a"}, {"id": 2, "name": "b"}]`), []string{"orders"}},
+		{"orders.csv.gz", gz.Bytes(), []string{"orders"}},
+		{"bundle.zip", archive.Bytes(), []string{"bundle - events", "bundle - orders/2024"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeFile(t, dir, "input", tt.data)
+			db := openDuckDB(t)
+			conv := NewConverter(db, dir, nil)
+			if err := conv.Convert(context.Background(), path, tt.name, DatasetBaseName(tt.name)); err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+			var names []string
+			for _, table := range conv.Tables() {
+				names = append(names, table.DatasetName)
+				var n int
+				if err := db.QueryRow("SELECT COUNT(*) FROM " + table.Name).Scan(&n); err != nil {
+					t.Fatal(err)
+				}
+				if n != 2 {
+					t.Errorf("%s: %d rows, want 2", table.DatasetName, n)
+				}
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.datasets) {
+				t.Errorf("datasets = %v, want %v", names, tt.datasets)
+			}
+		})
+	}
+}
+
+func TestConvertUnsupported(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "input", []byte("x"))
+	conv := NewConverter(openDuckDB(t), dir, nil)
+	if err := conv.Convert(context.Background(), path, "report.pdf", "report"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("err = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestDatasetBaseName(t *testing.T) {
+	for in, want := range map[string]string{
+		"orders.csv":    "orders",
+		"orders.csv.gz": "orders",
+		"orders.GZ":     "orders",
+		"a.b.json":      "a.b",
+	} {
+		if got := DatasetBaseName(in); got != want {
+			t.Errorf("DatasetBaseName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
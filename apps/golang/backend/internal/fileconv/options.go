@@ -0,0 +1,153 @@
+package fileconv
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+const (
+	maxSkipRows    = 10000
+	maxNullStrings = 20
+)
+
+// Options are parse options for delimited text files. Unset fields are
+// sniffed from the file, like read_csv_auto does.
+type Options struct {
+	// Delimiter is the single-character column separator; "\t" is a tab.
+	Delimiter string `json:"delimiter,omitempty"`
+	// Quote is the single-character quote.
+	Quote string `json:"quote,omitempty"`
+	// Header tells whether the first row (after SkipRows) holds column
+	// names. Without a header, columns are named column0, column1, ...
+	Header *bool `json:"header,omitempty"`
+	// SkipRows is the number of lines to skip before the header or data.
+	SkipRows int `json:"skip_rows,omitempty"`
+	// Encoding is the file's character encoding, e.g. "shift_jis",
+	// "euc-jp" or "windows-1252". Defaults to UTF-8.
+	Encoding string `json:"encoding,omitempty"`
+	// NullStrings are values read as NULL.
+	NullStrings []string `json:"null_strings,omitempty"`
+	// DateFormat is a strptime format for DATE columns, e.g. "%d/%m/%Y".
+	DateFormat string `json:"date_format,omitempty"`
+	// ColumnTypes overrides the sniffed type of columns by name, e.g.
+	// {"zip_code": "VARCHAR"} to keep leading zeros.
+	ColumnTypes map[string]string `json:"column_types,omitempty"`
+}
+
+var columnTypePattern = regexp.MustCompile(`^(VARCHAR|TEXT|BOOLEAN|TINYINT|SMALLINT|INTEGER|BIGINT|HUGEINT|UTINYINT|USMALLINT|UINTEGER|UBIGINT|FLOAT|DOUBLE|DATE|TIME|TIMESTAMP|TIMESTAMPTZ|UUID|DECIMAL\([0-9]{1,2}(, ?[0-9]{1,2})?\))$`)
+
+// Validate checks the options and normalizes column type names to upper
+// case.
+func (o *Options) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.Delimiter != "" && o.Delimiter != `\t` && utf8.RuneCountInString(o.Delimiter) != 1 {
+		return fmt.Errorf("delimiter must be a single character")
+	}
+	if strings.ContainsAny(o.Delimiter, "\r\n") {
+		return fmt.Errorf("delimiter must not be a line break")
+	}
+	if o.Quote != "" && utf8.RuneCountInString(o.Quote) != 1 {
+		return fmt.Errorf("quote must be a single character")
+	}
+	if o.Quote != "" && o.Quote == o.Delimiter {
+		return fmt.Errorf("quote and delimiter must differ")
+	}
+	if o.SkipRows < 0 || o.SkipRows > maxSkipRows {
+		return fmt.Errorf("skip_rows must be between 0 and %d", maxSkipRows)
+	}
+	if o.Encoding != "" {
+		if _, err := htmlindex.Get(o.Encoding); err != nil {
+			return fmt.Errorf("unsupported encoding %q", o.Encoding)
+		}
+	}
+	if len(o.NullStrings) > maxNullStrings {
+		return fmt.Errorf("at most %d null_strings are allowed", maxNullStrings)
+	}
+	if o.DateFormat != "" && !strings.Contains(o.DateFormat, "%") {
+		return fmt.Errorf("date_format must be a strptime format such as %%Y-%%m-%%d")
+	}
+	for name, typ := range o.ColumnTypes {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("column_types has an empty column name")
+		}
+		typ = strings.ToUpper(strings.TrimSpace(typ))
+		if !columnTypePattern.MatchString(typ) {
+			return fmt.Errorf("column_types[%q]: unsupported type %q", name, o.ColumnTypes[name])
+		}
+		o.ColumnTypes[name] = typ
+	}
+	return nil
+}
+
+// encoding returns the decoder for a non-UTF-8 Encoding, or nil.
+func (o *Options) encoding() encoding.Encoding {
+	if o.Encoding == "" {
+		return nil
+	}
+	enc, err := htmlindex.Get(o.Encoding)
+	if err != nil {
+		return nil
+	}
+	if name, _ := htmlindex.Name(enc); name == "utf-8" {
+		return nil
+	}
+	return enc
+}
+
+// csvParams renders the options as read_csv named parameters, each with a
+// leading comma. defaultDelim applies when no Delimiter is set.
+func (o *Options) csvParams(defaultDelim string) string {
+	var b strings.Builder
+	delim := o.Delimiter
+	if delim == "" {
+		delim = defaultDelim
+	}
+	if delim != "" {
+		fmt.Fprintf(&b, ", delim=%s", sqlString(delim))
+	}
+	if o.Quote != "" {
+		fmt.Fprintf(&b, ", quote=%s", sqlString(o.Quote))
+	}
+	if o.Header != nil {
+		fmt.Fprintf(&b, ", header=%t", *o.Header)
+	}
+	if o.SkipRows > 0 {
+		fmt.Fprintf(&b, ", skip=%d", o.SkipRows)
+	}
+	if len(o.NullStrings) > 0 {
+		items := make([]string, len(o.NullStrings))
+		for i, s := range o.NullStrings {
+			items[i] = sqlString(s)
+		}
+		fmt.Fprintf(&b, ", nullstr=[%s]", strings.Join(items, ", "))
+	}
+	if o.DateFormat != "" {
+		fmt.Fprintf(&b, ", dateformat=%s", sqlString(o.DateFormat))
+	}
+	if len(o.ColumnTypes) > 0 {
+		names := make([]string, 0, len(o.ColumnTypes))
+		for name := range o.ColumnTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		items := make([]string, len(names))
+		for i, name := range names {
+			items[i] = fmt.Sprintf("%s: %s", sqlString(name), sqlString(o.ColumnTypes[name]))
+		}
+		fmt.Fprintf(&b, ", types={%s}", strings.Join(items, ", "))
+	}
+	return b.String()
+}
+
+// sqlString quotes s as a SQL string literal.
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
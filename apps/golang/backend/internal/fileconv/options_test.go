@@ -0,0 +1,77 @@
+package fileconv
+
+import "testing"
+
+func TestOptionsValidate(t *testing.T) {
+	header := false
+	valid := &Options{
+		Delimiter:   ";",
+		Quote:       "'",
+		Header:      &header,
+		SkipRows:    2,
+		Encoding:    "Shift_JIS",
+		NullStrings: []string{"", "NA"},
+		DateFormat:  "%d/%m/%Y",
+		ColumnTypes: map[string]string{"zip": "varchar", "amount": "decimal(12, 2)"},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if valid.ColumnTypes["zip"] != "VARCHAR" || valid.ColumnTypes["amount"] != "DECIMAL(12, 2)" {
+		t.Errorf("column types not normalized: %v", valid.ColumnTypes)
+	}
+	if err := (*Options)(nil).Validate(); err != nil {
+		t.Errorf("nil options: %v", err)
+	}
+	if err := (&Options{Delimiter: `\t`}).Validate(); err != nil {
+		t.Errorf(`\t delimiter: %v`, err)
+	}
+
+	invalid := map[string]*Options{
+		"long delimiter":   {Delimiter: ";;"},
+		"line break":       {Delimiter: "\n"},
+		"long quote":       {Quote: `""`},
+		"quote delimiter":  {Delimiter: ",", Quote: ","},
+		"negative skip":    {SkipRows: -1},
+		"unknown encoding": {Encoding: "klingon"},
+		"date format":      {DateFormat: "dd/mm/yyyy"},
+		"type injection":   {ColumnTypes: map[string]string{"a": "VARCHAR); DROP TABLE x; --"}},
+		"empty column":     {ColumnTypes: map[string]string{" ": "VARCHAR"}},
+	}
+	for name, opts := range invalid {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestOptionsCSVParams(t *testing.T) {
+	header := true
+	opts := &Options{
+		Quote:       "'",
+		Header:      &header,
+		SkipRows:    1,
+		NullStrings: []string{"N/A", "it's null"},
+		DateFormat:  "%Y/%m/%d",
+		ColumnTypes: map[string]string{"b": "DATE", "a": "VARCHAR"},
+	}
+	want := `, delim='\t', quote='''', header=true, skip=1, nullstr=['N/A', 'it''s null'], dateformat='%Y/%m/%d', types={'a': 'VARCHAR', 'b': 'DATE'}`
+	if got := opts.csvParams(`\t`); got != want {
+		t.Errorf("csvParams =\n%s\nwant\n%s", got, want)
+	}
+	if got := (&Options{Delimiter: ";"}).csvParams(`\t`); got != ", delim=';'" {
+		t.Errorf("explicit delimiter: %s", got)
+	}
+	if got := (&Options{}).csvParams(""); got != "" {
+		t.Errorf("empty options: %s", got)
+	}
+}
+
+func TestOptionsEncoding(t *testing.T) {
+	if (&Options{}).encoding() != nil || (&Options{Encoding: "UTF-8"}).encoding() != nil {
+		t.Error("UTF-8 should need no decoder")
+	}
+	if (&Options{Encoding: "shift_jis"}).encoding() == nil {
+		t.Error("shift_jis decoder missing")
+	}
+}
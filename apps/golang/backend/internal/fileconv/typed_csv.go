@@ -0,0 +1,75 @@
+package fileconv
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/user/micro-dp/internal/gsheets"
+)
+
+// LoadTypedTable writes a typed table to csvPath and loads it into DuckDB
+// as tableName with the inferred column types.
+func LoadTypedTable(ctx context.Context, duckDB *sql.DB, tableName, csvPath string, table *gsheets.Table) error {
+	if err := writeTypedCSV(csvPath, table); err != nil {
+		return fmt.Errorf("write csv: %w", err)
+	}
+	_, err := duckDB.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE %s AS SELECT * FROM read_csv('%s', header=true, auto_detect=false, delim=',', quote='\"', escape='\"', columns=%s)",
+		tableName, csvPath, duckDBColumns(table.Columns)))
+	if err != nil {
+		return fmt.Errorf("read csv: %w", err)
+	}
+	return nil
+}
+
+// writeTypedCSV writes a typed table as CSV with a header row. NULL cells are
+// written as empty fields.
+func writeTypedCSV(path string, table *gsheets.Table) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	writer := csv.NewWriter(f)
+
+	header := make([]string, len(table.Columns))
+	for i, c := range table.Columns {
+		header[i] = c.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i, v := range row {
+			record[i] = ""
+			if v != nil {
+				record[i] = *v
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// duckDBColumns renders a read_csv columns struct such as {'id': 'BIGINT'}.
+func duckDBColumns(cols []gsheets.Column) string {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		parts[i] = fmt.Sprintf("'%s': '%s'", strings.ReplaceAll(c.Name, "'", "''"), c.Type)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
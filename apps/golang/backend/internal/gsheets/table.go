@@ -0,0 +1,338 @@
+// Package gsheets turns the value grids returned by the Google Sheets API
+// into typed tables: it resolves the header row (including merged and blank
+// header cells) and infers a DuckDB type for every column.
+package gsheets
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Column types produced by Build.
+const (
+	TypeVarchar   = "VARCHAR"
+	TypeBigint    = "BIGINT"
+	TypeDouble    = "DOUBLE"
+	TypeBoolean   = "BOOLEAN"
+	TypeDate      = "DATE"
+	TypeTimestamp = "TIMESTAMP"
+)
+
+// Merge is a merged cell range in sheet coordinates (0-based, end exclusive),
+// as returned in the Sheets API GridRange.
+type Merge struct {
+	StartRow    int `json:"startRowIndex"`
+	EndRow      int `json:"endRowIndex"`
+	StartColumn int `json:"startColumnIndex"`
+	EndColumn   int `json:"endColumnIndex"`
+}
+
+// Options controls how a value grid is turned into a table.
+type Options struct {
+	// HeaderRow is the 1-based row of the grid that holds column names.
+	// Rows above it are skipped. Defaults to 1.
+	HeaderRow int
+	// OriginRow and OriginColumn are the 0-based sheet coordinates of the
+	// grid's top-left cell, i.e. the start of the requested range.
+	OriginRow    int
+	OriginColumn int
+	// Merges are the sheet's merged ranges; merged header cells repeat the
+	// merge's value.
+	Merges []Merge
+}
+
+// Column is a named, typed table column.
+type Column struct {
+	Name string
+	Type string
+}
+
+// Table is a typed table. Cell values are normalized text suitable for a
+// typed CSV load (ISO dates, "true"/"false"); nil means NULL.
+type Table struct {
+	Columns []Column
+	Rows    [][]*string
+}
+
+// Build converts the rows of a values.get response (decoded with
+// json.Decoder.UseNumber) into a Table.
+//
+// Header cells are resolved deterministically: a cell inside a merged range
+// takes the merge's value, a blank cell is named column_<letter> after its
+// sheet column, and duplicates (case-insensitive) get _2, _3, ... suffixes in
+// column order. Blank data rows are skipped.
+func Build(values [][]any, opts Options) (*Table, error) {
+	headerRow := opts.HeaderRow
+	if headerRow <= 0 {
+		headerRow = 1
+	}
+	if len(values) < headerRow {
+		return nil, fmt.Errorf("header row %d is beyond the %d rows returned", headerRow, len(values))
+	}
+	header := values[headerRow-1]
+	data := values[headerRow:]
+
+	width := len(header)
+	for _, row := range data {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	if width == 0 {
+		return nil, fmt.Errorf("header row %d is empty", headerRow)
+	}
+
+	names := resolveHeader(values, headerRow-1, width, opts)
+
+	var rows [][]any
+	for _, row := range data {
+		if !blankRow(row) {
+			rows = append(rows, row)
+		}
+	}
+
+	t := &Table{Columns: make([]Column, width), Rows: make([][]*string, len(rows))}
+	for i := range t.Rows {
+		t.Rows[i] = make([]*string, width)
+	}
+	for col := 0; col < width; col++ {
+		cells := make([]any, len(rows))
+		for i, row := range rows {
+			if col < len(row) {
+				cells[i] = row[col]
+			}
+		}
+		typ, rendered := inferColumn(cells)
+		t.Columns[col] = Column{Name: names[col], Type: typ}
+		for i := range rows {
+			t.Rows[i][col] = rendered[i]
+		}
+	}
+	return t, nil
+}
+
+func resolveHeader(values [][]any, headerIdx, width int, opts Options) []string {
+	raw := make([]string, width)
+	for col := 0; col < width; col++ {
+		raw[col] = cellText(cellAt(values, headerIdx, col))
+	}
+
+	absRow := opts.OriginRow + headerIdx
+	for _, m := range opts.Merges {
+		if absRow < m.StartRow || absRow >= m.EndRow {
+			continue
+		}
+		value := cellText(cellAt(values, m.StartRow-opts.OriginRow, m.StartColumn-opts.OriginColumn))
+		if value == "" {
+			// The merge starts outside the fetched grid; use the first
+			// non-blank header cell it covers.
+			for c := m.StartColumn; c < m.EndColumn && value == ""; c++ {
+				if rel := c - opts.OriginColumn; rel >= 0 && rel < width {
+					value = raw[rel]
+				}
+			}
+		}
+		for c := m.StartColumn; c < m.EndColumn; c++ {
+			if rel := c - opts.OriginColumn; rel >= 0 && rel < width && raw[rel] == "" {
+				raw[rel] = value
+			}
+		}
+	}
+
+	names := make([]string, width)
+	used := make(map[string]bool, width)
+	for col, name := range raw {
+		if name == "" {
+			name = "column_" + ColumnLetter(opts.OriginColumn+col)
+		}
+		candidate := name
+		for n := 2; used[strings.ToLower(candidate)]; n++ {
+			candidate = fmt.Sprintf("%s_%d", name, n)
+		}
+		used[strings.ToLower(candidate)] = true
+		names[col] = candidate
+	}
+	return names
+}
+
+func cellAt(values [][]any, row, col int) any {
+	if row < 0 || row >= len(values) || col < 0 || col >= len(values[row]) {
+		return nil
+	}
+	return values[row][col]
+}
+
+func cellText(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(x)
+	case bool:
+		if x {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return strings.TrimSpace(fmt.Sprint(x))
+	}
+}
+
+func blankRow(row []any) bool {
+	for _, v := range row {
+		if s, ok := v.(string); ok && s == "" {
+			continue
+		}
+		if v != nil {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	dateLayouts     = []string{"2006-01-02", "2006/01/02", "1/2/2006"}
+	dateTimeLayouts = []string{
+		time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04",
+		"2006/01/02 15:04:05", "1/2/2006 15:04:05", "1/2/2006 15:04",
+	}
+)
+
+// inferColumn picks the narrowest type that fits every non-blank cell and
+// renders the cells for that type. Mixed columns fall back to VARCHAR.
+func inferColumn(cells []any) (string, []*string) {
+	allBool, allInt, allNumber, allDate, allDateTime := true, true, true, true, true
+	nonBlank := 0
+	for _, v := range cells {
+		switch x := v.(type) {
+		case nil:
+			continue
+		case string:
+			if x == "" {
+				continue
+			}
+			nonBlank++
+			allBool, allInt, allNumber = false, false, false
+			if _, ok := parseTime(x, dateLayouts); !ok {
+				allDate = false
+				if _, ok := parseTime(x, dateTimeLayouts); !ok {
+					allDateTime = false
+				}
+			}
+		case bool:
+			nonBlank++
+			allInt, allNumber, allDate, allDateTime = false, false, false, false
+		case json.Number:
+			nonBlank++
+			allBool, allDate, allDateTime = false, false, false
+			if _, err := strconv.ParseInt(x.String(), 10, 64); err != nil {
+				allInt = false
+			}
+		case float64:
+			nonBlank++
+			allBool, allDate, allDateTime = false, false, false
+			if x != float64(int64(x)) {
+				allInt = false
+			}
+		default:
+			nonBlank++
+			allBool, allInt, allNumber, allDate, allDateTime = false, false, false, false, false
+		}
+	}
+
+	typ := TypeVarchar
+	switch {
+	case nonBlank == 0:
+	case allBool:
+		typ = TypeBoolean
+	case allInt:
+		typ = TypeBigint
+	case allNumber:
+		typ = TypeDouble
+	case allDate:
+		typ = TypeDate
+	case allDateTime:
+		typ = TypeTimestamp
+	}
+
+	out := make([]*string, len(cells))
+	for i, v := range cells {
+		if s, ok := render(v, typ); ok {
+			out[i] = &s
+		}
+	}
+	return typ, out
+}
+
+func render(v any, typ string) (string, bool) {
+	switch x := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		if x == "" {
+			return "", false
+		}
+		switch typ {
+		case TypeDate:
+			t, _ := parseTime(x, dateLayouts)
+			return t.Format("2006-01-02"), true
+		case TypeTimestamp:
+			t, ok := parseTime(x, dateLayouts)
+			if !ok {
+				t, _ = parseTime(x, dateTimeLayouts)
+			}
+			return t.UTC().Format("2006-01-02 15:04:05"), true
+		}
+		return x, true
+	case bool:
+		if typ == TypeBoolean {
+			return strconv.FormatBool(x), true
+		}
+		return cellText(x), true
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	default:
+		return fmt.Sprint(x), true
+	}
+}
+
+func parseTime(s string, layouts []string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ColumnLetter returns the A1 column letters for a 0-based column index.
+func ColumnLetter(col int) string {
+	var b []byte
+	for col++; col > 0; col = (col - 1) / 26 {
+		b = append([]byte{byte('A' + (col-1)%26)}, b...)
+	}
+	return string(b)
+}
+
+// RangeOrigin returns the 0-based row and column of the top-left cell of an
+// A1 range such as "B3:H", "C:F" or "5:9". An empty range starts at A1.
+func RangeOrigin(a1 string) (row, col int) {
+	start, _, _ := strings.Cut(a1, ":")
+	i := 0
+	for i < len(start) && unicode.IsLetter(rune(start[i])) {
+		col = col*26 + int(unicode.ToUpper(rune(start[i]))-'A'+1)
+		i++
+	}
+	if col > 0 {
+		col--
+	}
+	if n, err := strconv.Atoi(start[i:]); err == nil && n > 0 {
+		row = n - 1
+	}
+	return row, col
+}
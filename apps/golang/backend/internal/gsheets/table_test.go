@@ -0,0 +1,133 @@
+package gsheets
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// decode parses a values.get style JSON grid the way the importer does.
+func decode(t *testing.T, s string) [][]any {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewBufferString(s))
+	dec.UseNumber()
+	var v [][]any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func cellValues(row []*string) []any {
+	out := make([]any, len(row))
+	for i, c := range row {
+		if c != nil {
+			out[i] = *c
+		}
+	}
+	return out
+}
+
+func TestBuildTypes(t *testing.T) {
+	values := decode(t, `[
+		["id", "amount", "active", "signup", "seen_at", "note", "mixed"],
+		[1, 9.5, true, "2024-01-31", "2024-01-31 08:15:00", "a", 1],
+		[],
+		[2, 10, false, "2/1/2024", "1/2/2024 09:00", "", "x"],
+		[3]
+	]`)
+
+	table, err := Build(values, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Column{
+		{"id", TypeBigint},
+		{"amount", TypeDouble},
+		{"active", TypeBoolean},
+		{"signup", TypeDate},
+		{"seen_at", TypeTimestamp},
+		{"note", TypeVarchar},
+		{"mixed", TypeVarchar},
+	}
+	if !reflect.DeepEqual(table.Columns, want) {
+		t.Fatalf("columns:\n got %+v\nwant %+v", table.Columns, want)
+	}
+	if len(table.Rows) != 3 {
+		t.Fatalf("expected 3 rows (blank row skipped), got %d", len(table.Rows))
+	}
+	if got, want := cellValues(table.Rows[1]), []any{"2", "10", "false", "2024-02-01", "2024-01-02 09:00:00", nil, "x"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("row 2:\n got %v\nwant %v", got, want)
+	}
+	if got := cellValues(table.Rows[2]); got[0] != "3" || got[1] != nil {
+		t.Fatalf("short row should be padded with NULLs, got %v", got)
+	}
+}
+
+func TestBuildHeader(t *testing.T) {
+	values := decode(t, `[
+		["Quarterly report"],
+		["Region", "Q1", "", "", "", "region", "Region"],
+		["EU", 1, 2, 3, 4, "x", "y", "extra"]
+	]`)
+
+	table, err := Build(values, Options{
+		HeaderRow:    2,
+		OriginRow:    0,
+		OriginColumn: 1, // range B1:...
+		Merges: []Merge{
+			{StartRow: 1, EndRow: 2, StartColumn: 2, EndColumn: 4}, // Q1 spans C2:D2
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, c := range table.Columns {
+		names = append(names, c.Name)
+	}
+	want := []string{"Region", "Q1", "Q1_2", "column_E", "column_F", "region_2", "Region_3", "column_I"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("names:\n got %v\nwant %v", names, want)
+	}
+	if len(table.Rows) != 1 {
+		t.Fatalf("expected 1 data row, got %d", len(table.Rows))
+	}
+}
+
+func TestBuildHeaderRowMissing(t *testing.T) {
+	if _, err := Build(decode(t, `[["a"]]`), Options{HeaderRow: 3}); err == nil {
+		t.Fatal("expected error for header row beyond data")
+	}
+}
+
+func TestColumnLetter(t *testing.T) {
+	for col, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := ColumnLetter(col); got != want {
+			t.Errorf("ColumnLetter(%d) = %q, want %q", col, got, want)
+		}
+	}
+}
+
+func TestRangeOrigin(t *testing.T) {
+	cases := []struct {
+		in       string
+		row, col int
+	}{
+		{"", 0, 0},
+		{"A1:Z100", 0, 0},
+		{"B3:H", 2, 1},
+		{"C:F", 0, 2},
+		{"5:9", 4, 0},
+		{"AA10", 9, 26},
+	}
+	for _, c := range cases {
+		row, col := RangeOrigin(c.in)
+		if row != c.row || col != c.col {
+			t.Errorf("RangeOrigin(%q) = (%d, %d), want (%d, %d)", c.in, row, col, c.row, c.col)
+		}
+	}
+}
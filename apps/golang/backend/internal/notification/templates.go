@@ -58,6 +58,34 @@ func RenderInvitation(inviterName, tenantName, role, token, acceptURL string) (s
 	return subject, html, text, nil
 }
 
+type connectionFailingData struct {
+	TenantName     string
+	ConnectionName string
+	Code           string
+	Message        string
+	Reconnect      bool
+}
+
+// RenderConnectionFailing renders the email sent when a connection health
+// check starts failing. reconnect adds a hint to re-authorize the account.
+func RenderConnectionFailing(tenantName, connectionName, code, message string, reconnect bool) (subject, html, text string, err error) {
+	var buf bytes.Buffer
+	data := connectionFailingData{
+		TenantName:     tenantName,
+		ConnectionName: connectionName,
+		Code:           code,
+		Message:        message,
+		Reconnect:      reconnect,
+	}
+	if err = tmpl.ExecuteTemplate(&buf, "connection_failing.html", data); err != nil {
+		return "", "", "", err
+	}
+	html = buf.String()
+	text = htmlToPlainText(html)
+	subject = "Connection \"" + connectionName + "\" is failing"
+	return subject, html, text, nil
+}
+
 func htmlToPlainText(s string) string {
 	s = strings.ReplaceAll(s, "<br>", "\n")
 	s = strings.ReplaceAll(s, "<br/>", "\n")
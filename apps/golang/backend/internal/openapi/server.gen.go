@@ -2624,6 +2624,14 @@ func (siw *ServerInterfaceWrapper) GetDatasetRows(w http.ResponseWriter, r *http
 		return
 	}
 
+	// ------------- Optional query parameter "page_token" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "page_token", r.URL.Query(), &params.PageToken)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "page_token", Err: err})
+		return
+	}
+
 	// ------------- Optional query parameter "offset" -------------
 
 	err = runtime.BindQueryParameter("form", true, false, "offset", r.URL.Query(), &params.Offset)
@@ -2632,6 +2640,30 @@ func (siw *ServerInterfaceWrapper) GetDatasetRows(w http.ResponseWriter, r *http
 		return
 	}
 
+	// ------------- Optional query parameter "columns" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "columns", r.URL.Query(), &params.Columns)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "columns", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "filter" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "filter", r.URL.Query(), &params.Filter)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "filter", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "sort" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "sort", r.URL.Query(), &params.Sort)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "sort", Err: err})
+		return
+	}
+
 	headers := r.Header
 
 	// ------------- Required header parameter "X-Tenant-ID" -------------
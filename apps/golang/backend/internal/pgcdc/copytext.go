@@ -0,0 +1,84 @@
+package pgcdc
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// ParseCopyText splits one row of COPY ... TO STDOUT text format (without
+// the trailing newline) into its fields; \N fields are returned as nil.
+func ParseCopyText(line []byte) ([]*string, error) {
+	var fields []*string
+	for _, raw := range bytes.Split(line, []byte{'\t'}) {
+		if bytes.Equal(raw, []byte(`\N`)) {
+			fields = append(fields, nil)
+			continue
+		}
+		s, err := unescapeCopy(raw)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, &s)
+	}
+	return fields, nil
+}
+
+func unescapeCopy(raw []byte) (string, error) {
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw), nil
+	}
+	out := make([]byte, 0, len(raw))
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if c != '\\' {
+			out = append(out, c)
+			continue
+		}
+		i++
+		if i == len(raw) {
+			return "", fmt.Errorf("copy field ends with a backslash")
+		}
+		switch c = raw[i]; c {
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'v':
+			out = append(out, '\v')
+		case 'x':
+			j := i + 1
+			for j < len(raw) && j < i+3 && isHex(raw[j]) {
+				j++
+			}
+			if j == i+1 {
+				out = append(out, 'x')
+				continue
+			}
+			n, _ := strconv.ParseUint(string(raw[i+1:j]), 16, 8)
+			out = append(out, byte(n))
+			i = j - 1
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(raw) && j < i+3 && raw[j] >= '0' && raw[j] <= '7' {
+				j++
+			}
+			n, _ := strconv.ParseUint(string(raw[i:j]), 8, 16)
+			out = append(out, byte(n))
+			i = j - 1
+		default:
+			out = append(out, c)
+		}
+	}
+	return string(out), nil
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
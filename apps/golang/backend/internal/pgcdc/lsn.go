@@ -0,0 +1,36 @@
+// Package pgcdc reads PostgreSQL logical replication streams. It decodes the
+// pgoutput plugin's messages into row changes, and wraps the replication
+// commands needed to create a slot, take an initial snapshot and stream
+// changes from a checkpointed LSN.
+package pgcdc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LSN is a PostgreSQL write-ahead log position.
+type LSN uint64
+
+// ParseLSN parses the textual form "XXX/XXX" used by PostgreSQL.
+func ParseLSN(s string) (LSN, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid lsn %q", s)
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lsn %q", s)
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lsn %q", s)
+	}
+	return LSN(h<<32 | l), nil
+}
+
+// String formats the LSN as "XXX/XXX".
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint32(l>>32), uint32(l))
+}
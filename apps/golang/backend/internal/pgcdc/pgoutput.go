@@ -0,0 +1,349 @@
+package pgcdc
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Change operations. OpSnapshot marks rows copied by the initial snapshot.
+const (
+	OpSnapshot = "r"
+	OpInsert   = "i"
+	OpUpdate   = "u"
+	OpDelete   = "d"
+	OpTruncate = "t"
+)
+
+// Column is a column of a replicated table.
+type Column struct {
+	Name    string
+	TypeOID uint32
+	TypeMod int32
+	Key     bool // part of the primary key (replica identity)
+}
+
+// Relation is a replicated table as described by a pgoutput Relation message.
+type Relation struct {
+	ID        uint32
+	Namespace string
+	Name      string
+	Columns   []Column
+}
+
+// QualifiedName returns "namespace.name".
+func (r *Relation) QualifiedName() string {
+	return r.Namespace + "." + r.Name
+}
+
+// Keys returns the names of the key columns.
+func (r *Relation) Keys() []string {
+	var keys []string
+	for _, c := range r.Columns {
+		if c.Key {
+			keys = append(keys, c.Name)
+		}
+	}
+	return keys
+}
+
+// Change is a committed row change.
+type Change struct {
+	Relation *Relation
+	Op       string
+	// LSN is the end of the transaction that made the change; it is the
+	// position to checkpoint once the change is stored.
+	LSN LSN
+	// Values holds the new row (the key columns only for deletes), in text
+	// format; a nil value is NULL. Truncates carry no values.
+	Values map[string]*string
+	// Unchanged lists TOASTed columns whose value was not sent because the
+	// update did not modify them; the previous value must be kept.
+	Unchanged []string
+}
+
+// Decoder turns pgoutput (protocol version 1) messages into changes. Changes
+// are buffered per transaction and released on commit, so a stream that is
+// cut mid-transaction never yields a partial transaction.
+type Decoder struct {
+	// After skips transactions that end at or before this LSN, which were
+	// already stored by an earlier run.
+	After LSN
+
+	relations map[uint32]*Relation
+	inTx      bool
+	pending   []Change
+}
+
+// NewDecoder returns a Decoder that skips transactions ending at or before
+// after.
+func NewDecoder(after LSN) *Decoder {
+	return &Decoder{After: after, relations: make(map[uint32]*Relation)}
+}
+
+// InTransaction reports whether a transaction has begun but not committed.
+func (d *Decoder) InTransaction() bool {
+	return d.inTx
+}
+
+// Decode processes one pgoutput message. When the message commits a
+// transaction, its changes and end LSN are returned.
+func (d *Decoder) Decode(msg []byte) ([]Change, LSN, error) {
+	if len(msg) == 0 {
+		return nil, 0, fmt.Errorf("empty pgoutput message")
+	}
+	r := &reader{buf: msg[1:]}
+	switch msg[0] {
+	case 'B':
+		d.inTx = true
+		d.pending = d.pending[:0]
+		return nil, 0, nil
+	case 'C':
+		r.uint8()  // flags
+		r.uint64() // commit LSN
+		end := LSN(r.uint64())
+		if r.err != nil {
+			return nil, 0, fmt.Errorf("decode commit: %w", r.err)
+		}
+		d.inTx = false
+		if end <= d.After || len(d.pending) == 0 {
+			d.pending = d.pending[:0]
+			return nil, end, nil
+		}
+		changes := make([]Change, len(d.pending))
+		for i, c := range d.pending {
+			c.LSN = end
+			changes[i] = c
+		}
+		d.pending = d.pending[:0]
+		return changes, end, nil
+	case 'R':
+		rel := &Relation{ID: r.uint32(), Namespace: r.string(), Name: r.string()}
+		r.uint8() // replica identity setting
+		n := int(r.uint16())
+		for i := 0; i < n && r.err == nil; i++ {
+			flags := r.uint8()
+			rel.Columns = append(rel.Columns, Column{
+				Name:    r.string(),
+				TypeOID: r.uint32(),
+				TypeMod: int32(r.uint32()),
+				Key:     flags&1 == 1,
+			})
+		}
+		if r.err != nil {
+			return nil, 0, fmt.Errorf("decode relation: %w", r.err)
+		}
+		d.relations[rel.ID] = rel
+		return nil, 0, nil
+	case 'I':
+		rel, err := d.relation(r)
+		if err != nil {
+			return nil, 0, err
+		}
+		if r.uint8() != 'N' {
+			return nil, 0, fmt.Errorf("decode insert: expected new tuple")
+		}
+		values, unchanged, err := r.tuple(rel)
+		if err != nil {
+			return nil, 0, fmt.Errorf("decode insert: %w", err)
+		}
+		d.pending = append(d.pending, Change{Relation: rel, Op: OpInsert, Values: values, Unchanged: unchanged})
+		return nil, 0, nil
+	case 'U':
+		rel, err := d.relation(r)
+		if err != nil {
+			return nil, 0, err
+		}
+		var old map[string]*string
+		oldKind := r.uint8()
+		kind := oldKind
+		if kind == 'K' || kind == 'O' {
+			if old, _, err = r.tuple(rel); err != nil {
+				return nil, 0, fmt.Errorf("decode update: %w", err)
+			}
+			kind = r.uint8()
+		}
+		if kind != 'N' {
+			return nil, 0, fmt.Errorf("decode update: expected new tuple")
+		}
+		values, unchanged, err := r.tuple(rel)
+		if err != nil {
+			return nil, 0, fmt.Errorf("decode update: %w", err)
+		}
+		if oldKind == 'O' {
+			// A full old row (REPLICA IDENTITY FULL) supplies the TOASTed
+			// values that were not resent.
+			for _, name := range unchanged {
+				values[name] = old[name]
+			}
+			unchanged = nil
+		}
+		if old != nil {
+			// The key changed: the row under the old key is gone.
+			if keyChanged(rel, old, values) {
+				d.pending = append(d.pending, Change{Relation: rel, Op: OpDelete, Values: keyValues(rel, old)})
+			}
+		}
+		d.pending = append(d.pending, Change{Relation: rel, Op: OpUpdate, Values: values, Unchanged: unchanged})
+		return nil, 0, nil
+	case 'D':
+		rel, err := d.relation(r)
+		if err != nil {
+			return nil, 0, err
+		}
+		if kind := r.uint8(); kind != 'K' && kind != 'O' {
+			return nil, 0, fmt.Errorf("decode delete: expected old tuple")
+		}
+		old, _, err := r.tuple(rel)
+		if err != nil {
+			return nil, 0, fmt.Errorf("decode delete: %w", err)
+		}
+		d.pending = append(d.pending, Change{Relation: rel, Op: OpDelete, Values: keyValues(rel, old)})
+		return nil, 0, nil
+	case 'T':
+		n := int(r.uint32())
+		r.uint8() // options
+		for i := 0; i < n && r.err == nil; i++ {
+			id := r.uint32()
+			rel, ok := d.relations[id]
+			if !ok {
+				return nil, 0, fmt.Errorf("decode truncate: unknown relation %d", id)
+			}
+			d.pending = append(d.pending, Change{Relation: rel, Op: OpTruncate})
+		}
+		if r.err != nil {
+			return nil, 0, fmt.Errorf("decode truncate: %w", r.err)
+		}
+		return nil, 0, nil
+	default:
+		// Origin, Type and logical decoding messages carry no row changes.
+		return nil, 0, nil
+	}
+}
+
+func (d *Decoder) relation(r *reader) (*Relation, error) {
+	id := r.uint32()
+	if r.err != nil {
+		return nil, fmt.Errorf("decode relation id: %w", r.err)
+	}
+	rel, ok := d.relations[id]
+	if !ok {
+		return nil, fmt.Errorf("change for unknown relation %d", id)
+	}
+	return rel, nil
+}
+
+func keyChanged(rel *Relation, old, values map[string]*string) bool {
+	for _, c := range rel.Columns {
+		if !c.Key {
+			continue
+		}
+		a, b := old[c.Name], values[c.Name]
+		if (a == nil) != (b == nil) || (a != nil && *a != *b) {
+			return true
+		}
+	}
+	return false
+}
+
+func keyValues(rel *Relation, values map[string]*string) map[string]*string {
+	keys := make(map[string]*string)
+	for _, c := range rel.Columns {
+		if c.Key {
+			keys[c.Name] = values[c.Name]
+		}
+	}
+	return keys
+}
+
+// reader decodes the big-endian fields of a pgoutput message. The first
+// error sticks and later reads return zero values.
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.buf) < n {
+		r.err = fmt.Errorf("message truncated")
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) uint8() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) uint64() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *reader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	for i, c := range r.buf {
+		if c == 0 {
+			s := string(r.buf[:i])
+			r.buf = r.buf[i+1:]
+			return s
+		}
+	}
+	r.err = fmt.Errorf("unterminated string")
+	return ""
+}
+
+// tuple decodes TupleData into values keyed by column name, and the names of
+// unchanged TOASTed columns.
+func (r *reader) tuple(rel *Relation) (map[string]*string, []string, error) {
+	n := int(r.uint16())
+	if r.err == nil && n > len(rel.Columns) {
+		return nil, nil, fmt.Errorf("tuple has %d columns, relation %s has %d", n, rel.QualifiedName(), len(rel.Columns))
+	}
+	values := make(map[string]*string, n)
+	var unchanged []string
+	for i := 0; i < n && r.err == nil; i++ {
+		name := rel.Columns[i].Name
+		kind := r.uint8()
+		if r.err != nil {
+			break
+		}
+		switch kind {
+		case 'n':
+			values[name] = nil
+		case 'u':
+			unchanged = append(unchanged, name)
+		case 't':
+			s := string(r.next(int(r.uint32())))
+			values[name] = &s
+		default:
+			return nil, nil, fmt.Errorf("unsupported tuple value kind %q", kind)
+		}
+	}
+	return values, unchanged, r.err
+}
@@ -0,0 +1,179 @@
+package pgcdc
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// msg builds a pgoutput message from its type byte and fields.
+type msg []byte
+
+func newMsg(typ byte) msg { return msg{typ} }
+
+func (m msg) u8(v byte) msg    { return append(m, v) }
+func (m msg) u16(v uint16) msg { return binary.BigEndian.AppendUint16(m, v) }
+func (m msg) u32(v uint32) msg { return binary.BigEndian.AppendUint32(m, v) }
+func (m msg) u64(v uint64) msg { return binary.BigEndian.AppendUint64(m, v) }
+func (m msg) str(s string) msg { return append(append(m, s...), 0) }
+
+// tuple appends TupleData; "\x00n" is NULL and "\x00u" unchanged TOAST.
+func (m msg) tuple(values ...string) msg {
+	m = m.u16(uint16(len(values)))
+	for _, v := range values {
+		switch v {
+		case "\x00n":
+			m = m.u8('n')
+		case "\x00u":
+			m = m.u8('u')
+		default:
+			m = m.u8('t').u32(uint32(len(v)))
+			m = append(m, v...)
+		}
+	}
+	return m
+}
+
+func relationMsg() msg {
+	return newMsg('R').u32(42).str("public").str("orders").u8('d').u16(3).
+		u8(1).str("id").u32(oidInt8).u32(0xffffffff).
+		u8(0).str("status").u32(25).u32(0xffffffff).
+		u8(0).str("note").u32(25).u32(0xffffffff)
+}
+
+func commitMsg(end uint64) msg {
+	return newMsg('C').u8(0).u64(end - 8).u64(end).u64(0)
+}
+
+func decodeAll(t *testing.T, d *Decoder, msgs ...msg) ([]Change, LSN) {
+	t.Helper()
+	var all []Change
+	var last LSN
+	for _, m := range msgs {
+		changes, end, err := d.Decode(m)
+		if err != nil {
+			t.Fatalf("decode %q: %v", m[0], err)
+		}
+		all = append(all, changes...)
+		if end != 0 {
+			last = end
+		}
+	}
+	return all, last
+}
+
+func str(s string) *string { return &s }
+
+func TestDecoder(t *testing.T) {
+	d := NewDecoder(0)
+	changes, end := decodeAll(t, d,
+		relationMsg(),
+		newMsg('B').u64(0x100).u64(0).u32(7),
+		newMsg('I').u32(42).u8('N').tuple("1", "new", "\x00n"),
+		newMsg('U').u32(42).u8('N').tuple("1", "paid", "\x00u"),
+		newMsg('U').u32(42).u8('K').tuple("1", "\x00n", "\x00n").u8('N').tuple("2", "paid", "\x00u"),
+		newMsg('D').u32(42).u8('K').tuple("2", "\x00n", "\x00n"),
+		newMsg('T').u32(1).u8(0).u32(42),
+		commitMsg(0x110),
+	)
+	if end != 0x110 {
+		t.Fatalf("end = %s", end)
+	}
+
+	type got struct {
+		Op        string
+		Values    map[string]*string
+		Unchanged []string
+	}
+	want := []got{
+		{OpInsert, map[string]*string{"id": str("1"), "status": str("new"), "note": nil}, nil},
+		{OpUpdate, map[string]*string{"id": str("1"), "status": str("paid")}, []string{"note"}},
+		{OpDelete, map[string]*string{"id": str("1")}, nil},
+		{OpUpdate, map[string]*string{"id": str("2"), "status": str("paid")}, []string{"note"}},
+		{OpDelete, map[string]*string{"id": str("2")}, nil},
+		{OpTruncate, nil, nil},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, want %d", len(changes), len(want))
+	}
+	for i, c := range changes {
+		if c.LSN != 0x110 || c.Relation.QualifiedName() != "public.orders" {
+			t.Errorf("change %d: lsn=%s relation=%s", i, c.LSN, c.Relation.QualifiedName())
+		}
+		if g := (got{c.Op, c.Values, c.Unchanged}); !reflect.DeepEqual(g, want[i]) {
+			t.Errorf("change %d:\n got %+v\nwant %+v", i, g, want[i])
+		}
+	}
+	if keys := changes[0].Relation.Keys(); !reflect.DeepEqual(keys, []string{"id"}) {
+		t.Errorf("keys = %v", keys)
+	}
+}
+
+func TestDecoderFullOldTupleFillsUnchanged(t *testing.T) {
+	d := NewDecoder(0)
+	changes, _ := decodeAll(t, d,
+		relationMsg(),
+		newMsg('B').u64(0x100).u64(0).u32(7),
+		newMsg('U').u32(42).u8('O').tuple("1", "new", "long text").u8('N').tuple("1", "paid", "\x00u"),
+		commitMsg(0x110),
+	)
+	if len(changes) != 1 {
+		t.Fatalf("got %d changes, want 1", len(changes))
+	}
+	c := changes[0]
+	if c.Op != OpUpdate || len(c.Unchanged) != 0 || c.Values["note"] == nil || *c.Values["note"] != "long text" {
+		t.Fatalf("unexpected change %+v", c)
+	}
+}
+
+func TestDecoderBuffersAndSkips(t *testing.T) {
+	d := NewDecoder(0x110)
+	changes, _ := decodeAll(t, d,
+		relationMsg(),
+		newMsg('B').u64(0x100).u64(0).u32(7),
+		newMsg('I').u32(42).u8('N').tuple("1", "new", "\x00n"),
+	)
+	if len(changes) != 0 || !d.InTransaction() {
+		t.Fatal("changes must be held until commit")
+	}
+	if changes, _ = decodeAll(t, d, commitMsg(0x110)); len(changes) != 0 {
+		t.Fatal("transaction at the checkpoint must be skipped")
+	}
+	changes, _ = decodeAll(t, d,
+		newMsg('B').u64(0x120).u64(0).u32(8),
+		newMsg('I').u32(42).u8('N').tuple("2", "new", "\x00n"),
+		commitMsg(0x128),
+	)
+	if len(changes) != 1 || d.InTransaction() {
+		t.Fatalf("expected 1 change after the checkpoint, got %d", len(changes))
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	d := NewDecoder(0)
+	if _, _, err := d.Decode(newMsg('I').u32(1).u8('N').tuple("1")); err == nil {
+		t.Error("expected error for unknown relation")
+	}
+	if _, _, err := d.Decode(relationMsg()[:10]); err == nil {
+		t.Error("expected error for truncated relation")
+	}
+	decodeAll(t, d, relationMsg())
+	if _, _, err := d.Decode(newMsg('I').u32(42).u8('N').tuple("1", "a", "b", "c")); err == nil {
+		t.Error("expected error for too many columns")
+	}
+}
+
+func TestLSN(t *testing.T) {
+	lsn, err := ParseLSN("16/B374D848")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lsn != 0x16B374D848 || lsn.String() != "16/B374D848" {
+		t.Fatalf("got %d %s", uint64(lsn), lsn)
+	}
+	for _, bad := range []string{"", "16", "x/1", "1/100000000"} {
+		if _, err := ParseLSN(bad); err == nil {
+			t.Errorf("ParseLSN(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package pgcdc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+)
+
+// Config holds the settings for connecting to the source database.
+type Config struct {
+	Host     string
+	Port     int
+	Database string
+	Username string
+	Password string
+	SSLMode  string // disable, require, verify-ca or verify-full; default disable
+}
+
+func (c Config) connString(replication bool) string {
+	port := c.Port
+	if port == 0 {
+		port = 5432
+	}
+	sslMode := c.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	q := url.Values{"sslmode": {sslMode}, "application_name": {"micro-dp-cdc"}}
+	if replication {
+		q.Set("replication", "database")
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.Username, c.Password),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(port)),
+		Path:     "/" + c.Database,
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
+
+var namePattern = regexp.MustCompile(`^[a-z0-9_]{1,63}$`)
+
+// ValidName reports whether name can be used as a replication slot or
+// publication name: lower-case letters, digits and underscores, at most 63
+// characters.
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// Table is a schema-qualified table name.
+type Table struct {
+	Schema string
+	Name   string
+}
+
+// ParseTable parses "schema.table" or "table" (in schema public).
+func ParseTable(s string) (Table, error) {
+	schema, name, ok := strings.Cut(strings.TrimSpace(s), ".")
+	if !ok {
+		schema, name = "public", schema
+	}
+	if schema == "" || name == "" || strings.Contains(name, ".") {
+		return Table{}, fmt.Errorf("invalid table name %q", s)
+	}
+	return Table{Schema: schema, Name: name}, nil
+}
+
+// String returns "schema.table".
+func (t Table) String() string {
+	return t.Schema + "." + t.Name
+}
+
+// quoted returns the table as a quoted SQL identifier.
+func (t Table) quoted() string {
+	return quoteIdent(t.Schema) + "." + quoteIdent(t.Name)
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func quoteLiteral(s string) string {
+	return `'` + strings.ReplaceAll(s, `'`, `''`) + `'`
+}
+
+// ReplicationConn is a connection in logical replication mode.
+type ReplicationConn struct {
+	pg *pgconn.PgConn
+}
+
+// DialReplication opens a replication connection to cfg's database.
+func DialReplication(ctx context.Context, cfg Config) (*ReplicationConn, error) {
+	pg, err := pgconn.Connect(ctx, cfg.connString(true))
+	if err != nil {
+		return nil, err
+	}
+	return &ReplicationConn{pg: pg}, nil
+}
+
+// Close closes the connection.
+func (c *ReplicationConn) Close(ctx context.Context) error {
+	return c.pg.Close(ctx)
+}
+
+// query runs a simple query and returns the rows of its last result.
+func (c *ReplicationConn) query(ctx context.Context, sql string) ([][][]byte, error) {
+	results, err := c.pg.Exec(ctx, sql).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return results[len(results)-1].Rows, nil
+}
+
+// IdentifySystem returns the server's current WAL flush position.
+func (c *ReplicationConn) IdentifySystem(ctx context.Context) (LSN, error) {
+	rows, err := c.query(ctx, "IDENTIFY_SYSTEM")
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) != 1 || len(rows[0]) < 3 {
+		return 0, fmt.Errorf("unexpected IDENTIFY_SYSTEM result")
+	}
+	return ParseLSN(string(rows[0][2]))
+}
+
+// SlotPosition returns the confirmed flush position of a pgoutput slot in
+// the current database, and false when the slot does not exist.
+func (c *ReplicationConn) SlotPosition(ctx context.Context, slot string) (LSN, bool, error) {
+	rows, err := c.query(ctx, fmt.Sprintf(
+		"SELECT plugin, confirmed_flush_lsn FROM pg_replication_slots WHERE slot_name = %s AND database = current_database()",
+		quoteLiteral(slot)))
+	if err != nil {
+		return 0, false, err
+	}
+	if len(rows) == 0 {
+		return 0, false, nil
+	}
+	if plugin := string(rows[0][0]); plugin != "pgoutput" {
+		return 0, false, fmt.Errorf("replication slot %s uses plugin %s, not pgoutput", slot, plugin)
+	}
+	if rows[0][1] == nil {
+		return 0, true, nil
+	}
+	lsn, err := ParseLSN(string(rows[0][1]))
+	return lsn, true, err
+}
+
+// CreateSlot creates a pgoutput slot and exports a snapshot of the database
+// as of the slot's consistent point. The snapshot can be imported by other
+// sessions until the next command is run on this connection.
+func (c *ReplicationConn) CreateSlot(ctx context.Context, slot string) (LSN, string, error) {
+	rows, err := c.query(ctx, fmt.Sprintf("CREATE_REPLICATION_SLOT %s LOGICAL pgoutput EXPORT_SNAPSHOT", quoteIdent(slot)))
+	if err != nil {
+		return 0, "", err
+	}
+	if len(rows) != 1 || len(rows[0]) < 3 {
+		return 0, "", fmt.Errorf("unexpected CREATE_REPLICATION_SLOT result")
+	}
+	lsn, err := ParseLSN(string(rows[0][1]))
+	if err != nil {
+		return 0, "", err
+	}
+	return lsn, string(rows[0][2]), nil
+}
+
+// DropSlot drops a replication slot.
+func (c *ReplicationConn) DropSlot(ctx context.Context, slot string) error {
+	_, err := c.query(ctx, "DROP_REPLICATION_SLOT "+quoteIdent(slot))
+	return err
+}
+
+// EnsurePublication creates the publication for tables unless it already
+// exists, and reports whether it did. An existing publication is used as is.
+func (c *ReplicationConn) EnsurePublication(ctx context.Context, publication string, tables []Table) (bool, error) {
+	rows, err := c.query(ctx, "SELECT 1 FROM pg_publication WHERE pubname = "+quoteLiteral(publication))
+	if err != nil {
+		return false, err
+	}
+	if len(rows) > 0 {
+		return false, nil
+	}
+	names := make([]string, len(tables))
+	for i, t := range tables {
+		names[i] = t.quoted()
+	}
+	if _, err := c.query(ctx, fmt.Sprintf("CREATE PUBLICATION %s FOR TABLE %s", quoteIdent(publication), strings.Join(names, ", "))); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// StartReplication starts streaming the slot's changes for publication from
+// start (or from the slot's confirmed position, whichever is later).
+func (c *ReplicationConn) StartReplication(ctx context.Context, slot, publication string, start LSN) error {
+	sql := fmt.Sprintf("START_REPLICATION SLOT %s LOGICAL %s (proto_version '1', publication_names %s)",
+		quoteIdent(slot), start, quoteLiteral(quoteIdent(publication)))
+	c.pg.Frontend().SendQuery(&pgproto3.Query{String: sql})
+	if err := c.pg.Frontend().Flush(); err != nil {
+		return err
+	}
+	for {
+		msg, err := c.pg.ReceiveMessage(ctx)
+		if err != nil {
+			return err
+		}
+		switch msg := msg.(type) {
+		case *pgproto3.CopyBothResponse:
+			return nil
+		case *pgproto3.ErrorResponse:
+			return pgconn.ErrorResponseToPgError(msg)
+		case *pgproto3.NoticeResponse, *pgproto3.ParameterStatus:
+		default:
+			return fmt.Errorf("unexpected response to START_REPLICATION: %T", msg)
+		}
+	}
+}
+
+// XLogData carries a pgoutput message.
+type XLogData struct {
+	WALStart     LSN
+	ServerWALEnd LSN
+	Data         []byte
+}
+
+// Keepalive is a primary keepalive message. ServerWALEnd is the position up
+// to which the server has sent changes.
+type Keepalive struct {
+	ServerWALEnd   LSN
+	ReplyRequested bool
+}
+
+// Receive waits for the next replication message: an *XLogData or a
+// *Keepalive. It returns io.EOF when the server ends the stream. A timeout
+// from ctx (see pgconn.Timeout) leaves the connection usable.
+func (c *ReplicationConn) Receive(ctx context.Context) (any, error) {
+	for {
+		msg, err := c.pg.ReceiveMessage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		switch msg := msg.(type) {
+		case *pgproto3.CopyData:
+			return ParseCopyData(msg.Data)
+		case *pgproto3.CopyDone:
+			return nil, io.EOF
+		case *pgproto3.ErrorResponse:
+			return nil, pgconn.ErrorResponseToPgError(msg)
+		case *pgproto3.NoticeResponse, *pgproto3.ParameterStatus:
+		default:
+			return nil, fmt.Errorf("unexpected replication message: %T", msg)
+		}
+	}
+}
+
+// SendStatus reports the positions written and flushed by the client. The
+// server may discard WAL up to flushed.
+func (c *ReplicationConn) SendStatus(written, flushed LSN) error {
+	data, err := (&pgproto3.CopyData{Data: EncodeStandbyStatus(written, flushed, time.Now())}).Encode(nil)
+	if err != nil {
+		return err
+	}
+	return c.pg.Frontend().SendUnbufferedEncodedCopyData(data)
+}
+
+// pgEpoch is the origin of replication protocol timestamps.
+var pgEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ParseCopyData decodes the payload of a CopyData message sent during
+// replication.
+func ParseCopyData(data []byte) (any, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty replication message")
+	}
+	r := &reader{buf: data[1:]}
+	switch data[0] {
+	case 'w':
+		x := &XLogData{WALStart: LSN(r.uint64()), ServerWALEnd: LSN(r.uint64())}
+		r.uint64() // server clock
+		if r.err != nil {
+			return nil, fmt.Errorf("decode xlogdata: %w", r.err)
+		}
+		x.Data = append([]byte(nil), r.buf...)
+		return x, nil
+	case 'k':
+		k := &Keepalive{ServerWALEnd: LSN(r.uint64())}
+		r.uint64() // server clock
+		k.ReplyRequested = r.uint8() == 1
+		if r.err != nil {
+			return nil, fmt.Errorf("decode keepalive: %w", r.err)
+		}
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unknown replication message %q", data[0])
+	}
+}
+
+// EncodeStandbyStatus encodes a standby status update. Applied is reported
+// equal to flushed.
+func EncodeStandbyStatus(written, flushed LSN, now time.Time) []byte {
+	buf := make([]byte, 34)
+	buf[0] = 'r'
+	binary.BigEndian.PutUint64(buf[1:], uint64(written))
+	binary.BigEndian.PutUint64(buf[9:], uint64(flushed))
+	binary.BigEndian.PutUint64(buf[17:], uint64(flushed))
+	binary.BigEndian.PutUint64(buf[25:], uint64(now.Sub(pgEpoch).Microseconds()))
+	return buf
+}
+
+// AdvanceSlot moves the slot's confirmed position to lsn, allowing the
+// server to discard the WAL before it. The slot must not be in use; a slot
+// that is still being released by a just-closed stream is retried briefly.
+func AdvanceSlot(ctx context.Context, cfg Config, slot string, lsn LSN) error {
+	pg, err := pgconn.Connect(ctx, cfg.connString(false))
+	if err != nil {
+		return err
+	}
+	defer pg.Close(context.Background())
+
+	sql := fmt.Sprintf("SELECT pg_replication_slot_advance(%s, %s)", quoteLiteral(slot), quoteLiteral(lsn.String()))
+	for attempt := 0; ; attempt++ {
+		_, err = pg.Exec(ctx, sql).ReadAll()
+		if err == nil || attempt == 10 || !strings.Contains(err.Error(), "is active") {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// Snapshot reads tables as of a snapshot exported by CreateSlot.
+type Snapshot struct {
+	pg *pgconn.PgConn
+}
+
+// OpenSnapshot opens a read-only transaction on the exported snapshot.
+func OpenSnapshot(ctx context.Context, cfg Config, snapshotName string) (*Snapshot, error) {
+	pg, err := pgconn.Connect(ctx, cfg.connString(false))
+	if err != nil {
+		return nil, err
+	}
+	_, err = pg.Exec(ctx, "BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY; SET TRANSACTION SNAPSHOT "+quoteLiteral(snapshotName)).ReadAll()
+	if err != nil {
+		pg.Close(context.Background())
+		return nil, fmt.Errorf("import snapshot: %w", err)
+	}
+	return &Snapshot{pg: pg}, nil
+}
+
+// Close ends the snapshot transaction.
+func (s *Snapshot) Close(ctx context.Context) error {
+	return s.pg.Close(ctx)
+}
+
+// Describe returns the table's columns with its primary key columns marked.
+func (s *Snapshot) Describe(ctx context.Context, t Table) (*Relation, error) {
+	result := s.pg.ExecParams(ctx,
+		`SELECT a.attname, a.atttypid, a.atttypmod, COALESCE(a.attnum = ANY(i.indkey), false)
+		 FROM pg_attribute a
+		 LEFT JOIN pg_index i ON i.indrelid = a.attrelid AND i.indisprimary
+		 WHERE a.attrelid = $1::regclass AND a.attnum > 0 AND NOT a.attisdropped
+		 ORDER BY a.attnum`,
+		[][]byte{[]byte(t.quoted())}, nil, nil, nil,
+	).Read()
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	rel := &Relation{Namespace: t.Schema, Name: t.Name}
+	for _, row := range result.Rows {
+		oid, _ := strconv.ParseUint(string(row[1]), 10, 32)
+		mod, _ := strconv.ParseInt(string(row[2]), 10, 32)
+		rel.Columns = append(rel.Columns, Column{
+			Name:    string(row[0]),
+			TypeOID: uint32(oid),
+			TypeMod: int32(mod),
+			Key:     string(row[3]) == "t",
+		})
+	}
+	if len(rel.Columns) == 0 {
+		return nil, fmt.Errorf("table %s has no columns", t)
+	}
+	return rel, nil
+}
+
+// Copy streams every row of the table to fn, with values in rel's column
+// order.
+func (s *Snapshot) Copy(ctx context.Context, rel *Relation, fn func(values []*string) error) error {
+	names := make([]string, len(rel.Columns))
+	for i, c := range rel.Columns {
+		names[i] = quoteIdent(c.Name)
+	}
+	sql := fmt.Sprintf("COPY %s (%s) TO STDOUT",
+		Table{Schema: rel.Namespace, Name: rel.Name}.quoted(), strings.Join(names, ", "))
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.pg.CopyTo(ctx, pw, sql)
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	br := bufio.NewReaderSize(pr, 1<<20)
+	var readErr error
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			values, perr := ParseCopyText(bytes.TrimSuffix(line, []byte{'\n'}))
+			if perr == nil && len(values) != len(rel.Columns) {
+				perr = fmt.Errorf("copy row has %d fields, expected %d", len(values), len(rel.Columns))
+			}
+			if perr == nil {
+				perr = fn(values)
+			}
+			if perr != nil {
+				readErr = perr
+				break
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				readErr = err
+			}
+			break
+		}
+	}
+	pr.CloseWithError(io.ErrClosedPipe)
+	copyErr := <-done
+	if readErr != nil {
+		return readErr
+	}
+	return copyErr
+}
@@ -0,0 +1,96 @@
+package pgcdc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCopyText(t *testing.T) {
+	fields, err := ParseCopyText([]byte(`1	a\tb\nc	\N		back\\slash\x41\101`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []any
+	for _, f := range fields {
+		if f == nil {
+			got = append(got, nil)
+		} else {
+			got = append(got, *f)
+		}
+	}
+	want := []any{"1", "a\tb\nc", nil, "", `back\slashAA`}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if _, err := ParseCopyText([]byte(`bad\`)); err == nil {
+		t.Fatal("expected error for trailing backslash")
+	}
+}
+
+func TestDuckDBType(t *testing.T) {
+	cases := []struct {
+		col  Column
+		want string
+	}{
+		{Column{TypeOID: oidInt8}, "BIGINT"},
+		{Column{TypeOID: oidBool}, "BOOLEAN"},
+		{Column{TypeOID: oidTimestamptz}, "TIMESTAMPTZ"},
+		{Column{TypeOID: oidNumeric, TypeMod: -1}, "DOUBLE"},
+		{Column{TypeOID: oidNumeric, TypeMod: (10<<16 | 2) + 4}, "DECIMAL(10,2)"},
+		{Column{TypeOID: 3802}, "VARCHAR"}, // jsonb
+	}
+	for _, c := range cases {
+		if got := DuckDBType(c.col); got != c.want {
+			t.Errorf("DuckDBType(%d, %d) = %s, want %s", c.col.TypeOID, c.col.TypeMod, got, c.want)
+		}
+	}
+}
+
+func TestParseCopyData(t *testing.T) {
+	m, err := ParseCopyData(newMsg('w').u64(0x10).u64(0x20).u64(0).u8('B'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, ok := m.(*XLogData)
+	if !ok || x.WALStart != 0x10 || x.ServerWALEnd != 0x20 || string(x.Data) != "B" {
+		t.Fatalf("unexpected xlogdata %+v", m)
+	}
+
+	m, err = ParseCopyData(newMsg('k').u64(0x30).u64(0).u8(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k, ok := m.(*Keepalive); !ok || k.ServerWALEnd != 0x30 || !k.ReplyRequested {
+		t.Fatalf("unexpected keepalive %+v", m)
+	}
+
+	if _, err := ParseCopyData(newMsg('k').u64(1)); err == nil {
+		t.Fatal("expected error for truncated keepalive")
+	}
+}
+
+func TestEncodeStandbyStatus(t *testing.T) {
+	buf := EncodeStandbyStatus(0x20, 0x10, pgEpoch.Add(time.Second))
+	want := newMsg('r').u64(0x20).u64(0x10).u64(0x10).u64(1_000_000).u8(0)
+	if !reflect.DeepEqual([]byte(want), buf) {
+		t.Fatalf("got %x, want %x", buf, want)
+	}
+}
+
+func TestParseTable(t *testing.T) {
+	for in, want := range map[string]Table{
+		"orders":         {"public", "orders"},
+		"sales.orders":   {"sales", "orders"},
+		" sales.Orders ": {"sales", "Orders"},
+	} {
+		if got, err := ParseTable(in); err != nil || got != want {
+			t.Errorf("ParseTable(%q) = %v, %v", in, got, err)
+		}
+	}
+	for _, bad := range []string{"", ".orders", "a.b.c"} {
+		if _, err := ParseTable(bad); err == nil {
+			t.Errorf("ParseTable(%q) should fail", bad)
+		}
+	}
+}
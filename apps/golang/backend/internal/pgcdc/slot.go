@@ -0,0 +1,105 @@
+package pgcdc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DefaultName is the slot and publication name of a job that sets none.
+func DefaultName(jobID string) string {
+	return "micro_dp_" + strings.ReplaceAll(strings.ToLower(jobID), "-", "_")
+}
+
+// ConfigFromConnection reads a source-postgres connection config.
+func ConfigFromConnection(cfg map[string]any) (*Config, error) {
+	source := &Config{}
+	source.Host, _ = cfg["host"].(string)
+	source.Database, _ = cfg["database"].(string)
+	source.Username, _ = cfg["username"].(string)
+	source.Password, _ = cfg["password"].(string)
+	source.SSLMode, _ = cfg["ssl_mode"].(string)
+	if port, ok := cfg["port"].(float64); ok {
+		source.Port = int(port)
+	}
+	if source.Host == "" || source.Database == "" || source.Username == "" {
+		return nil, fmt.Errorf("connection config missing host, database or username")
+	}
+	return source, nil
+}
+
+// Release drops slot, so that the source stops retaining WAL for it, and
+// the publication when dropPublication. A slot or publication that no
+// longer exists is not an error; a slot still in use by a run that is
+// shutting down is retried briefly.
+func Release(ctx context.Context, cfg Config, slot, publication string, dropPublication bool) error {
+	pg, err := pgconn.Connect(ctx, cfg.connString(false))
+	if err != nil {
+		return err
+	}
+	defer pg.Close(context.Background())
+
+	sql := fmt.Sprintf(
+		"SELECT pg_drop_replication_slot(slot_name) FROM pg_replication_slots WHERE slot_name = %s AND database = current_database()",
+		quoteLiteral(slot))
+	for attempt := 0; ; attempt++ {
+		_, err = pg.Exec(ctx, sql).ReadAll()
+		if err == nil || attempt == 10 || !strings.Contains(err.Error(), "is active") {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("drop slot %s: %w", slot, err)
+	}
+	if dropPublication && publication != "" {
+		if _, err := pg.Exec(ctx, "DROP PUBLICATION IF EXISTS "+quoteIdent(publication)).ReadAll(); err != nil {
+			return fmt.Errorf("drop publication %s: %w", publication, err)
+		}
+	}
+	return nil
+}
+
+// SlotInfo describes a logical replication slot of the source database.
+type SlotInfo struct {
+	Name   string
+	Active bool
+	// RetainedBytes is the WAL the server keeps for the slot.
+	RetainedBytes int64
+}
+
+// Slots lists the pgoutput slots of cfg's database with the WAL each one
+// retains.
+func Slots(ctx context.Context, cfg Config) ([]SlotInfo, error) {
+	pg, err := pgconn.Connect(ctx, cfg.connString(false))
+	if err != nil {
+		return nil, err
+	}
+	defer pg.Close(context.Background())
+
+	results, err := pg.Exec(ctx, `SELECT slot_name, active,
+		COALESCE(pg_wal_lsn_diff(pg_current_wal_lsn(), restart_lsn), 0)::bigint
+		FROM pg_replication_slots
+		WHERE plugin = 'pgoutput' AND database = current_database()
+		ORDER BY slot_name`).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var slots []SlotInfo
+	for _, row := range results[len(results)-1].Rows {
+		retained, err := strconv.ParseInt(string(row[2]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("slot %s: %w", row[0], err)
+		}
+		slots = append(slots, SlotInfo{Name: string(row[0]), Active: string(row[1]) == "t", RetainedBytes: retained})
+	}
+	return slots, nil
+}
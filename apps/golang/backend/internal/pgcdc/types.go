@@ -0,0 +1,61 @@
+package pgcdc
+
+import "fmt"
+
+// PostgreSQL type OIDs with a DuckDB counterpart.
+const (
+	oidBool        = 16
+	oidInt8        = 20
+	oidInt2        = 21
+	oidInt4        = 23
+	oidOID         = 26
+	oidFloat4      = 700
+	oidFloat8      = 701
+	oidDate        = 1082
+	oidTime        = 1083
+	oidTimestamp   = 1114
+	oidTimestamptz = 1184
+	oidNumeric     = 1700
+	oidUUID        = 2950
+)
+
+// DuckDBType returns the DuckDB type that a column's text values are cast
+// to. Types without a lossless counterpart (json, arrays, intervals, ...)
+// are kept as VARCHAR.
+func DuckDBType(c Column) string {
+	switch c.TypeOID {
+	case oidBool:
+		return "BOOLEAN"
+	case oidInt2:
+		return "SMALLINT"
+	case oidInt4:
+		return "INTEGER"
+	case oidInt8, oidOID:
+		return "BIGINT"
+	case oidFloat4:
+		return "FLOAT"
+	case oidFloat8:
+		return "DOUBLE"
+	case oidNumeric:
+		// typmod encodes ((precision << 16) | scale) + 4; -1 is unconstrained.
+		if c.TypeMod >= 4 {
+			precision, scale := (c.TypeMod-4)>>16&0xffff, (c.TypeMod-4)&0xffff
+			if precision >= 1 && precision <= 38 {
+				return fmt.Sprintf("DECIMAL(%d,%d)", precision, scale)
+			}
+		}
+		return "DOUBLE"
+	case oidDate:
+		return "DATE"
+	case oidTime:
+		return "TIME"
+	case oidTimestamp:
+		return "TIMESTAMP"
+	case oidTimestamptz:
+		return "TIMESTAMPTZ"
+	case oidUUID:
+		return "UUID"
+	default:
+		return "VARCHAR"
+	}
+}
@@ -0,0 +1,192 @@
+// Package pii recognizes personal data in sampled column values and builds
+// the DuckDB expressions that mask it.
+package pii
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalid is wrapped by errors in a masking policy.
+var ErrInvalid = errors.New("invalid masking policy")
+
+// Kinds of personal data Detect recognizes.
+const (
+	KindEmail      = "email"
+	KindPhone      = "phone"
+	KindIPAddress  = "ip_address"
+	KindCreditCard = "credit_card" // 13 to 19 digits passing the Luhn check
+	KindMyNumber   = "my_number"   // Japanese individual number, 12 digits with a check digit
+)
+
+// Masking policies. Every masked column reads as text; NULLs stay NULL.
+const (
+	PolicyHash    = "hash"    // hex SHA-256 of the text, so equal values stay equal
+	PolicyRedact  = "redact"  // a fixed placeholder
+	PolicyPartial = "partial" // asterisks, keeping the last 4 characters of values of 8 or more
+	PolicyNull    = "null"    // NULL
+)
+
+// TagPII is the tag suggested for every column Detect classifies; a tag
+// "pii:<kind>" names the kind.
+const TagPII = "pii"
+
+// minShare is the share of sampled values that must match a kind for the
+// column to be classified as it.
+const minShare = 0.8
+
+var (
+	emailPattern = regexp.MustCompile(`^[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}$`)
+	phonePattern = regexp.MustCompile(`^(\+\d{1,3}[ \-]?)?(\(\d{1,4}\)[ \-]?)?\d{1,4}([ \-.]?\d{2,4}){1,3}$`)
+	digitSeps    = strings.NewReplacer(" ", "", "-", "")
+)
+
+// Detect classifies a column by a sample of its non-NULL values in text
+// form, returning the kind most of them match or "" if none does.
+func Detect(values []string) string {
+	counts := make(map[string]int)
+	n := 0
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		n++
+		if kind := classify(v); kind != "" {
+			counts[kind]++
+		}
+	}
+	if n == 0 {
+		return ""
+	}
+	for _, kind := range []string{KindEmail, KindIPAddress, KindMyNumber, KindCreditCard, KindPhone} {
+		if float64(counts[kind]) >= minShare*float64(n) {
+			return kind
+		}
+	}
+	return ""
+}
+
+// SuggestedTags returns the tags suggested for a column of kind.
+func SuggestedTags(kind string) []string {
+	if kind == "" {
+		return nil
+	}
+	return []string{TagPII, TagPII + ":" + kind}
+}
+
+func classify(v string) string {
+	if emailPattern.MatchString(v) {
+		return KindEmail
+	}
+	if _, err := netip.ParseAddr(v); err == nil && strings.ContainsAny(v, ".:") {
+		return KindIPAddress
+	}
+	digits := digitSeps.Replace(v)
+	if allDigits(digits) {
+		switch {
+		case len(digits) == 12 && myNumberValid(digits):
+			return KindMyNumber
+		case len(digits) >= 13 && len(digits) <= 19 && luhnValid(digits):
+			return KindCreditCard
+		}
+	}
+	if isPhone(v) {
+		return KindPhone
+	}
+	return ""
+}
+
+// isPhone accepts international numbers and domestic ones with a leading
+// trunk 0, so that plain identifiers of 10 or so digits are not phones.
+func isPhone(v string) bool {
+	if !phonePattern.MatchString(v) {
+		return false
+	}
+	n := 0
+	for _, r := range v {
+		if r >= '0' && r <= '9' {
+			n++
+		}
+	}
+	if n < 10 || n > 15 {
+		return false
+	}
+	return strings.HasPrefix(v, "+") || strings.HasPrefix(v, "0") || strings.HasPrefix(v, "(0")
+}
+
+func allDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func luhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// myNumberValid checks the last digit of a 12-digit individual number
+// against the check digit computed from the other 11.
+func myNumberValid(digits string) bool {
+	sum := 0
+	for n := 1; n <= 11; n++ {
+		p := int(digits[11-n] - '0')
+		q := n + 1
+		if n > 6 {
+			q = n - 5
+		}
+		sum += p * q
+	}
+	check := 0
+	if r := sum % 11; r > 1 {
+		check = 11 - r
+	}
+	return int(digits[11]-'0') == check
+}
+
+// ValidPolicy reports whether p is a known masking policy.
+func ValidPolicy(p string) bool {
+	switch p {
+	case PolicyHash, PolicyRedact, PolicyPartial, PolicyNull:
+		return true
+	}
+	return false
+}
+
+// MaskExpr returns the DuckDB expression masking col, an expression of any
+// type, with policy.
+func MaskExpr(policy, col string) (string, error) {
+	text := fmt.Sprintf("CAST(%s AS VARCHAR)", col)
+	switch policy {
+	case PolicyHash:
+		return fmt.Sprintf("sha256(%s)", text), nil
+	case PolicyRedact:
+		return fmt.Sprintf("CASE WHEN %s IS NULL THEN NULL ELSE '[REDACTED]' END", col), nil
+	case PolicyPartial:
+		return fmt.Sprintf("CASE WHEN length(%[1]s) >= 8 THEN repeat('*', length(%[1]s) - 4) || right(%[1]s, 4) ELSE repeat('*', length(%[1]s)) END", text), nil
+	case PolicyNull:
+		return "CAST(NULL AS VARCHAR)", nil
+	}
+	return "", fmt.Errorf("%w: unknown policy %q", ErrInvalid, policy)
+}
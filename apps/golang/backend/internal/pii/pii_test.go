@@ -0,0 +1,92 @@
+package pii
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/marcboeker/go-duckdb"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{"email", []string{"a@example.com", "taro.yamada+news@mail.example.co.jp", ""}, KindEmail},
+		{"ipv4 and ipv6", []string{"192.168.0.1", "10.0.0.254", "2001:db8::1"}, KindIPAddress},
+		{"japanese phones", []string{"090-1234-5678", "03-1234-5678", "+81 90 1234 5678"}, KindPhone},
+		{"international phone", []string{"+1 (415) 555-0132", "+44 20 7946 0958"}, KindPhone},
+		{"credit cards", []string{"4111 1111 1111 1111", "5500-0000-0000-0004", "378282246310005"}, KindCreditCard},
+		{"my number", []string{"123456789018", "1234 5678 9018"}, KindMyNumber},
+		{"my number with bad check digit", []string{"123456789012", "123456789013"}, ""},
+		{"identifiers", []string{"1234567890", "9876543210", "5555555555"}, ""},
+		{"mostly not", []string{"a@example.com", "n/a", "unknown", "none"}, ""},
+		{"share threshold", []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "-"}, KindEmail},
+		{"versions", []string{"1.2", "2.0"}, ""},
+		{"empty", []string{"", " "}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.values); got != tt.want {
+				t.Errorf("Detect(%q) = %q, want %q", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestedTags(t *testing.T) {
+	if got := SuggestedTags(KindEmail); len(got) != 2 || got[0] != "pii" || got[1] != "pii:email" {
+		t.Errorf("SuggestedTags(email) = %q", got)
+	}
+	if got := SuggestedTags(""); got != nil {
+		t.Errorf("SuggestedTags(\"\") = %q", got)
+	}
+}
+
+func TestMaskExpr(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		policy string
+		value  string // SQL expression
+		want   *string
+	}{
+		{PolicyHash, "'abc'", ptr("ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad")},
+		{PolicyRedact, "'a@example.com'", ptr("[REDACTED]")},
+		{PolicyPartial, "'4111111111111111'", ptr("************1111")},
+		{PolicyPartial, "'0312'", ptr("****")},
+		{PolicyPartial, "12345678::BIGINT", ptr("****5678")},
+		{PolicyNull, "'a@example.com'", nil},
+		{PolicyHash, "NULL::VARCHAR", nil},
+		{PolicyRedact, "NULL::VARCHAR", nil},
+		{PolicyPartial, "NULL::VARCHAR", nil},
+	}
+	for _, tt := range tests {
+		expr, err := MaskExpr(tt.policy, "v")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got sql.NullString
+		if err := db.QueryRow("SELECT " + expr + " FROM (SELECT " + tt.value + " AS v)").Scan(&got); err != nil {
+			t.Fatalf("%s(%s): %v", tt.policy, tt.value, err)
+		}
+		switch {
+		case tt.want == nil && got.Valid:
+			t.Errorf("%s(%s) = %q, want NULL", tt.policy, tt.value, got.String)
+		case tt.want != nil && (!got.Valid || got.String != *tt.want):
+			t.Errorf("%s(%s) = %v, want %q", tt.policy, tt.value, got, *tt.want)
+		}
+	}
+
+	if _, err := MaskExpr("shuffle", "v"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("unknown policy: err = %v", err)
+	}
+}
+
+func ptr(s string) *string { return &s }
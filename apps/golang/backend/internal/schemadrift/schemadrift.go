@@ -0,0 +1,220 @@
+// Package schemadrift compares dataset schemas between imports and decides
+// whether a change is acceptable under a job's drift policy.
+package schemadrift
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrRejected is wrapped by RejectedError.
+	ErrRejected = errors.New("schema drift rejected")
+	// ErrIncompatible is returned by Conform.
+	ErrIncompatible = errors.New("incompatible schema")
+)
+
+// Change kinds.
+const (
+	KindAdded        = "added"
+	KindRemoved      = "removed"
+	KindTypeWidened  = "type_widened"
+	KindTypeNarrowed = "type_narrowed"
+	KindRenamed      = "renamed"
+)
+
+// Policies.
+const (
+	PolicyAccept       = "accept"        // apply every change
+	PolicyAdditiveOnly = "additive_only" // apply added columns and widened types only
+	PolicyFail         = "fail"          // reject any change
+)
+
+// Actions recorded in a Report.
+const (
+	ActionApplied  = "applied"
+	ActionRejected = "rejected"
+)
+
+// Column is the part of a dataset column that drift detection looks at.
+type Column struct {
+	Name string `json:"column_name"`
+	Type string `json:"column_type"`
+}
+
+// Change describes one difference between two schemas.
+type Change struct {
+	Kind           string `json:"kind"`
+	Column         string `json:"column"`
+	PreviousColumn string `json:"previous_column,omitempty"` // renamed only
+	Type           string `json:"type,omitempty"`
+	PreviousType   string `json:"previous_type,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case KindAdded:
+		return fmt.Sprintf("added column %q (%s)", c.Column, c.Type)
+	case KindRemoved:
+		return fmt.Sprintf("removed column %q", c.Column)
+	case KindRenamed:
+		return fmt.Sprintf("renamed column %q to %q", c.PreviousColumn, c.Column)
+	default:
+		return fmt.Sprintf("%s column %q %s -> %s", strings.ReplaceAll(c.Kind, "_", " "), c.Column, c.PreviousType, c.Type)
+	}
+}
+
+// Report is the drift an import detected against the previous version of a
+// dataset and what was done about it.
+type Report struct {
+	DatasetID string   `json:"dataset_id"`
+	Policy    string   `json:"policy"`
+	Action    string   `json:"action"`
+	Changes   []Change `json:"changes"`
+}
+
+// Summary joins the changes into a single line for logs and run errors.
+func (r *Report) Summary() string {
+	parts := make([]string, len(r.Changes))
+	for i, c := range r.Changes {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// RejectedError is returned by an import whose schema changes were rejected
+// by the job's policy. The dataset is left untouched.
+type RejectedError struct {
+	Report *Report
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("schema drift rejected by policy %s: %s", e.Report.Policy, e.Report.Summary())
+}
+
+func (e *RejectedError) Unwrap() error {
+	return ErrRejected
+}
+
+// ValidPolicy reports whether p is a known policy.
+func ValidPolicy(p string) bool {
+	switch p {
+	case PolicyAccept, PolicyAdditiveOnly, PolicyFail:
+		return true
+	}
+	return false
+}
+
+// Diff lists the changes from prev to next. Columns are matched by name.
+// A column removed and another added at the same position with the same
+// type is reported as a rename; this is a guess, as sources such as Google
+// Sheets carry no column identity beyond the header text.
+func Diff(prev, next []Column) []Change {
+	prevByName := make(map[string]int, len(prev))
+	for i, c := range prev {
+		prevByName[c.Name] = i
+	}
+	nextByName := make(map[string]bool, len(next))
+	for _, c := range next {
+		nextByName[c.Name] = true
+	}
+
+	removedAt := make(map[int]Column)
+	for i, c := range prev {
+		if !nextByName[c.Name] {
+			removedAt[i] = c
+		}
+	}
+
+	var changes []Change
+	for i, c := range next {
+		j, ok := prevByName[c.Name]
+		if !ok {
+			if old, found := removedAt[i]; found && canonical(old.Type) == canonical(c.Type) {
+				delete(removedAt, i)
+				changes = append(changes, Change{Kind: KindRenamed, Column: c.Name, PreviousColumn: old.Name, Type: c.Type})
+				continue
+			}
+			changes = append(changes, Change{Kind: KindAdded, Column: c.Name, Type: c.Type})
+			continue
+		}
+		old := prev[j]
+		if canonical(old.Type) == canonical(c.Type) {
+			continue
+		}
+		kind := KindTypeNarrowed
+		if Widens(old.Type, c.Type) {
+			kind = KindTypeWidened
+		}
+		changes = append(changes, Change{Kind: kind, Column: c.Name, Type: c.Type, PreviousType: old.Type})
+	}
+
+	for i, c := range prev {
+		if _, ok := removedAt[i]; ok {
+			changes = append(changes, Change{Kind: KindRemoved, Column: c.Name, PreviousType: c.Type})
+		}
+	}
+	return changes
+}
+
+// Evaluate diffs prev and next and decides on the result under policy. It
+// returns nil when the schemas match. An empty policy means accept.
+func Evaluate(policy string, prev, next []Column) *Report {
+	changes := Diff(prev, next)
+	if len(changes) == 0 {
+		return nil
+	}
+	if policy == "" {
+		policy = PolicyAccept
+	}
+	action := ActionApplied
+	if !Allowed(policy, changes) {
+		action = ActionRejected
+	}
+	return &Report{Policy: policy, Action: action, Changes: changes}
+}
+
+// Allowed reports whether policy accepts all changes. Unknown policies are
+// treated as accept so that runs created before a policy existed still work.
+func Allowed(policy string, changes []Change) bool {
+	switch policy {
+	case PolicyFail:
+		return len(changes) == 0
+	case PolicyAdditiveOnly:
+		for _, c := range changes {
+			if c.Kind != KindAdded && c.Kind != KindTypeWidened {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Conform checks that rows with schema next can be written into a dataset
+// with schema target. Columns are matched by name; target columns missing
+// from next are filled with NULL, while columns of next that target lacks
+// are an error. It returns the columns whose type does not widen to the
+// target type: their values must be cast, and the cast may fail.
+func Conform(target, next []Column) ([]Change, error) {
+	targetTypes := make(map[string]string, len(target))
+	for _, c := range target {
+		targetTypes[c.Name] = c.Type
+	}
+	var unknown []string
+	var casts []Change
+	for _, c := range next {
+		typ, ok := targetTypes[c.Name]
+		if !ok {
+			unknown = append(unknown, fmt.Sprintf("%q", c.Name))
+			continue
+		}
+		if !Widens(c.Type, typ) {
+			casts = append(casts, Change{Kind: KindTypeNarrowed, Column: c.Name, Type: typ, PreviousType: c.Type})
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: the dataset has no column named %s", ErrIncompatible, strings.Join(unknown, ", "))
+	}
+	return casts, nil
+}
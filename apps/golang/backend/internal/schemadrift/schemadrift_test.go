@@ -0,0 +1,229 @@
+package schemadrift
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	prev := []Column{
+		{Name: "id", Type: "BIGINT"},
+		{Name: "name", Type: "VARCHAR"},
+		{Name: "amount", Type: "DOUBLE"},
+		{Name: "signup", Type: "DATE"},
+		{Name: "legacy", Type: "BOOLEAN"},
+	}
+	next := []Column{
+		{Name: "id", Type: "BIGINT"},
+		{Name: "full_name", Type: "VARCHAR"},
+		{Name: "amount", Type: "BIGINT"},
+		{Name: "signup", Type: "TIMESTAMP"},
+		{Name: "email", Type: "VARCHAR"},
+	}
+
+	got := Diff(prev, next)
+	want := []Change{
+		{Kind: KindRenamed, Column: "full_name", PreviousColumn: "name", Type: "VARCHAR"},
+		{Kind: KindTypeNarrowed, Column: "amount", Type: "BIGINT", PreviousType: "DOUBLE"},
+		{Kind: KindTypeWidened, Column: "signup", Type: "TIMESTAMP", PreviousType: "DATE"},
+		{Kind: KindAdded, Column: "email", Type: "VARCHAR"},
+		{Kind: KindRemoved, Column: "legacy", PreviousType: "BOOLEAN"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Diff:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	cols := []Column{{Name: "a", Type: "INTEGER"}, {Name: "b", Type: "varchar"}}
+	same := []Column{{Name: "a", Type: "INTEGER"}, {Name: "b", Type: "VARCHAR"}}
+	if got := Diff(cols, same); len(got) != 0 {
+		t.Fatalf("expected no changes, got %+v", got)
+	}
+}
+
+func TestWidens(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{"INTEGER", "BIGINT", true},
+		{"BIGINT", "INTEGER", false},
+		{"INT", "DOUBLE", true},
+		{"DECIMAL(10,2)", "DECIMAL(18,3)", true},
+		{"BOOLEAN", "VARCHAR", true},
+		{"VARCHAR", "BIGINT", false},
+		{"DATE", "TIMESTAMP WITH TIME ZONE", true},
+		{"TIMESTAMP", "DATE", false},
+		{"DATE", "BIGINT", false},
+		// Parameters
+		{"DECIMAL(18,3)", "DECIMAL(10,2)", false},
+		{"DECIMAL(10,2)", "DECIMAL(12,2)", true},
+		{"DECIMAL(10,2)", "DECIMAL(10,3)", false},
+		{"DECIMAL(10,4)", "DECIMAL(10,2)", false},
+		{"DECIMAL", "DECIMAL(18, 3)", true},
+		{"DECIMAL(18,3)", "DOUBLE", true},
+		{"INTEGER", "DECIMAL(12,2)", true},
+		{"INTEGER", "DECIMAL(10,2)", false},
+		{"BIGINT", "DECIMAL", false},
+		{"DOUBLE", "DECIMAL(38,10)", false},
+		{"VARCHAR(10)", "VARCHAR(20)", true},
+		{"VARCHAR(20)", "VARCHAR(10)", false},
+		{"VARCHAR", "VARCHAR(10)", false},
+		{"VARCHAR(10)", "TEXT", true},
+		// Nested types
+		{"INTEGER[]", "BIGINT[]", true},
+		{"BIGINT[]", "INTEGER[]", false},
+		{"INTEGER[]", "BIGINT", false},
+		{"INTEGER[3]", "INTEGER[]", false},
+		{"STRUCT(a INTEGER, b VARCHAR)", "STRUCT(a BIGINT, b VARCHAR, c DATE)", true},
+		{"STRUCT(a INTEGER, b VARCHAR)", "STRUCT(a INTEGER)", false},
+		{"STRUCT(a BIGINT)", "STRUCT(a INTEGER)", false},
+		{"STRUCT(a INTEGER)", "STRUCT(x INTEGER)", false},
+		{`STRUCT("order id" DECIMAL(18,3), tags VARCHAR[])`, `STRUCT("order id" DECIMAL(10,2), tags VARCHAR[])`, false},
+		{"STRUCT(a STRUCT(b INTEGER))", "STRUCT(a STRUCT(b BIGINT))", true},
+		{"MAP(VARCHAR, INTEGER)", "MAP(VARCHAR, BIGINT)", true},
+		{"MAP(VARCHAR, BIGINT)", "MAP(VARCHAR, INTEGER)", false},
+		{"STRUCT(a INTEGER)", "VARCHAR", true},
+	}
+	for _, c := range cases {
+		if got := Widens(c.from, c.to); got != c.want {
+			t.Errorf("Widens(%q, %q) = %v, want %v", c.from, c.to, got, c.want)
+		}
+	}
+}
+
+func TestDiffParameters(t *testing.T) {
+	prev := []Column{
+		{Name: "price", Type: "DECIMAL(18,3)"},
+		{Name: "code", Type: "VARCHAR(20)"},
+		{Name: "meta", Type: "STRUCT(a INTEGER, b VARCHAR)"},
+		{Name: "qty", Type: "DECIMAL(10, 2)"},
+	}
+	next := []Column{
+		{Name: "price", Type: "DECIMAL(10,2)"},
+		{Name: "code", Type: "VARCHAR(10)"},
+		{Name: "meta", Type: "STRUCT(a INTEGER)"},
+		{Name: "qty", Type: "numeric(10,2)"},
+	}
+	got := Diff(prev, next)
+	want := []Change{
+		{Kind: KindTypeNarrowed, Column: "price", Type: "DECIMAL(10,2)", PreviousType: "DECIMAL(18,3)"},
+		{Kind: KindTypeNarrowed, Column: "code", Type: "VARCHAR(10)", PreviousType: "VARCHAR(20)"},
+		{Kind: KindTypeNarrowed, Column: "meta", Type: "STRUCT(a INTEGER)", PreviousType: "STRUCT(a INTEGER, b VARCHAR)"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Diff:\n got %+v\nwant %+v", got, want)
+	}
+	if Allowed(PolicyAdditiveOnly, got) {
+		t.Error("additive_only allowed narrowed parameters")
+	}
+}
+
+func TestRounds(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{"DECIMAL(18,3)", "DECIMAL(10,2)", true},
+		{"DECIMAL(10,2)", "DECIMAL(18,3)", false},
+		{"DOUBLE", "DECIMAL(10,2)", true},
+		{"VARCHAR", "DECIMAL(10,2)", false},
+		{"DECIMAL(18,3)", "INTEGER", true},
+		{"DECIMAL(18,0)", "BIGINT", false},
+		{"DOUBLE", "BIGINT", true},
+		{"BIGINT", "INTEGER", false},
+		{"DOUBLE", "VARCHAR", false},
+	}
+	for _, c := range cases {
+		if got := Rounds(c.from, c.to); got != c.want {
+			t.Errorf("Rounds(%q, %q) = %v, want %v", c.from, c.to, got, c.want)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	added := []Change{{Kind: KindAdded, Column: "x"}, {Kind: KindTypeWidened, Column: "y"}}
+	removed := []Change{{Kind: KindRemoved, Column: "z"}}
+
+	cases := []struct {
+		policy  string
+		changes []Change
+		want    bool
+	}{
+		{PolicyAccept, removed, true},
+		{PolicyAdditiveOnly, added, true},
+		{PolicyAdditiveOnly, removed, false},
+		{PolicyAdditiveOnly, []Change{{Kind: KindRenamed}}, false},
+		{PolicyFail, nil, true},
+		{PolicyFail, added, false},
+		{"", removed, true},
+	}
+	for _, c := range cases {
+		if got := Allowed(c.policy, c.changes); got != c.want {
+			t.Errorf("Allowed(%q, %v) = %v, want %v", c.policy, c.changes, got, c.want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	prev := []Column{{Name: "a", Type: "INTEGER"}, {Name: "b", Type: "VARCHAR"}}
+
+	if r := Evaluate(PolicyFail, prev, prev); r != nil {
+		t.Fatalf("expected nil report for unchanged schema, got %+v", r)
+	}
+
+	r := Evaluate(PolicyAdditiveOnly, prev, prev[:1])
+	if r == nil || r.Action != ActionRejected {
+		t.Fatalf("expected rejected report, got %+v", r)
+	}
+	err := error(&RejectedError{Report: r})
+	if !errors.Is(err, ErrRejected) {
+		t.Fatal("RejectedError should wrap ErrRejected")
+	}
+	if want := `schema drift rejected by policy additive_only: removed column "b"`; err.Error() != want {
+		t.Fatalf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	r = Evaluate("", prev, append(prev, Column{Name: "c", Type: "DATE"}))
+	if r == nil || r.Action != ActionApplied || r.Policy != PolicyAccept {
+		t.Fatalf("expected applied report under accept, got %+v", r)
+	}
+}
+
+func TestConform(t *testing.T) {
+	target := []Column{
+		{Name: "id", Type: "BIGINT"},
+		{Name: "code", Type: "VARCHAR"},
+		{Name: "amount", Type: "DOUBLE"},
+		{Name: "day", Type: "DATE"},
+	}
+
+	casts, err := Conform(target, []Column{
+		{Name: "code", Type: "BIGINT"},
+		{Name: "id", Type: "INTEGER"},
+		{Name: "day", Type: "VARCHAR"},
+	})
+	if err != nil {
+		t.Fatalf("Conform: %v", err)
+	}
+	want := []Change{{Kind: KindTypeNarrowed, Column: "day", Type: "DATE", PreviousType: "VARCHAR"}}
+	if !reflect.DeepEqual(casts, want) {
+		t.Errorf("casts = %+v, want %+v", casts, want)
+	}
+
+	casts, err = Conform([]Column{{Name: "price", Type: "DECIMAL(10,2)"}}, []Column{{Name: "price", Type: "DECIMAL(18,3)"}})
+	if err != nil {
+		t.Fatalf("Conform: %v", err)
+	}
+	want = []Change{{Kind: KindTypeNarrowed, Column: "price", Type: "DECIMAL(10,2)", PreviousType: "DECIMAL(18,3)"}}
+	if !reflect.DeepEqual(casts, want) {
+		t.Errorf("decimal casts = %+v, want %+v", casts, want)
+	}
+
+	_, err = Conform(target, []Column{{Name: "id", Type: "BIGINT"}, {Name: "extra", Type: "VARCHAR"}})
+	if !errors.Is(err, ErrIncompatible) {
+		t.Errorf("extra column: err = %v, want ErrIncompatible", err)
+	}
+}
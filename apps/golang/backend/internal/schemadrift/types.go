@@ -0,0 +1,318 @@
+package schemadrift
+
+import (
+	"strconv"
+	"strings"
+)
+
+// typeFamilies orders types from narrowest to widest within each family.
+var typeFamilies = [][]string{
+	{"BOOLEAN", "TINYINT", "SMALLINT", "INTEGER", "BIGINT", "HUGEINT", "DECIMAL", "FLOAT", "DOUBLE"},
+	{"UTINYINT", "USMALLINT", "UINTEGER", "UBIGINT"},
+	{"DATE", "TIMESTAMP", "TIMESTAMPTZ"},
+}
+
+var typeAliases = map[string]string{
+	"BOOL":                     "BOOLEAN",
+	"INT1":                     "TINYINT",
+	"INT2":                     "SMALLINT",
+	"SHORT":                    "SMALLINT",
+	"INT":                      "INTEGER",
+	"INT4":                     "INTEGER",
+	"SIGNED":                   "INTEGER",
+	"INT8":                     "BIGINT",
+	"LONG":                     "BIGINT",
+	"INT128":                   "HUGEINT",
+	"NUMERIC":                  "DECIMAL",
+	"REAL":                     "FLOAT",
+	"FLOAT4":                   "FLOAT",
+	"FLOAT8":                   "DOUBLE",
+	"DATETIME":                 "TIMESTAMP",
+	"TIMESTAMP WITH TIME ZONE": "TIMESTAMPTZ",
+	"TEXT":                     "VARCHAR",
+	"STRING":                   "VARCHAR",
+	"CHAR":                     "VARCHAR",
+	"BPCHAR":                   "VARCHAR",
+}
+
+// integerDigits is the number of decimal digits an integer type needs, for
+// deciding whether it fits a DECIMAL.
+var integerDigits = map[string]int{
+	"BOOLEAN":   1,
+	"TINYINT":   3,
+	"SMALLINT":  5,
+	"INTEGER":   10,
+	"BIGINT":    19,
+	"HUGEINT":   39,
+	"UTINYINT":  3,
+	"USMALLINT": 5,
+	"UINTEGER":  10,
+	"UBIGINT":   20,
+}
+
+// DuckDB's DECIMAL without parameters is DECIMAL(18,3).
+const (
+	defaultDecimalWidth = 18
+	defaultDecimalScale = 3
+)
+
+// columnType is a parsed DuckDB type name.
+type columnType struct {
+	name   string       // canonical base name, e.g. DECIMAL, LIST, STRUCT
+	params []int        // DECIMAL width and scale, VARCHAR length
+	raw    string       // parameters that are not numbers, kept for comparison
+	elem   *columnType  // LIST and ARRAY element
+	size   string       // ARRAY size
+	fields []typedField // STRUCT and UNION members; MAP key and value
+}
+
+type typedField struct {
+	name string
+	typ  *columnType
+}
+
+// parseType parses a DuckDB type name such as "DECIMAL(18,3)",
+// "INTEGER[]" or "STRUCT(a INTEGER, b VARCHAR[])".
+func parseType(t string) *columnType {
+	t = strings.TrimSpace(t)
+	if strings.HasSuffix(t, "]") {
+		if i := strings.LastIndexByte(t, '['); i > 0 && i > strings.LastIndexByte(t, ')') {
+			ct := &columnType{name: "LIST", elem: parseType(t[:i])}
+			if size := strings.TrimSpace(t[i+1 : len(t)-1]); size != "" {
+				ct.name, ct.size = "ARRAY", size
+			}
+			return ct
+		}
+	}
+
+	base, inner := t, ""
+	if i := strings.IndexByte(t, '('); i >= 0 && strings.HasSuffix(t, ")") {
+		base, inner = t[:i], t[i+1:len(t)-1]
+	}
+	base = strings.ToUpper(strings.Join(strings.Fields(base), " "))
+	if alias, ok := typeAliases[base]; ok {
+		base = alias
+	}
+	ct := &columnType{name: base}
+	if inner == "" {
+		return ct
+	}
+
+	parts := splitTopLevel(inner)
+	switch base {
+	case "STRUCT", "UNION":
+		for _, p := range parts {
+			name, typ := splitFieldName(p)
+			ct.fields = append(ct.fields, typedField{name: name, typ: parseType(typ)})
+		}
+	case "MAP":
+		for _, p := range parts {
+			ct.fields = append(ct.fields, typedField{typ: parseType(p)})
+		}
+	case "LIST":
+		ct.elem = parseType(inner)
+	default:
+		for _, p := range parts {
+			n, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil {
+				ct.params, ct.raw = nil, strings.ToUpper(strings.Join(strings.Fields(inner), ""))
+				break
+			}
+			ct.params = append(ct.params, n)
+		}
+	}
+	return ct
+}
+
+// splitTopLevel splits s at the commas outside parentheses and quotes.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start, quoted := 0, 0, false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// splitFieldName splits a STRUCT member such as `"order id" BIGINT` into
+// its name and type.
+func splitFieldName(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, `"`) {
+		for i := 1; i < len(s); i++ {
+			if s[i] != '"' {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '"' {
+				i++
+				continue
+			}
+			return strings.ReplaceAll(s[1:i], `""`, `"`), s[i+1:]
+		}
+	}
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+// String renders the type in canonical form, so that types that differ
+// only in aliases, case or spacing compare equal.
+func (t *columnType) String() string {
+	switch {
+	case t.name == "LIST":
+		return t.elem.String() + "[]"
+	case t.name == "ARRAY":
+		return t.elem.String() + "[" + t.size + "]"
+	case len(t.fields) > 0:
+		parts := make([]string, len(t.fields))
+		for i, f := range t.fields {
+			parts[i] = f.typ.String()
+			if f.name != "" {
+				parts[i] = strconv.Quote(f.name) + " " + parts[i]
+			}
+		}
+		return t.name + "(" + strings.Join(parts, ", ") + ")"
+	case t.raw != "":
+		return t.name + "(" + t.raw + ")"
+	case len(t.params) > 0:
+		parts := make([]string, len(t.params))
+		for i, p := range t.params {
+			parts[i] = strconv.Itoa(p)
+		}
+		return t.name + "(" + strings.Join(parts, ",") + ")"
+	}
+	return t.name
+}
+
+// decimal returns the width and scale of a DECIMAL.
+func (t *columnType) decimal() (width, scale int) {
+	width, scale = defaultDecimalWidth, defaultDecimalScale
+	if len(t.params) > 0 {
+		width, scale = t.params[0], 0
+	}
+	if len(t.params) > 1 {
+		scale = t.params[1]
+	}
+	return width, scale
+}
+
+// canonical returns the canonical form of a DuckDB type name.
+func canonical(t string) string {
+	return parseType(t).String()
+}
+
+// Widens reports whether every value of type from can be represented in
+// type to. Any type widens to VARCHAR. DECIMALs widen when neither the
+// scale nor the integer digits shrink, and nested types widen when each of
+// their members does; a STRUCT may gain fields but not lose them.
+func Widens(from, to string) bool {
+	return widens(parseType(from), parseType(to))
+}
+
+func widens(from, to *columnType) bool {
+	if from.String() == to.String() {
+		return true
+	}
+	if to.name == "VARCHAR" {
+		if len(to.params) == 0 {
+			return true
+		}
+		return from.name == "VARCHAR" && len(from.params) > 0 && from.params[0] <= to.params[0]
+	}
+
+	switch from.name {
+	case "LIST", "ARRAY":
+		return from.name == to.name && from.size == to.size && widens(from.elem, to.elem)
+	case "STRUCT":
+		if to.name != "STRUCT" {
+			return false
+		}
+		for _, f := range from.fields {
+			tf := to.field(f.name)
+			if tf == nil || !widens(f.typ, tf.typ) {
+				return false
+			}
+		}
+		return true
+	case "MAP":
+		return to.name == "MAP" && len(from.fields) == 2 && len(to.fields) == 2 &&
+			widens(from.fields[0].typ, to.fields[0].typ) && widens(from.fields[1].typ, to.fields[1].typ)
+	case "UNION":
+		return false
+	}
+
+	if to.name == "DECIMAL" {
+		toWidth, toScale := to.decimal()
+		if from.name == "DECIMAL" {
+			width, scale := from.decimal()
+			return toScale >= scale && toWidth-toScale >= width-scale
+		}
+		if digits, ok := integerDigits[from.name]; ok {
+			return toWidth-toScale >= digits
+		}
+		return false
+	}
+	if from.raw != "" || to.raw != "" || len(to.params) > 0 || len(to.fields) > 0 || to.elem != nil {
+		return false
+	}
+
+	for _, family := range typeFamilies {
+		fi, ti := -1, -1
+		for i, t := range family {
+			if t == from.name {
+				fi = i
+			}
+			if t == to.name {
+				ti = i
+			}
+		}
+		if fi >= 0 && ti >= 0 {
+			return ti > fi
+		}
+	}
+	return false
+}
+
+func (t *columnType) field(name string) *typedField {
+	for i := range t.fields {
+		if t.fields[i].name == name {
+			return &t.fields[i]
+		}
+	}
+	return nil
+}
+
+// Rounds reports whether casting a value of type from to the DECIMAL or
+// integer type to may round it, so that a cast that succeeds can still
+// change the value.
+func Rounds(from, to string) bool {
+	f, t := parseType(from), parseType(to)
+	toScale := 0
+	switch {
+	case t.name == "DECIMAL":
+		_, toScale = t.decimal()
+	case t.name != "BOOLEAN" && integerDigits[t.name] > 0:
+	default:
+		return false
+	}
+	switch f.name {
+	case "FLOAT", "DOUBLE":
+		return true
+	case "DECIMAL":
+		_, scale := f.decimal()
+		return toScale < scale
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package secret
+
+import (
+	"crypto/cipher"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// sealedPrefix marks a value sealed with a row data key.
+const sealedPrefix = "enc:v1:"
+
+// Envelope seals and opens the secret values of a single row with that
+// row's data key. An Envelope without a data key passes values through.
+type Envelope struct {
+	// WrappedKey is the row's data key wrapped with a master key; persist
+	// it alongside the sealed values. Empty when encryption is disabled.
+	WrappedKey string
+	aead       cipher.AEAD
+}
+
+func newEnvelope(dek []byte, wrapped string) (*Envelope, error) {
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return nil, fmt.Errorf("secret: data key: %w", err)
+	}
+	return &Envelope{WrappedKey: wrapped, aead: aead}, nil
+}
+
+// IsSealed reports whether value was produced by Envelope.Seal.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// Seal encrypts plaintext. Empty strings are stored as-is so that "no
+// value" stays distinguishable without decrypting.
+func (e *Envelope) Seal(plaintext string) (string, error) {
+	if e.aead == nil || plaintext == "" {
+		return plaintext, nil
+	}
+	sealed, err := seal(e.aead, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a sealed value. Plain-text values (rows written before
+// encryption was enabled) are returned unchanged.
+func (e *Envelope) Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+	if e.aead == nil {
+		return "", ErrNoMasterKey
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if err != nil {
+		return "", ErrMalformed
+	}
+	plaintext, err := open(e.aead, raw, nil)
+	if err != nil {
+		return "", fmt.Errorf("secret: open value: %w", err)
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,236 @@
+// Package secret implements envelope encryption for secrets stored at rest.
+//
+// Each row gets its own random data key (DEK). Secret fields of the row are
+// sealed with the DEK using AES-GCM, and the DEK itself is wrapped with a
+// master key (KEK) from the Keyring. Rotating the master key only requires
+// re-wrapping the per-row data keys.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrNoMasterKey   = errors.New("secret: no master key configured")
+	ErrUnknownKey    = errors.New("secret: unknown master key id")
+	ErrMalformed     = errors.New("secret: malformed ciphertext")
+	errInvalidKeyLen = errors.New("master key must be 32 bytes (base64-encoded)")
+)
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Config holds master key settings.
+type Config struct {
+	// MasterKeys is a comma or newline separated list of "id:base64key"
+	// entries. The first entry is the active key; the rest are kept for
+	// decrypting rows that have not been re-encrypted yet.
+	MasterKeys string
+	// RefDir is the base directory for file-based secret references.
+	RefDir string
+}
+
+// LoadConfig reads secret settings from environment variables.
+// SECRETS_MASTER_KEYS_FILE takes precedence over SECRETS_MASTER_KEYS. A key
+// file that cannot be read is an error rather than a fallback, so that
+// secrets are never stored in plain text because of a missing mount.
+func LoadConfig() (Config, error) {
+	keys := os.Getenv("SECRETS_MASTER_KEYS")
+	if path := os.Getenv("SECRETS_MASTER_KEYS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("secret: read SECRETS_MASTER_KEYS_FILE: %w", err)
+		}
+		keys = string(data)
+	}
+	return Config{
+		MasterKeys: keys,
+		RefDir:     os.Getenv("SECRETS_REF_DIR"),
+	}, nil
+}
+
+// Keyring holds the master keys used to wrap per-row data keys.
+// A nil or empty Keyring stores secrets in plain text.
+type Keyring struct {
+	activeID string
+	keys     map[string]cipher.AEAD
+}
+
+// NewKeyring parses cfg.MasterKeys. An empty value yields an empty keyring.
+func NewKeyring(cfg Config) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+	entries := strings.FieldsFunc(cfg.MasterKeys, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	})
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("secret: invalid master key entry (want id:base64key)")
+		}
+		if _, exists := k.keys[id]; exists {
+			return nil, fmt.Errorf("secret: duplicate master key id %q", id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("secret: decode master key %q: %w", id, err)
+		}
+		if len(raw) != 32 {
+			return nil, fmt.Errorf("secret: master key %q: %w", id, errInvalidKeyLen)
+		}
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return nil, fmt.Errorf("secret: master key %q: %w", id, err)
+		}
+		k.keys[id] = aead
+		if k.activeID == "" {
+			k.activeID = id
+		}
+	}
+	return k, nil
+}
+
+// Enabled reports whether a master key is configured.
+func (k *Keyring) Enabled() bool {
+	return k != nil && k.activeID != ""
+}
+
+// ActiveKeyID returns the ID of the key used to wrap new data keys.
+func (k *Keyring) ActiveKeyID() string {
+	if k == nil {
+		return ""
+	}
+	return k.activeID
+}
+
+// LogStartup logs the keyring state at startup.
+func LogStartup(k *Keyring) {
+	if !k.Enabled() {
+		log.Printf("secret: no master key configured, secrets are stored unencrypted")
+		return
+	}
+	log.Printf("secret: envelope encryption enabled active_key=%s keys=%d", k.activeID, len(k.keys))
+}
+
+// NewEnvelope creates a fresh data key wrapped with the active master key.
+// Without a master key the returned envelope passes values through unchanged.
+func (k *Keyring) NewEnvelope() (*Envelope, error) {
+	if !k.Enabled() {
+		return &Envelope{}, nil
+	}
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("secret: generate data key: %w", err)
+	}
+	wrapped, err := k.wrap(k.activeID, dek)
+	if err != nil {
+		return nil, err
+	}
+	return newEnvelope(dek, wrapped)
+}
+
+// OpenEnvelope unwraps a stored data key. An empty wrapped key means the
+// row predates encryption and its values are plain text.
+func (k *Keyring) OpenEnvelope(wrapped string) (*Envelope, error) {
+	if wrapped == "" {
+		return &Envelope{}, nil
+	}
+	dek, err := k.unwrap(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return newEnvelope(dek, wrapped)
+}
+
+// Rewrap re-wraps a stored data key with the active master key. It reports
+// false when the key is already wrapped with the active master key.
+func (k *Keyring) Rewrap(wrapped string) (string, bool, error) {
+	if !k.Enabled() {
+		return "", false, ErrNoMasterKey
+	}
+	id, _, ok := strings.Cut(wrapped, ":")
+	if !ok {
+		return "", false, ErrMalformed
+	}
+	if id == k.activeID {
+		return wrapped, false, nil
+	}
+	dek, err := k.unwrap(wrapped)
+	if err != nil {
+		return "", false, err
+	}
+	out, err := k.wrap(k.activeID, dek)
+	if err != nil {
+		return "", false, err
+	}
+	return out, true, nil
+}
+
+// wrap encrypts dek with master key id. Format: "<id>:<base64(nonce|ct)>".
+// The key id is bound as additional data so entries cannot be relabelled.
+func (k *Keyring) wrap(id string, dek []byte) (string, error) {
+	aead := k.keys[id]
+	sealed, err := seal(aead, dek, []byte(id))
+	if err != nil {
+		return "", err
+	}
+	return id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (k *Keyring) unwrap(wrapped string) ([]byte, error) {
+	if !k.Enabled() {
+		return nil, ErrNoMasterKey
+	}
+	id, encoded, ok := strings.Cut(wrapped, ":")
+	if !ok {
+		return nil, ErrMalformed
+	}
+	aead, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	dek, err := open(aead, raw, []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("secret: unwrap data key: %w", err)
+	}
+	return dek, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("secret: generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func open(aead cipher.AEAD, data, additional []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ct := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ct, additional)
+}
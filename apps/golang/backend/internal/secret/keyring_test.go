@@ -0,0 +1,168 @@
+package secret
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(t *testing.T) string {
+	t.Helper()
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func mustKeyring(t *testing.T, keys string) *Keyring {
+	t.Helper()
+	k, err := NewKeyring(Config{MasterKeys: keys})
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return k
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	k := mustKeyring(t, "k1:"+testKey(t))
+
+	env, err := k.NewEnvelope()
+	if err != nil {
+		t.Fatalf("NewEnvelope: %v", err)
+	}
+	sealed, err := env.Seal("hunter2")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if !IsSealed(sealed) || sealed == "hunter2" {
+		t.Fatalf("value not sealed: %q", sealed)
+	}
+
+	reopened, err := k.OpenEnvelope(env.WrappedKey)
+	if err != nil {
+		t.Fatalf("OpenEnvelope: %v", err)
+	}
+	got, err := reopened.Open(sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if got != "hunter2" {
+		t.Errorf("Open = %q, want hunter2", got)
+	}
+
+	// Plain-text legacy values pass through.
+	if got, _ := reopened.Open("legacy"); got != "legacy" {
+		t.Errorf("Open(legacy) = %q", got)
+	}
+}
+
+func TestDisabledKeyring(t *testing.T) {
+	k := mustKeyring(t, "")
+	if k.Enabled() {
+		t.Fatal("empty keyring should be disabled")
+	}
+	env, err := k.NewEnvelope()
+	if err != nil {
+		t.Fatalf("NewEnvelope: %v", err)
+	}
+	if v, _ := env.Seal("plain"); v != "plain" || env.WrappedKey != "" {
+		t.Errorf("disabled envelope should pass through, got %q key=%q", v, env.WrappedKey)
+	}
+	if _, err := env.Open(sealedPrefix + "AAAA"); !errors.Is(err, ErrNoMasterKey) {
+		t.Errorf("Open sealed without key: err = %v, want ErrNoMasterKey", err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	oldKey, newKey := testKey(t), testKey(t)
+	before := mustKeyring(t, "old:"+oldKey)
+	env, _ := before.NewEnvelope()
+	sealed, _ := env.Seal("secret")
+
+	after := mustKeyring(t, "new:"+newKey+",old:"+oldKey)
+	wrapped, changed, err := after.Rewrap(env.WrappedKey)
+	if err != nil || !changed {
+		t.Fatalf("Rewrap: changed=%v err=%v", changed, err)
+	}
+	if _, changed, _ := after.Rewrap(wrapped); changed {
+		t.Error("Rewrap of active key should be a no-op")
+	}
+
+	// The old key can be dropped once rows are rewrapped.
+	onlyNew := mustKeyring(t, "new:"+newKey)
+	reopened, err := onlyNew.OpenEnvelope(wrapped)
+	if err != nil {
+		t.Fatalf("OpenEnvelope after rotation: %v", err)
+	}
+	if got, err := reopened.Open(sealed); err != nil || got != "secret" {
+		t.Errorf("Open after rotation = %q, %v", got, err)
+	}
+	if _, err := onlyNew.OpenEnvelope(env.WrappedKey); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("old wrap: err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestNewKeyringRejectsInvalid(t *testing.T) {
+	for _, keys := range []string{
+		"nokey",
+		"k1:not-base64!",
+		"k1:" + base64.StdEncoding.EncodeToString([]byte("short")),
+		"k1:" + testKey(t) + ",k1:" + testKey(t),
+	} {
+		if _, err := NewKeyring(Config{MasterKeys: keys}); err == nil {
+			t.Errorf("NewKeyring(%q) should fail", keys)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	key := "k1:" + testKey(t)
+	t.Setenv("SECRETS_MASTER_KEYS", "env:"+testKey(t))
+	path := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(path, []byte(key+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SECRETS_MASTER_KEYS_FILE", path)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k := mustKeyring(t, cfg.MasterKeys); k.ActiveKeyID() != "k1" {
+		t.Errorf("active key = %s, want k1 from the file", k.ActiveKeyID())
+	}
+
+	// A key file that cannot be read does not fall back to the variable
+	t.Setenv("SECRETS_MASTER_KEYS_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := LoadConfig(); err == nil {
+		t.Error("LoadConfig with a missing key file succeeded, want an error")
+	}
+}
+
+func TestRefResolver(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "t1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "t1", "pg.json"), []byte(`{"password":"pw"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r := NewRefResolver(dir)
+
+	values, err := r.Resolve("t1", "file:pg.json")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if values["password"] != "pw" {
+		t.Errorf("password = %v", values["password"])
+	}
+
+	for _, ref := range []string{"vault:pg", "file:../t1/pg.json", "file:/etc/passwd"} {
+		if _, err := r.Resolve("t2", ref); !errors.Is(err, ErrRefUnsupported) {
+			t.Errorf("Resolve(%q) err = %v, want ErrRefUnsupported", ref, err)
+		}
+	}
+}
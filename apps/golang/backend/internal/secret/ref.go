@@ -0,0 +1,59 @@
+package secret
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrRefUnsupported = errors.New("secret: unsupported secret_ref")
+
+// RefResolver loads externally stored secrets referenced by a connection's
+// secret_ref. References are tenant-scoped: "file:<name>" resolves to
+// <dir>/<tenant_id>/<name>, which must contain a JSON object whose keys are
+// connector config fields (e.g. {"password": "..."}).
+type RefResolver struct {
+	dir string
+}
+
+// NewRefResolver creates a resolver rooted at dir. An empty dir disables
+// secret references.
+func NewRefResolver(dir string) *RefResolver {
+	return &RefResolver{dir: dir}
+}
+
+// Resolve returns the secret values referenced by ref for tenantID.
+func (r *RefResolver) Resolve(tenantID, ref string) (map[string]any, error) {
+	scheme, name, ok := strings.Cut(ref, ":")
+	if !ok || scheme != "file" {
+		return nil, fmt.Errorf("%w: %q (expected file:<name>)", ErrRefUnsupported, ref)
+	}
+	if r == nil || r.dir == "" {
+		return nil, fmt.Errorf("%w: SECRETS_REF_DIR is not configured", ErrRefUnsupported)
+	}
+	if tenantID == "" || strings.ContainsAny(tenantID, `/\`) {
+		return nil, fmt.Errorf("%w: invalid tenant", ErrRefUnsupported)
+	}
+	if name == "" || filepath.IsAbs(name) || strings.Contains(name, "..") {
+		return nil, fmt.Errorf("%w: invalid name %q", ErrRefUnsupported, name)
+	}
+
+	base := filepath.Join(r.dir, tenantID)
+	path := filepath.Join(base, filepath.Clean(name))
+	if !strings.HasPrefix(path, base+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%w: invalid name %q", ErrRefUnsupported, name)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("secret: read secret_ref %q: %w", ref, err)
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("secret: parse secret_ref %q: %w", ref, err)
+	}
+	return values, nil
+}
@@ -0,0 +1,417 @@
+// Package urlfetch downloads remote files for URL imports. It refuses
+// connections into private networks, enforces a size limit, sniffs the
+// content type and verifies an expected checksum while the body streams.
+package urlfetch
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL       = errors.New("invalid url")
+	ErrBlockedAddress   = errors.New("address is not allowed")
+	ErrTooLarge         = errors.New("file exceeds the size limit")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrHTMLResponse     = errors.New("url returned an HTML page")
+)
+
+// Auth types.
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+)
+
+const maxRedirects = 5
+
+// Config controls the HTTP client of a Fetcher.
+type Config struct {
+	// AllowPrivateNetworks permits loopback, private and link-local
+	// addresses; for local development and tests only.
+	AllowPrivateNetworks bool
+	// Timeout bounds a whole download, body included.
+	Timeout time.Duration
+}
+
+// LoadConfig reads UPLOAD_FETCH_ALLOW_PRIVATE_NETWORKS and
+// UPLOAD_FETCH_TIMEOUT (default 30m).
+func LoadConfig() Config {
+	cfg := Config{Timeout: 30 * time.Minute}
+	if v, err := strconv.ParseBool(os.Getenv("UPLOAD_FETCH_ALLOW_PRIVATE_NETWORKS")); err == nil {
+		cfg.AllowPrivateNetworks = v
+	}
+	if d, err := time.ParseDuration(os.Getenv("UPLOAD_FETCH_TIMEOUT")); err == nil && d > 0 {
+		cfg.Timeout = d
+	}
+	return cfg
+}
+
+// Auth is the credential sent with a request.
+type Auth struct {
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// Validate checks that the fields required by the auth type are set.
+func (a *Auth) Validate() error {
+	if a == nil {
+		return nil
+	}
+	switch a.Type {
+	case AuthBasic:
+		if a.Username == "" {
+			return errors.New("basic auth requires a username")
+		}
+	case AuthBearer:
+		if a.Token == "" {
+			return errors.New("bearer auth requires a token")
+		}
+	default:
+		return fmt.Errorf("auth type must be %s or %s", AuthBasic, AuthBearer)
+	}
+	return nil
+}
+
+// Checksum is an expected digest of the downloaded file.
+type Checksum struct {
+	Algorithm string // sha256, sha1 or md5
+	Hex       string
+}
+
+func (c Checksum) String() string {
+	return c.Algorithm + ":" + c.Hex
+}
+
+// ParseChecksum parses "<algorithm>:<hex digest>", e.g. "sha256:9f86d0...".
+func ParseChecksum(s string) (*Checksum, error) {
+	alg, digest, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, errors.New("checksum must be <algorithm>:<hex digest>")
+	}
+	c := &Checksum{Algorithm: strings.ToLower(alg), Hex: strings.ToLower(digest)}
+	h, err := c.newHash()
+	if err != nil {
+		return nil, err
+	}
+	if raw, err := hex.DecodeString(c.Hex); err != nil || len(raw) != h.Size() {
+		return nil, fmt.Errorf("checksum is not a hex %s digest", c.Algorithm)
+	}
+	return c, nil
+}
+
+func (c Checksum) newHash() (hash.Hash, error) {
+	switch c.Algorithm {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "md5":
+		return md5.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algorithm %q (sha256, sha1 or md5)", c.Algorithm)
+}
+
+// ValidateURL checks that raw is an absolute http or https URL without
+// embedded credentials.
+func ValidateURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%w: scheme must be http or https", ErrInvalidURL)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("%w: host is required", ErrInvalidURL)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("%w: pass credentials as auth, not in the url", ErrInvalidURL)
+	}
+	return u, nil
+}
+
+// Request describes one download.
+type Request struct {
+	URL      string
+	Auth     *Auth
+	Checksum *Checksum
+	// MaxBytes limits the file size; zero or less means no limit.
+	MaxBytes int64
+}
+
+// Fetcher downloads files over HTTP.
+type Fetcher struct {
+	client  *http.Client
+	timeout time.Duration
+}
+
+func New(cfg Config) *Fetcher {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !cfg.AllowPrivateNetworks {
+		// Checked on the resolved address, so redirects and DNS rebinding
+		// cannot reach internal services either.
+		dialer.Control = checkAddress
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: time.Minute,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s", ErrInvalidURL, req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Minute
+	}
+	return &Fetcher{client: client, timeout: timeout}
+}
+
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isBlocked(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
+
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isBlocked(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip)
+}
+
+// Open starts a download. The caller reads the file from the returned
+// Download and must close it.
+func (f *Fetcher) Open(ctx context.Context, req Request) (*Download, error) {
+	if _, err := ValidateURL(req.URL); err != nil {
+		return nil, err
+	}
+	if err := req.Auth.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if req.Auth != nil {
+		switch req.Auth.Type {
+		case AuthBasic:
+			httpReq.SetBasicAuth(req.Auth.Username, req.Auth.Password)
+		case AuthBearer:
+			httpReq.Header.Set("Authorization", "Bearer "+req.Auth.Token)
+		}
+	}
+
+	resp, err := f.client.Do(httpReq)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("fetch: %w", err)
+	}
+	d := &Download{
+		Size:     resp.ContentLength,
+		body:     resp.Body,
+		cancel:   cancel,
+		maxBytes: req.MaxBytes,
+		checksum: req.Checksum,
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		d.Close()
+		return nil, fmt.Errorf("fetch: HTTP %s", resp.Status)
+	}
+	if req.MaxBytes > 0 && resp.ContentLength > req.MaxBytes {
+		d.Close()
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", ErrTooLarge, resp.ContentLength, req.MaxBytes)
+	}
+	if req.Checksum != nil {
+		if d.hash, err = req.Checksum.newHash(); err != nil {
+			d.Close()
+			return nil, err
+		}
+	}
+
+	d.r = bufio.NewReaderSize(resp.Body, 4096)
+	head, err := d.r.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		d.Close()
+		return nil, fmt.Errorf("fetch: read body: %w", err)
+	}
+	if strings.HasPrefix(http.DetectContentType(head), "text/html") {
+		d.Close()
+		return nil, ErrHTMLResponse
+	}
+	d.ContentType = sniffContentType(resp.Header.Get("Content-Type"), head)
+	d.FileName = fileName(resp)
+	return d, nil
+}
+
+// Download is an open download. Reading it returns the file; the read that
+// reaches the end of the file fails when the checksum does not match.
+type Download struct {
+	// ContentType is the server's content type, or the sniffed one when
+	// the server sends none or a generic binary type.
+	ContentType string
+	// FileName comes from Content-Disposition or the last path segment of
+	// the final URL; it may be empty.
+	FileName string
+	// Size is the announced Content-Length, -1 when unknown.
+	Size int64
+
+	body     io.ReadCloser
+	r        *bufio.Reader
+	cancel   context.CancelFunc
+	maxBytes int64
+	checksum *Checksum
+	hash     hash.Hash
+	n        int64
+	done     bool
+	err      error
+}
+
+func (d *Download) Read(p []byte) (int, error) {
+	if d.done {
+		if d.err != nil {
+			return 0, d.err
+		}
+		return 0, io.EOF
+	}
+	n, err := d.r.Read(p)
+	d.n += int64(n)
+	if d.hash != nil {
+		d.hash.Write(p[:n])
+	}
+	if d.maxBytes > 0 && d.n > d.maxBytes {
+		d.done = true
+		d.err = fmt.Errorf("%w: more than %d bytes", ErrTooLarge, d.maxBytes)
+		return 0, d.err
+	}
+	if errors.Is(err, io.EOF) {
+		d.done = true
+		d.err = d.verify()
+		if d.err != nil {
+			return n, d.err
+		}
+	}
+	return n, err
+}
+
+// Verify reads what is left of the file and reports whether it matched the
+// size limit and checksum. Call it after a consumer that may stop reading
+// at the announced size.
+func (d *Download) Verify() error {
+	if !d.done {
+		if _, err := io.Copy(io.Discard, d); err != nil {
+			return err
+		}
+	}
+	return d.err
+}
+
+// BytesRead is the number of bytes read so far.
+func (d *Download) BytesRead() int64 {
+	return d.n
+}
+
+func (d *Download) Close() error {
+	defer d.cancel()
+	return d.body.Close()
+}
+
+func (d *Download) verify() error {
+	if d.hash == nil {
+		return nil
+	}
+	if got := hex.EncodeToString(d.hash.Sum(nil)); got != d.checksum.Hex {
+		return fmt.Errorf("%w: %s is %s, expected %s", ErrChecksumMismatch, d.checksum.Algorithm, got, d.checksum.Hex)
+	}
+	return nil
+}
+
+func sniffContentType(header string, head []byte) string {
+	if strings.HasPrefix(string(head), "PAR1") {
+		return "application/vnd.apache.parquet"
+	}
+	if mediaType, _, err := mime.ParseMediaType(header); err == nil &&
+		mediaType != "application/octet-stream" && mediaType != "binary/octet-stream" {
+		return mediaType
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	return mediaType
+}
+
+func fileName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := path.Base(strings.ReplaceAll(params["filename"], `\`, "/")); name != "." && name != "/" {
+			return name
+		}
+	}
+	name := path.Base(resp.Request.URL.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// Extension returns the file extension uploads use for a content type, or
+// "" when the type has no converter.
+func Extension(contentType string) string {
+	switch contentType {
+	case "text/csv", "application/csv":
+		return ".csv"
+	case "text/tab-separated-values":
+		return ".tsv"
+	case "text/plain":
+		return ".txt"
+	case "application/json", "application/x-ndjson", "application/jsonl":
+		return ".json"
+	case "application/vnd.apache.parquet", "application/x-parquet":
+		return ".parquet"
+	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		return ".xlsx"
+	case "application/gzip", "application/x-gzip":
+		return ".gz"
+	case "application/zip", "application/x-zip-compressed":
+		return ".zip"
+	}
+	return ""
+}
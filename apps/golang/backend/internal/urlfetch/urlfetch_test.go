@@ -0,0 +1,203 @@
+package urlfetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func localFetcher() *Fetcher {
+	return New(Config{AllowPrivateNetworks: true})
+}
+
+func fetchAll(t *testing.T, f *Fetcher, req Request) (*Download, []byte, error) {
+	t.Helper()
+	d, err := f.Open(context.Background(), req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer d.Close()
+	body, err := io.ReadAll(d)
+	return d, body, err
+}
+
+func TestFetchCSV(t *testing.T) {
+	const data = "id,name\n1,alice\n2,bob\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		io.WriteString(w, data)
+	}))
+	defer srv.Close()
+
+	sum := sha256.Sum256([]byte(data))
+	checksum, err := ParseChecksum("sha256:" + hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, body, err := fetchAll(t, localFetcher(), Request{URL: srv.URL + "/exports/users.csv", Checksum: checksum})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if string(body) != data {
+		t.Errorf("body = %q", body)
+	}
+	if d.ContentType != "text/csv" || Extension(d.ContentType) != ".csv" {
+		t.Errorf("content type = %q", d.ContentType)
+	}
+	if d.FileName != "users.csv" {
+		t.Errorf("file name = %q", d.FileName)
+	}
+	if d.BytesRead() != int64(len(data)) {
+		t.Errorf("bytes read = %d", d.BytesRead())
+	}
+}
+
+func TestFetchChecksumMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "id\n1\n")
+	}))
+	defer srv.Close()
+
+	checksum, err := ParseChecksum("md5:" + strings.Repeat("0", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = fetchAll(t, localFetcher(), Request{URL: srv.URL, Checksum: checksum})
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("err = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestFetchSizeLimit(t *testing.T) {
+	body := strings.Repeat("x", 2048)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") != "" {
+			// Flushing before the body is written drops Content-Length.
+			w.(http.Flusher).Flush()
+		}
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	if _, err := localFetcher().Open(context.Background(), Request{URL: srv.URL, MaxBytes: 1024}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("content-length: err = %v, want ErrTooLarge", err)
+	}
+	if _, _, err := fetchAll(t, localFetcher(), Request{URL: srv.URL + "?chunked=1", MaxBytes: 1024}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("chunked: err = %v, want ErrTooLarge", err)
+	}
+	if _, _, err := fetchAll(t, localFetcher(), Request{URL: srv.URL, MaxBytes: 2048}); err != nil {
+		t.Errorf("at the limit: %v", err)
+	}
+}
+
+func TestFetchAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if (ok && user == "partner" && pass == "s3cret") || r.Header.Get("Authorization") == "Bearer tok" {
+			io.WriteString(w, "a\n1\n")
+			return
+		}
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	f := localFetcher()
+	if _, _, err := fetchAll(t, f, Request{URL: srv.URL, Auth: &Auth{Type: AuthBasic, Username: "partner", Password: "s3cret"}}); err != nil {
+		t.Errorf("basic: %v", err)
+	}
+	if _, _, err := fetchAll(t, f, Request{URL: srv.URL, Auth: &Auth{Type: AuthBearer, Token: "tok"}}); err != nil {
+		t.Errorf("bearer: %v", err)
+	}
+	if _, err := f.Open(context.Background(), Request{URL: srv.URL}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("no auth: err = %v, want HTTP 401", err)
+	}
+}
+
+func TestFetchSniffing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/parquet":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", `attachment; filename="orders.parquet"`)
+			io.WriteString(w, "PAR1\x15\x04\x15")
+		case "/gzip":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte{0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00})
+		case "/html":
+			w.Header().Set("Content-Type", "text/csv")
+			io.WriteString(w, "<!DOCTYPE html><html><body>Sign in</body></html>")
+		}
+	}))
+	defer srv.Close()
+
+	f := localFetcher()
+	d, _, err := fetchAll(t, f, Request{URL: srv.URL + "/parquet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Extension(d.ContentType) != ".parquet" || d.FileName != "orders.parquet" {
+		t.Errorf("parquet: content type %q, file name %q", d.ContentType, d.FileName)
+	}
+	if d, _, err = fetchAll(t, f, Request{URL: srv.URL + "/gzip"}); err != nil {
+		t.Fatal(err)
+	}
+	if Extension(d.ContentType) != ".gz" {
+		t.Errorf("gzip: content type %q", d.ContentType)
+	}
+	if _, err := f.Open(context.Background(), Request{URL: srv.URL + "/html"}); !errors.Is(err, ErrHTMLResponse) {
+		t.Errorf("html: err = %v, want ErrHTMLResponse", err)
+	}
+}
+
+func TestFetchBlocksPrivateNetworks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "secret")
+	}))
+	defer srv.Close()
+
+	if _, err := New(Config{}).Open(context.Background(), Request{URL: srv.URL}); !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("err = %v, want ErrBlockedAddress", err)
+	}
+
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0", "::1", "fd00::1", "fe80::1"} {
+		if !isBlocked(net.ParseIP(ip)) {
+			t.Errorf("%s is not blocked", ip)
+		}
+	}
+	for _, ip := range []string{"8.8.8.8", "2606:4700::1111"} {
+		if isBlocked(net.ParseIP(ip)) {
+			t.Errorf("%s is blocked", ip)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	for _, raw := range []string{"https://example.com/a.csv", "http://example.com:8080/x?y=1"} {
+		if _, err := ValidateURL(raw); err != nil {
+			t.Errorf("%s: %v", raw, err)
+		}
+	}
+	for _, raw := range []string{"ftp://example.com/a.csv", "file:///etc/passwd", "/relative", "https://user:pw@example.com/a.csv", "http://"} {
+		if _, err := ValidateURL(raw); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("%s: err = %v, want ErrInvalidURL", raw, err)
+		}
+	}
+}
+
+func TestParseChecksum(t *testing.T) {
+	if _, err := ParseChecksum("SHA256:" + strings.Repeat("AB", 32)); err != nil {
+		t.Errorf("sha256: %v", err)
+	}
+	for _, s := range []string{"sha256", "sha512:" + strings.Repeat("a", 128), "sha1:abc", "md5:" + strings.Repeat("z", 32)} {
+		if _, err := ParseChecksum(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Package xlsx reads Excel workbooks into the value grids understood by
+// gsheets.Build, so uploaded workbooks get the same header resolution and
+// type inference as Google Sheets imports.
+package xlsx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/user/micro-dp/internal/gsheets"
+)
+
+// Sheet is one worksheet of a workbook.
+type Sheet struct {
+	Name string
+	// Values holds one entry per cell: json.Number for numbers, bool for
+	// booleans, an ISO 8601 string for date-formatted numbers and a string
+	// otherwise. Empty cells are nil.
+	Values [][]any
+	Merges []gsheets.Merge
+}
+
+// Limits bounds how much of a workbook is decompressed.
+type Limits struct {
+	// UnzipSizeLimit caps the total uncompressed size of the workbook.
+	UnzipSizeLimit int64
+}
+
+// ReadFile reads every visible worksheet of the workbook at path, in
+// workbook order. Sheets without any values are returned with nil Values.
+func ReadFile(path string, limits Limits) ([]Sheet, error) {
+	opts := excelize.Options{RawCellValue: true}
+	if limits.UnzipSizeLimit > 0 {
+		opts.UnzipSizeLimit = limits.UnzipSizeLimit
+		opts.UnzipXMLSizeLimit = limits.UnzipSizeLimit
+	}
+	f, err := excelize.OpenFile(path, opts)
+	if err != nil {
+		return nil, fmt.Errorf("open workbook: %w", err)
+	}
+	defer f.Close()
+
+	props, err := f.GetWorkbookProps()
+	if err != nil {
+		return nil, fmt.Errorf("read workbook properties: %w", err)
+	}
+	r := &reader{f: f, dateFormats: make(map[int]dateKind)}
+	if props.Date1904 != nil {
+		r.date1904 = *props.Date1904
+	}
+
+	var sheets []Sheet
+	for _, name := range f.GetSheetList() {
+		if visible, err := f.GetSheetVisible(name); err == nil && !visible {
+			continue
+		}
+		sheet, err := r.readSheet(name)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %q: %w", name, err)
+		}
+		sheets = append(sheets, *sheet)
+	}
+	return sheets, nil
+}
+
+type reader struct {
+	f           *excelize.File
+	date1904    bool
+	dateFormats map[int]dateKind
+}
+
+func (r *reader) readSheet(name string) (*Sheet, error) {
+	rows, err := r.f.GetRows(name, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &Sheet{Name: name}
+	for len(rows) > 0 && blank(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	if len(rows) == 0 {
+		return sheet, nil
+	}
+
+	sheet.Values = make([][]any, len(rows))
+	for i, row := range rows {
+		values := make([]any, len(row))
+		for j, raw := range row {
+			if raw == "" {
+				continue
+			}
+			cell, err := excelize.CoordinatesToCellName(j+1, i+1)
+			if err != nil {
+				return nil, err
+			}
+			v, err := r.cellValue(name, cell, raw)
+			if err != nil {
+				return nil, fmt.Errorf("cell %s: %w", cell, err)
+			}
+			values[j] = v
+		}
+		sheet.Values[i] = values
+	}
+
+	merges, err := r.f.GetMergeCells(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range merges {
+		startCol, startRow, err := excelize.CellNameToCoordinates(m.GetStartAxis())
+		if err != nil {
+			return nil, err
+		}
+		endCol, endRow, err := excelize.CellNameToCoordinates(m.GetEndAxis())
+		if err != nil {
+			return nil, err
+		}
+		sheet.Merges = append(sheet.Merges, gsheets.Merge{
+			StartRow:    startRow - 1,
+			EndRow:      endRow,
+			StartColumn: startCol - 1,
+			EndColumn:   endCol,
+		})
+	}
+	return sheet, nil
+}
+
+// cellValue converts the raw value of a non-empty cell.
+func (r *reader) cellValue(sheet, cell, raw string) (any, error) {
+	typ, err := r.f.GetCellType(sheet, cell)
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case excelize.CellTypeBool:
+		return raw == "1" || strings.EqualFold(raw, "true"), nil
+	case excelize.CellTypeSharedString, excelize.CellTypeInlineString,
+		excelize.CellTypeFormula, excelize.CellTypeError:
+		return raw, nil
+	}
+
+	// Numbers (including formula results) are untyped; dates are numbers
+	// with a date number format.
+	n, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return raw, nil
+	}
+	kind, err := r.dateKind(sheet, cell)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case kindDate, kindDateTime:
+		t, err := excelize.ExcelDateToTime(n, r.date1904)
+		if err != nil {
+			return raw, nil
+		}
+		if kind == kindDate && t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+			return t.Format("2006-01-02"), nil
+		}
+		return t.Format("2006-01-02 15:04:05"), nil
+	case kindTime:
+		t, err := excelize.ExcelDateToTime(n, r.date1904)
+		if err != nil {
+			return raw, nil
+		}
+		return t.Format("15:04:05"), nil
+	}
+	return json.Number(raw), nil
+}
+
+type dateKind int
+
+const (
+	kindNone dateKind = iota
+	kindDate
+	kindDateTime
+	kindTime
+)
+
+func (r *reader) dateKind(sheet, cell string) (dateKind, error) {
+	styleID, err := r.f.GetCellStyle(sheet, cell)
+	if err != nil {
+		return kindNone, err
+	}
+	if kind, ok := r.dateFormats[styleID]; ok {
+		return kind, nil
+	}
+	kind := kindNone
+	if style, err := r.f.GetStyle(styleID); err == nil && style != nil {
+		if style.CustomNumFmt != nil {
+			kind = formatKind(*style.CustomNumFmt)
+		} else {
+			kind = builtInFormatKind(style.NumFmt)
+		}
+	}
+	r.dateFormats[styleID] = kind
+	return kind, nil
+}
+
+// builtInFormatKind classifies the built-in number formats (ECMA-376
+// 18.8.30) that render dates and times.
+func builtInFormatKind(id int) dateKind {
+	switch {
+	case id >= 14 && id <= 17, id >= 27 && id <= 31, id >= 34 && id <= 36, id >= 50 && id <= 58:
+		return kindDate
+	case id == 22:
+		return kindDateTime
+	case id >= 18 && id <= 21, id == 32, id == 33, id >= 45 && id <= 47:
+		return kindTime
+	}
+	return kindNone
+}
+
+// formatKind classifies a custom number format code by its date and time
+// tokens, ignoring quoted literals, escaped characters and bracketed
+// sections such as colors and locales.
+func formatKind(code string) dateKind {
+	// Only the first section applies to positive numbers.
+	if i := strings.IndexByte(code, ';'); i >= 0 {
+		code = code[:i]
+	}
+	var hasDate, hasMonth, hasTime bool
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; c {
+		case '"':
+			for i++; i < len(code) && code[i] != '"'; i++ {
+			}
+		case '\\', '_', '*':
+			i++
+		case '[':
+			end := strings.IndexByte(code[i:], ']')
+			if end < 0 {
+				return kindNone
+			}
+			// Elapsed time such as [h]:mm
+			if token := strings.ToLower(code[i+1 : i+end]); token == "h" || token == "hh" || token == "m" || token == "mm" || token == "s" || token == "ss" {
+				hasTime = true
+			}
+			i += end
+		case 'y', 'Y', 'd', 'D':
+			hasDate = true
+		case 'h', 'H', 's', 'S':
+			hasTime = true
+		case 'm', 'M':
+			hasMonth = true
+		}
+	}
+	// "m" means minutes next to hours or seconds and months otherwise.
+	if hasMonth && !hasTime {
+		hasDate = true
+	}
+	switch {
+	case hasDate && hasTime:
+		return kindDateTime
+	case hasDate:
+		return kindDate
+	case hasTime:
+		return kindTime
+	}
+	return kindNone
+}
+
+func blank(row []string) bool {
+	for _, v := range row {
+		if v != "" {
+			return false
+		}
+	}
+	return true
+}
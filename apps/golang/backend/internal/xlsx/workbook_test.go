@@ -0,0 +1,107 @@
+package xlsx
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/user/micro-dp/internal/gsheets"
+)
+
+func TestReadFile(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(f.SetSheetName("Sheet1", "Orders"))
+	must(f.SetSheetRow("Orders", "A1", &[]any{"Region", nil, "Paid", "Ordered", "Note"}))
+	must(f.SetSheetRow("Orders", "A2", &[]any{"north", 12, true, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "first"}))
+	must(f.SetSheetRow("Orders", "A3", &[]any{"south", 7.5, false, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)}))
+	must(f.MergeCell("Orders", "A1", "B1"))
+	dateStyle, err := f.NewStyle(&excelize.Style{NumFmt: 14})
+	must(err)
+	must(f.SetCellStyle("Orders", "D2", "D3", dateStyle))
+
+	_, err = f.NewSheet("Empty")
+	must(err)
+	_, err = f.NewSheet("Hidden")
+	must(err)
+	must(f.SetCellValue("Hidden", "A1", "secret"))
+	must(f.SetSheetVisible("Hidden", false))
+
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+	must(f.SaveAs(path))
+
+	sheets, err := ReadFile(path, Limits{UnzipSizeLimit: 16 << 20})
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if len(sheets) != 2 || sheets[0].Name != "Orders" || sheets[1].Name != "Empty" {
+		t.Fatalf("sheets = %+v", sheets)
+	}
+	if sheets[1].Values != nil {
+		t.Errorf("empty sheet values = %v", sheets[1].Values)
+	}
+
+	want := [][]any{
+		{"Region", nil, "Paid", "Ordered", "Note"},
+		{"north", json.Number("12"), true, "2024-03-01", "first"},
+		{"south", json.Number("7.5"), false, "2024-03-02"},
+	}
+	if !reflect.DeepEqual(sheets[0].Values, want) {
+		t.Errorf("values = %#v\nwant %#v", sheets[0].Values, want)
+	}
+	wantMerges := []gsheets.Merge{{StartRow: 0, EndRow: 1, StartColumn: 0, EndColumn: 2}}
+	if !reflect.DeepEqual(sheets[0].Merges, wantMerges) {
+		t.Errorf("merges = %+v, want %+v", sheets[0].Merges, wantMerges)
+	}
+
+	table, err := gsheets.Build(sheets[0].Values, gsheets.Options{Merges: sheets[0].Merges})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	wantCols := []gsheets.Column{
+		{Name: "Region", Type: gsheets.TypeVarchar},
+		{Name: "Region_2", Type: gsheets.TypeDouble},
+		{Name: "Paid", Type: gsheets.TypeBoolean},
+		{Name: "Ordered", Type: gsheets.TypeDate},
+		{Name: "Note", Type: gsheets.TypeVarchar},
+	}
+	if !reflect.DeepEqual(table.Columns, wantCols) {
+		t.Errorf("columns = %+v, want %+v", table.Columns, wantCols)
+	}
+}
+
+func TestFormatKind(t *testing.T) {
+	tests := []struct {
+		code string
+		want dateKind
+	}{
+		{"General", kindNone},
+		{"#,##0.00", kindNone},
+		{`0.0 "days"`, kindNone},
+		{"[Red]#,##0;[Blue]-#,##0", kindNone},
+		{"yyyy-mm-dd", kindDate},
+		{"d/m/yy", kindDate},
+		{"mmm yyyy", kindDate},
+		{"[$-409]mmmm d, yyyy", kindDate},
+		{"yyyy-mm-dd hh:mm:ss", kindDateTime},
+		{"h:mm AM/PM", kindTime},
+		{"[h]:mm", kindTime},
+		{"mm:ss", kindTime},
+	}
+	for _, tt := range tests {
+		if got := formatKind(tt.code); got != tt.want {
+			t.Errorf("formatKind(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
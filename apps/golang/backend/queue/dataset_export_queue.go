@@ -0,0 +1,84 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/user/micro-dp/domain"
+)
+
+const (
+	exportPrefix      = "micro-dp:dataset-exports:"
+	exportIngestKey   = exportPrefix + "ingest"
+	exportDLQKey      = exportPrefix + "dlq"
+	exportSeenPrefix  = exportPrefix + "seen:"
+	exportSeenTTL     = 24 * time.Hour
+	exportDequeueWait = 5 * time.Second
+)
+
+type DatasetExportQueueImpl struct {
+	rdb *redis.Client
+}
+
+func NewDatasetExportQueue(client *ValkeyClient) *DatasetExportQueueImpl {
+	return &DatasetExportQueueImpl{rdb: client.Client()}
+}
+
+func (q *DatasetExportQueueImpl) Enqueue(ctx context.Context, msg *domain.DatasetExportMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal dataset export: %w", err)
+	}
+	return q.rdb.LPush(ctx, exportIngestKey, data).Err()
+}
+
+func (q *DatasetExportQueueImpl) Dequeue(ctx context.Context) (*domain.DatasetExportMessage, error) {
+	result, err := q.rdb.BRPop(ctx, exportDequeueWait, exportIngestKey).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("dequeue dataset export: %w", err)
+	}
+
+	var msg domain.DatasetExportMessage
+	if err := json.Unmarshal([]byte(result[1]), &msg); err != nil {
+		return nil, fmt.Errorf("unmarshal dataset export: %w", err)
+	}
+	return &msg, nil
+}
+
+func (q *DatasetExportQueueImpl) MarkProcessed(ctx context.Context, exportID string) error {
+	key := exportSeenPrefix + exportID
+	ok, err := q.rdb.SetArgs(ctx, key, "1", redis.SetArgs{
+		Mode: "NX",
+		TTL:  exportSeenTTL,
+	}).Result()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("mark dataset export processed: %w", err)
+	}
+	if ok != "OK" {
+		return domain.ErrDatasetExportAlreadyProcessed
+	}
+	return nil
+}
+
+func (q *DatasetExportQueueImpl) EnqueueDLQ(ctx context.Context, msg *domain.DatasetExportMessage, reason string) error {
+	wrapper := struct {
+		Export *domain.DatasetExportMessage `json:"export"`
+		Reason string                       `json:"reason"`
+		Time   time.Time                    `json:"time"`
+	}{
+		Export: msg,
+		Reason: reason,
+		Time:   time.Now().UTC(),
+	}
+	data, err := json.Marshal(wrapper)
+	if err != nil {
+		return fmt.Errorf("marshal dataset export dlq: %w", err)
+	}
+	return q.rdb.LPush(ctx, exportDLQKey, data).Err()
+}
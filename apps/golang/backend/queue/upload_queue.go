@@ -51,8 +51,8 @@ func (q *UploadQueueImpl) Dequeue(ctx context.Context) (*domain.UploadJobMessage
 	return &msg, nil
 }
 
-func (q *UploadQueueImpl) MarkProcessed(ctx context.Context, uploadID string) error {
-	key := uploadSeenPrefix + uploadID
+func (q *UploadQueueImpl) MarkProcessed(ctx context.Context, jobID string) error {
+	key := uploadSeenPrefix + jobID
 	ok, err := q.rdb.SetArgs(ctx, key, "1", redis.SetArgs{
 		Mode: "NX",
 		TTL:  uploadSeenTTL,
@@ -4,8 +4,26 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+
+	_ "github.com/marcboeker/go-duckdb"
+	"github.com/user/micro-dp/internal/duckdbsandbox"
 )
 
+// tenantDataRoots are the roots under which a tenant's datasets and exports
+// are stored, each followed by the tenant ID.
+var tenantDataRoots = []string{
+	"events",
+	"aggregated/events",
+	"aggregated/visits",
+	"transforms",
+	"imports",
+	"sheets_imports",
+	"postgres_cdc",
+	"datasets",
+	"exports",
+}
+
 // ConfigureDuckDBHTTPFS loads the httpfs extension and configures S3 credentials for DuckDB.
 func ConfigureDuckDBHTTPFS(ctx context.Context, db *sql.DB, cfg S3Config) error {
 	stmts := []string{
@@ -26,7 +44,87 @@ func ConfigureDuckDBHTTPFS(ctx context.Context, db *sql.DB, cfg S3Config) error
 	return nil
 }
 
-// S3ParquetURI builds an s3:// URI from a bucket name and object key.
+// TenantPrefixes returns the object prefixes holding the tenant's datasets
+// and exports.
+func TenantPrefixes(tenantID string) []string {
+	prefixes := make([]string, len(tenantDataRoots))
+	for i, root := range tenantDataRoots {
+		prefixes[i] = root + "/" + tenantID
+	}
+	return prefixes
+}
+
+// ConfigureDuckDBTenantS3 loads the httpfs extension and gives DuckDB S3
+// credentials scoped to the tenant's dataset prefixes, for sessions that run
+// user SQL. Unlike ConfigureDuckDBHTTPFS it sets no global credentials, so
+// objects of other tenants are not readable and the keys cannot be read
+// back with current_setting.
+func ConfigureDuckDBTenantS3(ctx context.Context, db *sql.DB, cfg S3Config, tenantID string) error {
+	for _, stmt := range []string{"INSTALL httpfs", "LOAD httpfs"} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("duckdb httpfs setup (%s): %w", stmt, err)
+		}
+	}
+	creds := duckdbsandbox.S3Credentials{
+		Endpoint:        cfg.Endpoint,
+		Region:          cfg.Region,
+		AccessKeyID:     cfg.AccessKey,
+		SecretAccessKey: cfg.SecretKey,
+		UseSSL:          cfg.Secure,
+	}
+	return duckdbsandbox.ScopeS3(ctx, db, creds, cfg.Bucket, TenantPrefixes(tenantID))
+}
+
+// S3ParquetURI builds an s3:// URI from a bucket name and a dataset storage
+// path. A path that is not a .parquet object is a prefix of Parquet parts and
+// becomes a glob over them.
 func S3ParquetURI(bucket, objectKey string) string {
+	if !IsParquetObject(objectKey) {
+		return fmt.Sprintf("s3://%s/%s/**/*.parquet", bucket, strings.TrimSuffix(objectKey, "/"))
+	}
 	return fmt.Sprintf("s3://%s/%s", bucket, objectKey)
 }
+
+// IsParquetObject reports whether a dataset storage path names a single
+// Parquet object rather than a prefix of parts.
+func IsParquetObject(storagePath string) bool {
+	return strings.HasSuffix(storagePath, ".parquet")
+}
+
+// S3ParquetSource returns the quoted read_parquet argument for a dataset
+// storage path. When keys lists the parts of one version of a prefix, it is
+// a list of those objects instead of a glob over the prefix.
+func S3ParquetSource(bucket, storagePath string, keys []string) string {
+	if len(keys) == 0 {
+		return "'" + S3ParquetURI(bucket, storagePath) + "'"
+	}
+	uris := make([]string, len(keys))
+	for i, k := range keys {
+		uris[i] = fmt.Sprintf("'s3://%s/%s'", bucket, k)
+	}
+	return "[" + strings.Join(uris, ", ") + "]"
+}
+
+// CountParquetRows counts the rows of the Parquet objects under prefix from
+// their footers, in a DuckDB session scoped to the tenant's prefixes. It is
+// 0 when the prefix holds no Parquet objects.
+func (m *MinIOClient) CountParquetRows(ctx context.Context, tenantID, prefix string) (int64, error) {
+	keys, err := m.DatasetObjectKeys(ctx, prefix)
+	if err != nil || len(keys) == 0 {
+		return 0, err
+	}
+	duckDB, err := sql.Open("duckdb", "")
+	if err != nil {
+		return 0, fmt.Errorf("open duckdb: %w", err)
+	}
+	defer duckDB.Close()
+	if err := ConfigureDuckDBTenantS3(ctx, duckDB, m.S3Config(), tenantID); err != nil {
+		return 0, err
+	}
+	var n int64
+	err = duckDB.QueryRowContext(ctx, fmt.Sprintf(
+		`SELECT COALESCE(SUM(num_rows), 0) FROM parquet_file_metadata(%s)`,
+		S3ParquetSource(m.bucket, prefix, keys),
+	)).Scan(&n)
+	return n, err
+}
@@ -3,16 +3,26 @@ package storage
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/user/micro-dp/domain"
 )
 
+// ErrObjectNotFound is returned when a downloaded object does not exist.
+var ErrObjectNotFound = errors.New("object not found")
+
 type MinIOClient struct {
 	client    *minio.Client
 	bucket    string
@@ -98,8 +108,10 @@ func NewMinIOClient() (*MinIOClient, error) {
 
 // MinIOPresignClient generates presigned URLs for browser-direct uploads.
 // Uses MINIO_PRESIGN_ENDPOINT for signature host matching when set.
+// Multipart upload calls made by the server itself go to MINIO_ENDPOINT.
 type MinIOPresignClient struct {
 	client *minio.Client
+	core   *minio.Core
 	bucket string
 }
 
@@ -141,8 +153,26 @@ func NewMinIOPresignClient() (*MinIOPresignClient, error) {
 		return nil, fmt.Errorf("minio presign client: %w", err)
 	}
 
+	apiEndpoint := os.Getenv("MINIO_ENDPOINT")
+	if apiEndpoint == "" {
+		apiEndpoint = endpoint
+	}
+	apiHost, apiSecure, err := parseEndpoint(apiEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("minio endpoint: %w", err)
+	}
+	core, err := minio.NewCore(apiHost, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: apiSecure,
+		Region: region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("minio multipart client: %w", err)
+	}
+
 	return &MinIOPresignClient{
 		client: client,
+		core:   core,
 		bucket: bucket,
 	}, nil
 }
@@ -157,8 +187,83 @@ func (m *MinIOPresignClient) GeneratePresignedPutURL(ctx context.Context, object
 	return presignedURL.String(), expiresAt, nil
 }
 
+// GeneratePresignedGetURL returns a download URL for an object that saves
+// it as fileName.
+func (m *MinIOPresignClient) GeneratePresignedGetURL(ctx context.Context, objectKey, fileName string, expiry time.Duration) (string, time.Time, error) {
+	params := url.Values{}
+	params.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	presignedURL, err := m.client.PresignedGetObject(ctx, m.bucket, objectKey, expiry, params)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("presigned get: %w", err)
+	}
+
+	expiresAt := time.Now().Add(expiry)
+	return presignedURL.String(), expiresAt, nil
+}
+
+func (m *MinIOPresignClient) InitiateMultipartUpload(ctx context.Context, objectKey, contentType string) (string, error) {
+	uploadID, err := m.core.NewMultipartUpload(ctx, m.bucket, objectKey, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return "", fmt.Errorf("initiate multipart upload: %w", err)
+	}
+	return uploadID, nil
+}
+
+// PresignUploadPartURL returns a URL the client PUTs one part to. The ETag
+// response header of that PUT identifies the stored part.
+func (m *MinIOPresignClient) PresignUploadPartURL(ctx context.Context, objectKey, uploadID string, partNumber int, expiry time.Duration) (string, time.Time, error) {
+	params := url.Values{}
+	params.Set("partNumber", strconv.Itoa(partNumber))
+	params.Set("uploadId", uploadID)
+	presignedURL, err := m.client.Presign(ctx, http.MethodPut, m.bucket, objectKey, expiry, params)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("presign upload part: %w", err)
+	}
+	return presignedURL.String(), time.Now().Add(expiry), nil
+}
+
+// ListUploadedParts returns the parts stored so far, by part number.
+func (m *MinIOPresignClient) ListUploadedParts(ctx context.Context, objectKey, uploadID string) ([]domain.UploadedPart, error) {
+	var parts []domain.UploadedPart
+	marker := 0
+	for {
+		result, err := m.core.ListObjectParts(ctx, m.bucket, objectKey, uploadID, marker, 1000)
+		if err != nil {
+			return nil, fmt.Errorf("list upload parts: %w", err)
+		}
+		for _, p := range result.ObjectParts {
+			parts = append(parts, domain.UploadedPart{PartNumber: p.PartNumber, ETag: p.ETag, SizeBytes: p.Size})
+		}
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+func (m *MinIOPresignClient) CompleteMultipartUpload(ctx context.Context, objectKey, uploadID string, parts []domain.UploadedPart) error {
+	complete := make([]minio.CompletePart, len(parts))
+	for i, p := range parts {
+		complete[i] = minio.CompletePart{PartNumber: p.PartNumber, ETag: p.ETag}
+	}
+	if _, err := m.core.CompleteMultipartUpload(ctx, m.bucket, objectKey, uploadID, complete, minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+func (m *MinIOPresignClient) AbortMultipartUpload(ctx context.Context, objectKey, uploadID string) error {
+	if err := m.core.AbortMultipartUpload(ctx, m.bucket, objectKey, uploadID); err != nil {
+		return fmt.Errorf("abort multipart upload: %w", err)
+	}
+	return nil
+}
+
 func (m *MinIOClient) DownloadToFile(ctx context.Context, objectKey, destPath string) error {
 	if err := m.client.FGetObject(ctx, m.bucket, objectKey, destPath, minio.GetObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return fmt.Errorf("download to file: %w: %s", ErrObjectNotFound, objectKey)
+		}
 		return fmt.Errorf("download to file: %w", err)
 	}
 	return nil
@@ -197,6 +302,61 @@ func (m *MinIOClient) ListPrefixes(ctx context.Context, prefix string) ([]string
 	return prefixes, nil
 }
 
+// DatasetObjectKeys returns the Parquet objects of a dataset storage path:
+// the path itself for a single object, or every .parquet object under it.
+func (m *MinIOClient) DatasetObjectKeys(ctx context.Context, storagePath string) ([]string, error) {
+	if IsParquetObject(storagePath) {
+		return []string{storagePath}, nil
+	}
+	keys, err := m.ListObjectKeys(ctx, strings.TrimSuffix(storagePath, "/")+"/")
+	if err != nil {
+		return nil, err
+	}
+	var parquetKeys []string
+	for _, k := range keys {
+		if IsParquetObject(k) {
+			parquetKeys = append(parquetKeys, k)
+		}
+	}
+	return parquetKeys, nil
+}
+
+// DownloadDataset downloads the Parquet objects of a dataset storage path
+// into destDir and returns a read_parquet glob over the local files.
+func (m *MinIOClient) DownloadDataset(ctx context.Context, storagePath, destDir string) (string, error) {
+	keys, err := m.DatasetObjectKeys(ctx, storagePath)
+	if err != nil {
+		return "", err
+	}
+	if len(keys) == 0 {
+		return "", fmt.Errorf("download dataset: %w: %s", ErrObjectNotFound, storagePath)
+	}
+	return m.DownloadObjects(ctx, keys, destDir)
+}
+
+// DownloadObjects downloads Parquet objects into destDir and returns a
+// read_parquet glob over the local files.
+func (m *MinIOClient) DownloadObjects(ctx context.Context, keys []string, destDir string) (string, error) {
+	for i, key := range keys {
+		if err := m.DownloadToFile(ctx, key, filepath.Join(destDir, fmt.Sprintf("part_%d.parquet", i))); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(destDir, "part_*.parquet"), nil
+}
+
+// CopyObject copies an object within the bucket.
+func (m *MinIOClient) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	_, err := m.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: m.bucket, Object: dstKey},
+		minio.CopySrcOptions{Bucket: m.bucket, Object: srcKey},
+	)
+	if err != nil {
+		return fmt.Errorf("copy object: %w", err)
+	}
+	return nil
+}
+
 func (m *MinIOClient) PutParquet(ctx context.Context, objectKey string, data []byte) error {
 	reader := bytes.NewReader(data)
 	_, err := m.client.PutObject(ctx, m.bucket, objectKey, reader, int64(len(data)), minio.PutObjectOptions{
@@ -207,3 +367,35 @@ func (m *MinIOClient) PutParquet(ctx context.Context, objectKey string, data []b
 	}
 	return nil
 }
+
+// PutStream uploads r as objectKey. A size of -1 streams an unknown length
+// in multipart chunks.
+func (m *MinIOClient) PutStream(ctx context.Context, objectKey string, r io.Reader, size int64, contentType string) (int64, error) {
+	info, err := m.client.PutObject(ctx, m.bucket, objectKey, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("put object: %w", err)
+	}
+	return info.Size, nil
+}
+
+// ObjectSize returns the size of an object, or ErrObjectNotFound.
+func (m *MinIOClient) ObjectSize(ctx context.Context, objectKey string) (int64, error) {
+	info, err := m.client.StatObject(ctx, m.bucket, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return 0, fmt.Errorf("stat object: %w: %s", ErrObjectNotFound, objectKey)
+		}
+		return 0, fmt.Errorf("stat object: %w", err)
+	}
+	return info.Size, nil
+}
+
+// RemoveObject deletes an object; a missing object is not an error.
+func (m *MinIOClient) RemoveObject(ctx context.Context, objectKey string) error {
+	if err := m.client.RemoveObject(ctx, m.bucket, objectKey, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("remove object: %w", err)
+	}
+	return nil
+}
@@ -14,17 +14,21 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/datasetquery"
 	"github.com/user/micro-dp/storage"
 )
 
 type ChartService struct {
 	charts   domain.ChartRepository
 	datasets domain.DatasetRepository
+	lineage  domain.LineageRepository
 	minio    *storage.MinIOClient
+	masking  *DatasetMaskingService
+	catalog  *DataCatalogService
 }
 
-func NewChartService(charts domain.ChartRepository, datasets domain.DatasetRepository, minio *storage.MinIOClient) *ChartService {
-	return &ChartService{charts: charts, datasets: datasets, minio: minio}
+func NewChartService(charts domain.ChartRepository, datasets domain.DatasetRepository, lineage domain.LineageRepository, minio *storage.MinIOClient, masking *DatasetMaskingService, catalog *DataCatalogService) *ChartService {
+	return &ChartService{charts: charts, datasets: datasets, lineage: lineage, minio: minio, masking: masking, catalog: catalog}
 }
 
 type ChartDataResult struct {
@@ -61,6 +65,9 @@ func (s *ChartService) Create(ctx context.Context, name, chartType, datasetID, m
 	if err := s.charts.Create(ctx, c); err != nil {
 		return nil, err
 	}
+	if err := s.recordLineage(ctx, c); err != nil {
+		return nil, err
+	}
 	return s.charts.FindByID(ctx, tenantID, c.ID)
 }
 
@@ -108,6 +115,9 @@ func (s *ChartService) Update(ctx context.Context, id, name, chartType, datasetI
 	if err := s.charts.Update(ctx, c); err != nil {
 		return nil, err
 	}
+	if err := s.recordLineage(ctx, c); err != nil {
+		return nil, err
+	}
 	return s.charts.FindByID(ctx, tenantID, id)
 }
 
@@ -120,7 +130,27 @@ func (s *ChartService) Delete(ctx context.Context, id string) error {
 	if _, err := s.charts.FindByID(ctx, tenantID, id); err != nil {
 		return err
 	}
-	return s.charts.Delete(ctx, tenantID, id)
+	if err := s.charts.Delete(ctx, tenantID, id); err != nil {
+		return err
+	}
+	if err := s.lineage.DeleteNode(ctx, tenantID, domain.LineageNode{Type: domain.LineageNodeChart, ID: id}); err != nil {
+		return fmt.Errorf("delete chart lineage: %w", err)
+	}
+	return nil
+}
+
+// recordLineage makes the chart's dataset its only lineage source.
+func (s *ChartService) recordLineage(ctx context.Context, c *domain.Chart) error {
+	chart := domain.LineageNode{Type: domain.LineageNodeChart, ID: c.ID}
+	edge := domain.LineageEdge{
+		TenantID: c.TenantID,
+		Source:   domain.LineageNode{Type: domain.LineageNodeDataset, ID: c.DatasetID},
+		Target:   chart,
+	}
+	if err := s.lineage.ReplaceSources(ctx, c.TenantID, chart, []domain.LineageEdge{edge}); err != nil {
+		return fmt.Errorf("record chart lineage: %w", err)
+	}
+	return nil
 }
 
 func (s *ChartService) GetData(ctx context.Context, chartID, period string, startDate, endDate *time.Time) (*ChartDataResult, error) {
@@ -142,23 +172,9 @@ func (s *ChartService) GetData(ctx context.Context, chartID, period string, star
 	// Resolve parquet files from MinIO.
 	// StoragePath may be a single file (e.g. imports/.../xxx.parquet)
 	// or a directory prefix.
-	var parquetKeys []string
-	if strings.HasSuffix(dataset.StoragePath, ".parquet") {
-		parquetKeys = []string{dataset.StoragePath}
-	} else {
-		prefix := dataset.StoragePath
-		if !strings.HasSuffix(prefix, "/") {
-			prefix += "/"
-		}
-		keys, err := s.minio.ListObjectKeys(ctx, prefix)
-		if err != nil {
-			return nil, fmt.Errorf("list parquet files: %w", err)
-		}
-		for _, k := range keys {
-			if strings.HasSuffix(k, ".parquet") {
-				parquetKeys = append(parquetKeys, k)
-			}
-		}
+	parquetKeys, err := s.minio.DatasetObjectKeys(ctx, dataset.StoragePath)
+	if err != nil {
+		return nil, fmt.Errorf("list parquet files: %w", err)
 	}
 
 	if len(parquetKeys) == 0 {
@@ -189,10 +205,26 @@ func (s *ChartService) GetData(ctx context.Context, chartID, period string, star
 	glob := filepath.Join(tmpDir, "*.parquet")
 	dimension := quoteIdent(chart.Dimension)
 	measure := quoteIdent(chart.Measure)
+	from := fmt.Sprintf("read_parquet('%s', union_by_name=true)", glob)
+
+	// Masked columns are grouped by their masked form; a masked measure
+	// has no values to sum
+	masks, err := s.masking.Masks(ctx, dataset.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(masks) > 0 {
+		if from, err = datasetquery.From(ctx, ddb, datasetquery.QuoteLiteral(glob), masks); err != nil {
+			return nil, fmt.Errorf("mask chart data: %w", err)
+		}
+		if _, ok := masks[chart.Measure]; ok {
+			measure = "NULL"
+		}
+	}
 
 	baseQuery := fmt.Sprintf(
-		`SELECT COALESCE(CAST(%s AS VARCHAR), '') AS label, SUM(CAST(%s AS DOUBLE)) AS value FROM read_parquet('%s', union_by_name=true)`,
-		dimension, measure, glob,
+		`SELECT COALESCE(CAST(%s AS VARCHAR), '') AS label, SUM(CAST(%s AS DOUBLE)) AS value FROM %s`,
+		dimension, measure, from,
 	)
 	groupOrder := " GROUP BY label ORDER BY label"
 
@@ -231,6 +263,7 @@ func (s *ChartService) GetData(ctx context.Context, chartID, period string, star
 	if data == nil {
 		data = []float32{}
 	}
+	s.catalog.RecordUsage(ctx, domain.DatasetUsageChart, chart.ID, dataset.ID)
 
 	return &ChartDataResult{
 		Labels: labels,
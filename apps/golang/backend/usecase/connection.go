@@ -2,20 +2,25 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
 
 	"github.com/google/uuid"
 	"github.com/user/micro-dp/domain"
 	"github.com/user/micro-dp/internal/connector"
+	"github.com/user/micro-dp/internal/secret"
 )
 
 type ConnectionService struct {
 	connections domain.ConnectionRepository
 	registry    *connector.Registry
+	keyring     *secret.Keyring
+	refs        *secret.RefResolver
 }
 
-func NewConnectionService(connections domain.ConnectionRepository, registry *connector.Registry) *ConnectionService {
-	return &ConnectionService{connections: connections, registry: registry}
+func NewConnectionService(connections domain.ConnectionRepository, registry *connector.Registry, keyring *secret.Keyring, refs *secret.RefResolver) *ConnectionService {
+	return &ConnectionService{connections: connections, registry: registry, keyring: keyring, refs: refs}
 }
 
 func (s *ConnectionService) Create(ctx context.Context, name, connType, configJSON string, secretRef, credentialID *string) (*domain.Connection, error) {
@@ -24,7 +29,16 @@ func (s *ConnectionService) Create(ctx context.Context, name, connType, configJS
 		return nil, fmt.Errorf("tenant id not found in context")
 	}
 
-	if err := s.validateConnector(connType, configJSON); err != nil {
+	if err := s.validateConnector(tenantID, connType, configJSON, secretRef); err != nil {
+		return nil, err
+	}
+
+	env, err := s.keyring.NewEnvelope()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.sealConfig(env, connType, configJSON)
+	if err != nil {
 		return nil, err
 	}
 
@@ -33,9 +47,10 @@ func (s *ConnectionService) Create(ctx context.Context, name, connType, configJS
 		TenantID:     tenantID,
 		Name:         name,
 		Type:         connType,
-		ConfigJSON:   configJSON,
+		ConfigJSON:   sealed,
 		SecretRef:    secretRef,
 		CredentialID: credentialID,
+		DataKey:      env.WrappedKey,
 	}
 
 	if err := s.connections.Create(ctx, c); err != nil {
@@ -72,15 +87,30 @@ func (s *ConnectionService) Update(ctx context.Context, id, name, connType, conf
 		return nil, err
 	}
 
-	if err := s.validateConnector(connType, configJSON); err != nil {
+	configJSON, err = s.keepExistingSecrets(c, connType, configJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateConnector(tenantID, connType, configJSON, secretRef); err != nil {
+		return nil, err
+	}
+
+	env, err := s.envelopeFor(c)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.sealConfig(env, connType, configJSON)
+	if err != nil {
 		return nil, err
 	}
 
 	c.Name = name
 	c.Type = connType
-	c.ConfigJSON = configJSON
+	c.ConfigJSON = sealed
 	c.SecretRef = secretRef
 	c.CredentialID = credentialID
+	c.DataKey = env.WrappedKey
 
 	if err := s.connections.Update(ctx, c); err != nil {
 		return nil, err
@@ -103,12 +133,314 @@ func (s *ConnectionService) Delete(ctx context.Context, id string) error {
 	return s.connections.Delete(ctx, tenantID, id)
 }
 
-func (s *ConnectionService) validateConnector(connType, configJSON string) error {
+// ResolveConfig returns the connection's config in plain text: secret
+// fields are decrypted and values referenced by secret_ref are merged in.
+// The result must never be returned to API clients.
+func (s *ConnectionService) ResolveConfig(ctx context.Context, c *domain.Connection) (string, error) {
+	configJSON, err := s.openConfig(c)
+	if err != nil {
+		return "", err
+	}
+	return s.mergeSecretRef(c.TenantID, configJSON, c.SecretRef)
+}
+
+// ResolveTestConfig prepares a config submitted for a connectivity test.
+// When connectionID refers to a stored connection, secret fields that were
+// omitted or left redacted are filled in from it, so that an edit form can
+// be tested without re-entering passwords. The stored secrets, and those
+// behind its secret_ref, are only used while every other field matches the
+// stored config; otherwise ErrConnectionSecretRequired is returned.
+func (s *ConnectionService) ResolveTestConfig(ctx context.Context, connectionID, connType, configJSON string) (string, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("tenant id not found in context")
+	}
+	if connectionID == "" {
+		return configJSON, nil
+	}
+	c, err := s.connections.FindByID(ctx, tenantID, connectionID)
+	if err != nil {
+		return "", err
+	}
+	configJSON, err = s.keepExistingSecrets(c, connType, configJSON)
+	if err != nil {
+		return "", err
+	}
+	if c.SecretRef != nil && *c.SecretRef != "" {
+		if err := s.checkSameSettings(c, connType, configJSON); err != nil {
+			return "", err
+		}
+	}
+	return s.mergeSecretRef(tenantID, configJSON, c.SecretRef)
+}
+
+// RedactConfig hides the secret fields of a stored config for API responses.
+func (s *ConnectionService) RedactConfig(c *domain.Connection) string {
+	def := s.registry.Get(c.Type)
+	if def == nil {
+		return c.ConfigJSON
+	}
+	return def.RedactConfig(c.ConfigJSON)
+}
+
+// Reencrypt seals secret fields of connections stored before encryption
+// was enabled and re-wraps data keys that are not under the active master
+// key. It returns the number of rows updated.
+func (s *ConnectionService) Reencrypt(ctx context.Context) (int, error) {
+	if !s.keyring.Enabled() {
+		return 0, secret.ErrNoMasterKey
+	}
+	conns, err := s.connections.ListAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for i := range conns {
+		c := &conns[i]
+		if c.DataKey == "" {
+			env, err := s.keyring.NewEnvelope()
+			if err != nil {
+				return updated, err
+			}
+			sealed, err := s.sealConfig(env, c.Type, c.ConfigJSON)
+			if err != nil {
+				return updated, fmt.Errorf("seal connection %s: %w", c.ID, err)
+			}
+			if sealed == c.ConfigJSON {
+				continue
+			}
+			c.ConfigJSON = sealed
+			c.DataKey = env.WrappedKey
+		} else {
+			wrapped, changed, err := s.keyring.Rewrap(c.DataKey)
+			if err != nil {
+				return updated, fmt.Errorf("rewrap connection %s: %w", c.ID, err)
+			}
+			if !changed {
+				continue
+			}
+			c.DataKey = wrapped
+		}
+		if err := s.connections.Update(ctx, c); err != nil {
+			return updated, fmt.Errorf("update connection %s: %w", c.ID, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+func (s *ConnectionService) validateConnector(tenantID, connType, configJSON string, secretRef *string) error {
 	if !s.registry.Exists(connType) {
 		return domain.ErrConnectorTypeUnknown
 	}
-	if err := s.registry.ValidateConfig(connType, configJSON); err != nil {
+	// Required secrets may live behind secret_ref, so validate the merged view.
+	merged, err := s.mergeSecretRef(tenantID, configJSON, secretRef)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrConnectionConfigInvalid, err)
+	}
+	if err := s.registry.ValidateConfig(connType, merged); err != nil {
 		return fmt.Errorf("%w: %v", domain.ErrConnectionConfigInvalid, err)
 	}
 	return nil
 }
+
+func (s *ConnectionService) envelopeFor(c *domain.Connection) (*secret.Envelope, error) {
+	if c.DataKey == "" {
+		return s.keyring.NewEnvelope()
+	}
+	return s.keyring.OpenEnvelope(c.DataKey)
+}
+
+func (s *ConnectionService) secretFields(connType string) []string {
+	def := s.registry.Get(connType)
+	if def == nil {
+		return nil
+	}
+	return def.SecretFields()
+}
+
+// sealConfig encrypts the x-secret fields of a plain-text config. Each value
+// is JSON-encoded before sealing so non-string secrets round-trip.
+func (s *ConnectionService) sealConfig(env *secret.Envelope, connType, configJSON string) (string, error) {
+	fields := s.secretFields(connType)
+	if len(fields) == 0 || env.WrappedKey == "" {
+		return configJSON, nil
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrConnectionConfigInvalid, err)
+	}
+	for _, f := range fields {
+		v, ok := cfg[f]
+		if !ok || v == nil {
+			continue
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		sealed, err := env.Seal(string(raw))
+		if err != nil {
+			return "", err
+		}
+		cfg[f] = sealed
+	}
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// openConfig decrypts the sealed fields of a stored config. Values written
+// before encryption was enabled are returned as-is.
+func (s *ConnectionService) openConfig(c *domain.Connection) (string, error) {
+	if c.DataKey == "" {
+		return c.ConfigJSON, nil
+	}
+	env, err := s.keyring.OpenEnvelope(c.DataKey)
+	if err != nil {
+		return "", err
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(c.ConfigJSON), &cfg); err != nil {
+		return "", err
+	}
+	for k, v := range cfg {
+		str, ok := v.(string)
+		if !ok || !secret.IsSealed(str) {
+			continue
+		}
+		plain, err := env.Open(str)
+		if err != nil {
+			return "", fmt.Errorf("decrypt %s: %w", k, err)
+		}
+		var decoded any
+		if err := json.Unmarshal([]byte(plain), &decoded); err != nil {
+			return "", fmt.Errorf("decrypt %s: %w", k, err)
+		}
+		cfg[k] = decoded
+	}
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// keepExistingSecrets implements "keep existing value" semantics: secret
+// fields that are absent from configJSON or still hold RedactedValue take
+// the stored value of connection c. A stored secret is only kept while the
+// other fields match the stored config, so that it cannot be sent to a
+// different host.
+func (s *ConnectionService) keepExistingSecrets(c *domain.Connection, connType, configJSON string) (string, error) {
+	fields := s.secretFields(connType)
+	if len(fields) == 0 || c.Type != connType {
+		return configJSON, nil
+	}
+	cfg, existing, err := s.configs(c, configJSON)
+	if err != nil {
+		return "", err
+	}
+	kept := false
+	for _, f := range fields {
+		v, ok := cfg[f]
+		if ok && v != connector.RedactedValue {
+			continue
+		}
+		if old, found := existing[f]; found {
+			cfg[f] = old
+			kept = true
+		} else {
+			delete(cfg, f)
+		}
+	}
+	if kept {
+		if err := sameSettings(cfg, existing, fields); err != nil {
+			return "", err
+		}
+	}
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// checkSameSettings returns ErrConnectionSecretRequired unless configJSON
+// is for the type of connection c and its fields other than secrets match
+// the stored config.
+func (s *ConnectionService) checkSameSettings(c *domain.Connection, connType, configJSON string) error {
+	if c.Type != connType {
+		return fmt.Errorf("%w: type changed", domain.ErrConnectionSecretRequired)
+	}
+	cfg, existing, err := s.configs(c, configJSON)
+	if err != nil {
+		return err
+	}
+	return sameSettings(cfg, existing, s.secretFields(connType))
+}
+
+// configs decodes a submitted config and the decrypted stored config of c.
+func (s *ConnectionService) configs(c *domain.Connection, configJSON string) (map[string]any, map[string]any, error) {
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", domain.ErrConnectionConfigInvalid, err)
+	}
+	if cfg == nil {
+		cfg = map[string]any{}
+	}
+	stored, err := s.openConfig(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	var existing map[string]any
+	if err := json.Unmarshal([]byte(stored), &existing); err != nil || existing == nil {
+		existing = map[string]any{}
+	}
+	return cfg, existing, nil
+}
+
+// sameSettings returns ErrConnectionSecretRequired when a field other than
+// the secret fields differs between cfg and the stored config.
+func sameSettings(cfg, existing map[string]any, secretFields []string) error {
+	secrets := make(map[string]bool, len(secretFields))
+	for _, f := range secretFields {
+		secrets[f] = true
+	}
+	for _, m := range []map[string]any{cfg, existing} {
+		for k := range m {
+			if !secrets[k] && !reflect.DeepEqual(cfg[k], existing[k]) {
+				return fmt.Errorf("%w: %s changed", domain.ErrConnectionSecretRequired, k)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeSecretRef overlays the values stored behind secretRef onto configJSON.
+func (s *ConnectionService) mergeSecretRef(tenantID, configJSON string, secretRef *string) (string, error) {
+	if secretRef == nil || *secretRef == "" {
+		return configJSON, nil
+	}
+	values, err := s.refs.Resolve(tenantID, *secretRef)
+	if err != nil {
+		return "", err
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+		return "", err
+	}
+	if cfg == nil {
+		cfg = map[string]any{}
+	}
+	for k, v := range values {
+		cfg[k] = v
+	}
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
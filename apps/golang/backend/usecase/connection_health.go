@@ -0,0 +1,265 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/connector"
+	"github.com/user/micro-dp/internal/notification"
+)
+
+// ConnectionHealthConfig holds periodic connection health check settings.
+type ConnectionHealthConfig struct {
+	Interval  time.Duration // how often every connection is tested (0 disables)
+	Timeout   time.Duration // per-connection test timeout
+	Retention time.Duration // history older than this is pruned
+	// SlotLagLimit fails a Postgres connection whose unused replication
+	// slot retains more WAL than this many bytes (0 disables).
+	SlotLagLimit int64
+}
+
+// LoadConnectionHealthConfig reads health check settings from environment variables.
+func LoadConnectionHealthConfig() ConnectionHealthConfig {
+	return ConnectionHealthConfig{
+		Interval:  envDuration("CONNECTION_HEALTH_INTERVAL", 15*time.Minute),
+		Timeout:   envDuration("CONNECTION_HEALTH_TIMEOUT", 30*time.Second),
+		Retention: envDuration("CONNECTION_HEALTH_RETENTION", 30*24*time.Hour),
+
+		SlotLagLimit: int64(envInt("CONNECTION_HEALTH_SLOT_LAG_MB", 1024)) << 20,
+	}
+}
+
+type ConnectionHealthService struct {
+	health      domain.ConnectionHealthRepository
+	connections domain.ConnectionRepository
+	tenants     domain.TenantRepository
+	connSvc     *ConnectionService
+	credentials *CredentialService
+	registry    *connector.Registry
+	emailSender notification.EmailSender
+	cfg         ConnectionHealthConfig
+}
+
+func NewConnectionHealthService(
+	health domain.ConnectionHealthRepository,
+	connections domain.ConnectionRepository,
+	tenants domain.TenantRepository,
+	connSvc *ConnectionService,
+	credentials *CredentialService,
+	registry *connector.Registry,
+	emailSender notification.EmailSender,
+	cfg ConnectionHealthConfig,
+) *ConnectionHealthService {
+	return &ConnectionHealthService{
+		health:      health,
+		connections: connections,
+		tenants:     tenants,
+		connSvc:     connSvc,
+		credentials: credentials,
+		registry:    registry,
+		emailSender: emailSender,
+		cfg:         cfg,
+	}
+}
+
+// Latest returns the most recent health check of a connection in the
+// current tenant, or nil if it has not been checked yet.
+func (s *ConnectionHealthService) Latest(ctx context.Context, connectionID string) (*domain.ConnectionHealthCheck, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	return s.health.FindLatest(ctx, tenantID, connectionID)
+}
+
+// History returns the most recent health checks of a connection, newest first.
+func (s *ConnectionHealthService) History(ctx context.Context, connectionID string, limit int) ([]domain.ConnectionHealthCheck, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if _, err := s.connections.FindByID(ctx, tenantID, connectionID); err != nil {
+		return nil, err
+	}
+	return s.health.ListByConnection(ctx, tenantID, connectionID, limit)
+}
+
+// CheckAll tests every connection that has a registered tester and prunes
+// history past the retention period.
+func (s *ConnectionHealthService) CheckAll(ctx context.Context) {
+	conns, err := s.connections.ListAll(ctx)
+	if err != nil {
+		log.Printf("connection_health: list connections: %v", err)
+		return
+	}
+	for i := range conns {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := s.Check(ctx, &conns[i]); err != nil {
+			log.Printf("connection_health: check connection=%s: %v", conns[i].ID, err)
+		}
+	}
+
+	if s.cfg.Retention > 0 {
+		if n, err := s.health.DeleteOlderThan(ctx, time.Now().Add(-s.cfg.Retention)); err != nil {
+			log.Printf("connection_health: prune history: %v", err)
+		} else if n > 0 {
+			log.Printf("connection_health: pruned %d checks", n)
+		}
+	}
+}
+
+// Check runs the connector's tester for c, records the result and notifies
+// tenant admins when the connection flips to failing. It returns nil without
+// recording anything when the connector type has no tester.
+func (s *ConnectionHealthService) Check(ctx context.Context, c *domain.Connection) (*domain.ConnectionHealthCheck, error) {
+	tester := s.registry.GetTester(c.Type)
+	if tester == nil {
+		return nil, nil
+	}
+
+	previous, err := s.lastSettled(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &domain.ConnectionHealthCheck{
+		ID:           uuid.New().String(),
+		TenantID:     c.TenantID,
+		ConnectionID: c.ID,
+	}
+	start := time.Now()
+	s.runTest(ctx, tester, c, check)
+	check.LatencyMs = time.Since(start).Milliseconds()
+
+	if err := s.health.Create(ctx, check); err != nil {
+		return nil, err
+	}
+	log.Printf("connection_health: connection=%s status=%s code=%s latency_ms=%d", c.ID, check.Status, check.Code, check.LatencyMs)
+
+	if notifiable(check) && !notifiable(previous) {
+		s.notifyFailing(ctx, c, check)
+	}
+	return check, nil
+}
+
+// transientCode marks a check that failed for a reason that says nothing
+// about the connection itself (token endpoint unreachable, provider 5xx,
+// tester crash). Such checks are recorded but never notify.
+const transientCode = "error"
+
+// settledHistory bounds how many recent checks lastSettled looks through.
+const settledHistory = 50
+
+// notifiable reports whether check is a failure admins are emailed about.
+func notifiable(check *domain.ConnectionHealthCheck) bool {
+	return check != nil && check.Status == domain.ConnectionHealthFailed && check.Code != transientCode
+}
+
+// lastSettled returns the most recent check of c that did not fail
+// transiently, so that a blip between two real failures is not taken for a
+// flip to failing.
+func (s *ConnectionHealthService) lastSettled(ctx context.Context, c *domain.Connection) (*domain.ConnectionHealthCheck, error) {
+	checks, err := s.health.ListByConnection(ctx, c.TenantID, c.ID, settledHistory)
+	if err != nil {
+		return nil, err
+	}
+	for i := range checks {
+		if checks[i].Status != domain.ConnectionHealthFailed || checks[i].Code != transientCode {
+			return &checks[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *ConnectionHealthService) runTest(ctx context.Context, tester connector.ConnectionTester, c *domain.Connection, check *domain.ConnectionHealthCheck) {
+	fail := func(code, message string) {
+		check.Status = domain.ConnectionHealthFailed
+		check.Code = code
+		check.Message = message
+	}
+
+	configJSON, err := s.connSvc.ResolveConfig(ctx, c)
+	if err != nil {
+		fail("invalid_config", err.Error())
+		return
+	}
+
+	accessToken := ""
+	if c.CredentialID != nil && *c.CredentialID != "" {
+		token, err := s.credentials.GetValidAccessToken(ctx, c.TenantID, *c.CredentialID)
+		switch {
+		case errors.Is(err, domain.ErrCredentialNeedsReconsent):
+			fail("needs_reconsent", "the linked account must be reconnected")
+			return
+		case errors.Is(err, domain.ErrCredentialNotFound):
+			fail("credential_missing", "the linked credential was deleted")
+			return
+		case errors.Is(err, domain.ErrCredentialExpired):
+			fail("credential_expired", err.Error())
+			return
+		case err != nil:
+			fail(transientCode, err.Error())
+			return
+		}
+		accessToken = token
+	}
+
+	testCtx := ctx
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		testCtx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+	result := tester.Test(testCtx, configJSON, accessToken)
+	if result == nil {
+		fail(transientCode, "tester returned no result")
+		return
+	}
+	if !result.OK {
+		fail(result.Code, result.Message)
+		return
+	}
+	check.Status = domain.ConnectionHealthOK
+	check.Code = result.Code
+	check.Message = result.Message
+}
+
+// notifyFailing emails tenant owners and admins. Failures are logged only.
+func (s *ConnectionHealthService) notifyFailing(ctx context.Context, c *domain.Connection, check *domain.ConnectionHealthCheck) {
+	if s.emailSender == nil {
+		return
+	}
+	tenant, err := s.tenants.FindByID(ctx, c.TenantID)
+	if err != nil {
+		log.Printf("connection_health notify: find tenant: %v", err)
+		return
+	}
+	members, err := s.tenants.ListMembersByTenantID(ctx, c.TenantID)
+	if err != nil {
+		log.Printf("connection_health notify: list members: %v", err)
+		return
+	}
+
+	reconnect := check.Code == "needs_reconsent" || check.Code == "credential_expired" || check.Code == "unauthorized"
+	subject, html, text, err := notification.RenderConnectionFailing(tenant.Name, c.Name, check.Code, check.Message, reconnect)
+	if err != nil {
+		log.Printf("connection_health notify: render: %v", err)
+		return
+	}
+	for _, m := range members {
+		if m.Role != domain.TenantRoleOwner && m.Role != domain.TenantRoleAdmin {
+			continue
+		}
+		msg := &notification.EmailMessage{To: m.Email, Subject: subject, HTML: html, Text: text}
+		if err := s.emailSender.Send(ctx, msg); err != nil {
+			log.Printf("connection_health notify: send to %s: %v", m.UserID, err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/connector"
+	"github.com/user/micro-dp/internal/credential"
+	"github.com/user/micro-dp/internal/notification"
+	"golang.org/x/oauth2"
+)
+
+// recordingSender records the recipients of the emails it is asked to send.
+type recordingSender struct {
+	mu sync.Mutex
+	to []string
+}
+
+func (s *recordingSender) Send(_ context.Context, msg *notification.EmailMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.to = append(s.to, msg.To)
+	return nil
+}
+
+func (s *recordingSender) recipients() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.to...)
+}
+
+// scriptedTester returns its results in order.
+type scriptedTester struct {
+	results []*connector.TestResult
+}
+
+func (t *scriptedTester) Test(context.Context, string, string) *connector.TestResult {
+	r := t.results[0]
+	t.results = t.results[1:]
+	return r
+}
+
+// scriptedProvider fails each token refresh with the next error of errs.
+type scriptedProvider struct {
+	credential.OAuthProvider
+	errs []error
+}
+
+func (p *scriptedProvider) ProviderName() string { return "scripted" }
+
+func (p *scriptedProvider) RefreshToken(context.Context, string) (*oauth2.Token, error) {
+	err := p.errs[0]
+	p.errs = p.errs[1:]
+	return nil, err
+}
+
+// healthFixture is a tenant with an owner, an admin and a member, and a
+// connection of a type served by tester.
+type healthFixture struct {
+	sqlDB  *sql.DB
+	health *db.ConnectionHealthRepo
+	sender *recordingSender
+	conn   *domain.Connection
+	svc    *ConnectionHealthService
+}
+
+func newHealthFixture(t *testing.T, tester connector.ConnectionTester, providers ...credential.OAuthProvider) *healthFixture {
+	t.Helper()
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	createTestMember(t, sqlDB, "t1", "owner", domain.TenantRoleOwner)
+	createTestMember(t, sqlDB, "t1", "admin", domain.TenantRoleAdmin)
+	createTestMember(t, sqlDB, "t1", "member", domain.TenantRoleMember)
+
+	registry := connector.New("")
+	registry.RegisterTester("health-test", tester)
+	keyring := testKeyring(t, masterKey(t, "k1"))
+	connections := db.NewConnectionRepo(sqlDB)
+	conn := &domain.Connection{ID: "c1", TenantID: "t1", Name: "warehouse", Type: "health-test", ConfigJSON: "{}"}
+	if err := connections.Create(context.Background(), conn); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &healthFixture{sqlDB: sqlDB, health: db.NewConnectionHealthRepo(sqlDB), sender: &recordingSender{}, conn: conn}
+	credentials := NewCredentialService(db.NewCredentialRepo(sqlDB), providers, "jwt", keyring)
+	f.svc = NewConnectionHealthService(f.health, connections, db.NewTenantRepo(sqlDB), NewConnectionService(connections, registry, keyring, nil),
+		credentials, registry, f.sender, ConnectionHealthConfig{Timeout: time.Second})
+	return f
+}
+
+// check runs a health check of the fixture's connection.
+func (f *healthFixture) check(t *testing.T) *domain.ConnectionHealthCheck {
+	t.Helper()
+	check, err := f.svc.Check(context.Background(), f.conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return check
+}
+
+func TestConnectionHealthCheckNotifiesOnFlipToFailing(t *testing.T) {
+	ok := &connector.TestResult{OK: true, Code: "ok"}
+	failing := &connector.TestResult{Code: "unauthorized", Message: "bad password"}
+	f := newHealthFixture(t, &scriptedTester{results: []*connector.TestResult{failing, failing, ok, ok, failing}})
+
+	wantStatus := []string{domain.ConnectionHealthFailed, domain.ConnectionHealthFailed, domain.ConnectionHealthOK, domain.ConnectionHealthOK, domain.ConnectionHealthFailed}
+	wantSent := []int{2, 2, 2, 2, 4}
+	for i := range wantStatus {
+		check := f.check(t)
+		if check.Status != wantStatus[i] {
+			t.Errorf("check %d status = %s, want %s", i, check.Status, wantStatus[i])
+		}
+		if got := len(f.sender.recipients()); got != wantSent[i] {
+			t.Errorf("after check %d sent %d emails, want %d", i, got, wantSent[i])
+		}
+	}
+
+	want := []string{"owner@example.com", "admin@example.com", "owner@example.com", "admin@example.com"}
+	if got := f.sender.recipients(); !reflect.DeepEqual(got, want) {
+		t.Errorf("recipients = %v, want %v", got, want)
+	}
+	checks, err := f.health.ListByConnection(context.Background(), "t1", "c1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 5 {
+		t.Fatalf("recorded %d checks, want 5", len(checks))
+	}
+	if c := checks[len(checks)-1]; c.Code != "unauthorized" || c.Message != "bad password" {
+		t.Errorf("first check = %s %q, want the tester's code and message", c.Code, c.Message)
+	}
+}
+
+func TestConnectionHealthCheckWithoutTester(t *testing.T) {
+	f := newHealthFixture(t, &scriptedTester{})
+	f.conn.Type = "no-tester"
+
+	if check := f.check(t); check != nil {
+		t.Fatalf("Check = %+v, want nil", check)
+	}
+	if latest, err := f.health.FindLatest(context.Background(), "t1", "c1"); err != nil || latest != nil {
+		t.Errorf("FindLatest = %+v, %v, want nothing recorded", latest, err)
+	}
+}
+
+func TestConnectionHealthCheckMissingCredential(t *testing.T) {
+	// A connection whose credential is gone fails without running the tester
+	f := newHealthFixture(t, &scriptedTester{})
+	credID := "missing"
+	f.conn.CredentialID = &credID
+
+	if check := f.check(t); check.Status != domain.ConnectionHealthFailed || check.Code != "credential_missing" {
+		t.Errorf("check = %s %s, want failed credential_missing", check.Status, check.Code)
+	}
+}
+
+func TestConnectionHealthCheckRefreshFailures(t *testing.T) {
+	rejected := fmt.Errorf("token refresh: %w: invalid_client", credential.ErrRefreshRejected)
+	unavailable := errors.New("token refresh: oauth2: server response missing access_token")
+	timeout := context.DeadlineExceeded
+	ok := &connector.TestResult{OK: true, Code: "ok"}
+	provider := &scriptedProvider{errs: []error{unavailable, timeout, rejected, unavailable, rejected}}
+	f := newHealthFixture(t, &scriptedTester{results: []*connector.TestResult{ok}}, provider)
+
+	// An expired access token with a refresh token, so every check refreshes
+	ctx := context.Background()
+	expired := time.Now().Add(-time.Hour)
+	createTestMember(t, f.sqlDB, "t1", "u1", domain.TenantRoleMember)
+	cred := &domain.Credential{ID: "cred1", UserID: "u1", TenantID: "t1", Provider: "scripted", AccessToken: "access", RefreshToken: "refresh", TokenExpiry: &expired}
+	if err := f.svc.credentials.sealTokens(cred); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.NewCredentialRepo(f.sqlDB).Create(ctx, cred); err != nil {
+		t.Fatal(err)
+	}
+	f.conn.CredentialID = &cred.ID
+
+	// Transient failures are recorded without an email, and do not hide the
+	// flip to failing of the rejection that follows them
+	wantCode := []string{"error", "error", "credential_expired", "error", "credential_expired"}
+	wantSent := []int{0, 0, 2, 2, 2}
+	for i := range wantCode {
+		check := f.check(t)
+		if check.Status != domain.ConnectionHealthFailed || check.Code != wantCode[i] {
+			t.Errorf("check %d = %s %s, want failed %s", i, check.Status, check.Code, wantCode[i])
+		}
+		if got := len(f.sender.recipients()); got != wantSent[i] {
+			t.Errorf("after check %d sent %d emails, want %d", i, got, wantSent[i])
+		}
+	}
+}
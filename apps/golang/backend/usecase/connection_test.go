@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/connector"
+	"github.com/user/micro-dp/internal/secret"
+)
+
+// masterKey returns a "id:base64key" master key entry.
+func masterKey(t *testing.T, id string) string {
+	t.Helper()
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return id + ":" + base64.StdEncoding.EncodeToString(b)
+}
+
+func testKeyring(t *testing.T, keys ...string) *secret.Keyring {
+	t.Helper()
+	k, err := secret.NewKeyring(secret.Config{MasterKeys: strings.Join(keys, ",")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// configField returns a field of a config, or nil.
+func configField(t *testing.T, configJSON, field string) any {
+	t.Helper()
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+		t.Fatalf("config %s: %v", configJSON, err)
+	}
+	return cfg[field]
+}
+
+const pgConfig = `{"host":"db.internal","port":5432,"database":"app","username":"app","password":"hunter2"}`
+
+func TestConnectionSecrets(t *testing.T) {
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	repo := db.NewConnectionRepo(sqlDB)
+	svc := NewConnectionService(repo, connector.New(""), testKeyring(t, masterKey(t, "k1")), nil)
+	ctx := domain.ContextWithTenantID(context.Background(), "t1")
+
+	c, err := svc.Create(ctx, "pg", "source-postgres", pgConfig, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := repo.FindByID(ctx, "t1", c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := configField(t, stored.ConfigJSON, "password").(string); !secret.IsSealed(p) || stored.DataKey == "" {
+		t.Errorf("stored config %s with data key %q, want the password sealed", stored.ConfigJSON, stored.DataKey)
+	}
+	if got := configField(t, stored.ConfigJSON, "host"); got != "db.internal" {
+		t.Errorf("stored host = %v, want it in plain text", got)
+	}
+	resolved, err := svc.ResolveConfig(ctx, stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := configField(t, resolved, "password"); got != "hunter2" {
+		t.Errorf("resolved password = %v, want hunter2", got)
+	}
+	if got := configField(t, svc.RedactConfig(stored), "password"); got != connector.RedactedValue {
+		t.Errorf("redacted password = %v", got)
+	}
+
+	password := func() any {
+		t.Helper()
+		c, err := repo.FindByID(ctx, "t1", c.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resolved, err := svc.ResolveConfig(ctx, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return configField(t, resolved, "password")
+	}
+
+	// A redacted or omitted password keeps the stored one while the other
+	// fields are unchanged
+	redacted := strings.Replace(pgConfig, "hunter2", connector.RedactedValue, 1)
+	if _, err := svc.Update(ctx, c.ID, "pg renamed", "source-postgres", redacted, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	omitted := `{"host":"db.internal","port":5432,"database":"app","username":"app"}`
+	if _, err := svc.Update(ctx, c.ID, "pg", "source-postgres", omitted, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := password(); got != "hunter2" {
+		t.Errorf("password after keeping it = %v, want hunter2", got)
+	}
+
+	// Pointing the connection elsewhere requires the password again
+	moved := strings.Replace(redacted, "db.internal", "attacker.example", 1)
+	if _, err := svc.Update(ctx, c.ID, "pg", "source-postgres", moved, nil, nil); !errors.Is(err, domain.ErrConnectionSecretRequired) {
+		t.Errorf("Update with a new host and the redacted password = %v, want ErrConnectionSecretRequired", err)
+	}
+	reentered := strings.Replace(pgConfig, "db.internal", "replica.internal", 1)
+	reentered = strings.Replace(reentered, "hunter2", "s3cret", 1)
+	if _, err := svc.Update(ctx, c.ID, "pg", "source-postgres", reentered, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := password(); got != "s3cret" {
+		t.Errorf("password after re-entering it = %v, want s3cret", got)
+	}
+}
+
+func TestResolveTestConfig(t *testing.T) {
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	refDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(refDir, "t1"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(refDir, "t1", "pg"), []byte(`{"password":"from-ref"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewConnectionService(db.NewConnectionRepo(sqlDB), connector.New(""), testKeyring(t, masterKey(t, "k1")), secret.NewRefResolver(refDir))
+	ctx := domain.ContextWithTenantID(context.Background(), "t1")
+
+	stored, err := svc.Create(ctx, "pg", "source-postgres", pgConfig, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The password of a secret reference satisfies the required field
+	ref := "file:pg"
+	withRef, err := svc.Create(ctx, "pg ref", "source-postgres", `{"host":"db.internal","port":5432,"database":"app","username":"app"}`, &ref, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := svc.ResolveConfig(ctx, withRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := configField(t, resolved, "password"); got != "from-ref" {
+		t.Errorf("resolved password of the secret reference = %v, want from-ref", got)
+	}
+	if strings.Contains(withRef.ConfigJSON, "from-ref") {
+		t.Errorf("stored config %s holds the referenced secret", withRef.ConfigJSON)
+	}
+
+	redacted := strings.Replace(pgConfig, "hunter2", connector.RedactedValue, 1)
+	moved := strings.Replace(redacted, "db.internal", "attacker.example", 1)
+	movedPort := strings.Replace(redacted, "5432", "6543", 1)
+	tests := []struct {
+		name         string
+		connectionID string
+		connType     string
+		config       string
+		wantPassword any
+		wantErr      error
+	}{
+		{name: "redacted password", connectionID: stored.ID, connType: "source-postgres", config: redacted, wantPassword: "hunter2"},
+		{name: "new password", connectionID: stored.ID, connType: "source-postgres", config: strings.Replace(moved, connector.RedactedValue, "typed", 1), wantPassword: "typed"},
+		{name: "changed host", connectionID: stored.ID, connType: "source-postgres", config: moved, wantErr: domain.ErrConnectionSecretRequired},
+		{name: "changed port", connectionID: stored.ID, connType: "source-postgres", config: movedPort, wantErr: domain.ErrConnectionSecretRequired},
+		{name: "secret reference", connectionID: withRef.ID, connType: "source-postgres", config: redacted, wantPassword: "from-ref"},
+		{name: "secret reference with changed host", connectionID: withRef.ID, connType: "source-postgres", config: moved, wantErr: domain.ErrConnectionSecretRequired},
+		{name: "secret reference with another type", connectionID: withRef.ID, connType: "source-mysql", config: moved, wantErr: domain.ErrConnectionSecretRequired},
+		{name: "unknown connection", connectionID: "missing", connType: "source-postgres", config: redacted, wantErr: domain.ErrConnectionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.ResolveTestConfig(ctx, tt.connectionID, tt.connType, tt.config)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ResolveTestConfig = %s, %v, want %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p := configField(t, got, "password"); p != tt.wantPassword {
+				t.Errorf("password = %v, want %v", p, tt.wantPassword)
+			}
+		})
+	}
+}
+
+func TestConnectionReencrypt(t *testing.T) {
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	repo := db.NewConnectionRepo(sqlDB)
+	ctx := domain.ContextWithTenantID(context.Background(), "t1")
+	k1, k2 := masterKey(t, "k1"), masterKey(t, "k2")
+
+	// One connection sealed under k1, one stored before encryption was on
+	sealed, err := NewConnectionService(repo, connector.New(""), testKeyring(t, k1), nil).Create(ctx, "sealed", "source-postgres", pgConfig, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := NewConnectionService(repo, connector.New(""), testKeyring(t), nil).Create(ctx, "plain", "source-postgres", pgConfig, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.DataKey != "" || configField(t, plain.ConfigJSON, "password") != "hunter2" {
+		t.Fatalf("connection stored without a master key = %+v, want plain text", plain)
+	}
+
+	// k2 becomes the active key
+	rotated := NewConnectionService(repo, connector.New(""), testKeyring(t, k2, k1), nil)
+	n, err := rotated.Reencrypt(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Reencrypt updated %d connections, want 2", n)
+	}
+	if n, err := rotated.Reencrypt(ctx); err != nil || n != 0 {
+		t.Errorf("second Reencrypt = %d, %v, want nothing left to update", n, err)
+	}
+
+	// Without k1 both connections still decrypt
+	k2Only := NewConnectionService(repo, connector.New(""), testKeyring(t, k2), nil)
+	for _, id := range []string{sealed.ID, plain.ID} {
+		c, err := repo.FindByID(ctx, "t1", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.DataKey == sealed.DataKey {
+			t.Errorf("connection %s kept its data key wrapped by k1", c.Name)
+		}
+		resolved, err := k2Only.ResolveConfig(ctx, c)
+		if err != nil {
+			t.Fatalf("resolve %s with k2: %v", c.Name, err)
+		}
+		if got := configField(t, resolved, "password"); got != "hunter2" {
+			t.Errorf("%s password = %v, want hunter2", c.Name, got)
+		}
+	}
+
+	if _, err := NewConnectionService(repo, connector.New(""), testKeyring(t), nil).Reencrypt(ctx); !errors.Is(err, secret.ErrNoMasterKey) {
+		t.Errorf("Reencrypt without a master key = %v, want ErrNoMasterKey", err)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 
 	"github.com/user/micro-dp/domain"
 	"github.com/user/micro-dp/internal/credential"
+	"github.com/user/micro-dp/internal/secret"
 )
 
 // ErrUnsupportedProvider is returned when the requested provider is not registered.
@@ -24,12 +27,14 @@ type CredentialService struct {
 	credentials domain.CredentialRepository
 	providers   map[string]credential.OAuthProvider
 	hmacSecret  []byte
+	keyring     *secret.Keyring
 }
 
 func NewCredentialService(
 	credentials domain.CredentialRepository,
 	providers []credential.OAuthProvider,
 	jwtSecret string,
+	keyring *secret.Keyring,
 ) *CredentialService {
 	m := make(map[string]credential.OAuthProvider, len(providers))
 	for _, p := range providers {
@@ -39,6 +44,7 @@ func NewCredentialService(
 		credentials: credentials,
 		providers:   m,
 		hmacSecret:  []byte(jwtSecret),
+		keyring:     keyring,
 	}
 }
 
@@ -66,6 +72,16 @@ func (s *CredentialService) ProviderPostRedirectURL(name string) string {
 	return p.PostRedirectURL()
 }
 
+// Providers returns the registered providers sorted by name.
+func (s *CredentialService) Providers() []credential.OAuthProvider {
+	out := make([]credential.OAuthProvider, 0, len(s.providers))
+	for _, p := range s.providers {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ProviderName() < out[j].ProviderName() })
+	return out
+}
+
 func (s *CredentialService) List(ctx context.Context) ([]domain.Credential, error) {
 	tenantID, ok := domain.TenantIDFromContext(ctx)
 	if !ok {
@@ -95,6 +111,9 @@ func (s *CredentialService) BuildAuthURL(providerName, userID, tenantID, codeCha
 
 	state := s.signState(userID, tenantID)
 	url := p.AuthCodeURL(state, codeChallenge)
+	if url == "" {
+		return "", fmt.Errorf("provider %q: authorization endpoint unavailable", providerName)
+	}
 	return url, nil
 }
 
@@ -128,9 +147,13 @@ func (s *CredentialService) CompleteOAuth(ctx context.Context, providerName, cod
 			existing.TokenExpiry = &oauthToken.Expiry
 		}
 		existing.Scopes = scopes
+		existing.Status = domain.CredentialStatusActive
 		if label != "" {
 			existing.ProviderLabel = label
 		}
+		if err := s.sealTokens(existing); err != nil {
+			return err
+		}
 		return s.credentials.Update(ctx, existing)
 	}
 
@@ -143,10 +166,14 @@ func (s *CredentialService) CompleteOAuth(ctx context.Context, providerName, cod
 		AccessToken:   oauthToken.AccessToken,
 		RefreshToken:  oauthToken.RefreshToken,
 		Scopes:        scopes,
+		Status:        domain.CredentialStatusActive,
 	}
 	if !oauthToken.Expiry.IsZero() {
 		cred.TokenExpiry = &oauthToken.Expiry
 	}
+	if err := s.sealTokens(cred); err != nil {
+		return err
+	}
 
 	return s.credentials.Create(ctx, cred)
 }
@@ -157,6 +184,12 @@ func (s *CredentialService) GetValidAccessToken(ctx context.Context, tenantID, c
 	if err != nil {
 		return "", err
 	}
+	if cred.Status == domain.CredentialStatusNeedsReconsent {
+		return "", domain.ErrCredentialNeedsReconsent
+	}
+	if err := s.openTokens(cred); err != nil {
+		return "", err
+	}
 
 	if cred.TokenExpiry != nil && time.Now().Before(*cred.TokenExpiry) {
 		return cred.AccessToken, nil
@@ -174,6 +207,13 @@ func (s *CredentialService) GetValidAccessToken(ctx context.Context, tenantID, c
 	newToken, err := p.RefreshToken(ctx, cred.RefreshToken)
 	if err != nil {
 		log.Printf("credential_refresh failed cred=%s: %v", credentialID, err)
+		if errors.Is(err, credential.ErrReconsentRequired) {
+			s.markNeedsReconsent(ctx, cred)
+			return "", fmt.Errorf("%w: %v", domain.ErrCredentialNeedsReconsent, err)
+		}
+		if errors.Is(err, credential.ErrRefreshRejected) {
+			return "", fmt.Errorf("%w: %v", domain.ErrCredentialExpired, err)
+		}
 		return "", fmt.Errorf("token refresh failed: %w", err)
 	}
 
@@ -184,13 +224,67 @@ func (s *CredentialService) GetValidAccessToken(ctx context.Context, tenantID, c
 	if !newToken.Expiry.IsZero() {
 		cred.TokenExpiry = &newToken.Expiry
 	}
-	if err := s.credentials.Update(ctx, cred); err != nil {
+	if err := s.sealTokens(cred); err != nil {
+		log.Printf("credential_seal failed cred=%s: %v", credentialID, err)
+	} else if err := s.credentials.Update(ctx, cred); err != nil {
 		log.Printf("credential_update failed cred=%s: %v", credentialID, err)
 	}
 
 	return newToken.AccessToken, nil
 }
 
+// markNeedsReconsent flags cred so that it is not refreshed again until the
+// user re-authorizes. The stored tokens are left untouched.
+func (s *CredentialService) markNeedsReconsent(ctx context.Context, cred *domain.Credential) {
+	stored, err := s.credentials.FindByID(ctx, cred.TenantID, cred.ID)
+	if err != nil {
+		log.Printf("credential_mark_reconsent failed cred=%s: %v", cred.ID, err)
+		return
+	}
+	stored.Status = domain.CredentialStatusNeedsReconsent
+	if err := s.credentials.Update(ctx, stored); err != nil {
+		log.Printf("credential_mark_reconsent failed cred=%s: %v", cred.ID, err)
+	}
+}
+
+// sealTokens encrypts the plain-text tokens of cred in place, creating a
+// data key for rows that do not have one yet.
+func (s *CredentialService) sealTokens(cred *domain.Credential) error {
+	var env *secret.Envelope
+	var err error
+	if cred.DataKey == "" {
+		env, err = s.keyring.NewEnvelope()
+	} else {
+		env, err = s.keyring.OpenEnvelope(cred.DataKey)
+	}
+	if err != nil {
+		return err
+	}
+	if cred.AccessToken, err = env.Seal(cred.AccessToken); err != nil {
+		return err
+	}
+	if cred.RefreshToken, err = env.Seal(cred.RefreshToken); err != nil {
+		return err
+	}
+	cred.DataKey = env.WrappedKey
+	return nil
+}
+
+// openTokens decrypts the tokens of cred in place.
+func (s *CredentialService) openTokens(cred *domain.Credential) error {
+	env, err := s.keyring.OpenEnvelope(cred.DataKey)
+	if err != nil {
+		return err
+	}
+	if cred.AccessToken, err = env.Open(cred.AccessToken); err != nil {
+		return err
+	}
+	if cred.RefreshToken, err = env.Open(cred.RefreshToken); err != nil {
+		return err
+	}
+	return nil
+}
+
 // GeneratePKCE generates a PKCE verifier and challenge pair.
 func GeneratePKCE() (verifier, challenge string, err error) {
 	b := make([]byte, 32)
@@ -239,3 +333,40 @@ func (s *CredentialService) verifyState(state string) (string, string, error) {
 
 	return p[0], p[1], nil
 }
+
+// Reencrypt seals credentials stored before encryption was enabled and
+// re-wraps data keys that are not under the active master key. It returns
+// the number of rows updated.
+func (s *CredentialService) Reencrypt(ctx context.Context) (int, error) {
+	if !s.keyring.Enabled() {
+		return 0, secret.ErrNoMasterKey
+	}
+	creds, err := s.credentials.ListAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for i := range creds {
+		cred := &creds[i]
+		if cred.DataKey == "" {
+			if err := s.sealTokens(cred); err != nil {
+				return updated, fmt.Errorf("seal credential %s: %w", cred.ID, err)
+			}
+		} else {
+			wrapped, changed, err := s.keyring.Rewrap(cred.DataKey)
+			if err != nil {
+				return updated, fmt.Errorf("rewrap credential %s: %w", cred.ID, err)
+			}
+			if !changed {
+				continue
+			}
+			cred.DataKey = wrapped
+		}
+		if err := s.credentials.Update(ctx, cred); err != nil {
+			return updated, fmt.Errorf("update credential %s: %w", cred.ID, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
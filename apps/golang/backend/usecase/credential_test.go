@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/secret"
+)
+
+func TestCredentialTokens(t *testing.T) {
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	ctx := context.Background()
+	if err := db.NewUserRepo(sqlDB).Create(ctx, &domain.User{ID: "u1", Email: "u1@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	repo := db.NewCredentialRepo(sqlDB)
+	k1, k2 := masterKey(t, "k1"), masterKey(t, "k2")
+	expiry := time.Now().Add(time.Hour).UTC()
+
+	// One credential sealed under k1, one stored before encryption was on
+	svc := NewCredentialService(repo, nil, "jwt", testKeyring(t, k1))
+	sealed := &domain.Credential{ID: "c1", UserID: "u1", TenantID: "t1", Provider: "google", AccessToken: "access", RefreshToken: "refresh", TokenExpiry: &expiry}
+	if err := svc.sealTokens(sealed); err != nil {
+		t.Fatal(err)
+	}
+	if !secret.IsSealed(sealed.AccessToken) || !secret.IsSealed(sealed.RefreshToken) || sealed.DataKey == "" {
+		t.Fatalf("sealed credential = %+v, want both tokens sealed", sealed)
+	}
+	plain := &domain.Credential{ID: "c2", UserID: "u1", TenantID: "t1", Provider: "github", AccessToken: "access", RefreshToken: "refresh", TokenExpiry: &expiry}
+	for _, c := range []*domain.Credential{sealed, plain} {
+		if err := repo.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if token, err := svc.GetValidAccessToken(ctx, "t1", "c1"); err != nil || token != "access" {
+		t.Errorf("GetValidAccessToken = %q, %v, want the opened token", token, err)
+	}
+
+	// k2 becomes the active key
+	rotated := NewCredentialService(repo, nil, "jwt", testKeyring(t, k2, k1))
+	n, err := rotated.Reencrypt(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Reencrypt updated %d credentials, want 2", n)
+	}
+	if n, err := rotated.Reencrypt(ctx); err != nil || n != 0 {
+		t.Errorf("second Reencrypt = %d, %v, want nothing left to update", n, err)
+	}
+
+	// Without k1 both credentials still open
+	k2Only := NewCredentialService(repo, nil, "jwt", testKeyring(t, k2))
+	for _, id := range []string{"c1", "c2"} {
+		stored, err := repo.FindByID(ctx, "t1", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !secret.IsSealed(stored.AccessToken) || stored.DataKey == sealed.DataKey {
+			t.Errorf("credential %s = %+v, want it sealed under k2", id, stored)
+		}
+		if err := k2Only.openTokens(stored); err != nil {
+			t.Fatalf("open %s with k2: %v", id, err)
+		}
+		if stored.AccessToken != "access" || stored.RefreshToken != "refresh" {
+			t.Errorf("credential %s tokens = %q, %q", id, stored.AccessToken, stored.RefreshToken)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+)
+
+// CatalogConfig holds data catalog settings.
+type CatalogConfig struct {
+	UsageWindow time.Duration // trailing window dataset usage is counted over
+}
+
+// LoadCatalogConfig reads catalog settings from environment variables.
+// Usage events older than CATALOG_USAGE_WINDOW are pruned.
+func LoadCatalogConfig() CatalogConfig {
+	return CatalogConfig{
+		UsageWindow: envDuration("CATALOG_USAGE_WINDOW", 30*24*time.Hour),
+	}
+}
+
+// DatasetCatalog is what the catalog knows about a dataset besides its
+// schema: its badge, the users accountable for it, the glossary terms of
+// its columns and how much it was used over the usage window.
+type DatasetCatalog struct {
+	Certification *domain.DatasetCertification
+	Stewards      []domain.DatasetSteward
+	Terms         []domain.GlossaryTermColumn
+	Usage         domain.DatasetUsage
+}
+
+type DataCatalogService struct {
+	stewards       domain.DatasetStewardRepository
+	certifications domain.DatasetCertificationRepository
+	terms          domain.GlossaryTermRepository
+	usage          domain.DatasetUsageRepository
+	datasets       domain.DatasetRepository
+	tenants        domain.TenantRepository
+	cfg            CatalogConfig
+}
+
+func NewDataCatalogService(
+	stewards domain.DatasetStewardRepository,
+	certifications domain.DatasetCertificationRepository,
+	terms domain.GlossaryTermRepository,
+	usage domain.DatasetUsageRepository,
+	datasets domain.DatasetRepository,
+	tenants domain.TenantRepository,
+	cfg CatalogConfig,
+) *DataCatalogService {
+	return &DataCatalogService{
+		stewards:       stewards,
+		certifications: certifications,
+		terms:          terms,
+		usage:          usage,
+		datasets:       datasets,
+		tenants:        tenants,
+		cfg:            cfg,
+	}
+}
+
+// Describe returns the catalog entries of datasets by dataset ID.
+func (s *DataCatalogService) Describe(ctx context.Context, datasets ...*domain.Dataset) (map[string]*DatasetCatalog, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	ids := make([]string, len(datasets))
+	entries := make(map[string]*DatasetCatalog, len(datasets))
+	for i, ds := range datasets {
+		ids[i] = ds.ID
+		entries[ds.ID] = &DatasetCatalog{}
+	}
+
+	certs, err := s.certifications.ListByDatasets(ctx, tenantID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("list certifications: %w", err)
+	}
+	for i := range certs {
+		entries[certs[i].DatasetID].Certification = &certs[i]
+	}
+	stewards, err := s.stewards.ListByDatasets(ctx, tenantID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("list stewards: %w", err)
+	}
+	for _, st := range stewards {
+		entries[st.DatasetID].Stewards = append(entries[st.DatasetID].Stewards, st)
+	}
+	terms, err := s.terms.ListColumnsByDatasets(ctx, tenantID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("list glossary terms: %w", err)
+	}
+	for _, t := range terms {
+		entries[t.DatasetID].Terms = append(entries[t.DatasetID].Terms, t)
+	}
+	usage, err := s.usage.CountSince(ctx, tenantID, ids, time.Now().Add(-s.cfg.UsageWindow))
+	if err != nil {
+		return nil, fmt.Errorf("count usage: %w", err)
+	}
+	for id, u := range usage {
+		entries[id].Usage = u
+	}
+	return entries, nil
+}
+
+// SetSteward makes a member of the tenant an owner or steward of a dataset.
+func (s *DataCatalogService) SetSteward(ctx context.Context, datasetID, userID, role string) (*domain.DatasetSteward, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if role != domain.DatasetStewardRoleOwner && role != domain.DatasetStewardRoleSteward {
+		return nil, fmt.Errorf("%w: unknown steward role %q", domain.ErrInvalidCatalogEntry, role)
+	}
+	if err := s.checkCurator(ctx, tenantID, datasetID); err != nil {
+		return nil, err
+	}
+	if _, err := s.tenants.GetUserRole(ctx, userID, tenantID); err != nil {
+		if errors.Is(err, domain.ErrTenantNotFound) {
+			return nil, domain.ErrNotTenantMember
+		}
+		return nil, err
+	}
+
+	if err := s.stewards.Upsert(ctx, &domain.DatasetSteward{
+		DatasetID: datasetID,
+		TenantID:  tenantID,
+		UserID:    userID,
+		Role:      role,
+	}); err != nil {
+		return nil, err
+	}
+	stewards, err := s.stewards.ListByDatasets(ctx, tenantID, []string{datasetID})
+	if err != nil {
+		return nil, err
+	}
+	for i := range stewards {
+		if stewards[i].UserID == userID {
+			return &stewards[i], nil
+		}
+	}
+	return nil, domain.ErrDatasetStewardNotFound
+}
+
+func (s *DataCatalogService) RemoveSteward(ctx context.Context, datasetID, userID string) error {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("tenant id not found in context")
+	}
+	if err := s.checkCurator(ctx, tenantID, datasetID); err != nil {
+		return err
+	}
+	return s.stewards.Delete(ctx, tenantID, datasetID, userID)
+}
+
+// Certify gives a dataset a certification badge in the name of the caller.
+func (s *DataCatalogService) Certify(ctx context.Context, datasetID, status, note string) (*domain.DatasetCertification, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if status != domain.DatasetCertified && status != domain.DatasetDeprecated {
+		return nil, fmt.Errorf("%w: unknown certification status %q", domain.ErrInvalidCatalogEntry, status)
+	}
+	if err := s.checkCurator(ctx, tenantID, datasetID); err != nil {
+		return nil, err
+	}
+	userID, _ := domain.UserIDFromContext(ctx)
+
+	if err := s.certifications.Upsert(ctx, &domain.DatasetCertification{
+		DatasetID:   datasetID,
+		TenantID:    tenantID,
+		Status:      status,
+		Note:        note,
+		CertifiedBy: userID,
+	}); err != nil {
+		return nil, err
+	}
+	certs, err := s.certifications.ListByDatasets(ctx, tenantID, []string{datasetID})
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, domain.ErrDatasetCertificationNotFound
+	}
+	return &certs[0], nil
+}
+
+func (s *DataCatalogService) Uncertify(ctx context.Context, datasetID string) error {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("tenant id not found in context")
+	}
+	if err := s.checkCurator(ctx, tenantID, datasetID); err != nil {
+		return err
+	}
+	return s.certifications.Delete(ctx, tenantID, datasetID)
+}
+
+func (s *DataCatalogService) ListTerms(ctx context.Context) ([]domain.GlossaryTerm, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	terms, err := s.terms.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(terms))
+	byID := make(map[string]*domain.GlossaryTerm, len(terms))
+	for i := range terms {
+		ids[i] = terms[i].ID
+		byID[terms[i].ID] = &terms[i]
+	}
+	cols, err := s.terms.ListColumnsByTerms(ctx, tenantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cols {
+		byID[c.TermID].Columns = append(byID[c.TermID].Columns, c)
+	}
+	return terms, nil
+}
+
+func (s *DataCatalogService) GetTerm(ctx context.Context, id string) (*domain.GlossaryTerm, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	return s.term(ctx, tenantID, id)
+}
+
+// CreateTerm adds a term to the glossary. Only owners and admins may edit
+// the glossary.
+func (s *DataCatalogService) CreateTerm(ctx context.Context, name, definition string) (*domain.GlossaryTerm, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if err := checkOwnerOrAdmin(ctx, s.tenants, tenantID); err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", domain.ErrInvalidCatalogEntry)
+	}
+
+	t := &domain.GlossaryTerm{
+		ID:         uuid.New().String(),
+		TenantID:   tenantID,
+		Name:       name,
+		Definition: definition,
+	}
+	if err := s.terms.Create(ctx, t); err != nil {
+		return nil, err
+	}
+	return s.term(ctx, tenantID, t.ID)
+}
+
+func (s *DataCatalogService) UpdateTerm(ctx context.Context, id, name, definition string) (*domain.GlossaryTerm, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if err := checkOwnerOrAdmin(ctx, s.tenants, tenantID); err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", domain.ErrInvalidCatalogEntry)
+	}
+
+	if err := s.terms.Update(ctx, &domain.GlossaryTerm{
+		ID:         id,
+		TenantID:   tenantID,
+		Name:       name,
+		Definition: definition,
+	}); err != nil {
+		return nil, err
+	}
+	return s.term(ctx, tenantID, id)
+}
+
+func (s *DataCatalogService) DeleteTerm(ctx context.Context, id string) error {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("tenant id not found in context")
+	}
+	if err := checkOwnerOrAdmin(ctx, s.tenants, tenantID); err != nil {
+		return err
+	}
+	return s.terms.Delete(ctx, tenantID, id)
+}
+
+// LinkTerm links a glossary term to a column of a dataset.
+func (s *DataCatalogService) LinkTerm(ctx context.Context, datasetID, column, termID string) (*domain.GlossaryTerm, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if err := s.checkCurator(ctx, tenantID, datasetID); err != nil {
+		return nil, err
+	}
+	if _, err := s.terms.FindByID(ctx, tenantID, termID); err != nil {
+		return nil, err
+	}
+	ds, err := s.datasets.FindByID(ctx, tenantID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := ds.ParseColumns()
+	if err != nil {
+		return nil, fmt.Errorf("parse columns: %w", err)
+	}
+	found := false
+	for _, c := range cols {
+		found = found || c.Name == column
+	}
+	if !found {
+		return nil, fmt.Errorf("column %q: %w", column, domain.ErrColumnNotFound)
+	}
+
+	if err := s.terms.LinkColumn(ctx, tenantID, &domain.GlossaryTermColumn{
+		TermID:     termID,
+		DatasetID:  datasetID,
+		ColumnName: column,
+	}); err != nil {
+		return nil, err
+	}
+	return s.term(ctx, tenantID, termID)
+}
+
+func (s *DataCatalogService) UnlinkTerm(ctx context.Context, datasetID, column, termID string) error {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("tenant id not found in context")
+	}
+	if err := s.checkCurator(ctx, tenantID, datasetID); err != nil {
+		return err
+	}
+	return s.terms.UnlinkColumn(ctx, tenantID, termID, datasetID, column)
+}
+
+// RecordUsage records a read of datasets by chart data or a preview query.
+// Failures are logged only so that they never fail the read.
+func (s *DataCatalogService) RecordUsage(ctx context.Context, kind, subjectID string, datasetIDs ...string) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	for _, id := range datasetIDs {
+		e := &domain.DatasetUsageEvent{
+			TenantID:  tenantID,
+			DatasetID: id,
+			Kind:      kind,
+			SubjectID: subjectID,
+		}
+		if err := s.usage.Record(ctx, e); err != nil {
+			log.Printf("data catalog: record %s usage dataset_id=%s: %v", kind, id, err)
+		}
+	}
+}
+
+// PruneUsage removes the usage events older than the usage window.
+func (s *DataCatalogService) PruneUsage(ctx context.Context) {
+	n, err := s.usage.DeleteBefore(ctx, time.Now().Add(-s.cfg.UsageWindow))
+	if err != nil {
+		log.Printf("data catalog: prune usage: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("data catalog: pruned %d usage events", n)
+	}
+}
+
+// term returns a glossary term with its linked columns.
+func (s *DataCatalogService) term(ctx context.Context, tenantID, id string) (*domain.GlossaryTerm, error) {
+	t, err := s.terms.FindByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Columns, err = s.terms.ListColumnsByTerms(ctx, tenantID, []string{id}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// checkCurator allows tenant owners and admins, and the owners and stewards
+// of the dataset, to curate its catalog entry.
+func (s *DataCatalogService) checkCurator(ctx context.Context, tenantID, datasetID string) error {
+	if _, err := s.datasets.FindByID(ctx, tenantID, datasetID); err != nil {
+		return err
+	}
+	err := checkOwnerOrAdmin(ctx, s.tenants, tenantID)
+	if !errors.Is(err, domain.ErrInsufficientRole) {
+		return err
+	}
+	userID, _ := domain.UserIDFromContext(ctx)
+	stewards, serr := s.stewards.ListByDatasets(ctx, tenantID, []string{datasetID})
+	if serr != nil {
+		return serr
+	}
+	for _, st := range stewards {
+		if st.UserID == userID {
+			return nil
+		}
+	}
+	return err
+}
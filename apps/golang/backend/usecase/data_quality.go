@@ -0,0 +1,551 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/dataquality"
+	"github.com/user/micro-dp/internal/datasetquery"
+	"github.com/user/micro-dp/internal/duckdbsandbox"
+	"github.com/user/micro-dp/storage"
+)
+
+// Dataset health statuses, derived from the latest result of each enabled
+// rule.
+const (
+	DatasetHealthHealthy = "healthy"
+	DatasetHealthWarning = "warning" // a rule of severity warn did not pass
+	DatasetHealthFailing = "failing" // a rule of severity fail did not pass
+	DatasetHealthUnknown = "unknown" // no rule has been evaluated yet
+)
+
+type DataQualityService struct {
+	rules    domain.DataQualityRuleRepository
+	results  domain.DataQualityResultRepository
+	datasets domain.DatasetRepository
+	versions domain.DatasetVersionRepository
+	rollback *DatasetVersionService
+	tenants  domain.TenantRepository
+	masking  *DatasetMaskingService
+	minio    *storage.MinIOClient
+}
+
+func NewDataQualityService(
+	rules domain.DataQualityRuleRepository,
+	results domain.DataQualityResultRepository,
+	datasets domain.DatasetRepository,
+	versions domain.DatasetVersionRepository,
+	rollback *DatasetVersionService,
+	tenants domain.TenantRepository,
+	masking *DatasetMaskingService,
+	minio *storage.MinIOClient,
+) *DataQualityService {
+	return &DataQualityService{
+		rules:    rules,
+		results:  results,
+		datasets: datasets,
+		versions: versions,
+		rollback: rollback,
+		tenants:  tenants,
+		masking:  masking,
+		minio:    minio,
+	}
+}
+
+// DataQualityRuleInput is the definition of a rule to create or replace.
+type DataQualityRuleInput struct {
+	Name     string
+	Type     string
+	Config   dataquality.Config
+	Severity string // defaults to warn
+	Enabled  bool
+}
+
+// DataQualityRuleHealth is a rule with its latest result, if it has one.
+type DataQualityRuleHealth struct {
+	Rule   domain.DataQualityRule
+	Latest *domain.DataQualityResult
+}
+
+// DatasetHealth summarizes the data quality of a dataset.
+type DatasetHealth struct {
+	Status    string
+	CheckedAt *time.Time // when the latest stored result was recorded
+	Rules     []DataQualityRuleHealth
+}
+
+func (s *DataQualityService) ListRules(ctx context.Context, datasetID string) ([]domain.DataQualityRule, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if _, err := s.datasets.FindByID(ctx, tenantID, datasetID); err != nil {
+		return nil, err
+	}
+	return s.rules.ListByDataset(ctx, tenantID, datasetID)
+}
+
+func (s *DataQualityService) CreateRule(ctx context.Context, datasetID string, in DataQualityRuleInput) (*domain.DataQualityRule, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if _, err := s.datasets.FindByID(ctx, tenantID, datasetID); err != nil {
+		return nil, err
+	}
+	rule := &domain.DataQualityRule{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		DatasetID: datasetID,
+	}
+	if err := s.applyRuleInput(ctx, rule, in); err != nil {
+		return nil, err
+	}
+	if err := s.rules.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+	return s.rules.FindByID(ctx, tenantID, rule.ID)
+}
+
+// UpdateRule replaces the definition of a rule. Its past results are kept.
+func (s *DataQualityService) UpdateRule(ctx context.Context, datasetID, ruleID string, in DataQualityRuleInput) (*domain.DataQualityRule, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	rule, err := s.findRule(ctx, tenantID, datasetID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRuleInput(ctx, rule, in); err != nil {
+		return nil, err
+	}
+	if err := s.rules.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+	return s.rules.FindByID(ctx, tenantID, rule.ID)
+}
+
+// DeleteRule deletes a rule with its results.
+func (s *DataQualityService) DeleteRule(ctx context.Context, datasetID, ruleID string) error {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("tenant id not found in context")
+	}
+	if _, err := s.findRule(ctx, tenantID, datasetID, ruleID); err != nil {
+		return err
+	}
+	return s.rules.Delete(ctx, tenantID, ruleID)
+}
+
+// ListResults returns the results of a dataset's rules, newest first, those
+// of one job run when jobRunID is set.
+func (s *DataQualityService) ListResults(ctx context.Context, datasetID, jobRunID string, limit int) ([]domain.DataQualityResult, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if _, err := s.datasets.FindByID(ctx, tenantID, datasetID); err != nil {
+		return nil, err
+	}
+	return s.results.ListByDataset(ctx, tenantID, datasetID, jobRunID, limit)
+}
+
+// Check evaluates the enabled rules of a dataset against its current data
+// and records the results.
+func (s *DataQualityService) Check(ctx context.Context, datasetID string) ([]domain.DataQualityResult, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	ds, err := s.datasets.FindByID(ctx, tenantID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	var current *domain.DatasetVersion
+	versions, err := s.versions.ListByDataset(ctx, tenantID, ds.ID, 1)
+	if err != nil {
+		return nil, fmt.Errorf("list versions: %w", err)
+	}
+	if len(versions) > 0 {
+		current = &versions[0]
+	}
+	return s.check(ctx, ds, current, "")
+}
+
+// CheckRun evaluates the enabled rules of a dataset a job run has just
+// written and records the results. When a rule of severity fail does not
+// pass, the dataset is rolled back to its previous version, if it has one,
+// and an error wrapping domain.ErrDataQualityFailed is returned to fail the
+// run.
+func (s *DataQualityService) CheckRun(ctx context.Context, tenantID, datasetID, jobRunID string) error {
+	ds, err := s.datasets.FindByID(ctx, tenantID, datasetID)
+	if err != nil {
+		return fmt.Errorf("data quality: %w", err)
+	}
+	versions, err := s.versions.ListByDataset(ctx, tenantID, ds.ID, 2)
+	if err != nil {
+		return fmt.Errorf("data quality: list versions: %w", err)
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("data quality: dataset %s has no version", ds.Name)
+	}
+	results, err := s.check(ctx, ds, &versions[0], jobRunID)
+	if err != nil {
+		return fmt.Errorf("data quality: %w", err)
+	}
+
+	var failed []string
+	for _, res := range results {
+		if res.Severity == dataquality.SeverityFail && res.Status != dataquality.StatusPassed {
+			failed = append(failed, res.RuleID)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	if len(versions) > 1 {
+		tctx := domain.ContextWithTenantID(ctx, tenantID)
+		if _, err := s.rollback.Rollback(tctx, ds.ID, versions[1].Version); err != nil {
+			return fmt.Errorf("%w on %s (failing rules: %d), and rolling back failed: %v", domain.ErrDataQualityFailed, ds.Name, len(failed), err)
+		}
+		return fmt.Errorf("%w on %s (failing rules: %d); rolled back to version %d", domain.ErrDataQualityFailed, ds.Name, len(failed), versions[1].Version)
+	}
+	return fmt.Errorf("%w on %s (failing rules: %d)", domain.ErrDataQualityFailed, ds.Name, len(failed))
+}
+
+// Health returns the latest result of each rule of a dataset. Freshness is
+// evaluated as of now rather than read from the last check.
+func (s *DataQualityService) Health(ctx context.Context, datasetID string) (*DatasetHealth, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	ds, err := s.datasets.FindByID(ctx, tenantID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.rules.ListByDataset(ctx, tenantID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.results.LatestByDataset(ctx, tenantID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	byRule := make(map[string]*domain.DataQualityResult, len(latest))
+	for i := range latest {
+		byRule[latest[i].RuleID] = &latest[i]
+	}
+
+	health := &DatasetHealth{Status: DatasetHealthUnknown, Rules: make([]DataQualityRuleHealth, len(rules))}
+	now := time.Now().UTC()
+	for i, rule := range rules {
+		res := byRule[rule.ID]
+		if res != nil && (health.CheckedAt == nil || res.CreatedAt.After(*health.CheckedAt)) {
+			health.CheckedAt = &res.CreatedAt
+		}
+		if rule.Type == dataquality.TypeFreshness {
+			var cfg dataquality.Config
+			if err := json.Unmarshal([]byte(rule.ConfigJSON), &cfg); err == nil {
+				fresh := newDataQualityResult(ds, &rule, nil, "", dataquality.Fresh(cfg, ds.LastUpdatedAt, now))
+				fresh.CreatedAt = now
+				res = &fresh
+			}
+		}
+		health.Rules[i] = DataQualityRuleHealth{Rule: rule, Latest: res}
+
+		if !rule.Enabled || res == nil {
+			continue
+		}
+		switch {
+		case res.Status == dataquality.StatusPassed:
+			if health.Status == DatasetHealthUnknown {
+				health.Status = DatasetHealthHealthy
+			}
+		case res.Severity == dataquality.SeverityFail:
+			health.Status = DatasetHealthFailing
+		case health.Status != DatasetHealthFailing:
+			health.Status = DatasetHealthWarning
+		}
+	}
+	return health, nil
+}
+
+func (s *DataQualityService) findRule(ctx context.Context, tenantID, datasetID, ruleID string) (*domain.DataQualityRule, error) {
+	rule, err := s.rules.FindByID(ctx, tenantID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if rule.DatasetID != datasetID {
+		return nil, domain.ErrDataQualityRuleNotFound
+	}
+	return rule, nil
+}
+
+// applyRuleInput validates in and sets it as the definition of rule. SQL
+// rules run the caller's query, so only owners and admins write them, and
+// since results count rows by raw values, no rule reads a column masked
+// for the caller.
+func (s *DataQualityService) applyRuleInput(ctx context.Context, rule *domain.DataQualityRule, in DataQualityRuleInput) error {
+	if in.Type == dataquality.TypeSQL || rule.Type == dataquality.TypeSQL {
+		if err := checkOwnerOrAdmin(ctx, s.tenants, rule.TenantID); err != nil {
+			return err
+		}
+	}
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		return fmt.Errorf("%w: name is required", dataquality.ErrInvalid)
+	}
+	if in.Severity == "" {
+		in.Severity = dataquality.SeverityWarn
+	}
+	if !dataquality.ValidSeverity(in.Severity) {
+		return fmt.Errorf("%w: unknown severity %q", dataquality.ErrInvalid, in.Severity)
+	}
+	if err := dataquality.Validate(in.Type, in.Config); err != nil {
+		return err
+	}
+	if in.Type == dataquality.TypeSQL {
+		if err := checkAssertion(ctx, in.Config); err != nil {
+			return err
+		}
+	}
+	if in.Type == dataquality.TypeReferential || (in.Type == dataquality.TypeSQL && in.Config.RefDatasetID != "") {
+		if _, err := s.datasets.FindByID(ctx, rule.TenantID, in.Config.RefDatasetID); err != nil {
+			if errors.Is(err, domain.ErrDatasetNotFound) {
+				return fmt.Errorf("%w: referenced dataset not found", dataquality.ErrInvalid)
+			}
+			return err
+		}
+	}
+	if err := s.checkUnmasked(ctx, rule.DatasetID, in.Type, in.Config); err != nil {
+		return err
+	}
+	cfg, err := json.Marshal(in.Config)
+	if err != nil {
+		return err
+	}
+
+	rule.Name = in.Name
+	rule.Type = in.Type
+	rule.ConfigJSON = string(cfg)
+	rule.Severity = in.Severity
+	rule.Enabled = in.Enabled
+	return nil
+}
+
+// checkUnmasked returns domain.ErrInsufficientRole when a rule of type typ
+// reads a column of the dataset or the referenced dataset that is masked
+// for the caller. An SQL rule may read any column of either.
+func (s *DataQualityService) checkUnmasked(ctx context.Context, datasetID, typ string, cfg dataquality.Config) error {
+	read := func(datasetID, column string) error {
+		masks, err := s.masking.Masks(ctx, datasetID)
+		if err != nil {
+			return err
+		}
+		if _, ok := masks[column]; ok || (column == "" && len(masks) > 0) {
+			return fmt.Errorf("%w: the rule reads columns masked for your role", domain.ErrInsufficientRole)
+		}
+		return nil
+	}
+	switch typ {
+	case dataquality.TypeSQL:
+		if err := read(datasetID, ""); err != nil {
+			return err
+		}
+		if cfg.RefDatasetID != "" {
+			return read(cfg.RefDatasetID, "")
+		}
+	case dataquality.TypeReferential:
+		if err := read(datasetID, cfg.Column); err != nil {
+			return err
+		}
+		return read(cfg.RefDatasetID, cfg.RefColumn)
+	case dataquality.TypeRowCount, dataquality.TypeFreshness:
+	default:
+		return read(datasetID, cfg.Column)
+	}
+	return nil
+}
+
+// check evaluates the enabled rules of ds against version v, or its current
+// data when v is nil, and records the results.
+func (s *DataQualityService) check(ctx context.Context, ds *domain.Dataset, v *domain.DatasetVersion, jobRunID string) ([]domain.DataQualityResult, error) {
+	rules, err := s.rules.ListByDataset(ctx, ds.TenantID, ds.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		duckDB  *sql.DB
+		openErr error
+	)
+	defer func() {
+		if duckDB != nil {
+			duckDB.Close()
+		}
+	}()
+	open := func() (*sql.DB, error) {
+		if duckDB == nil && openErr == nil {
+			duckDB, openErr = s.openDataset(ctx, ds, v)
+		}
+		return duckDB, openErr
+	}
+
+	now := time.Now().UTC()
+	results := []domain.DataQualityResult{}
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Enabled {
+			continue
+		}
+		var outcome dataquality.Result
+		var cfg dataquality.Config
+		if err := json.Unmarshal([]byte(rule.ConfigJSON), &cfg); err != nil {
+			outcome = dataquality.Result{Status: dataquality.StatusError, Message: fmt.Sprintf("parse config: %v", err)}
+		} else if rule.Type == dataquality.TypeFreshness {
+			outcome = dataquality.Fresh(cfg, ds.LastUpdatedAt, now)
+		} else if db, err := open(); err != nil {
+			outcome = dataquality.Result{Status: dataquality.StatusError, Message: err.Error()}
+		} else if err := s.openReference(ctx, db, ds.TenantID, rule.Type, cfg); err != nil {
+			outcome = dataquality.Result{Status: dataquality.StatusError, Message: err.Error()}
+		} else if err := sandboxAssertion(ctx, db, rule.Type, cfg); err != nil {
+			outcome = dataquality.Result{Status: dataquality.StatusError, Message: err.Error()}
+		} else {
+			outcome = dataquality.Evaluate(ctx, db, rule.Type, cfg)
+		}
+
+		res := newDataQualityResult(ds, rule, v, jobRunID, outcome)
+		if err := s.results.Create(ctx, &res); err != nil {
+			return nil, fmt.Errorf("record result: %w", err)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// openDataset opens a DuckDB session with the data of ds at version v as
+// the view rules are evaluated against. The session reads S3 only under the
+// tenant's prefixes and is locked down before any rule runs.
+func (s *DataQualityService) openDataset(ctx context.Context, ds *domain.Dataset, v *domain.DatasetVersion) (*sql.DB, error) {
+	duckDB, source, err := openDatasetSource(ctx, s.minio, ds, v)
+	if err != nil {
+		return nil, err
+	}
+	viewSQL := fmt.Sprintf("CREATE VIEW %s AS SELECT * FROM read_parquet(%s, union_by_name = true)",
+		datasetquery.QuoteIdentifier(dataquality.DatasetView), source)
+	if _, err := duckDB.ExecContext(ctx, viewSQL); err != nil {
+		duckDB.Close()
+		return nil, fmt.Errorf("open dataset: %w", err)
+	}
+	if err := duckdbsandbox.Lock(ctx, duckDB, false); err != nil {
+		duckDB.Close()
+		return nil, err
+	}
+	return duckDB, nil
+}
+
+// openReference points the reference view at the current data of the
+// dataset a referential rule, or an SQL rule setting one, refers to.
+func (s *DataQualityService) openReference(ctx context.Context, db *sql.DB, tenantID, typ string, cfg dataquality.Config) error {
+	if typ != dataquality.TypeReferential && (typ != dataquality.TypeSQL || cfg.RefDatasetID == "") {
+		return nil
+	}
+	source, err := s.referenceSource(ctx, tenantID, cfg.RefDatasetID)
+	if err != nil {
+		return err
+	}
+	viewSQL := fmt.Sprintf("CREATE OR REPLACE VIEW %s AS SELECT * FROM read_parquet(%s, union_by_name = true)",
+		datasetquery.QuoteIdentifier(dataquality.RefView), source)
+	if _, err := db.ExecContext(ctx, viewSQL); err != nil {
+		return fmt.Errorf("open referenced dataset: %w", err)
+	}
+	return nil
+}
+
+// referenceSource returns the read_parquet source of the current version of
+// a referenced dataset, as rules read their own dataset, or of its storage
+// path when it has no versions.
+func (s *DataQualityService) referenceSource(ctx context.Context, tenantID, refID string) (string, error) {
+	ref, err := s.datasets.FindByID(ctx, tenantID, refID)
+	if err != nil {
+		return "", fmt.Errorf("referenced dataset: %w", err)
+	}
+	versions, err := s.versions.ListByDataset(ctx, tenantID, ref.ID, 1)
+	if err != nil {
+		return "", fmt.Errorf("referenced dataset: list versions: %w", err)
+	}
+	var current *domain.DatasetVersion
+	if len(versions) > 0 {
+		current = &versions[0]
+	}
+	source, err := datasetSource(s.minio.S3Config().Bucket, ref, current)
+	if err != nil {
+		return "", fmt.Errorf("referenced dataset: %w", err)
+	}
+	return source, nil
+}
+
+// assertionViews returns the views the query of an SQL rule may read.
+func assertionViews(cfg dataquality.Config) []string {
+	if cfg.RefDatasetID != "" {
+		return []string{dataquality.DatasetView, dataquality.RefView}
+	}
+	return []string{dataquality.DatasetView}
+}
+
+// sandboxAssertion accepts the query of an SQL rule only when it is a
+// single SELECT over the views of the rule.
+func sandboxAssertion(ctx context.Context, db *sql.DB, typ string, cfg dataquality.Config) error {
+	if typ != dataquality.TypeSQL {
+		return nil
+	}
+	return duckdbsandbox.CheckSelect(ctx, db, cfg.SQL, assertionViews(cfg))
+}
+
+// checkAssertion parses the query of an SQL rule in a session of its own,
+// so that a rule the sandbox would refuse is rejected when it is written.
+func checkAssertion(ctx context.Context, cfg dataquality.Config) error {
+	duckDB, err := sql.Open("duckdb", "")
+	if err != nil {
+		return fmt.Errorf("open duckdb: %w", err)
+	}
+	defer duckDB.Close()
+	if err := duckdbsandbox.CheckSelect(ctx, duckDB, cfg.SQL, assertionViews(cfg)); err != nil {
+		if errors.Is(err, duckdbsandbox.ErrRejected) {
+			return fmt.Errorf("%w: %v", dataquality.ErrInvalid, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func newDataQualityResult(ds *domain.Dataset, rule *domain.DataQualityRule, v *domain.DatasetVersion, jobRunID string, outcome dataquality.Result) domain.DataQualityResult {
+	res := domain.DataQualityResult{
+		ID:          uuid.New().String(),
+		TenantID:    ds.TenantID,
+		DatasetID:   ds.ID,
+		RuleID:      rule.ID,
+		Status:      outcome.Status,
+		Severity:    rule.Severity,
+		FailingRows: outcome.FailingRows,
+	}
+	if jobRunID != "" {
+		res.JobRunID = &jobRunID
+	}
+	if v != nil {
+		res.DatasetVersion = &v.Version
+	}
+	if outcome.Message != "" {
+		res.Message = &outcome.Message
+	}
+	return res
+}
@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/dataquality"
+	"github.com/user/micro-dp/storage"
+)
+
+// qualityFixture stores the datasets orders (d1) and customers (d2) of
+// tenant t1, and a member of each role named after it.
+func qualityFixture(t *testing.T) (*sql.DB, *db.DatasetRepo, *db.TenantRepo) {
+	t.Helper()
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	for _, role := range []string{domain.TenantRoleOwner, domain.TenantRoleAdmin, domain.TenantRoleMember} {
+		createTestMember(t, sqlDB, "t1", role, role)
+	}
+	datasets := db.NewDatasetRepo(sqlDB)
+	for _, d := range []*domain.Dataset{
+		{ID: "d1", TenantID: "t1", Name: "orders", SourceType: "import"},
+		{ID: "d2", TenantID: "t1", Name: "customers", SourceType: "import"},
+	} {
+		if err := datasets.Create(context.Background(), d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sqlDB, datasets, db.NewTenantRepo(sqlDB)
+}
+
+func TestDataQualitySQLRule(t *testing.T) {
+	sqlDB, datasets, tenants := qualityFixture(t)
+	rules := db.NewDataQualityRuleRepo(sqlDB)
+	if err := rules.Create(context.Background(), &domain.DataQualityRule{
+		ID: "r1", TenantID: "t1", DatasetID: "d1", Name: "ids", Type: dataquality.TypeNotNull, ConfigJSON: `{"column":"id"}`, Severity: dataquality.SeverityWarn, Enabled: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	masking := NewDatasetMaskingService(db.NewDatasetMaskingPolicyRepo(sqlDB), datasets, tenants, LoadPIIConfig())
+	svc := NewDataQualityService(rules, nil, datasets, nil, nil, tenants, masking, nil)
+	admin := tenantContext("t1", "admin", domain.TenantRoleAdmin)
+	member := tenantContext("t1", "member", domain.TenantRoleMember)
+	sqlRule := func(query, ref string) DataQualityRuleInput {
+		return DataQualityRuleInput{Name: "assertion", Type: dataquality.TypeSQL, Config: dataquality.Config{SQL: query, RefDatasetID: ref}, Enabled: true}
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		in      DataQualityRuleInput
+		wantErr error
+	}{
+		{name: "admin", ctx: admin, in: sqlRule("SELECT * FROM dataset WHERE amount < 0", "")},
+		{name: "with the referenced dataset", ctx: admin, in: sqlRule("SELECT * FROM dataset d ANTI JOIN ref r ON d.customer_id = r.id", "d2")},
+		{name: "member", ctx: member, in: sqlRule("SELECT * FROM dataset WHERE amount < 0", ""), wantErr: domain.ErrInsufficientRole},
+		{name: "table function", ctx: admin, in: sqlRule("SELECT * FROM read_parquet('s3://bucket/datasets/t2/**/*.parquet')", ""), wantErr: dataquality.ErrInvalid},
+		{name: "ref without a referenced dataset", ctx: admin, in: sqlRule("SELECT * FROM ref", ""), wantErr: dataquality.ErrInvalid},
+		{name: "settings", ctx: admin, in: sqlRule("SELECT current_setting('s3_secret_access_key') FROM dataset", ""), wantErr: dataquality.ErrInvalid},
+		{name: "not a select", ctx: admin, in: sqlRule("COPY dataset TO 'out.csv'", ""), wantErr: dataquality.ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.Name = tt.name
+			_, err := svc.CreateRule(tt.ctx, "d1", tt.in)
+			if tt.wantErr == nil && err != nil {
+				t.Fatal(err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateRule = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A member can edit other rules but cannot turn one into SQL
+	notNull := DataQualityRuleInput{Name: "ids", Type: dataquality.TypeNotNull, Config: dataquality.Config{Column: "order_id"}}
+	if _, err := svc.UpdateRule(member, "d1", "r1", notNull); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.UpdateRule(member, "d1", "r1", sqlRule("SELECT * FROM dataset", "")); !errors.Is(err, domain.ErrInsufficientRole) {
+		t.Errorf("UpdateRule to sql by a member = %v, want %v", err, domain.ErrInsufficientRole)
+	}
+	if r1, err := rules.FindByID(context.Background(), "t1", "r1"); err != nil || r1.Type != dataquality.TypeNotNull {
+		t.Errorf("rule = %+v, %v, want its type unchanged", r1, err)
+	}
+}
+
+func TestDataQualityMaskedColumns(t *testing.T) {
+	sqlDB, datasets, tenants := qualityFixture(t)
+	policies := db.NewDatasetMaskingPolicyRepo(sqlDB)
+	for _, p := range []*domain.DatasetMaskingPolicy{
+		{ID: "p1", TenantID: "t1", DatasetID: "d1", ColumnName: "email", Policy: "hash"},
+		{ID: "p2", TenantID: "t1", DatasetID: "d2", ColumnName: "phone", Policy: "redact"},
+	} {
+		if err := policies.Upsert(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Admins read masked columns masked too
+	masking := NewDatasetMaskingService(policies, datasets, tenants, PIIConfig{UnmaskedRoles: []string{domain.TenantRoleOwner}})
+	svc := NewDataQualityService(db.NewDataQualityRuleRepo(sqlDB), nil, datasets, nil, nil, tenants, masking, nil)
+	rule := func(typ string, cfg dataquality.Config) DataQualityRuleInput {
+		return DataQualityRuleInput{Name: typ, Type: typ, Config: cfg, Enabled: true}
+	}
+
+	tests := []struct {
+		name   string
+		user   string
+		in     DataQualityRuleInput
+		masked bool
+	}{
+		{name: "unmasked column", user: "member", in: rule(dataquality.TypeNotNull, dataquality.Config{Column: "id"})},
+		{name: "row count", user: "member", in: rule(dataquality.TypeRowCount, dataquality.Config{MinRows: new(int64)})},
+		{name: "masked column", user: "member", in: rule(dataquality.TypeAcceptedValues, dataquality.Config{Column: "email", Values: []string{"a@example.com"}}), masked: true},
+		{name: "masked column read raw", user: "owner", in: rule(dataquality.TypeRegex, dataquality.Config{Column: "email", Pattern: ".+@example.com"})},
+		{name: "masked referenced column", user: "member", in: rule(dataquality.TypeReferential, dataquality.Config{Column: "phone", RefDatasetID: "d2", RefColumn: "phone"}), masked: true},
+		{name: "unmasked referenced column", user: "member", in: rule(dataquality.TypeReferential, dataquality.Config{Column: "customer_id", RefDatasetID: "d2", RefColumn: "id"})},
+		{name: "sql over a masked dataset", user: "admin", in: rule(dataquality.TypeSQL, dataquality.Config{SQL: "SELECT * FROM dataset WHERE amount < 0"}), masked: true},
+		{name: "sql over a masked referenced dataset", user: "owner", in: rule(dataquality.TypeSQL, dataquality.Config{SQL: "SELECT * FROM ref", RefDatasetID: "d2"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.Name = tt.name
+			_, err := svc.CreateRule(tenantContext("t1", tt.user, tt.user), "d1", tt.in)
+			if tt.masked != errors.Is(err, domain.ErrInsufficientRole) || (!tt.masked && err != nil) {
+				t.Errorf("CreateRule = %v, want masked %t", err, tt.masked)
+			}
+		})
+	}
+}
+
+func TestDataQualityReferenceSource(t *testing.T) {
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	ctx := context.Background()
+	datasets, versions := db.NewDatasetRepo(sqlDB), db.NewDatasetVersionRepo(sqlDB)
+	for _, d := range []*domain.Dataset{
+		{ID: "d1", TenantID: "t1", Name: "customers", SourceType: "import", StoragePath: "datasets/t1/customers/"},
+		{ID: "d2", TenantID: "t1", Name: "regions", SourceType: "import", StoragePath: "datasets/t1/regions/"},
+	} {
+		if err := datasets.Create(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The current version of customers lists its parts; an older one does not
+	parts := `["datasets/t1/customers/v2/part-0.parquet","datasets/t1/customers/v2/part-1.parquet"]`
+	for _, v := range []*domain.DatasetVersion{
+		{ID: "v1", TenantID: "t1", DatasetID: "d1", StoragePath: "datasets/t1/customers/v1/"},
+		{ID: "v2", TenantID: "t1", DatasetID: "d1", StoragePath: "datasets/t1/customers/v2/", ObjectKeysJSON: &parts},
+	} {
+		if err := versions.Create(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("MINIO_BUCKET", "lake")
+	minio, err := storage.NewMinIOClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewDataQualityService(nil, nil, datasets, versions, nil, nil, nil, minio)
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"d1", "['s3://lake/datasets/t1/customers/v2/part-0.parquet', 's3://lake/datasets/t1/customers/v2/part-1.parquet']"},
+		{"d2", storage.S3ParquetSource("lake", "datasets/t1/regions/", nil)},
+	}
+	for _, tt := range tests {
+		got, err := svc.referenceSource(ctx, "t1", tt.ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("referenceSource(%s) = %s, want %s", tt.ref, got, tt.want)
+		}
+	}
+	if _, err := svc.referenceSource(ctx, "t2", "d1"); !errors.Is(err, domain.ErrDatasetNotFound) {
+		t.Errorf("referenceSource of another tenant = %v, want %v", err, domain.ErrDatasetNotFound)
+	}
+}
@@ -2,21 +2,25 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/datasetquery"
 	"github.com/user/micro-dp/storage"
 )
 
 type DatasetService struct {
-	datasets domain.DatasetRepository
-	minio    *storage.MinIOClient
+	datasets      domain.DatasetRepository
+	schemaChanges domain.DatasetSchemaChangeRepository
+	versions      domain.DatasetVersionRepository
+	minio         *storage.MinIOClient
+	masking       *DatasetMaskingService
+	catalog       *DataCatalogService
 }
 
-func NewDatasetService(datasets domain.DatasetRepository, minio *storage.MinIOClient) *DatasetService {
-	return &DatasetService{datasets: datasets, minio: minio}
+func NewDatasetService(datasets domain.DatasetRepository, schemaChanges domain.DatasetSchemaChangeRepository, versions domain.DatasetVersionRepository, minio *storage.MinIOClient, masking *DatasetMaskingService, catalog *DataCatalogService) *DatasetService {
+	return &DatasetService{datasets: datasets, schemaChanges: schemaChanges, versions: versions, minio: minio, masking: masking, catalog: catalog}
 }
 
 func (s *DatasetService) Get(ctx context.Context, id string) (*domain.Dataset, error) {
@@ -24,7 +28,14 @@ func (s *DatasetService) Get(ctx context.Context, id string) (*domain.Dataset, e
 	if !ok {
 		return nil, fmt.Errorf("tenant id not found in context")
 	}
-	return s.datasets.FindByID(ctx, tenantID, id)
+	ds, err := s.datasets.FindByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.masking.RedactColumns(ctx, ds); err != nil {
+		return nil, err
+	}
+	return ds, nil
 }
 
 func (s *DatasetService) List(ctx context.Context, filter domain.DatasetListFilter) ([]domain.Dataset, error) {
@@ -32,7 +43,30 @@ func (s *DatasetService) List(ctx context.Context, filter domain.DatasetListFilt
 	if !ok {
 		return nil, fmt.Errorf("tenant id not found in context")
 	}
-	return s.datasets.ListByTenant(ctx, tenantID, filter)
+	datasets, err := s.datasets.ListByTenant(ctx, tenantID, filter)
+	if err != nil {
+		return nil, err
+	}
+	redacted := make([]*domain.Dataset, len(datasets))
+	for i := range datasets {
+		redacted[i] = &datasets[i]
+	}
+	if err := s.masking.RedactColumns(ctx, redacted...); err != nil {
+		return nil, err
+	}
+	return datasets, nil
+}
+
+// ListSchemaChanges returns the schema change log of a dataset, newest first.
+func (s *DatasetService) ListSchemaChanges(ctx context.Context, id string, limit int) ([]domain.DatasetSchemaChange, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if _, err := s.datasets.FindByID(ctx, tenantID, id); err != nil {
+		return nil, err
+	}
+	return s.schemaChanges.ListByDataset(ctx, tenantID, id, limit)
 }
 
 type UpdateColumnInput struct {
@@ -79,10 +113,17 @@ func (s *DatasetService) UpdateColumns(ctx context.Context, id string, inputs []
 	if err := s.datasets.Update(ctx, ds); err != nil {
 		return nil, fmt.Errorf("update dataset: %w", err)
 	}
+	if err := s.masking.RedactColumns(ctx, ds); err != nil {
+		return nil, err
+	}
 	return ds, nil
 }
 
-func (s *DatasetService) GetRows(ctx context.Context, id string, limit, offset int) (*storage.ParquetRowsResult, error) {
+// GetRows reads a page of a dataset's rows, from the version sel picks or
+// from the current data when sel is zero. The rows are queried in object
+// storage; token is the NextToken of the previous page, and offset the rows
+// to skip when there is none.
+func (s *DatasetService) GetRows(ctx context.Context, id string, sel DatasetVersionSelector, q datasetquery.Query, limit, offset int, token string) (*datasetquery.Page, error) {
 	tenantID, ok := domain.TenantIDFromContext(ctx)
 	if !ok {
 		return nil, fmt.Errorf("tenant id not found in context")
@@ -92,23 +133,66 @@ func (s *DatasetService) GetRows(ctx context.Context, id string, limit, offset i
 	if err != nil {
 		return nil, err
 	}
-	if ds.StoragePath == "" {
-		return nil, fmt.Errorf("dataset has no storage path")
+	v, err := resolveDatasetVersion(ctx, s.versions, tenantID, ds.ID, sel)
+	if err != nil {
+		return nil, err
+	}
+	if q.Masks, err = s.masking.Masks(ctx, ds.ID); err != nil {
+		return nil, err
 	}
-	if s.minio == nil {
-		return nil, fmt.Errorf("storage client not available")
+	duckDB, source, err := openDatasetSource(ctx, s.minio, ds, v)
+	if err != nil {
+		return nil, err
+	}
+	defer duckDB.Close()
+
+	page, err := datasetquery.ReadPage(ctx, duckDB, source, q, limit, offset, token)
+	if err != nil {
+		return nil, err
 	}
+	s.catalog.RecordUsage(ctx, domain.DatasetUsagePreview, "", ds.ID)
+	return page, nil
+}
 
-	tmpDir, err := os.MkdirTemp("", "micro-dp-dataset-preview-*")
+// openDatasetSource opens a DuckDB session reading the tenant's objects
+// through httpfs and returns it with the read_parquet source of a dataset,
+// at version v when set. A dataset stored under a prefix is read from all of
+// its parts.
+func openDatasetSource(ctx context.Context, minio *storage.MinIOClient, ds *domain.Dataset, v *domain.DatasetVersion) (*sql.DB, string, error) {
+	if minio == nil {
+		return nil, "", fmt.Errorf("storage client not available")
+	}
+	s3Cfg := minio.S3Config()
+	source, err := datasetSource(s3Cfg.Bucket, ds, v)
 	if err != nil {
-		return nil, fmt.Errorf("create temp dir: %w", err)
+		return nil, "", err
 	}
-	defer os.RemoveAll(tmpDir)
 
-	localPath := filepath.Join(tmpDir, "data.parquet")
-	if err := s.minio.DownloadToFile(ctx, ds.StoragePath, localPath); err != nil {
-		return nil, fmt.Errorf("download parquet: %w", err)
+	duckDB, err := sql.Open("duckdb", "")
+	if err != nil {
+		return nil, "", fmt.Errorf("open duckdb: %w", err)
 	}
+	if err := storage.ConfigureDuckDBTenantS3(ctx, duckDB, s3Cfg, ds.TenantID); err != nil {
+		duckDB.Close()
+		return nil, "", fmt.Errorf("configure httpfs: %w", err)
+	}
+	return duckDB, source, nil
+}
 
-	return storage.ReadParquetRows(ctx, localPath, limit, offset)
+// datasetSource returns the read_parquet source of a dataset in bucket: the
+// parts of version v when set, otherwise its storage path.
+func datasetSource(bucket string, ds *domain.Dataset, v *domain.DatasetVersion) (string, error) {
+	storagePath := ds.StoragePath
+	var keys []string
+	if v != nil {
+		storagePath = v.StoragePath
+		var err error
+		if keys, err = v.ObjectKeys(); err != nil {
+			return "", fmt.Errorf("parse version parts: %w", err)
+		}
+	}
+	if storagePath == "" {
+		return "", fmt.Errorf("dataset has no storage path")
+	}
+	return storage.S3ParquetSource(bucket, storagePath, keys), nil
 }
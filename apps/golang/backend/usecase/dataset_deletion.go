@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/storage"
+)
+
+// DatasetDeletionConfig holds soft-delete settings.
+type DatasetDeletionConfig struct {
+	RestoreWindow time.Duration // how long a deleted dataset can be restored (0 purges at once)
+	PurgeInterval time.Duration // how often expired datasets are purged (0 disables)
+}
+
+// LoadDatasetDeletionConfig reads soft-delete settings from environment variables.
+func LoadDatasetDeletionConfig() DatasetDeletionConfig {
+	return DatasetDeletionConfig{
+		RestoreWindow: envDuration("DATASET_RESTORE_WINDOW", 7*24*time.Hour),
+		PurgeInterval: envDuration("DATASET_PURGE_INTERVAL", time.Hour),
+	}
+}
+
+type DatasetDeletionService struct {
+	datasets domain.DatasetRepository
+	versions domain.DatasetVersionRepository
+	charts   domain.ChartRepository
+	jobs     domain.JobRepository
+	lineage  domain.LineageRepository
+	metering *MeteringService
+	objects  domain.ObjectStore
+	cfg      DatasetDeletionConfig
+}
+
+func NewDatasetDeletionService(
+	datasets domain.DatasetRepository,
+	versions domain.DatasetVersionRepository,
+	charts domain.ChartRepository,
+	jobs domain.JobRepository,
+	lineage domain.LineageRepository,
+	metering *MeteringService,
+	objects domain.ObjectStore,
+	cfg DatasetDeletionConfig,
+) *DatasetDeletionService {
+	return &DatasetDeletionService{
+		datasets: datasets,
+		versions: versions,
+		charts:   charts,
+		jobs:     jobs,
+		lineage:  lineage,
+		metering: metering,
+		objects:  objects,
+		cfg:      cfg,
+	}
+}
+
+// Delete soft-deletes a dataset. When charts or transform jobs read it, in
+// any of their versions and whether active or not, a
+// *domain.DatasetInUseError is returned unless cascade is set, in which
+// case the charts are deleted and the active jobs deactivated first.
+func (s *DatasetDeletionService) Delete(ctx context.Context, id string, cascade bool) error {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("tenant id not found in context")
+	}
+
+	ds, err := s.datasets.FindByID(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	charts, err := s.charts.ListByDataset(ctx, tenantID, id)
+	if err != nil {
+		return fmt.Errorf("list charts: %w", err)
+	}
+	jobs, err := s.jobs.ListByInputDataset(ctx, tenantID, id)
+	if err != nil {
+		return fmt.Errorf("list jobs: %w", err)
+	}
+
+	if len(charts) > 0 || len(jobs) > 0 {
+		if !cascade {
+			inUse := &domain.DatasetInUseError{}
+			for _, c := range charts {
+				inUse.Charts = append(inUse.Charts, domain.DatasetReference{ID: c.ID, Name: c.Name})
+			}
+			for _, j := range jobs {
+				inUse.Jobs = append(inUse.Jobs, domain.DatasetReference{ID: j.ID, Name: j.Name})
+			}
+			return inUse
+		}
+		for _, c := range charts {
+			if err := s.charts.Delete(ctx, tenantID, c.ID); err != nil {
+				return fmt.Errorf("delete chart %s: %w", c.ID, err)
+			}
+			if err := s.lineage.DeleteNode(ctx, tenantID, domain.LineageNode{Type: domain.LineageNodeChart, ID: c.ID}); err != nil {
+				return fmt.Errorf("delete chart lineage: %w", err)
+			}
+		}
+		for i := range jobs {
+			if !jobs[i].IsActive {
+				continue
+			}
+			jobs[i].IsActive = false
+			if err := s.jobs.Update(ctx, &jobs[i]); err != nil {
+				return fmt.Errorf("deactivate job %s: %w", jobs[i].ID, err)
+			}
+		}
+	}
+
+	if err := s.datasets.SoftDelete(ctx, tenantID, id); err != nil {
+		return err
+	}
+	if s.cfg.RestoreWindow <= 0 {
+		return s.purge(ctx, ds)
+	}
+	return nil
+}
+
+// Restore undeletes a soft-deleted dataset within the restore window.
+func (s *DatasetDeletionService) Restore(ctx context.Context, id string) (*domain.Dataset, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	ds, err := s.datasets.FindDeletedByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if ds.DeletedAt != nil && time.Since(*ds.DeletedAt) > s.cfg.RestoreWindow {
+		return nil, domain.ErrDatasetRestoreExpired
+	}
+	if err := s.datasets.Restore(ctx, tenantID, id); err != nil {
+		return nil, err
+	}
+	return s.datasets.FindByID(ctx, tenantID, id)
+}
+
+// PurgeExpired permanently deletes the datasets whose restore window has
+// passed.
+func (s *DatasetDeletionService) PurgeExpired(ctx context.Context) {
+	expired, err := s.datasets.ListDeletedBefore(ctx, time.Now().Add(-s.cfg.RestoreWindow))
+	if err != nil {
+		log.Printf("dataset purge: list datasets error: %v", err)
+		return
+	}
+	for i := range expired {
+		ds := &expired[i]
+		if err := s.purge(ctx, ds); errors.Is(err, domain.ErrDatasetNotFound) {
+			log.Printf("dataset purge: dataset_id=%s was restored, skipped", ds.ID)
+			continue
+		} else if err != nil {
+			log.Printf("dataset purge: dataset_id=%s error: %v", ds.ID, err)
+			continue
+		}
+		log.Printf("dataset purge: purged dataset_id=%s tenant_id=%s", ds.ID, ds.TenantID)
+	}
+}
+
+// purge deletes a soft-deleted dataset, then removes the objects under
+// its storage path and those its versions owned and credits the freed
+// storage back. The objects are listed first, and only removed once the
+// dataset row is gone, so that a write restoring the dataset in the
+// meantime makes the purge fail with domain.ErrDatasetNotFound instead of
+// losing its data.
+func (s *DatasetDeletionService) purge(ctx context.Context, ds *domain.Dataset) error {
+	objects := make(map[string]bool)
+	switch {
+	case ds.StoragePath == "":
+	case storage.IsParquetObject(ds.StoragePath):
+		objects[ds.StoragePath] = true
+	default:
+		keys, err := s.objects.ListObjectKeys(ctx, strings.TrimSuffix(ds.StoragePath, "/")+"/")
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			objects[key] = true
+		}
+	}
+	versions, err := s.versions.ListByDataset(ctx, ds.TenantID, ds.ID, 0)
+	if err != nil {
+		return fmt.Errorf("list versions: %w", err)
+	}
+	for i := range versions {
+		keys, err := versionObjects(&versions[i])
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			objects[key] = true
+		}
+	}
+
+	if err := s.datasets.DeleteSoftDeleted(ctx, ds.TenantID, ds.ID); err != nil {
+		return fmt.Errorf("delete dataset: %w", err)
+	}
+	if err := s.lineage.DeleteNode(ctx, ds.TenantID, domain.LineageNode{Type: domain.LineageNodeDataset, ID: ds.ID}); err != nil {
+		log.Printf("dataset purge: delete lineage dataset_id=%s: %v", ds.ID, err)
+	}
+
+	// The dataset is gone, so every object is attempted even when some fail
+	var freed int64
+	var removeErr error
+	for key := range objects {
+		size, err := s.objects.ObjectSize(ctx, key)
+		if err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+			removeErr = err
+			continue
+		}
+		if err := s.objects.RemoveObject(ctx, key); err != nil {
+			removeErr = err
+			continue
+		}
+		freed += size
+	}
+
+	rows := 0
+	if ds.RowCount != nil {
+		rows = int(*ds.RowCount)
+	}
+	if err := s.metering.RecordStorageRelease(ctx, ds.TenantID, rows, freed); err != nil {
+		log.Printf("metering record storage release error tenant=%s: %v", ds.TenantID, err)
+	}
+	if removeErr != nil {
+		return fmt.Errorf("remove objects: %w", removeErr)
+	}
+	return nil
+}
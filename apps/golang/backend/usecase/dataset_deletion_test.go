@@ -0,0 +1,367 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/storage"
+)
+
+// deletionBucket is a bucket of objects by key, each 100 bytes.
+type deletionBucket struct {
+	domain.ObjectStore
+	objects map[string]bool
+	removed []string
+}
+
+func (s *deletionBucket) ListObjectKeys(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for k := range s.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (s *deletionBucket) ObjectSize(_ context.Context, key string) (int64, error) {
+	if !s.objects[key] {
+		return 0, storage.ErrObjectNotFound
+	}
+	return 100, nil
+}
+
+func (s *deletionBucket) RemoveObject(_ context.Context, key string) error {
+	delete(s.objects, key)
+	s.removed = append(s.removed, key)
+	return nil
+}
+
+type deletionFixture struct {
+	svc      *DatasetDeletionService
+	sqlDB    *sql.DB
+	datasets *db.DatasetRepo
+	charts   *db.ChartRepo
+	jobs     *db.JobRepo
+	lineage  *db.LineageRepo
+	objects  *deletionBucket
+}
+
+// newDeletionFixture stores the dataset orders, under a prefix and with an
+// older version in a single file, read by a chart, an active and an
+// inactive transform job, and the dataset customers that only a chart
+// reads.
+func newDeletionFixture(t *testing.T, window time.Duration) *deletionFixture {
+	t.Helper()
+	const p = "datasets/t1/orders/"
+	ctx := context.Background()
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	createTestTenant(t, sqlDB, "t2")
+	f := &deletionFixture{
+		sqlDB:    sqlDB,
+		datasets: db.NewDatasetRepo(sqlDB),
+		charts:   db.NewChartRepo(sqlDB),
+		jobs:     db.NewJobRepo(sqlDB),
+		lineage:  db.NewLineageRepo(sqlDB),
+		objects: &deletionBucket{objects: map[string]bool{
+			p + "old.parquet": true, p + "200/part-0.parquet": true, p + "200/part-1.parquet": true,
+			"datasets/t1/customers/100/part-0.parquet": true,
+		}},
+	}
+
+	rows := int64(42)
+	for _, d := range []*domain.Dataset{
+		{ID: "orders", TenantID: "t1", Name: "orders", SourceType: "import", StoragePath: p + "200", RowCount: &rows},
+		{ID: "customers", TenantID: "t1", Name: "customers", SourceType: "import", StoragePath: "datasets/t1/customers/100"},
+	} {
+		if err := f.datasets.Create(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	versions := db.NewDatasetVersionRepo(sqlDB)
+	for _, v := range []*domain.DatasetVersion{
+		{ID: "v1", TenantID: "t1", DatasetID: "orders", StoragePath: p + "old.parquet"},
+		{ID: "v2", TenantID: "t1", DatasetID: "orders", StoragePath: p + "200", ObjectKeysJSON: versionKeys(p + "200/part-0.parquet")},
+	} {
+		if err := versions.Create(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []*domain.Chart{
+		{ID: "ch1", TenantID: "t1", Name: "orders per day", ChartType: "line", DatasetID: "orders", Measure: "orders", Dimension: "day"},
+		{ID: "ch2", TenantID: "t1", Name: "customers", ChartType: "bar", DatasetID: "customers", Measure: "customers", Dimension: "country"},
+	} {
+		if err := f.charts.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.NewModuleTypeRepo(sqlDB).Create(ctx, &domain.ModuleType{ID: "transform", TenantID: "t1", Name: "transform", Category: "transform"}); err != nil {
+		t.Fatal(err)
+	}
+	jobVersions, modules := db.NewJobVersionRepo(sqlDB), db.NewJobModuleRepo(sqlDB)
+	for _, j := range []struct {
+		job    domain.Job
+		inputs string
+	}{
+		{domain.Job{ID: "j1", TenantID: "t1", Name: "clean orders", Slug: "clean-orders", IsActive: true}, `["orders"]`},
+		{domain.Job{ID: "j2", TenantID: "t1", Name: "old report", Slug: "old-report"}, `["customers", "orders"]`},
+	} {
+		if err := f.jobs.Create(ctx, &j.job); err != nil {
+			t.Fatal(err)
+		}
+		v := &domain.JobVersion{ID: j.job.ID + "-v1", TenantID: "t1", JobID: j.job.ID, Version: 1, Status: "published"}
+		if err := jobVersions.Create(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+		if err := modules.Create(ctx, &domain.JobModule{ID: j.job.ID + "-m1", TenantID: "t1", JobVersionID: v.ID, ModuleTypeID: "transform",
+			Name: "transform", ConfigJSON: `{"dataset_ids":` + j.inputs + `}`}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []domain.LineageEdge{
+		{TenantID: "t1", Source: dsNode("orders"), Target: chartNode("ch1")},
+		{TenantID: "t1", Source: dsNode("orders"), Target: jobNode("j1")},
+		{TenantID: "t1", Source: dsNode("customers"), Target: chartNode("ch2")},
+	} {
+		if err := f.lineage.Upsert(ctx, &e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f.svc = NewDatasetDeletionService(f.datasets, versions, f.charts, f.jobs, f.lineage,
+		NewMeteringService(db.NewUsageRepo(sqlDB)), f.objects, DatasetDeletionConfig{RestoreWindow: window})
+	return f
+}
+
+// deleteAt soft-deletes the dataset id with its charts as of at.
+func (f *deletionFixture) deleteAt(t *testing.T, id string, at time.Time) {
+	t.Helper()
+	if err := f.svc.Delete(tenantContext("t1", "u1", domain.TenantRoleAdmin), id, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.sqlDB.Exec(`UPDATE datasets SET deleted_at = ? WHERE id = ?`, at.UTC().Format("2006-01-02 15:04:05"), id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// exists reports whether the dataset id is stored, deleted or not.
+func (f *deletionFixture) exists(t *testing.T, id string) bool {
+	t.Helper()
+	_, err := f.datasets.FindDeletedByID(context.Background(), "t1", id)
+	if errors.Is(err, domain.ErrDatasetNotFound) {
+		_, err = f.datasets.FindByID(context.Background(), "t1", id)
+	}
+	if errors.Is(err, domain.ErrDatasetNotFound) {
+		return false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return true
+}
+
+// deleted reports whether the dataset id is soft-deleted.
+func (f *deletionFixture) deleted(t *testing.T, id string) bool {
+	t.Helper()
+	_, err := f.datasets.FindDeletedByID(context.Background(), "t1", id)
+	if err != nil && !errors.Is(err, domain.ErrDatasetNotFound) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+// chartExists reports whether the chart id is stored.
+func (f *deletionFixture) chartExists(t *testing.T, id string) bool {
+	t.Helper()
+	_, err := f.charts.FindByID(context.Background(), "t1", id)
+	if err != nil && !errors.Is(err, domain.ErrChartNotFound) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+// active reports whether the job id is active.
+func (f *deletionFixture) active(t *testing.T, id string) bool {
+	t.Helper()
+	job, err := f.jobs.FindByID(context.Background(), "t1", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job.IsActive
+}
+
+// usage returns the storage credited back today and the row deltas of
+// the storage release events.
+func (f *deletionFixture) usage(t *testing.T) (int64, []int) {
+	t.Helper()
+	var freed int64
+	if u, err := db.NewUsageRepo(f.sqlDB).FindDailyByTenantAndDate(context.Background(), "t1", today()); err != nil {
+		t.Fatal(err)
+	} else if u != nil {
+		freed = -u.StorageBytes
+	}
+	rows, err := f.sqlDB.Query(`SELECT delta FROM usage_events WHERE event_type = ?`, domain.UsageTypeStorageRelease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var deltas []int
+	for rows.Next() {
+		var d int
+		if err := rows.Scan(&d); err != nil {
+			t.Fatal(err)
+		}
+		deltas = append(deltas, d)
+	}
+	return freed, deltas
+}
+
+func TestDatasetDeleteRefused(t *testing.T) {
+	f := newDeletionFixture(t, time.Hour)
+	err := f.svc.Delete(tenantContext("t1", "u1", domain.TenantRoleAdmin), "orders", false)
+
+	var inUse *domain.DatasetInUseError
+	if !errors.As(err, &inUse) || !errors.Is(err, domain.ErrDatasetInUse) {
+		t.Fatalf("Delete = %v, want a DatasetInUseError", err)
+	}
+	want := &domain.DatasetInUseError{
+		Charts: []domain.DatasetReference{{ID: "ch1", Name: "orders per day"}},
+		// inactive jobs still reference the dataset in their modules
+		Jobs: []domain.DatasetReference{{ID: "j1", Name: "clean orders"}, {ID: "j2", Name: "old report"}},
+	}
+	if !reflect.DeepEqual(inUse, want) {
+		t.Errorf("references = %+v, want %+v", inUse, want)
+	}
+	if f.deleted(t, "orders") || !f.chartExists(t, "ch1") || !f.active(t, "j1") {
+		t.Error("refused delete changed the dataset, its charts or jobs")
+	}
+}
+
+func TestDatasetDeleteCascade(t *testing.T) {
+	f := newDeletionFixture(t, time.Hour)
+	if err := f.svc.Delete(tenantContext("t1", "u1", domain.TenantRoleAdmin), "orders", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if !f.deleted(t, "orders") {
+		t.Error("dataset was not soft-deleted")
+	}
+	if f.chartExists(t, "ch1") {
+		t.Error("chart ch1 was not deleted")
+	}
+	if !f.chartExists(t, "ch2") {
+		t.Error("chart ch2 of another dataset was deleted")
+	}
+	if f.active(t, "j1") || f.active(t, "j2") {
+		t.Error("jobs reading the dataset are still active")
+	}
+	edges, err := f.lineage.ListByTargets(context.Background(), "t1", []domain.LineageNode{chartNode("ch1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edges) != 0 {
+		t.Error("lineage of the deleted chart was kept")
+	}
+	if len(f.objects.removed) != 0 {
+		t.Errorf("soft delete removed objects %v", f.objects.removed)
+	}
+}
+
+func TestDatasetRestoreWindow(t *testing.T) {
+	ctx := tenantContext("t1", "u1", domain.TenantRoleAdmin)
+	f := newDeletionFixture(t, 7*24*time.Hour)
+	f.deleteAt(t, "orders", time.Now().Add(-time.Hour))
+	f.deleteAt(t, "customers", time.Now().Add(-8*24*time.Hour))
+
+	ds, err := f.svc.Restore(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.DeletedAt != nil || f.deleted(t, "orders") {
+		t.Error("restored dataset is still deleted")
+	}
+	if _, err := f.svc.Restore(ctx, "customers"); !errors.Is(err, domain.ErrDatasetRestoreExpired) {
+		t.Errorf("Restore after the window = %v, want %v", err, domain.ErrDatasetRestoreExpired)
+	}
+	if _, err := f.svc.Restore(ctx, "orders"); !errors.Is(err, domain.ErrDatasetNotFound) {
+		t.Errorf("Restore of a live dataset = %v, want %v", err, domain.ErrDatasetNotFound)
+	}
+	if _, err := f.svc.Restore(tenantContext("t2", "u2", domain.TenantRoleAdmin), "customers"); !errors.Is(err, domain.ErrDatasetNotFound) {
+		t.Errorf("Restore by another tenant = %v, want %v", err, domain.ErrDatasetNotFound)
+	}
+}
+
+func TestDatasetPurgeExpired(t *testing.T) {
+	f := newDeletionFixture(t, 7*24*time.Hour)
+	f.deleteAt(t, "orders", time.Now().Add(-8*24*time.Hour))
+	f.deleteAt(t, "customers", time.Now().Add(-time.Hour))
+
+	f.svc.PurgeExpired(context.Background())
+
+	if f.exists(t, "orders") {
+		t.Error("expired dataset was not purged")
+	}
+	if !f.exists(t, "customers") {
+		t.Error("dataset within the restore window was purged")
+	}
+	removed := append([]string(nil), f.objects.removed...)
+	sort.Strings(removed)
+	want := []string{"datasets/t1/orders/200/part-0.parquet", "datasets/t1/orders/200/part-1.parquet", "datasets/t1/orders/old.parquet"}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed = %v, want %v", removed, want)
+	}
+	if freed, deltas := f.usage(t); freed != 300 || !reflect.DeepEqual(deltas, []int{-42}) {
+		t.Errorf("usage = %d bytes, row deltas %v; want 300 bytes and 42 rows credited back", freed, deltas)
+	}
+	edges, err := f.lineage.ListBySources(context.Background(), "t1", []domain.LineageNode{dsNode("orders")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range edges {
+		t.Errorf("lineage edge %s of the purged dataset was kept", e.ID)
+	}
+}
+
+func TestDatasetPurgeRestoredMeanwhile(t *testing.T) {
+	// A write restores the dataset after PurgeExpired listed it: the purge
+	// fails before any object is removed
+	f := newDeletionFixture(t, 7*24*time.Hour)
+	listed, err := f.datasets.FindByID(context.Background(), "t1", "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-8 * 24 * time.Hour)
+	listed.DeletedAt = &old
+
+	if err := f.svc.purge(context.Background(), listed); !errors.Is(err, domain.ErrDatasetNotFound) {
+		t.Fatalf("purge = %v, want %v", err, domain.ErrDatasetNotFound)
+	}
+	if freed, _ := f.usage(t); len(f.objects.removed) != 0 || freed != 0 {
+		t.Errorf("purge of a restored dataset removed %v", f.objects.removed)
+	}
+	if !f.exists(t, "orders") {
+		t.Error("restored dataset was deleted")
+	}
+}
+
+func TestDatasetDeleteWithoutRestoreWindow(t *testing.T) {
+	f := newDeletionFixture(t, 0)
+	if err := f.svc.Delete(tenantContext("t1", "u1", domain.TenantRoleAdmin), "customers", true); err != nil {
+		t.Fatal(err)
+	}
+	if f.exists(t, "customers") {
+		t.Error("dataset was not purged at once")
+	}
+	if !reflect.DeepEqual(f.objects.removed, []string{"datasets/t1/customers/100/part-0.parquet"}) {
+		t.Errorf("removed = %v, want the customers part", f.objects.removed)
+	}
+}
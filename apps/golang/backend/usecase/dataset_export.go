@@ -0,0 +1,406 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/datasetquery"
+	"github.com/user/micro-dp/storage"
+)
+
+// DatasetExportConfig holds dataset export settings.
+type DatasetExportConfig struct {
+	URLExpiry time.Duration // lifetime of export download links
+}
+
+// LoadDatasetExportConfig reads export settings from environment variables.
+func LoadDatasetExportConfig() DatasetExportConfig {
+	return DatasetExportConfig{
+		URLExpiry: envDuration("DATASET_EXPORT_URL_EXPIRY", time.Hour),
+	}
+}
+
+// ValidDatasetExportFormat reports whether f is a known export format.
+func ValidDatasetExportFormat(f string) bool {
+	switch f {
+	case domain.DatasetExportCSV, domain.DatasetExportJSONL, domain.DatasetExportParquet:
+		return true
+	}
+	return false
+}
+
+// copyOptions are the DuckDB COPY options writing each export format.
+var copyOptions = map[string]string{
+	domain.DatasetExportCSV:     "FORMAT csv, HEADER",
+	domain.DatasetExportJSONL:   "FORMAT json",
+	domain.DatasetExportParquet: "FORMAT parquet",
+}
+
+type DatasetExportService struct {
+	datasets domain.DatasetRepository
+	versions domain.DatasetVersionRepository
+	exports  domain.DatasetExportRepository
+	queue    domain.DatasetExportQueue
+	minio    *storage.MinIOClient
+	presign  *storage.MinIOPresignClient
+	masking  *DatasetMaskingService
+	cfg      DatasetExportConfig
+}
+
+func NewDatasetExportService(
+	datasets domain.DatasetRepository,
+	versions domain.DatasetVersionRepository,
+	exports domain.DatasetExportRepository,
+	queue domain.DatasetExportQueue,
+	minio *storage.MinIOClient,
+	presign *storage.MinIOPresignClient,
+	masking *DatasetMaskingService,
+	cfg DatasetExportConfig,
+) *DatasetExportService {
+	return &DatasetExportService{
+		datasets: datasets,
+		versions: versions,
+		exports:  exports,
+		queue:    queue,
+		minio:    minio,
+		presign:  presign,
+		masking:  masking,
+		cfg:      cfg,
+	}
+}
+
+// DatasetExportStream is an export whose query is already running, so that
+// errors surface before any of it is written. It must be closed.
+type DatasetExportStream struct {
+	Dataset *domain.Dataset
+	Format  string
+	db      *sql.DB
+	columns []datasetquery.Column
+	rows    *sql.Rows // csv and jsonl
+	file    *os.File  // parquet
+	tmpDir  string
+}
+
+// OpenStream starts exporting a dataset, at the version sel picks, in format.
+// CSV and JSON lines are read from object storage a chunk at a time while
+// they are written; Parquet is written to a temporary file first.
+func (s *DatasetExportService) OpenStream(ctx context.Context, id, format string, sel DatasetVersionSelector, q datasetquery.Query) (*DatasetExportStream, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if !ValidDatasetExportFormat(format) {
+		return nil, fmt.Errorf("%w: unknown format %q", datasetquery.ErrInvalid, format)
+	}
+
+	ds, err := s.datasets.FindByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	v, err := resolveDatasetVersion(ctx, s.versions, tenantID, ds.ID, sel)
+	if err != nil {
+		return nil, err
+	}
+	if q.Masks, err = s.masking.Masks(ctx, ds.ID); err != nil {
+		return nil, err
+	}
+	duckDB, query, cols, err := s.openQuery(ctx, ds, v, q)
+	if err != nil {
+		return nil, err
+	}
+	st := &DatasetExportStream{Dataset: ds, Format: format, db: duckDB, columns: cols}
+
+	switch format {
+	case domain.DatasetExportCSV:
+		casts := make([]string, len(cols))
+		for i, c := range cols {
+			casts[i] = fmt.Sprintf("CAST(%s AS VARCHAR)", datasetquery.QuoteIdentifier(c.Name))
+		}
+		st.rows, err = duckDB.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM (%s) AS _q", strings.Join(casts, ", "), query))
+	case domain.DatasetExportJSONL:
+		st.rows, err = duckDB.QueryContext(ctx, fmt.Sprintf("SELECT CAST(to_json(_q) AS VARCHAR) FROM (%s) AS _q", query))
+	case domain.DatasetExportParquet:
+		if st.tmpDir, err = os.MkdirTemp("", "micro-dp-dataset-export-*"); err != nil {
+			break
+		}
+		path := filepath.Join(st.tmpDir, "export.parquet")
+		if _, err = duckDB.ExecContext(ctx, fmt.Sprintf("COPY (%s) TO %s (%s)", query, datasetquery.QuoteLiteral(path), copyOptions[format])); err != nil {
+			break
+		}
+		st.file, err = os.Open(path)
+	}
+	if err != nil {
+		st.Close()
+		return nil, fmt.Errorf("export dataset: %w", err)
+	}
+	return st, nil
+}
+
+// WriteTo writes the export to w.
+func (st *DatasetExportStream) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	var err error
+	switch {
+	case st.file != nil:
+		_, err = io.Copy(cw, st.file)
+	case st.Format == domain.DatasetExportCSV:
+		err = st.writeCSV(cw)
+	default:
+		err = st.writeLines(cw)
+	}
+	return cw.n, err
+}
+
+func (st *DatasetExportStream) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(st.columns))
+	for i, c := range st.columns {
+		record[i] = c.Name
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	values := make([]sql.NullString, len(st.columns))
+	ptrs := make([]any, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for st.rows.Next() {
+		if err := st.rows.Scan(ptrs...); err != nil {
+			return fmt.Errorf("scan row: %w", err)
+		}
+		for i, v := range values {
+			record[i] = v.String
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := st.rows.Err(); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (st *DatasetExportStream) writeLines(w io.Writer) error {
+	var line string
+	for st.rows.Next() {
+		if err := st.rows.Scan(&line); err != nil {
+			return fmt.Errorf("scan row: %w", err)
+		}
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return st.rows.Err()
+}
+
+func (st *DatasetExportStream) Close() error {
+	if st.rows != nil {
+		st.rows.Close()
+	}
+	if st.file != nil {
+		st.file.Close()
+	}
+	if st.tmpDir != "" {
+		os.RemoveAll(st.tmpDir)
+	}
+	return st.db.Close()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Create queues an export of a dataset for the worker. The projection and
+// filters are checked against the dataset now, and an as_of selector is
+// resolved to the version it picks.
+func (s *DatasetExportService) Create(ctx context.Context, id, format string, sel DatasetVersionSelector, q datasetquery.Query) (*domain.DatasetExport, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if !ValidDatasetExportFormat(format) {
+		return nil, fmt.Errorf("%w: unknown format %q", datasetquery.ErrInvalid, format)
+	}
+
+	ds, err := s.datasets.FindByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	v, err := resolveDatasetVersion(ctx, s.versions, tenantID, ds.ID, sel)
+	if err != nil {
+		return nil, err
+	}
+	// The worker runs the export without the caller, so the masks that
+	// apply to them are stored with the query
+	if q.Masks, err = s.masking.Masks(ctx, ds.ID); err != nil {
+		return nil, err
+	}
+	duckDB, _, _, err := s.openQuery(ctx, ds, v, q)
+	if err != nil {
+		return nil, err
+	}
+	duckDB.Close()
+
+	queryJSON, err := json.Marshal(q)
+	if err != nil {
+		return nil, err
+	}
+	e := &domain.DatasetExport{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		DatasetID: ds.ID,
+		Format:    format,
+		QueryJSON: string(queryJSON),
+		Status:    domain.DatasetExportQueued,
+	}
+	if v != nil {
+		e.Version = &v.Version
+	}
+	if err := s.exports.Create(ctx, e); err != nil {
+		return nil, fmt.Errorf("create export: %w", err)
+	}
+	if err := s.queue.Enqueue(ctx, &domain.DatasetExportMessage{ExportID: e.ID, TenantID: tenantID}); err != nil {
+		return nil, fmt.Errorf("enqueue export: %w", err)
+	}
+	return s.exports.FindByID(ctx, tenantID, e.ID)
+}
+
+// DatasetExportResult is an export with a download link once it completed.
+type DatasetExportResult struct {
+	*domain.DatasetExport
+	DownloadURL string
+	ExpiresAt   *time.Time
+}
+
+func (s *DatasetExportService) Get(ctx context.Context, datasetID, exportID string) (*DatasetExportResult, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	ds, err := s.datasets.FindByID(ctx, tenantID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.exports.FindByID(ctx, tenantID, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if e.DatasetID != ds.ID {
+		return nil, domain.ErrDatasetExportNotFound
+	}
+
+	out := &DatasetExportResult{DatasetExport: e}
+	if e.Status == domain.DatasetExportCompleted && e.ObjectKey != nil && s.presign != nil {
+		url, expiresAt, err := s.presign.GeneratePresignedGetURL(ctx, *e.ObjectKey, ds.Name+"."+e.Format, s.cfg.URLExpiry)
+		if err != nil {
+			return nil, err
+		}
+		out.DownloadURL = url
+		out.ExpiresAt = &expiresAt
+	}
+	return out, nil
+}
+
+// Run writes a queued export to object storage (called by Worker). DuckDB
+// writes the file to the bucket directly.
+func (s *DatasetExportService) Run(ctx context.Context, tenantID, exportID string) error {
+	e, err := s.exports.FindByID(ctx, tenantID, exportID)
+	if err != nil {
+		return err
+	}
+	if e.Status != domain.DatasetExportQueued {
+		return nil
+	}
+	e.Status = domain.DatasetExportRunning
+	if err := s.exports.Update(ctx, e); err != nil {
+		return fmt.Errorf("update export: %w", err)
+	}
+
+	key, size, err := s.write(ctx, e)
+	now := time.Now().UTC()
+	e.CompletedAt = &now
+	if err != nil {
+		msg := err.Error()
+		e.Status = domain.DatasetExportFailed
+		e.ErrorMessage = &msg
+	} else {
+		e.Status = domain.DatasetExportCompleted
+		e.ObjectKey = &key
+		e.SizeBytes = &size
+	}
+	if uerr := s.exports.Update(ctx, e); uerr != nil {
+		return fmt.Errorf("update export: %w", uerr)
+	}
+	return err
+}
+
+func (s *DatasetExportService) write(ctx context.Context, e *domain.DatasetExport) (string, int64, error) {
+	ds, err := s.datasets.FindByID(ctx, e.TenantID, e.DatasetID)
+	if err != nil {
+		return "", 0, err
+	}
+	var v *domain.DatasetVersion
+	if e.Version != nil {
+		if v, err = s.versions.FindByVersion(ctx, e.TenantID, ds.ID, *e.Version); err != nil {
+			return "", 0, err
+		}
+	}
+	var q datasetquery.Query
+	if err := json.Unmarshal([]byte(e.QueryJSON), &q); err != nil {
+		return "", 0, fmt.Errorf("parse export query: %w", err)
+	}
+
+	duckDB, query, _, err := s.openQuery(ctx, ds, v, q)
+	if err != nil {
+		return "", 0, err
+	}
+	defer duckDB.Close()
+
+	key := fmt.Sprintf("exports/%s/%s.%s", e.TenantID, e.ID, e.Format)
+	uri := fmt.Sprintf("s3://%s/%s", s.minio.S3Config().Bucket, key)
+	if _, err := duckDB.ExecContext(ctx, fmt.Sprintf("COPY (%s) TO %s (%s)", query, datasetquery.QuoteLiteral(uri), copyOptions[e.Format])); err != nil {
+		return "", 0, fmt.Errorf("write export: %w", err)
+	}
+	size, err := s.minio.ObjectSize(ctx, key)
+	if err != nil {
+		return "", 0, err
+	}
+	return key, size, nil
+}
+
+// openQuery opens a DuckDB session reading the dataset, at version v when
+// set, from object storage and builds the query q selects.
+func (s *DatasetExportService) openQuery(ctx context.Context, ds *domain.Dataset, v *domain.DatasetVersion, q datasetquery.Query) (*sql.DB, string, []datasetquery.Column, error) {
+	duckDB, source, err := openDatasetSource(ctx, s.minio, ds, v)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	query, cols, err := datasetquery.Build(ctx, duckDB, source, q)
+	if err != nil {
+		duckDB.Close()
+		return nil, "", nil, err
+	}
+	return duckDB, query, cols, nil
+}
@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/pii"
+)
+
+// PIIConfig holds who may read masked columns raw.
+type PIIConfig struct {
+	UnmaskedRoles []string // tenant roles masking policies do not apply to
+}
+
+// LoadPIIConfig reads PII settings from environment variables.
+// PII_UNMASKED_ROLES is a comma-separated list of tenant roles.
+func LoadPIIConfig() PIIConfig {
+	cfg := PIIConfig{UnmaskedRoles: []string{domain.TenantRoleOwner, domain.TenantRoleAdmin}}
+	if v, ok := os.LookupEnv("PII_UNMASKED_ROLES"); ok {
+		cfg.UnmaskedRoles = nil
+		for _, role := range strings.Split(v, ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				cfg.UnmaskedRoles = append(cfg.UnmaskedRoles, role)
+			}
+		}
+	}
+	return cfg
+}
+
+type DatasetMaskingService struct {
+	policies domain.DatasetMaskingPolicyRepository
+	datasets domain.DatasetRepository
+	tenants  domain.TenantRepository
+	cfg      PIIConfig
+}
+
+func NewDatasetMaskingService(policies domain.DatasetMaskingPolicyRepository, datasets domain.DatasetRepository, tenants domain.TenantRepository, cfg PIIConfig) *DatasetMaskingService {
+	return &DatasetMaskingService{policies: policies, datasets: datasets, tenants: tenants, cfg: cfg}
+}
+
+func (s *DatasetMaskingService) List(ctx context.Context, datasetID string) ([]domain.DatasetMaskingPolicy, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if _, err := s.datasets.FindByID(ctx, tenantID, datasetID); err != nil {
+		return nil, err
+	}
+	return s.policies.ListByDataset(ctx, tenantID, datasetID)
+}
+
+// Set masks a column of a dataset with policy. Only owners and admins may
+// change masking.
+func (s *DatasetMaskingService) Set(ctx context.Context, datasetID, column, policy string) (*domain.DatasetMaskingPolicy, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if err := checkOwnerOrAdmin(ctx, s.tenants, tenantID); err != nil {
+		return nil, err
+	}
+	if !pii.ValidPolicy(policy) {
+		return nil, fmt.Errorf("%w: unknown policy %q", pii.ErrInvalid, policy)
+	}
+	ds, err := s.datasets.FindByID(ctx, tenantID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := ds.ParseColumns()
+	if err != nil {
+		return nil, fmt.Errorf("parse columns: %w", err)
+	}
+	found := false
+	for _, c := range cols {
+		found = found || c.Name == column
+	}
+	if !found {
+		return nil, fmt.Errorf("column %q: %w", column, domain.ErrColumnNotFound)
+	}
+
+	p := &domain.DatasetMaskingPolicy{
+		ID:         uuid.New().String(),
+		TenantID:   tenantID,
+		DatasetID:  datasetID,
+		ColumnName: column,
+		Policy:     policy,
+	}
+	if err := s.policies.Upsert(ctx, p); err != nil {
+		return nil, err
+	}
+	policies, err := s.policies.ListByDataset(ctx, tenantID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range policies {
+		if policies[i].ColumnName == column {
+			return &policies[i], nil
+		}
+	}
+	return nil, domain.ErrMaskingPolicyNotFound
+}
+
+// Delete unmasks a column. Only owners and admins may change masking.
+func (s *DatasetMaskingService) Delete(ctx context.Context, datasetID, column string) error {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("tenant id not found in context")
+	}
+	if err := checkOwnerOrAdmin(ctx, s.tenants, tenantID); err != nil {
+		return err
+	}
+	if _, err := s.datasets.FindByID(ctx, tenantID, datasetID); err != nil {
+		return err
+	}
+	return s.policies.Delete(ctx, tenantID, datasetID, column)
+}
+
+// Masks returns the masking policies of a dataset's columns that apply to
+// the caller, by column name: none when their role reads them raw.
+func (s *DatasetMaskingService) Masks(ctx context.Context, datasetID string) (map[string]string, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if unmasked, err := s.unmasked(ctx, tenantID); err != nil || unmasked {
+		return nil, err
+	}
+	policies, err := s.policies.ListByDataset(ctx, tenantID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	return masksOf(policies)[datasetID], nil
+}
+
+// RedactColumns removes the sample values and bounds of the columns masked
+// for the caller from the catalog entries of datasets.
+func (s *DatasetMaskingService) RedactColumns(ctx context.Context, datasets ...*domain.Dataset) error {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("tenant id not found in context")
+	}
+	if unmasked, err := s.unmasked(ctx, tenantID); err != nil || unmasked {
+		return err
+	}
+	policies, err := s.policies.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	masks := masksOf(policies)
+	for _, ds := range datasets {
+		if len(masks[ds.ID]) == 0 {
+			continue
+		}
+		cols, err := ds.ParseColumns()
+		if err != nil {
+			return fmt.Errorf("parse columns: %w", err)
+		}
+		for i := range cols {
+			if _, ok := masks[ds.ID][cols[i].Name]; !ok {
+				continue
+			}
+			cols[i].SampleValues = nil
+			if cols[i].Statistics != nil {
+				cols[i].Statistics.Min = nil
+				cols[i].Statistics.Max = nil
+			}
+		}
+		if err := ds.SetColumns(cols); err != nil {
+			return fmt.Errorf("set columns: %w", err)
+		}
+	}
+	return nil
+}
+
+// unmasked reports whether the caller's role reads masked columns raw.
+// Callers without a user, such as write keys, never do.
+func (s *DatasetMaskingService) unmasked(ctx context.Context, tenantID string) (bool, error) {
+	userID, ok := domain.UserIDFromContext(ctx)
+	if !ok {
+		return false, nil
+	}
+	role, err := s.tenants.GetUserRole(ctx, userID, tenantID)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range s.cfg.UnmaskedRoles {
+		if r == role {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// masksOf groups policies by dataset and then column.
+func masksOf(policies []domain.DatasetMaskingPolicy) map[string]map[string]string {
+	masks := make(map[string]map[string]string)
+	for _, p := range policies {
+		if masks[p.DatasetID] == nil {
+			masks[p.DatasetID] = make(map[string]string)
+		}
+		masks[p.DatasetID][p.ColumnName] = p.Policy
+	}
+	return masks
+}
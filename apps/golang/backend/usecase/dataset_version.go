@@ -0,0 +1,344 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/storage"
+)
+
+// DatasetVersionConfig holds dataset version garbage collection settings.
+type DatasetVersionConfig struct {
+	Keep       int           // latest versions kept per dataset, besides pinned ones (0 keeps all)
+	MinAge     time.Duration // versions younger than this are always kept
+	GCInterval time.Duration // how often old versions are collected (0 disables)
+}
+
+// LoadDatasetVersionConfig reads version retention settings from environment variables.
+func LoadDatasetVersionConfig() DatasetVersionConfig {
+	return DatasetVersionConfig{
+		Keep:       envInt("DATASET_VERSION_RETENTION_COUNT", 20),
+		MinAge:     envDuration("DATASET_VERSION_RETENTION_MIN_AGE", 7*24*time.Hour),
+		GCInterval: envDuration("DATASET_VERSION_GC_INTERVAL", time.Hour),
+	}
+}
+
+// DatasetVersionSelector picks the data a read sees: Version when set,
+// else the latest version created at or before AsOf, else the current data.
+type DatasetVersionSelector struct {
+	Version int
+	AsOf    *time.Time
+}
+
+func (s DatasetVersionSelector) IsZero() bool {
+	return s.Version == 0 && s.AsOf == nil
+}
+
+type DatasetVersionService struct {
+	datasets domain.DatasetRepository
+	versions domain.DatasetVersionRepository
+	policies domain.RetentionPolicyRepository
+	objects  domain.ObjectStore
+	cfg      DatasetVersionConfig
+}
+
+func NewDatasetVersionService(datasets domain.DatasetRepository, versions domain.DatasetVersionRepository, policies domain.RetentionPolicyRepository, objects domain.ObjectStore, cfg DatasetVersionConfig) *DatasetVersionService {
+	return &DatasetVersionService{datasets: datasets, versions: versions, policies: policies, objects: objects, cfg: cfg}
+}
+
+// List returns the versions of a dataset, newest first.
+func (s *DatasetVersionService) List(ctx context.Context, datasetID string, limit int) ([]domain.DatasetVersion, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if _, err := s.datasets.FindByID(ctx, tenantID, datasetID); err != nil {
+		return nil, err
+	}
+	return s.versions.ListByDataset(ctx, tenantID, datasetID, limit)
+}
+
+func (s *DatasetVersionService) Get(ctx context.Context, datasetID string, version int) (*domain.DatasetVersion, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	return s.versions.FindByVersion(ctx, tenantID, datasetID, version)
+}
+
+// SetPinned pins a version, which exempts it from garbage collection, or
+// unpins it.
+func (s *DatasetVersionService) SetPinned(ctx context.Context, datasetID string, version int, pinned bool) (*domain.DatasetVersion, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if err := s.versions.SetPinned(ctx, tenantID, datasetID, version, pinned); err != nil {
+		return nil, err
+	}
+	return s.versions.FindByVersion(ctx, tenantID, datasetID, version)
+}
+
+// Rollback makes the data of a prior version current again. The history
+// stays append-only: the restored data is recorded as a new version.
+func (s *DatasetVersionService) Rollback(ctx context.Context, datasetID string, version int) (*domain.DatasetVersion, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	ds, err := s.datasets.FindByID(ctx, tenantID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.versions.FindByVersion(ctx, tenantID, datasetID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	storagePath := v.StoragePath
+	objectKeysJSON := v.ObjectKeysJSON
+	if !storage.IsParquetObject(storagePath) {
+		keys, err := v.ObjectKeys()
+		if err != nil {
+			return nil, fmt.Errorf("parse version parts: %w", err)
+		}
+		if keys == nil {
+			return nil, fmt.Errorf("%w: version %d predates tracking of its parts", domain.ErrDatasetVersionUnavailable, version)
+		}
+		// Later appends add parts to the version's prefix, so the parts
+		// are copied to a prefix of their own
+		storagePath = fmt.Sprintf("datasets/%s/%s/%d", tenantID, ds.ID, now.UnixNano())
+		restored := make([]string, len(keys))
+		for i, key := range keys {
+			restored[i] = fmt.Sprintf("%s/part-%05d.parquet", storagePath, i)
+			if err := s.objects.CopyObject(ctx, key, restored[i]); err != nil {
+				return nil, fmt.Errorf("%w: %v", domain.ErrDatasetVersionUnavailable, err)
+			}
+		}
+		data, err := json.Marshal(restored)
+		if err != nil {
+			return nil, err
+		}
+		keysJSON := string(data)
+		objectKeysJSON = &keysJSON
+	}
+
+	schemaJSON, err := restoreColumnMetadata(ds, v.SchemaJSON)
+	if err != nil {
+		return nil, err
+	}
+	ds.StoragePath = storagePath
+	ds.SchemaJSON = schemaJSON
+	ds.RowCount = v.RowCount
+	ds.LastUpdatedAt = &now
+	if err := s.datasets.Update(ctx, ds); err != nil {
+		return nil, fmt.Errorf("update dataset: %w", err)
+	}
+
+	restored := &domain.DatasetVersion{
+		ID:             uuid.New().String(),
+		TenantID:       tenantID,
+		DatasetID:      ds.ID,
+		StoragePath:    storagePath,
+		ObjectKeysJSON: objectKeysJSON,
+		SchemaJSON:     schemaJSON,
+		RowCount:       v.RowCount,
+		RestoredFrom:   &v.Version,
+	}
+	if err := s.versions.Create(ctx, restored); err != nil {
+		return nil, fmt.Errorf("record version: %w", err)
+	}
+	return restored, nil
+}
+
+// restoreColumnMetadata returns a version's schema with the descriptions,
+// semantic types and tags the dataset's columns have now, so that a
+// rollback restores data without undoing catalog edits.
+func restoreColumnMetadata(ds *domain.Dataset, schemaJSON *string) (*string, error) {
+	if schemaJSON == nil {
+		return nil, nil
+	}
+	current, err := ds.ParseColumns()
+	if err != nil {
+		return nil, fmt.Errorf("parse columns: %w", err)
+	}
+	restored := &domain.Dataset{SchemaJSON: schemaJSON}
+	cols, err := restored.ParseColumns()
+	if err != nil {
+		return nil, fmt.Errorf("parse version columns: %w", err)
+	}
+	byName := make(map[string]domain.DatasetColumnMeta, len(current))
+	for _, c := range current {
+		byName[c.Name] = c
+	}
+	for i, c := range cols {
+		if cur, ok := byName[c.Name]; ok {
+			cols[i].Description = cur.Description
+			cols[i].SemanticType = cur.SemanticType
+			cols[i].Tags = cur.Tags
+		}
+	}
+	if err := restored.SetColumns(cols); err != nil {
+		return nil, fmt.Errorf("set columns: %w", err)
+	}
+	return restored.SchemaJSON, nil
+}
+
+// resolveDatasetVersion returns the version sel picks for a dataset, or
+// nil when sel is zero and the current data is read.
+func resolveDatasetVersion(ctx context.Context, versions domain.DatasetVersionRepository, tenantID, datasetID string, sel DatasetVersionSelector) (*domain.DatasetVersion, error) {
+	switch {
+	case sel.Version > 0:
+		return versions.FindByVersion(ctx, tenantID, datasetID, sel.Version)
+	case sel.AsOf != nil:
+		v, err := versions.FindAsOf(ctx, tenantID, datasetID, *sel.AsOf)
+		if errors.Is(err, domain.ErrDatasetVersionNotFound) {
+			return nil, fmt.Errorf("%w: no version as of %s", err, sel.AsOf.UTC().Format(time.RFC3339))
+		}
+		return v, err
+	}
+	return nil, nil
+}
+
+// CollectGarbage deletes versions beyond the retention settings together
+// with the objects no remaining version or the current data uses. Pinned
+// versions and the latest Keep versions are never collected, nor are the
+// versions of datasets whose retention policy sets keep_versions.
+func (s *DatasetVersionService) CollectGarbage(ctx context.Context) {
+	if s.cfg.Keep <= 0 {
+		return
+	}
+	keys, err := s.versions.ListDatasetsOverCount(ctx, s.cfg.Keep)
+	if err != nil {
+		log.Printf("dataset version gc: list datasets error: %v", err)
+		return
+	}
+	for _, k := range keys {
+		n, err := s.collectDataset(ctx, k)
+		if err != nil {
+			log.Printf("dataset version gc: dataset_id=%s error: %v", k.DatasetID, err)
+		}
+		if n > 0 {
+			log.Printf("dataset version gc: deleted versions=%d dataset_id=%s", n, k.DatasetID)
+		}
+	}
+}
+
+func (s *DatasetVersionService) collectDataset(ctx context.Context, k domain.DatasetKey) (int, error) {
+	// Datasets whose retention policy keeps a number of versions are left
+	// to the retention sweeper
+	policy, err := effectiveRetention(ctx, s.policies, k.TenantID, k.DatasetID)
+	if err != nil {
+		return 0, err
+	}
+	if policy.KeepVersions != nil {
+		return 0, nil
+	}
+
+	ds, err := s.datasets.FindByID(ctx, k.TenantID, k.DatasetID)
+	if err != nil {
+		return 0, err
+	}
+	versions, err := s.versions.ListByDataset(ctx, k.TenantID, k.DatasetID, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-s.cfg.MinAge)
+	pruned, err := pruneVersions(ctx, s.versions, ds, versions,
+		func(i int, v *domain.DatasetVersion) bool {
+			return i < s.cfg.Keep || v.Pinned || v.CreatedAt.After(cutoff)
+		},
+		func(ctx context.Context, key string) (int64, error) {
+			return 0, s.objects.RemoveObject(ctx, key)
+		},
+	)
+	return len(pruned), err
+}
+
+// prunedVersion is a deleted dataset version with the objects disposed of
+// and their size, when measured.
+type prunedVersion struct {
+	version domain.DatasetVersion
+	objects int
+	bytes   int64
+}
+
+// pruneVersions deletes the versions of a dataset, newest first, that keep
+// rejects. Objects no remaining version or the current data uses are
+// handed to dispose first.
+func pruneVersions(
+	ctx context.Context,
+	repo domain.DatasetVersionRepository,
+	ds *domain.Dataset,
+	versions []domain.DatasetVersion,
+	keep func(i int, v *domain.DatasetVersion) bool,
+	dispose func(ctx context.Context, key string) (int64, error),
+) ([]prunedVersion, error) {
+	inUse := map[string]bool{ds.StoragePath: true}
+	var expired []domain.DatasetVersion
+	for i, v := range versions {
+		if keep(i, &v) {
+			objects, err := versionObjects(&v)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range objects {
+				inUse[key] = true
+			}
+			continue
+		}
+		expired = append(expired, v)
+	}
+	// Parts under the current prefix may be current data even when no
+	// kept version lists them
+	currentPrefix := ""
+	if ds.StoragePath != "" && !storage.IsParquetObject(ds.StoragePath) {
+		currentPrefix = strings.TrimSuffix(ds.StoragePath, "/") + "/"
+	}
+
+	var pruned []prunedVersion
+	for _, v := range expired {
+		objects, err := versionObjects(&v)
+		if err != nil {
+			return pruned, err
+		}
+		p := prunedVersion{version: v}
+		for _, key := range objects {
+			if inUse[key] || (currentPrefix != "" && strings.HasPrefix(key, currentPrefix)) {
+				continue
+			}
+			size, err := dispose(ctx, key)
+			if err != nil {
+				return pruned, err
+			}
+			// Parts shared by several expired versions are disposed of once
+			inUse[key] = true
+			p.objects++
+			p.bytes += size
+		}
+		if err := repo.Delete(ctx, v.TenantID, v.DatasetID, v.Version); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, p)
+	}
+	return pruned, nil
+}
+
+// versionObjects returns the objects a version owns. A prefix recorded
+// without its parts owns none, since they may still be current.
+func versionObjects(v *domain.DatasetVersion) ([]string, error) {
+	if storage.IsParquetObject(v.StoragePath) {
+		return []string{v.StoragePath}, nil
+	}
+	return v.ObjectKeys()
+}
@@ -0,0 +1,323 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/storage"
+)
+
+// versionBucket is a bucket of objects by key, each 100 bytes.
+type versionBucket struct {
+	domain.ObjectStore
+	objects map[string]bool
+	removed []string
+	copied  map[string]string // destination to source
+}
+
+func (s *versionBucket) ObjectSize(_ context.Context, key string) (int64, error) {
+	if !s.objects[key] {
+		return 0, storage.ErrObjectNotFound
+	}
+	return 100, nil
+}
+
+func (s *versionBucket) CopyObject(_ context.Context, src, dst string) error {
+	if !s.objects[src] {
+		return storage.ErrObjectNotFound
+	}
+	s.objects[dst] = true
+	s.copied[dst] = src
+	return nil
+}
+
+func (s *versionBucket) RemoveObject(_ context.Context, key string) error {
+	delete(s.objects, key)
+	s.removed = append(s.removed, key)
+	return nil
+}
+
+type versionFixture struct {
+	datasets *db.DatasetRepo
+	versions *db.DatasetVersionRepo
+	policies *db.RetentionPolicyRepo
+	objects  *versionBucket
+}
+
+// newVersionFixture stores a dataset that was written as a single file,
+// then replaced by a prefix appended to twice, then replaced by another
+// prefix appended to once, with versions created an hour ago:
+//
+//	v1 old.parquet
+//	v2 200/part-0
+//	v3 200/part-0 200/part-1
+//	v4 200/part-0 200/part-1 200/part-2
+//	v5 300/part-0
+//	v6 300/part-0 300/part-1 (current)
+//
+// edit, when given, changes each version before it is stored.
+func newVersionFixture(t *testing.T, edit func(*domain.DatasetVersion)) *versionFixture {
+	t.Helper()
+	const p = "datasets/t1/d1/"
+	ctx := context.Background()
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	f := &versionFixture{
+		datasets: db.NewDatasetRepo(sqlDB),
+		versions: db.NewDatasetVersionRepo(sqlDB),
+		policies: db.NewRetentionPolicyRepo(sqlDB),
+		objects:  &versionBucket{objects: map[string]bool{}, copied: map[string]string{}},
+	}
+	for _, key := range []string{"old.parquet", "200/part-0.parquet", "200/part-1.parquet", "200/part-2.parquet", "300/part-0.parquet", "300/part-1.parquet"} {
+		f.objects.objects[p+key] = true
+	}
+	rows := func(n int64) *int64 { return &n }
+	if err := f.datasets.Create(ctx, &domain.Dataset{ID: "d1", TenantID: "t1", Name: "orders", SourceType: "import", StoragePath: p + "300", RowCount: rows(60)}); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range []domain.DatasetVersion{
+		{StoragePath: p + "old.parquet", RowCount: rows(10)},
+		{StoragePath: p + "200", ObjectKeysJSON: versionKeys(p + "200/part-0.parquet"), RowCount: rows(20)},
+		{StoragePath: p + "200", ObjectKeysJSON: versionKeys(p+"200/part-0.parquet", p+"200/part-1.parquet"), RowCount: rows(30)},
+		{StoragePath: p + "200", ObjectKeysJSON: versionKeys(p+"200/part-0.parquet", p+"200/part-1.parquet", p+"200/part-2.parquet"), RowCount: rows(40)},
+		{StoragePath: p + "300", ObjectKeysJSON: versionKeys(p + "300/part-0.parquet"), RowCount: rows(50)},
+		{StoragePath: p + "300", ObjectKeysJSON: versionKeys(p+"300/part-0.parquet", p+"300/part-1.parquet"), RowCount: rows(60)},
+	} {
+		v.ID, v.TenantID, v.DatasetID, v.Version = fmt.Sprintf("v%d", i+1), "t1", "d1", i+1
+		if edit != nil {
+			edit(&v)
+		}
+		if err := f.versions.Create(ctx, &v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := sqlDB.ExecContext(ctx, `UPDATE dataset_versions SET created_at = datetime('now', '-1 hour')`); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// numbers returns the version numbers of d1, oldest first.
+func (f *versionFixture) numbers(t *testing.T) []int {
+	t.Helper()
+	list, err := f.versions.ListByDataset(context.Background(), "t1", "d1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var numbers []int
+	for _, v := range list {
+		numbers = append(numbers, v.Version)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// dataset returns d1 as stored.
+func (f *versionFixture) dataset(t *testing.T) *domain.Dataset {
+	t.Helper()
+	ds, err := f.datasets.FindByID(context.Background(), "t1", "d1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ds
+}
+
+func (f *versionFixture) service(cfg DatasetVersionConfig) *DatasetVersionService {
+	return NewDatasetVersionService(f.datasets, f.versions, f.policies, f.objects, cfg)
+}
+
+func TestCollectGarbage(t *testing.T) {
+	const p = "datasets/t1/d1/"
+	tests := []struct {
+		name         string
+		keep         int
+		minAge       time.Duration
+		pin          int
+		keepVersions bool
+		wantVersions []int
+		wantRemoved  []string
+	}{
+		{
+			// v2 and v3 share every part with v4
+			name: "parts shared with a kept append version survive", keep: 3,
+			wantVersions: []int{4, 5, 6},
+			wantRemoved:  []string{p + "old.parquet"},
+		},
+		{
+			name: "replaced prefix", keep: 2,
+			wantVersions: []int{5, 6},
+			wantRemoved:  []string{p + "200/part-0.parquet", p + "200/part-1.parquet", p + "200/part-2.parquet", p + "old.parquet"},
+		},
+		{
+			name: "pinned version", keep: 2, pin: 3,
+			wantVersions: []int{3, 5, 6},
+			wantRemoved:  []string{p + "200/part-2.parquet", p + "old.parquet"},
+		},
+		{
+			name: "younger than the minimum age", keep: 2, minAge: 2 * time.Hour,
+			wantVersions: []int{1, 2, 3, 4, 5, 6},
+		},
+		{
+			name: "left to the retention policy", keep: 2, keepVersions: true,
+			wantVersions: []int{1, 2, 3, 4, 5, 6},
+		},
+		{
+			name: "under the count", keep: 6,
+			wantVersions: []int{1, 2, 3, 4, 5, 6},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newVersionFixture(t, func(v *domain.DatasetVersion) {
+				v.Pinned = v.Version == tt.pin
+			})
+			if tt.keepVersions {
+				n, id := 1, "d1"
+				if err := f.policies.Upsert(context.Background(), &domain.RetentionPolicy{ID: "rp1", TenantID: "t1", DatasetID: &id, KeepVersions: &n}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			f.service(DatasetVersionConfig{Keep: tt.keep, MinAge: tt.minAge}).CollectGarbage(context.Background())
+
+			if got := f.numbers(t); !reflect.DeepEqual(got, tt.wantVersions) {
+				t.Errorf("versions = %v, want %v", got, tt.wantVersions)
+			}
+			removed := append([]string(nil), f.objects.removed...)
+			sort.Strings(removed)
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", removed, tt.wantRemoved)
+			}
+			for _, key := range []string{p + "300/part-0.parquet", p + "300/part-1.parquet"} {
+				if !f.objects.objects[key] {
+					t.Errorf("current part %s was removed", key)
+				}
+			}
+		})
+	}
+}
+
+func TestPruneVersionsCurrentPrefix(t *testing.T) {
+	// Parts under the current prefix survive even when no kept version
+	// lists them, and each disposed object is counted once
+	f := newVersionFixture(t, nil)
+	list, err := f.versions.ListByDataset(context.Background(), "t1", "d1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pruned, err := pruneVersions(context.Background(), f.versions, f.dataset(t), list,
+		func(int, *domain.DatasetVersion) bool { return false },
+		func(ctx context.Context, key string) (int64, error) {
+			size, _ := f.objects.ObjectSize(ctx, key)
+			return size, f.objects.RemoveObject(ctx, key)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.numbers(t); len(got) != 0 {
+		t.Errorf("versions = %v, want none", got)
+	}
+	var objects int
+	var bytes int64
+	for _, p := range pruned {
+		objects += p.objects
+		bytes += p.bytes
+	}
+	if objects != 4 || bytes != 400 || len(f.objects.removed) != 4 {
+		t.Errorf("disposed %d objects, %d bytes, removed %v; want the 4 objects outside the current prefix once", objects, bytes, f.objects.removed)
+	}
+	for _, key := range f.objects.removed {
+		if strings.Contains(key, "/300/") {
+			t.Errorf("removed current part %s", key)
+		}
+	}
+}
+
+func TestRollback(t *testing.T) {
+	ctx := tenantContext("t1", "u1", domain.TenantRoleMember)
+	const p = "datasets/t1/d1/"
+
+	t.Run("appended prefix", func(t *testing.T) {
+		old := &domain.Dataset{}
+		_ = old.SetColumns([]domain.DatasetColumnMeta{{Name: "id", Type: "INTEGER"}})
+		f := newVersionFixture(t, func(v *domain.DatasetVersion) {
+			if v.Version == 3 {
+				v.SchemaJSON = old.SchemaJSON
+			}
+		})
+		current := f.dataset(t)
+		_ = current.SetColumns([]domain.DatasetColumnMeta{{Name: "id", Type: "BIGINT", Description: "order id", Tags: []string{"key"}}})
+		if err := f.datasets.Update(context.Background(), current); err != nil {
+			t.Fatal(err)
+		}
+		svc := f.service(DatasetVersionConfig{Keep: 2})
+
+		restored, err := svc.Rollback(ctx, "d1", 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if restored.Version != 7 || restored.RestoredFrom == nil || *restored.RestoredFrom != 3 {
+			t.Errorf("restored version = %d from %v, want 7 from 3", restored.Version, restored.RestoredFrom)
+		}
+		keys, _ := restored.ObjectKeys()
+		if len(keys) != 2 || f.objects.copied[keys[0]] != p+"200/part-0.parquet" || f.objects.copied[keys[1]] != p+"200/part-1.parquet" {
+			t.Errorf("restored parts = %v copied from %v, want copies of v3's parts", keys, f.objects.copied)
+		}
+		ds := f.dataset(t)
+		if ds.StoragePath != restored.StoragePath || !strings.HasPrefix(ds.StoragePath, p) || ds.StoragePath == p+"200" {
+			t.Errorf("dataset storage path = %s, want a new prefix", ds.StoragePath)
+		}
+		if ds.RowCount == nil || *ds.RowCount != 30 {
+			t.Errorf("dataset row count = %v, want 30", ds.RowCount)
+		}
+		cols, _ := ds.ParseColumns()
+		if len(cols) != 1 || cols[0].Type != "INTEGER" || cols[0].Description != "order id" || len(cols[0].Tags) != 1 {
+			t.Errorf("columns = %+v, want v3's type with the current description and tags", cols)
+		}
+
+		// Collecting the version rolled back to leaves the restored copies
+		svc.CollectGarbage(context.Background())
+		for _, key := range keys {
+			if !f.objects.objects[key] {
+				t.Errorf("restored part %s was collected", key)
+			}
+		}
+		if got := f.numbers(t); !reflect.DeepEqual(got, []int{6, 7}) {
+			t.Errorf("versions after gc = %v, want [6 7]", got)
+		}
+	})
+
+	t.Run("single file", func(t *testing.T) {
+		f := newVersionFixture(t, nil)
+		restored, err := f.service(DatasetVersionConfig{}).Rollback(ctx, "d1", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ds := f.dataset(t); restored.StoragePath != p+"old.parquet" || ds.StoragePath != p+"old.parquet" || len(f.objects.copied) != 0 {
+			t.Errorf("restored %s, dataset %s, copied %v; want the file read in place", restored.StoragePath, ds.StoragePath, f.objects.copied)
+		}
+	})
+
+	t.Run("parts not tracked", func(t *testing.T) {
+		f := newVersionFixture(t, func(v *domain.DatasetVersion) {
+			if v.Version == 2 {
+				v.ObjectKeysJSON = nil
+			}
+		})
+		if _, err := f.service(DatasetVersionConfig{}).Rollback(ctx, "d1", 2); !errors.Is(err, domain.ErrDatasetVersionUnavailable) {
+			t.Errorf("Rollback = %v, want %v", err, domain.ErrDatasetVersionUnavailable)
+		}
+		if ds := f.dataset(t); ds.StoragePath != p+"300" {
+			t.Errorf("dataset storage path changed to %s", ds.StoragePath)
+		}
+	})
+}
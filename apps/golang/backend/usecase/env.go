@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// envDuration reads a duration such as "15m" from the environment, or
+// returns def when the variable is unset or invalid.
+func envDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+// envInt reads an integer from the environment, or returns def when the
+// variable is unset or invalid.
+func envInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return def
+}
@@ -70,7 +70,7 @@ func (s *ImportJobService) CreateImportJob(ctx context.Context, input CreateImpo
 	}
 
 	// Create Job
-	job, err := s.jobs.CreateJob(ctx, input.Name, input.Slug, input.Description, domain.JobKindImport)
+	job, err := s.jobs.CreateJob(ctx, input.Name, input.Slug, input.Description, domain.JobKindImport, "")
 	if err != nil {
 		return nil, fmt.Errorf("create job: %w", err)
 	}
@@ -107,6 +107,9 @@ func (s *ImportJobService) CreateImportJob(ctx context.Context, input CreateImpo
 		Version:  nextVer,
 		Status:   domain.JobVersionStatusDraft,
 	}
+	if userID, ok := domain.UserIDFromContext(ctx); ok {
+		version.CreatedBy = &userID
+	}
 	if err := s.versions.Create(ctx, version); err != nil {
 		return nil, fmt.Errorf("create version: %w", err)
 	}
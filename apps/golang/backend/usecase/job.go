@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/schemadrift"
 )
 
 type TxRunner interface {
@@ -28,6 +29,7 @@ type JobService struct {
 	edges             domain.JobModuleEdgeRepository
 	moduleTypeSchemas domain.ModuleTypeSchemaRepository
 	txRunner          TxRunner
+	cdc               *PostgresCDCService
 }
 
 func NewJobService(
@@ -37,6 +39,7 @@ func NewJobService(
 	edges domain.JobModuleEdgeRepository,
 	moduleTypeSchemas domain.ModuleTypeSchemaRepository,
 	txRunner TxRunner,
+	cdc *PostgresCDCService,
 ) *JobService {
 	return &JobService{
 		jobs:              jobs,
@@ -45,10 +48,12 @@ func NewJobService(
 		edges:             edges,
 		moduleTypeSchemas: moduleTypeSchemas,
 		txRunner:          txRunner,
+		cdc:               cdc,
 	}
 }
 
-func (s *JobService) CreateJob(ctx context.Context, name, slug, description, kind string) (*domain.Job, error) {
+// CreateJob creates a job. An empty schemaDriftPolicy defaults to accept.
+func (s *JobService) CreateJob(ctx context.Context, name, slug, description, kind, schemaDriftPolicy string) (*domain.Job, error) {
 	tenantID, ok := domain.TenantIDFromContext(ctx)
 	if !ok {
 		return nil, fmt.Errorf("tenant id not found in context")
@@ -57,6 +62,12 @@ func (s *JobService) CreateJob(ctx context.Context, name, slug, description, kin
 	if kind == "" {
 		kind = domain.JobKindPipeline
 	}
+	if schemaDriftPolicy == "" {
+		schemaDriftPolicy = schemadrift.PolicyAccept
+	}
+	if !schemadrift.ValidPolicy(schemaDriftPolicy) {
+		return nil, domain.ErrInvalidSchemaDriftPolicy
+	}
 
 	job := &domain.Job{
 		ID:          uuid.New().String(),
@@ -66,6 +77,8 @@ func (s *JobService) CreateJob(ctx context.Context, name, slug, description, kin
 		Description: description,
 		Kind:        kind,
 		IsActive:    true,
+
+		SchemaDriftPolicy: schemaDriftPolicy,
 	}
 
 	if err := s.jobs.Create(ctx, job); err != nil {
@@ -91,21 +104,36 @@ func (s *JobService) ListJobs(ctx context.Context) ([]domain.Job, error) {
 	return s.jobs.ListByTenant(ctx, tenantID)
 }
 
-func (s *JobService) UpdateJob(ctx context.Context, id, name, slug, description string, isActive bool) (*domain.Job, error) {
+// UpdateJob updates a job. An empty schemaDriftPolicy keeps the current policy.
+// Deactivating a job first releases its Postgres replication slot; the job
+// stays active when that fails, so that the slot is not left behind.
+func (s *JobService) UpdateJob(ctx context.Context, id, name, slug, description string, isActive bool, schemaDriftPolicy string) (*domain.Job, error) {
 	tenantID, ok := domain.TenantIDFromContext(ctx)
 	if !ok {
 		return nil, fmt.Errorf("tenant id not found in context")
 	}
+	if schemaDriftPolicy != "" && !schemadrift.ValidPolicy(schemaDriftPolicy) {
+		return nil, domain.ErrInvalidSchemaDriftPolicy
+	}
 
 	job, err := s.jobs.FindByID(ctx, tenantID, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if job.IsActive && !isActive && s.cdc != nil {
+		if err := s.cdc.Release(ctx, tenantID, id); err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrReplicationSlotRelease, err)
+		}
+	}
+
 	job.Name = name
 	job.Slug = slug
 	job.Description = description
 	job.IsActive = isActive
+	if schemaDriftPolicy != "" {
+		job.SchemaDriftPolicy = schemaDriftPolicy
+	}
 
 	if err := s.jobs.Update(ctx, job); err != nil {
 		return nil, err
@@ -166,6 +194,9 @@ func (s *JobService) CreateVersion(ctx context.Context, jobID string, modules []
 		Version:  nextVer,
 		Status:   domain.JobVersionStatusDraft,
 	}
+	if userID, ok := domain.UserIDFromContext(ctx); ok {
+		version.CreatedBy = &userID
+	}
 
 	if err := s.versions.Create(ctx, version); err != nil {
 		return nil, err
@@ -49,10 +49,9 @@ func (s *JobRunService) Create(ctx context.Context, jobID string, jobVersionID *
 	}
 
 	// Resolve version: use provided or auto-select latest published
-	var versionID string
+	var version *domain.JobVersion
 	if jobVersionID != nil && *jobVersionID != "" {
-		versionID = *jobVersionID
-		if _, err := s.versions.FindByID(ctx, tenantID, versionID); err != nil {
+		if version, err = s.versions.FindByID(ctx, tenantID, *jobVersionID); err != nil {
 			return nil, err
 		}
 	} else {
@@ -71,8 +70,9 @@ func (s *JobRunService) Create(ctx context.Context, jobID string, jobVersionID *
 		if latest == nil {
 			return nil, domain.ErrNoPublishedVersion
 		}
-		versionID = latest.ID
+		version = latest
 	}
+	versionID := version.ID
 
 	// Build RunSnapshot
 	modules, err := s.modules.ListByJobVersionID(ctx, tenantID, versionID)
@@ -110,11 +110,15 @@ func (s *JobRunService) Create(ctx context.Context, jobID string, jobVersionID *
 	}
 
 	snapshot := domain.RunSnapshot{
-		JobKind:   job.Kind,
-		JobID:     job.ID,
-		VersionID: versionID,
-		Modules:   snapshotModules,
-		Edges:     snapshotEdges,
+		JobKind:           job.Kind,
+		JobID:             job.ID,
+		VersionID:         versionID,
+		SchemaDriftPolicy: job.SchemaDriftPolicy,
+		Modules:           snapshotModules,
+		Edges:             snapshotEdges,
+	}
+	if version.CreatedBy != nil {
+		snapshot.CreatedBy = *version.CreatedBy
 	}
 
 	snapshotJSON, err := json.Marshal(snapshot)
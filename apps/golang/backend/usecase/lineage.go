@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/user/micro-dp/domain"
+)
+
+const (
+	defaultLineageDepth = 3
+	maxLineageDepth     = 10
+)
+
+// LineageGraphNode is a node reached from the root of a lineage query.
+// Depth is the number of edges between the node and the root, Direction
+// is empty for the root. Name is empty when the node no longer exists.
+type LineageGraphNode struct {
+	domain.LineageNode
+	Name      string
+	Direction string
+	Depth     int
+}
+
+type LineageGraph struct {
+	Root  domain.LineageNode
+	Nodes []LineageGraphNode
+	Edges []domain.LineageEdge
+}
+
+type LineageService struct {
+	edges       domain.LineageRepository
+	datasets    domain.DatasetRepository
+	jobs        domain.JobRepository
+	connections domain.ConnectionRepository
+	charts      domain.ChartRepository
+}
+
+func NewLineageService(
+	edges domain.LineageRepository,
+	datasets domain.DatasetRepository,
+	jobs domain.JobRepository,
+	connections domain.ConnectionRepository,
+	charts domain.ChartRepository,
+) *LineageService {
+	return &LineageService{
+		edges:       edges,
+		datasets:    datasets,
+		jobs:        jobs,
+		connections: connections,
+		charts:      charts,
+	}
+}
+
+// DatasetGraph returns the lineage of a dataset: the nodes its data comes
+// from (upstream), the nodes that read it (downstream), or both, up to
+// depth edges away. A depth of 0 selects the default.
+func (s *LineageService) DatasetGraph(ctx context.Context, datasetID, direction string, depth int) (*LineageGraph, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	switch direction {
+	case "":
+		direction = domain.LineageBoth
+	case domain.LineageUpstream, domain.LineageDownstream, domain.LineageBoth:
+	default:
+		return nil, fmt.Errorf("%w: direction must be upstream, downstream or both", domain.ErrInvalidLineageQuery)
+	}
+	if depth == 0 {
+		depth = defaultLineageDepth
+	}
+	if depth < 1 || depth > maxLineageDepth {
+		return nil, fmt.Errorf("%w: depth must be between 1 and %d", domain.ErrInvalidLineageQuery, maxLineageDepth)
+	}
+
+	ds, err := s.datasets.FindByID(ctx, tenantID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	root := domain.LineageNode{Type: domain.LineageNodeDataset, ID: ds.ID}
+	g := &LineageGraph{
+		Root:  root,
+		Nodes: []LineageGraphNode{{LineageNode: root, Name: ds.Name}},
+	}
+	seen := map[domain.LineageNode]bool{root: true}
+	edgeSeen := make(map[string]bool)
+
+	if direction != domain.LineageDownstream {
+		if err := s.walk(ctx, tenantID, g, root, domain.LineageUpstream, depth, seen, edgeSeen); err != nil {
+			return nil, err
+		}
+	}
+	if direction != domain.LineageUpstream {
+		if err := s.walk(ctx, tenantID, g, root, domain.LineageDownstream, depth, seen, edgeSeen); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range g.Nodes[1:] {
+		n := &g.Nodes[i+1]
+		if n.Name, err = s.nodeName(ctx, tenantID, n.LineageNode); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// walk adds the nodes and edges reachable from root in one direction,
+// breadth first, to g.
+func (s *LineageService) walk(ctx context.Context, tenantID string, g *LineageGraph, root domain.LineageNode, direction string, depth int, seen map[domain.LineageNode]bool, edgeSeen map[string]bool) error {
+	frontier := []domain.LineageNode{root}
+	for d := 1; d <= depth && len(frontier) > 0; d++ {
+		var edges []domain.LineageEdge
+		var err error
+		if direction == domain.LineageUpstream {
+			edges, err = s.edges.ListByTargets(ctx, tenantID, frontier)
+		} else {
+			edges, err = s.edges.ListBySources(ctx, tenantID, frontier)
+		}
+		if err != nil {
+			return fmt.Errorf("list lineage edges: %w", err)
+		}
+
+		var next []domain.LineageNode
+		for _, e := range edges {
+			if !edgeSeen[e.ID] {
+				edgeSeen[e.ID] = true
+				g.Edges = append(g.Edges, e)
+			}
+			n := e.Target
+			if direction == domain.LineageUpstream {
+				n = e.Source
+			}
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+			g.Nodes = append(g.Nodes, LineageGraphNode{LineageNode: n, Direction: direction, Depth: d})
+			next = append(next, n)
+		}
+		frontier = next
+	}
+	return nil
+}
+
+// nodeName returns the name of a node, or "" when it no longer exists.
+func (s *LineageService) nodeName(ctx context.Context, tenantID string, n domain.LineageNode) (string, error) {
+	var name string
+	var err error
+	switch n.Type {
+	case domain.LineageNodeDataset:
+		var ds *domain.Dataset
+		if ds, err = s.datasets.FindByID(ctx, tenantID, n.ID); err == nil {
+			name = ds.Name
+		}
+	case domain.LineageNodeJob:
+		var job *domain.Job
+		if job, err = s.jobs.FindByID(ctx, tenantID, n.ID); err == nil {
+			name = job.Name
+		}
+	case domain.LineageNodeConnection:
+		var conn *domain.Connection
+		if conn, err = s.connections.FindByID(ctx, tenantID, n.ID); err == nil {
+			name = conn.Name
+		}
+	case domain.LineageNodeChart:
+		var c *domain.Chart
+		if c, err = s.charts.FindByID(ctx, tenantID, n.ID); err == nil {
+			name = c.Name
+		}
+	}
+	if errors.Is(err, domain.ErrDatasetNotFound) || errors.Is(err, domain.ErrJobNotFound) ||
+		errors.Is(err, domain.ErrConnectionNotFound) || errors.Is(err, domain.ErrChartNotFound) {
+		return "", nil
+	}
+	return name, err
+}
@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+)
+
+func dsNode(id string) domain.LineageNode {
+	return domain.LineageNode{Type: domain.LineageNodeDataset, ID: id}
+}
+func jobNode(id string) domain.LineageNode {
+	return domain.LineageNode{Type: domain.LineageNodeJob, ID: id}
+}
+func chartNode(id string) domain.LineageNode {
+	return domain.LineageNode{Type: domain.LineageNodeChart, ID: id}
+}
+
+// countingLineage counts the edge lookups, one per level walked.
+type countingLineage struct {
+	*db.LineageRepo
+	queries int
+}
+
+func (r *countingLineage) ListBySources(ctx context.Context, tenantID string, sources []domain.LineageNode) ([]domain.LineageEdge, error) {
+	r.queries++
+	return r.LineageRepo.ListBySources(ctx, tenantID, sources)
+}
+
+func (r *countingLineage) ListByTargets(ctx context.Context, tenantID string, targets []domain.LineageNode) ([]domain.LineageEdge, error) {
+	r.queries++
+	return r.LineageRepo.ListByTargets(ctx, tenantID, targets)
+}
+
+// lineageFixture stores the graph
+//
+//	c1 -> raw -> j1 -> clean -> j2 -> agg -> ch1
+//	                   clean -> ch2
+//
+// plus an edge of another tenant into clean, and the edges extra. The job
+// j1 and the chart ch2 no longer exist.
+func lineageFixture(t *testing.T, extra ...domain.LineageEdge) (*LineageService, *countingLineage) {
+	t.Helper()
+	ctx := context.Background()
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	createTestTenant(t, sqlDB, "t2")
+
+	edge := func(tenantID string, source, target domain.LineageNode) domain.LineageEdge {
+		return domain.LineageEdge{TenantID: tenantID, Source: source, Target: target}
+	}
+	c1 := domain.LineageNode{Type: domain.LineageNodeConnection, ID: "c1"}
+	edges := &countingLineage{LineageRepo: db.NewLineageRepo(sqlDB)}
+	for _, e := range append([]domain.LineageEdge{
+		edge("t1", c1, dsNode("raw")),
+		edge("t1", dsNode("raw"), jobNode("j1")),
+		edge("t1", jobNode("j1"), dsNode("clean")),
+		edge("t1", dsNode("clean"), jobNode("j2")),
+		edge("t1", jobNode("j2"), dsNode("agg")),
+		edge("t1", dsNode("agg"), chartNode("ch1")),
+		edge("t1", dsNode("clean"), chartNode("ch2")),
+		edge("t2", dsNode("other"), dsNode("clean")),
+	}, extra...) {
+		if err := edges.Upsert(ctx, &e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	datasets := db.NewDatasetRepo(sqlDB)
+	for _, d := range []*domain.Dataset{
+		{ID: "raw", TenantID: "t1", Name: "raw_orders", SourceType: "import"},
+		{ID: "clean", TenantID: "t1", Name: "orders", SourceType: "transform"},
+		{ID: "agg", TenantID: "t1", Name: "daily_orders", SourceType: "transform"},
+	} {
+		if err := datasets.Create(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jobs := db.NewJobRepo(sqlDB)
+	if err := jobs.Create(ctx, &domain.Job{ID: "j2", TenantID: "t1", Name: "aggregate orders", Slug: "aggregate-orders"}); err != nil {
+		t.Fatal(err)
+	}
+	connections := db.NewConnectionRepo(sqlDB)
+	if err := connections.Create(ctx, &domain.Connection{ID: "c1", TenantID: "t1", Name: "shop db", Type: postgresConnectorID, ConfigJSON: "{}"}); err != nil {
+		t.Fatal(err)
+	}
+	charts := db.NewChartRepo(sqlDB)
+	if err := charts.Create(ctx, &domain.Chart{ID: "ch1", TenantID: "t1", Name: "orders per day", ChartType: "line", DatasetID: "agg", Measure: "orders", Dimension: "day"}); err != nil {
+		t.Fatal(err)
+	}
+	return NewLineageService(edges, datasets, jobs, connections, charts), edges
+}
+
+// graphDepths renders the non-root nodes of g as "direction id depth".
+func graphDepths(g *LineageGraph) []string {
+	var nodes []string
+	for _, n := range g.Nodes[1:] {
+		nodes = append(nodes, fmt.Sprintf("%s %s %d", n.Direction, n.ID, n.Depth))
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+func TestLineageDatasetGraphDepth(t *testing.T) {
+	tests := []struct {
+		direction string
+		depth     int
+		want      []string
+		wantEdges int
+	}{
+		{direction: domain.LineageUpstream, depth: 1, want: []string{"upstream j1 1"}, wantEdges: 1},
+		{direction: domain.LineageUpstream, depth: 2, want: []string{"upstream j1 1", "upstream raw 2"}, wantEdges: 2},
+		{direction: domain.LineageUpstream, depth: 10, want: []string{"upstream c1 3", "upstream j1 1", "upstream raw 2"}, wantEdges: 3},
+		{direction: domain.LineageDownstream, depth: 1, want: []string{"downstream ch2 1", "downstream j2 1"}, wantEdges: 2},
+		{direction: domain.LineageDownstream, depth: 2, want: []string{"downstream agg 2", "downstream ch2 1", "downstream j2 1"}, wantEdges: 3},
+		{
+			// the default depth of 3 in both directions
+			want: []string{"downstream agg 2", "downstream ch1 3", "downstream ch2 1", "downstream j2 1",
+				"upstream c1 3", "upstream j1 1", "upstream raw 2"},
+			wantEdges: 7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %d", tt.direction, tt.depth), func(t *testing.T) {
+			svc, _ := lineageFixture(t)
+			g, err := svc.DatasetGraph(tenantContext("t1", "u1", domain.TenantRoleMember), "clean", tt.direction, tt.depth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.Root != dsNode("clean") || g.Nodes[0].Name != "orders" || g.Nodes[0].Depth != 0 {
+				t.Errorf("root = %+v, want dataset clean named orders", g.Nodes[0])
+			}
+			if got := graphDepths(g); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nodes = %v, want %v", got, tt.want)
+			}
+			if len(g.Edges) != tt.wantEdges {
+				t.Errorf("edges = %d, want %d", len(g.Edges), tt.wantEdges)
+			}
+		})
+	}
+}
+
+func TestLineageDatasetGraphNames(t *testing.T) {
+	svc, _ := lineageFixture(t)
+	g, err := svc.DatasetGraph(tenantContext("t1", "u1", domain.TenantRoleMember), "clean", domain.LineageBoth, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]string{}
+	for _, n := range g.Nodes {
+		names[n.ID] = n.Name
+	}
+	want := map[string]string{
+		"clean": "orders", "raw": "raw_orders", "agg": "daily_orders", "c1": "shop db",
+		"j1": "", "j2": "aggregate orders", "ch1": "orders per day", "ch2": "",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+}
+
+func TestLineageDatasetGraphCycle(t *testing.T) {
+	// A job that writes back into an upstream dataset closes a cycle; the
+	// walk visits each node once, at its shortest distance, and stops when
+	// no new node is reached
+	svc, edges := lineageFixture(t,
+		domain.LineageEdge{TenantID: "t1", Source: dsNode("agg"), Target: jobNode("j3")},
+		domain.LineageEdge{TenantID: "t1", Source: jobNode("j3"), Target: dsNode("raw")},
+	)
+
+	g, err := svc.DatasetGraph(tenantContext("t1", "u1", domain.TenantRoleMember), "clean", domain.LineageDownstream, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"downstream agg 2", "downstream ch1 3", "downstream ch2 1", "downstream j1 5", "downstream j2 1",
+		"downstream j3 3", "downstream raw 4"}
+	if got := graphDepths(g); !reflect.DeepEqual(got, want) {
+		t.Errorf("nodes = %v, want %v", got, want)
+	}
+	if len(g.Edges) != 8 {
+		t.Errorf("edges = %d, want 8 (each once, including j1 -> clean)", len(g.Edges))
+	}
+	if edges.queries > 6 {
+		t.Errorf("walked %d levels, want the walk to stop at the last new node", edges.queries)
+	}
+}
+
+func TestLineageDatasetGraphInvalid(t *testing.T) {
+	svc, _ := lineageFixture(t)
+	ctx := tenantContext("t1", "u1", domain.TenantRoleMember)
+	for _, q := range []struct {
+		direction string
+		depth     int
+	}{{"sideways", 1}, {domain.LineageBoth, -1}, {domain.LineageBoth, maxLineageDepth + 1}} {
+		if _, err := svc.DatasetGraph(ctx, "clean", q.direction, q.depth); !errors.Is(err, domain.ErrInvalidLineageQuery) {
+			t.Errorf("DatasetGraph(%q, %d) = %v, want %v", q.direction, q.depth, err, domain.ErrInvalidLineageQuery)
+		}
+	}
+	if _, err := svc.DatasetGraph(tenantContext("t2", "u2", domain.TenantRoleMember), "clean", domain.LineageBoth, 1); !errors.Is(err, domain.ErrDatasetNotFound) {
+		t.Errorf("DatasetGraph of another tenant's dataset = %v, want %v", err, domain.ErrDatasetNotFound)
+	}
+}
@@ -52,6 +52,20 @@ func (s *MeteringService) RecordUpload(ctx context.Context, tenantID string, row
 	})
 }
 
+// RecordStorageRelease credits back the storage of a purged dataset. The
+// event delta is the negated row count, mirroring storage_write.
+func (s *MeteringService) RecordStorageRelease(ctx context.Context, tenantID string, rowCount int, storageBytes int64) error {
+	if err := s.usage.IncrementStorage(ctx, tenantID, today(), -storageBytes); err != nil {
+		return err
+	}
+	return s.usage.RecordEvent(ctx, &domain.UsageEvent{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		EventType: domain.UsageTypeStorageRelease,
+		Delta:     -rowCount,
+	})
+}
+
 // RecordUploadCount increments the daily upload counter by 1.
 func (s *MeteringService) RecordUploadCount(ctx context.Context, tenantID string) error {
 	date := today()
@@ -123,6 +123,38 @@ func (s *PlanService) CheckUploadQuota(ctx context.Context) error {
 	})
 }
 
+// MaxUploadFileSize returns the largest file the tenant may upload, or -1
+// when unlimited. OSS edition always returns -1.
+func (s *PlanService) MaxUploadFileSize(ctx context.Context) (int64, error) {
+	if edition.IsOSS() {
+		return -1, nil
+	}
+	plan, _, err := s.GetTenantPlan(ctx)
+	if err != nil {
+		if errors.Is(err, domain.ErrPlanNotFound) {
+			return -1, nil // no plan = no limit
+		}
+		return 0, err
+	}
+	return plan.MaxFileSizeBytes, nil
+}
+
+// MaxRetentionDays returns the longest the tenant's data may be kept, in
+// days, or -1 when unlimited. OSS edition always returns -1.
+func (s *PlanService) MaxRetentionDays(ctx context.Context) (int, error) {
+	if edition.IsOSS() {
+		return -1, nil
+	}
+	plan, _, err := s.GetTenantPlan(ctx)
+	if err != nil {
+		if errors.Is(err, domain.ErrPlanNotFound) {
+			return -1, nil // no plan = no limit
+		}
+		return 0, err
+	}
+	return plan.MaxRetentionDays, nil
+}
+
 func (s *PlanService) checkQuota(ctx context.Context, exceeded func(*domain.Plan, *domain.UsageDaily) bool) error {
 	tenantID, ok := domain.TenantIDFromContext(ctx)
 	if !ok {
@@ -151,7 +183,7 @@ func (s *PlanService) checkQuota(ctx context.Context, exceeded func(*domain.Plan
 
 // --- Admin operations ---
 
-func (s *PlanService) CreatePlan(ctx context.Context, name, displayName string, maxEvents, maxRows, maxUploads int, maxStorage int64) (*domain.Plan, error) {
+func (s *PlanService) CreatePlan(ctx context.Context, name, displayName string, maxEvents, maxRows, maxUploads int, maxStorage, maxFileSize int64, maxRetention int) (*domain.Plan, error) {
 	p := &domain.Plan{
 		ID:               uuid.New().String(),
 		Name:             name,
@@ -160,6 +192,8 @@ func (s *PlanService) CreatePlan(ctx context.Context, name, displayName string,
 		MaxStorageBytes:  maxStorage,
 		MaxRowsPerDay:    maxRows,
 		MaxUploadsPerDay: maxUploads,
+		MaxFileSizeBytes: maxFileSize,
+		MaxRetentionDays: maxRetention,
 	}
 	if err := s.plans.Create(ctx, p); err != nil {
 		return nil, err
@@ -171,7 +205,7 @@ func (s *PlanService) ListPlans(ctx context.Context) ([]domain.Plan, error) {
 	return s.plans.ListAll(ctx)
 }
 
-func (s *PlanService) UpdatePlan(ctx context.Context, id string, displayName *string, maxEvents, maxRows, maxUploads *int, maxStorage *int64) (*domain.Plan, error) {
+func (s *PlanService) UpdatePlan(ctx context.Context, id string, displayName *string, maxEvents, maxRows, maxUploads *int, maxStorage, maxFileSize *int64, maxRetention *int) (*domain.Plan, error) {
 	plan, err := s.plans.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -191,6 +225,12 @@ func (s *PlanService) UpdatePlan(ctx context.Context, id string, displayName *st
 	if maxUploads != nil {
 		plan.MaxUploadsPerDay = *maxUploads
 	}
+	if maxFileSize != nil {
+		plan.MaxFileSizeBytes = *maxFileSize
+	}
+	if maxRetention != nil {
+		plan.MaxRetentionDays = *maxRetention
+	}
 	if err := s.plans.Update(ctx, plan); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/pgcdc"
+)
+
+// postgresConnectorID is the connector whose import jobs capture changes
+// through a replication slot.
+const postgresConnectorID = "source-postgres"
+
+// postgresCDCCheckpoint is the part of a CDC run's checkpoint_json that
+// names what the job holds on the source.
+type postgresCDCCheckpoint struct {
+	SlotName        string `json:"slot_name"`
+	Publication     string `json:"publication"`
+	OwnsPublication bool   `json:"owns_publication"`
+}
+
+// PostgresCDCService releases what Postgres CDC jobs hold on their source
+// database: an unused replication slot keeps the source's WAL forever.
+type PostgresCDCService struct {
+	jobRuns     domain.JobRunRepository
+	versions    domain.JobVersionRepository
+	modules     domain.JobModuleRepository
+	connections domain.ConnectionRepository
+	connSvc     *ConnectionService
+	release     func(ctx context.Context, cfg pgcdc.Config, slot, publication string, dropPublication bool) error
+}
+
+func NewPostgresCDCService(
+	jobRuns domain.JobRunRepository,
+	versions domain.JobVersionRepository,
+	modules domain.JobModuleRepository,
+	connections domain.ConnectionRepository,
+	connSvc *ConnectionService,
+) *PostgresCDCService {
+	return &PostgresCDCService{
+		jobRuns:     jobRuns,
+		versions:    versions,
+		modules:     modules,
+		connections: connections,
+		connSvc:     connSvc,
+		release:     pgcdc.Release,
+	}
+}
+
+// Release drops the replication slot of the job's last checkpoint, and the
+// publication when a run created it. Jobs that never captured changes hold
+// nothing. A job whose Postgres connection was deleted is only logged, as
+// the source can no longer be reached.
+func (s *PostgresCDCService) Release(ctx context.Context, tenantID, jobID string) error {
+	raw, err := s.jobRuns.FindLatestCheckpoint(ctx, tenantID, jobID)
+	if err != nil {
+		return fmt.Errorf("find checkpoint: %w", err)
+	}
+	if raw == nil {
+		return nil
+	}
+	var cp postgresCDCCheckpoint
+	if err := json.Unmarshal([]byte(*raw), &cp); err != nil || cp.SlotName == "" {
+		return nil
+	}
+
+	conn, err := s.sourceConnection(ctx, tenantID, jobID)
+	if err != nil {
+		return err
+	}
+	if conn == nil {
+		log.Printf("postgres cdc: job %s has no postgres connection, slot %s is left on the source", jobID, cp.SlotName)
+		return nil
+	}
+	configJSON, err := s.connSvc.ResolveConfig(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("resolve connection config: %w", err)
+	}
+	var config map[string]any
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return fmt.Errorf("parse connection config: %w", err)
+	}
+	source, err := pgcdc.ConfigFromConnection(config)
+	if err != nil {
+		return err
+	}
+	if err := s.release(ctx, *source, cp.SlotName, cp.Publication, cp.OwnsPublication); err != nil {
+		return err
+	}
+	log.Printf("postgres cdc: released slot %s of job %s", cp.SlotName, jobID)
+	return nil
+}
+
+// sourceConnection returns the Postgres connection of the job's newest
+// version that has one, or nil.
+func (s *PostgresCDCService) sourceConnection(ctx context.Context, tenantID, jobID string) (*domain.Connection, error) {
+	versions, err := s.versions.ListByJobID(ctx, tenantID, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("list versions: %w", err)
+	}
+	for _, v := range versions {
+		modules, err := s.modules.ListByJobVersionID(ctx, tenantID, v.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list modules: %w", err)
+		}
+		for _, m := range modules {
+			if m.ConnectionID == nil {
+				continue
+			}
+			conn, err := s.connections.FindByID(ctx, tenantID, *m.ConnectionID)
+			if errors.Is(err, domain.ErrConnectionNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("find connection: %w", err)
+			}
+			if conn.Type == postgresConnectorID {
+				return conn, nil
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/pgcdc"
+)
+
+type releaseCall struct {
+	cfg             pgcdc.Config
+	slot            string
+	publication     string
+	dropPublication bool
+}
+
+// newCDCService stores job j1 with a draft version v2 reading a Google
+// Sheets connection and a published v1 reading a Postgres one, and a
+// successful run of it that saved checkpoint, if set. The service records
+// the slots it releases in calls.
+func newCDCService(t *testing.T, checkpoint string, calls *[]releaseCall) *PostgresCDCService {
+	t.Helper()
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	ctx := context.Background()
+
+	connections := db.NewConnectionRepo(sqlDB)
+	for _, c := range []*domain.Connection{
+		{ID: "gsheets", TenantID: "t1", Name: "sheet", Type: "source-google-sheets", ConfigJSON: "{}"},
+		{ID: "pg", TenantID: "t1", Name: "shop", Type: postgresConnectorID,
+			ConfigJSON: `{"host":"db","port":5433,"database":"shop","username":"cdc","password":"pw"}`},
+	} {
+		if err := connections.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.NewJobRepo(sqlDB).Create(ctx, &domain.Job{ID: "j1", TenantID: "t1", Name: "orders", Slug: "orders", IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.NewModuleTypeRepo(sqlDB).Create(ctx, &domain.ModuleType{ID: "source", TenantID: "t1", Name: "source", Category: "source"}); err != nil {
+		t.Fatal(err)
+	}
+	versions, modules := db.NewJobVersionRepo(sqlDB), db.NewJobModuleRepo(sqlDB)
+	gsheets, pg := "gsheets", "pg"
+	for _, v := range []struct {
+		version domain.JobVersion
+		modules []domain.JobModule
+	}{
+		{domain.JobVersion{ID: "v1", TenantID: "t1", JobID: "j1", Version: 1, Status: "published"},
+			[]domain.JobModule{{ID: "m1", ConnectionID: &pg}}},
+		{domain.JobVersion{ID: "v2", TenantID: "t1", JobID: "j1", Version: 2, Status: "draft"},
+			[]domain.JobModule{{ID: "m2"}, {ID: "m3", ConnectionID: &gsheets}}},
+	} {
+		if err := versions.Create(ctx, &v.version); err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range v.modules {
+			m.TenantID, m.JobVersionID, m.ModuleTypeID, m.Name, m.ConfigJSON = "t1", v.version.ID, "source", m.ID, "{}"
+			if err := modules.Create(ctx, &m); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	runs := db.NewJobRunRepo(sqlDB)
+	if checkpoint != "" {
+		run := &domain.JobRun{ID: "r1", TenantID: "t1", JobID: "j1", Status: "queued"}
+		if err := runs.Create(ctx, run); err != nil {
+			t.Fatal(err)
+		}
+		if err := runs.UpdateCheckpoint(ctx, run.ID, checkpoint); err != nil {
+			t.Fatal(err)
+		}
+		if err := runs.UpdateStatus(ctx, "t1", run.ID, "success"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := NewPostgresCDCService(runs, versions, modules, connections, NewConnectionService(connections, nil, nil, nil))
+	svc.release = func(_ context.Context, cfg pgcdc.Config, slot, publication string, dropPublication bool) error {
+		*calls = append(*calls, releaseCall{cfg, slot, publication, dropPublication})
+		return nil
+	}
+	return svc
+}
+
+func TestPostgresCDCRelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		checkpoint string
+		want       []releaseCall
+	}{
+		{
+			name:       "owned publication",
+			checkpoint: `{"lsn":"0/16B3748","slot_name":"micro_dp_j1","publication":"micro_dp_j1","owns_publication":true}`,
+			want: []releaseCall{{
+				cfg:  pgcdc.Config{Host: "db", Port: 5433, Database: "shop", Username: "cdc", Password: "pw"},
+				slot: "micro_dp_j1", publication: "micro_dp_j1", dropPublication: true,
+			}},
+		},
+		{
+			name:       "existing publication",
+			checkpoint: `{"lsn":"0/16B3748","slot_name":"orders_slot","publication":"all_tables"}`,
+			want: []releaseCall{{
+				cfg:  pgcdc.Config{Host: "db", Port: 5433, Database: "shop", Username: "cdc", Password: "pw"},
+				slot: "orders_slot", publication: "all_tables",
+			}},
+		},
+		{name: "never ran"},
+		{name: "not a cdc checkpoint", checkpoint: `{"cursor":"2024-01-01"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []releaseCall
+			svc := newCDCService(t, tt.checkpoint, &calls)
+			if err := svc.Release(context.Background(), "t1", "j1"); err != nil {
+				t.Fatal(err)
+			}
+			if len(calls) != len(tt.want) {
+				t.Fatalf("release calls = %+v, want %+v", calls, tt.want)
+			}
+			for i := range calls {
+				if calls[i] != tt.want[i] {
+					t.Errorf("release call = %+v, want %+v", calls[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPostgresCDCReleaseError(t *testing.T) {
+	var calls []releaseCall
+	svc := newCDCService(t, `{"slot_name":"micro_dp_j1","publication":"micro_dp_j1"}`, &calls)
+	failure := errors.New("slot is active")
+	svc.release = func(context.Context, pgcdc.Config, string, string, bool) error { return failure }
+	if err := svc.Release(context.Background(), "t1", "j1"); !errors.Is(err, failure) {
+		t.Errorf("Release = %v, want %v", err, failure)
+	}
+}
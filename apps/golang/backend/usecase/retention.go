@@ -0,0 +1,494 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/storage"
+)
+
+// RetentionConfig holds retention sweeper settings.
+type RetentionConfig struct {
+	SweepInterval time.Duration // how often retention policies are enforced (0 disables)
+	ArchivePrefix string        // where archiving policies move purged objects
+}
+
+// LoadRetentionConfig reads retention settings from environment variables.
+func LoadRetentionConfig() RetentionConfig {
+	cfg := RetentionConfig{
+		SweepInterval: envDuration("RETENTION_SWEEP_INTERVAL", 24*time.Hour),
+		ArchivePrefix: "archive/",
+	}
+	if v := os.Getenv("RETENTION_ARCHIVE_PREFIX"); v != "" {
+		cfg.ArchivePrefix = strings.TrimSuffix(v, "/") + "/"
+	}
+	return cfg
+}
+
+// retentionPartitionRoots are the roots of the dt= partitioned objects a
+// tenant's policy sweeps, each followed by the tenant ID.
+var retentionPartitionRoots = []string{"events", "aggregated/events", "aggregated/visits", "transforms"}
+
+type RetentionService struct {
+	policies domain.RetentionPolicyRepository
+	purges   domain.RetentionPurgeRepository
+	datasets domain.DatasetRepository
+	versions domain.DatasetVersionRepository
+	tenants  domain.TenantRepository
+	plans    *PlanService
+	metering *MeteringService
+	objects  domain.ObjectStore
+	cfg      RetentionConfig
+}
+
+func NewRetentionService(
+	policies domain.RetentionPolicyRepository,
+	purges domain.RetentionPurgeRepository,
+	datasets domain.DatasetRepository,
+	versions domain.DatasetVersionRepository,
+	tenants domain.TenantRepository,
+	plans *PlanService,
+	metering *MeteringService,
+	objects domain.ObjectStore,
+	cfg RetentionConfig,
+) *RetentionService {
+	return &RetentionService{
+		policies: policies,
+		purges:   purges,
+		datasets: datasets,
+		versions: versions,
+		tenants:  tenants,
+		plans:    plans,
+		metering: metering,
+		objects:  objects,
+		cfg:      cfg,
+	}
+}
+
+// GetPolicy returns the retention policy of a dataset, or the tenant's
+// when datasetID is empty.
+func (s *RetentionService) GetPolicy(ctx context.Context, datasetID string) (*domain.RetentionPolicy, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if datasetID != "" {
+		if _, err := s.datasets.FindByID(ctx, tenantID, datasetID); err != nil {
+			return nil, err
+		}
+	}
+	return s.policies.Find(ctx, tenantID, datasetID)
+}
+
+// SetPolicy sets the retention policy of a dataset, or the tenant's when
+// datasetID is empty. Only owners and admins may change retention, and not
+// beyond the plan's maximum.
+func (s *RetentionService) SetPolicy(ctx context.Context, datasetID string, keepDays, keepVersions *int, archive bool) (*domain.RetentionPolicy, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	if err := checkOwnerOrAdmin(ctx, s.tenants, tenantID); err != nil {
+		return nil, err
+	}
+	if keepDays == nil && keepVersions == nil {
+		return nil, fmt.Errorf("%w: set keep_days or keep_versions", domain.ErrInvalidRetentionPolicy)
+	}
+	if (keepDays != nil && *keepDays < 1) || (keepVersions != nil && *keepVersions < 1) {
+		return nil, fmt.Errorf("%w: keep_days and keep_versions must be positive", domain.ErrInvalidRetentionPolicy)
+	}
+	maxDays, err := s.plans.MaxRetentionDays(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get plan: %w", err)
+	}
+	if keepDays != nil && maxDays >= 0 && *keepDays > maxDays {
+		return nil, fmt.Errorf("%w: keep_days is at most %d", domain.ErrRetentionExceedsPlan, maxDays)
+	}
+
+	p := &domain.RetentionPolicy{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		KeepDays:     keepDays,
+		KeepVersions: keepVersions,
+		Archive:      archive,
+	}
+	if datasetID != "" {
+		if _, err := s.datasets.FindByID(ctx, tenantID, datasetID); err != nil {
+			return nil, err
+		}
+		p.DatasetID = &datasetID
+	}
+	if err := s.policies.Upsert(ctx, p); err != nil {
+		return nil, err
+	}
+	return s.policies.Find(ctx, tenantID, datasetID)
+}
+
+// DeletePolicy removes the retention policy of a dataset, or the tenant's
+// when datasetID is empty.
+func (s *RetentionService) DeletePolicy(ctx context.Context, datasetID string) error {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("tenant id not found in context")
+	}
+	if err := checkOwnerOrAdmin(ctx, s.tenants, tenantID); err != nil {
+		return err
+	}
+	if datasetID != "" {
+		if _, err := s.datasets.FindByID(ctx, tenantID, datasetID); err != nil {
+			return err
+		}
+	}
+	return s.policies.Delete(ctx, tenantID, datasetID)
+}
+
+// ListPurges returns what retention purged, newest first, of one dataset
+// when datasetID is set.
+func (s *RetentionService) ListPurges(ctx context.Context, datasetID string, limit int) ([]domain.RetentionPurge, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+	return s.purges.ListByTenant(ctx, tenantID, datasetID, limit)
+}
+
+// Sweep enforces the retention policies and plan maximums of every tenant.
+func (s *RetentionService) Sweep(ctx context.Context) {
+	tenants, err := s.tenants.ListAll(ctx)
+	if err != nil {
+		log.Printf("retention sweep: list tenants error: %v", err)
+		return
+	}
+	for _, t := range tenants {
+		if err := s.sweepTenant(ctx, t.ID); err != nil {
+			log.Printf("retention sweep: tenant_id=%s error: %v", t.ID, err)
+		}
+	}
+}
+
+// sweepTenant purges the dataset versions, then the raw partitions, the
+// tenant's policies and plan no longer keep, and credits deleted storage
+// back.
+func (s *RetentionService) sweepTenant(ctx context.Context, tenantID string) error {
+	maxDays, err := s.plans.MaxRetentionDays(domain.ContextWithTenantID(ctx, tenantID))
+	if err != nil {
+		return fmt.Errorf("get plan: %w", err)
+	}
+	tenantPolicy, err := effectiveRetention(ctx, s.policies, tenantID, "")
+	if err != nil {
+		return err
+	}
+	datasets, err := s.datasets.ListAllByTenant(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("list datasets: %w", err)
+	}
+
+	var freed int64
+	inUse := make(map[string]bool)
+	var inUsePrefixes []string
+	// A tracker dataset is the partitions of its root rather than a use of
+	// them; its own policy, if any, sweeps them instead of the tenant's
+	rootPolicies := make(map[string]*domain.RetentionPolicy)
+	for i := range datasets {
+		ds := &datasets[i]
+		n, err := s.sweepDataset(ctx, ds, maxDays)
+		freed += n
+		if err != nil {
+			log.Printf("retention sweep: dataset_id=%s error: %v", ds.ID, err)
+		}
+
+		switch {
+		case ds.SourceType == domain.SourceTypeTracker:
+			if ds.DeletedAt == nil {
+				policy, err := effectiveRetention(ctx, s.policies, tenantID, ds.ID)
+				if err != nil {
+					return err
+				}
+				rootPolicies[strings.TrimSuffix(ds.StoragePath, "/")+"/"] = policy
+			}
+		case ds.StoragePath == "":
+		case storage.IsParquetObject(ds.StoragePath):
+			inUse[ds.StoragePath] = true
+		default:
+			inUsePrefixes = append(inUsePrefixes, strings.TrimSuffix(ds.StoragePath, "/")+"/")
+		}
+		versions, err := s.versions.ListByDataset(ctx, tenantID, ds.ID, 0)
+		if err != nil {
+			return fmt.Errorf("list versions: %w", err)
+		}
+		for j := range versions {
+			keys, err := versionObjects(&versions[j])
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				inUse[key] = true
+			}
+		}
+	}
+
+	for _, root := range retentionPartitionRoots {
+		prefix := fmt.Sprintf("%s/%s/", root, tenantID)
+		policy := tenantPolicy
+		if p, ok := rootPolicies[prefix]; ok {
+			policy = p
+		}
+		keepDays := capKeepDays(policy.KeepDays, maxDays)
+		if keepDays == nil {
+			continue
+		}
+		cutoff := time.Now().UTC().AddDate(0, 0, -*keepDays).Format("2006-01-02")
+		purged, n, err := s.sweepPartitions(ctx, tenantID, prefix, cutoff, policy.Archive,
+			func(key string) bool {
+				if inUse[key] {
+					return true
+				}
+				for _, prefix := range inUsePrefixes {
+					if strings.HasPrefix(key, prefix) {
+						return true
+					}
+				}
+				return false
+			})
+		freed += n
+		if purged > 0 {
+			s.refreshDatasets(ctx, datasets, prefix)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if freed > 0 {
+		if err := s.metering.RecordStorageRelease(ctx, tenantID, 0, freed); err != nil {
+			log.Printf("metering record storage release error tenant=%s: %v", tenantID, err)
+		}
+	}
+	return nil
+}
+
+// sweepDataset purges the versions of a dataset beyond its policy and
+// returns the bytes deleted. The current version and pinned versions are
+// always kept.
+func (s *RetentionService) sweepDataset(ctx context.Context, ds *domain.Dataset, maxDays int) (int64, error) {
+	policy, err := effectiveRetention(ctx, s.policies, ds.TenantID, ds.ID)
+	if err != nil {
+		return 0, err
+	}
+	keepDays := capKeepDays(policy.KeepDays, maxDays)
+	if keepDays == nil && policy.KeepVersions == nil {
+		return 0, nil
+	}
+	versions, err := s.versions.ListByDataset(ctx, ds.TenantID, ds.ID, 0)
+	if err != nil {
+		return 0, fmt.Errorf("list versions: %w", err)
+	}
+
+	now := time.Now()
+	pruned, err := pruneVersions(ctx, s.versions, ds, versions,
+		func(i int, v *domain.DatasetVersion) bool {
+			if i == 0 || v.Pinned {
+				return true
+			}
+			if policy.KeepVersions != nil && i >= *policy.KeepVersions {
+				return false
+			}
+			return keepDays == nil || v.CreatedAt.After(now.AddDate(0, 0, -*keepDays))
+		},
+		func(ctx context.Context, key string) (int64, error) {
+			return s.dispose(ctx, key, policy.Archive)
+		},
+	)
+
+	var freed int64
+	for _, p := range pruned {
+		datasetID := ds.ID
+		s.recordPurge(ctx, &domain.RetentionPurge{
+			TenantID:    ds.TenantID,
+			DatasetID:   &datasetID,
+			Kind:        domain.RetentionPurgeVersion,
+			Target:      strconv.Itoa(p.version.Version),
+			Action:      purgeAction(policy.Archive),
+			ObjectCount: p.objects,
+			Bytes:       p.bytes,
+		})
+		if !policy.Archive {
+			freed += p.bytes
+		}
+	}
+	if len(pruned) > 0 {
+		log.Printf("retention sweep: purged versions=%d dataset_id=%s", len(pruned), ds.ID)
+	}
+	return freed, err
+}
+
+// sweepPartitions purges the dt= partitions under prefix dated before
+// cutoff, skipping the objects datasets still read, and returns the number
+// of objects purged and the bytes deleted.
+func (s *RetentionService) sweepPartitions(ctx context.Context, tenantID, prefix, cutoff string, archive bool, inUse func(key string) bool) (int, int64, error) {
+	partitions, err := s.objects.ListPrefixes(ctx, prefix)
+	if err != nil {
+		return 0, 0, err
+	}
+	var purged int
+	var freed int64
+	for _, partition := range partitions {
+		date, ok := strings.CutPrefix(strings.TrimSuffix(strings.TrimPrefix(partition, prefix), "/"), "dt=")
+		if !ok || date >= cutoff {
+			continue
+		}
+		keys, err := s.objects.ListObjectKeys(ctx, partition)
+		if err != nil {
+			return purged, freed, err
+		}
+		purge := &domain.RetentionPurge{
+			TenantID: tenantID,
+			Kind:     domain.RetentionPurgePartition,
+			Target:   partition,
+			Action:   purgeAction(archive),
+		}
+		for _, key := range keys {
+			if inUse(key) {
+				continue
+			}
+			size, err := s.dispose(ctx, key, archive)
+			if err != nil {
+				return purged, freed, err
+			}
+			purge.ObjectCount++
+			purge.Bytes += size
+		}
+		if purge.ObjectCount == 0 {
+			continue
+		}
+		s.recordPurge(ctx, purge)
+		purged += purge.ObjectCount
+		if !archive {
+			freed += purge.Bytes
+		}
+		log.Printf("retention sweep: %s partition=%s objects=%d", purge.Action, partition, purge.ObjectCount)
+	}
+	return purged, freed, nil
+}
+
+// refreshDatasets recounts the rows of the datasets stored over prefix once
+// partitions under it are purged. A dataset left without objects has its
+// storage path cleared, so it reads as having no data until written again.
+func (s *RetentionService) refreshDatasets(ctx context.Context, datasets []domain.Dataset, prefix string) {
+	for i := range datasets {
+		ds := datasets[i]
+		if ds.DeletedAt != nil || ds.StoragePath == "" || storage.IsParquetObject(ds.StoragePath) {
+			continue
+		}
+		root := strings.TrimSuffix(ds.StoragePath, "/") + "/"
+		if !strings.HasPrefix(prefix, root) {
+			continue
+		}
+		rows, err := s.objects.CountParquetRows(ctx, ds.TenantID, root)
+		if err != nil {
+			log.Printf("retention sweep: count rows dataset_id=%s error: %v", ds.ID, err)
+			continue
+		}
+		keys, err := s.objects.ListObjectKeys(ctx, root)
+		if err != nil {
+			log.Printf("retention sweep: list objects dataset_id=%s error: %v", ds.ID, err)
+			continue
+		}
+		if !slices.ContainsFunc(keys, storage.IsParquetObject) {
+			ds.StoragePath = ""
+		}
+		ds.RowCount = &rows
+		if err := s.datasets.Update(ctx, &ds); err != nil {
+			log.Printf("retention sweep: update dataset_id=%s error: %v", ds.ID, err)
+		}
+	}
+}
+
+// dispose deletes an object, or moves it under the archive prefix, and
+// returns its size.
+func (s *RetentionService) dispose(ctx context.Context, key string, archive bool) (int64, error) {
+	size, err := s.objects.ObjectSize(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if archive {
+		if err := s.objects.CopyObject(ctx, key, s.cfg.ArchivePrefix+key); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.objects.RemoveObject(ctx, key); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// recordPurge writes an audit record of a purge, logging errors since the
+// objects are gone either way.
+func (s *RetentionService) recordPurge(ctx context.Context, p *domain.RetentionPurge) {
+	p.ID = uuid.New().String()
+	if err := s.purges.Create(ctx, p); err != nil {
+		log.Printf("retention sweep: record purge tenant_id=%s target=%s error: %v", p.TenantID, p.Target, err)
+	}
+}
+
+// effectiveRetention returns the retention that applies to a dataset: its
+// own policy's limits, falling back to the tenant's. With an empty
+// datasetID it returns the tenant's policy. Without any policy it returns
+// one limiting nothing.
+func effectiveRetention(ctx context.Context, policies domain.RetentionPolicyRepository, tenantID, datasetID string) (*domain.RetentionPolicy, error) {
+	policy := &domain.RetentionPolicy{TenantID: tenantID}
+	tenantPolicy, err := policies.Find(ctx, tenantID, "")
+	if err != nil && !errors.Is(err, domain.ErrRetentionPolicyNotFound) {
+		return nil, fmt.Errorf("find retention policy: %w", err)
+	}
+	if tenantPolicy != nil {
+		*policy = *tenantPolicy
+	}
+	if datasetID == "" {
+		return policy, nil
+	}
+
+	own, err := policies.Find(ctx, tenantID, datasetID)
+	if errors.Is(err, domain.ErrRetentionPolicyNotFound) {
+		return policy, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find retention policy: %w", err)
+	}
+	if own.KeepDays != nil {
+		policy.KeepDays = own.KeepDays
+	}
+	if own.KeepVersions != nil {
+		policy.KeepVersions = own.KeepVersions
+	}
+	policy.Archive = own.Archive
+	policy.DatasetID = own.DatasetID
+	return policy, nil
+}
+
+// capKeepDays limits keepDays to a plan's maximum, -1 being unlimited.
+func capKeepDays(keepDays *int, maxDays int) *int {
+	if maxDays < 0 || (keepDays != nil && *keepDays <= maxDays) {
+		return keepDays
+	}
+	return &maxDays
+}
+
+func purgeAction(archive bool) string {
+	if archive {
+		return domain.RetentionPurgeArchived
+	}
+	return domain.RetentionPurgeDeleted
+}
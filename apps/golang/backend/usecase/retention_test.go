@@ -0,0 +1,331 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/storage"
+)
+
+// retentionBucket is a bucket of objects by key, each 100 bytes. Every
+// Parquet object holds 10 rows.
+type retentionBucket struct {
+	domain.ObjectStore
+	objects map[string]bool
+	removed []string
+	copied  map[string]string // destination to source
+}
+
+func (s *retentionBucket) ListObjectKeys(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for k := range s.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *retentionBucket) ListPrefixes(_ context.Context, prefix string) ([]string, error) {
+	seen := map[string]bool{}
+	var prefixes []string
+	for k := range s.objects {
+		rest, ok := strings.CutPrefix(k, prefix)
+		if i := strings.IndexByte(rest, '/'); ok && i >= 0 && !seen[rest[:i]] {
+			seen[rest[:i]] = true
+			prefixes = append(prefixes, prefix+rest[:i+1])
+		}
+	}
+	sort.Strings(prefixes)
+	return prefixes, nil
+}
+
+func (s *retentionBucket) ObjectSize(_ context.Context, key string) (int64, error) {
+	if !s.objects[key] {
+		return 0, storage.ErrObjectNotFound
+	}
+	return 100, nil
+}
+
+func (s *retentionBucket) CopyObject(_ context.Context, src, dst string) error {
+	s.objects[dst] = true
+	s.copied[dst] = src
+	return nil
+}
+
+func (s *retentionBucket) RemoveObject(_ context.Context, key string) error {
+	delete(s.objects, key)
+	s.removed = append(s.removed, key)
+	return nil
+}
+
+func (s *retentionBucket) CountParquetRows(ctx context.Context, _, prefix string) (int64, error) {
+	keys, _ := s.ListObjectKeys(ctx, prefix)
+	var n int64
+	for _, k := range keys {
+		if storage.IsParquetObject(k) {
+			n += 10
+		}
+	}
+	return n, nil
+}
+
+type retentionFixture struct {
+	svc      *RetentionService
+	datasets *db.DatasetRepo
+	versions *db.DatasetVersionRepo
+	purges   *db.RetentionPurgeRepo
+	usage    *db.UsageRepo
+	objects  *retentionBucket
+}
+
+// newRetentionFixture stores a tenant on planID, or the default plan, whose
+// objects and versions are all 60 days old but for one tracked events
+// partition of today:
+//
+//   - the tracker datasets tracker_events, over both events partitions,
+//     and tracker_event_counts_hourly, over an old aggregate partition;
+//   - the transform output report, whose current run1 and pinned run0
+//     share their partition with the unreferenced run9;
+//   - the dataset orders, single files v1 to v4 with v2 pinned.
+func newRetentionFixture(t *testing.T, policy *domain.RetentionPolicy, planID string) *retentionFixture {
+	t.Helper()
+	ctx := context.Background()
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	old := time.Now().UTC().AddDate(0, 0, -60)
+	oldDay, today := "dt="+old.Format("2006-01-02"), "dt="+time.Now().UTC().Format("2006-01-02")
+	const o = "datasets/t1/orders/"
+	r := "transforms/t1/" + oldDay + "/"
+	rows := func(n int64) *int64 { return &n }
+
+	f := &retentionFixture{
+		datasets: db.NewDatasetRepo(sqlDB),
+		versions: db.NewDatasetVersionRepo(sqlDB),
+		purges:   db.NewRetentionPurgeRepo(sqlDB),
+		usage:    db.NewUsageRepo(sqlDB),
+		objects:  &retentionBucket{objects: map[string]bool{}, copied: map[string]string{}},
+	}
+	for _, d := range []*domain.Dataset{
+		{ID: "events", TenantID: "t1", Name: "tracker_events", SourceType: domain.SourceTypeTracker, StoragePath: "events/t1", RowCount: rows(20)},
+		{ID: "counts", TenantID: "t1", Name: "tracker_event_counts_hourly", SourceType: domain.SourceTypeTracker, StoragePath: "aggregated/events/t1", RowCount: rows(10)},
+		{ID: "report", TenantID: "t1", Name: "report", SourceType: domain.SourceTypeTransform, StoragePath: r + "run1.parquet"},
+		{ID: "orders", TenantID: "t1", Name: "orders", SourceType: domain.SourceTypeImport, StoragePath: o + "v4.parquet"},
+	} {
+		if err := f.datasets.Create(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, v := range []domain.DatasetVersion{
+		{DatasetID: "report", StoragePath: r + "run0.parquet", Pinned: true},
+		{DatasetID: "report", StoragePath: r + "run1.parquet"},
+		{DatasetID: "orders", StoragePath: o + "v1.parquet"},
+		{DatasetID: "orders", StoragePath: o + "v2.parquet", Pinned: true},
+		{DatasetID: "orders", StoragePath: o + "v3.parquet"},
+		{DatasetID: "orders", StoragePath: o + "v4.parquet"},
+	} {
+		v.ID, v.TenantID = fmt.Sprintf("v%d", i), "t1"
+		if err := f.versions.Create(ctx, &v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := sqlDB.ExecContext(ctx, `UPDATE dataset_versions SET created_at = ?`, old.Format("2006-01-02 15:04:05")); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{
+		"events/t1/" + oldDay + "/a.parquet", "events/t1/" + today + "/b.parquet",
+		"aggregated/events/t1/" + oldDay + "/x.parquet",
+		r + "run0.parquet", r + "run1.parquet", r + "run9.parquet",
+		o + "v1.parquet", o + "v2.parquet", o + "v3.parquet", o + "v4.parquet",
+	} {
+		f.objects.objects[key] = true
+	}
+
+	policies := db.NewRetentionPolicyRepo(sqlDB)
+	if policy != nil {
+		policy.ID, policy.TenantID = "rp1", "t1"
+		if err := policies.Upsert(ctx, policy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tenantPlans := db.NewTenantPlanRepo(sqlDB)
+	if planID != "" {
+		if err := tenantPlans.Upsert(ctx, &domain.TenantPlan{ID: "tp1", TenantID: "t1", PlanID: planID, StartedAt: old}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plans := NewPlanService(db.NewPlanRepo(sqlDB), tenantPlans, f.usage)
+	f.svc = NewRetentionService(policies, f.purges, f.datasets, f.versions, db.NewTenantRepo(sqlDB), plans,
+		NewMeteringService(f.usage), f.objects, RetentionConfig{ArchivePrefix: "archive/"})
+	return f
+}
+
+// numbers returns the version numbers of datasetID, oldest first.
+func (f *retentionFixture) numbers(t *testing.T, datasetID string) []int {
+	t.Helper()
+	list, err := f.versions.ListByDataset(context.Background(), "t1", datasetID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var numbers []int
+	for _, v := range list {
+		numbers = append(numbers, v.Version)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// dataset returns the stored dataset id.
+func (f *retentionFixture) dataset(t *testing.T, id string) *domain.Dataset {
+	t.Helper()
+	ds, err := f.datasets.FindByID(context.Background(), "t1", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ds
+}
+
+func TestRetentionSweep(t *testing.T) {
+	oldDay := "dt=" + time.Now().UTC().AddDate(0, 0, -60).Format("2006-01-02")
+	keep30 := 30
+	// Kept whatever the policy: the current and pinned report runs and
+	// orders versions, and today's events
+	purged := []string{
+		"aggregated/events/t1/" + oldDay + "/x.parquet",
+		"datasets/t1/orders/v1.parquet",
+		"datasets/t1/orders/v3.parquet",
+		"events/t1/" + oldDay + "/a.parquet",
+		"transforms/t1/" + oldDay + "/run9.parquet",
+	}
+
+	tests := []struct {
+		name        string
+		edition     string
+		policy      *domain.RetentionPolicy
+		planID      string
+		wantRemoved []string
+		wantAction  string
+		wantFreed   int64
+	}{
+		{name: "no policy or plan cap", edition: "oss", planID: "plan-free-default"},
+		{
+			name: "plan cap without a policy", edition: "web", planID: "plan-free-default",
+			wantRemoved: purged, wantAction: domain.RetentionPurgeDeleted, wantFreed: 500,
+		},
+		{
+			name: "policy deletes", edition: "oss", policy: &domain.RetentionPolicy{KeepDays: &keep30},
+			wantRemoved: purged, wantAction: domain.RetentionPurgeDeleted, wantFreed: 500,
+		},
+		{
+			// Archived objects still take storage, so none is credited back
+			name: "policy archives", edition: "oss", policy: &domain.RetentionPolicy{KeepDays: &keep30, Archive: true},
+			wantRemoved: purged, wantAction: domain.RetentionPurgeArchived,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITION", tt.edition)
+			f := newRetentionFixture(t, tt.policy, tt.planID)
+			if err := f.svc.sweepTenant(context.Background(), "t1"); err != nil {
+				t.Fatal(err)
+			}
+
+			removed := append([]string(nil), f.objects.removed...)
+			sort.Strings(removed)
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", removed, tt.wantRemoved)
+			}
+			var archived []string
+			for dst, src := range f.objects.copied {
+				if dst != "archive/"+src {
+					t.Errorf("%s archived to %s", src, dst)
+				}
+				archived = append(archived, src)
+			}
+			sort.Strings(archived)
+			if wantArchived := tt.wantAction == domain.RetentionPurgeArchived; wantArchived != (len(archived) > 0) ||
+				(wantArchived && !reflect.DeepEqual(archived, tt.wantRemoved)) {
+				t.Errorf("archived = %v", archived)
+			}
+			var freed int64
+			if u, err := f.usage.FindDailyByTenantAndDate(context.Background(), "t1", today()); err != nil {
+				t.Fatal(err)
+			} else if u != nil {
+				freed = -u.StorageBytes
+			}
+			if freed != tt.wantFreed {
+				t.Errorf("storage credited back = %d, want %d", freed, tt.wantFreed)
+			}
+			if got := f.numbers(t, "orders"); len(tt.wantRemoved) > 0 && !reflect.DeepEqual(got, []int{2, 4}) {
+				t.Errorf("orders versions = %v, want the pinned 2 and the current 4", got)
+			}
+			if got := f.numbers(t, "report"); !reflect.DeepEqual(got, []int{1, 2}) {
+				t.Errorf("report versions = %v, want both kept", got)
+			}
+
+			// One audit record per purged version and partition, in the
+			// order they were purged
+			purges, err := f.purges.ListByTenant(context.Background(), "t1", "", 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Reverse(purges)
+			var records []string
+			for _, p := range purges {
+				if p.Action != tt.wantAction || p.TenantID != "t1" || p.ObjectCount != 1 || p.Bytes != 100 {
+					t.Errorf("purge %+v, want %s of one 100 byte object", p, tt.wantAction)
+				}
+				records = append(records, p.Kind+" "+p.Target)
+			}
+			var wantRecords []string
+			if len(tt.wantRemoved) > 0 {
+				wantRecords = []string{
+					"version 3", "version 1",
+					"partition events/t1/" + oldDay + "/",
+					"partition aggregated/events/t1/" + oldDay + "/",
+					"partition transforms/t1/" + oldDay + "/",
+				}
+			}
+			if !reflect.DeepEqual(records, wantRecords) {
+				t.Errorf("purges = %v, want %v", records, wantRecords)
+			}
+		})
+	}
+}
+
+func TestRetentionSweepRefreshesDatasets(t *testing.T) {
+	t.Setenv("EDITION", "oss")
+	keep30 := 30
+	f := newRetentionFixture(t, &domain.RetentionPolicy{KeepDays: &keep30}, "")
+	if err := f.svc.sweepTenant(context.Background(), "t1"); err != nil {
+		t.Fatal(err)
+	}
+
+	rowCount := func(ds *domain.Dataset) int64 {
+		if ds.RowCount == nil {
+			return -1
+		}
+		return *ds.RowCount
+	}
+	events := f.dataset(t, "events")
+	if events.StoragePath != "events/t1" || rowCount(events) != 10 {
+		t.Errorf("tracker_events = %s with %d rows, want events/t1 with today's 10", events.StoragePath, rowCount(events))
+	}
+	// Every partition of the aggregate was purged, so it has no data left
+	counts := f.dataset(t, "counts")
+	if counts.StoragePath != "" || rowCount(counts) != 0 {
+		t.Errorf("tracker_event_counts_hourly = %q with %d rows, want no storage path and 0 rows", counts.StoragePath, rowCount(counts))
+	}
+	if report := f.dataset(t, "report"); report.RowCount != nil {
+		t.Errorf("report over a kept object was recounted to %d rows", *report.RowCount)
+	}
+}
@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/user/micro-dp/domain"
+)
+
+// checkOwnerOrAdmin returns domain.ErrInsufficientRole unless the caller is
+// an owner or admin of the tenant.
+func checkOwnerOrAdmin(ctx context.Context, tenants domain.TenantRepository, tenantID string) error {
+	userID, ok := domain.UserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("user id not found in context")
+	}
+	role, err := tenants.GetUserRole(ctx, userID, tenantID)
+	if err != nil {
+		return err
+	}
+	if role != domain.TenantRoleOwner && role != domain.TenantRoleAdmin {
+		return domain.ErrInsufficientRole
+	}
+	return nil
+}
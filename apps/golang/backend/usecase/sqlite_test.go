@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+)
+
+// openTestDB returns a migrated database in a temporary file.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "test.db"))
+	sqlDB, err := db.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.Migrate(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	return sqlDB
+}
+
+// createTestTenant inserts an active tenant.
+func createTestTenant(t *testing.T, sqlDB *sql.DB, id string) {
+	t.Helper()
+	if err := db.NewTenantRepo(sqlDB).Create(context.Background(), &domain.Tenant{ID: id, Name: id, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createTestMember inserts a user <id>@example.com with role in tenantID.
+func createTestMember(t *testing.T, sqlDB *sql.DB, tenantID, id, role string) {
+	t.Helper()
+	ctx := context.Background()
+	if err := db.NewUserRepo(sqlDB).Create(ctx, &domain.User{ID: id, Email: id + "@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.NewTenantRepo(sqlDB).AddUserToTenant(ctx, &domain.UserTenant{UserID: id, TenantID: tenantID, Role: role}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createTestUpload inserts upload u1 of tenant t1 with status and files.
+func createTestUpload(t *testing.T, uploads *db.UploadRepo, status string, files ...domain.UploadFile) {
+	t.Helper()
+	ctx := context.Background()
+	if err := uploads.CreateUpload(ctx, &domain.Upload{ID: "u1", TenantID: "t1", Status: status}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range files {
+		f := &files[i]
+		f.TenantID, f.UploadID, f.ObjectKey = "t1", "u1", "uploads/t1/u1/"+f.FileName
+		if err := uploads.CreateUploadFile(ctx, f); err != nil {
+			t.Fatal(err)
+		}
+		if f.ErrorMessage != nil {
+			if err := uploads.UpdateFileResult(ctx, f); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+// tenantContext returns a context for a request by userID with role in
+// tenantID.
+func tenantContext(tenantID, userID, role string) context.Context {
+	ctx := domain.ContextWithTenantID(context.Background(), tenantID)
+	ctx = domain.ContextWithUserID(ctx, userID)
+	return domain.ContextWithTenantRole(ctx, role)
+}
+
+// versionKeys returns the ObjectKeysJSON of a version made of keys.
+func versionKeys(keys ...string) *string {
+	data, _ := json.Marshal(keys)
+	s := string(data)
+	return &s
+}
@@ -3,29 +3,43 @@ package usecase
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	_ "github.com/marcboeker/go-duckdb"
 
 	"github.com/google/uuid"
 	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/datasetquery"
+	"github.com/user/micro-dp/internal/duckdbsandbox"
 	"github.com/user/micro-dp/storage"
 )
 
+// outputNamePattern is the form of an output dataset name: an identifier
+// that reads the same quoted or not.
+var outputNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,127}$`)
+
 type TransformService struct {
-	datasets    domain.DatasetRepository
-	minio       *storage.MinIOClient
-	jobs        *JobService
-	moduleTypes domain.ModuleTypeRepository
-	jobRuns     domain.JobRunRepository
-	versions    domain.JobVersionRepository
-	modules     domain.JobModuleRepository
-	queue       domain.TransformJobQueue
+	datasets        domain.DatasetRepository
+	datasetVersions domain.DatasetVersionRepository
+	minio           *storage.MinIOClient
+	jobs            *JobService
+	moduleTypes     domain.ModuleTypeRepository
+	jobRuns         domain.JobRunRepository
+	versions        domain.JobVersionRepository
+	modules         domain.JobModuleRepository
+	queue           domain.TransformJobQueue
+	masking         *DatasetMaskingService
+	catalog         *DataCatalogService
 }
 
 func NewTransformService(
 	datasets domain.DatasetRepository,
+	datasetVersions domain.DatasetVersionRepository,
 	minio *storage.MinIOClient,
 	jobs *JobService,
 	moduleTypes domain.ModuleTypeRepository,
@@ -33,16 +47,21 @@ func NewTransformService(
 	versions domain.JobVersionRepository,
 	modules domain.JobModuleRepository,
 	queue domain.TransformJobQueue,
+	masking *DatasetMaskingService,
+	catalog *DataCatalogService,
 ) *TransformService {
 	return &TransformService{
-		datasets:    datasets,
-		minio:       minio,
-		jobs:        jobs,
-		moduleTypes: moduleTypes,
-		jobRuns:     jobRuns,
-		versions:    versions,
-		modules:     modules,
-		queue:       queue,
+		datasets:        datasets,
+		datasetVersions: datasetVersions,
+		minio:           minio,
+		jobs:            jobs,
+		moduleTypes:     moduleTypes,
+		jobRuns:         jobRuns,
+		versions:        versions,
+		modules:         modules,
+		queue:           queue,
+		masking:         masking,
+		catalog:         catalog,
 	}
 }
 
@@ -63,14 +82,33 @@ type PreviewResult struct {
 	RowCount int
 }
 
+// TransformDatasetVersions selects the versions of a transform's input
+// datasets. Versions pins datasets by ID; AsOf reads every other input at
+// its latest version created at or before that time. The zero value reads
+// the current data.
+type TransformDatasetVersions struct {
+	Versions map[string]int
+	AsOf     *time.Time
+}
+
 type CreateTransformJobInput struct {
-	Name        string
-	Slug        string
-	Description string
-	SQL         string
-	DatasetIDs  []string
-	Execution   string // "save_only", "immediate", "scheduled"
-	ScheduledAt *time.Time
+	Name            string
+	Slug            string
+	Description     string
+	SQL             string
+	DatasetIDs      []string
+	DatasetVersions TransformDatasetVersions
+	Execution       string // "save_only", "immediate", "scheduled"
+	ScheduledAt     *time.Time
+	Output          *domain.TransformOutput // nil writes a new dataset per run
+}
+
+// transformModuleConfig is the config_json of a transform job's module.
+type transformModuleConfig struct {
+	SQL             string                  `json:"sql"`
+	DatasetIDs      []string                `json:"dataset_ids"`
+	DatasetVersions map[string]int          `json:"dataset_versions,omitempty"`
+	Output          *domain.TransformOutput `json:"output,omitempty"`
 }
 
 type CreateTransformJobResult struct {
@@ -79,7 +117,40 @@ type CreateTransformJobResult struct {
 	JobRun  *domain.JobRun
 }
 
-func (s *TransformService) setupDuckDB(ctx context.Context, tenantID string, datasetIDs []string) (*sql.DB, error) {
+// resolveDatasetVersions returns the version of every input dataset sel
+// selects by dataset ID. Inputs read at their current data are left out.
+func (s *TransformService) resolveDatasetVersions(ctx context.Context, tenantID string, datasetIDs []string, sel TransformDatasetVersions) (map[string]int, error) {
+	inputs := make(map[string]bool, len(datasetIDs))
+	for _, id := range datasetIDs {
+		inputs[id] = true
+	}
+	for id := range sel.Versions {
+		if !inputs[id] {
+			return nil, fmt.Errorf("dataset_versions: dataset %s is not an input", id)
+		}
+	}
+
+	resolved := make(map[string]int)
+	for _, id := range datasetIDs {
+		v, err := resolveDatasetVersion(ctx, s.datasetVersions, tenantID, id, DatasetVersionSelector{Version: sel.Versions[id], AsOf: sel.AsOf})
+		if err != nil {
+			return nil, fmt.Errorf("dataset %s: %w", id, err)
+		}
+		if v != nil {
+			resolved[id] = v.Version
+		}
+	}
+	if len(resolved) == 0 {
+		return nil, nil
+	}
+	return resolved, nil
+}
+
+// setupDuckDB opens a DuckDB session for running sqlStr over the given
+// datasets. The session reads S3 only under the tenant's prefixes, and once
+// the dataset views are registered, sqlStr is checked to be a single SELECT
+// over them and the session is locked down.
+func (s *TransformService) setupDuckDB(ctx context.Context, tenantID, sqlStr string, datasetIDs []string, sel TransformDatasetVersions) (*sql.DB, error) {
 	datasets := make([]*domain.Dataset, 0, len(datasetIDs))
 	for _, id := range datasetIDs {
 		ds, err := s.datasets.FindByID(ctx, tenantID, id)
@@ -88,6 +159,10 @@ func (s *TransformService) setupDuckDB(ctx context.Context, tenantID string, dat
 		}
 		datasets = append(datasets, ds)
 	}
+	versions, err := s.resolveDatasetVersions(ctx, tenantID, datasetIDs, sel)
+	if err != nil {
+		return nil, err
+	}
 
 	duckDB, err := sql.Open("duckdb", "")
 	if err != nil {
@@ -95,25 +170,60 @@ func (s *TransformService) setupDuckDB(ctx context.Context, tenantID string, dat
 	}
 
 	s3Cfg := s.minio.S3Config()
-	if err := storage.ConfigureDuckDBHTTPFS(ctx, duckDB, s3Cfg); err != nil {
+	if err := storage.ConfigureDuckDBTenantS3(ctx, duckDB, s3Cfg, tenantID); err != nil {
 		duckDB.Close()
 		return nil, fmt.Errorf("configure httpfs: %w", err)
 	}
 
-	// Register each dataset as a VIEW reading directly from S3
+	// Register each dataset as a VIEW reading directly from S3, at its
+	// selected version if any, with the columns masked for the caller
 	for _, ds := range datasets {
-		uri := storage.S3ParquetURI(s3Cfg.Bucket, ds.StoragePath)
-		viewSQL := fmt.Sprintf("CREATE VIEW %s AS SELECT * FROM read_parquet('%s')", quoteIdentifier(ds.Name), uri)
-		if _, err := duckDB.ExecContext(ctx, viewSQL); err != nil {
+		source := storage.S3ParquetSource(s3Cfg.Bucket, ds.StoragePath, nil)
+		if n, ok := versions[ds.ID]; ok {
+			v, err := s.datasetVersions.FindByVersion(ctx, tenantID, ds.ID, n)
+			if err == nil {
+				var keys []string
+				if keys, err = v.ObjectKeys(); err == nil {
+					source = storage.S3ParquetSource(s3Cfg.Bucket, v.StoragePath, keys)
+				}
+			}
+			if err != nil {
+				duckDB.Close()
+				return nil, fmt.Errorf("dataset %s version %d: %w", ds.Name, n, err)
+			}
+		}
+		from := fmt.Sprintf("read_parquet(%s)", source)
+		masks, err := s.masking.Masks(ctx, ds.ID)
+		if err == nil && len(masks) > 0 {
+			from, err = datasetquery.From(ctx, duckDB, source, masks)
+		}
+		if err != nil {
+			duckDB.Close()
+			return nil, fmt.Errorf("dataset %s masking: %w", ds.Name, err)
+		}
+		if err := duckdbsandbox.CreateView(ctx, duckDB, ds.Name, from); err != nil {
 			duckDB.Close()
-			return nil, fmt.Errorf("create view %s: %w", ds.Name, err)
+			return nil, err
 		}
 	}
 
+	views := make([]string, len(datasets))
+	for i, ds := range datasets {
+		views[i] = ds.Name
+	}
+	if err := duckdbsandbox.CheckSelect(ctx, duckDB, sqlStr, views); err != nil {
+		duckDB.Close()
+		return nil, err
+	}
+	if err := duckdbsandbox.Lock(ctx, duckDB, false); err != nil {
+		duckDB.Close()
+		return nil, err
+	}
+
 	return duckDB, nil
 }
 
-func (s *TransformService) ValidateSQL(ctx context.Context, sqlStr string, datasetIDs []string) (*ValidateResult, error) {
+func (s *TransformService) ValidateSQL(ctx context.Context, sqlStr string, datasetIDs []string, sel TransformDatasetVersions) (*ValidateResult, error) {
 	tenantID, ok := domain.TenantIDFromContext(ctx)
 	if !ok {
 		return nil, fmt.Errorf("tenant id not found in context")
@@ -129,7 +239,7 @@ func (s *TransformService) ValidateSQL(ctx context.Context, sqlStr string, datas
 	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	duckDB, err := s.setupDuckDB(timeoutCtx, tenantID, datasetIDs)
+	duckDB, err := s.setupDuckDB(timeoutCtx, tenantID, sqlStr, datasetIDs, sel)
 	if err != nil {
 		return &ValidateResult{Valid: false, Error: err.Error()}, nil
 	}
@@ -141,7 +251,7 @@ func (s *TransformService) ValidateSQL(ctx context.Context, sqlStr string, datas
 	}
 
 	// Get columns from the query
-	createTmp := fmt.Sprintf("CREATE TEMP TABLE _r AS SELECT * FROM (%s) AS _q LIMIT 0", sqlStr)
+	createTmp := fmt.Sprintf("CREATE TEMP TABLE _r AS SELECT * FROM (\n%s\n) AS _q LIMIT 0", sqlStr)
 	if _, err := duckDB.ExecContext(timeoutCtx, createTmp); err != nil {
 		return &ValidateResult{Valid: false, Error: err.Error()}, nil
 	}
@@ -154,7 +264,7 @@ func (s *TransformService) ValidateSQL(ctx context.Context, sqlStr string, datas
 	return &ValidateResult{Valid: true, Columns: columns}, nil
 }
 
-func (s *TransformService) PreviewSQL(ctx context.Context, sqlStr string, datasetIDs []string, limit int) (*PreviewResult, error) {
+func (s *TransformService) PreviewSQL(ctx context.Context, sqlStr string, datasetIDs []string, sel TransformDatasetVersions, limit int) (*PreviewResult, error) {
 	tenantID, ok := domain.TenantIDFromContext(ctx)
 	if !ok {
 		return nil, fmt.Errorf("tenant id not found in context")
@@ -170,13 +280,13 @@ func (s *TransformService) PreviewSQL(ctx context.Context, sqlStr string, datase
 	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	duckDB, err := s.setupDuckDB(timeoutCtx, tenantID, datasetIDs)
+	duckDB, err := s.setupDuckDB(timeoutCtx, tenantID, sqlStr, datasetIDs, sel)
 	if err != nil {
 		return nil, fmt.Errorf("setup duckdb: %w", err)
 	}
 	defer duckDB.Close()
 
-	query := fmt.Sprintf("SELECT * FROM (%s) AS _q LIMIT %d", sqlStr, limit)
+	query := fmt.Sprintf("SELECT * FROM (\n%s\n) AS _q LIMIT %d", sqlStr, limit)
 	rows, err := duckDB.QueryContext(timeoutCtx, query)
 	if err != nil {
 		return nil, fmt.Errorf("execute query: %w", err)
@@ -217,6 +327,7 @@ func (s *TransformService) PreviewSQL(ctx context.Context, sqlStr string, datase
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate rows: %w", err)
 	}
+	s.catalog.RecordUsage(ctx, domain.DatasetUsagePreview, "", datasetIDs...)
 
 	return &PreviewResult{
 		Columns:  columns,
@@ -231,17 +342,27 @@ func (s *TransformService) CreateTransformJob(ctx context.Context, input CreateT
 		return nil, fmt.Errorf("tenant id not found in context")
 	}
 
+	// Resolve as_of to concrete versions, so that every run reads the same data
+	datasetVersions, err := s.resolveDatasetVersions(ctx, tenantID, input.DatasetIDs, input.DatasetVersions)
+	if err != nil {
+		return nil, err
+	}
+	pinned := TransformDatasetVersions{Versions: datasetVersions}
+
 	// Validate SQL first
-	result, err := s.ValidateSQL(ctx, input.SQL, input.DatasetIDs)
+	result, err := s.ValidateSQL(ctx, input.SQL, input.DatasetIDs, pinned)
 	if err != nil {
 		return nil, err
 	}
 	if !result.Valid {
 		return nil, fmt.Errorf("invalid SQL: %s", result.Error)
 	}
+	if err := s.validateOutput(ctx, tenantID, input.Output, result.Columns); err != nil {
+		return nil, err
+	}
 
 	// Create Job
-	job, err := s.jobs.CreateJob(ctx, input.Name, input.Slug, input.Description, domain.JobKindTransform)
+	job, err := s.jobs.CreateJob(ctx, input.Name, input.Slug, input.Description, domain.JobKindTransform, "")
 	if err != nil {
 		return nil, fmt.Errorf("create job: %w", err)
 	}
@@ -278,12 +399,24 @@ func (s *TransformService) CreateTransformJob(ctx context.Context, input CreateT
 		Version:  nextVer,
 		Status:   domain.JobVersionStatusDraft,
 	}
+	if userID, ok := domain.UserIDFromContext(ctx); ok {
+		version.CreatedBy = &userID
+	}
 	if err := s.versions.Create(ctx, version); err != nil {
 		return nil, fmt.Errorf("create version: %w", err)
 	}
 
 	// Create Module with SQL config
-	configJSON := fmt.Sprintf(`{"sql":%q,"dataset_ids":%s}`, input.SQL, toJSONArray(input.DatasetIDs))
+	config, err := json.Marshal(transformModuleConfig{
+		SQL:             input.SQL,
+		DatasetIDs:      input.DatasetIDs,
+		DatasetVersions: datasetVersions,
+		Output:          input.Output,
+	})
+	if err != nil {
+		return nil, err
+	}
+	configJSON := string(config)
 	mod := &domain.JobModule{
 		ID:           uuid.New().String(),
 		TenantID:     tenantID,
@@ -335,12 +468,17 @@ func (s *TransformService) CreateTransformJob(ctx context.Context, input CreateT
 		// Enqueue for immediate execution
 		if execution == "immediate" && s.queue != nil {
 			msg := &domain.TransformJobMessage{
-				JobRunID:   jr.ID,
-				TenantID:   tenantID,
-				SQL:        input.SQL,
-				DatasetIDs: input.DatasetIDs,
-				JobID:      job.ID,
-				VersionID:  version.ID,
+				JobRunID:        jr.ID,
+				TenantID:        tenantID,
+				SQL:             input.SQL,
+				DatasetIDs:      input.DatasetIDs,
+				DatasetVersions: datasetVersions,
+				JobID:           job.ID,
+				VersionID:       version.ID,
+				Output:          input.Output,
+			}
+			if version.CreatedBy != nil {
+				msg.UserID = *version.CreatedBy
 			}
 			if err := s.queue.Enqueue(ctx, msg); err != nil {
 				return nil, fmt.Errorf("enqueue transform: %w", err)
@@ -351,8 +489,66 @@ func (s *TransformService) CreateTransformJob(ctx context.Context, input CreateT
 	return out, nil
 }
 
-func quoteIdentifier(name string) string {
-	return fmt.Sprintf(`"%s"`, name)
+// validateOutput checks that output names a known write mode with the
+// columns it needs among the transform's result columns, and a dataset
+// whose name is a plain identifier and that, if it exists, is a transform
+// output.
+func (s *TransformService) validateOutput(ctx context.Context, tenantID string, output *domain.TransformOutput, columns []ColumnInfo) error {
+	if output == nil {
+		return nil
+	}
+	if strings.TrimSpace(output.DatasetName) == "" {
+		return fmt.Errorf("%w: dataset_name is required", domain.ErrInvalidTransformOutput)
+	}
+	// Checked before the lookup, so that datasets named after uploaded
+	// files cannot be written by name either
+	if !outputNamePattern.MatchString(output.DatasetName) {
+		return fmt.Errorf("%w: dataset_name must be letters, digits and underscores, not starting with a digit", domain.ErrInvalidTransformOutput)
+	}
+	var required []string
+	switch output.Mode {
+	case domain.TransformOutputModeOverwrite, domain.TransformOutputModeAppend:
+	case domain.TransformOutputModeMerge:
+		if len(output.KeyColumns) == 0 {
+			return fmt.Errorf("%w: merge requires key_columns", domain.ErrInvalidTransformOutput)
+		}
+		required = output.KeyColumns
+	case domain.TransformOutputModeReplacePartitions:
+		if output.PartitionColumn == "" {
+			return fmt.Errorf("%w: replace_partitions requires partition_column", domain.ErrInvalidTransformOutput)
+		}
+		required = []string{output.PartitionColumn}
+	default:
+		return fmt.Errorf("%w: mode must be overwrite, append, merge or replace_partitions", domain.ErrInvalidTransformOutput)
+	}
+	if len(output.KeyColumns) > 0 && output.Mode != domain.TransformOutputModeMerge {
+		return fmt.Errorf("%w: key_columns is only used by merge", domain.ErrInvalidTransformOutput)
+	}
+	if output.PartitionColumn != "" && output.Mode != domain.TransformOutputModeReplacePartitions {
+		return fmt.Errorf("%w: partition_column is only used by replace_partitions", domain.ErrInvalidTransformOutput)
+	}
+
+	known := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		known[c.Name] = true
+	}
+	for _, c := range required {
+		if !known[c] {
+			return fmt.Errorf("%w: column %q is not in the transform's result", domain.ErrInvalidTransformOutput, c)
+		}
+	}
+
+	ds, err := s.datasets.FindByName(ctx, tenantID, output.DatasetName)
+	if errors.Is(err, domain.ErrDatasetNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("find dataset: %w", err)
+	}
+	if ds.SourceType != domain.SourceTypeTransform {
+		return fmt.Errorf("%w: dataset %q is not a transform output", domain.ErrInvalidTransformOutput, output.DatasetName)
+	}
+	return nil
 }
 
 func describeTable(ctx context.Context, db *sql.DB, tableName string) ([]ColumnInfo, error) {
@@ -373,18 +569,3 @@ func describeTable(ctx context.Context, db *sql.DB, tableName string) ([]ColumnI
 	}
 	return columns, rows.Err()
 }
-
-func toJSONArray(ss []string) string {
-	if len(ss) == 0 {
-		return "[]"
-	}
-	result := "["
-	for i, s := range ss {
-		if i > 0 {
-			result += ","
-		}
-		result += fmt.Sprintf("%q", s)
-	}
-	result += "]"
-	return result
-}
@@ -2,19 +2,28 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	_ "github.com/marcboeker/go-duckdb"
+
 	"github.com/google/uuid"
 	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/fileconv"
+	"github.com/user/micro-dp/internal/secret"
+	"github.com/user/micro-dp/storage"
 )
 
 const (
-	maxFileSize     = 100 * 1024 * 1024 // 100 MB
-	maxFilesPerReq  = 10
-	presignExpiry   = 15 * time.Minute
+	maxSinglePutSize = 100 * 1024 * 1024 // 100 MB; larger files use multipart uploads
+	maxFilesPerReq   = 10
+	presignExpiry    = 15 * time.Minute
 )
 
 var allowedExtensions = map[string]bool{
@@ -29,9 +38,11 @@ var allowedExtensions = map[string]bool{
 }
 
 type UploadFileInput struct {
-	Filename    string
-	ContentType string
-	SizeBytes   int64
+	Filename     string
+	ContentType  string
+	SizeBytes    int64
+	ParseOptions *fileconv.Options
+	Target       *domain.UploadTarget
 }
 
 type UploadPresignedFile struct {
@@ -47,14 +58,50 @@ type UploadPresignResult struct {
 	Files    []UploadPresignedFile
 }
 
+// CompleteUploadInput sets parse options when completing an upload.
+// Files overrides ParseOptions for individual files, keyed by file ID.
+type CompleteUploadInput struct {
+	ParseOptions *fileconv.Options
+	Files        map[string]*fileconv.Options
+}
+
 type UploadService struct {
 	uploads   domain.UploadRepository
+	sessions  domain.UploadSessionRepository
+	fetches   domain.UploadFetchRepository
+	datasets  domain.DatasetRepository
 	presigner domain.PresignedURLGenerator
+	multipart domain.MultipartUploader
 	queue     domain.UploadJobQueue
+	minio     *storage.MinIOClient
+	plans     *PlanService
+	keyring   *secret.Keyring
 }
 
-func NewUploadService(uploads domain.UploadRepository, presigner domain.PresignedURLGenerator, queue domain.UploadJobQueue) *UploadService {
-	return &UploadService{uploads: uploads, presigner: presigner, queue: queue}
+func NewUploadService(
+	uploads domain.UploadRepository,
+	sessions domain.UploadSessionRepository,
+	fetches domain.UploadFetchRepository,
+	datasets domain.DatasetRepository,
+	presigner domain.PresignedURLGenerator,
+	multipart domain.MultipartUploader,
+	queue domain.UploadJobQueue,
+	minio *storage.MinIOClient,
+	plans *PlanService,
+	keyring *secret.Keyring,
+) *UploadService {
+	return &UploadService{
+		uploads:   uploads,
+		sessions:  sessions,
+		fetches:   fetches,
+		datasets:  datasets,
+		presigner: presigner,
+		multipart: multipart,
+		queue:     queue,
+		minio:     minio,
+		plans:     plans,
+		keyring:   keyring,
+	}
 }
 
 func (s *UploadService) CreatePresign(ctx context.Context, files []UploadFileInput) (*UploadPresignResult, error) {
@@ -70,16 +117,16 @@ func (s *UploadService) CreatePresign(ctx context.Context, files []UploadFileInp
 		return nil, fmt.Errorf("too many files: max %d", maxFilesPerReq)
 	}
 
+	maxSize, err := s.plans.MaxUploadFileSize(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get file size limit: %w", err)
+	}
 	for _, f := range files {
-		if f.SizeBytes <= 0 {
-			return nil, fmt.Errorf("invalid size for file %q", f.Filename)
+		if err := s.validateFileInput(ctx, tenantID, f, maxSize); err != nil {
+			return nil, err
 		}
-		if f.SizeBytes > maxFileSize {
-			return nil, fmt.Errorf("file %q exceeds max size %d bytes", f.Filename, maxFileSize)
-		}
-		ext := strings.ToLower(filepath.Ext(f.Filename))
-		if !allowedExtensions[ext] {
-			return nil, fmt.Errorf("file extension %q is not allowed", ext)
+		if f.SizeBytes > maxSinglePutSize {
+			return nil, fmt.Errorf("%w: file %q is larger than %d bytes; use a multipart upload", domain.ErrUploadFileTooLarge, f.Filename, maxSinglePutSize)
 		}
 	}
 
@@ -97,33 +144,25 @@ func (s *UploadService) CreatePresign(ctx context.Context, files []UploadFileInp
 	result := &UploadPresignResult{UploadID: uploadID}
 
 	for _, f := range files {
-		fileID := uuid.New().String()
-		ext := strings.ToLower(filepath.Ext(f.Filename))
-		objectKey := fmt.Sprintf("uploads/%s/%s/%s%s", tenantID, datePart, fileID, ext)
+		uf, err := newUploadFile(tenantID, uploadID, datePart, f)
+		if err != nil {
+			return nil, err
+		}
 
-		presignedURL, expiresAt, err := s.presigner.GeneratePresignedPutURL(ctx, objectKey, f.ContentType, presignExpiry)
+		presignedURL, expiresAt, err := s.presigner.GeneratePresignedPutURL(ctx, uf.ObjectKey, f.ContentType, presignExpiry)
 		if err != nil {
 			return nil, fmt.Errorf("generate presigned url: %w", err)
 		}
 
-		uf := &domain.UploadFile{
-			ID:          fileID,
-			TenantID:    tenantID,
-			UploadID:    uploadID,
-			FileName:    f.Filename,
-			ObjectKey:   objectKey,
-			ContentType: f.ContentType,
-			SizeBytes:   f.SizeBytes,
-		}
 		if err := s.uploads.CreateUploadFile(ctx, uf); err != nil {
 			return nil, fmt.Errorf("create upload file: %w", err)
 		}
 
 		result.Files = append(result.Files, UploadPresignedFile{
-			FileID:       fileID,
+			FileID:       uf.ID,
 			Filename:     f.Filename,
 			PresignedURL: presignedURL,
-			ObjectKey:    objectKey,
+			ObjectKey:    uf.ObjectKey,
 			ExpiresAt:    expiresAt,
 		})
 	}
@@ -131,7 +170,60 @@ func (s *UploadService) CreatePresign(ctx context.Context, files []UploadFileInp
 	return result, nil
 }
 
-func (s *UploadService) Complete(ctx context.Context, uploadID string) (*domain.Upload, []domain.UploadFile, error) {
+// validateFileInput checks one file of an upload request against the
+// allowed extensions and the plan's file size limit (maxSize, -1 for none).
+func (s *UploadService) validateFileInput(ctx context.Context, tenantID string, f UploadFileInput, maxSize int64) error {
+	if f.SizeBytes <= 0 {
+		return fmt.Errorf("invalid size for file %q", f.Filename)
+	}
+	if maxSize >= 0 && f.SizeBytes > maxSize {
+		return fmt.Errorf("%w: file %q exceeds max size %d bytes", domain.ErrUploadFileTooLarge, f.Filename, maxSize)
+	}
+	ext := strings.ToLower(filepath.Ext(f.Filename))
+	if !allowedExtensions[ext] {
+		return fmt.Errorf("file extension %q is not allowed", ext)
+	}
+	if err := validateParseOptions(f.ParseOptions); err != nil {
+		return fmt.Errorf("file %q: %w", f.Filename, err)
+	}
+	if err := s.validateTarget(ctx, tenantID, f.Target); err != nil {
+		return fmt.Errorf("file %q: %w", f.Filename, err)
+	}
+	return nil
+}
+
+// newUploadFile builds a pending upload file with a new ID and object key.
+func newUploadFile(tenantID, uploadID, datePart string, f UploadFileInput) (*domain.UploadFile, error) {
+	fileID := uuid.New().String()
+	ext := strings.ToLower(filepath.Ext(f.Filename))
+
+	optionsJSON, err := marshalParseOptions(f.ParseOptions)
+	if err != nil {
+		return nil, err
+	}
+	targetJSON, err := marshalTarget(f.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.UploadFile{
+		ID:          fileID,
+		TenantID:    tenantID,
+		UploadID:    uploadID,
+		FileName:    f.Filename,
+		ObjectKey:   fmt.Sprintf("uploads/%s/%s/%s%s", tenantID, datePart, fileID, ext),
+		ContentType: f.ContentType,
+		SizeBytes:   f.SizeBytes,
+		Status:      domain.UploadFileStatusPending,
+
+		ParseOptionsJSON: optionsJSON,
+		TargetJSON:       targetJSON,
+	}, nil
+}
+
+// Complete marks an upload as uploaded and queues its files for
+// conversion, first storing any parse options in input.
+func (s *UploadService) Complete(ctx context.Context, uploadID string, input CompleteUploadInput) (*domain.Upload, []domain.UploadFile, error) {
 	tenantID, ok := domain.TenantIDFromContext(ctx)
 	if !ok {
 		return nil, nil, fmt.Errorf("tenant id not found in context")
@@ -146,9 +238,27 @@ func (s *UploadService) Complete(ctx context.Context, uploadID string) (*domain.
 		return nil, nil, domain.ErrUploadAlreadyComplete
 	}
 
+	// Files uploaded in parts must have had their multipart upload completed
+	sessions, err := s.sessions.ListByUploadID(ctx, tenantID, uploadID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find upload sessions: %w", err)
+	}
+	for _, us := range sessions {
+		if us.Status != domain.UploadSessionStatusCompleted {
+			return nil, nil, fmt.Errorf("%w: multipart upload of file %s is %s", domain.ErrUploadFileMissing, us.FileID, us.Status)
+		}
+	}
+
+	if err := s.applyParseOptions(ctx, tenantID, uploadID, input); err != nil {
+		return nil, nil, err
+	}
+
 	if err := s.uploads.UpdateStatus(ctx, tenantID, uploadID, domain.UploadStatusUploaded); err != nil {
 		return nil, nil, fmt.Errorf("update status: %w", err)
 	}
+	if err := s.uploads.UpdateFilesStatus(ctx, tenantID, uploadID, domain.UploadFileStatusQueued); err != nil {
+		return nil, nil, fmt.Errorf("update file status: %w", err)
+	}
 
 	upload, err = s.uploads.FindByID(ctx, tenantID, uploadID)
 	if err != nil {
@@ -160,7 +270,7 @@ func (s *UploadService) Complete(ctx context.Context, uploadID string) (*domain.
 		return nil, nil, fmt.Errorf("find files: %w", err)
 	}
 
-	// Enqueue upload job for CSV→Parquet conversion
+	// Enqueue upload job for Parquet conversion
 	jobMsg := &domain.UploadJobMessage{
 		UploadID: uploadID,
 		TenantID: tenantID,
@@ -173,6 +283,378 @@ func (s *UploadService) Complete(ctx context.Context, uploadID string) (*domain.
 	return upload, files, nil
 }
 
+// applyParseOptions validates and stores the parse options of a Complete
+// request.
+func (s *UploadService) applyParseOptions(ctx context.Context, tenantID, uploadID string, input CompleteUploadInput) error {
+	if input.ParseOptions == nil && len(input.Files) == 0 {
+		return nil
+	}
+	if err := validateParseOptions(input.ParseOptions); err != nil {
+		return err
+	}
+	for fileID, opts := range input.Files {
+		if err := validateParseOptions(opts); err != nil {
+			return fmt.Errorf("file %s: %w", fileID, err)
+		}
+	}
+
+	files, err := s.uploads.FindFilesByUploadID(ctx, tenantID, uploadID)
+	if err != nil {
+		return fmt.Errorf("find files: %w", err)
+	}
+	known := make(map[string]bool, len(files))
+	for _, f := range files {
+		known[f.ID] = true
+	}
+	for fileID := range input.Files {
+		if !known[fileID] {
+			return fmt.Errorf("%w: %s", domain.ErrUploadFileNotFound, fileID)
+		}
+	}
+
+	for _, f := range files {
+		opts, ok := input.Files[f.ID]
+		if !ok || opts == nil {
+			opts = input.ParseOptions
+		}
+		if opts == nil {
+			continue
+		}
+		optionsJSON, err := marshalParseOptions(opts)
+		if err != nil {
+			return err
+		}
+		if err := s.uploads.UpdateFileParseOptions(ctx, tenantID, f.ID, optionsJSON); err != nil {
+			return fmt.Errorf("update parse options: %w", err)
+		}
+	}
+	return nil
+}
+
+// Reimport converts one file of a completed upload again. opts and target
+// replace the file's stored parse options and target dataset when set.
+func (s *UploadService) Reimport(ctx context.Context, uploadID, fileID string, opts *fileconv.Options, target *domain.UploadTarget) (*domain.Upload, []domain.UploadFile, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	upload, err := s.uploads.FindByID(ctx, tenantID, uploadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if upload.Status != domain.UploadStatusUploaded {
+		return nil, nil, domain.ErrUploadNotComplete
+	}
+	file, err := s.uploads.FindFileByID(ctx, tenantID, uploadID, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if file.Status == domain.UploadFileStatusQueued || file.Status == domain.UploadFileStatusProcessing {
+		return nil, nil, domain.ErrUploadFileBusy
+	}
+
+	if opts != nil {
+		if err := validateParseOptions(opts); err != nil {
+			return nil, nil, err
+		}
+		optionsJSON, err := marshalParseOptions(opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := s.uploads.UpdateFileParseOptions(ctx, tenantID, fileID, optionsJSON); err != nil {
+			return nil, nil, fmt.Errorf("update parse options: %w", err)
+		}
+		file.ParseOptionsJSON = optionsJSON
+	}
+	if target != nil {
+		if err := s.validateTarget(ctx, tenantID, target); err != nil {
+			return nil, nil, err
+		}
+		targetJSON, err := marshalTarget(target)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := s.uploads.UpdateFileTarget(ctx, tenantID, fileID, targetJSON); err != nil {
+			return nil, nil, fmt.Errorf("update target: %w", err)
+		}
+		file.TargetJSON = targetJSON
+	}
+	if err := s.uploads.UpdateFileStatus(ctx, tenantID, fileID, domain.UploadFileStatusQueued); err != nil {
+		return nil, nil, fmt.Errorf("update file status: %w", err)
+	}
+
+	jobMsg := &domain.UploadJobMessage{
+		JobID:    uuid.New().String(),
+		UploadID: uploadID,
+		TenantID: tenantID,
+		Files:    toJobFiles([]domain.UploadFile{*file}),
+	}
+	if err := s.queue.Enqueue(ctx, jobMsg); err != nil {
+		return nil, nil, fmt.Errorf("enqueue upload job: %w", err)
+	}
+
+	files, err := s.uploads.FindFilesByUploadID(ctx, tenantID, uploadID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find files: %w", err)
+	}
+	return upload, files, nil
+}
+
+// UploadPreviewTable is the inferred schema and first rows of one dataset
+// an uploaded file would produce.
+type UploadPreviewTable struct {
+	DatasetName string
+	Columns     []ColumnInfo
+	Rows        []map[string]any
+}
+
+// Preview converts an uploaded file without writing any dataset and
+// returns what each of its datasets would look like. opts overrides the
+// file's stored parse options.
+func (s *UploadService) Preview(ctx context.Context, uploadID, fileID string, opts *fileconv.Options, limit int) ([]UploadPreviewTable, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	file, err := s.uploads.FindFileByID(ctx, tenantID, uploadID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if opts == nil && file.ParseOptionsJSON != nil {
+		if err := json.Unmarshal([]byte(*file.ParseOptionsJSON), &opts); err != nil {
+			return nil, fmt.Errorf("parse stored options: %w", err)
+		}
+	}
+	if err := validateParseOptions(opts); err != nil {
+		return nil, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	tmpDir, err := os.MkdirTemp("", "micro-dp-upload-preview-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inputPath := filepath.Join(tmpDir, "input"+strings.ToLower(filepath.Ext(file.FileName)))
+	if err := s.minio.DownloadToFile(timeoutCtx, file.ObjectKey, inputPath); err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, domain.ErrUploadFileMissing
+		}
+		return nil, fmt.Errorf("download upload: %w", err)
+	}
+
+	duckDB, err := sql.Open("duckdb", "")
+	if err != nil {
+		return nil, fmt.Errorf("open duckdb: %w", err)
+	}
+	defer duckDB.Close()
+
+	conv := fileconv.NewConverter(duckDB, tmpDir, opts)
+	if err := conv.Convert(timeoutCtx, inputPath, file.FileName, fileconv.DatasetBaseName(file.FileName)); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUploadConversion, err)
+	}
+
+	tables := conv.Tables()
+	result := make([]UploadPreviewTable, len(tables))
+	for i, t := range tables {
+		columns, rows, err := previewTable(timeoutCtx, duckDB, t.Name, limit)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", domain.ErrUploadConversion, t.DatasetName, err)
+		}
+		result[i] = UploadPreviewTable{DatasetName: t.DatasetName, Columns: columns, Rows: rows}
+	}
+	return result, nil
+}
+
+func previewTable(ctx context.Context, duckDB *sql.DB, table string, limit int) ([]ColumnInfo, []map[string]any, error) {
+	descRows, err := duckDB.QueryContext(ctx, fmt.Sprintf("DESCRIBE %s", table))
+	if err != nil {
+		return nil, nil, fmt.Errorf("describe: %w", err)
+	}
+	defer descRows.Close()
+
+	var columns []ColumnInfo
+	for descRows.Next() {
+		var name, typ string
+		var null, key, dflt, extra sql.NullString
+		if err := descRows.Scan(&name, &typ, &null, &key, &dflt, &extra); err != nil {
+			return nil, nil, fmt.Errorf("scan column: %w", err)
+		}
+		columns = append(columns, ColumnInfo{Name: name, Type: typ})
+	}
+	if err := descRows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("describe: %w", err)
+	}
+
+	rows, err := duckDB.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s LIMIT %d", table, limit))
+	if err != nil {
+		return nil, nil, fmt.Errorf("read rows: %w", err)
+	}
+	defer rows.Close()
+
+	colNames, err := rows.Columns()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get columns: %w", err)
+	}
+	var result []map[string]any
+	for rows.Next() {
+		values := make([]any, len(colNames))
+		valuePtrs := make([]any, len(colNames))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, nil, fmt.Errorf("scan row: %w", err)
+		}
+		row := make(map[string]any, len(colNames))
+		for i, name := range colNames {
+			row[name] = values[i]
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("iterate rows: %w", err)
+	}
+	return columns, result, nil
+}
+
+// validateTarget checks that target names a dataset of the tenant and a
+// known write mode, and that upsert key columns are dataset columns.
+func (s *UploadService) validateTarget(ctx context.Context, tenantID string, target *domain.UploadTarget) error {
+	if target == nil {
+		return nil
+	}
+	if target.DatasetID == "" {
+		return fmt.Errorf("%w: dataset_id is required", domain.ErrInvalidUploadTarget)
+	}
+	switch target.Mode {
+	case domain.UploadWriteModeReplace, domain.UploadWriteModeAppend:
+		if len(target.KeyColumns) > 0 {
+			return fmt.Errorf("%w: key_columns is only used by upsert", domain.ErrInvalidUploadTarget)
+		}
+	case domain.UploadWriteModeUpsert:
+		if len(target.KeyColumns) == 0 {
+			return fmt.Errorf("%w: upsert requires key_columns", domain.ErrInvalidUploadTarget)
+		}
+	default:
+		return fmt.Errorf("%w: mode must be replace, append or upsert", domain.ErrInvalidUploadTarget)
+	}
+
+	ds, err := s.datasets.FindByID(ctx, tenantID, target.DatasetID)
+	if err != nil {
+		if errors.Is(err, domain.ErrDatasetNotFound) {
+			return fmt.Errorf("%w: dataset %s not found", domain.ErrInvalidUploadTarget, target.DatasetID)
+		}
+		return fmt.Errorf("find dataset: %w", err)
+	}
+	cols, err := ds.ParseColumns()
+	if err != nil {
+		return fmt.Errorf("parse dataset schema: %w", err)
+	}
+	if len(cols) == 0 {
+		return fmt.Errorf("%w: dataset %s has no schema", domain.ErrInvalidUploadTarget, target.DatasetID)
+	}
+	known := make(map[string]bool, len(cols))
+	for _, c := range cols {
+		known[c.Name] = true
+	}
+	for _, k := range target.KeyColumns {
+		if !known[k] {
+			return fmt.Errorf("%w: key column %q is not in dataset %s", domain.ErrInvalidUploadTarget, k, target.DatasetID)
+		}
+	}
+	return nil
+}
+
+func marshalTarget(target *domain.UploadTarget) (*string, error) {
+	if target == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(target)
+	if err != nil {
+		return nil, fmt.Errorf("marshal target: %w", err)
+	}
+	targetJSON := string(data)
+	return &targetJSON, nil
+}
+
+func validateParseOptions(opts *fileconv.Options) error {
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidParseOptions, err)
+	}
+	return nil
+}
+
+func marshalParseOptions(opts *fileconv.Options) (*string, error) {
+	if opts == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("marshal parse options: %w", err)
+	}
+	optionsJSON := string(data)
+	return &optionsJSON, nil
+}
+
+// Get returns an upload with the processing state of its files.
+func (s *UploadService) Get(ctx context.Context, uploadID string) (*domain.Upload, []domain.UploadFile, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	upload, err := s.uploads.FindByID(ctx, tenantID, uploadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := s.uploads.FindFilesByUploadID(ctx, tenantID, uploadID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find files: %w", err)
+	}
+	return upload, files, nil
+}
+
+// UploadWithFiles is an upload and its files.
+type UploadWithFiles struct {
+	Upload domain.Upload
+	Files  []domain.UploadFile
+}
+
+// List returns the tenant's uploads, newest first.
+func (s *UploadService) List(ctx context.Context, filter domain.UploadListFilter) ([]UploadWithFiles, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	uploads, err := s.uploads.ListByTenant(ctx, tenantID, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]UploadWithFiles, len(uploads))
+	for i, u := range uploads {
+		files, err := s.uploads.FindFilesByUploadID(ctx, tenantID, u.ID)
+		if err != nil {
+			return nil, fmt.Errorf("find files: %w", err)
+		}
+		result[i] = UploadWithFiles{Upload: u, Files: files}
+	}
+	return result, nil
+}
+
 func toJobFiles(files []domain.UploadFile) []domain.UploadJobFile {
 	result := make([]domain.UploadJobFile, len(files))
 	for i, f := range files {
@@ -182,6 +664,16 @@ func toJobFiles(files []domain.UploadFile) []domain.UploadJobFile {
 			ObjectKey:   f.ObjectKey,
 			ContentType: f.ContentType,
 			SizeBytes:   f.SizeBytes,
+			Fetch:       needsFetch(f),
+		}
+		if f.ParseOptionsJSON != nil {
+			result[i].ParseOptions = json.RawMessage(*f.ParseOptionsJSON)
+		}
+		if f.TargetJSON != nil {
+			var target domain.UploadTarget
+			if err := json.Unmarshal([]byte(*f.TargetJSON), &target); err == nil {
+				result[i].Target = &target
+			}
 		}
 	}
 	return result
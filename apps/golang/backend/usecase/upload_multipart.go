@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+)
+
+// S3 multipart limits
+const (
+	defaultPartSize = 64 * 1024 * 1024       // 64 MB
+	minPartSize     = 5 * 1024 * 1024        // 5 MB; every part but the last
+	maxPartSize     = 5 * 1024 * 1024 * 1024 // 5 GB
+	maxUploadParts  = 10000
+	maxObjectSize   = 5 * 1024 * 1024 * 1024 * 1024 // 5 TB
+)
+
+// CreateMultipartUploadInput describes a file uploaded in parts.
+// PartSizeBytes zero picks a part size from the file size.
+type CreateMultipartUploadInput struct {
+	File          UploadFileInput
+	PartSizeBytes int64
+}
+
+// MultipartUpload is the state of a file's multipart upload. Parts lists
+// the parts stored so far while the session is active.
+type MultipartUpload struct {
+	File    domain.UploadFile
+	Session domain.UploadSession
+	Parts   []domain.UploadedPart
+}
+
+// UploadPartURL is a presigned URL for one part.
+type UploadPartURL struct {
+	PartNumber int
+	URL        string
+	ExpiresAt  time.Time
+}
+
+// CreateMultipart creates an upload of a single file that the client sends
+// in parts, and starts the S3 multipart upload for it.
+func (s *UploadService) CreateMultipart(ctx context.Context, input CreateMultipartUploadInput) (*MultipartUpload, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	maxSize, err := s.plans.MaxUploadFileSize(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get file size limit: %w", err)
+	}
+	if maxSize < 0 || maxSize > maxObjectSize {
+		maxSize = maxObjectSize
+	}
+	if err := s.validateFileInput(ctx, tenantID, input.File, maxSize); err != nil {
+		return nil, err
+	}
+	partSize, partCount, err := multipartLayout(input.File.SizeBytes, input.PartSizeBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadID := uuid.New().String()
+	upload := &domain.Upload{
+		ID:       uploadID,
+		TenantID: tenantID,
+		Status:   domain.UploadStatusPresigned,
+	}
+	if err := s.uploads.CreateUpload(ctx, upload); err != nil {
+		return nil, fmt.Errorf("create upload: %w", err)
+	}
+
+	uf, err := newUploadFile(tenantID, uploadID, time.Now().UTC().Format("2006-01-02"), input.File)
+	if err != nil {
+		return nil, err
+	}
+	s3UploadID, err := s.multipart.InitiateMultipartUpload(ctx, uf.ObjectKey, uf.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.uploads.CreateUploadFile(ctx, uf); err != nil {
+		return nil, fmt.Errorf("create upload file: %w", err)
+	}
+	session := &domain.UploadSession{
+		FileID:        uf.ID,
+		TenantID:      tenantID,
+		UploadID:      uploadID,
+		S3UploadID:    s3UploadID,
+		PartSizeBytes: partSize,
+		PartCount:     partCount,
+		Status:        domain.UploadSessionStatusActive,
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("create upload session: %w", err)
+	}
+
+	return s.GetMultipart(ctx, uploadID, uf.ID)
+}
+
+// GetMultipart returns a file's multipart upload with the parts stored so
+// far, from which a client resumes an interrupted upload.
+func (s *UploadService) GetMultipart(ctx context.Context, uploadID, fileID string) (*MultipartUpload, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	mu, err := s.findMultipart(ctx, tenantID, uploadID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if mu.Session.Status == domain.UploadSessionStatusActive {
+		if mu.Parts, err = s.listParts(ctx, mu); err != nil {
+			return nil, err
+		}
+	}
+	return mu, nil
+}
+
+// PresignParts returns presigned PUT URLs for the given part numbers.
+func (s *UploadService) PresignParts(ctx context.Context, uploadID, fileID string, partNumbers []int) ([]UploadPartURL, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	mu, err := s.findMultipart(ctx, tenantID, uploadID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if mu.Session.Status != domain.UploadSessionStatusActive {
+		return nil, domain.ErrUploadSessionNotActive
+	}
+	if len(partNumbers) == 0 {
+		return nil, fmt.Errorf("%w: at least one part number is required", domain.ErrInvalidUploadPart)
+	}
+	if len(partNumbers) > 1000 {
+		return nil, fmt.Errorf("%w: at most 1000 parts per request", domain.ErrInvalidUploadPart)
+	}
+
+	urls := make([]UploadPartURL, len(partNumbers))
+	for i, n := range partNumbers {
+		if n < 1 || n > mu.Session.PartCount {
+			return nil, fmt.Errorf("%w: part number %d is not between 1 and %d", domain.ErrInvalidUploadPart, n, mu.Session.PartCount)
+		}
+		url, expiresAt, err := s.multipart.PresignUploadPartURL(ctx, mu.File.ObjectKey, mu.Session.S3UploadID, n, presignExpiry)
+		if err != nil {
+			return nil, err
+		}
+		urls[i] = UploadPartURL{PartNumber: n, URL: url, ExpiresAt: expiresAt}
+	}
+	return urls, nil
+}
+
+// CompleteMultipart assembles the stored parts into the upload file's
+// object. Every part must be stored and their sizes must add up to the
+// declared file size.
+func (s *UploadService) CompleteMultipart(ctx context.Context, uploadID, fileID string) (*MultipartUpload, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	mu, err := s.findMultipart(ctx, tenantID, uploadID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if mu.Session.Status != domain.UploadSessionStatusActive {
+		return nil, domain.ErrUploadSessionNotActive
+	}
+
+	parts, err := s.listParts(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) != mu.Session.PartCount {
+		return nil, fmt.Errorf("%w: %d of %d parts uploaded", domain.ErrUploadPartsIncomplete, len(parts), mu.Session.PartCount)
+	}
+	var total int64
+	for i, p := range parts {
+		if p.PartNumber != i+1 {
+			return nil, fmt.Errorf("%w: part %d is missing", domain.ErrUploadPartsIncomplete, i+1)
+		}
+		total += p.SizeBytes
+	}
+	if total != mu.File.SizeBytes {
+		return nil, fmt.Errorf("%w: parts hold %d bytes, expected %d", domain.ErrUploadPartsIncomplete, total, mu.File.SizeBytes)
+	}
+
+	if err := s.multipart.CompleteMultipartUpload(ctx, mu.File.ObjectKey, mu.Session.S3UploadID, parts); err != nil {
+		return nil, err
+	}
+	if err := s.sessions.UpdateStatus(ctx, tenantID, fileID, domain.UploadSessionStatusCompleted); err != nil {
+		return nil, fmt.Errorf("update upload session: %w", err)
+	}
+	mu.Session.Status = domain.UploadSessionStatusCompleted
+	return mu, nil
+}
+
+// AbortMultipart discards a file's multipart upload and its stored parts.
+func (s *UploadService) AbortMultipart(ctx context.Context, uploadID, fileID string) error {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("tenant id not found in context")
+	}
+
+	mu, err := s.findMultipart(ctx, tenantID, uploadID, fileID)
+	if err != nil {
+		return err
+	}
+	if mu.Session.Status != domain.UploadSessionStatusActive {
+		return domain.ErrUploadSessionNotActive
+	}
+	if err := s.multipart.AbortMultipartUpload(ctx, mu.File.ObjectKey, mu.Session.S3UploadID); err != nil {
+		return err
+	}
+	if err := s.sessions.UpdateStatus(ctx, tenantID, fileID, domain.UploadSessionStatusAborted); err != nil {
+		return fmt.Errorf("update upload session: %w", err)
+	}
+	return nil
+}
+
+func (s *UploadService) findMultipart(ctx context.Context, tenantID, uploadID, fileID string) (*MultipartUpload, error) {
+	file, err := s.uploads.FindFileByID(ctx, tenantID, uploadID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	session, err := s.sessions.FindByFileID(ctx, tenantID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	return &MultipartUpload{File: *file, Session: *session}, nil
+}
+
+func (s *UploadService) listParts(ctx context.Context, mu *MultipartUpload) ([]domain.UploadedPart, error) {
+	parts, err := s.multipart.ListUploadedParts(ctx, mu.File.ObjectKey, mu.Session.S3UploadID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	return parts, nil
+}
+
+// multipartLayout returns the part size and part count of a file. Without
+// a requested part size, the default is doubled until the file fits in
+// the S3 part count limit.
+func multipartLayout(size, requested int64) (int64, int, error) {
+	partSize := requested
+	if partSize == 0 {
+		partSize = defaultPartSize
+		for partSize < maxPartSize && size > partSize*maxUploadParts {
+			partSize *= 2
+		}
+	}
+	if partSize < minPartSize || partSize > maxPartSize {
+		return 0, 0, fmt.Errorf("%w: part size must be between %d and %d bytes", domain.ErrInvalidUploadPart, int64(minPartSize), int64(maxPartSize))
+	}
+	partCount := (size + partSize - 1) / partSize
+	if partCount > maxUploadParts {
+		return 0, 0, fmt.Errorf("%w: a part size of %d bytes needs more than %d parts", domain.ErrInvalidUploadPart, partSize, maxUploadParts)
+	}
+	return partSize, int(partCount), nil
+}
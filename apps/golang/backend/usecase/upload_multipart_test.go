@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+)
+
+// fakeMultipart stores the parts of a single multipart upload.
+type fakeMultipart struct {
+	domain.MultipartUploader
+	parts     []domain.UploadedPart
+	completed []domain.UploadedPart
+}
+
+func (m *fakeMultipart) InitiateMultipartUpload(context.Context, string, string) (string, error) {
+	return "s3-upload", nil
+}
+
+func (m *fakeMultipart) ListUploadedParts(context.Context, string, string) ([]domain.UploadedPart, error) {
+	return append([]domain.UploadedPart(nil), m.parts...), nil
+}
+
+func (m *fakeMultipart) CompleteMultipartUpload(_ context.Context, _, _ string, parts []domain.UploadedPart) error {
+	m.completed = parts
+	return nil
+}
+
+// findSession returns the stored session of file f1.
+func findSession(t *testing.T, sessions *db.UploadSessionRepo) *domain.UploadSession {
+	t.Helper()
+	s, err := sessions.FindByFileID(context.Background(), "t1", "f1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+const mb = 1024 * 1024
+
+func TestMultipartLayout(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int64
+		requested int64
+		wantSize  int64
+		wantCount int
+		wantErr   bool
+	}{
+		{name: "smaller than a part", size: 1, wantSize: 64 * mb, wantCount: 1},
+		{name: "exact parts", size: 128 * mb, wantSize: 64 * mb, wantCount: 2},
+		{name: "last part short", size: 128*mb + 1, wantSize: 64 * mb, wantCount: 3},
+		{name: "default doubled to fit part limit", size: 64 * mb * maxUploadParts * 3, wantSize: 256 * mb, wantCount: 7500},
+		{name: "requested", size: 12 * mb, requested: 5 * mb, wantSize: 5 * mb, wantCount: 3},
+		{name: "requested below minimum", size: 12 * mb, requested: 5*mb - 1, wantErr: true},
+		{name: "requested above maximum", size: 12 * mb, requested: maxPartSize + 1, wantErr: true},
+		{name: "requested needs too many parts", size: 5*mb*maxUploadParts + 1, requested: 5 * mb, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, count, err := multipartLayout(tt.size, tt.requested)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrInvalidUploadPart) {
+					t.Fatalf("multipartLayout = %d, %d, %v, want %v", size, count, err, domain.ErrInvalidUploadPart)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != tt.wantSize || count != tt.wantCount {
+				t.Errorf("multipartLayout = %d bytes x %d, want %d x %d", size, count, tt.wantSize, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestCreateMultipartFileSizeLimit(t *testing.T) {
+	t.Setenv("EDITION", "web")
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	tenantPlans := db.NewTenantPlanRepo(sqlDB)
+	if err := tenantPlans.Upsert(context.Background(), &domain.TenantPlan{ID: "tp1", TenantID: "t1", PlanID: "plan-free-default", StartedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	plans := NewPlanService(db.NewPlanRepo(sqlDB), tenantPlans, nil)
+	ctx := tenantContext("t1", "u1", domain.TenantRoleMember)
+
+	uploads, multipart := db.NewUploadRepo(sqlDB), &fakeMultipart{}
+	svc := NewUploadService(uploads, db.NewUploadSessionRepo(sqlDB), nil, nil, nil, multipart, nil, nil, plans, nil)
+
+	_, err := svc.CreateMultipart(ctx, CreateMultipartUploadInput{File: UploadFileInput{Filename: "big.csv", ContentType: "text/csv", SizeBytes: 100*mb + 1}})
+	if !errors.Is(err, domain.ErrUploadFileTooLarge) {
+		t.Fatalf("CreateMultipart over the plan limit = %v, want %v", err, domain.ErrUploadFileTooLarge)
+	}
+	if list, err := uploads.ListByTenant(ctx, "t1", domain.UploadListFilter{Limit: 10}); err != nil || len(list) != 0 {
+		t.Errorf("rejected upload was stored: %d uploads, %v", len(list), err)
+	}
+
+	mu, err := svc.CreateMultipart(ctx, CreateMultipartUploadInput{File: UploadFileInput{Filename: "big.csv", ContentType: "text/csv", SizeBytes: 100 * mb}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mu.Session.Status != domain.UploadSessionStatusActive || mu.Session.PartSizeBytes != 64*mb || mu.Session.PartCount != 2 || mu.Session.S3UploadID != "s3-upload" {
+		t.Errorf("session = %+v, want active with 2 parts of 64 MB", mu.Session)
+	}
+	if mu.File.SizeBytes != 100*mb || mu.File.Status != domain.UploadFileStatusPending {
+		t.Errorf("file = %d bytes %s, want 100 MB pending", mu.File.SizeBytes, mu.File.Status)
+	}
+}
+
+func TestCompleteMultipart(t *testing.T) {
+	part := func(n int, size int64) domain.UploadedPart {
+		return domain.UploadedPart{PartNumber: n, ETag: "etag", SizeBytes: size}
+	}
+	tests := []struct {
+		name    string
+		status  string
+		parts   []domain.UploadedPart
+		wantErr error
+	}{
+		{name: "complete", parts: []domain.UploadedPart{part(3, 2*mb), part(1, 5*mb), part(2, 5*mb)}},
+		{name: "part missing", parts: []domain.UploadedPart{part(1, 5*mb), part(3, 2*mb)}, wantErr: domain.ErrUploadPartsIncomplete},
+		{name: "part numbers out of range", parts: []domain.UploadedPart{part(1, 5*mb), part(2, 5*mb), part(4, 2*mb)}, wantErr: domain.ErrUploadPartsIncomplete},
+		{name: "short part", parts: []domain.UploadedPart{part(1, 5*mb), part(2, 5*mb), part(3, 2*mb-1)}, wantErr: domain.ErrUploadPartsIncomplete},
+		{name: "oversized part", parts: []domain.UploadedPart{part(1, 5*mb), part(2, 6*mb), part(3, 2*mb)}, wantErr: domain.ErrUploadPartsIncomplete},
+		{name: "aborted", status: domain.UploadSessionStatusAborted, parts: []domain.UploadedPart{part(1, 5*mb), part(2, 5*mb), part(3, 2*mb)}, wantErr: domain.ErrUploadSessionNotActive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			if status == "" {
+				status = domain.UploadSessionStatusActive
+			}
+			sqlDB := openTestDB(t)
+			createTestTenant(t, sqlDB, "t1")
+			uploads, sessions := db.NewUploadRepo(sqlDB), db.NewUploadSessionRepo(sqlDB)
+			createTestUpload(t, uploads, domain.UploadStatusPresigned,
+				domain.UploadFile{ID: "f1", FileName: "big.csv", SizeBytes: 12 * mb, Status: domain.UploadFileStatusPending},
+			)
+			if err := sessions.Create(context.Background(), &domain.UploadSession{
+				FileID: "f1", TenantID: "t1", UploadID: "u1", S3UploadID: "s3-upload",
+				PartSizeBytes: 5 * mb, PartCount: 3, Status: status,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			multipart := &fakeMultipart{parts: tt.parts}
+			svc := NewUploadService(uploads, sessions, nil, nil, nil, multipart, &fakeUploadQueue{}, nil, nil, nil)
+			ctx := tenantContext("t1", "u1", domain.TenantRoleMember)
+
+			mu, err := svc.CompleteMultipart(ctx, "u1", "f1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CompleteMultipart = %v, want %v", err, tt.wantErr)
+				}
+				if session := findSession(t, sessions); multipart.completed != nil || session.Status != status {
+					t.Errorf("failed completion changed the upload: session %s", session.Status)
+				}
+				// The upload cannot be completed while a file is still in parts
+				if _, _, err := svc.Complete(ctx, "u1", CompleteUploadInput{}); !errors.Is(err, domain.ErrUploadFileMissing) {
+					t.Errorf("Complete = %v, want %v", err, domain.ErrUploadFileMissing)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if session := findSession(t, sessions); mu.Session.Status != domain.UploadSessionStatusCompleted || session.Status != domain.UploadSessionStatusCompleted {
+				t.Errorf("session = %s, want completed", session.Status)
+			}
+			for i, p := range multipart.completed {
+				if p.PartNumber != i+1 {
+					t.Errorf("completed parts = %+v, want them in order", multipart.completed)
+					break
+				}
+			}
+			if _, err := svc.CompleteMultipart(ctx, "u1", "f1"); !errors.Is(err, domain.ErrUploadSessionNotActive) {
+				t.Errorf("second CompleteMultipart = %v, want %v", err, domain.ErrUploadSessionNotActive)
+			}
+		})
+	}
+}
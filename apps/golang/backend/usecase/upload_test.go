@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/fileconv"
+)
+
+type fakeUploadQueue struct {
+	domain.UploadJobQueue
+	enqueued []*domain.UploadJobMessage
+}
+
+func (q *fakeUploadQueue) Enqueue(_ context.Context, msg *domain.UploadJobMessage) error {
+	q.enqueued = append(q.enqueued, msg)
+	return nil
+}
+
+func TestUploadReimportStatus(t *testing.T) {
+	errMsg := "bad delimiter"
+	tests := []struct {
+		status  string
+		wantErr error
+	}{
+		{status: domain.UploadFileStatusQueued, wantErr: domain.ErrUploadFileBusy},
+		{status: domain.UploadFileStatusProcessing, wantErr: domain.ErrUploadFileBusy},
+		{status: domain.UploadFileStatusFailed},
+		{status: domain.UploadFileStatusSkipped},
+		{status: domain.UploadFileStatusConverted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			sqlDB := openTestDB(t)
+			createTestTenant(t, sqlDB, "t1")
+			uploads := db.NewUploadRepo(sqlDB)
+			createTestUpload(t, uploads, domain.UploadStatusUploaded,
+				domain.UploadFile{ID: "f1", FileName: "orders.csv", Status: tt.status, ErrorMessage: &errMsg},
+				domain.UploadFile{ID: "f2", FileName: "items.csv", Status: domain.UploadFileStatusConverted},
+			)
+			queue := &fakeUploadQueue{}
+			svc := NewUploadService(uploads, nil, nil, nil, nil, nil, queue, nil, nil, nil)
+
+			ctx := tenantContext("t1", "u1", domain.TenantRoleMember)
+			_, files, err := svc.Reimport(ctx, "u1", "f1", &fileconv.Options{Delimiter: ";"}, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Reimport = %v, want %v", err, tt.wantErr)
+				}
+				f, err := uploads.FindFileByID(ctx, "t1", "u1", "f1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if f.Status != tt.status || f.ParseOptionsJSON != nil || len(queue.enqueued) != 0 {
+					t.Errorf("busy file was changed: status %s, options %v, %d jobs", f.Status, f.ParseOptionsJSON, len(queue.enqueued))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			byID := map[string]domain.UploadFile{}
+			for _, f := range files {
+				byID[f.ID] = f
+			}
+			if f := byID["f1"]; f.Status != domain.UploadFileStatusQueued || f.ErrorMessage != nil || f.ParseOptionsJSON == nil {
+				t.Errorf("file = %s %v, options %v, want queued with the error cleared and the new options", f.Status, f.ErrorMessage, f.ParseOptionsJSON)
+			}
+			if f := byID["f2"]; f.Status != domain.UploadFileStatusConverted {
+				t.Errorf("other file = %s, want converted", f.Status)
+			}
+			if len(queue.enqueued) != 1 {
+				t.Fatalf("enqueued %d jobs, want 1", len(queue.enqueued))
+			}
+			msg := queue.enqueued[0]
+			if msg.JobID == "" || msg.JobID == msg.UploadID {
+				t.Errorf("job id = %q, want a new id so the job is not dropped as a duplicate", msg.JobID)
+			}
+			if len(msg.Files) != 1 || msg.Files[0].FileID != "f1" || string(msg.Files[0].ParseOptions) == "" {
+				t.Errorf("job files = %+v, want f1 with its new parse options", msg.Files)
+			}
+		})
+	}
+}
+
+func TestUploadReimportNotComplete(t *testing.T) {
+	sqlDB := openTestDB(t)
+	createTestTenant(t, sqlDB, "t1")
+	uploads := db.NewUploadRepo(sqlDB)
+	createTestUpload(t, uploads, domain.UploadStatusPresigned,
+		domain.UploadFile{ID: "f1", FileName: "orders.csv", Status: domain.UploadFileStatusFailed},
+	)
+	svc := NewUploadService(uploads, nil, nil, nil, nil, nil, &fakeUploadQueue{}, nil, nil, nil)
+	_, _, err := svc.Reimport(tenantContext("t1", "u1", domain.TenantRoleMember), "u1", "f1", nil, nil)
+	if !errors.Is(err, domain.ErrUploadNotComplete) {
+		t.Errorf("Reimport = %v, want %v", err, domain.ErrUploadNotComplete)
+	}
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/fileconv"
+	"github.com/user/micro-dp/internal/urlfetch"
+)
+
+// CreateURLUploadInput describes a file the worker downloads from a URL.
+// Filename defaults to the last segment of the URL path; without a known
+// extension the format is sniffed from the downloaded file.
+type CreateURLUploadInput struct {
+	URL          string
+	Filename     string
+	Auth         *urlfetch.Auth
+	Checksum     string // "<algorithm>:<hex digest>", optional
+	ParseOptions *fileconv.Options
+	Target       *domain.UploadTarget
+}
+
+// CreateFromURL records a URL import and queues it. The worker fetches the
+// file into object storage, then converts it like an uploaded file.
+func (s *UploadService) CreateFromURL(ctx context.Context, input CreateURLUploadInput) (*domain.Upload, []domain.UploadFile, error) {
+	tenantID, ok := domain.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, nil, fmt.Errorf("tenant id not found in context")
+	}
+
+	u, err := urlfetch.ValidateURL(input.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", domain.ErrInvalidUploadURL, err)
+	}
+	if err := input.Auth.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", domain.ErrInvalidUploadURL, err)
+	}
+	var checksum *string
+	if input.Checksum != "" {
+		c, err := urlfetch.ParseChecksum(input.Checksum)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", domain.ErrInvalidUploadURL, err)
+		}
+		v := c.String()
+		checksum = &v
+	}
+
+	filename := input.Filename
+	ext := strings.ToLower(filepath.Ext(filename))
+	if filename == "" {
+		filename = path.Base(u.Path)
+		if filename == "." || filename == "/" {
+			filename = "download"
+		}
+		ext = strings.ToLower(filepath.Ext(filename))
+		if !allowedExtensions[ext] {
+			ext = "" // sniffed by the worker
+		}
+	} else if !allowedExtensions[ext] {
+		return nil, nil, fmt.Errorf("file extension %q is not allowed", ext)
+	}
+	if err := validateParseOptions(input.ParseOptions); err != nil {
+		return nil, nil, err
+	}
+	if err := s.validateTarget(ctx, tenantID, input.Target); err != nil {
+		return nil, nil, err
+	}
+	maxSize, err := s.plans.MaxUploadFileSize(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get file size limit: %w", err)
+	}
+
+	fetch := &domain.UploadFetch{
+		TenantID:     tenantID,
+		AuthType:     domain.UploadFetchAuthNone,
+		Checksum:     checksum,
+		MaxSizeBytes: maxSize,
+	}
+	if input.Auth != nil {
+		env, err := s.keyring.NewEnvelope()
+		if err != nil {
+			return nil, nil, err
+		}
+		raw, err := json.Marshal(input.Auth)
+		if err != nil {
+			return nil, nil, err
+		}
+		if fetch.AuthSecret, err = env.Seal(string(raw)); err != nil {
+			return nil, nil, err
+		}
+		fetch.AuthType = input.Auth.Type
+		fetch.DataKey = env.WrappedKey
+	}
+
+	uploadID := uuid.New().String()
+	upload := &domain.Upload{
+		ID:       uploadID,
+		TenantID: tenantID,
+		Status:   domain.UploadStatusUploaded,
+	}
+	if err := s.uploads.CreateUpload(ctx, upload); err != nil {
+		return nil, nil, fmt.Errorf("create upload: %w", err)
+	}
+
+	uf, err := newUploadFile(tenantID, uploadID, time.Now().UTC().Format("2006-01-02"), UploadFileInput{
+		Filename:     filename,
+		ParseOptions: input.ParseOptions,
+		Target:       input.Target,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	uf.ObjectKey = strings.TrimSuffix(uf.ObjectKey, filepath.Ext(uf.ObjectKey)) + ext
+	sourceURL := u.String()
+	uf.SourceURL = &sourceURL
+	uf.Status = domain.UploadFileStatusQueued
+	if err := s.uploads.CreateUploadFile(ctx, uf); err != nil {
+		return nil, nil, fmt.Errorf("create upload file: %w", err)
+	}
+	fetch.FileID = uf.ID
+	fetch.UploadID = uploadID
+	if err := s.fetches.Create(ctx, fetch); err != nil {
+		return nil, nil, fmt.Errorf("create upload fetch: %w", err)
+	}
+
+	jobMsg := &domain.UploadJobMessage{
+		UploadID: uploadID,
+		TenantID: tenantID,
+		Files:    toJobFiles([]domain.UploadFile{*uf}),
+	}
+	if err := s.queue.Enqueue(ctx, jobMsg); err != nil {
+		return nil, nil, fmt.Errorf("enqueue upload job: %w", err)
+	}
+
+	return s.Get(ctx, uploadID)
+}
+
+// needsFetch reports whether a URL-imported file has yet to be downloaded.
+func needsFetch(f domain.UploadFile) bool {
+	return f.SourceURL != nil && f.SizeBytes == 0
+}
@@ -22,22 +22,11 @@ func NewWriteKeyService(repo domain.WriteKeyRepository, tenants domain.TenantRep
 }
 
 func (s *WriteKeyService) checkOwnerOrAdmin(ctx context.Context) error {
-	userID, ok := domain.UserIDFromContext(ctx)
-	if !ok {
-		return fmt.Errorf("user id not found in context")
-	}
 	tenantID, ok := domain.TenantIDFromContext(ctx)
 	if !ok {
 		return fmt.Errorf("tenant id not found in context")
 	}
-	role, err := s.tenants.GetUserRole(ctx, userID, tenantID)
-	if err != nil {
-		return err
-	}
-	if role != domain.TenantRoleOwner && role != domain.TenantRoleAdmin {
-		return domain.ErrInsufficientRole
-	}
-	return nil
+	return checkOwnerOrAdmin(ctx, s.tenants, tenantID)
 }
 
 // Generate creates a new write key and returns (WriteKey, rawKey, error).
@@ -4,25 +4,34 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"time"
 
 	_ "github.com/marcboeker/go-duckdb"
 
+	"github.com/user/micro-dp/domain"
 	"github.com/user/micro-dp/storage"
 )
 
 type AggregationWriter struct {
-	minio *storage.MinIOClient
+	minio   *storage.MinIOClient
+	tracker *trackerDatasetRegistrar
 }
 
-func NewAggregationWriter(minio *storage.MinIOClient) *AggregationWriter {
-	return &AggregationWriter{minio: minio}
+func NewAggregationWriter(minio *storage.MinIOClient, datasets domain.DatasetRepository) *AggregationWriter {
+	return &AggregationWriter{
+		minio:   minio,
+		tracker: newTrackerDatasetRegistrar(datasets, minio),
+	}
 }
 
 // AggregateEvents reads raw event parquet files for a tenant+date and writes
-// aggregated events and visits parquet files.
+// aggregated events and visits parquet files. Each replaces the files a
+// previous aggregation of the date wrote, and the tracker datasets over
+// them are registered.
 func (w *AggregationWriter) AggregateEvents(ctx context.Context, tenantID, datePart string) error {
 	// List raw parquet files for this tenant+date
 	prefix := fmt.Sprintf("events/%s/dt=%s/", tenantID, datePart)
@@ -72,9 +81,8 @@ func (w *AggregationWriter) AggregateEvents(ctx context.Context, tenantID, dateP
 	_, _ = ddb.ExecContext(ctx, `ALTER TABLE raw_events ADD COLUMN session_id VARCHAR DEFAULT ''`)
 
 	// Aggregate events: count by event_name per hour
-	eventsPath := filepath.Join(tmpDir, "events.parquet")
-	_, err = ddb.ExecContext(ctx, fmt.Sprintf(`
-		COPY (
+	_, err = ddb.ExecContext(ctx, `
+		CREATE TABLE agg_events AS
 			SELECT
 				tenant_id,
 				event_name,
@@ -82,16 +90,18 @@ func (w *AggregationWriter) AggregateEvents(ctx context.Context, tenantID, dateP
 				count(*) AS event_count
 			FROM raw_events
 			GROUP BY tenant_id, event_name, date_trunc('hour', event_time)
-			ORDER BY hour, event_name
-		) TO '%s' (FORMAT PARQUET)`, eventsPath))
+			ORDER BY hour, event_name`)
 	if err != nil {
 		return fmt.Errorf("aggregate events: %w", err)
 	}
+	eventsPath := filepath.Join(tmpDir, "events.parquet")
+	if _, err := ddb.ExecContext(ctx, fmt.Sprintf(`COPY agg_events TO '%s' (FORMAT PARQUET)`, eventsPath)); err != nil {
+		return fmt.Errorf("write events parquet: %w", err)
+	}
 
 	// Aggregate visits: session-based with legacy fallback
-	visitsPath := filepath.Join(tmpDir, "visits.parquet")
-	_, err = ddb.ExecContext(ctx, fmt.Sprintf(`
-		COPY (
+	_, err = ddb.ExecContext(ctx, `
+		CREATE TABLE agg_visits AS
 			SELECT * FROM (
 				-- Pass 1: session_id present (new SDK data)
 				SELECT
@@ -129,7 +139,7 @@ func (w *AggregationWriter) AggregateEvents(ctx context.Context, tenantID, dateP
 
 				-- Pass 2: no session_id (legacy data) — hourly pseudo-sessions
 				SELECT
-					concat('legacy-', tenant_id, '-', strftime(date_trunc('hour', event_time), '%%Y%%m%%dT%%H')) AS session_id,
+					concat('legacy-', tenant_id, '-', strftime(date_trunc('hour', event_time), '%Y%m%dT%H')) AS session_id,
 					tenant_id,
 					min(event_time) AS session_start,
 					max(event_time) AS session_end,
@@ -159,11 +169,14 @@ func (w *AggregationWriter) AggregateEvents(ctx context.Context, tenantID, dateP
 				WHERE session_id IS NULL OR session_id = ''
 				GROUP BY tenant_id, date_trunc('hour', event_time)
 			)
-			ORDER BY session_start
-		) TO '%s' (FORMAT PARQUET)`, visitsPath))
+			ORDER BY session_start`)
 	if err != nil {
 		return fmt.Errorf("aggregate visits: %w", err)
 	}
+	visitsPath := filepath.Join(tmpDir, "visits.parquet")
+	if _, err := ddb.ExecContext(ctx, fmt.Sprintf(`COPY agg_visits TO '%s' (FORMAT PARQUET)`, visitsPath)); err != nil {
+		return fmt.Errorf("write visits parquet: %w", err)
+	}
 
 	// Upload aggregated files
 	now := time.Now().UTC()
@@ -173,8 +186,9 @@ func (w *AggregationWriter) AggregateEvents(ctx context.Context, tenantID, dateP
 	if err != nil {
 		return fmt.Errorf("read events parquet: %w", err)
 	}
-	eventsKey := fmt.Sprintf("aggregated/events/%s/dt=%s/%s.parquet", tenantID, datePart, timestamp)
-	if err := w.minio.PutParquet(ctx, eventsKey, eventsData); err != nil {
+	eventsRoot := fmt.Sprintf("aggregated/events/%s", tenantID)
+	eventsKey := fmt.Sprintf("%s/dt=%s/%s.parquet", eventsRoot, datePart, timestamp)
+	if err := replacePartition(ctx, w.minio, eventsKey, eventsData); err != nil {
 		return fmt.Errorf("upload events parquet: %w", err)
 	}
 
@@ -182,10 +196,55 @@ func (w *AggregationWriter) AggregateEvents(ctx context.Context, tenantID, dateP
 	if err != nil {
 		return fmt.Errorf("read visits parquet: %w", err)
 	}
-	visitsKey := fmt.Sprintf("aggregated/visits/%s/dt=%s/%s.parquet", tenantID, datePart, timestamp)
-	if err := w.minio.PutParquet(ctx, visitsKey, visitsData); err != nil {
+	visitsRoot := fmt.Sprintf("aggregated/visits/%s", tenantID)
+	visitsKey := fmt.Sprintf("%s/dt=%s/%s.parquet", visitsRoot, datePart, timestamp)
+	if err := replacePartition(ctx, w.minio, visitsKey, visitsData); err != nil {
 		return fmt.Errorf("upload visits parquet: %w", err)
 	}
 
+	// The aggregates are stored, so a failed registration is only logged
+	// and caught up by the next aggregation
+	if err := storage.ConfigureDuckDBHTTPFS(ctx, ddb, w.minio.S3Config()); err != nil {
+		log.Printf("aggregation: tenant=%s: configure httpfs: %v", tenantID, err)
+		return nil
+	}
+	for _, t := range []struct{ name, root string }{
+		{trackerEventCountsDataset, eventsRoot},
+		{trackerVisitsDataset, visitsRoot},
+	} {
+		if err := w.tracker.register(ctx, ddb, tenantID, t.name, t.root); err != nil {
+			log.Printf("aggregation: tenant=%s: %v", tenantID, err)
+		}
+	}
+
+	return nil
+}
+
+// partitionObjects is the part of the bucket replacePartition writes
+// through.
+type partitionObjects interface {
+	PutParquet(ctx context.Context, objectKey string, data []byte) error
+	ListObjectKeys(ctx context.Context, prefix string) ([]string, error)
+	RemoveObject(ctx context.Context, objectKey string) error
+}
+
+// replacePartition uploads the object key and removes the other objects of
+// its dt= partition, so that re-aggregating a date does not repeat its rows.
+func replacePartition(ctx context.Context, objects partitionObjects, key string, data []byte) error {
+	if err := objects.PutParquet(ctx, key, data); err != nil {
+		return err
+	}
+	stale, err := objects.ListObjectKeys(ctx, path.Dir(key)+"/")
+	if err != nil {
+		return fmt.Errorf("list partition: %w", err)
+	}
+	for _, k := range stale {
+		if k == key {
+			continue
+		}
+		if err := objects.RemoveObject(ctx, k); err != nil {
+			return fmt.Errorf("remove %s: %w", k, err)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/user/micro-dp/usecase"
+)
+
+// ConnectionHealthMonitor periodically runs the connection testers of all
+// tenants and records the results.
+type ConnectionHealthMonitor struct {
+	health   *usecase.ConnectionHealthService
+	interval time.Duration
+}
+
+func NewConnectionHealthMonitor(health *usecase.ConnectionHealthService, interval time.Duration) *ConnectionHealthMonitor {
+	return &ConnectionHealthMonitor{health: health, interval: interval}
+}
+
+func (m *ConnectionHealthMonitor) Run(ctx context.Context) {
+	if m.interval <= 0 {
+		log.Println("connection_health_monitor disabled")
+		return
+	}
+	log.Printf("connection_health_monitor started interval=%s", m.interval)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("connection_health_monitor stopped")
+			return
+		case <-ticker.C:
+			m.health.CheckAll(ctx)
+		}
+	}
+}
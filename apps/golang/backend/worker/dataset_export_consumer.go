@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/usecase"
+)
+
+// DatasetExportConsumer writes queued dataset exports to object storage.
+type DatasetExportConsumer struct {
+	queue   domain.DatasetExportQueue
+	exports *usecase.DatasetExportService
+}
+
+func NewDatasetExportConsumer(queue domain.DatasetExportQueue, exports *usecase.DatasetExportService) *DatasetExportConsumer {
+	return &DatasetExportConsumer{queue: queue, exports: exports}
+}
+
+func (c *DatasetExportConsumer) Run(ctx context.Context) {
+	log.Println("dataset export consumer started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("dataset export consumer stopped")
+			return
+		default:
+			msg, err := c.queue.Dequeue(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					log.Println("dataset export consumer stopped")
+					return
+				}
+				log.Printf("dataset export dequeue error: %v", err)
+				continue
+			}
+			if msg == nil {
+				continue
+			}
+
+			c.processMessage(ctx, msg)
+		}
+	}
+}
+
+func (c *DatasetExportConsumer) processMessage(ctx context.Context, msg *domain.DatasetExportMessage) {
+	if err := c.queue.MarkProcessed(ctx, msg.ExportID); err != nil {
+		if errors.Is(err, domain.ErrDatasetExportAlreadyProcessed) {
+			log.Printf("dataset export: skipping duplicate export_id=%s", msg.ExportID)
+			return
+		}
+		log.Printf("dataset export: mark processed error export_id=%s: %v", msg.ExportID, err)
+		c.enqueueDLQ(ctx, msg, err.Error())
+		return
+	}
+
+	if err := c.exports.Run(ctx, msg.TenantID, msg.ExportID); err != nil {
+		log.Printf("dataset export: export_id=%s error: %v", msg.ExportID, err)
+		c.enqueueDLQ(ctx, msg, err.Error())
+		return
+	}
+	log.Printf("dataset export: completed export_id=%s", msg.ExportID)
+}
+
+func (c *DatasetExportConsumer) enqueueDLQ(ctx context.Context, msg *domain.DatasetExportMessage, reason string) {
+	if err := c.queue.EnqueueDLQ(ctx, msg, reason); err != nil {
+		log.Printf("dataset export: DLQ enqueue error export_id=%s: %v", msg.ExportID, err)
+	}
+}
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/user/micro-dp/usecase"
+)
+
+// DatasetPurger periodically purges soft-deleted datasets whose restore
+// window has passed, and the catalog usage events past the usage window.
+type DatasetPurger struct {
+	deletions *usecase.DatasetDeletionService
+	catalog   *usecase.DataCatalogService
+	interval  time.Duration
+}
+
+func NewDatasetPurger(deletions *usecase.DatasetDeletionService, catalog *usecase.DataCatalogService, interval time.Duration) *DatasetPurger {
+	return &DatasetPurger{deletions: deletions, catalog: catalog, interval: interval}
+}
+
+func (p *DatasetPurger) Run(ctx context.Context) {
+	if p.interval <= 0 {
+		log.Println("dataset_purger disabled")
+		return
+	}
+	log.Printf("dataset_purger started interval=%s", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("dataset_purger stopped")
+			return
+		case <-ticker.C:
+			p.deletions.PurgeExpired(ctx)
+			p.catalog.PruneUsage(ctx)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/storage"
+	"github.com/user/micro-dp/usecase"
+)
+
+// datasetVersionRecorder appends the state a write left a dataset in to
+// the dataset's version history, then checks its data quality rules.
+type datasetVersionRecorder struct {
+	datasets domain.DatasetRepository
+	versions domain.DatasetVersionRepository
+	minio    *storage.MinIOClient
+	quality  *usecase.DataQualityService // nil skips data quality checks
+}
+
+// Record reads the dataset named name back and records it as a new
+// version. A dataset stored as a prefix records the parts it holds now, so
+// that parts appended later are not part of this version. A rule of
+// severity fail that does not pass fails the write.
+func (r *datasetVersionRecorder) Record(ctx context.Context, tenantID, name, jobRunID string) error {
+	ds, err := r.datasets.FindByName(ctx, tenantID, name)
+	if err != nil {
+		return fmt.Errorf("record dataset version: %w", err)
+	}
+	v := &domain.DatasetVersion{
+		ID:          uuid.New().String(),
+		TenantID:    tenantID,
+		DatasetID:   ds.ID,
+		StoragePath: ds.StoragePath,
+		SchemaJSON:  ds.SchemaJSON,
+		RowCount:    ds.RowCount,
+	}
+	if jobRunID != "" {
+		v.JobRunID = &jobRunID
+	}
+	if !storage.IsParquetObject(ds.StoragePath) {
+		keys, err := r.minio.DatasetObjectKeys(ctx, ds.StoragePath)
+		if err != nil {
+			return fmt.Errorf("record dataset version: list parts: %w", err)
+		}
+		data, err := json.Marshal(keys)
+		if err != nil {
+			return fmt.Errorf("record dataset version: %w", err)
+		}
+		keysJSON := string(data)
+		v.ObjectKeysJSON = &keysJSON
+	}
+	if err := r.versions.Create(ctx, v); err != nil {
+		return fmt.Errorf("record dataset version: %w", err)
+	}
+	if r.quality != nil {
+		return r.quality.CheckRun(ctx, tenantID, ds.ID, jobRunID)
+	}
+	return nil
+}
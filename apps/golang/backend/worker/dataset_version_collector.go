@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/user/micro-dp/usecase"
+)
+
+// DatasetVersionCollector periodically deletes dataset versions beyond the
+// retention settings.
+type DatasetVersionCollector struct {
+	versions *usecase.DatasetVersionService
+	interval time.Duration
+}
+
+func NewDatasetVersionCollector(versions *usecase.DatasetVersionService, interval time.Duration) *DatasetVersionCollector {
+	return &DatasetVersionCollector{versions: versions, interval: interval}
+}
+
+func (c *DatasetVersionCollector) Run(ctx context.Context) {
+	if c.interval <= 0 {
+		log.Println("dataset_version_collector disabled")
+		return
+	}
+	log.Printf("dataset_version_collector started interval=%s", c.interval)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("dataset_version_collector stopped")
+			return
+		case <-ticker.C:
+			c.versions.CollectGarbage(ctx)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/user/micro-dp/domain"
 	"github.com/user/micro-dp/internal/connector"
 	"github.com/user/micro-dp/internal/observability"
+	"github.com/user/micro-dp/internal/schemadrift"
 	"github.com/user/micro-dp/usecase"
 )
 
@@ -21,6 +22,8 @@ type JobRunConsumer struct {
 	registry        *connector.Registry
 	credentials     *usecase.CredentialService
 	connections     domain.ConnectionRepository
+	connSvc         *usecase.ConnectionService
+	lineage         *lineageRecorder
 	metrics         *observability.JobRunMetrics
 	metering        *usecase.MeteringService
 }
@@ -32,6 +35,9 @@ func NewJobRunConsumer(
 	registry *connector.Registry,
 	credentials *usecase.CredentialService,
 	connections domain.ConnectionRepository,
+	connSvc *usecase.ConnectionService,
+	datasets domain.DatasetRepository,
+	lineage domain.LineageRepository,
 	metrics *observability.JobRunMetrics,
 	metering *usecase.MeteringService,
 ) *JobRunConsumer {
@@ -42,6 +48,8 @@ func NewJobRunConsumer(
 		registry:        registry,
 		credentials:     credentials,
 		connections:     connections,
+		connSvc:         connSvc,
+		lineage:         &lineageRecorder{datasets: datasets, edges: lineage},
 		metrics:         metrics,
 		metering:        metering,
 	}
@@ -161,10 +169,13 @@ func (c *JobRunConsumer) executeTransform(ctx context.Context, msg *domain.JobRu
 		return fmt.Errorf("no transform module found in snapshot")
 	}
 
-	// Parse config_json to extract sql and dataset_ids
+	// Parse config_json to extract sql, dataset_ids, pinned dataset_versions
+	// and the output dataset
 	var config struct {
-		SQL        string   `json:"sql"`
-		DatasetIDs []string `json:"dataset_ids"`
+		SQL             string                  `json:"sql"`
+		DatasetIDs      []string                `json:"dataset_ids"`
+		DatasetVersions map[string]int          `json:"dataset_versions"`
+		Output          *domain.TransformOutput `json:"output"`
 	}
 	if err := json.Unmarshal([]byte(transformModule.ConfigJSON), &config); err != nil {
 		return fmt.Errorf("parse transform config: %w", err)
@@ -175,12 +186,15 @@ func (c *JobRunConsumer) executeTransform(ctx context.Context, msg *domain.JobRu
 	}
 
 	transformMsg := &domain.TransformJobMessage{
-		JobRunID:   msg.JobRunID,
-		TenantID:   msg.TenantID,
-		SQL:        config.SQL,
-		DatasetIDs: config.DatasetIDs,
-		JobID:      snapshot.JobID,
-		VersionID:  snapshot.VersionID,
+		JobRunID:        msg.JobRunID,
+		TenantID:        msg.TenantID,
+		SQL:             config.SQL,
+		DatasetIDs:      config.DatasetIDs,
+		DatasetVersions: config.DatasetVersions,
+		JobID:           snapshot.JobID,
+		VersionID:       snapshot.VersionID,
+		UserID:          snapshot.CreatedBy,
+		Output:          config.Output,
 	}
 
 	result, err := c.transformWriter.Execute(ctx, transformMsg)
@@ -244,6 +258,29 @@ func (c *JobRunConsumer) executeImport(ctx context.Context, msg *domain.JobRunMe
 		}
 	}
 
+	// Resolve connection config (secrets decrypted) for connectors that
+	// connect with it directly
+	connConfigJSON, err := c.connSvc.ResolveConfig(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("resolve connection config: %w", err)
+	}
+	var connConfig map[string]any
+	if connConfigJSON != "" {
+		if err := json.Unmarshal([]byte(connConfigJSON), &connConfig); err != nil {
+			return fmt.Errorf("parse connection config: %w", err)
+		}
+	}
+
+	// Previous checkpoint for incremental connectors
+	var checkpoint string
+	prev, err := c.jobRuns.FindLatestCheckpoint(ctx, msg.TenantID, snapshot.JobID)
+	if err != nil {
+		return fmt.Errorf("find checkpoint: %w", err)
+	}
+	if prev != nil {
+		checkpoint = *prev
+	}
+
 	params := &connector.ImportParams{
 		TenantID:    msg.TenantID,
 		JobRunID:    msg.JobRunID,
@@ -251,12 +288,29 @@ func (c *JobRunConsumer) executeImport(ctx context.Context, msg *domain.JobRunMe
 		VersionID:   snapshot.VersionID,
 		Config:      config,
 		AccessToken: accessToken,
+
+		ConnectionConfig:  connConfig,
+		Checkpoint:        checkpoint,
+		SchemaDriftPolicy: snapshot.SchemaDriftPolicy,
 	}
 
 	result, err := executor.ExecuteImport(ctx, params)
 	if err != nil {
+		var rejected *schemadrift.RejectedError
+		if errors.As(err, &rejected) {
+			c.recordSchemaDrift(ctx, msg, []*schemadrift.Report{rejected.Report})
+		}
 		return err
 	}
+	if len(result.SchemaDrift) > 0 {
+		c.recordSchemaDrift(ctx, msg, result.SchemaDrift)
+	}
+	if result.Checkpoint != "" {
+		if err := c.jobRuns.UpdateCheckpoint(ctx, msg.JobRunID, result.Checkpoint); err != nil {
+			return fmt.Errorf("update checkpoint: %w", err)
+		}
+	}
+	c.lineage.RecordImport(ctx, msg.TenantID, msg.JobRunID, conn.ID, result.Datasets)
 
 	log.Printf("job_run_consumer: import completed job_run_id=%s rows=%d output=%s",
 		msg.JobRunID, result.RowCount, result.OutputKey)
@@ -264,6 +318,18 @@ func (c *JobRunConsumer) executeImport(ctx context.Context, msg *domain.JobRunMe
 	return nil
 }
 
+// recordSchemaDrift stores the drift detected by an import on the job run.
+func (c *JobRunConsumer) recordSchemaDrift(ctx context.Context, msg *domain.JobRunMessage, reports []*schemadrift.Report) {
+	data, err := json.Marshal(reports)
+	if err != nil {
+		log.Printf("job_run_consumer: marshal schema drift job_run_id=%s: %v", msg.JobRunID, err)
+		return
+	}
+	if err := c.jobRuns.UpdateSchemaDrift(ctx, msg.JobRunID, string(data)); err != nil {
+		log.Printf("job_run_consumer: update schema drift job_run_id=%s: %v", msg.JobRunID, err)
+	}
+}
+
 func (c *JobRunConsumer) enqueueDLQ(ctx context.Context, msg *domain.JobRunMessage, reason string) {
 	if err := c.queue.EnqueueDLQ(ctx, msg, reason); err != nil {
 		log.Printf("job_run_consumer: enqueue dlq error job_run_id=%s: %v", msg.JobRunID, err)
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"log"
+
+	"github.com/user/micro-dp/domain"
+)
+
+// lineageRecorder records the lineage edges of completed runs. Failures
+// are logged only so that they never fail a run.
+type lineageRecorder struct {
+	datasets domain.DatasetRepository
+	edges    domain.LineageRepository
+}
+
+// RecordTransform records the inputs of a transform job, replacing the
+// inputs of earlier runs, and the dataset named output it wrote.
+func (r *lineageRecorder) RecordTransform(ctx context.Context, msg *domain.TransformJobMessage, output string) {
+	if msg.JobID == "" {
+		return
+	}
+	job := domain.LineageNode{Type: domain.LineageNodeJob, ID: msg.JobID}
+	jobRunID := msg.JobRunID
+
+	inputs := make([]domain.LineageEdge, len(msg.DatasetIDs))
+	for i, id := range msg.DatasetIDs {
+		inputs[i] = domain.LineageEdge{
+			TenantID: msg.TenantID,
+			Source:   domain.LineageNode{Type: domain.LineageNodeDataset, ID: id},
+			Target:   job,
+			JobRunID: &jobRunID,
+		}
+	}
+	if err := r.edges.ReplaceSources(ctx, msg.TenantID, job, inputs); err != nil {
+		log.Printf("lineage: record transform inputs job_id=%s: %v", msg.JobID, err)
+	}
+	r.recordOutput(ctx, msg.TenantID, job, output, jobRunID)
+}
+
+// RecordImport records the datasets an import run wrote from a connection.
+func (r *lineageRecorder) RecordImport(ctx context.Context, tenantID, jobRunID, connectionID string, outputs []string) {
+	conn := domain.LineageNode{Type: domain.LineageNodeConnection, ID: connectionID}
+	for _, name := range outputs {
+		r.recordOutput(ctx, tenantID, conn, name, jobRunID)
+	}
+}
+
+func (r *lineageRecorder) recordOutput(ctx context.Context, tenantID string, source domain.LineageNode, name, jobRunID string) {
+	ds, err := r.datasets.FindByName(ctx, tenantID, name)
+	if err != nil {
+		log.Printf("lineage: find dataset %q: %v", name, err)
+		return
+	}
+	e := &domain.LineageEdge{
+		TenantID: tenantID,
+		Source:   source,
+		Target:   domain.LineageNode{Type: domain.LineageNodeDataset, ID: ds.ID},
+		JobRunID: &jobRunID,
+	}
+	if err := r.edges.Upsert(ctx, e); err != nil {
+		log.Printf("lineage: record %s %s -> dataset %s: %v", source.Type, source.ID, ds.ID, err)
+	}
+}
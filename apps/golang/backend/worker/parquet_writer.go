@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"time"
@@ -15,11 +16,15 @@ import (
 )
 
 type ParquetWriter struct {
-	minio *storage.MinIOClient
+	minio   *storage.MinIOClient
+	tracker *trackerDatasetRegistrar
 }
 
-func NewParquetWriter(minio *storage.MinIOClient) *ParquetWriter {
-	return &ParquetWriter{minio: minio}
+func NewParquetWriter(minio *storage.MinIOClient, datasets domain.DatasetRepository) *ParquetWriter {
+	return &ParquetWriter{
+		minio:   minio,
+		tracker: newTrackerDatasetRegistrar(datasets, minio),
+	}
 }
 
 func (w *ParquetWriter) WriteBatch(ctx context.Context, events []*domain.EventQueueMessage) error {
@@ -83,13 +88,14 @@ func (w *ParquetWriter) WriteBatch(ctx context.Context, events []*domain.EventQu
 
 	tenantID := events[0].TenantID
 	now := time.Now().UTC()
+	datePart := now.Format("2006-01-02")
 	eid := events[0].EventID
 	if len(eid) > 8 {
 		eid = eid[:8]
 	}
 	objectKey := fmt.Sprintf("events/%s/dt=%s/%d_%s.parquet",
 		tenantID,
-		now.Format("2006-01-02"),
+		datePart,
 		now.UnixMilli(),
 		eid,
 	)
@@ -98,5 +104,14 @@ func (w *ParquetWriter) WriteBatch(ctx context.Context, events []*domain.EventQu
 		return fmt.Errorf("upload parquet: %w", err)
 	}
 
+	// The batch is stored, so a failed registration is only logged
+	err = storage.ConfigureDuckDBHTTPFS(ctx, db, w.minio.S3Config())
+	if err == nil {
+		err = w.tracker.register(ctx, db, tenantID, trackerEventsDataset, fmt.Sprintf("events/%s", tenantID))
+	}
+	if err != nil {
+		log.Printf("parquet writer: tenant=%s: %v", tenantID, err)
+	}
+
 	return nil
 }
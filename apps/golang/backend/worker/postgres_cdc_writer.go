@@ -0,0 +1,651 @@
+package worker
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/pgcdc"
+	"github.com/user/micro-dp/internal/schemadrift"
+	"github.com/user/micro-dp/storage"
+	"github.com/user/micro-dp/usecase"
+)
+
+// CDCTableSpec maps a replicated table to its current-state dataset.
+type CDCTableSpec struct {
+	Table       string // "schema.table", or "table" in schema public
+	DatasetName string // defaults to "<database>.<schema>.<table>"
+}
+
+// PostgresCDCCheckpoint is stored in JobRun.CheckpointJSON after every run.
+type PostgresCDCCheckpoint struct {
+	SlotName    string `json:"slot_name"`
+	Publication string `json:"publication"`
+	LSN         string `json:"lsn"` // end of the last stored transaction
+	// OwnsPublication is set when a run created the publication, so that
+	// releasing the job's slot drops it too.
+	OwnsPublication bool `json:"owns_publication,omitempty"`
+}
+
+type PostgresCDCMessage struct {
+	JobRunID    string
+	TenantID    string
+	JobID       string
+	VersionID   string
+	Source      pgcdc.Config
+	SlotName    string
+	Publication string // created for Tables when missing
+	Tables      []CDCTableSpec
+	// Checkpoint is the previous run's checkpoint; nil for the first run,
+	// which creates the slot and copies a snapshot of every table.
+	Checkpoint *PostgresCDCCheckpoint
+	// IdleTimeout ends the run when no change arrives for this long.
+	// MaxChanges ends it at the first commit past this many changes.
+	IdleTimeout time.Duration
+	MaxChanges  int64
+	// SchemaDriftPolicy applies to the current-state datasets (see package
+	// schemadrift). Empty means accept.
+	SchemaDriftPolicy string
+}
+
+// PostgresCDCDataset describes the dataset written for one table.
+type PostgresCDCDataset struct {
+	Table       string
+	DatasetName string
+	Changes     int64  // changes in this run's batch (snapshot rows excluded)
+	RowCount    int64  // rows in the current state
+	ChangesKey  string // change batch Parquet; empty without changes
+	OutputKey   string // current-state Parquet
+}
+
+type PostgresCDCResult struct {
+	RowCount    int64  // total current-state rows over the written datasets
+	OutputKey   string // output of the first written dataset
+	Datasets    []PostgresCDCDataset
+	Checkpoint  *PostgresCDCCheckpoint
+	SchemaDrift []*schemadrift.Report // one per dataset whose schema changed
+}
+
+type PostgresCDCWriter struct {
+	minio    *storage.MinIOClient
+	datasets domain.DatasetRepository
+	drift    *schemaDriftGuard
+	versions *datasetVersionRecorder
+}
+
+func NewPostgresCDCWriter(minio *storage.MinIOClient, datasets domain.DatasetRepository, schemaChanges domain.DatasetSchemaChangeRepository, versions domain.DatasetVersionRepository, quality *usecase.DataQualityService) *PostgresCDCWriter {
+	return &PostgresCDCWriter{
+		minio:    minio,
+		datasets: datasets,
+		drift:    &schemaDriftGuard{datasets: datasets, changes: schemaChanges},
+		versions: &datasetVersionRecorder{datasets: datasets, versions: versions, minio: minio, quality: quality},
+	}
+}
+
+// Change batch columns written next to the table's own columns.
+const (
+	cdcOpColumn        = "_cdc_op"
+	cdcLSNColumn       = "_cdc_lsn"
+	cdcSeqColumn       = "_cdc_seq"
+	cdcUnchangedColumn = "_cdc_unchanged"
+)
+
+// cdcTable collects the changes of one table as newline-delimited JSON
+// until they are loaded into DuckDB.
+type cdcTable struct {
+	spec     CDCTableSpec
+	table    pgcdc.Table
+	columns  []pgcdc.Column // union of the columns seen; latest type wins
+	keys     []string
+	snapshot bool // the batch starts with a full copy of the table
+	changes  int64
+	rows     int64
+	path     string
+	file     *os.File
+	buf      *bufio.Writer
+}
+
+// setRelation merges the table's current shape into the batch schema.
+func (t *cdcTable) setRelation(rel *pgcdc.Relation) error {
+	keys := rel.Keys()
+	if len(keys) == 0 {
+		return fmt.Errorf("table %s has no primary key", t.table)
+	}
+	t.keys = keys
+	for _, c := range rel.Columns {
+		found := false
+		for i := range t.columns {
+			if t.columns[i].Name == c.Name {
+				t.columns[i] = c
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.columns = append(t.columns, c)
+		}
+	}
+	return nil
+}
+
+func (t *cdcTable) write(op string, lsn pgcdc.LSN, seq int64, values map[string]*string, unchanged []string) error {
+	if t.file == nil {
+		f, err := os.Create(t.path)
+		if err != nil {
+			return err
+		}
+		t.file, t.buf = f, bufio.NewWriter(f)
+	}
+	row := make(map[string]any, len(values)+4)
+	for k, v := range values {
+		row[k] = v
+	}
+	if unchanged == nil {
+		unchanged = []string{}
+	}
+	row[cdcOpColumn], row[cdcLSNColumn], row[cdcSeqColumn], row[cdcUnchangedColumn] = op, lsn.String(), seq, unchanged
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if _, err := t.buf.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	t.rows++
+	if op != pgcdc.OpSnapshot {
+		t.changes++
+	}
+	return nil
+}
+
+func (t *cdcTable) close() error {
+	if t.file == nil {
+		return nil
+	}
+	if err := t.buf.Flush(); err != nil {
+		t.file.Close()
+		return err
+	}
+	return t.file.Close()
+}
+
+// Execute reads the changes committed since the previous checkpoint (after
+// an initial snapshot on the first run) and merges them into one
+// current-state dataset per table. Every dataset is staged and checked for
+// schema drift before any is written. The slot is only advanced once the
+// datasets are stored, so a failed run is replayed by the next one.
+func (w *PostgresCDCWriter) Execute(ctx context.Context, msg *PostgresCDCMessage) (*PostgresCDCResult, error) {
+	tmpDir, err := os.MkdirTemp("", "micro-dp-postgres-cdc-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tables := make(map[string]*cdcTable, len(msg.Tables))
+	ordered := make([]*cdcTable, 0, len(msg.Tables))
+	pgTables := make([]pgcdc.Table, 0, len(msg.Tables))
+	for i, spec := range msg.Tables {
+		t, err := pgcdc.ParseTable(spec.Table)
+		if err != nil {
+			return nil, err
+		}
+		if spec.DatasetName == "" {
+			spec.DatasetName = fmt.Sprintf("%s.%s", msg.Source.Database, t)
+		}
+		ct := &cdcTable{spec: spec, table: t, path: filepath.Join(tmpDir, fmt.Sprintf("changes_%d.jsonl", i))}
+		tables[t.String()] = ct
+		ordered = append(ordered, ct)
+		pgTables = append(pgTables, t)
+	}
+	defer func() {
+		for _, t := range ordered {
+			t.close()
+		}
+	}()
+
+	after, last, createdPublication, err := w.capture(ctx, msg, tables, pgTables)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range ordered {
+		if err := t.close(); err != nil {
+			return nil, fmt.Errorf("write changes: %w", err)
+		}
+	}
+
+	duckDB, err := sql.Open("duckdb", "")
+	if err != nil {
+		return nil, fmt.Errorf("open duckdb: %w", err)
+	}
+	defer duckDB.Close()
+
+	// Stage: merge every table's batch into its current state
+	var staged []*stagedCDCTable
+	for i, t := range ordered {
+		if t.rows == 0 && !t.snapshot {
+			continue
+		}
+		st, err := w.stageTable(ctx, duckDB, tmpDir, msg, t, i)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", t.table, err)
+		}
+		staged = append(staged, st)
+	}
+
+	// Write: Parquet → MinIO → dataset
+	now := time.Now().UTC()
+	result := &PostgresCDCResult{}
+	for i, st := range staged {
+		ds, err := w.writeTable(ctx, duckDB, tmpDir, msg, st, i, now)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", st.t.table, err)
+		}
+		if i == 0 {
+			result.OutputKey = ds.OutputKey
+		}
+		result.RowCount += ds.RowCount
+		result.Datasets = append(result.Datasets, *ds)
+		if st.drift != nil {
+			result.SchemaDrift = append(result.SchemaDrift, st.drift)
+		}
+	}
+
+	// Release the WAL of the stored transactions. A failure only delays
+	// the release: the next run starts from the stored checkpoint.
+	checkpoint := after
+	if last > checkpoint {
+		if err := pgcdc.AdvanceSlot(ctx, msg.Source, msg.SlotName, last); err != nil {
+			log.Printf("postgres cdc: advance slot %s to %s: %v", msg.SlotName, last, err)
+		}
+		checkpoint = last
+	}
+	ownsPublication := createdPublication ||
+		(msg.Checkpoint != nil && msg.Checkpoint.OwnsPublication && msg.Checkpoint.Publication == msg.Publication)
+	result.Checkpoint = &PostgresCDCCheckpoint{
+		SlotName:        msg.SlotName,
+		Publication:     msg.Publication,
+		LSN:             checkpoint.String(),
+		OwnsPublication: ownsPublication,
+	}
+	return result, nil
+}
+
+// capture streams the slot into the tables' batches. It returns the LSN the
+// run started after, the end of the last transaction read and whether it
+// created the publication.
+func (w *PostgresCDCWriter) capture(ctx context.Context, msg *PostgresCDCMessage, tables map[string]*cdcTable, pgTables []pgcdc.Table) (pgcdc.LSN, pgcdc.LSN, bool, error) {
+	repl, err := pgcdc.DialReplication(ctx, msg.Source)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("connect: %w", err)
+	}
+	defer repl.Close(context.Background())
+
+	created, err := repl.EnsurePublication(ctx, msg.Publication, pgTables)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("ensure publication %s: %w", msg.Publication, err)
+	}
+	target, err := repl.IdentifySystem(ctx)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("identify system: %w", err)
+	}
+
+	var after pgcdc.LSN
+	if msg.Checkpoint != nil {
+		if after, err = pgcdc.ParseLSN(msg.Checkpoint.LSN); err != nil {
+			return 0, 0, false, fmt.Errorf("checkpoint: %w", err)
+		}
+	}
+	confirmed, exists, err := repl.SlotPosition(ctx, msg.SlotName)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("find slot %s: %w", msg.SlotName, err)
+	}
+
+	var seq int64
+	if !exists || msg.Checkpoint == nil {
+		// Without both a slot and a checkpoint the stored state cannot be
+		// continued: start over from a fresh slot and snapshot.
+		if exists {
+			log.Printf("postgres cdc: dropping slot %s without checkpoint", msg.SlotName)
+			if err := repl.DropSlot(ctx, msg.SlotName); err != nil {
+				return 0, 0, false, fmt.Errorf("drop slot %s: %w", msg.SlotName, err)
+			}
+		} else if msg.Checkpoint != nil {
+			log.Printf("postgres cdc: slot %s is missing, taking a new snapshot", msg.SlotName)
+		}
+		consistent, snapshotName, err := repl.CreateSlot(ctx, msg.SlotName)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("create slot %s: %w", msg.SlotName, err)
+		}
+		if seq, err = copySnapshot(ctx, msg.Source, snapshotName, consistent, tables, pgTables); err != nil {
+			return 0, 0, false, err
+		}
+		after, confirmed = consistent, consistent
+	}
+	if confirmed > after {
+		after = confirmed
+	}
+	if after >= target {
+		return after, after, created, nil
+	}
+
+	if err := repl.StartReplication(ctx, msg.SlotName, msg.Publication, after); err != nil {
+		return 0, 0, false, fmt.Errorf("start replication: %w", err)
+	}
+
+	idle := msg.IdleTimeout
+	if idle <= 0 {
+		idle = 10 * time.Second
+	}
+	dec := pgcdc.NewDecoder(after)
+	last, received := after, after
+	var changes int64
+	for {
+		recvCtx, cancel := context.WithTimeout(ctx, idle)
+		m, err := repl.Receive(recvCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, 0, false, ctx.Err()
+			}
+			if pgconn.Timeout(err) {
+				if dec.InTransaction() {
+					continue
+				}
+				return after, last, created, nil
+			}
+			return 0, 0, false, fmt.Errorf("receive: %w", err)
+		}
+
+		switch m := m.(type) {
+		case *pgcdc.XLogData:
+			if m.WALStart > received {
+				received = m.WALStart
+			}
+			committed, end, err := dec.Decode(m.Data)
+			if err != nil {
+				return 0, 0, false, err
+			}
+			for _, c := range committed {
+				t, ok := tables[c.Relation.QualifiedName()]
+				if !ok {
+					continue
+				}
+				if err := t.setRelation(c.Relation); err != nil {
+					return 0, 0, false, err
+				}
+				seq++
+				if err := t.write(c.Op, c.LSN, seq, c.Values, c.Unchanged); err != nil {
+					return 0, 0, false, fmt.Errorf("write changes: %w", err)
+				}
+				changes++
+			}
+			if end > last {
+				last = end
+			}
+			if end != 0 && (end >= target || (msg.MaxChanges > 0 && changes >= msg.MaxChanges)) {
+				return after, last, created, nil
+			}
+		case *pgcdc.Keepalive:
+			if m.ReplyRequested {
+				// Report progress without confirming it; the slot is
+				// advanced only after the datasets are written.
+				if err := repl.SendStatus(received, after); err != nil {
+					return 0, 0, false, fmt.Errorf("send status: %w", err)
+				}
+			}
+			if !dec.InTransaction() && m.ServerWALEnd >= target {
+				return after, last, created, nil
+			}
+		}
+	}
+}
+
+// copySnapshot copies every table as of the slot's exported snapshot.
+func copySnapshot(ctx context.Context, cfg pgcdc.Config, snapshotName string, consistent pgcdc.LSN, tables map[string]*cdcTable, pgTables []pgcdc.Table) (int64, error) {
+	snap, err := pgcdc.OpenSnapshot(ctx, cfg, snapshotName)
+	if err != nil {
+		return 0, err
+	}
+	defer snap.Close(context.Background())
+
+	var seq int64
+	for _, pt := range pgTables {
+		t := tables[pt.String()]
+		rel, err := snap.Describe(ctx, pt)
+		if err != nil {
+			return 0, fmt.Errorf("describe %s: %w", pt, err)
+		}
+		if err := t.setRelation(rel); err != nil {
+			return 0, err
+		}
+		t.snapshot = true
+		err = snap.Copy(ctx, rel, func(values []*string) error {
+			row := make(map[string]*string, len(values))
+			for i, v := range values {
+				row[rel.Columns[i].Name] = v
+			}
+			seq++
+			return t.write(pgcdc.OpSnapshot, consistent, seq, row, nil)
+		})
+		if err != nil {
+			return 0, fmt.Errorf("copy %s: %w", pt, err)
+		}
+		log.Printf("postgres cdc: snapshot table=%s rows=%d", pt, t.rows)
+	}
+	return seq, nil
+}
+
+// stagedCDCTable is a table's new current state in DuckDB, checked for
+// schema drift and waiting to be written.
+type stagedCDCTable struct {
+	t          *cdcTable
+	changes    string // DuckDB table of the batch
+	state      string // DuckDB table of the current state
+	schemaJSON string
+	rowCount   int64
+	drift      *schemadrift.Report
+}
+
+func (w *PostgresCDCWriter) stageTable(ctx context.Context, duckDB *sql.DB, tmpDir string, msg *PostgresCDCMessage, t *cdcTable, idx int) (*stagedCDCTable, error) {
+	changesTable := fmt.Sprintf("changes_%d", idx)
+	stateTable := fmt.Sprintf("state_%d", idx)
+
+	// Batch: text values cast to the column types
+	defs := []string{
+		cdcOpColumn + " VARCHAR", cdcLSNColumn + " VARCHAR", cdcSeqColumn + " BIGINT", cdcUnchangedColumn + " VARCHAR[]",
+	}
+	jsonCols := []string{
+		fmt.Sprintf("'%s': 'VARCHAR'", cdcOpColumn), fmt.Sprintf("'%s': 'VARCHAR'", cdcLSNColumn),
+		fmt.Sprintf("'%s': 'BIGINT'", cdcSeqColumn), fmt.Sprintf("'%s': 'VARCHAR[]'", cdcUnchangedColumn),
+	}
+	selects := []string{cdcOpColumn, cdcLSNColumn, cdcSeqColumn, cdcUnchangedColumn}
+	for _, c := range t.columns {
+		typ := pgcdc.DuckDBType(c)
+		defs = append(defs, fmt.Sprintf("%s %s", quoteDuckDBIdent(c.Name), typ))
+		jsonCols = append(jsonCols, fmt.Sprintf("'%s': 'VARCHAR'", strings.ReplaceAll(c.Name, "'", "''")))
+		selects = append(selects, fmt.Sprintf("TRY_CAST(%s AS %s)", quoteDuckDBIdent(c.Name), typ))
+	}
+	if _, err := duckDB.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", changesTable, strings.Join(defs, ", "))); err != nil {
+		return nil, fmt.Errorf("create changes table: %w", err)
+	}
+	if t.rows > 0 {
+		_, err := duckDB.ExecContext(ctx, fmt.Sprintf(
+			"INSERT INTO %s SELECT %s FROM read_json('%s', format='newline_delimited', columns={%s})",
+			changesTable, strings.Join(selects, ", "), t.path, strings.Join(jsonCols, ", ")))
+		if err != nil {
+			return nil, fmt.Errorf("load changes: %w", err)
+		}
+	}
+
+	// Previous state, unless this batch starts from a snapshot
+	base := ""
+	if !t.snapshot {
+		prev, err := w.datasets.FindByName(ctx, msg.TenantID, t.spec.DatasetName)
+		switch {
+		case errors.Is(err, domain.ErrDatasetNotFound):
+		case err != nil:
+			return nil, fmt.Errorf("find dataset: %w", err)
+		default:
+			prevDir := filepath.Join(tmpDir, fmt.Sprintf("previous_%d", idx))
+			if err := os.Mkdir(prevDir, 0o755); err != nil {
+				return nil, fmt.Errorf("create temp dir: %w", err)
+			}
+			prevPath, err := w.minio.DownloadDataset(ctx, prev.StoragePath, prevDir)
+			if err != nil {
+				return nil, fmt.Errorf("download previous state: %w", err)
+			}
+			base = fmt.Sprintf(
+				"SELECT *, '%s' AS %s, 0::BIGINT AS %s, []::VARCHAR[] AS %s FROM read_parquet('%s') UNION ALL BY NAME ",
+				pgcdc.OpSnapshot, cdcOpColumn, cdcSeqColumn, cdcUnchangedColumn, prevPath)
+		}
+	}
+
+	// Current state: the latest value of every column per key, skipping
+	// unchanged TOAST values and everything before the last truncate;
+	// keys whose latest change is a delete are dropped.
+	keys := make([]string, len(t.keys))
+	for i, k := range t.keys {
+		keys[i] = quoteDuckDBIdent(k)
+	}
+	var cols []string
+	for _, c := range t.columns {
+		name := quoteDuckDBIdent(c.Name)
+		if isKey(t.keys, c.Name) {
+			cols = append(cols, name)
+			continue
+		}
+		cols = append(cols, fmt.Sprintf(
+			"CAST(arg_max_null(%s, %s) FILTER (WHERE NOT list_contains(%s, '%s')) AS %s) AS %s",
+			name, cdcSeqColumn, cdcUnchangedColumn, strings.ReplaceAll(c.Name, "'", "''"), pgcdc.DuckDBType(c), name))
+	}
+	_, err := duckDB.ExecContext(ctx, fmt.Sprintf(
+		`CREATE TABLE %s AS SELECT %s FROM (%sSELECT * FROM %s)
+		 WHERE %s <> '%s' AND %s > (SELECT COALESCE(MAX(%s), -1) FROM %s WHERE %s = '%s')
+		 GROUP BY %s HAVING arg_max(%s, %s) <> '%s' ORDER BY %s`,
+		stateTable, strings.Join(cols, ", "), base, changesTable,
+		cdcOpColumn, pgcdc.OpTruncate, cdcSeqColumn, cdcSeqColumn, changesTable, cdcOpColumn, pgcdc.OpTruncate,
+		strings.Join(keys, ", "), cdcOpColumn, cdcSeqColumn, pgcdc.OpDelete, strings.Join(keys, ", ")))
+	if err != nil {
+		return nil, fmt.Errorf("compact: %w", err)
+	}
+
+	schemaJSON, err := ExtractEnrichedSchema(ctx, duckDB, stateTable)
+	if err != nil {
+		return nil, fmt.Errorf("extract schema: %w", err)
+	}
+	drift, err := w.drift.Check(ctx, msg.TenantID, msg.JobRunID, t.spec.DatasetName, schemaJSON, msg.SchemaDriftPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	var rowCount int64
+	if err := duckDB.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", stateTable)).Scan(&rowCount); err != nil {
+		return nil, fmt.Errorf("count rows: %w", err)
+	}
+
+	return &stagedCDCTable{
+		t:          t,
+		changes:    changesTable,
+		state:      stateTable,
+		schemaJSON: schemaJSON,
+		rowCount:   rowCount,
+		drift:      drift,
+	}, nil
+}
+
+func (w *PostgresCDCWriter) writeTable(ctx context.Context, duckDB *sql.DB, tmpDir string, msg *PostgresCDCMessage, st *stagedCDCTable, idx int, now time.Time) (*PostgresCDCDataset, error) {
+	objectName := msg.JobRunID
+	if idx > 0 {
+		objectName = fmt.Sprintf("%s-%d", msg.JobRunID, idx)
+	}
+	ds := &PostgresCDCDataset{
+		Table:       st.t.table.String(),
+		DatasetName: st.t.spec.DatasetName,
+		Changes:     st.t.changes,
+		RowCount:    st.rowCount,
+	}
+
+	// Change batch (snapshot rows are not changes)
+	if st.t.changes > 0 {
+		key := fmt.Sprintf("postgres_cdc/%s/changes/dt=%s/%s.parquet", msg.TenantID, now.Format("2006-01-02"), objectName)
+		query := fmt.Sprintf("SELECT * FROM %s WHERE %s <> '%s' ORDER BY %s", st.changes, cdcOpColumn, pgcdc.OpSnapshot, cdcSeqColumn)
+		if err := w.putParquet(ctx, duckDB, tmpDir, query, st.changes, key); err != nil {
+			return nil, fmt.Errorf("change batch: %w", err)
+		}
+		ds.ChangesKey = key
+	}
+
+	// Current state
+	ds.OutputKey = fmt.Sprintf("postgres_cdc/%s/dt=%s/%s.parquet", msg.TenantID, now.Format("2006-01-02"), objectName)
+	if err := w.putParquet(ctx, duckDB, tmpDir, st.state, st.state, ds.OutputKey); err != nil {
+		return nil, fmt.Errorf("current state: %w", err)
+	}
+
+	// Upsert dataset
+	lastUpdated := now
+	rowCount := st.rowCount
+	dataset := &domain.Dataset{
+		ID:            uuid.New().String(),
+		TenantID:      msg.TenantID,
+		Name:          ds.DatasetName,
+		SourceType:    domain.SourceTypeImport,
+		SchemaJSON:    &st.schemaJSON,
+		RowCount:      &rowCount,
+		StoragePath:   ds.OutputKey,
+		LastUpdatedAt: &lastUpdated,
+	}
+	if err := w.datasets.Upsert(ctx, dataset); err != nil {
+		return nil, fmt.Errorf("upsert dataset: %w", err)
+	}
+	if err := w.versions.Record(ctx, msg.TenantID, ds.DatasetName, msg.JobRunID); err != nil {
+		return nil, err
+	}
+	if st.drift != nil {
+		w.drift.Record(ctx, msg.TenantID, msg.JobRunID, st.drift)
+	}
+	log.Printf("postgres cdc: table=%s dataset=%q changes=%d rows=%d", ds.Table, ds.DatasetName, ds.Changes, ds.RowCount)
+	return ds, nil
+}
+
+// putParquet exports a DuckDB table or query to Parquet and uploads it.
+func (w *PostgresCDCWriter) putParquet(ctx context.Context, duckDB *sql.DB, tmpDir, source, name, key string) error {
+	if strings.HasPrefix(source, "SELECT ") {
+		source = "(" + source + ")"
+	}
+	path := filepath.Join(tmpDir, name+".parquet")
+	if _, err := duckDB.ExecContext(ctx, fmt.Sprintf("COPY %s TO '%s' (FORMAT PARQUET)", source, path)); err != nil {
+		return fmt.Errorf("copy to parquet: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read parquet: %w", err)
+	}
+	if err := w.minio.PutParquet(ctx, key, data); err != nil {
+		return fmt.Errorf("upload parquet: %w", err)
+	}
+	return nil
+}
+
+func quoteDuckDBIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func isKey(keys []string, name string) bool {
+	for _, k := range keys {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
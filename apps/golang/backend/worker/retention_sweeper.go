@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/user/micro-dp/usecase"
+)
+
+// RetentionSweeper periodically purges the raw partitions and dataset
+// versions past the tenants' retention policies and plan maximums.
+type RetentionSweeper struct {
+	retention *usecase.RetentionService
+	interval  time.Duration
+}
+
+func NewRetentionSweeper(retention *usecase.RetentionService, interval time.Duration) *RetentionSweeper {
+	return &RetentionSweeper{retention: retention, interval: interval}
+}
+
+func (s *RetentionSweeper) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		log.Println("retention_sweeper disabled")
+		return
+	}
+	log.Printf("retention_sweeper started interval=%s", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("retention_sweeper stopped")
+			return
+		case <-ticker.C:
+			s.retention.Sweep(ctx)
+		}
+	}
+}
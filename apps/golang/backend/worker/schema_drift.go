@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/schemadrift"
+)
+
+// schemaDriftGuard compares an import's schema with the current version of
+// the target dataset and keeps the per-dataset schema change log.
+type schemaDriftGuard struct {
+	datasets domain.DatasetRepository
+	changes  domain.DatasetSchemaChangeRepository
+}
+
+// Check evaluates the drift between the dataset named name and schemaJSON.
+// It returns nil when the dataset is new or its schema is unchanged. When
+// policy rejects the drift, the rejection is recorded and a
+// *schemadrift.RejectedError is returned; the caller must not write the
+// dataset.
+func (g *schemaDriftGuard) Check(ctx context.Context, tenantID, jobRunID, name, schemaJSON, policy string) (*schemadrift.Report, error) {
+	prev, err := g.datasets.FindByName(ctx, tenantID, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrDatasetNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("find dataset: %w", err)
+	}
+	if prev.SchemaJSON == nil || *prev.SchemaJSON == "" {
+		return nil, nil
+	}
+
+	var prevCols, nextCols []schemadrift.Column
+	if err := json.Unmarshal([]byte(*prev.SchemaJSON), &prevCols); err != nil {
+		return nil, fmt.Errorf("parse previous schema: %w", err)
+	}
+	if err := json.Unmarshal([]byte(schemaJSON), &nextCols); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+
+	report := schemadrift.Evaluate(policy, prevCols, nextCols)
+	if report == nil {
+		return nil, nil
+	}
+	report.DatasetID = prev.ID
+	if report.Action == schemadrift.ActionRejected {
+		g.Record(ctx, tenantID, jobRunID, report)
+		return report, &schemadrift.RejectedError{Report: report}
+	}
+	return report, nil
+}
+
+// Record appends report to the dataset's schema change log. Failures are
+// logged only so that they never fail an import.
+func (g *schemaDriftGuard) Record(ctx context.Context, tenantID, jobRunID string, report *schemadrift.Report) {
+	changesJSON, err := json.Marshal(report.Changes)
+	if err != nil {
+		log.Printf("schema drift: marshal changes dataset=%s: %v", report.DatasetID, err)
+		return
+	}
+	c := &domain.DatasetSchemaChange{
+		ID:          uuid.New().String(),
+		TenantID:    tenantID,
+		DatasetID:   report.DatasetID,
+		Policy:      report.Policy,
+		Action:      report.Action,
+		ChangesJSON: string(changesJSON),
+	}
+	if jobRunID != "" {
+		c.JobRunID = &jobRunID
+	}
+	if err := g.changes.Create(ctx, c); err != nil {
+		log.Printf("schema drift: record changes dataset=%s: %v", report.DatasetID, err)
+		return
+	}
+	log.Printf("schema drift: dataset=%s policy=%s action=%s changes=%d", report.DatasetID, report.Policy, report.Action, len(report.Changes))
+}
@@ -6,11 +6,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"regexp"
 
 	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/pii"
 )
 
-// ExtractEnrichedSchema extracts column metadata with statistics and sample values from a DuckDB table.
+// piiSampleSize is the number of values of a column checked for personal data.
+const piiSampleSize = 100
+
+// piiColumnType matches the column types personal data is looked for in.
+var piiColumnType = regexp.MustCompile(`^(VARCHAR|BIGINT|HUGEINT|UBIGINT|DECIMAL\(\d+,0\))$`)
+
+// ExtractEnrichedSchema extracts column metadata with statistics, sample values and detected personal data from a DuckDB table.
 func ExtractEnrichedSchema(ctx context.Context, db *sql.DB, tableName string) (string, error) {
 	cols, err := describeTable(ctx, db, tableName)
 	if err != nil {
@@ -31,6 +39,17 @@ func ExtractEnrichedSchema(ctx context.Context, db *sql.DB, tableName string) (s
 			continue
 		}
 		cols[i].SampleValues = samples
+
+		if !piiColumnType.MatchString(cols[i].Type) {
+			continue
+		}
+		kind, err := detectPII(ctx, db, tableName, cols[i].Name)
+		if err != nil {
+			log.Printf("schema_extractor: pii detection for %s.%s failed: %v", tableName, cols[i].Name, err)
+			continue
+		}
+		cols[i].PIIKind = kind
+		cols[i].SuggestedTags = pii.SuggestedTags(kind)
 	}
 
 	data, err := json.Marshal(cols)
@@ -119,3 +138,29 @@ func sampleColumnValues(ctx context.Context, db *sql.DB, tableName, colName stri
 	}
 	return samples, rows.Err()
 }
+
+func detectPII(ctx context.Context, db *sql.DB, tableName, colName string) (string, error) {
+	query := fmt.Sprintf(
+		`SELECT CAST("%s" AS VARCHAR) FROM %s WHERE "%s" IS NOT NULL LIMIT %d`,
+		colName, tableName, colName, piiSampleSize,
+	)
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var val string
+		if err := rows.Scan(&val); err != nil {
+			return "", err
+		}
+		values = append(values, val)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return pii.Detect(values), nil
+}
@@ -3,7 +3,6 @@ package worker
 import (
 	"context"
 	"database/sql"
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,62 +14,175 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/fileconv"
+	"github.com/user/micro-dp/internal/gsheets"
+	"github.com/user/micro-dp/internal/schemadrift"
 	"github.com/user/micro-dp/storage"
+	"github.com/user/micro-dp/usecase"
 )
 
+// SheetSpec selects one tab of a spreadsheet and the dataset it is written to.
+type SheetSpec struct {
+	SheetName   string // empty selects the first sheet
+	Range       string // A1 range within the sheet; empty reads the whole sheet
+	HeaderRow   int    // 1-based row of the range that holds column names (default 1)
+	DatasetName string // stable dataset name; defaults to "<spreadsheet title> - <sheet name>"
+}
+
 type SheetsImportMessage struct {
 	JobRunID      string
 	TenantID      string
 	SpreadsheetID string
-	SheetName     string
-	Range         string
-	AccessToken   string
-	JobID         string
-	VersionID     string
+	Sheets        []SheetSpec // empty imports the first sheet
+	// ValueRenderOption and DateTimeRenderOption are passed to the Sheets
+	// API. The defaults (UNFORMATTED_VALUE, FORMATTED_STRING) keep numbers
+	// and booleans typed and return dates as text that is parsed as DATE or
+	// TIMESTAMP.
+	ValueRenderOption    string
+	DateTimeRenderOption string
+	AccessToken          string
+	JobID                string
+	VersionID            string
+	// SchemaDriftPolicy applies when a dataset already exists with a
+	// different schema (see package schemadrift). Empty means accept.
+	SchemaDriftPolicy string
+}
+
+// SheetsImportDataset describes the dataset written for one sheet.
+type SheetsImportDataset struct {
+	SheetName   string
+	DatasetName string
+	RowCount    int64
+	OutputKey   string
 }
 
 type SheetsImportResult struct {
-	RowCount  int64
-	OutputKey string
+	RowCount    int64  // total over all sheets
+	OutputKey   string // output of the first sheet
+	Datasets    []SheetsImportDataset
+	SchemaDrift []*schemadrift.Report // one per dataset whose schema changed
 }
 
 type SheetsImportWriter struct {
 	minio    *storage.MinIOClient
 	datasets domain.DatasetRepository
+	drift    *schemaDriftGuard
+	versions *datasetVersionRecorder
+}
+
+func NewSheetsImportWriter(minio *storage.MinIOClient, datasets domain.DatasetRepository, schemaChanges domain.DatasetSchemaChangeRepository, versions domain.DatasetVersionRepository, quality *usecase.DataQualityService) *SheetsImportWriter {
+	return &SheetsImportWriter{
+		minio:    minio,
+		datasets: datasets,
+		drift:    &schemaDriftGuard{datasets: datasets, changes: schemaChanges},
+		versions: &datasetVersionRecorder{datasets: datasets, versions: versions, minio: minio, quality: quality},
+	}
 }
 
-func NewSheetsImportWriter(minio *storage.MinIOClient, datasets domain.DatasetRepository) *SheetsImportWriter {
-	return &SheetsImportWriter{minio: minio, datasets: datasets}
+// sheetInfo is a tab of the spreadsheet.
+type sheetInfo struct {
+	Title  string
+	Merges []gsheets.Merge
 }
 
+// stagedSheet is a sheet loaded into DuckDB and checked for schema drift,
+// waiting to be written.
+type stagedSheet struct {
+	sheetName   string
+	datasetName string
+	table       string
+	schemaJSON  string
+	rowCount    int64
+	drift       *schemadrift.Report
+}
+
+// Execute imports every requested sheet into its own dataset. All sheets are
+// fetched and checked for schema drift before any dataset is written, so a
+// rejected change in one sheet leaves every dataset untouched.
 func (w *SheetsImportWriter) Execute(ctx context.Context, msg *SheetsImportMessage) (*SheetsImportResult, error) {
+	specs := msg.Sheets
+	if len(specs) == 0 {
+		specs = []SheetSpec{{}}
+	}
+
 	tmpDir, err := os.MkdirTemp("", "micro-dp-sheets-import-*")
 	if err != nil {
 		return nil, fmt.Errorf("create temp dir: %w", err)
 	}
 	defer os.RemoveAll(tmpDir)
 
-	// Resolve sheet name if empty
-	sheetName := msg.SheetName
-	spreadsheetTitle := ""
-	if sheetName == "" {
-		title, firstSheet, err := w.getSpreadsheetInfo(ctx, msg.AccessToken, msg.SpreadsheetID)
+	spreadsheetTitle, sheets, err := w.getSpreadsheetInfo(ctx, msg.AccessToken, msg.SpreadsheetID)
+	if err != nil {
+		for _, spec := range specs {
+			if spec.SheetName == "" {
+				return nil, fmt.Errorf("get spreadsheet info: %w", err)
+			}
+		}
+		// Named sheets can still be read; only titles and merges are missing.
+		spreadsheetTitle = msg.SpreadsheetID
+	}
+
+	duckDB, err := sql.Open("duckdb", "")
+	if err != nil {
+		return nil, fmt.Errorf("open duckdb: %w", err)
+	}
+	defer duckDB.Close()
+
+	// Stage: fetch, type and compare every sheet
+	staged := make([]stagedSheet, len(specs))
+	seen := make(map[string]bool, len(specs))
+	for i, spec := range specs {
+		st, err := w.stageSheet(ctx, duckDB, tmpDir, msg, spec, i, spreadsheetTitle, sheets)
 		if err != nil {
-			return nil, fmt.Errorf("get spreadsheet info: %w", err)
+			return nil, fmt.Errorf("sheet %q: %w", spec.SheetName, err)
+		}
+		if seen[st.datasetName] {
+			return nil, fmt.Errorf("sheet %q: dataset name %q is used by another sheet", st.sheetName, st.datasetName)
 		}
-		sheetName = firstSheet
-		spreadsheetTitle = title
-	} else {
-		title, _, err := w.getSpreadsheetInfo(ctx, msg.AccessToken, msg.SpreadsheetID)
+		seen[st.datasetName] = true
+		staged[i] = *st
+	}
+
+	// Write: Parquet → MinIO → dataset
+	now := time.Now().UTC()
+	result := &SheetsImportResult{}
+	for i, st := range staged {
+		outputKey, err := w.writeSheet(ctx, duckDB, tmpDir, msg, &st, i, now)
 		if err != nil {
-			spreadsheetTitle = msg.SpreadsheetID
-		} else {
-			spreadsheetTitle = title
+			return nil, fmt.Errorf("sheet %q: %w", st.sheetName, err)
+		}
+		if i == 0 {
+			result.OutputKey = outputKey
+		}
+		result.RowCount += st.rowCount
+		result.Datasets = append(result.Datasets, SheetsImportDataset{
+			SheetName:   st.sheetName,
+			DatasetName: st.datasetName,
+			RowCount:    st.rowCount,
+			OutputKey:   outputKey,
+		})
+		if st.drift != nil {
+			result.SchemaDrift = append(result.SchemaDrift, st.drift)
 		}
 	}
+	return result, nil
+}
 
-	// Fetch data from Sheets API
-	values, err := w.getSheetValues(ctx, msg.AccessToken, msg.SpreadsheetID, sheetName, msg.Range)
+func (w *SheetsImportWriter) stageSheet(ctx context.Context, duckDB *sql.DB, tmpDir string, msg *SheetsImportMessage, spec SheetSpec, idx int, spreadsheetTitle string, sheets []sheetInfo) (*stagedSheet, error) {
+	sheetName := spec.SheetName
+	var merges []gsheets.Merge
+	for _, s := range sheets {
+		if sheetName == "" || s.Title == sheetName {
+			sheetName = s.Title
+			merges = s.Merges
+			break
+		}
+	}
+	if sheetName == "" {
+		return nil, fmt.Errorf("spreadsheet has no sheets")
+	}
+
+	values, err := w.getSheetValues(ctx, msg, sheetName, spec.Range)
 	if err != nil {
 		return nil, fmt.Errorf("get sheet values: %w", err)
 	}
@@ -78,98 +190,124 @@ func (w *SheetsImportWriter) Execute(ctx context.Context, msg *SheetsImportMessa
 		return nil, fmt.Errorf("sheet returned no data")
 	}
 
-	// Write to CSV temp file
-	csvPath := filepath.Join(tmpDir, "input.csv")
-	if err := w.writeCSV(csvPath, values); err != nil {
-		return nil, fmt.Errorf("write csv: %w", err)
+	originRow, originCol := gsheets.RangeOrigin(spec.Range)
+	table, err := gsheets.Build(values, gsheets.Options{
+		HeaderRow:    spec.HeaderRow,
+		OriginRow:    originRow,
+		OriginColumn: originCol,
+		Merges:       merges,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// DuckDB: CSV → Parquet
-	duckDB, err := sql.Open("duckdb", "")
-	if err != nil {
-		return nil, fmt.Errorf("open duckdb: %w", err)
+	// Typed CSV → DuckDB table with the inferred column types
+	tableName := fmt.Sprintf("sheet_%d", idx)
+	if err := fileconv.LoadTypedTable(ctx, duckDB, tableName, filepath.Join(tmpDir, tableName+".csv"), table); err != nil {
+		return nil, err
 	}
-	defer duckDB.Close()
 
-	_, err = duckDB.ExecContext(ctx, fmt.Sprintf("CREATE TABLE imported AS SELECT * FROM read_csv('%s', header=true, auto_detect=true, delim=',', null_padding=true, ignore_errors=true)", csvPath))
+	schemaJSON, err := ExtractEnrichedSchema(ctx, duckDB, tableName)
 	if err != nil {
-		return nil, fmt.Errorf("read csv: %w", err)
+		return nil, fmt.Errorf("extract schema: %w", err)
 	}
 
-	schemaJSON, err := ExtractEnrichedSchema(ctx, duckDB, "imported")
+	datasetName := spec.DatasetName
+	if datasetName == "" {
+		datasetName = fmt.Sprintf("%s - %s", spreadsheetTitle, sheetName)
+	}
+
+	// Compare with the previous version before anything is written
+	drift, err := w.drift.Check(ctx, msg.TenantID, msg.JobRunID, datasetName, schemaJSON, msg.SchemaDriftPolicy)
 	if err != nil {
-		return nil, fmt.Errorf("extract schema: %w", err)
+		return nil, err
 	}
 
 	var rowCount int64
-	if err := duckDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM imported").Scan(&rowCount); err != nil {
+	if err := duckDB.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", tableName)).Scan(&rowCount); err != nil {
 		return nil, fmt.Errorf("count rows: %w", err)
 	}
 
-	parquetPath := filepath.Join(tmpDir, "output.parquet")
-	_, err = duckDB.ExecContext(ctx, fmt.Sprintf("COPY imported TO '%s' (FORMAT PARQUET)", parquetPath))
+	return &stagedSheet{
+		sheetName:   sheetName,
+		datasetName: datasetName,
+		table:       tableName,
+		schemaJSON:  schemaJSON,
+		rowCount:    rowCount,
+		drift:       drift,
+	}, nil
+}
+
+func (w *SheetsImportWriter) writeSheet(ctx context.Context, duckDB *sql.DB, tmpDir string, msg *SheetsImportMessage, st *stagedSheet, idx int, now time.Time) (string, error) {
+	parquetPath := filepath.Join(tmpDir, st.table+".parquet")
+	_, err := duckDB.ExecContext(ctx, fmt.Sprintf("COPY %s TO '%s' (FORMAT PARQUET)", st.table, parquetPath))
 	if err != nil {
-		return nil, fmt.Errorf("copy to parquet: %w", err)
+		return "", fmt.Errorf("copy to parquet: %w", err)
 	}
 
 	// Upload to MinIO
 	data, err := os.ReadFile(parquetPath)
 	if err != nil {
-		return nil, fmt.Errorf("read parquet: %w", err)
+		return "", fmt.Errorf("read parquet: %w", err)
 	}
 
-	now := time.Now().UTC()
+	objectName := msg.JobRunID
+	if idx > 0 {
+		objectName = fmt.Sprintf("%s-%d", msg.JobRunID, idx)
+	}
 	outputKey := fmt.Sprintf("sheets_imports/%s/dt=%s/%s.parquet",
 		msg.TenantID,
 		now.Format("2006-01-02"),
-		msg.JobRunID,
+		objectName,
 	)
 	if err := w.minio.PutParquet(ctx, outputKey, data); err != nil {
-		return nil, fmt.Errorf("upload parquet: %w", err)
+		return "", fmt.Errorf("upload parquet: %w", err)
 	}
 
 	// Upsert dataset
-	datasetName := fmt.Sprintf("%s - %s", spreadsheetTitle, sheetName)
 	lastUpdated := now
+	rowCount := st.rowCount
 	dataset := &domain.Dataset{
 		ID:            uuid.New().String(),
 		TenantID:      msg.TenantID,
-		Name:          datasetName,
+		Name:          st.datasetName,
 		SourceType:    domain.SourceTypeImport,
-		SchemaJSON:    &schemaJSON,
+		SchemaJSON:    &st.schemaJSON,
 		RowCount:      &rowCount,
 		StoragePath:   outputKey,
 		LastUpdatedAt: &lastUpdated,
 	}
 	if err := w.datasets.Upsert(ctx, dataset); err != nil {
-		return nil, fmt.Errorf("upsert dataset: %w", err)
+		return "", fmt.Errorf("upsert dataset: %w", err)
 	}
-
-	return &SheetsImportResult{
-		RowCount:  rowCount,
-		OutputKey: outputKey,
-	}, nil
+	if err := w.versions.Record(ctx, msg.TenantID, st.datasetName, msg.JobRunID); err != nil {
+		return "", err
+	}
+	if st.drift != nil {
+		w.drift.Record(ctx, msg.TenantID, msg.JobRunID, st.drift)
+	}
+	return outputKey, nil
 }
 
-// getSpreadsheetInfo fetches the spreadsheet title and first sheet name.
-func (w *SheetsImportWriter) getSpreadsheetInfo(ctx context.Context, accessToken, spreadsheetID string) (title string, firstSheet string, err error) {
-	apiURL := fmt.Sprintf("https://sheets.googleapis.com/v4/spreadsheets/%s?fields=properties.title,sheets.properties.title", url.PathEscape(spreadsheetID))
+// getSpreadsheetInfo fetches the spreadsheet title and its sheets in order.
+func (w *SheetsImportWriter) getSpreadsheetInfo(ctx context.Context, accessToken, spreadsheetID string) (string, []sheetInfo, error) {
+	apiURL := fmt.Sprintf("https://sheets.googleapis.com/v4/spreadsheets/%s?fields=properties.title,sheets(properties.title,merges)", url.PathEscape(spreadsheetID))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
-		return "", "", err
+		return "", nil, err
 	}
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", "", err
+		return "", nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", "", fmt.Errorf("sheets api returned %d: %s", resp.StatusCode, string(body))
+		return "", nil, fmt.Errorf("sheets api returned %d: %s", resp.StatusCode, string(body))
 	}
 
 	var result struct {
@@ -180,36 +318,55 @@ func (w *SheetsImportWriter) getSpreadsheetInfo(ctx context.Context, accessToken
 			Properties struct {
 				Title string `json:"title"`
 			} `json:"properties"`
+			Merges []gsheets.Merge `json:"merges"`
 		} `json:"sheets"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", "", fmt.Errorf("decode response: %w", err)
+		return "", nil, fmt.Errorf("decode response: %w", err)
 	}
 
 	if len(result.Sheets) == 0 {
-		return "", "", fmt.Errorf("spreadsheet has no sheets")
+		return "", nil, fmt.Errorf("spreadsheet has no sheets")
 	}
 
-	return result.Properties.Title, result.Sheets[0].Properties.Title, nil
+	sheets := make([]sheetInfo, len(result.Sheets))
+	for i, s := range result.Sheets {
+		sheets[i] = sheetInfo{Title: s.Properties.Title, Merges: s.Merges}
+	}
+	return result.Properties.Title, sheets, nil
 }
 
-// getSheetValues fetches cell values from the Sheets API.
-func (w *SheetsImportWriter) getSheetValues(ctx context.Context, accessToken, spreadsheetID, sheetName, cellRange string) ([][]interface{}, error) {
+// getSheetValues fetches cell values from the Sheets API. Numbers are
+// decoded as json.Number so that large integers keep their precision.
+func (w *SheetsImportWriter) getSheetValues(ctx context.Context, msg *SheetsImportMessage, sheetName, cellRange string) ([][]any, error) {
 	rangeStr := sheetName
 	if cellRange != "" {
 		rangeStr = sheetName + "!" + cellRange
 	}
 
-	apiURL := fmt.Sprintf("https://sheets.googleapis.com/v4/spreadsheets/%s/values/%s",
-		url.PathEscape(spreadsheetID),
+	valueRender := msg.ValueRenderOption
+	if valueRender == "" {
+		valueRender = "UNFORMATTED_VALUE"
+	}
+	dateTimeRender := msg.DateTimeRenderOption
+	if dateTimeRender == "" {
+		dateTimeRender = "FORMATTED_STRING"
+	}
+	query := url.Values{
+		"valueRenderOption":    {valueRender},
+		"dateTimeRenderOption": {dateTimeRender},
+	}
+	apiURL := fmt.Sprintf("https://sheets.googleapis.com/v4/spreadsheets/%s/values/%s?%s",
+		url.PathEscape(msg.SpreadsheetID),
 		url.PathEscape(rangeStr),
+		query.Encode(),
 	)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Authorization", "Bearer "+msg.AccessToken)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -223,47 +380,13 @@ func (w *SheetsImportWriter) getSheetValues(ctx context.Context, accessToken, sp
 	}
 
 	var result struct {
-		Values [][]interface{} `json:"values"`
+		Values [][]any `json:"values"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&result); err != nil {
 		return nil, fmt.Errorf("decode response: %w", err)
 	}
 
 	return result.Values, nil
 }
-
-// writeCSV writes the Sheets values to a CSV file. First row is treated as header.
-// Sheets API omits trailing empty cells, so rows may have fewer columns than the header.
-// This function pads all rows to match the header length.
-func (w *SheetsImportWriter) writeCSV(path string, values [][]interface{}) error {
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	writer := csv.NewWriter(f)
-	defer writer.Flush()
-
-	// Determine max column count from header (first row)
-	numCols := 0
-	if len(values) > 0 {
-		numCols = len(values[0])
-	}
-
-	for _, row := range values {
-		record := make([]string, numCols)
-		for i := 0; i < numCols; i++ {
-			if i < len(row) {
-				record[i] = fmt.Sprintf("%v", row[i])
-			}
-			// else remains "" (empty string)
-		}
-		if err := writer.Write(record); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+)
+
+// openTestDB returns a migrated database in a temporary file with the
+// tenant t1.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "test.db"))
+	sqlDB, err := db.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.Migrate(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.NewTenantRepo(sqlDB).Create(context.Background(), &domain.Tenant{ID: "t1", Name: "t1", IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	return sqlDB
+}
@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/storage"
+)
+
+// Tracker datasets expose a tenant's tracked events and their aggregates.
+// Each is stored as the prefix holding its dt= partitions, so readers see
+// every date, with the partition date as the dt column.
+const (
+	trackerEventsDataset      = "tracker_events"
+	trackerEventCountsDataset = "tracker_event_counts_hourly"
+	trackerVisitsDataset      = "tracker_visits"
+)
+
+// trackerDatasetRegistrar keeps a tenant's tracker datasets registered as
+// its partitions are written.
+type trackerDatasetRegistrar struct {
+	datasets domain.DatasetRepository
+	// source returns the read_parquet source of the partitions stored
+	// under a prefix.
+	source func(storagePath string) string
+}
+
+func newTrackerDatasetRegistrar(datasets domain.DatasetRepository, minio *storage.MinIOClient) *trackerDatasetRegistrar {
+	return &trackerDatasetRegistrar{
+		datasets: datasets,
+		source: func(storagePath string) string {
+			return storage.S3ParquetSource(minio.S3Config().Bucket, storagePath, nil)
+		},
+	}
+}
+
+// register upserts the tracker dataset name stored under storagePath. Its
+// schema and row count are read from every partition, through duckDB,
+// which must have httpfs configured.
+func (r *trackerDatasetRegistrar) register(ctx context.Context, duckDB *sql.DB, tenantID, name, storagePath string) error {
+	if _, err := r.find(ctx, tenantID, name); err != nil {
+		return fmt.Errorf("register %s: %w", name, err)
+	}
+	source := r.source(storagePath)
+	rowCount, err := countRows(ctx, duckDB, source)
+	if err != nil {
+		return fmt.Errorf("register %s: count rows: %w", name, err)
+	}
+	view := "_" + name + "_dataset"
+	if _, err := duckDB.ExecContext(ctx, fmt.Sprintf(
+		`CREATE OR REPLACE VIEW %s AS SELECT * FROM read_parquet(%s, union_by_name = true, hive_partitioning = true)`,
+		view, source)); err != nil {
+		return fmt.Errorf("register %s: %w", name, err)
+	}
+	schemaJSON, err := ExtractEnrichedSchema(ctx, duckDB, view)
+	if err != nil {
+		return fmt.Errorf("register %s: extract schema: %w", name, err)
+	}
+
+	lastUpdated := time.Now().UTC()
+	if err := r.datasets.Upsert(ctx, &domain.Dataset{
+		ID:            uuid.New().String(),
+		TenantID:      tenantID,
+		Name:          name,
+		SourceType:    domain.SourceTypeTracker,
+		SchemaJSON:    &schemaJSON,
+		RowCount:      &rowCount,
+		StoragePath:   storagePath,
+		LastUpdatedAt: &lastUpdated,
+	}); err != nil {
+		return fmt.Errorf("register %s: %w", name, err)
+	}
+	return nil
+}
+
+// find returns the tracker dataset name, nil before it is first
+// registered. A dataset of another source holding the name is an error, so
+// that it is never overwritten.
+func (r *trackerDatasetRegistrar) find(ctx context.Context, tenantID, name string) (*domain.Dataset, error) {
+	ds, err := r.datasets.FindByName(ctx, tenantID, name)
+	if errors.Is(err, domain.ErrDatasetNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ds.SourceType != domain.SourceTypeTracker {
+		return nil, fmt.Errorf("dataset %s already exists with source type %s", name, ds.SourceType)
+	}
+	return ds, nil
+}
+
+// countRows counts the rows of every partition of source, read from the
+// Parquet footers.
+func countRows(ctx context.Context, duckDB *sql.DB, source string) (int64, error) {
+	var n int64
+	err := duckDB.QueryRowContext(ctx, fmt.Sprintf(
+		`SELECT COALESCE(SUM(num_rows), 0) FROM parquet_file_metadata(%s)`, source,
+	)).Scan(&n)
+	return n, err
+}
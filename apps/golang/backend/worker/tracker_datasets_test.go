@@ -0,0 +1,220 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/user/micro-dp/db"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/datasetquery"
+)
+
+// dirObjects is a bucket kept in a local directory, which DuckDB reads the
+// objects of directly.
+type dirObjects struct {
+	root string
+}
+
+func (d *dirObjects) PutParquet(_ context.Context, key string, data []byte) error {
+	path := filepath.Join(d.root, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (d *dirObjects) ListObjectKeys(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(d.root, func(path string, e fs.DirEntry, err error) error {
+		if err != nil || e.IsDir() {
+			return err
+		}
+		key, _ := filepath.Rel(d.root, path)
+		if key = filepath.ToSlash(key); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (d *dirObjects) RemoveObject(_ context.Context, key string) error {
+	err := os.Remove(filepath.Join(d.root, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// source is the read_parquet source of the partitions under a prefix.
+func (d *dirObjects) source(storagePath string) string {
+	return datasetquery.QuoteLiteral(filepath.Join(d.root, storagePath) + "/**/*.parquet")
+}
+
+// parquet returns the rows query selects as a Parquet file.
+func parquet(t *testing.T, duckDB *sql.DB, query string) []byte {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rows.parquet")
+	if _, err := duckDB.Exec("COPY (" + query + ") TO " + datasetquery.QuoteLiteral(path) + " (FORMAT PARQUET)"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// trackerFixture is a bucket, a DuckDB session reading it and a registrar
+// of tracker datasets over it.
+type trackerFixture struct {
+	objects  *dirObjects
+	duckDB   *sql.DB
+	datasets *db.DatasetRepo
+	tracker  *trackerDatasetRegistrar
+}
+
+func newTrackerFixture(t *testing.T) *trackerFixture {
+	t.Helper()
+	duckDB, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { duckDB.Close() })
+	objects := &dirObjects{root: t.TempDir()}
+	datasets := db.NewDatasetRepo(openTestDB(t))
+	return &trackerFixture{
+		objects:  objects,
+		duckDB:   duckDB,
+		datasets: datasets,
+		tracker:  &trackerDatasetRegistrar{datasets: datasets, source: objects.source},
+	}
+}
+
+// put writes the rows query selects to key.
+func (f *trackerFixture) put(t *testing.T, key, query string) {
+	t.Helper()
+	if err := f.objects.PutParquet(context.Background(), key, parquet(t, f.duckDB, query)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// registered returns the row count and column names of a registered dataset.
+func (f *trackerFixture) registered(t *testing.T, name string) (int64, []string) {
+	t.Helper()
+	ds, err := f.datasets.FindByName(context.Background(), "t1", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.SourceType != domain.SourceTypeTracker || ds.RowCount == nil || ds.SchemaJSON == nil {
+		t.Fatalf("dataset %s = %+v, want a tracker dataset with rows and schema", name, ds)
+	}
+	var cols []domain.DatasetColumnMeta
+	if err := json.Unmarshal([]byte(*ds.SchemaJSON), &cols); err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.Name
+	}
+	sort.Strings(names)
+	return *ds.RowCount, names
+}
+
+func TestTrackerDatasetRegister(t *testing.T) {
+	f := newTrackerFixture(t)
+	ctx := context.Background()
+
+	// The second date has a property the first lacks
+	f.put(t, "events/t1/dt=2026-01-01/a.parquet", `SELECT * FROM (VALUES ('view', 1), ('click', 2)) t(event, n)`)
+	f.put(t, "events/t1/dt=2026-01-02/b.parquet", `SELECT * FROM (VALUES ('view', 3, 'ja'), ('view', 4, 'en'), ('buy', 5, 'en')) t(event, n, locale)`)
+	if err := f.tracker.register(ctx, f.duckDB, "t1", trackerEventsDataset, "events/t1"); err != nil {
+		t.Fatal(err)
+	}
+	rows, cols := f.registered(t, trackerEventsDataset)
+	if rows != 5 {
+		t.Errorf("row count = %d, want 5", rows)
+	}
+	if want := []string{"dt", "event", "locale", "n"}; !reflect.DeepEqual(cols, want) {
+		t.Errorf("columns = %v, want %v", cols, want)
+	}
+
+	// A new date updates the same dataset
+	first, err := f.datasets.FindByName(ctx, "t1", trackerEventsDataset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.put(t, "events/t1/dt=2026-01-03/c.parquet", `SELECT 'view' AS event, 6 AS n`)
+	if err := f.tracker.register(ctx, f.duckDB, "t1", trackerEventsDataset, "events/t1"); err != nil {
+		t.Fatal(err)
+	}
+	if rows, _ := f.registered(t, trackerEventsDataset); rows != 6 {
+		t.Errorf("row count after a new date = %d, want 6", rows)
+	}
+	if again, _ := f.datasets.FindByName(ctx, "t1", trackerEventsDataset); again.ID != first.ID {
+		t.Errorf("dataset id = %s, want it kept as %s", again.ID, first.ID)
+	}
+}
+
+func TestTrackerDatasetRegisterKeepsOtherSources(t *testing.T) {
+	f := newTrackerFixture(t)
+	ctx := context.Background()
+	own := &domain.Dataset{ID: "d1", TenantID: "t1", Name: trackerEventsDataset, SourceType: domain.SourceTypeImport, StoragePath: "datasets/t1/mine.parquet"}
+	if err := f.datasets.Create(ctx, own); err != nil {
+		t.Fatal(err)
+	}
+
+	f.put(t, "events/t1/dt=2026-01-01/a.parquet", `SELECT 'view' AS event`)
+	if err := f.tracker.register(ctx, f.duckDB, "t1", trackerEventsDataset, "events/t1"); err == nil {
+		t.Fatal("register over an imported dataset succeeded")
+	}
+	ds, err := f.datasets.FindByName(ctx, "t1", trackerEventsDataset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.SourceType != domain.SourceTypeImport || ds.StoragePath != own.StoragePath {
+		t.Errorf("dataset = %s at %s, want the import left as it was", ds.SourceType, ds.StoragePath)
+	}
+}
+
+func TestReplacePartition(t *testing.T) {
+	f := newTrackerFixture(t)
+	ctx := context.Background()
+	root := "aggregated/visits/t1"
+	f.put(t, root+"/dt=2026-01-01/100.parquet", `SELECT * FROM (VALUES ('s1', 1)) t(session, pages)`)
+
+	// Aggregating 2026-01-02 twice leaves the second run's rows only
+	runs := []struct{ key, query string }{
+		{root + "/dt=2026-01-02/200.parquet", `SELECT * FROM (VALUES ('s2', 1), ('s3', 2)) t(session, pages)`},
+		{root + "/dt=2026-01-02/300.parquet", `SELECT * FROM (VALUES ('s2', 1), ('s3', 2), ('s4', 5)) t(session, pages)`},
+	}
+	for _, run := range runs {
+		if err := replacePartition(ctx, f.objects, run.key, parquet(t, f.duckDB, run.query)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys, err := f.objects.ListObjectKeys(ctx, root+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if want := []string{root + "/dt=2026-01-01/100.parquet", root + "/dt=2026-01-02/300.parquet"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("objects = %v, want %v", keys, want)
+	}
+
+	if err := f.tracker.register(ctx, f.duckDB, "t1", trackerVisitsDataset, root); err != nil {
+		t.Fatal(err)
+	}
+	if rows, _ := f.registered(t, trackerVisitsDataset); rows != 4 {
+		t.Errorf("row count = %d, want 4", rows)
+	}
+}
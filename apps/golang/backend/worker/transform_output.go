@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+)
+
+// writeOutput writes the _result table into the dataset msg.Output names,
+// creating it on the first run, so that every run records a new version
+// of one dataset. After the first run, the rows are conformed to the
+// dataset's schema except when overwriting. The result's OutputKey is the
+// object the run wrote.
+func (w *TransformWriter) writeOutput(ctx context.Context, duckDB *sql.DB, tmpDir string, msg *domain.TransformJobMessage) (*TransformResult, error) {
+	out := msg.Output
+	ds, err := w.datasets.FindByName(ctx, msg.TenantID, out.DatasetName)
+	switch {
+	case errors.Is(err, domain.ErrDatasetNotFound):
+		ds = &domain.Dataset{
+			ID:         uuid.New().String(),
+			TenantID:   msg.TenantID,
+			Name:       out.DatasetName,
+			SourceType: domain.SourceTypeTransform,
+		}
+	case err != nil:
+		return nil, fmt.Errorf("find output dataset: %w", err)
+	case ds.SourceType != domain.SourceTypeTransform:
+		return nil, fmt.Errorf("%w: dataset %q is not a transform output", domain.ErrInvalidTransformOutput, ds.Name)
+	}
+	cols, err := ds.ParseColumns()
+	if err != nil {
+		return nil, fmt.Errorf("parse dataset schema: %w", err)
+	}
+
+	var runRows int64
+	if err := duckDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM _result").Scan(&runRows); err != nil {
+		return nil, fmt.Errorf("count rows: %w", err)
+	}
+
+	table, err := outputTable(ctx, duckDB, out, ds, cols, func() (string, error) {
+		return currentRows(ctx, w.minio, duckDB, tmpDir, ds, cols)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dataset %q: %w", ds.Name, err)
+	}
+
+	// outputKey is the object written, and storagePath the dataset's data:
+	// the prefix an appended part is written under, otherwise the object
+	now := time.Now().UTC()
+	outputKey := transformObjectKey(msg.TenantID, msg.JobRunID, now)
+	storagePath := outputKey
+	var rowCount int64
+	schemaJSON := ""
+	if table == appendTable {
+		if storagePath, outputKey, err = appendPart(ctx, w.minio, duckDB, tmpDir, ds, msg.JobRunID, now); err != nil {
+			return nil, err
+		}
+		rowCount = runRows
+		if ds.RowCount != nil {
+			rowCount += *ds.RowCount
+		}
+	} else {
+		if err := duckDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&rowCount); err != nil {
+			return nil, fmt.Errorf("count rows: %w", err)
+		}
+		if schemaJSON, err = putTable(ctx, w.minio, duckDB, tmpDir, table, outputKey); err != nil {
+			return nil, err
+		}
+	}
+
+	// Appends keep the dataset's schema; the other modes refresh its statistics
+	if schemaJSON != "" {
+		ds.SchemaJSON = &schemaJSON
+	}
+	lastUpdated := now
+	ds.StoragePath = storagePath
+	ds.RowCount = &rowCount
+	ds.LastUpdatedAt = &lastUpdated
+	if err := w.datasets.Upsert(ctx, ds); err != nil {
+		return nil, fmt.Errorf("upsert dataset: %w", err)
+	}
+	if err := w.recorder.Record(ctx, msg.TenantID, ds.Name, msg.JobRunID); err != nil {
+		return nil, err
+	}
+	w.lineage.RecordTransform(ctx, msg, ds.Name)
+
+	return &TransformResult{
+		RowCount:  runRows,
+		OutputKey: outputKey,
+	}, nil
+}
+
+// appendTable holds the rows an append adds to the dataset as a new part.
+const appendTable = "incoming"
+
+// outputTable builds the table a run writes into ds and returns its name.
+// The first run, and a run overwriting the dataset, write the _result
+// table as is, but for a first merge, which still keeps the last row of
+// each key. Otherwise the rows are conformed to the dataset's columns
+// cols, then appended as appendTable, or combined with the current rows,
+// read by the query current returns, into the table merged.
+func outputTable(ctx context.Context, duckDB *sql.DB, out *domain.TransformOutput, ds *domain.Dataset, cols []domain.DatasetColumnMeta, current func() (string, error)) (string, error) {
+	firstRun := ds.StoragePath == "" || len(cols) == 0
+	if out.Mode == domain.TransformOutputModeOverwrite || (firstRun && out.Mode != domain.TransformOutputModeMerge) {
+		return "_result", nil
+	}
+	if firstRun {
+		if _, err := duckDB.ExecContext(ctx, "CREATE TABLE incoming AS SELECT * FROM _result"); err != nil {
+			return "", fmt.Errorf("copy result: %w", err)
+		}
+		return "merged", mergeByKeys(ctx, duckDB, out.KeyColumns, func() (string, error) {
+			return "SELECT * FROM incoming LIMIT 0", nil
+		})
+	}
+	if err := conformTable(ctx, duckDB, "_result", "incoming", cols); err != nil {
+		return "", err
+	}
+	switch out.Mode {
+	case domain.TransformOutputModeAppend:
+		return appendTable, nil
+	case domain.TransformOutputModeMerge:
+		return "merged", mergeByKeys(ctx, duckDB, out.KeyColumns, current)
+	case domain.TransformOutputModeReplacePartitions:
+		return "merged", replacePartitions(ctx, duckDB, out.PartitionColumn, current)
+	default:
+		return "", fmt.Errorf("%w: unknown mode %q", domain.ErrInvalidTransformOutput, out.Mode)
+	}
+}
+
+// replacePartitions builds the table merged from the incoming rows and the
+// dataset's current rows, read by the query current returns, whose
+// partition column value the incoming rows do not have.
+func replacePartitions(ctx context.Context, duckDB *sql.DB, column string, current func() (string, error)) error {
+	currentQuery, err := current()
+	if err != nil {
+		return err
+	}
+	col := quoteDuckDBIdent(column)
+	_, err = duckDB.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE merged AS SELECT c.* FROM (%s) c WHERE NOT EXISTS (SELECT 1 FROM incoming i WHERE i.%s IS NOT DISTINCT FROM c.%s) UNION ALL SELECT * FROM incoming",
+		currentQuery, col, col))
+	if err != nil {
+		return fmt.Errorf("replace partitions: %w", err)
+	}
+	return nil
+}
+
+// transformObjectKey is where a transform run's Parquet output is stored.
+func transformObjectKey(tenantID, jobRunID string, now time.Time) string {
+	return fmt.Sprintf("transforms/%s/dt=%s/%s.parquet", tenantID, now.Format("2006-01-02"), jobRunID)
+}
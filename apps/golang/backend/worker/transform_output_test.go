@@ -0,0 +1,184 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/schemadrift"
+)
+
+// currentOrders are the rows of the output dataset before the run.
+const currentOrders = `SELECT * FROM (VALUES (1, 'a', DATE '2026-01-01'), (2, 'b', DATE '2026-01-02')) t(id, v, day)`
+
+// openOutput returns a DuckDB session holding the run's result, and the
+// output dataset with its current rows in a Parquet fixture, or none when
+// current is empty.
+func openOutput(t *testing.T, result, current string) (*sql.DB, *domain.Dataset, []domain.DatasetColumnMeta, func() (string, error)) {
+	t.Helper()
+	ctx := context.Background()
+	duckDB, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { duckDB.Close() })
+	if _, err := duckDB.ExecContext(ctx, "CREATE TABLE _result AS "+result); err != nil {
+		t.Fatal(err)
+	}
+
+	ds := &domain.Dataset{ID: "d1", TenantID: "t1", Name: "orders", SourceType: domain.SourceTypeTransform}
+	if current == "" {
+		return duckDB, ds, nil, nil
+	}
+	path := filepath.Join(t.TempDir(), "current.parquet")
+	if _, err := duckDB.ExecContext(ctx, fmt.Sprintf("COPY (%s) TO '%s' (FORMAT PARQUET)", current, path)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := duckDB.ExecContext(ctx, fmt.Sprintf("CREATE VIEW fixture AS SELECT * FROM read_parquet('%s')", path)); err != nil {
+		t.Fatal(err)
+	}
+	cols, err := describeTable(ctx, duckDB, "fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds.StoragePath = "transforms/t1/dt=2026-01-02/run1.parquet"
+	return duckDB, ds, cols, func() (string, error) { return readRows(ctx, duckDB, path, cols) }
+}
+
+func TestOutputTable(t *testing.T) {
+	merge := &domain.TransformOutput{Mode: domain.TransformOutputModeMerge, KeyColumns: []string{"id"}}
+	partitions := &domain.TransformOutput{Mode: domain.TransformOutputModeReplacePartitions, PartitionColumn: "day"}
+	overwrite := &domain.TransformOutput{Mode: domain.TransformOutputModeOverwrite}
+	appendRows := &domain.TransformOutput{Mode: domain.TransformOutputModeAppend}
+
+	tests := []struct {
+		name      string
+		out       *domain.TransformOutput
+		current   string
+		result    string
+		wantTable string
+		want      []string
+		wantErr   error
+	}{
+		// First run: the dataset takes the result as is, deduplicated on merge keys
+		{
+			name: "overwrite first run", out: overwrite,
+			result:    `SELECT 1 AS id, 'x' AS v`,
+			wantTable: "_result", want: []string{"1|x"},
+		},
+		{
+			name: "append first run", out: appendRows,
+			result:    `SELECT * FROM (VALUES (1, 'x'), (1, 'y')) t(id, v)`,
+			wantTable: "_result", want: []string{"1|x", "1|y"},
+		},
+		{
+			name: "merge first run keeps the last row of a repeated key", out: merge,
+			result:    `SELECT * FROM (VALUES (1, 'x'), (1, 'y'), (2, 'z')) t(id, v)`,
+			wantTable: "merged", want: []string{"1|y", "2|z"},
+		},
+		{
+			name: "merge first run NULL key", out: merge,
+			result:  `SELECT * FROM (VALUES (1, 'x'), (NULL, 'y')) t(id, v)`,
+			wantErr: schemadrift.ErrIncompatible,
+		},
+		{
+			name: "replace_partitions first run", out: partitions,
+			result:    `SELECT 1 AS id, 'x' AS v, DATE '2026-01-02' AS day`,
+			wantTable: "_result", want: []string{"1|x|2026-01-02"},
+		},
+
+		// Overwrite replaces the rows and schema
+		{
+			name: "overwrite changed schema", out: overwrite, current: currentOrders,
+			result:    `SELECT * FROM (VALUES ('k', 5), ('k', 6)) t(id, n)`,
+			wantTable: "_result", want: []string{"k|5", "k|6"},
+		},
+
+		// Append adds only the run's rows, conformed to the dataset
+		{
+			name: "append repeated key", out: appendRows, current: currentOrders,
+			result:    `SELECT 1 AS id, 'x' AS v, DATE '2026-01-03' AS day`,
+			wantTable: appendTable, want: []string{"1|x|2026-01-03"},
+		},
+		{
+			name: "append NULL key", out: appendRows, current: currentOrders,
+			result:    `SELECT NULL::INTEGER AS id, 'x' AS v, DATE '2026-01-03' AS day`,
+			wantTable: appendTable, want: []string{"NULL|x|2026-01-03"},
+		},
+		{
+			name: "append missing column", out: appendRows, current: currentOrders,
+			result:    `SELECT 3 AS id, 'c' AS v`,
+			wantTable: appendTable, want: []string{"3|c|NULL"},
+		},
+		{
+			name: "append extra column", out: appendRows, current: currentOrders,
+			result:  `SELECT 3 AS id, 'c' AS v, DATE '2026-01-03' AS day, 1 AS extra`,
+			wantErr: schemadrift.ErrIncompatible,
+		},
+
+		// Merge replaces the rows of the result's keys
+		{
+			name: "merge repeated key", out: merge, current: currentOrders,
+			result:    `SELECT * FROM (VALUES (1, 'x', DATE '2026-01-03'), (1, 'y', DATE '2026-01-03'), (3, 'z', DATE '2026-01-03')) t(id, v, day)`,
+			wantTable: "merged", want: []string{"1|y|2026-01-03", "2|b|2026-01-02", "3|z|2026-01-03"},
+		},
+		{
+			name: "merge NULL key", out: merge, current: currentOrders,
+			result:  `SELECT NULL::INTEGER AS id, 'x' AS v, DATE '2026-01-03' AS day`,
+			wantErr: schemadrift.ErrIncompatible,
+		},
+		{
+			name: "merge widened column", out: merge, current: currentOrders,
+			result:    `SELECT 2::TINYINT AS id, 'x' AS v, '2026-01-05' AS day`,
+			wantTable: "merged", want: []string{"1|a|2026-01-01", "2|x|2026-01-05"},
+		},
+		{
+			name: "merge uncastable column", out: merge, current: currentOrders,
+			result:  `SELECT 'two' AS id, 'x' AS v, DATE '2026-01-03' AS day`,
+			wantErr: schemadrift.ErrIncompatible,
+		},
+
+		// Replace partitions replaces the rows of the result's partitions
+		{
+			name: "replace_partitions repeated partition", out: partitions, current: currentOrders,
+			result:    `SELECT * FROM (VALUES (3, 'x', DATE '2026-01-02'), (4, 'y', DATE '2026-01-02')) t(id, v, day)`,
+			wantTable: "merged", want: []string{"1|a|2026-01-01", "3|x|2026-01-02", "4|y|2026-01-02"},
+		},
+		{
+			name: "replace_partitions NULL partition", out: partitions, current: currentOrders,
+			result:    `SELECT 3 AS id, 'x' AS v, NULL::DATE AS day`,
+			wantTable: "merged", want: []string{"1|a|2026-01-01", "2|b|2026-01-02", "3|x|NULL"},
+		},
+		{
+			name: "replace_partitions uncastable partition", out: partitions, current: currentOrders,
+			result:  `SELECT 3 AS id, 'x' AS v, 'yesterday' AS day`,
+			wantErr: schemadrift.ErrIncompatible,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duckDB, ds, cols, current := openOutput(t, tt.result, tt.current)
+			table, err := outputTable(context.Background(), duckDB, tt.out, ds, cols, current)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("outputTable = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if table != tt.wantTable {
+				t.Errorf("table = %s, want %s", table, tt.wantTable)
+			}
+			if got := tableRows(t, duckDB, table); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
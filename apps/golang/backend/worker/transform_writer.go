@@ -12,7 +12,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/datasetquery"
+	"github.com/user/micro-dp/internal/duckdbsandbox"
 	"github.com/user/micro-dp/storage"
+	"github.com/user/micro-dp/usecase"
 )
 
 type TransformResult struct {
@@ -23,10 +26,21 @@ type TransformResult struct {
 type TransformWriter struct {
 	minio    *storage.MinIOClient
 	datasets domain.DatasetRepository
+	versions domain.DatasetVersionRepository
+	recorder *datasetVersionRecorder
+	lineage  *lineageRecorder
+	masking  *usecase.DatasetMaskingService
 }
 
-func NewTransformWriter(minio *storage.MinIOClient, datasets domain.DatasetRepository) *TransformWriter {
-	return &TransformWriter{minio: minio, datasets: datasets}
+func NewTransformWriter(minio *storage.MinIOClient, datasets domain.DatasetRepository, versions domain.DatasetVersionRepository, lineage domain.LineageRepository, quality *usecase.DataQualityService, masking *usecase.DatasetMaskingService) *TransformWriter {
+	return &TransformWriter{
+		minio:    minio,
+		datasets: datasets,
+		versions: versions,
+		masking:  masking,
+		recorder: &datasetVersionRecorder{datasets: datasets, versions: versions, minio: minio, quality: quality},
+		lineage:  &lineageRecorder{datasets: datasets, edges: lineage},
+	}
 }
 
 func (w *TransformWriter) Execute(ctx context.Context, msg *domain.TransformJobMessage) (*TransformResult, error) {
@@ -54,26 +68,66 @@ func (w *TransformWriter) Execute(ctx context.Context, msg *domain.TransformJobM
 	}
 	defer duckDB.Close()
 
-	// Configure httpfs for direct S3/MinIO reads
+	// Configure httpfs for direct S3/MinIO reads of the tenant's objects
 	s3Cfg := w.minio.S3Config()
-	if err := storage.ConfigureDuckDBHTTPFS(ctx, duckDB, s3Cfg); err != nil {
+	if err := storage.ConfigureDuckDBTenantS3(ctx, duckDB, s3Cfg, msg.TenantID); err != nil {
 		return nil, fmt.Errorf("configure httpfs: %w", err)
 	}
 
-	// Register each dataset as a VIEW reading directly from S3
+	// Register each dataset as a VIEW reading directly from S3, at its
+	// pinned version if any, with the columns masked for the user who
+	// wrote the transform
+	maskCtx := domain.ContextWithTenantID(ctx, msg.TenantID)
+	if msg.UserID != "" {
+		maskCtx = domain.ContextWithUserID(maskCtx, msg.UserID)
+	}
 	for _, ds := range datasets {
-		uri := storage.S3ParquetURI(s3Cfg.Bucket, ds.StoragePath)
-		viewSQL := fmt.Sprintf(`CREATE VIEW "%s" AS SELECT * FROM read_parquet('%s')`, ds.Name, uri)
-		if _, err := duckDB.ExecContext(ctx, viewSQL); err != nil {
-			return nil, fmt.Errorf("create view %s: %w", ds.Name, err)
+		source := storage.S3ParquetSource(s3Cfg.Bucket, ds.StoragePath, nil)
+		if n, ok := msg.DatasetVersions[ds.ID]; ok {
+			v, err := w.versions.FindByVersion(ctx, msg.TenantID, ds.ID, n)
+			if err != nil {
+				return nil, fmt.Errorf("dataset %s version %d: %w", ds.Name, n, err)
+			}
+			keys, err := v.ObjectKeys()
+			if err != nil {
+				return nil, fmt.Errorf("dataset %s version %d: %w", ds.Name, n, err)
+			}
+			source = storage.S3ParquetSource(s3Cfg.Bucket, v.StoragePath, keys)
+		}
+		from := fmt.Sprintf("read_parquet(%s)", source)
+		masks, err := w.masking.Masks(maskCtx, ds.ID)
+		if err == nil && len(masks) > 0 {
+			from, err = datasetquery.From(ctx, duckDB, source, masks)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("dataset %s masking: %w", ds.Name, err)
+		}
+		if err := duckdbsandbox.CreateView(ctx, duckDB, ds.Name, from); err != nil {
+			return nil, err
 		}
 	}
 
+	// Only a single SELECT over the views runs. Local files stay enabled for
+	// the output COPY, but the check keeps the user SQL from reading them
+	views := make([]string, len(datasets))
+	for i, ds := range datasets {
+		views[i] = ds.Name
+	}
+	if err := duckdbsandbox.CheckSelect(ctx, duckDB, msg.SQL, views); err != nil {
+		return nil, err
+	}
+	if err := duckdbsandbox.Lock(ctx, duckDB, true); err != nil {
+		return nil, err
+	}
+
 	// Execute user SQL
-	createResult := fmt.Sprintf("CREATE TABLE _result AS SELECT * FROM (%s) AS _q", msg.SQL)
+	createResult := fmt.Sprintf("CREATE TABLE _result AS SELECT * FROM (\n%s\n) AS _q", msg.SQL)
 	if _, err := duckDB.ExecContext(ctx, createResult); err != nil {
 		return nil, fmt.Errorf("execute sql: %w", err)
 	}
+	if msg.Output != nil {
+		return w.writeOutput(ctx, duckDB, tmpDir, msg)
+	}
 
 	// Get schema
 	schemaJSON, err := ExtractEnrichedSchema(ctx, duckDB, "_result")
@@ -100,11 +154,7 @@ func (w *TransformWriter) Execute(ctx context.Context, msg *domain.TransformJobM
 	}
 
 	now := time.Now().UTC()
-	outputKey := fmt.Sprintf("transforms/%s/dt=%s/%s.parquet",
-		msg.TenantID,
-		now.Format("2006-01-02"),
-		msg.JobRunID,
-	)
+	outputKey := transformObjectKey(msg.TenantID, msg.JobRunID, now)
 	if err := w.minio.PutParquet(ctx, outputKey, data); err != nil {
 		return nil, fmt.Errorf("upload parquet: %w", err)
 	}
@@ -125,6 +175,10 @@ func (w *TransformWriter) Execute(ctx context.Context, msg *domain.TransformJobM
 	if err := w.datasets.Upsert(ctx, dataset); err != nil {
 		return nil, fmt.Errorf("upsert dataset: %w", err)
 	}
+	if err := w.recorder.Record(ctx, msg.TenantID, datasetName, msg.JobRunID); err != nil {
+		return nil, err
+	}
+	w.lineage.RecordTransform(ctx, msg, datasetName)
 
 	return &TransformResult{
 		RowCount:  rowCount,
@@ -2,27 +2,32 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
-	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/fileconv"
 	"github.com/user/micro-dp/internal/observability"
 	"github.com/user/micro-dp/usecase"
 )
 
 type UploadConsumer struct {
 	queue    domain.UploadJobQueue
-	writer   *CSVImportWriter
+	uploads  domain.UploadRepository
+	fetcher  *UploadFetcher
+	writer   *UploadImportWriter
 	metrics  *observability.UploadMetrics
 	metering *usecase.MeteringService
 }
 
-func NewUploadConsumer(queue domain.UploadJobQueue, writer *CSVImportWriter, metrics *observability.UploadMetrics, metering *usecase.MeteringService) *UploadConsumer {
+func NewUploadConsumer(queue domain.UploadJobQueue, uploads domain.UploadRepository, fetcher *UploadFetcher, writer *UploadImportWriter, metrics *observability.UploadMetrics, metering *usecase.MeteringService) *UploadConsumer {
 	return &UploadConsumer{
 		queue:    queue,
+		uploads:  uploads,
+		fetcher:  fetcher,
 		writer:   writer,
 		metrics:  metrics,
 		metering: metering,
@@ -60,13 +65,17 @@ func (c *UploadConsumer) processMessage(ctx context.Context, msg *domain.UploadJ
 	start := time.Now()
 
 	// Idempotency check
-	if err := c.queue.MarkProcessed(ctx, msg.UploadID); err != nil {
+	jobID := msg.JobID
+	if jobID == "" {
+		jobID = msg.UploadID
+	}
+	if err := c.queue.MarkProcessed(ctx, jobID); err != nil {
 		if errors.Is(err, domain.ErrUploadAlreadyProcessed) {
-			log.Printf("csv import: skipping duplicate upload_id=%s", msg.UploadID)
+			log.Printf("upload import: skipping duplicate upload_id=%s job_id=%s", msg.UploadID, jobID)
 			c.metrics.DuplicateTotal.Add(ctx, 1)
 			return
 		}
-		log.Printf("csv import: mark processed error upload_id=%s: %v", msg.UploadID, err)
+		log.Printf("upload import: mark processed error upload_id=%s: %v", msg.UploadID, err)
 		c.enqueueDLQ(ctx, msg, err.Error())
 		return
 	}
@@ -75,23 +84,33 @@ func (c *UploadConsumer) processMessage(ctx context.Context, msg *domain.UploadJ
 	var filesConverted int64
 	var lastErr error
 
-	for _, file := range msg.Files {
-		if !isCSV(file.FileName) {
-			log.Printf("csv import: skipping non-csv file=%s upload_id=%s", file.FileName, msg.UploadID)
-			continue
+	for i := range msg.Files {
+		file := msg.Files[i]
+		if err := c.uploads.UpdateFileStarted(ctx, msg.TenantID, file.FileID); err != nil {
+			log.Printf("upload import: update file started error file_id=%s upload_id=%s: %v", file.FileID, msg.UploadID, err)
 		}
 
-		result, err := c.writer.ProcessFile(ctx, msg.TenantID, file)
+		results, err := c.processFile(ctx, msg, &msg.Files[i])
+		file = msg.Files[i]
 		if err != nil {
-			log.Printf("csv import: process file error file=%s upload_id=%s: %v", file.FileName, msg.UploadID, err)
+			if errors.Is(err, fileconv.ErrUnsupportedFormat) || errors.Is(err, fileconv.ErrNoData) {
+				log.Printf("upload import: skipping file=%s upload_id=%s: %v", file.FileName, msg.UploadID, err)
+				c.recordFileResult(ctx, msg, file, domain.UploadFileStatusSkipped, err, nil)
+				continue
+			}
+			log.Printf("upload import: process file error file=%s upload_id=%s: %v", file.FileName, msg.UploadID, err)
+			c.recordFileResult(ctx, msg, file, domain.UploadFileStatusFailed, err, nil)
 			lastErr = err
 			continue
 		}
 
 		filesConverted++
-		totalRows += result.RowCount
-		log.Printf("csv import: converted file=%s rows=%d output=%s upload_id=%s",
-			file.FileName, result.RowCount, result.OutputKey, msg.UploadID)
+		for _, result := range results {
+			totalRows += result.RowCount
+			log.Printf("upload import: converted file=%s dataset=%q rows=%d output=%s upload_id=%s",
+				file.FileName, result.DatasetName, result.RowCount, result.OutputKey, msg.UploadID)
+		}
+		c.recordFileResult(ctx, msg, file, domain.UploadFileStatusConverted, nil, results)
 	}
 
 	if lastErr != nil {
@@ -116,12 +135,56 @@ func (c *UploadConsumer) processMessage(ctx context.Context, msg *domain.UploadJ
 	c.metrics.Duration.Record(ctx, time.Since(start).Seconds())
 }
 
-func (c *UploadConsumer) enqueueDLQ(ctx context.Context, msg *domain.UploadJobMessage, reason string) {
-	if err := c.queue.EnqueueDLQ(ctx, msg, reason); err != nil {
-		log.Printf("csv import: enqueue dlq error upload_id=%s: %v", msg.UploadID, err)
+// processFile converts one file of a job, first downloading it when it is
+// imported from a URL. file is updated with the fetched object.
+func (c *UploadConsumer) processFile(ctx context.Context, msg *domain.UploadJobMessage, file *domain.UploadJobFile) ([]*ImportResult, error) {
+	if file.Fetch {
+		fetched, err := c.fetcher.Fetch(ctx, msg.TenantID, msg.UploadID, *file)
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", file.FileName, err)
+		}
+		*file = fetched
+		log.Printf("upload import: fetched file=%s size=%d content_type=%s upload_id=%s",
+			file.FileName, file.SizeBytes, file.ContentType, msg.UploadID)
 	}
+	return c.writer.ProcessFile(ctx, msg.TenantID, *file)
 }
 
-func isCSV(filename string) bool {
-	return strings.EqualFold(filepath.Ext(filename), ".csv")
+// recordFileResult stores the outcome of converting one file.
+func (c *UploadConsumer) recordFileResult(ctx context.Context, msg *domain.UploadJobMessage, file domain.UploadJobFile, status string, procErr error, results []*ImportResult) {
+	f := &domain.UploadFile{
+		ID:       file.FileID,
+		TenantID: msg.TenantID,
+		Status:   status,
+	}
+	if procErr != nil {
+		errMsg := procErr.Error()
+		f.ErrorMessage = &errMsg
+	}
+	if len(results) > 0 {
+		var rowCount int64
+		datasets := make([]domain.UploadFileDataset, len(results))
+		for i, r := range results {
+			rowCount += r.RowCount
+			datasets[i] = domain.UploadFileDataset{DatasetID: r.DatasetID, DatasetName: r.DatasetName, RowCount: r.RowCount}
+		}
+		data, err := json.Marshal(datasets)
+		if err != nil {
+			log.Printf("upload import: marshal datasets error file_id=%s: %v", file.FileID, err)
+		} else {
+			datasetsJSON := string(data)
+			f.DatasetsJSON = &datasetsJSON
+		}
+		f.RowCount = &rowCount
+		f.DatasetID = &datasets[0].DatasetID
+	}
+	if err := c.uploads.UpdateFileResult(ctx, f); err != nil {
+		log.Printf("upload import: update file result error file_id=%s upload_id=%s: %v", file.FileID, msg.UploadID, err)
+	}
+}
+
+func (c *UploadConsumer) enqueueDLQ(ctx context.Context, msg *domain.UploadJobMessage, reason string) {
+	if err := c.queue.EnqueueDLQ(ctx, msg, reason); err != nil {
+		log.Printf("upload import: enqueue dlq error upload_id=%s: %v", msg.UploadID, err)
+	}
 }
@@ -0,0 +1,141 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/fileconv"
+	"github.com/user/micro-dp/internal/secret"
+	"github.com/user/micro-dp/internal/urlfetch"
+	"github.com/user/micro-dp/storage"
+)
+
+// UploadFetcher downloads URL-imported files into object storage, where
+// the upload consumer converts them like uploaded files.
+type UploadFetcher struct {
+	uploads domain.UploadRepository
+	fetches domain.UploadFetchRepository
+	minio   *storage.MinIOClient
+	keyring *secret.Keyring
+	client  *urlfetch.Fetcher
+}
+
+func NewUploadFetcher(
+	uploads domain.UploadRepository,
+	fetches domain.UploadFetchRepository,
+	minio *storage.MinIOClient,
+	keyring *secret.Keyring,
+	client *urlfetch.Fetcher,
+) *UploadFetcher {
+	return &UploadFetcher{
+		uploads: uploads,
+		fetches: fetches,
+		minio:   minio,
+		keyring: keyring,
+		client:  client,
+	}
+}
+
+// Fetch downloads a file from its source URL and returns the job file
+// updated with the stored object. A file whose format cannot be told from
+// its name is named after the sniffed content type.
+func (f *UploadFetcher) Fetch(ctx context.Context, tenantID, uploadID string, file domain.UploadJobFile) (domain.UploadJobFile, error) {
+	fetch, err := f.fetches.FindByFileID(ctx, tenantID, file.FileID)
+	if err != nil {
+		return file, fmt.Errorf("find upload fetch: %w", err)
+	}
+	uf, err := f.uploads.FindFileByID(ctx, tenantID, uploadID, file.FileID)
+	if err != nil {
+		return file, fmt.Errorf("find upload file: %w", err)
+	}
+	if uf.SourceURL == nil {
+		return file, fmt.Errorf("upload file %s has no source url", file.FileID)
+	}
+
+	req := urlfetch.Request{URL: *uf.SourceURL, MaxBytes: fetch.MaxSizeBytes}
+	if fetch.AuthSecret != "" {
+		if req.Auth, err = f.openAuth(fetch); err != nil {
+			return file, err
+		}
+	}
+	if fetch.Checksum != nil {
+		if req.Checksum, err = urlfetch.ParseChecksum(*fetch.Checksum); err != nil {
+			return file, err
+		}
+	}
+
+	d, err := f.client.Open(ctx, req)
+	if err != nil {
+		return file, err
+	}
+	defer d.Close()
+
+	ext := strings.ToLower(filepath.Ext(file.ObjectKey))
+	if ext == "" {
+		ext = urlfetch.Extension(d.ContentType)
+		if ext == "" {
+			return file, fmt.Errorf("%w: %s", fileconv.ErrUnsupportedFormat, d.ContentType)
+		}
+		file.ObjectKey += ext
+		if strings.EqualFold(filepath.Ext(d.FileName), ext) {
+			file.FileName = d.FileName
+		} else {
+			file.FileName += ext
+		}
+	}
+
+	if _, err := f.minio.PutStream(ctx, file.ObjectKey, d, d.Size, d.ContentType); err != nil {
+		f.remove(ctx, file.ObjectKey)
+		return file, err
+	}
+	// The store stops reading at the announced size; check the rest.
+	if err := d.Verify(); err != nil {
+		f.remove(ctx, file.ObjectKey)
+		return file, err
+	}
+
+	file.ContentType = d.ContentType
+	file.SizeBytes = d.BytesRead()
+	file.Fetch = false
+	if err := f.uploads.UpdateFileFetched(ctx, &domain.UploadFile{
+		ID:          file.FileID,
+		TenantID:    tenantID,
+		FileName:    file.FileName,
+		ObjectKey:   file.ObjectKey,
+		ContentType: file.ContentType,
+		SizeBytes:   file.SizeBytes,
+	}); err != nil {
+		return file, fmt.Errorf("update upload file: %w", err)
+	}
+	if err := f.fetches.ClearAuth(ctx, tenantID, file.FileID); err != nil {
+		log.Printf("upload fetch: clear auth error file_id=%s: %v", file.FileID, err)
+	}
+	return file, nil
+}
+
+func (f *UploadFetcher) openAuth(fetch *domain.UploadFetch) (*urlfetch.Auth, error) {
+	env, err := f.keyring.OpenEnvelope(fetch.DataKey)
+	if err != nil {
+		return nil, fmt.Errorf("open fetch credentials: %w", err)
+	}
+	raw, err := env.Open(fetch.AuthSecret)
+	if err != nil {
+		return nil, fmt.Errorf("open fetch credentials: %w", err)
+	}
+	var auth urlfetch.Auth
+	if err := json.Unmarshal([]byte(raw), &auth); err != nil {
+		return nil, fmt.Errorf("decode fetch credentials: %w", err)
+	}
+	return &auth, nil
+}
+
+func (f *UploadFetcher) remove(ctx context.Context, objectKey string) {
+	if err := f.minio.RemoveObject(ctx, objectKey); err != nil {
+		log.Printf("upload fetch: remove object error key=%s: %v", objectKey, err)
+	}
+}
@@ -0,0 +1,176 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/marcboeker/go-duckdb"
+
+	"github.com/google/uuid"
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/fileconv"
+	"github.com/user/micro-dp/storage"
+	"github.com/user/micro-dp/usecase"
+)
+
+// ImportResult describes one dataset written from an uploaded file.
+type ImportResult struct {
+	DatasetID   string
+	DatasetName string
+	RowCount    int64
+	SchemaJSON  string
+	OutputKey   string
+}
+
+type UploadImportWriter struct {
+	minio    *storage.MinIOClient
+	datasets domain.DatasetRepository
+	versions *datasetVersionRecorder
+}
+
+func NewUploadImportWriter(minio *storage.MinIOClient, datasets domain.DatasetRepository, versions domain.DatasetVersionRepository, quality *usecase.DataQualityService) *UploadImportWriter {
+	return &UploadImportWriter{
+		minio:    minio,
+		datasets: datasets,
+		versions: &datasetVersionRecorder{datasets: datasets, versions: versions, minio: minio, quality: quality},
+	}
+}
+
+// ProcessFile converts an uploaded file into one or more Parquet datasets.
+// Most formats produce a single dataset named after the file; zip archives
+// and Excel workbooks produce one per member or sheet. A file with a target
+// is written into that existing dataset instead.
+func (w *UploadImportWriter) ProcessFile(ctx context.Context, tenantID string, file domain.UploadJobFile) ([]*ImportResult, error) {
+	tmpDir, err := os.MkdirTemp("", "micro-dp-upload-import-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Download the upload from MinIO
+	ext := strings.ToLower(filepath.Ext(file.FileName))
+	inputPath := filepath.Join(tmpDir, "input"+ext)
+	if err := w.minio.DownloadToFile(ctx, file.ObjectKey, inputPath); err != nil {
+		return nil, fmt.Errorf("download upload: %w", err)
+	}
+
+	// Open DuckDB in-memory
+	duckDB, err := sql.Open("duckdb", "")
+	if err != nil {
+		return nil, fmt.Errorf("open duckdb: %w", err)
+	}
+	defer duckDB.Close()
+
+	var opts *fileconv.Options
+	if len(file.ParseOptions) > 0 {
+		if err := json.Unmarshal(file.ParseOptions, &opts); err != nil {
+			return nil, fmt.Errorf("parse options: %w", err)
+		}
+	}
+
+	// Stage every table of the file before writing any dataset
+	conv := fileconv.NewConverter(duckDB, tmpDir, opts)
+	if err := conv.Convert(ctx, inputPath, file.FileName, fileconv.DatasetBaseName(file.FileName)); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	tables := conv.Tables()
+	if file.Target != nil {
+		if len(tables) != 1 {
+			return nil, fmt.Errorf("a file written into an existing dataset must hold exactly one table, got %d", len(tables))
+		}
+		result, err := w.writeTarget(ctx, duckDB, tmpDir, tenantID, file, tables[0], now)
+		if err != nil {
+			return nil, err
+		}
+		return []*ImportResult{result}, nil
+	}
+
+	results := make([]*ImportResult, 0, len(tables))
+	for i, t := range tables {
+		result, err := w.writeTable(ctx, duckDB, tmpDir, tenantID, file.FileID, t, i, now)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.DatasetName, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (w *UploadImportWriter) writeTable(ctx context.Context, duckDB *sql.DB, tmpDir, tenantID, fileID string, t fileconv.Table, idx int, now time.Time) (*ImportResult, error) {
+	// Extract schema
+	schemaJSON, err := ExtractEnrichedSchema(ctx, duckDB, t.Name)
+	if err != nil {
+		return nil, fmt.Errorf("extract schema: %w", err)
+	}
+
+	// Count rows
+	var rowCount int64
+	if err := duckDB.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", t.Name)).Scan(&rowCount); err != nil {
+		return nil, fmt.Errorf("count rows: %w", err)
+	}
+
+	// Export to Parquet; Parquet uploads are stored unchanged
+	parquetPath := t.ParquetPath
+	if parquetPath == "" {
+		parquetPath = filepath.Join(tmpDir, t.Name+".parquet")
+		_, err = duckDB.ExecContext(ctx, fmt.Sprintf("COPY %s TO '%s' (FORMAT PARQUET)", t.Name, parquetPath))
+		if err != nil {
+			return nil, fmt.Errorf("copy to parquet: %w", err)
+		}
+	}
+
+	// Upload Parquet to MinIO
+	data, err := os.ReadFile(parquetPath)
+	if err != nil {
+		return nil, fmt.Errorf("read parquet: %w", err)
+	}
+
+	objectName := fileID
+	if idx > 0 {
+		objectName = fmt.Sprintf("%s-%d", fileID, idx)
+	}
+	outputKey := importObjectKey(tenantID, objectName, now)
+	if err := w.minio.PutParquet(ctx, outputKey, data); err != nil {
+		return nil, fmt.Errorf("upload parquet: %w", err)
+	}
+
+	// Upsert dataset
+	lastUpdated := now
+	dataset := &domain.Dataset{
+		ID:            uuid.New().String(),
+		TenantID:      tenantID,
+		Name:          t.DatasetName,
+		SourceType:    domain.SourceTypeImport,
+		SchemaJSON:    &schemaJSON,
+		RowCount:      &rowCount,
+		StoragePath:   outputKey,
+		LastUpdatedAt: &lastUpdated,
+	}
+	if err := w.datasets.Upsert(ctx, dataset); err != nil {
+		return nil, fmt.Errorf("upsert dataset: %w", err)
+	}
+	if err := w.versions.Record(ctx, tenantID, t.DatasetName, ""); err != nil {
+		return nil, err
+	}
+	// An existing dataset keeps its ID
+	stored, err := w.datasets.FindByName(ctx, tenantID, t.DatasetName)
+	if err != nil {
+		return nil, fmt.Errorf("find dataset: %w", err)
+	}
+
+	return &ImportResult{
+		DatasetID:   stored.ID,
+		DatasetName: t.DatasetName,
+		RowCount:    rowCount,
+		SchemaJSON:  schemaJSON,
+		OutputKey:   outputKey,
+	}, nil
+}
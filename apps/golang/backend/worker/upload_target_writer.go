@@ -0,0 +1,307 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/fileconv"
+	"github.com/user/micro-dp/internal/schemadrift"
+	"github.com/user/micro-dp/storage"
+)
+
+// writeTarget writes a staged table into the existing dataset named by
+// file.Target. The rows are first conformed to the dataset's schema, so
+// every Parquet object of the dataset keeps the same columns and types.
+func (w *UploadImportWriter) writeTarget(ctx context.Context, duckDB *sql.DB, tmpDir, tenantID string, file domain.UploadJobFile, t fileconv.Table, now time.Time) (*ImportResult, error) {
+	target := file.Target
+	ds, err := w.datasets.FindByID(ctx, tenantID, target.DatasetID)
+	if err != nil {
+		return nil, fmt.Errorf("find target dataset: %w", err)
+	}
+	cols, err := ds.ParseColumns()
+	if err != nil {
+		return nil, fmt.Errorf("parse dataset schema: %w", err)
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("%w: dataset %q has no schema", schemadrift.ErrIncompatible, ds.Name)
+	}
+
+	table, err := targetTable(ctx, duckDB, t.Name, target, cols, func() (string, error) {
+		return currentRows(ctx, w.minio, duckDB, tmpDir, ds, cols)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dataset %q: %w", ds.Name, err)
+	}
+	var fileRows int64
+	if err := duckDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM incoming").Scan(&fileRows); err != nil {
+		return nil, fmt.Errorf("count rows: %w", err)
+	}
+
+	// outputKey is the object written, and storagePath the dataset's data:
+	// the prefix an appended part is written under, otherwise the object
+	var outputKey, storagePath string
+	var rowCount int64
+	schemaJSON := ""
+	if target.Mode == domain.UploadWriteModeAppend {
+		var prefix string
+		if prefix, outputKey, err = appendPart(ctx, w.minio, duckDB, tmpDir, ds, file.FileID, now); err != nil {
+			return nil, err
+		}
+		storagePath = prefix
+		rowCount = fileRows
+		if ds.RowCount != nil {
+			rowCount += *ds.RowCount
+		}
+	} else {
+		if err := duckDB.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&rowCount); err != nil {
+			return nil, fmt.Errorf("count rows: %w", err)
+		}
+		outputKey = importObjectKey(tenantID, file.FileID, now)
+		if schemaJSON, err = putTable(ctx, w.minio, duckDB, tmpDir, table, outputKey); err != nil {
+			return nil, err
+		}
+	}
+
+	// Appends keep the dataset's schema; replace and upsert refresh its statistics
+	if schemaJSON != "" {
+		ds.SchemaJSON = &schemaJSON
+	}
+	if storagePath == "" {
+		storagePath = outputKey
+	}
+	lastUpdated := now
+	ds.StoragePath = storagePath
+	ds.RowCount = &rowCount
+	ds.LastUpdatedAt = &lastUpdated
+	if err := w.datasets.Update(ctx, ds); err != nil {
+		return nil, fmt.Errorf("update dataset: %w", err)
+	}
+	if err := w.versions.Record(ctx, tenantID, ds.Name, ""); err != nil {
+		return nil, err
+	}
+
+	return &ImportResult{
+		DatasetID:   ds.ID,
+		DatasetName: ds.Name,
+		RowCount:    fileRows,
+		SchemaJSON:  *ds.SchemaJSON,
+		OutputKey:   outputKey,
+	}, nil
+}
+
+// targetTable builds the table an upload writes into a dataset with the
+// columns cols and returns its name. The staged table source is conformed
+// to cols as the table incoming, which replace writes and append adds as a
+// new part; upsert combines it with the current rows, read by the query
+// current returns, into the table merged.
+func targetTable(ctx context.Context, duckDB *sql.DB, source string, target *domain.UploadTarget, cols []domain.DatasetColumnMeta, current func() (string, error)) (string, error) {
+	switch target.Mode {
+	case domain.UploadWriteModeReplace, domain.UploadWriteModeAppend, domain.UploadWriteModeUpsert:
+	default:
+		return "", fmt.Errorf("unknown write mode %q", target.Mode)
+	}
+	if err := conformTable(ctx, duckDB, source, "incoming", cols); err != nil {
+		return "", err
+	}
+	if target.Mode != domain.UploadWriteModeUpsert {
+		return "incoming", nil
+	}
+	return "merged", mergeByKeys(ctx, duckDB, target.KeyColumns, current)
+}
+
+// appendPart stores the incoming rows as a new Parquet part named after
+// partID under the dataset's storage prefix and returns the prefix and the
+// part's key. A dataset stored as a single object first gets a new prefix
+// holding a copy of that object.
+func appendPart(ctx context.Context, minio *storage.MinIOClient, duckDB *sql.DB, tmpDir string, ds *domain.Dataset, partID string, now time.Time) (string, string, error) {
+	prefix := strings.TrimSuffix(ds.StoragePath, "/")
+	if prefix == "" || storage.IsParquetObject(prefix) {
+		prefix = fmt.Sprintf("datasets/%s/%s/%d", ds.TenantID, ds.ID, now.UnixNano())
+		if ds.StoragePath != "" {
+			if err := minio.CopyObject(ctx, ds.StoragePath, prefix+"/part-00000-base.parquet"); err != nil {
+				return "", "", fmt.Errorf("copy existing data: %w", err)
+			}
+		}
+	}
+	key := fmt.Sprintf("%s/part-%d-%s.parquet", prefix, now.UnixNano(), partID)
+	if _, err := putTable(ctx, minio, duckDB, tmpDir, "incoming", key); err != nil {
+		return "", "", err
+	}
+	return prefix, key, nil
+}
+
+// mergeByKeys builds the table merged from the dataset's current rows,
+// read by the query current returns, and the incoming rows: incoming rows
+// replace current rows with the same key columns, and the last incoming
+// row wins when a key repeats in the file.
+func mergeByKeys(ctx context.Context, duckDB *sql.DB, keyColumns []string, current func() (string, error)) error {
+	incomingCols, err := describeTable(ctx, duckDB, "incoming")
+	if err != nil {
+		return fmt.Errorf("describe: %w", err)
+	}
+	present := make(map[string]bool, len(incomingCols))
+	for _, c := range incomingCols {
+		present[c.Name] = true
+	}
+	keys := make([]string, len(keyColumns))
+	nullChecks := make([]string, len(keyColumns))
+	for i, k := range keyColumns {
+		if !present[k] {
+			return fmt.Errorf("%w: key column %q is not a column of the dataset", schemadrift.ErrIncompatible, k)
+		}
+		keys[i] = quoteDuckDBIdent(k)
+		nullChecks[i] = keys[i] + " IS NULL"
+	}
+
+	var nullKeys int64
+	if err := duckDB.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM incoming WHERE %s", strings.Join(nullChecks, " OR "))).Scan(&nullKeys); err != nil {
+		return fmt.Errorf("check key columns: %w", err)
+	}
+	if nullKeys > 0 {
+		return fmt.Errorf("%w: %d rows have an empty key column", schemadrift.ErrIncompatible, nullKeys)
+	}
+
+	_, err = duckDB.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE incoming_latest AS SELECT * FROM incoming QUALIFY row_number() OVER (PARTITION BY %s ORDER BY rowid DESC) = 1",
+		strings.Join(keys, ", ")))
+	if err != nil {
+		return fmt.Errorf("dedupe keys: %w", err)
+	}
+
+	currentQuery, err := current()
+	if err != nil {
+		return err
+	}
+
+	_, err = duckDB.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE merged AS SELECT c.* FROM (%s) c ANTI JOIN incoming_latest i USING (%s) UNION ALL SELECT * FROM incoming_latest",
+		currentQuery, strings.Join(keys, ", ")))
+	if err != nil {
+		return fmt.Errorf("merge rows: %w", err)
+	}
+	return nil
+}
+
+// currentRows returns a query for the dataset's current rows, conformed to
+// cols. A dataset without data reads as no rows of the incoming table.
+func currentRows(ctx context.Context, minio *storage.MinIOClient, duckDB *sql.DB, tmpDir string, ds *domain.Dataset, cols []domain.DatasetColumnMeta) (string, error) {
+	if ds.StoragePath == "" {
+		return "SELECT * FROM incoming LIMIT 0", nil
+	}
+	dir := filepath.Join(tmpDir, "current")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create temp dir: %w", err)
+	}
+	glob, err := minio.DownloadDataset(ctx, ds.StoragePath, dir)
+	switch {
+	case errors.Is(err, storage.ErrObjectNotFound):
+		return "SELECT * FROM incoming LIMIT 0", nil
+	case err != nil:
+		return "", fmt.Errorf("download dataset: %w", err)
+	}
+	return readRows(ctx, duckDB, glob, cols)
+}
+
+// readRows returns a query for the rows of the local Parquet files glob
+// matches, conformed to cols.
+func readRows(ctx context.Context, duckDB *sql.DB, glob string, cols []domain.DatasetColumnMeta) (string, error) {
+	if _, err := duckDB.ExecContext(ctx, fmt.Sprintf("CREATE VIEW current_parts AS SELECT * FROM read_parquet('%s', union_by_name=true)", glob)); err != nil {
+		return "", fmt.Errorf("read dataset: %w", err)
+	}
+	if err := conformTable(ctx, duckDB, "current_parts", "current_rows", cols); err != nil {
+		return "", fmt.Errorf("read dataset: %w", err)
+	}
+	return "SELECT * FROM current_rows", nil
+}
+
+// conformTable copies source into a new table dest with the dataset's
+// columns, in the dataset's order and types. Dataset columns missing from
+// source are NULL; source columns the dataset lacks, and values that
+// cannot be cast to the dataset's type or that the cast would round, are
+// errors.
+func conformTable(ctx context.Context, duckDB *sql.DB, source, dest string, cols []domain.DatasetColumnMeta) error {
+	sourceCols, err := describeTable(ctx, duckDB, source)
+	if err != nil {
+		return fmt.Errorf("describe: %w", err)
+	}
+	target := make([]schemadrift.Column, len(cols))
+	for i, c := range cols {
+		target[i] = schemadrift.Column{Name: c.Name, Type: c.Type}
+	}
+	next := make([]schemadrift.Column, len(sourceCols))
+	present := make(map[string]bool, len(sourceCols))
+	for i, c := range sourceCols {
+		next[i] = schemadrift.Column{Name: c.Name, Type: c.Type}
+		present[c.Name] = true
+	}
+
+	casts, err := schemadrift.Conform(target, next)
+	if err != nil {
+		return err
+	}
+	for _, c := range casts {
+		col := quoteDuckDBIdent(c.Column)
+		lost := fmt.Sprintf("TRY_CAST(%s AS %s) IS NULL", col, c.Type)
+		if schemadrift.Rounds(c.PreviousType, c.Type) {
+			lost = fmt.Sprintf("(%s OR TRY_CAST(%s AS %s) <> %s)", lost, col, c.Type, col)
+		}
+		var failed int64
+		err := duckDB.QueryRowContext(ctx, fmt.Sprintf(
+			"SELECT COUNT(*) FROM %s WHERE %s IS NOT NULL AND %s", source, col, lost)).Scan(&failed)
+		if err != nil {
+			return fmt.Errorf("check column %q: %w", c.Column, err)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%w: %d values of column %q cannot be read as %s", schemadrift.ErrIncompatible, failed, c.Column, c.Type)
+		}
+	}
+
+	selects := make([]string, len(cols))
+	for i, c := range cols {
+		col := quoteDuckDBIdent(c.Name)
+		if present[c.Name] {
+			selects[i] = fmt.Sprintf("CAST(%s AS %s) AS %s", col, c.Type, col)
+		} else {
+			selects[i] = fmt.Sprintf("CAST(NULL AS %s) AS %s", c.Type, col)
+		}
+	}
+	if _, err := duckDB.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s AS SELECT %s FROM %s", dest, strings.Join(selects, ", "), source)); err != nil {
+		return fmt.Errorf("conform to dataset schema: %w", err)
+	}
+	return nil
+}
+
+// putTable exports a DuckDB table to Parquet, uploads it under key and
+// returns its enriched schema.
+func putTable(ctx context.Context, minio *storage.MinIOClient, duckDB *sql.DB, tmpDir, table, key string) (string, error) {
+	schemaJSON, err := ExtractEnrichedSchema(ctx, duckDB, table)
+	if err != nil {
+		return "", fmt.Errorf("extract schema: %w", err)
+	}
+	path := filepath.Join(tmpDir, table+".parquet")
+	if _, err := duckDB.ExecContext(ctx, fmt.Sprintf("COPY %s TO '%s' (FORMAT PARQUET)", table, path)); err != nil {
+		return "", fmt.Errorf("copy to parquet: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read parquet: %w", err)
+	}
+	if err := minio.PutParquet(ctx, key, data); err != nil {
+		return "", fmt.Errorf("upload parquet: %w", err)
+	}
+	return schemaJSON, nil
+}
+
+// importObjectKey is where an uploaded file's Parquet output is stored.
+// Each write gets a new object, so re-importing a file never overwrites
+// the data of an earlier dataset version.
+func importObjectKey(tenantID, objectName string, now time.Time) string {
+	return fmt.Sprintf("imports/%s/dt=%s/%s-%d.parquet", tenantID, now.Format("2006-01-02"), objectName, now.UnixNano())
+}
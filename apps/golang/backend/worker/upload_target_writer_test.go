@@ -0,0 +1,186 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/user/micro-dp/domain"
+	"github.com/user/micro-dp/internal/schemadrift"
+)
+
+// datasetRows are the rows of the target dataset before an upload.
+const datasetRows = `SELECT * FROM (VALUES (1::BIGINT, 'a', DATE '2026-01-01'), (2, 'b', DATE '2026-01-02')) t(id, v, day)`
+
+// openTarget returns a DuckDB session holding an uploaded file's rows as
+// the staged table, and the columns of the target dataset with a function
+// reading its rows from a Parquet fixture.
+func openTarget(t *testing.T, staged string) (*sql.DB, []domain.DatasetColumnMeta, func() (string, error)) {
+	t.Helper()
+	ctx := context.Background()
+	duckDB, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { duckDB.Close() })
+	if _, err := duckDB.ExecContext(ctx, "CREATE TABLE staged AS "+staged); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "current.parquet")
+	if _, err := duckDB.ExecContext(ctx, fmt.Sprintf("COPY (%s) TO '%s' (FORMAT PARQUET)", datasetRows, path)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := duckDB.ExecContext(ctx, fmt.Sprintf("CREATE VIEW fixture AS SELECT * FROM read_parquet('%s')", path)); err != nil {
+		t.Fatal(err)
+	}
+	cols, err := describeTable(ctx, duckDB, "fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return duckDB, cols, func() (string, error) { return readRows(ctx, duckDB, path, cols) }
+}
+
+// tableRows returns the rows of table, ordered, as their values joined by |.
+func tableRows(t *testing.T, duckDB *sql.DB, table string) []string {
+	t.Helper()
+	rows, err := duckDB.Query(fmt.Sprintf("SELECT * FROM %s", table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for rows.Next() {
+		values := make([]sql.NullString, len(cols))
+		dest := make([]any, len(cols))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			t.Fatal(err)
+		}
+		fields := make([]string, len(values))
+		for i, v := range values {
+			fields[i] = "NULL"
+			if v.Valid {
+				fields[i] = strings.TrimSuffix(v.String, "T00:00:00Z")
+			}
+		}
+		got = append(got, strings.Join(fields, "|"))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestTargetTable(t *testing.T) {
+	replace := &domain.UploadTarget{Mode: domain.UploadWriteModeReplace}
+	appendRows := &domain.UploadTarget{Mode: domain.UploadWriteModeAppend}
+	upsert := &domain.UploadTarget{Mode: domain.UploadWriteModeUpsert, KeyColumns: []string{"id"}}
+
+	tests := []struct {
+		name      string
+		target    *domain.UploadTarget
+		staged    string
+		noData    bool // the dataset has a schema but no data yet
+		wantTable string
+		want      []string
+		wantErr   error
+	}{
+		// Replace writes the file's rows, conformed to the dataset
+		{
+			name: "replace", target: replace,
+			staged:    `SELECT * FROM (VALUES (3, 'c', '2026-01-03'), (4, 'd', NULL)) t(id, v, day)`,
+			wantTable: "incoming", want: []string{"3|c|2026-01-03", "4|d|NULL"},
+		},
+		{
+			name: "replace missing column", target: replace,
+			staged:    `SELECT 3 AS id, 'c' AS v`,
+			wantTable: "incoming", want: []string{"3|c|NULL"},
+		},
+		{
+			name: "replace extra column", target: replace,
+			staged:  `SELECT 3 AS id, 'c' AS v, DATE '2026-01-03' AS day, 1 AS extra`,
+			wantErr: schemadrift.ErrIncompatible,
+		},
+
+		// Append adds only the file's rows
+		{
+			name: "append", target: appendRows,
+			staged:    `SELECT * FROM (VALUES (1::TINYINT, 'x', DATE '2026-01-03')) t(id, v, day)`,
+			wantTable: "incoming", want: []string{"1|x|2026-01-03"},
+		},
+		{
+			name: "append uncastable column", target: appendRows,
+			staged:  `SELECT 'three' AS id, 'c' AS v, DATE '2026-01-03' AS day`,
+			wantErr: schemadrift.ErrIncompatible,
+		},
+		{
+			name: "append rounded column", target: appendRows,
+			staged:  `SELECT 3.5::DOUBLE AS id, 'c' AS v, DATE '2026-01-03' AS day`,
+			wantErr: schemadrift.ErrIncompatible,
+		},
+
+		// Upsert replaces the rows of the file's keys and adds the rest
+		{
+			name: "upsert", target: upsert,
+			staged:    `SELECT * FROM (VALUES (2, 'x', DATE '2026-01-03'), (2, 'y', DATE '2026-01-04'), (3, 'z', DATE '2026-01-05')) t(id, v, day)`,
+			wantTable: "merged", want: []string{"1|a|2026-01-01", "2|y|2026-01-04", "3|z|2026-01-05"},
+		},
+		{
+			name: "upsert without data", target: upsert, noData: true,
+			staged:    `SELECT * FROM (VALUES (2, 'x', DATE '2026-01-03'), (2, 'y', DATE '2026-01-04')) t(id, v, day)`,
+			wantTable: "merged", want: []string{"2|y|2026-01-04"},
+		},
+		{
+			name: "upsert NULL key", target: upsert,
+			staged:  `SELECT NULL::BIGINT AS id, 'x' AS v, DATE '2026-01-03' AS day`,
+			wantErr: schemadrift.ErrIncompatible,
+		},
+		{
+			name: "upsert missing key column", target: &domain.UploadTarget{Mode: domain.UploadWriteModeUpsert, KeyColumns: []string{"customer_id"}},
+			staged:  `SELECT 2 AS id, 'x' AS v, DATE '2026-01-03' AS day`,
+			wantErr: schemadrift.ErrIncompatible,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duckDB, cols, current := openTarget(t, tt.staged)
+			if tt.noData {
+				current = func() (string, error) { return "SELECT * FROM incoming LIMIT 0", nil }
+			}
+			table, err := targetTable(context.Background(), duckDB, "staged", tt.target, cols, current)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("targetTable = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if table != tt.wantTable {
+				t.Errorf("table = %s, want %s", table, tt.wantTable)
+			}
+			if got := tableRows(t, duckDB, table); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	duckDB, cols, current := openTarget(t, `SELECT 1 AS id`)
+	if _, err := targetTable(context.Background(), duckDB, "staged", &domain.UploadTarget{Mode: "merge"}, cols, current); err == nil {
+		t.Error("targetTable with an unknown mode succeeded")
+	}
+}
@@ -28,6 +28,12 @@ const (
 	Pie  ChartType = "pie"
 )
 
+// Defines values for ConnectionHealthStatus.
+const (
+	ConnectionHealthStatusFailed ConnectionHealthStatus = "failed"
+	ConnectionHealthStatusOk     ConnectionHealthStatus = "ok"
+)
+
 // Defines values for ConnectivityResultStatus.
 const (
 	ConnectivityResultStatusFailed  ConnectivityResultStatus = "failed"
@@ -35,6 +41,12 @@ const (
 	ConnectivityResultStatusSkipped ConnectivityResultStatus = "skipped"
 )
 
+// Defines values for ConnectorDefinitionSource.
+const (
+	Embedded ConnectorDefinitionSource = "embedded"
+	External ConnectorDefinitionSource = "external"
+)
+
 // Defines values for ConnectorKind.
 const (
 	ConnectorKindDestination ConnectorKind = "destination"
@@ -48,6 +60,44 @@ const (
 	CreateModuleTypeRequestCategoryTransform   CreateModuleTypeRequestCategory = "transform"
 )
 
+// Defines values for CredentialStatus.
+const (
+	Active         CredentialStatus = "active"
+	NeedsReconsent CredentialStatus = "needs_reconsent"
+)
+
+// Defines values for DataQualityResultStatus.
+const (
+	DataQualityResultStatusError  DataQualityResultStatus = "error"
+	DataQualityResultStatusFailed DataQualityResultStatus = "failed"
+	DataQualityResultStatusPassed DataQualityResultStatus = "passed"
+)
+
+// Defines values for DataQualityRuleType.
+const (
+	DataQualityRuleTypeAcceptedValues DataQualityRuleType = "accepted_values"
+	DataQualityRuleTypeFreshness      DataQualityRuleType = "freshness"
+	DataQualityRuleTypeNotNull        DataQualityRuleType = "not_null"
+	DataQualityRuleTypeRange          DataQualityRuleType = "range"
+	DataQualityRuleTypeReferential    DataQualityRuleType = "referential"
+	DataQualityRuleTypeRegex          DataQualityRuleType = "regex"
+	DataQualityRuleTypeRowCount       DataQualityRuleType = "row_count"
+	DataQualityRuleTypeSql            DataQualityRuleType = "sql"
+	DataQualityRuleTypeUnique         DataQualityRuleType = "unique"
+)
+
+// Defines values for DataQualitySeverity.
+const (
+	DataQualitySeverityFail DataQualitySeverity = "fail"
+	DataQualitySeverityWarn DataQualitySeverity = "warn"
+)
+
+// Defines values for DatasetCertificationStatus.
+const (
+	Certified  DatasetCertificationStatus = "certified"
+	Deprecated DatasetCertificationStatus = "deprecated"
+)
+
 // Defines values for DatasetColumnSemanticType.
 const (
 	Dimension  DatasetColumnSemanticType = "dimension"
@@ -56,6 +106,43 @@ const (
 	Timestamp  DatasetColumnSemanticType = "timestamp"
 )
 
+// Defines values for DatasetExportFormat.
+const (
+	DatasetExportFormatCsv     DatasetExportFormat = "csv"
+	DatasetExportFormatJsonl   DatasetExportFormat = "jsonl"
+	DatasetExportFormatParquet DatasetExportFormat = "parquet"
+)
+
+// Defines values for DatasetExportStatus.
+const (
+	DatasetExportStatusCompleted DatasetExportStatus = "completed"
+	DatasetExportStatusFailed    DatasetExportStatus = "failed"
+	DatasetExportStatusQueued    DatasetExportStatus = "queued"
+	DatasetExportStatusRunning   DatasetExportStatus = "running"
+)
+
+// Defines values for DatasetFilterOp.
+const (
+	Contains DatasetFilterOp = "contains"
+	Eq       DatasetFilterOp = "eq"
+	Gt       DatasetFilterOp = "gt"
+	Gte      DatasetFilterOp = "gte"
+	In       DatasetFilterOp = "in"
+	IsNull   DatasetFilterOp = "is_null"
+	Lt       DatasetFilterOp = "lt"
+	Lte      DatasetFilterOp = "lte"
+	Ne       DatasetFilterOp = "ne"
+	NotNull  DatasetFilterOp = "not_null"
+)
+
+// Defines values for DatasetHealthStatus.
+const (
+	DatasetHealthStatusFailing DatasetHealthStatus = "failing"
+	DatasetHealthStatusHealthy DatasetHealthStatus = "healthy"
+	DatasetHealthStatusUnknown DatasetHealthStatus = "unknown"
+	DatasetHealthStatusWarning DatasetHealthStatus = "warning"
+)
+
 // Defines values for DatasetSourceType.
 const (
 	DatasetSourceTypeImport    DatasetSourceType = "import"
@@ -64,6 +151,12 @@ const (
 	DatasetSourceTypeTransform DatasetSourceType = "transform"
 )
 
+// Defines values for DatasetStewardRole.
+const (
+	DatasetStewardRoleOwner   DatasetStewardRole = "owner"
+	DatasetStewardRoleSteward DatasetStewardRole = "steward"
+)
+
 // Defines values for HealthResponseStatus.
 const (
 	HealthResponseStatusDegraded HealthResponseStatus = "degraded"
@@ -113,6 +206,29 @@ const (
 	Published JobVersionStatus = "published"
 )
 
+// Defines values for LineageDirection.
+const (
+	Both       LineageDirection = "both"
+	Downstream LineageDirection = "downstream"
+	Upstream   LineageDirection = "upstream"
+)
+
+// Defines values for LineageNodeType.
+const (
+	LineageNodeTypeChart      LineageNodeType = "chart"
+	LineageNodeTypeConnection LineageNodeType = "connection"
+	LineageNodeTypeDataset    LineageNodeType = "dataset"
+	LineageNodeTypeJob        LineageNodeType = "job"
+)
+
+// Defines values for MaskingPolicy.
+const (
+	Hash    MaskingPolicy = "hash"
+	Null    MaskingPolicy = "null"
+	Partial MaskingPolicy = "partial"
+	Redact  MaskingPolicy = "redact"
+)
+
 // Defines values for MeResponsePlatformRole.
 const (
 	Superadmin MeResponsePlatformRole = "superadmin"
@@ -126,6 +242,56 @@ const (
 	Transform   ModuleTypeCategory = "transform"
 )
 
+// Defines values for MultipartUploadStatus.
+const (
+	MultipartUploadStatusAborted   MultipartUploadStatus = "aborted"
+	MultipartUploadStatusActive    MultipartUploadStatus = "active"
+	MultipartUploadStatusCompleted MultipartUploadStatus = "completed"
+)
+
+// Defines values for PIIKind.
+const (
+	PIIKindCreditCard PIIKind = "credit_card"
+	PIIKindEmail      PIIKind = "email"
+	PIIKindIpAddress  PIIKind = "ip_address"
+	PIIKindMyNumber   PIIKind = "my_number"
+	PIIKindPhone      PIIKind = "phone"
+)
+
+// Defines values for RetentionPurgeAction.
+const (
+	Archived RetentionPurgeAction = "archived"
+	Deleted  RetentionPurgeAction = "deleted"
+)
+
+// Defines values for RetentionPurgeKind.
+const (
+	Partition RetentionPurgeKind = "partition"
+	Version   RetentionPurgeKind = "version"
+)
+
+// Defines values for SchemaChangeKind.
+const (
+	Added        SchemaChangeKind = "added"
+	Removed      SchemaChangeKind = "removed"
+	Renamed      SchemaChangeKind = "renamed"
+	TypeNarrowed SchemaChangeKind = "type_narrowed"
+	TypeWidened  SchemaChangeKind = "type_widened"
+)
+
+// Defines values for SchemaDriftAction.
+const (
+	Applied  SchemaDriftAction = "applied"
+	Rejected SchemaDriftAction = "rejected"
+)
+
+// Defines values for SchemaDriftPolicy.
+const (
+	Accept       SchemaDriftPolicy = "accept"
+	AdditiveOnly SchemaDriftPolicy = "additive_only"
+	Fail         SchemaDriftPolicy = "fail"
+)
+
 // Defines values for SchemaItemType.
 const (
 	Sheet SchemaItemType = "sheet"
@@ -161,12 +327,43 @@ const (
 	TransformExecutionScheduled TransformExecution = "scheduled"
 )
 
+// Defines values for TransformOutputMode.
+const (
+	TransformOutputModeAppend            TransformOutputMode = "append"
+	TransformOutputModeMerge             TransformOutputMode = "merge"
+	TransformOutputModeOverwrite         TransformOutputMode = "overwrite"
+	TransformOutputModeReplacePartitions TransformOutputMode = "replace_partitions"
+)
+
+// Defines values for UploadFileStatus.
+const (
+	UploadFileStatusConverted  UploadFileStatus = "converted"
+	UploadFileStatusFailed     UploadFileStatus = "failed"
+	UploadFileStatusPending    UploadFileStatus = "pending"
+	UploadFileStatusProcessing UploadFileStatus = "processing"
+	UploadFileStatusQueued     UploadFileStatus = "queued"
+	UploadFileStatusSkipped    UploadFileStatus = "skipped"
+)
+
 // Defines values for UploadStatus.
 const (
 	Presigned UploadStatus = "presigned"
 	Uploaded  UploadStatus = "uploaded"
 )
 
+// Defines values for UploadWriteMode.
+const (
+	Append  UploadWriteMode = "append"
+	Replace UploadWriteMode = "replace"
+	Upsert  UploadWriteMode = "upsert"
+)
+
+// Defines values for UrlUploadAuthType.
+const (
+	Basic  UrlUploadAuthType = "basic"
+	Bearer UrlUploadAuthType = "bearer"
+)
+
 // Defines values for ValidationResultStatus.
 const (
 	ValidationResultStatusFailed ValidationResultStatus = "failed"
@@ -229,6 +426,12 @@ type BillingWebhookResponse struct {
 	Received bool `json:"received"`
 }
 
+// CertifyDatasetRequest defines model for CertifyDatasetRequest.
+type CertifyDatasetRequest struct {
+	Note   *string                    `json:"note,omitempty"`
+	Status DatasetCertificationStatus `json:"status"`
+}
+
 // Chart defines model for Chart.
 type Chart struct {
 	ChartType  ChartType `json:"chart_type"`
@@ -292,19 +495,50 @@ type ColumnStatistics struct {
 	NullRate      *float64 `json:"null_rate,omitempty"`
 }
 
+// CompleteUploadRequest defines model for CompleteUploadRequest.
+type CompleteUploadRequest struct {
+	// Files Parse options by file ID, overriding parse_options.
+	Files *map[string]UploadParseOptions `json:"files,omitempty"`
+
+	// ParseOptions Options for reading delimited text files (.csv, .tsv, .txt, also inside .gz and .zip). Unset options are sniffed from the file.
+	ParseOptions *UploadParseOptions `json:"parse_options,omitempty"`
+}
+
 // Connection defines model for Connection.
 type Connection struct {
+	// ConfigJson Connector config. Fields marked x-secret are redacted.
 	ConfigJson   *string    `json:"config_json,omitempty"`
 	CreatedAt    *time.Time `json:"created_at,omitempty"`
 	CredentialId *string    `json:"credential_id,omitempty"`
-	Id           string     `json:"id"`
-	Name         string     `json:"name"`
-	SecretRef    *string    `json:"secret_ref,omitempty"`
-	TenantId     string     `json:"tenant_id"`
-	Type         string     `json:"type"`
-	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+
+	// Health Result of a periodic connectivity test run by the worker.
+	Health *ConnectionHealthCheck `json:"health,omitempty"`
+	Id     string                 `json:"id"`
+	Name   string                 `json:"name"`
+
+	// SecretRef Reference to externally stored secrets merged into the config at runtime (file:<name>).
+	SecretRef *string    `json:"secret_ref,omitempty"`
+	TenantId  string     `json:"tenant_id"`
+	Type      string     `json:"type"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// ConnectionHealthCheck Result of a periodic connectivity test run by the worker.
+type ConnectionHealthCheck struct {
+	CheckedAt time.Time `json:"checked_at"`
+
+	// Code Tester result code (e.g. ok, unauthorized, needs_reconsent, credential_expired, or error for a transient failure).
+	Code         *string                `json:"code,omitempty"`
+	ConnectionId string                 `json:"connection_id"`
+	Id           string                 `json:"id"`
+	LatencyMs    int64                  `json:"latency_ms"`
+	Message      *string                `json:"message,omitempty"`
+	Status       ConnectionHealthStatus `json:"status"`
 }
 
+// ConnectionHealthStatus defines model for ConnectionHealthStatus.
+type ConnectionHealthStatus string
+
 // ConnectionSchemasResponse defines model for ConnectionSchemasResponse.
 type ConnectionSchemasResponse struct {
 	Items []SchemaItem `json:"items"`
@@ -345,9 +579,44 @@ type ConnectorDefinitionDetail struct {
 	Spec               map[string]interface{} `json:"spec"`
 }
 
+// ConnectorDefinitionSource defines model for ConnectorDefinitionSource.
+type ConnectorDefinitionSource string
+
 // ConnectorKind defines model for ConnectorKind.
 type ConnectorKind string
 
+// ConnectorLoadError defines model for ConnectorLoadError.
+type ConnectorLoadError struct {
+	// DefinitionId Definition ID, when the file could be parsed
+	DefinitionId *string `json:"definition_id,omitempty"`
+
+	// KeptPrevious The definition last loaded from the file is still served in its place
+	KeptPrevious *bool                     `json:"kept_previous,omitempty"`
+	Message      string                    `json:"message"`
+	Path         string                    `json:"path"`
+	Source       ConnectorDefinitionSource `json:"source"`
+}
+
+// ConnectorRegistryDefinition defines model for ConnectorRegistryDefinition.
+type ConnectorRegistryDefinition struct {
+	Id   string        `json:"id"`
+	Kind ConnectorKind `json:"kind"`
+
+	// Path File the definition was loaded from
+	Path   string                    `json:"path"`
+	Source ConnectorDefinitionSource `json:"source"`
+}
+
+// ConnectorRegistryStatus defines model for ConnectorRegistryStatus.
+type ConnectorRegistryStatus struct {
+	Definitions []ConnectorRegistryDefinition `json:"definitions"`
+
+	// DefinitionsDir External definitions directory (omitted when not configured)
+	DefinitionsDir *string              `json:"definitions_dir,omitempty"`
+	Errors         []ConnectorLoadError `json:"errors"`
+	LoadedAt       time.Time            `json:"loaded_at"`
+}
+
 // CreateBillingCheckoutSessionRequest defines model for CreateBillingCheckoutSessionRequest.
 type CreateBillingCheckoutSessionRequest struct {
 	CancelUrl  *string `json:"cancel_url,omitempty"`
@@ -380,6 +649,28 @@ type CreateChartRequest struct {
 	Name       string    `json:"name"`
 }
 
+// CreateDataQualityRuleRequest defines model for CreateDataQualityRuleRequest.
+type CreateDataQualityRuleRequest struct {
+	Config  DataQualityRuleConfig `json:"config"`
+	Enabled *bool                 `json:"enabled,omitempty"`
+	Name    string                `json:"name"`
+
+	// Severity warn records a failure; fail also rolls the dataset back and fails the run
+	Severity *DataQualitySeverity `json:"severity,omitempty"`
+	Type     DataQualityRuleType  `json:"type"`
+}
+
+// CreateDatasetExportRequest defines model for CreateDatasetExportRequest.
+type CreateDatasetExportRequest struct {
+	// AsOf Export the latest version created at or before this time
+	AsOf    *time.Time          `json:"as_of,omitempty"`
+	Columns *[]string           `json:"columns,omitempty"`
+	Filters *[]DatasetFilter    `json:"filters,omitempty"`
+	Format  DatasetExportFormat `json:"format"`
+	Sort    *[]DatasetSortKey   `json:"sort,omitempty"`
+	Version *int                `json:"version,omitempty"`
+}
+
 // CreateConnectionRequest defines model for CreateConnectionRequest.
 type CreateConnectionRequest struct {
 	ConfigJson   *string `json:"config_json,omitempty"`
@@ -448,7 +739,13 @@ type CreateJobRequest struct {
 	Description *string  `json:"description,omitempty"`
 	Kind        *JobKind `json:"kind,omitempty"`
 	Name        string   `json:"name"`
-	Slug        string   `json:"slug"`
+
+	// SchemaDriftPolicy What an import does when the output dataset already exists with a
+	// different schema. accept applies every change, additive_only applies
+	// added columns and widened types only, fail rejects any change. A
+	// rejected change fails the run and leaves the dataset untouched.
+	SchemaDriftPolicy *SchemaDriftPolicy `json:"schema_drift_policy,omitempty"`
+	Slug              string             `json:"slug"`
 }
 
 // CreateJobRunRequest defines model for CreateJobRunRequest.
@@ -477,10 +774,20 @@ type CreateModuleTypeSchemaRequest struct {
 	JsonSchema string `json:"json_schema"`
 }
 
+// CreateMultipartUploadRequest defines model for CreateMultipartUploadRequest.
+type CreateMultipartUploadRequest struct {
+	File UploadFileInput `json:"file"`
+
+	// PartSizeBytes Size of every part but the last, 5 MB to 5 GB. Chosen from the file size when omitted.
+	PartSizeBytes *int64 `json:"part_size_bytes,omitempty"`
+}
+
 // CreatePlanRequest defines model for CreatePlanRequest.
 type CreatePlanRequest struct {
 	DisplayName      string `json:"display_name"`
 	MaxEventsPerDay  *int   `json:"max_events_per_day,omitempty"`
+	MaxFileSizeBytes *int64 `json:"max_file_size_bytes,omitempty"`
+	MaxRetentionDays *int   `json:"max_retention_days,omitempty"`
 	MaxRowsPerDay    *int   `json:"max_rows_per_day,omitempty"`
 	MaxStorageBytes  *int64 `json:"max_storage_bytes,omitempty"`
 	MaxUploadsPerDay *int   `json:"max_uploads_per_day,omitempty"`
@@ -494,13 +801,21 @@ type CreateTemplateRunRequest struct {
 
 // CreateTransformJobRequest defines model for CreateTransformJobRequest.
 type CreateTransformJobRequest struct {
-	DatasetIds  []string            `json:"dataset_ids"`
-	Description *string             `json:"description,omitempty"`
-	Execution   *TransformExecution `json:"execution,omitempty"`
-	Name        string              `json:"name"`
-	ScheduledAt *time.Time          `json:"scheduled_at,omitempty"`
-	Slug        string              `json:"slug"`
-	Sql         string              `json:"sql"`
+	// AsOf Reads every input not in dataset_versions at its latest version
+	// created at or before this time. Resolved to versions when the
+	// job is created, so every run reads the same data.
+	AsOf       *time.Time `json:"as_of,omitempty"`
+	DatasetIds []string   `json:"dataset_ids"`
+
+	// DatasetVersions Pins input datasets to a version, keyed by dataset ID.
+	DatasetVersions *map[string]int     `json:"dataset_versions,omitempty"`
+	Description     *string             `json:"description,omitempty"`
+	Execution       *TransformExecution `json:"execution,omitempty"`
+	Name            string              `json:"name"`
+	Output          *TransformOutput    `json:"output,omitempty"`
+	ScheduledAt     *time.Time          `json:"scheduled_at,omitempty"`
+	Slug            string              `json:"slug"`
+	Sql             string              `json:"sql"`
 }
 
 // CreateTransformJobResponse defines model for CreateTransformJobResponse.
@@ -521,6 +836,26 @@ type CreateUploadPresignResponse struct {
 	UploadId string                `json:"upload_id"`
 }
 
+// CreateUrlUploadRequest defines model for CreateUrlUploadRequest.
+type CreateUrlUploadRequest struct {
+	Auth *UrlUploadAuth `json:"auth,omitempty"`
+
+	// Checksum Expected digest as <algorithm>:<hex>, with sha256, sha1 or md5. The file fails when it does not match.
+	Checksum *string `json:"checksum,omitempty"`
+
+	// Filename Name of the file, which sets its format and dataset name. Defaults to the last segment of the URL path; without a known extension the format is sniffed from the downloaded file.
+	Filename *string `json:"filename,omitempty"`
+
+	// ParseOptions Options for reading delimited text files (.csv, .tsv, .txt, also inside .gz and .zip). Unset options are sniffed from the file.
+	ParseOptions *UploadParseOptions `json:"parse_options,omitempty"`
+
+	// Target An existing dataset to write the file into instead of the dataset named after the file. The file must fit the dataset's schema.
+	Target *UploadTarget `json:"target,omitempty"`
+
+	// Url http or https URL of the file. Private network addresses are refused.
+	Url string `json:"url"`
+}
+
 // CreateWriteKeyRequest defines model for CreateWriteKeyRequest.
 type CreateWriteKeyRequest struct {
 	Name string `json:"name"`
@@ -540,11 +875,26 @@ type Credential struct {
 	Provider      string     `json:"provider"`
 	ProviderLabel *string    `json:"provider_label,omitempty"`
 	Scopes        *string    `json:"scopes,omitempty"`
-	TenantId      string     `json:"tenant_id"`
-	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
-	UserId        string     `json:"user_id"`
+
+	// Status needs_reconsent means the provider rejected the refresh token and the account must be reconnected.
+	Status    CredentialStatus `json:"status"`
+	TenantId  string           `json:"tenant_id"`
+	UpdatedAt *time.Time       `json:"updated_at,omitempty"`
+	UserId    string           `json:"user_id"`
+}
+
+// CredentialProvider defines model for CredentialProvider.
+type CredentialProvider struct {
+	DisplayName string `json:"display_name"`
+
+	// Enabled Whether OAuth client settings are configured for this provider.
+	Enabled bool   `json:"enabled"`
+	Name    string `json:"name"`
 }
 
+// CredentialStatus needs_reconsent means the provider rejected the refresh token and the account must be reconnected.
+type CredentialStatus string
+
 // Dashboard defines model for Dashboard.
 type Dashboard struct {
 	CreatedAt   time.Time `json:"created_at"`
@@ -564,44 +914,313 @@ type DashboardWidget struct {
 	Position    int       `json:"position"`
 }
 
+// DataQualityResult defines model for DataQualityResult.
+type DataQualityResult struct {
+	CreatedAt time.Time `json:"created_at"`
+	DatasetId string    `json:"dataset_id"`
+
+	// DatasetVersion Dataset version checked
+	DatasetVersion *int   `json:"dataset_version,omitempty"`
+	FailingRows    *int64 `json:"failing_rows,omitempty"`
+	Id             string `json:"id"`
+
+	// JobRunId Run whose write was checked; absent for checks run on demand
+	JobRunId *string `json:"job_run_id,omitempty"`
+	Message  *string `json:"message,omitempty"`
+	RuleId   string  `json:"rule_id"`
+
+	// Severity warn records a failure; fail also rolls the dataset back and fails the run
+	Severity DataQualitySeverity `json:"severity"`
+
+	// Status error when the rule could not be evaluated
+	Status DataQualityResultStatus `json:"status"`
+}
+
+// DataQualityResultStatus error when the rule could not be evaluated
+type DataQualityResultStatus string
+
+// DataQualityRule defines model for DataQualityRule.
+type DataQualityRule struct {
+	Config    DataQualityRuleConfig `json:"config"`
+	CreatedAt time.Time             `json:"created_at"`
+	DatasetId string                `json:"dataset_id"`
+	Enabled   bool                  `json:"enabled"`
+	Id        string                `json:"id"`
+	Name      string                `json:"name"`
+
+	// Severity warn records a failure; fail also rolls the dataset back and fails the run
+	Severity  DataQualitySeverity `json:"severity"`
+	Type      DataQualityRuleType `json:"type"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// DataQualityRuleConfig Parameters of a rule; which are required depends on its type
+type DataQualityRuleConfig struct {
+	// Column Column checked by not_null, unique, accepted_values, range, regex and referential
+	Column *string `json:"column,omitempty"`
+
+	// Max Inclusive upper bound of a range, in the text form of the column's type
+	Max *string `json:"max,omitempty"`
+
+	// MaxAge Longest time since the dataset was last updated, e.g. 26h
+	MaxAge  *string `json:"max_age,omitempty"`
+	MaxRows *int64  `json:"max_rows,omitempty"`
+
+	// Min Inclusive lower bound of a range, in the text form of the column's type
+	Min     *string `json:"min,omitempty"`
+	MinRows *int64  `json:"min_rows,omitempty"`
+
+	// Pattern Regular expression the whole text of the column must match
+	Pattern   *string `json:"pattern,omitempty"`
+	RefColumn *string `json:"ref_column,omitempty"`
+
+	// RefDatasetId Dataset whose ref_column must hold every value of column; for an
+	// sql rule, the dataset read as the view "ref"
+	RefDatasetId *string `json:"ref_dataset_id,omitempty"`
+
+	// Sql A single SELECT over the view "dataset", and "ref" when
+	// ref_dataset_id is set, returning the violating rows
+	Sql *string `json:"sql,omitempty"`
+
+	// Values Accepted values, compared as text
+	Values *[]string `json:"values,omitempty"`
+}
+
+// DataQualityRuleHealth defines model for DataQualityRuleHealth.
+type DataQualityRuleHealth struct {
+	LatestResult *DataQualityResult `json:"latest_result,omitempty"`
+	Rule         DataQualityRule    `json:"rule"`
+}
+
+// DataQualityRuleType defines model for DataQualityRuleType.
+type DataQualityRuleType string
+
+// DataQualitySeverity warn records a failure; fail also rolls the dataset back and fails the run
+type DataQualitySeverity string
+
 // Dataset defines model for Dataset.
 type Dataset struct {
-	Columns       *[]DatasetColumn `json:"columns,omitempty"`
-	CreatedAt     *time.Time       `json:"created_at,omitempty"`
-	Id            string           `json:"id"`
-	LastUpdatedAt *time.Time       `json:"last_updated_at,omitempty"`
-	Name          string           `json:"name"`
-	RowCount      *int64           `json:"row_count,omitempty"`
+	Certification *DatasetCertification `json:"certification,omitempty"`
+	Columns       *[]DatasetColumn      `json:"columns,omitempty"`
+	CreatedAt     *time.Time            `json:"created_at,omitempty"`
+
+	// DeletedAt Set on soft-deleted datasets
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	Id            string     `json:"id"`
+	LastUpdatedAt *time.Time `json:"last_updated_at,omitempty"`
+	Name          string     `json:"name"`
+	RowCount      *int64     `json:"row_count,omitempty"`
 	// Deprecated:
 	SchemaJson  *string           `json:"schema_json,omitempty"`
 	SourceType  DatasetSourceType `json:"source_type"`
+	Stewards    *[]DatasetSteward `json:"stewards,omitempty"`
 	StoragePath string            `json:"storage_path"`
 	TenantId    string            `json:"tenant_id"`
 	UpdatedAt   *time.Time        `json:"updated_at,omitempty"`
+
+	// Usage Reads of the dataset over the catalog usage window (30 days by default)
+	Usage *DatasetUsage `json:"usage,omitempty"`
+}
+
+// DatasetCertification defines model for DatasetCertification.
+type DatasetCertification struct {
+	CertifiedAt time.Time `json:"certified_at"`
+
+	// CertifiedBy User who certified the dataset
+	CertifiedBy string                     `json:"certified_by"`
+	Note        string                     `json:"note"`
+	Status      DatasetCertificationStatus `json:"status"`
 }
 
+// DatasetCertificationStatus defines model for DatasetCertificationStatus.
+type DatasetCertificationStatus string
+
 // DatasetColumn defines model for DatasetColumn.
 type DatasetColumn struct {
-	Description  *string                    `json:"description,omitempty"`
-	Name         string                     `json:"name"`
-	Nullable     *bool                      `json:"nullable,omitempty"`
+	Description   *string            `json:"description,omitempty"`
+	GlossaryTerms *[]GlossaryTermRef `json:"glossary_terms,omitempty"`
+	Name          string             `json:"name"`
+	Nullable      *bool              `json:"nullable,omitempty"`
+
+	// PiiKind Kind of personal data detected in the column's values
+	PiiKind      *PIIKind                   `json:"pii_kind,omitempty"`
 	SampleValues *[]interface{}             `json:"sample_values,omitempty"`
 	SemanticType *DatasetColumnSemanticType `json:"semantic_type,omitempty"`
 	Statistics   *ColumnStatistics          `json:"statistics,omitempty"`
-	Tags         *[]string                  `json:"tags,omitempty"`
-	Type         string                     `json:"type"`
+
+	// SuggestedTags Tags suggested by personal data detection that the column does not have yet
+	SuggestedTags *[]string `json:"suggested_tags,omitempty"`
+	Tags          *[]string `json:"tags,omitempty"`
+	Type          string    `json:"type"`
 }
 
 // DatasetColumnSemanticType defines model for DatasetColumnSemanticType.
 type DatasetColumnSemanticType string
 
+// DatasetExport defines model for DatasetExport.
+type DatasetExport struct {
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DatasetId   string     `json:"dataset_id"`
+
+	// DownloadExpiresAt Expiry of download_url
+	DownloadExpiresAt *time.Time `json:"download_expires_at,omitempty"`
+
+	// DownloadUrl Presigned link to the exported file, set once completed
+	DownloadUrl  *string             `json:"download_url,omitempty"`
+	ErrorMessage *string             `json:"error_message,omitempty"`
+	Format       DatasetExportFormat `json:"format"`
+	Id           string              `json:"id"`
+	SizeBytes    *int64              `json:"size_bytes,omitempty"`
+	Status       DatasetExportStatus `json:"status"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+
+	// Version Dataset version exported; absent exports the data current when the export runs
+	Version *int `json:"version,omitempty"`
+}
+
+// DatasetExportFormat defines model for DatasetExportFormat.
+type DatasetExportFormat string
+
+// DatasetExportStatus defines model for DatasetExportStatus.
+type DatasetExportStatus string
+
+// DatasetFilter defines model for DatasetFilter.
+type DatasetFilter struct {
+	Column string          `json:"column"`
+	Op     DatasetFilterOp `json:"op"`
+
+	// Value Comma-separated values for in; unused by is_null and not_null
+	Value *string `json:"value,omitempty"`
+}
+
+// DatasetFilterOp defines model for DatasetFilter.Op.
+type DatasetFilterOp string
+
+// DatasetHealth defines model for DatasetHealth.
+type DatasetHealth struct {
+	// CheckedAt When the latest result was recorded
+	CheckedAt *time.Time              `json:"checked_at,omitempty"`
+	DatasetId string                  `json:"dataset_id"`
+	Rules     []DataQualityRuleHealth `json:"rules"`
+
+	// Status failing when a rule of severity fail did not pass, warning when one of severity warn did not, unknown before any check
+	Status DatasetHealthStatus `json:"status"`
+}
+
+// DatasetHealthStatus failing when a rule of severity fail did not pass, warning when one of severity warn did not, unknown before any check
+type DatasetHealthStatus string
+
+// DatasetInUseResponse defines model for DatasetInUseResponse.
+type DatasetInUseResponse struct {
+	Charts []DatasetReference `json:"charts"`
+	Error  string             `json:"error"`
+	Jobs   []DatasetReference `json:"jobs"`
+}
+
+// DatasetLineage defines model for DatasetLineage.
+type DatasetLineage struct {
+	Edges []LineageEdge      `json:"edges"`
+	Nodes []LineageGraphNode `json:"nodes"`
+	Root  LineageNode        `json:"root"`
+}
+
+// DatasetMaskingPolicy defines model for DatasetMaskingPolicy.
+type DatasetMaskingPolicy struct {
+	Column    string        `json:"column"`
+	CreatedAt time.Time     `json:"created_at"`
+	DatasetId string        `json:"dataset_id"`
+	Id        string        `json:"id"`
+	Policy    MaskingPolicy `json:"policy"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// DatasetReference defines model for DatasetReference.
+type DatasetReference struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
 // DatasetRowsResponse defines model for DatasetRowsResponse.
 type DatasetRowsResponse struct {
-	Columns   []DatasetColumn          `json:"columns"`
-	Limit     int                      `json:"limit"`
-	Offset    int                      `json:"offset"`
-	Rows      []map[string]interface{} `json:"rows"`
-	TotalRows int64                    `json:"total_rows"`
+	Columns []DatasetColumn `json:"columns"`
+	Limit   int             `json:"limit"`
+
+	// NextPageToken Token reading the next page; absent on the last page
+	NextPageToken *string `json:"next_page_token,omitempty"`
+
+	// Offset Rows skipped before this page; 0 when reading by page_token
+	Offset int                      `json:"offset"`
+	Rows   []map[string]interface{} `json:"rows"`
+
+	// TotalRows Rows matching the filters
+	TotalRows int64 `json:"total_rows"`
+}
+
+// DatasetSchemaChange defines model for DatasetSchemaChange.
+type DatasetSchemaChange struct {
+	Action    SchemaDriftAction `json:"action"`
+	Changes   []SchemaChange    `json:"changes"`
+	CreatedAt time.Time         `json:"created_at"`
+	DatasetId string            `json:"dataset_id"`
+	Id        string            `json:"id"`
+	JobRunId  *string           `json:"job_run_id,omitempty"`
+
+	// Policy What an import does when the output dataset already exists with a
+	// different schema. accept applies every change, additive_only applies
+	// added columns and widened types only, fail rejects any change. A
+	// rejected change fails the run and leaves the dataset untouched.
+	Policy SchemaDriftPolicy `json:"policy"`
+}
+
+// DatasetSortKey defines model for DatasetSortKey.
+type DatasetSortKey struct {
+	Column string `json:"column"`
+
+	// Desc Descending order; NULLs sort last either way
+	Desc *bool `json:"desc,omitempty"`
+}
+
+// DatasetSteward defines model for DatasetSteward.
+type DatasetSteward struct {
+	CreatedAt   time.Time          `json:"created_at"`
+	DisplayName string             `json:"display_name"`
+	Email       string             `json:"email"`
+	Role        DatasetStewardRole `json:"role"`
+	UserId      string             `json:"user_id"`
+}
+
+// DatasetStewardRole defines model for DatasetStewardRole.
+type DatasetStewardRole string
+
+// DatasetUsage Reads of the dataset over the catalog usage window (30 days by default)
+type DatasetUsage struct {
+	// Charts Charts whose data was read
+	Charts int64 `json:"charts"`
+
+	// Previews Row and transform preview queries
+	Previews int64 `json:"previews"`
+
+	// Transforms Transform jobs that ran on the dataset
+	Transforms int64 `json:"transforms"`
+}
+
+// DatasetVersion defines model for DatasetVersion.
+type DatasetVersion struct {
+	Columns   *[]DatasetColumn `json:"columns,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	DatasetId string           `json:"dataset_id"`
+	Id        string           `json:"id"`
+
+	// JobRunId Job run that wrote the version; absent for uploads and rollbacks
+	JobRunId *string `json:"job_run_id,omitempty"`
+	Pinned   bool    `json:"pinned"`
+
+	// RestoredFrom Version whose data a rollback restored
+	RestoredFrom *int   `json:"restored_from,omitempty"`
+	RowCount     *int64 `json:"row_count,omitempty"`
+	StoragePath  string `json:"storage_path"`
+	Version      int    `json:"version"`
 }
 
 // DatasetSourceType defines model for DatasetSourceType.
@@ -624,6 +1243,34 @@ type EventsSummaryResponse struct {
 	Total  int64        `json:"total"`
 }
 
+// GlossaryTerm defines model for GlossaryTerm.
+type GlossaryTerm struct {
+	Columns    []GlossaryTermColumn `json:"columns"`
+	CreatedAt  time.Time            `json:"created_at"`
+	Definition string               `json:"definition"`
+	Id         string               `json:"id"`
+	Name       string               `json:"name"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+// GlossaryTermColumn defines model for GlossaryTermColumn.
+type GlossaryTermColumn struct {
+	Column    string `json:"column"`
+	DatasetId string `json:"dataset_id"`
+}
+
+// GlossaryTermRef defines model for GlossaryTermRef.
+type GlossaryTermRef struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// GlossaryTermRequest defines model for GlossaryTermRequest.
+type GlossaryTermRequest struct {
+	Definition *string `json:"definition,omitempty"`
+	Name       string  `json:"name"`
+}
+
 // HealthResponse defines model for HealthResponse.
 type HealthResponse struct {
 	Db     *string              `json:"db,omitempty"`
@@ -662,9 +1309,15 @@ type Job struct {
 	IsActive    bool       `json:"is_active"`
 	Kind        JobKind    `json:"kind"`
 	Name        string     `json:"name"`
-	Slug        string     `json:"slug"`
-	TenantId    string     `json:"tenant_id"`
-	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+
+	// SchemaDriftPolicy What an import does when the output dataset already exists with a
+	// different schema. accept applies every change, additive_only applies
+	// added columns and widened types only, fail rejects any change. A
+	// rejected change fails the run and leaves the dataset untouched.
+	SchemaDriftPolicy SchemaDriftPolicy `json:"schema_drift_policy"`
+	Slug              string            `json:"slug"`
+	TenantId          string            `json:"tenant_id"`
+	UpdatedAt         *time.Time        `json:"updated_at,omitempty"`
 }
 
 // JobKind defines model for JobKind.
@@ -695,13 +1348,16 @@ type JobModuleEdge struct {
 
 // JobRun defines model for JobRun.
 type JobRun struct {
-	FinishedAt   *time.Time   `json:"finished_at,omitempty"`
-	Id           string       `json:"id"`
-	JobId        string       `json:"job_id"`
-	JobVersionId *string      `json:"job_version_id,omitempty"`
-	StartedAt    *time.Time   `json:"started_at,omitempty"`
-	Status       JobRunStatus `json:"status"`
-	TenantId     string       `json:"tenant_id"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	Id           string     `json:"id"`
+	JobId        string     `json:"job_id"`
+	JobVersionId *string    `json:"job_version_id,omitempty"`
+
+	// SchemaDrift Schema drift detected per output dataset; import runs only.
+	SchemaDrift *[]SchemaDriftReport `json:"schema_drift,omitempty"`
+	StartedAt   *time.Time           `json:"started_at,omitempty"`
+	Status      JobRunStatus         `json:"status"`
+	TenantId    string               `json:"tenant_id"`
 }
 
 // JobRunArtifact defines model for JobRunArtifact.
@@ -769,6 +1425,42 @@ type JobVersionDetail struct {
 	Version JobVersion      `json:"version"`
 }
 
+// LineageDirection defines model for LineageDirection.
+type LineageDirection string
+
+// LineageEdge defines model for LineageEdge.
+type LineageEdge struct {
+	CreatedAt time.Time `json:"created_at"`
+	Id        string    `json:"id"`
+
+	// JobRunId Latest run that produced the edge
+	JobRunId  *string     `json:"job_run_id,omitempty"`
+	Source    LineageNode `json:"source"`
+	Target    LineageNode `json:"target"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// LineageGraphNode defines model for LineageGraphNode.
+type LineageGraphNode struct {
+	// Depth Number of edges between the node and the root; 0 for the root
+	Depth     int               `json:"depth"`
+	Direction *LineageDirection `json:"direction,omitempty"`
+	Id        string            `json:"id"`
+
+	// Name Absent when the node no longer exists
+	Name *string         `json:"name,omitempty"`
+	Type LineageNodeType `json:"type"`
+}
+
+// LineageNode defines model for LineageNode.
+type LineageNode struct {
+	Id   string          `json:"id"`
+	Type LineageNodeType `json:"type"`
+}
+
+// LineageNodeType defines model for LineageNodeType.
+type LineageNodeType string
+
 // LoginRequest defines model for LoginRequest.
 type LoginRequest struct {
 	Email    openapi_types.Email `json:"email"`
@@ -780,6 +1472,9 @@ type LoginResponse struct {
 	Token string `json:"token"`
 }
 
+// MaskingPolicy defines model for MaskingPolicy.
+type MaskingPolicy string
+
 // MeResponse defines model for MeResponse.
 type MeResponse struct {
 	DisplayName  string                 `json:"display_name"`
@@ -815,18 +1510,63 @@ type ModuleTypeSchema struct {
 	Version      int        `json:"version"`
 }
 
+// MultipartUpload defines model for MultipartUpload.
+type MultipartUpload struct {
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+	FileId        string     `json:"file_id"`
+	Filename      string     `json:"filename"`
+	ObjectKey     string     `json:"object_key"`
+	PartCount     int        `json:"part_count"`
+	PartSizeBytes int64      `json:"part_size_bytes"`
+
+	// Parts Parts stored so far; empty once the upload is completed or aborted.
+	Parts     []UploadedPart        `json:"parts"`
+	SizeBytes int64                 `json:"size_bytes"`
+	Status    MultipartUploadStatus `json:"status"`
+	UploadId  string                `json:"upload_id"`
+}
+
+// MultipartUploadStatus defines model for MultipartUploadStatus.
+type MultipartUploadStatus string
+
+// PIIKind Kind of personal data detected in the column's values
+type PIIKind string
+
 // Plan defines model for Plan.
 type Plan struct {
-	DisplayName      string `json:"display_name"`
-	Id               string `json:"id"`
-	IsDefault        bool   `json:"is_default"`
-	MaxEventsPerDay  int    `json:"max_events_per_day"`
+	DisplayName     string `json:"display_name"`
+	Id              string `json:"id"`
+	IsDefault       bool   `json:"is_default"`
+	MaxEventsPerDay int    `json:"max_events_per_day"`
+
+	// MaxFileSizeBytes Largest file one upload may hold; -1 = unlimited.
+	MaxFileSizeBytes int64 `json:"max_file_size_bytes"`
+
+	// MaxRetentionDays Longest retention policies may keep data, in days; -1 = unlimited.
+	MaxRetentionDays int    `json:"max_retention_days"`
 	MaxRowsPerDay    int    `json:"max_rows_per_day"`
 	MaxStorageBytes  int64  `json:"max_storage_bytes"`
 	MaxUploadsPerDay int    `json:"max_uploads_per_day"`
 	Name             string `json:"name"`
 }
 
+// PresignUploadPartsRequest defines model for PresignUploadPartsRequest.
+type PresignUploadPartsRequest struct {
+	PartNumbers []int `json:"part_numbers"`
+}
+
+// PresignUploadPartsResponse defines model for PresignUploadPartsResponse.
+type PresignUploadPartsResponse struct {
+	Parts []PresignedUploadPart `json:"parts"`
+}
+
+// PresignedUploadPart defines model for PresignedUploadPart.
+type PresignedUploadPart struct {
+	ExpiresAt  time.Time `json:"expires_at"`
+	PartNumber int       `json:"part_number"`
+	Url        string    `json:"url"`
+}
+
 // RegisterRequest defines model for RegisterRequest.
 type RegisterRequest struct {
 	DisplayName *string             `json:"display_name,omitempty"`
@@ -840,19 +1580,110 @@ type RegisterResponse struct {
 	UserId   string `json:"user_id"`
 }
 
-// SchemaColumn defines model for SchemaColumn.
-type SchemaColumn struct {
-	// CursorCandidate Whether this column can be used as an incremental cursor
-	CursorCandidate *bool  `json:"cursor_candidate,omitempty"`
-	Name            string `json:"name"`
-	Nullable        *bool  `json:"nullable,omitempty"`
-	PrimaryKey      *bool  `json:"primary_key,omitempty"`
+// ReimportUploadFileRequest defines model for ReimportUploadFileRequest.
+type ReimportUploadFileRequest struct {
+	// ParseOptions Options for reading delimited text files (.csv, .tsv, .txt, also inside .gz and .zip). Unset options are sniffed from the file.
+	ParseOptions *UploadParseOptions `json:"parse_options,omitempty"`
 
-	// Type Column data type (e.g. "integer", "varchar", "timestamp")
-	Type string `json:"type"`
+	// Target An existing dataset to write the file into instead of the dataset named after the file. The file must fit the dataset's schema.
+	Target *UploadTarget `json:"target,omitempty"`
 }
 
-// SchemaItem defines model for SchemaItem.
+// RetentionPolicy Retention of a tenant's data. The tenant's policy (no dataset_id) sweeps the raw dt= partitions and is the default for its datasets; a dataset's policy overrides it field by field. The current version and pinned versions of a dataset are always kept.
+type RetentionPolicy struct {
+	// Archive Move purged objects under the archive prefix instead of deleting them.
+	Archive   bool      `json:"archive"`
+	CreatedAt time.Time `json:"created_at"`
+	DatasetId *string   `json:"dataset_id,omitempty"`
+	Id        string    `json:"id"`
+
+	// KeepDays Days of partitions and dataset versions kept.
+	KeepDays *int `json:"keep_days,omitempty"`
+
+	// KeepVersions Latest dataset versions kept.
+	KeepVersions *int      `json:"keep_versions,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// RetentionPolicyRequest At least one of keep_days and keep_versions is required.
+type RetentionPolicyRequest struct {
+	Archive      *bool `json:"archive,omitempty"`
+	KeepDays     *int  `json:"keep_days,omitempty"`
+	KeepVersions *int  `json:"keep_versions,omitempty"`
+}
+
+// RetentionPurge defines model for RetentionPurge.
+type RetentionPurge struct {
+	Action      RetentionPurgeAction `json:"action"`
+	Bytes       int64                `json:"bytes"`
+	CreatedAt   time.Time            `json:"created_at"`
+	DatasetId   *string              `json:"dataset_id,omitempty"`
+	Id          string               `json:"id"`
+	Kind        RetentionPurgeKind   `json:"kind"`
+	ObjectCount int                  `json:"object_count"`
+
+	// Target Partition prefix, or dataset version number.
+	Target string `json:"target"`
+}
+
+// RetentionPurgeAction defines model for RetentionPurgeAction.
+type RetentionPurgeAction string
+
+// RetentionPurgeKind defines model for RetentionPurgeKind.
+type RetentionPurgeKind string
+
+// SchemaColumn defines model for SchemaColumn.
+type SchemaColumn struct {
+	// CursorCandidate Whether this column can be used as an incremental cursor
+	CursorCandidate *bool  `json:"cursor_candidate,omitempty"`
+	Name            string `json:"name"`
+	Nullable        *bool  `json:"nullable,omitempty"`
+	PrimaryKey      *bool  `json:"primary_key,omitempty"`
+
+	// Type Column data type (e.g. "integer", "varchar", "timestamp")
+	Type string `json:"type"`
+}
+
+// SchemaChange defines model for SchemaChange.
+type SchemaChange struct {
+	// Column Column name in the new schema (the old name for removed columns).
+	Column string `json:"column"`
+
+	// Kind renamed is a guess (a column removed and another of the same type added at the same position).
+	Kind SchemaChangeKind `json:"kind"`
+
+	// PreviousColumn Old column name; set for renamed columns only.
+	PreviousColumn *string `json:"previous_column,omitempty"`
+	PreviousType   *string `json:"previous_type,omitempty"`
+	Type           *string `json:"type,omitempty"`
+}
+
+// SchemaChangeKind renamed is a guess (a column removed and another of the same type added at the same position).
+type SchemaChangeKind string
+
+// SchemaDriftAction defines model for SchemaDriftAction.
+type SchemaDriftAction string
+
+// SchemaDriftPolicy What an import does when the output dataset already exists with a
+// different schema. accept applies every change, additive_only applies
+// added columns and widened types only, fail rejects any change. A
+// rejected change fails the run and leaves the dataset untouched.
+type SchemaDriftPolicy string
+
+// SchemaDriftReport Schema drift an import run detected against the previous version of its dataset.
+type SchemaDriftReport struct {
+	Action    SchemaDriftAction `json:"action"`
+	Changes   []SchemaChange    `json:"changes"`
+	DatasetId string            `json:"dataset_id"`
+
+	// Policy What an import does when the output dataset already exists with a
+	// different schema. accept applies every change, additive_only applies
+	// added columns and widened types only, fail rejects any change. A
+	// rejected change fails the run and leaves the dataset untouched.
+	Policy SchemaDriftPolicy `json:"policy"`
+}
+
+// SchemaItem defines model for SchemaItem.
 type SchemaItem struct {
 	Columns *[]SchemaColumn `json:"columns,omitempty"`
 
@@ -872,6 +1703,16 @@ type SchemaItem struct {
 // SchemaItemType defines model for SchemaItem.Type.
 type SchemaItemType string
 
+// SetDatasetStewardRequest defines model for SetDatasetStewardRequest.
+type SetDatasetStewardRequest struct {
+	Role DatasetStewardRole `json:"role"`
+}
+
+// SetMaskingPolicyRequest defines model for SetMaskingPolicyRequest.
+type SetMaskingPolicyRequest struct {
+	Policy MaskingPolicy `json:"policy"`
+}
+
 // TemplateRun defines model for TemplateRun.
 type TemplateRun struct {
 	CreatedAt    time.Time         `json:"created_at"`
@@ -933,7 +1774,10 @@ type TenantRole string
 
 // TestConnectionRequest defines model for TestConnectionRequest.
 type TestConnectionRequest struct {
-	ConfigJson   string  `json:"config_json"`
+	ConfigJson string `json:"config_json"`
+
+	// ConnectionId Stored connection whose secret values fill in x-secret fields that are omitted or redacted in config_json, as long as every other field matches the stored config.
+	ConnectionId *string `json:"connection_id,omitempty"`
 	CredentialId *string `json:"credential_id,omitempty"`
 	Type         string  `json:"type"`
 }
@@ -947,11 +1791,35 @@ type TestConnectionResponse struct {
 // TransformExecution defines model for TransformExecution.
 type TransformExecution string
 
+// TransformOutput The dataset every run writes, created by the first run; each run records a new version of it. Without an output, every run writes a new transform_<run> dataset. After the first run, the result must fit the dataset's schema except when overwriting.
+type TransformOutput struct {
+	// DatasetName Letters, digits and underscores, not starting with a digit.
+	DatasetName string `json:"dataset_name"`
+
+	// KeyColumns Columns identifying a row; required for merge.
+	KeyColumns *[]string `json:"key_columns,omitempty"`
+
+	// Mode How a run's rows are written into the output dataset. overwrite replaces the rows, append adds them as a new Parquet part, merge replaces rows with matching key_columns and adds the rest, replace_partitions replaces the rows whose partition_column value the run produced.
+	Mode TransformOutputMode `json:"mode"`
+
+	// PartitionColumn Column whose values are replaced; required for replace_partitions.
+	PartitionColumn *string `json:"partition_column,omitempty"`
+}
+
+// TransformOutputMode How a run's rows are written into the output dataset. overwrite replaces the rows, append adds them as a new Parquet part, merge replaces rows with matching key_columns and adds the rest, replace_partitions replaces the rows whose partition_column value the run produced.
+type TransformOutputMode string
+
 // TransformPreviewRequest defines model for TransformPreviewRequest.
 type TransformPreviewRequest struct {
-	DatasetIds []string `json:"dataset_ids"`
-	Limit      *int     `json:"limit,omitempty"`
-	Sql        string   `json:"sql"`
+	// AsOf Reads every input not in dataset_versions at its latest version
+	// created at or before this time.
+	AsOf       *time.Time `json:"as_of,omitempty"`
+	DatasetIds []string   `json:"dataset_ids"`
+
+	// DatasetVersions Pins input datasets to a version, keyed by dataset ID.
+	DatasetVersions *map[string]int `json:"dataset_versions,omitempty"`
+	Limit           *int            `json:"limit,omitempty"`
+	Sql             string          `json:"sql"`
 }
 
 // TransformPreviewResponse defines model for TransformPreviewResponse.
@@ -963,8 +1831,14 @@ type TransformPreviewResponse struct {
 
 // TransformValidateRequest defines model for TransformValidateRequest.
 type TransformValidateRequest struct {
-	DatasetIds []string `json:"dataset_ids"`
-	Sql        string   `json:"sql"`
+	// AsOf Reads every input not in dataset_versions at its latest version
+	// created at or before this time.
+	AsOf       *time.Time `json:"as_of,omitempty"`
+	DatasetIds []string   `json:"dataset_ids"`
+
+	// DatasetVersions Pins input datasets to a version, keyed by dataset ID.
+	DatasetVersions *map[string]int `json:"dataset_versions,omitempty"`
+	Sql             string          `json:"sql"`
 }
 
 // TransformValidateResponse defines model for TransformValidateResponse.
@@ -999,6 +1873,17 @@ type UpdateDashboardRequest struct {
 	Name        string  `json:"name"`
 }
 
+// UpdateDataQualityRuleRequest defines model for UpdateDataQualityRuleRequest.
+type UpdateDataQualityRuleRequest struct {
+	Config  DataQualityRuleConfig `json:"config"`
+	Enabled *bool                 `json:"enabled,omitempty"`
+	Name    string                `json:"name"`
+
+	// Severity warn records a failure; fail also rolls the dataset back and fails the run
+	Severity *DataQualitySeverity `json:"severity,omitempty"`
+	Type     DataQualityRuleType  `json:"type"`
+}
+
 // UpdateDatasetColumnRequest defines model for UpdateDatasetColumnRequest.
 type UpdateDatasetColumnRequest struct {
 	Description  *string                    `json:"description,omitempty"`
@@ -1012,12 +1897,23 @@ type UpdateDatasetColumnsRequest struct {
 	Columns []UpdateDatasetColumnRequest `json:"columns"`
 }
 
+// UpdateDatasetVersionRequest defines model for UpdateDatasetVersionRequest.
+type UpdateDatasetVersionRequest struct {
+	Pinned bool `json:"pinned"`
+}
+
 // UpdateJobRequest defines model for UpdateJobRequest.
 type UpdateJobRequest struct {
 	Description *string `json:"description,omitempty"`
 	IsActive    bool    `json:"is_active"`
 	Name        string  `json:"name"`
-	Slug        string  `json:"slug"`
+
+	// SchemaDriftPolicy What an import does when the output dataset already exists with a
+	// different schema. accept applies every change, additive_only applies
+	// added columns and widened types only, fail rejects any change. A
+	// rejected change fails the run and leaves the dataset untouched.
+	SchemaDriftPolicy *SchemaDriftPolicy `json:"schema_drift_policy,omitempty"`
+	Slug              string             `json:"slug"`
 }
 
 // UpdateMemberRoleRequest defines model for UpdateMemberRoleRequest.
@@ -1029,6 +1925,8 @@ type UpdateMemberRoleRequest struct {
 type UpdatePlanRequest struct {
 	DisplayName      *string `json:"display_name,omitempty"`
 	MaxEventsPerDay  *int    `json:"max_events_per_day,omitempty"`
+	MaxFileSizeBytes *int64  `json:"max_file_size_bytes,omitempty"`
+	MaxRetentionDays *int    `json:"max_retention_days,omitempty"`
 	MaxRowsPerDay    *int    `json:"max_rows_per_day,omitempty"`
 	MaxStorageBytes  *int64  `json:"max_storage_bytes,omitempty"`
 	MaxUploadsPerDay *int    `json:"max_uploads_per_day,omitempty"`
@@ -1048,18 +1946,57 @@ type Upload struct {
 type UploadFile struct {
 	ContentType string     `json:"content_type"`
 	CreatedAt   *time.Time `json:"created_at,omitempty"`
-	FileName    string     `json:"file_name"`
-	Id          string     `json:"id"`
-	ObjectKey   string     `json:"object_key"`
-	SizeBytes   int64      `json:"size_bytes"`
-	UploadId    string     `json:"upload_id"`
+
+	// DatasetId The dataset written from the file (the first one for zip archives and workbooks).
+	DatasetId *string `json:"dataset_id,omitempty"`
+
+	// Datasets Every dataset written from the file.
+	Datasets *[]UploadFileDataset `json:"datasets,omitempty"`
+
+	// ErrorMessage Why the file failed or was skipped.
+	ErrorMessage *string    `json:"error_message,omitempty"`
+	FileName     string     `json:"file_name"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	Id           string     `json:"id"`
+	ObjectKey    string     `json:"object_key"`
+
+	// ParseOptions Options for reading delimited text files (.csv, .tsv, .txt, also inside .gz and .zip). Unset options are sniffed from the file.
+	ParseOptions *UploadParseOptions `json:"parse_options,omitempty"`
+
+	// RowCount Rows written across all datasets of the file.
+	RowCount  *int64 `json:"row_count,omitempty"`
+	SizeBytes int64  `json:"size_bytes"`
+
+	// SourceUrl URL the file was imported from.
+	SourceUrl *string    `json:"source_url,omitempty"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+
+	// Status Processing state of an uploaded file. Files are pending until the upload is completed.
+	Status UploadFileStatus `json:"status"`
+
+	// Target An existing dataset to write the file into instead of the dataset named after the file. The file must fit the dataset's schema.
+	Target   *UploadTarget `json:"target,omitempty"`
+	UploadId string        `json:"upload_id"`
+}
+
+// UploadFileDataset defines model for UploadFileDataset.
+type UploadFileDataset struct {
+	DatasetId   string `json:"dataset_id"`
+	DatasetName string `json:"dataset_name"`
+	RowCount    int64  `json:"row_count"`
 }
 
 // UploadFileInput defines model for UploadFileInput.
 type UploadFileInput struct {
 	ContentType string `json:"content_type"`
 	Filename    string `json:"filename"`
-	SizeBytes   int64  `json:"size_bytes"`
+
+	// ParseOptions Options for reading delimited text files (.csv, .tsv, .txt, also inside .gz and .zip). Unset options are sniffed from the file.
+	ParseOptions *UploadParseOptions `json:"parse_options,omitempty"`
+	SizeBytes    int64               `json:"size_bytes"`
+
+	// Target An existing dataset to write the file into instead of the dataset named after the file. The file must fit the dataset's schema.
+	Target *UploadTarget `json:"target,omitempty"`
 }
 
 // UploadFilePresigned defines model for UploadFilePresigned.
@@ -1071,9 +2008,94 @@ type UploadFilePresigned struct {
 	PresignedUrl string     `json:"presigned_url"`
 }
 
+// UploadFilePreviewRequest defines model for UploadFilePreviewRequest.
+type UploadFilePreviewRequest struct {
+	Limit        *int                `json:"limit,omitempty"`
+	ParseOptions *UploadParseOptions `json:"parse_options,omitempty"`
+}
+
+// UploadFilePreviewResponse defines model for UploadFilePreviewResponse.
+type UploadFilePreviewResponse struct {
+	Tables []UploadFilePreviewTable `json:"tables"`
+}
+
+// UploadFilePreviewTable defines model for UploadFilePreviewTable.
+type UploadFilePreviewTable struct {
+	Columns     []DatasetColumn          `json:"columns"`
+	DatasetName string                   `json:"dataset_name"`
+	Rows        []map[string]interface{} `json:"rows"`
+}
+
+// UploadFileStatus Processing state of an uploaded file. Files are pending until the upload is completed.
+type UploadFileStatus string
+
+// UploadParseOptions Options for reading delimited text files (.csv, .tsv, .txt, also inside .gz and .zip). Unset options are sniffed from the file.
+type UploadParseOptions struct {
+	// ColumnTypes Column types by column name, e.g. {"zip_code": "VARCHAR"} to keep leading zeros.
+	ColumnTypes *map[string]string `json:"column_types,omitempty"`
+
+	// DateFormat strptime format for DATE columns, e.g. %d/%m/%Y.
+	DateFormat *string `json:"date_format,omitempty"`
+
+	// Delimiter Single-character column separator; \t is a tab.
+	Delimiter *string `json:"delimiter,omitempty"`
+
+	// Encoding Character encoding, e.g. shift_jis, euc-jp or windows-1252. Defaults to utf-8.
+	Encoding *string `json:"encoding,omitempty"`
+
+	// Header Whether the first row (after skip_rows) holds column names.
+	Header *bool `json:"header,omitempty"`
+
+	// NullStrings Values read as NULL.
+	NullStrings *[]string `json:"null_strings,omitempty"`
+
+	// Quote Single-character quote.
+	Quote *string `json:"quote,omitempty"`
+
+	// SkipRows Lines to skip before the header or data.
+	SkipRows *int `json:"skip_rows,omitempty"`
+}
+
 // UploadStatus defines model for UploadStatus.
 type UploadStatus string
 
+// UploadTarget An existing dataset to write the file into instead of the dataset named after the file. The file must fit the dataset's schema.
+type UploadTarget struct {
+	DatasetId string `json:"dataset_id"`
+
+	// KeyColumns Columns identifying a row; required for upsert.
+	KeyColumns *[]string `json:"key_columns,omitempty"`
+
+	// Mode How a file is written into its target dataset. replace overwrites the rows, append adds them as a new Parquet part, upsert replaces rows with matching key_columns and adds the rest.
+	Mode UploadWriteMode `json:"mode"`
+}
+
+// UploadWriteMode How a file is written into its target dataset. replace overwrites the rows, append adds them as a new Parquet part, upsert replaces rows with matching key_columns and adds the rest.
+type UploadWriteMode string
+
+// UploadedPart defines model for UploadedPart.
+type UploadedPart struct {
+	Etag       string `json:"etag"`
+	PartNumber int    `json:"part_number"`
+	SizeBytes  int64  `json:"size_bytes"`
+}
+
+// UrlUploadAuth defines model for UrlUploadAuth.
+type UrlUploadAuth struct {
+	// Password Basic auth only.
+	Password *string `json:"password,omitempty"`
+
+	// Token Bearer auth only.
+	Token *string           `json:"token,omitempty"`
+	Type  UrlUploadAuthType `json:"type"`
+
+	// Username Basic auth only.
+	Username *string `json:"username,omitempty"`
+}
+
+// UrlUploadAuthType defines model for UrlUploadAuthType.
+type UrlUploadAuthType string
+
 // UsageSummaryResponse defines model for UsageSummaryResponse.
 type UsageSummaryResponse struct {
 	Date         string `json:"date"`
@@ -1208,6 +2230,12 @@ type UpdateConnectionParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
 }
 
+// ListConnectionHealthParams defines parameters for ListConnectionHealth.
+type ListConnectionHealthParams struct {
+	Limit     *int      `form:"limit,omitempty" json:"limit,omitempty"`
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
 // ListConnectorsParams defines parameters for ListConnectors.
 type ListConnectorsParams struct {
 	Kind      *ConnectorKind `form:"kind,omitempty" json:"kind,omitempty"`
@@ -1224,6 +2252,11 @@ type ListCredentialsParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
 }
 
+// ListCredentialProvidersParams defines parameters for ListCredentialProviders.
+type ListCredentialProvidersParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
 // DeleteCredentialParams defines parameters for DeleteCredential.
 type DeleteCredentialParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
@@ -1282,11 +2315,24 @@ type UpdateDashboardParams struct {
 
 // ListDatasetsParams defines parameters for ListDatasets.
 type ListDatasetsParams struct {
-	Q          *string            `form:"q,omitempty" json:"q,omitempty"`
-	SourceType *DatasetSourceType `form:"source_type,omitempty" json:"source_type,omitempty"`
-	Limit      *int               `form:"limit,omitempty" json:"limit,omitempty"`
-	Offset     *int               `form:"offset,omitempty" json:"offset,omitempty"`
-	XTenantID  XTenantID          `json:"X-Tenant-ID"`
+	Q             *string                     `form:"q,omitempty" json:"q,omitempty"`
+	SourceType    *DatasetSourceType          `form:"source_type,omitempty" json:"source_type,omitempty"`
+	Certification *DatasetCertificationStatus `form:"certification,omitempty" json:"certification,omitempty"`
+
+	// StewardId Only datasets this user owns or stewards.
+	StewardId *string `form:"steward_id,omitempty" json:"steward_id,omitempty"`
+
+	// Deleted List soft-deleted datasets that can still be restored instead of live ones.
+	Deleted   *bool     `form:"deleted,omitempty" json:"deleted,omitempty"`
+	Limit     *int      `form:"limit,omitempty" json:"limit,omitempty"`
+	Offset    *int      `form:"offset,omitempty" json:"offset,omitempty"`
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// DeleteDatasetParams defines parameters for DeleteDataset.
+type DeleteDatasetParams struct {
+	Cascade   *bool     `form:"cascade,omitempty" json:"cascade,omitempty"`
+	XTenantID XTenantID `json:"X-Tenant-ID"`
 }
 
 // GetDatasetParams defines parameters for GetDataset.
@@ -1294,15 +2340,211 @@ type GetDatasetParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
 }
 
+// DeleteDatasetCertificationParams defines parameters for DeleteDatasetCertification.
+type DeleteDatasetCertificationParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// CertifyDatasetParams defines parameters for CertifyDataset.
+type CertifyDatasetParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
 // UpdateDatasetColumnsParams defines parameters for UpdateDatasetColumns.
 type UpdateDatasetColumnsParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
 }
 
+// UnlinkGlossaryTermParams defines parameters for UnlinkGlossaryTerm.
+type UnlinkGlossaryTermParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// LinkGlossaryTermParams defines parameters for LinkGlossaryTerm.
+type LinkGlossaryTermParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// ExportDatasetParams defines parameters for ExportDataset.
+type ExportDatasetParams struct {
+	Format *DatasetExportFormat `form:"format,omitempty" json:"format,omitempty"`
+
+	// Columns Comma-separated columns to export; all columns by default.
+	Columns *string `form:"columns,omitempty" json:"columns,omitempty"`
+
+	// Filter column:op:value, or column:op for is_null and not_null. Repeated
+	// filters are combined with AND.
+	Filter *[]string `form:"filter,omitempty" json:"filter,omitempty"`
+
+	// Sort Comma-separated columns to order by, each prefixed with - for
+	// descending order. NULLs sort last.
+	Sort *string `form:"sort,omitempty" json:"sort,omitempty"`
+
+	// Version Export this version of the dataset instead of the current data.
+	Version *int `form:"version,omitempty" json:"version,omitempty"`
+
+	// AsOf Export the latest version created at or before this time. Mutually
+	// exclusive with version.
+	AsOf      *time.Time `form:"as_of,omitempty" json:"as_of,omitempty"`
+	XTenantID XTenantID  `json:"X-Tenant-ID"`
+}
+
+// CreateDatasetExportParams defines parameters for CreateDatasetExport.
+type CreateDatasetExportParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// GetDatasetExportParams defines parameters for GetDatasetExport.
+type GetDatasetExportParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// GetDatasetHealthParams defines parameters for GetDatasetHealth.
+type GetDatasetHealthParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// GetDatasetLineageParams defines parameters for GetDatasetLineage.
+type GetDatasetLineageParams struct {
+	Direction *LineageDirection `form:"direction,omitempty" json:"direction,omitempty"`
+
+	// Depth Maximum number of edges between the dataset and a returned node.
+	Depth     *int      `form:"depth,omitempty" json:"depth,omitempty"`
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// ListDatasetMaskingPoliciesParams defines parameters for ListDatasetMaskingPolicies.
+type ListDatasetMaskingPoliciesParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// DeleteDatasetMaskingPolicyParams defines parameters for DeleteDatasetMaskingPolicy.
+type DeleteDatasetMaskingPolicyParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// SetDatasetMaskingPolicyParams defines parameters for SetDatasetMaskingPolicy.
+type SetDatasetMaskingPolicyParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// CheckDataQualityParams defines parameters for CheckDataQuality.
+type CheckDataQualityParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// ListDataQualityResultsParams defines parameters for ListDataQualityResults.
+type ListDataQualityResultsParams struct {
+	// JobRunId Only results of the checks after this job run
+	JobRunId  *string   `form:"job_run_id,omitempty" json:"job_run_id,omitempty"`
+	Limit     *int      `form:"limit,omitempty" json:"limit,omitempty"`
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// ListDataQualityRulesParams defines parameters for ListDataQualityRules.
+type ListDataQualityRulesParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// CreateDataQualityRuleParams defines parameters for CreateDataQualityRule.
+type CreateDataQualityRuleParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// DeleteDataQualityRuleParams defines parameters for DeleteDataQualityRule.
+type DeleteDataQualityRuleParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// UpdateDataQualityRuleParams defines parameters for UpdateDataQualityRule.
+type UpdateDataQualityRuleParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// RestoreDatasetParams defines parameters for RestoreDataset.
+type RestoreDatasetParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// DeleteDatasetRetentionPolicyParams defines parameters for DeleteDatasetRetentionPolicy.
+type DeleteDatasetRetentionPolicyParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// GetDatasetRetentionPolicyParams defines parameters for GetDatasetRetentionPolicy.
+type GetDatasetRetentionPolicyParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// SetDatasetRetentionPolicyParams defines parameters for SetDatasetRetentionPolicy.
+type SetDatasetRetentionPolicyParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
 // GetDatasetRowsParams defines parameters for GetDatasetRows.
 type GetDatasetRowsParams struct {
+	// AsOf Read the latest version created at or before this time. Mutually
+	// exclusive with version.
+	AsOf *time.Time `form:"as_of,omitempty" json:"as_of,omitempty"`
+
+	// Columns Comma-separated columns to return; all columns by default.
+	Columns *string `form:"columns,omitempty" json:"columns,omitempty"`
+
+	// Filter column:op:value, or column:op for is_null and not_null. Repeated
+	// filters are combined with AND.
+	Filter *[]string `form:"filter,omitempty" json:"filter,omitempty"`
+	Limit  *int      `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Offset Rows to skip, for clients that predate page_token. Mutually
+	// exclusive with page_token.
+	Offset *int `form:"offset,omitempty" json:"offset,omitempty"`
+
+	// PageToken next_page_token of the previous page.
+	PageToken *string `form:"page_token,omitempty" json:"page_token,omitempty"`
+
+	// Sort Comma-separated columns to order by, each prefixed with - for
+	// descending order. NULLs sort last.
+	Sort *string `form:"sort,omitempty" json:"sort,omitempty"`
+
+	// Version Read this version of the dataset instead of the current data.
+	Version   *int      `form:"version,omitempty" json:"version,omitempty"`
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// ListDatasetSchemaChangesParams defines parameters for ListDatasetSchemaChanges.
+type ListDatasetSchemaChangesParams struct {
 	Limit     *int      `form:"limit,omitempty" json:"limit,omitempty"`
-	Offset    *int      `form:"offset,omitempty" json:"offset,omitempty"`
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// DeleteDatasetStewardParams defines parameters for DeleteDatasetSteward.
+type DeleteDatasetStewardParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// SetDatasetStewardParams defines parameters for SetDatasetSteward.
+type SetDatasetStewardParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// ListDatasetVersionsParams defines parameters for ListDatasetVersions.
+type ListDatasetVersionsParams struct {
+	Limit     *int      `form:"limit,omitempty" json:"limit,omitempty"`
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// GetDatasetVersionParams defines parameters for GetDatasetVersion.
+type GetDatasetVersionParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// UpdateDatasetVersionParams defines parameters for UpdateDatasetVersion.
+type UpdateDatasetVersionParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// RollbackDatasetVersionParams defines parameters for RollbackDatasetVersion.
+type RollbackDatasetVersionParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
 }
 
@@ -1316,6 +2558,31 @@ type GetEventsSummaryParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
 }
 
+// ListGlossaryTermsParams defines parameters for ListGlossaryTerms.
+type ListGlossaryTermsParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// CreateGlossaryTermParams defines parameters for CreateGlossaryTerm.
+type CreateGlossaryTermParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// DeleteGlossaryTermParams defines parameters for DeleteGlossaryTerm.
+type DeleteGlossaryTermParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// GetGlossaryTermParams defines parameters for GetGlossaryTerm.
+type GetGlossaryTermParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// UpdateGlossaryTermParams defines parameters for UpdateGlossaryTerm.
+type UpdateGlossaryTermParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
 // CreateImportJobParams defines parameters for CreateImportJob.
 type CreateImportJobParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
@@ -1427,6 +2694,28 @@ type GetTenantPlanParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
 }
 
+// DeleteTenantRetentionPolicyParams defines parameters for DeleteTenantRetentionPolicy.
+type DeleteTenantRetentionPolicyParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// GetTenantRetentionPolicyParams defines parameters for GetTenantRetentionPolicy.
+type GetTenantRetentionPolicyParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// SetTenantRetentionPolicyParams defines parameters for SetTenantRetentionPolicy.
+type SetTenantRetentionPolicyParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// ListRetentionPurgesParams defines parameters for ListRetentionPurges.
+type ListRetentionPurgesParams struct {
+	DatasetId *string   `form:"dataset_id,omitempty" json:"dataset_id,omitempty"`
+	Limit     *int      `form:"limit,omitempty" json:"limit,omitempty"`
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
 // ListTemplateRunsParams defines parameters for ListTemplateRuns.
 type ListTemplateRunsParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
@@ -1477,16 +2766,68 @@ type TransformValidateParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
 }
 
+// ListUploadsParams defines parameters for ListUploads.
+type ListUploadsParams struct {
+	Limit     *int      `form:"limit,omitempty" json:"limit,omitempty"`
+	Offset    *int      `form:"offset,omitempty" json:"offset,omitempty"`
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// CreateMultipartUploadParams defines parameters for CreateMultipartUpload.
+type CreateMultipartUploadParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
 // CreateUploadPresignParams defines parameters for CreateUploadPresign.
 type CreateUploadPresignParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
 }
 
+// CreateUrlUploadParams defines parameters for CreateUrlUpload.
+type CreateUrlUploadParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// GetUploadParams defines parameters for GetUpload.
+type GetUploadParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
 // CompleteUploadParams defines parameters for CompleteUpload.
 type CompleteUploadParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
 }
 
+// AbortMultipartUploadParams defines parameters for AbortMultipartUpload.
+type AbortMultipartUploadParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// GetMultipartUploadParams defines parameters for GetMultipartUpload.
+type GetMultipartUploadParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// CompleteMultipartUploadParams defines parameters for CompleteMultipartUpload.
+type CompleteMultipartUploadParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// PresignMultipartUploadPartsParams defines parameters for PresignMultipartUploadParts.
+type PresignMultipartUploadPartsParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// PreviewUploadFileParams defines parameters for PreviewUploadFile.
+type PreviewUploadFileParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
+// ReimportUploadFileParams defines parameters for ReimportUploadFile.
+type ReimportUploadFileParams struct {
+	XTenantID XTenantID `json:"X-Tenant-ID"`
+}
+
 // GetUsageSummaryParams defines parameters for GetUsageSummary.
 type GetUsageSummaryParams struct {
 	XTenantID XTenantID `json:"X-Tenant-ID"`
@@ -1572,12 +2913,42 @@ type CreateDashboardWidgetJSONRequestBody = CreateDashboardWidgetRequest
 // UpdateDashboardJSONRequestBody defines body for UpdateDashboard for application/json ContentType.
 type UpdateDashboardJSONRequestBody = UpdateDashboardRequest
 
+// CertifyDatasetJSONRequestBody defines body for CertifyDataset for application/json ContentType.
+type CertifyDatasetJSONRequestBody = CertifyDatasetRequest
+
 // UpdateDatasetColumnsJSONRequestBody defines body for UpdateDatasetColumns for application/json ContentType.
 type UpdateDatasetColumnsJSONRequestBody = UpdateDatasetColumnsRequest
 
+// CreateDatasetExportJSONRequestBody defines body for CreateDatasetExport for application/json ContentType.
+type CreateDatasetExportJSONRequestBody = CreateDatasetExportRequest
+
+// SetDatasetMaskingPolicyJSONRequestBody defines body for SetDatasetMaskingPolicy for application/json ContentType.
+type SetDatasetMaskingPolicyJSONRequestBody = SetMaskingPolicyRequest
+
+// CreateDataQualityRuleJSONRequestBody defines body for CreateDataQualityRule for application/json ContentType.
+type CreateDataQualityRuleJSONRequestBody = CreateDataQualityRuleRequest
+
+// UpdateDataQualityRuleJSONRequestBody defines body for UpdateDataQualityRule for application/json ContentType.
+type UpdateDataQualityRuleJSONRequestBody = UpdateDataQualityRuleRequest
+
+// SetDatasetRetentionPolicyJSONRequestBody defines body for SetDatasetRetentionPolicy for application/json ContentType.
+type SetDatasetRetentionPolicyJSONRequestBody = RetentionPolicyRequest
+
+// SetDatasetStewardJSONRequestBody defines body for SetDatasetSteward for application/json ContentType.
+type SetDatasetStewardJSONRequestBody = SetDatasetStewardRequest
+
+// UpdateDatasetVersionJSONRequestBody defines body for UpdateDatasetVersion for application/json ContentType.
+type UpdateDatasetVersionJSONRequestBody = UpdateDatasetVersionRequest
+
 // IngestEventJSONRequestBody defines body for IngestEvent for application/json ContentType.
 type IngestEventJSONRequestBody = IngestEventRequest
 
+// CreateGlossaryTermJSONRequestBody defines body for CreateGlossaryTerm for application/json ContentType.
+type CreateGlossaryTermJSONRequestBody = GlossaryTermRequest
+
+// UpdateGlossaryTermJSONRequestBody defines body for UpdateGlossaryTerm for application/json ContentType.
+type UpdateGlossaryTermJSONRequestBody = GlossaryTermRequest
+
 // CreateImportJobJSONRequestBody defines body for CreateImportJob for application/json ContentType.
 type CreateImportJobJSONRequestBody = CreateImportJobRequest
 
@@ -1599,6 +2970,9 @@ type CreateModuleTypeJSONRequestBody = CreateModuleTypeRequest
 // CreateModuleTypeSchemaJSONRequestBody defines body for CreateModuleTypeSchema for application/json ContentType.
 type CreateModuleTypeSchemaJSONRequestBody = CreateModuleTypeSchemaRequest
 
+// SetTenantRetentionPolicyJSONRequestBody defines body for SetTenantRetentionPolicy for application/json ContentType.
+type SetTenantRetentionPolicyJSONRequestBody = RetentionPolicyRequest
+
 // CreateTemplateRunJSONRequestBody defines body for CreateTemplateRun for application/json ContentType.
 type CreateTemplateRunJSONRequestBody = CreateTemplateRunRequest
 
@@ -1617,8 +2991,26 @@ type TransformPreviewJSONRequestBody = TransformPreviewRequest
 // TransformValidateJSONRequestBody defines body for TransformValidate for application/json ContentType.
 type TransformValidateJSONRequestBody = TransformValidateRequest
 
+// CreateMultipartUploadJSONRequestBody defines body for CreateMultipartUpload for application/json ContentType.
+type CreateMultipartUploadJSONRequestBody = CreateMultipartUploadRequest
+
 // CreateUploadPresignJSONRequestBody defines body for CreateUploadPresign for application/json ContentType.
 type CreateUploadPresignJSONRequestBody = CreateUploadPresignRequest
 
+// CreateUrlUploadJSONRequestBody defines body for CreateUrlUpload for application/json ContentType.
+type CreateUrlUploadJSONRequestBody = CreateUrlUploadRequest
+
+// CompleteUploadJSONRequestBody defines body for CompleteUpload for application/json ContentType.
+type CompleteUploadJSONRequestBody = CompleteUploadRequest
+
+// PresignMultipartUploadPartsJSONRequestBody defines body for PresignMultipartUploadParts for application/json ContentType.
+type PresignMultipartUploadPartsJSONRequestBody = PresignUploadPartsRequest
+
+// PreviewUploadFileJSONRequestBody defines body for PreviewUploadFile for application/json ContentType.
+type PreviewUploadFileJSONRequestBody = UploadFilePreviewRequest
+
+// ReimportUploadFileJSONRequestBody defines body for ReimportUploadFile for application/json ContentType.
+type ReimportUploadFileJSONRequestBody = ReimportUploadFileRequest
+
 // CreateWriteKeyJSONRequestBody defines body for CreateWriteKey for application/json ContentType.
 type CreateWriteKeyJSONRequestBody = CreateWriteKeyRequest
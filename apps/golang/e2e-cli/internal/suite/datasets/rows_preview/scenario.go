@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/user/micro-dp/e2e-cli/internal/httpclient"
@@ -162,9 +163,9 @@ func (s *Scenario) Run(ctx context.Context, client *httpclient.Client) error {
 		return fmt.Errorf("get rows: total_rows is 0")
 	}
 
-	// 8. GET /api/v1/datasets/{id}/rows?limit=1&offset=0 -> 200
+	// 8. GET /api/v1/datasets/{id}/rows?limit=1 -> 200
 	var paginatedResp openapi.DatasetRowsResponse
-	code, body, err = client.GetJSON(ctx, "/api/v1/datasets/"+datasetID+"/rows?limit=1&offset=0", &paginatedResp)
+	code, body, err = client.GetJSON(ctx, "/api/v1/datasets/"+datasetID+"/rows?limit=1", &paginatedResp)
 	if err != nil {
 		return err
 	}
@@ -174,6 +175,22 @@ func (s *Scenario) Run(ctx context.Context, client *httpclient.Client) error {
 	if len(paginatedResp.Rows) != 1 {
 		return fmt.Errorf("get rows paginated: expected 1 row, got=%d", len(paginatedResp.Rows))
 	}
+	if rowsResp.TotalRows > 1 {
+		if paginatedResp.NextPageToken == nil {
+			return fmt.Errorf("get rows paginated: next_page_token missing with total_rows=%d", rowsResp.TotalRows)
+		}
+		var nextResp openapi.DatasetRowsResponse
+		code, body, err = client.GetJSON(ctx, "/api/v1/datasets/"+datasetID+"/rows?limit=1&page_token="+url.QueryEscape(*paginatedResp.NextPageToken), &nextResp)
+		if err != nil {
+			return err
+		}
+		if code != 200 {
+			return fmt.Errorf("get rows next page: status=%d body=%s", code, string(body))
+		}
+		if len(nextResp.Rows) != 1 {
+			return fmt.Errorf("get rows next page: expected 1 row, got=%d", len(nextResp.Rows))
+		}
+	}
 
 	// 9. GET /api/v1/datasets/nonexistent/rows -> 404
 	code, body, err = client.GetJSON(ctx, "/api/v1/datasets/nonexistent/rows", nil)